@@ -0,0 +1,557 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/config"
+	"github.com/alfagnish/ollqd-gateway/internal/handlers"
+	authmw "github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/plugin"
+	"github.com/alfagnish/ollqd-gateway/internal/proxy"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+// ── check-config ──────────────────────────────────────────
+
+func runCheckConfig(args []string) error {
+	flags := flag.NewFlagSet("check-config", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	var problems, warnings []string
+	fail := func(format string, a ...interface{}) { problems = append(problems, fmt.Sprintf(format, a...)) }
+	warn := func(format string, a ...interface{}) { warnings = append(warnings, fmt.Sprintf(format, a...)) }
+
+	if _, _, err := net.SplitHostPort(cfg.ListenAddr); err != nil {
+		fail("LISTEN_ADDR %q: %v", cfg.ListenAddr, err)
+	}
+	if _, _, err := net.SplitHostPort(cfg.WorkerAddr); err != nil {
+		fail("WORKER_ADDR %q: %v", cfg.WorkerAddr, err)
+	}
+	if cfg.GRPCListenAddr != "" {
+		if _, _, err := net.SplitHostPort(cfg.GRPCListenAddr); err != nil {
+			fail("GRPC_LISTEN_ADDR %q: %v", cfg.GRPCListenAddr, err)
+		}
+		if cfg.GRPCAuthToken == "" {
+			warn("GRPC_LISTEN_ADDR is set without GRPC_AUTH_TOKEN; the task API is unauthenticated")
+		}
+	}
+	for _, u := range []struct{ key, val string }{
+		{"OLLAMA_URL", cfg.OllamaURL},
+		{"QDRANT_URL", cfg.QdrantURL},
+	} {
+		if err := checkHTTPURL(u.val); err != nil {
+			fail("%s %q: %v", u.key, u.val, err)
+		}
+	}
+	if _, err := authmw.ParseTrustedProxies(cfg.TrustedProxies); err != nil {
+		fail("TRUSTED_PROXIES: %v", err)
+	}
+	if cfg.BasePath != "" && !strings.HasPrefix(cfg.BasePath, "/") {
+		fail("BASE_PATH %q must start with /", cfg.BasePath)
+	}
+	if cfg.MaxUploadSizeMB <= 0 {
+		fail("MAX_UPLOAD_SIZE_MB must be positive, got %d", cfg.MaxUploadSizeMB)
+	}
+	if cfg.UploadFilenames != config.UploadNamesUUID && cfg.UploadFilenames != config.UploadNamesPreserve {
+		fail("UPLOAD_FILENAMES must be %q or %q, got %q", config.UploadNamesUUID, config.UploadNamesPreserve, cfg.UploadFilenames)
+	}
+	if cfg.ImageURLTTLMinutes <= 0 {
+		fail("IMAGE_URL_TTL_MINUTES must be positive, got %d", cfg.ImageURLTTLMinutes)
+	}
+	switch cfg.GuardMode {
+	case config.GuardRefuse, config.GuardWarn, config.GuardOff:
+	default:
+		fail("GUARD_MODE must be %q, %q or %q, got %q", config.GuardRefuse, config.GuardWarn, config.GuardOff, cfg.GuardMode)
+	}
+	if cfg.GuardMinFreeDiskMB < 0 {
+		fail("GUARD_MIN_FREE_DISK_MB must not be negative, got %d", cfg.GuardMinFreeDiskMB)
+	}
+	if cfg.GuardMaxMemoryPct < 0 || cfg.GuardMaxMemoryPct > 100 {
+		fail("GUARD_MAX_MEMORY_PERCENT must be between 0 and 100, got %d", cfg.GuardMaxMemoryPct)
+	}
+	if cfg.GuardQdrantDir != "" {
+		if info, err := os.Stat(cfg.GuardQdrantDir); err != nil || !info.IsDir() {
+			fail("GUARD_QDRANT_STORAGE_DIR %q is not a directory", cfg.GuardQdrantDir)
+		}
+	}
+	if cfg.ImportMaxMB <= 0 {
+		fail("COLLECTION_IMPORT_MAX_MB must be positive, got %d", cfg.ImportMaxMB)
+	}
+	if cfg.WorkerTimeout < 0 || cfg.WorkerTimeoutMax < 0 {
+		fail("WORKER_TIMEOUT_S and WORKER_TIMEOUT_MAX_S must not be negative")
+	} else if cfg.WorkerTimeoutMax > 0 && cfg.WorkerTimeout > cfg.WorkerTimeoutMax {
+		fail("WORKER_TIMEOUT_S (%d) exceeds WORKER_TIMEOUT_MAX_S (%d)", cfg.WorkerTimeout, cfg.WorkerTimeoutMax)
+	}
+	if _, err := tasks.ParseRetention(cfg.TaskParamsRetention); err != nil {
+		fail("TASK_PARAMS_RETENTION: %v", err)
+	}
+	if cfg.TaskStallMinutes < 0 {
+		fail("TASK_STALL_MINUTES must not be negative, got %d", cfg.TaskStallMinutes)
+	} else if cfg.TaskStallMinutes == 0 && cfg.TaskStallAutoCancel {
+		warn("TASK_STALL_AUTO_CANCEL has no effect while TASK_STALL_MINUTES=0")
+	}
+	if cfg.WorkerRestartRetries < 0 || cfg.WorkerRestartBackoff < 0 {
+		fail("WORKER_RESTART_RETRIES and WORKER_RESTART_BACKOFF_MS must not be negative")
+	}
+	if cfg.DrainDelay < 0 || cfg.ShutdownTimeout < 0 {
+		fail("DRAIN_DELAY_S and SHUTDOWN_TIMEOUT_S must not be negative")
+	}
+	if cfg.MaxConcurrentTasks < 0 {
+		fail("MAX_CONCURRENT_TASKS must not be negative, got %d", cfg.MaxConcurrentTasks)
+	}
+	if cfg.MaxConcurrentPulls < 0 {
+		fail("OLLAMA_PULL_CONCURRENCY must not be negative, got %d", cfg.MaxConcurrentPulls)
+	}
+	if cfg.PullRetries < 0 {
+		fail("OLLAMA_PULL_RETRIES must not be negative, got %d", cfg.PullRetries)
+	}
+	if err := handlers.CheckRegistryMirror(cfg.RegistryMirror); err != nil {
+		fail("OLLAMA_REGISTRY_MIRROR: %v", err)
+	} else if cfg.RegistryMirror == "" && (cfg.RegistryInsecure || cfg.RegistryCAFile != "") {
+		warn("OLLAMA_REGISTRY_INSECURE and OLLAMA_REGISTRY_CA_FILE have no effect without OLLAMA_REGISTRY_MIRROR")
+	}
+	if cfg.RegistryCAFile != "" {
+		if _, err := proxy.LoadCAFile(cfg.RegistryCAFile); err != nil {
+			fail("OLLAMA_REGISTRY_CA_FILE %q: %v", cfg.RegistryCAFile, err)
+		}
+	}
+	if cfg.QdrantCAFile != "" {
+		if _, err := proxy.LoadCAFile(cfg.QdrantCAFile); err != nil {
+			fail("QDRANT_CA_FILE %q: %v", cfg.QdrantCAFile, err)
+		}
+	}
+	if !strings.HasPrefix(cfg.QdrantURL, "https://") {
+		if cfg.QdrantAPIKey != "" {
+			warn("QDRANT_API_KEY is sent over plain HTTP; use an https QDRANT_URL")
+		}
+		if cfg.QdrantCAFile != "" || cfg.QdrantInsecure {
+			warn("QDRANT_CA_FILE and QDRANT_TLS_INSECURE have no effect with a plain HTTP QDRANT_URL")
+		}
+	}
+	registered := map[string]bool{}
+	for _, p := range plugin.List() {
+		registered[p.Name] = true
+	}
+	for _, name := range cfg.PluginsDisabled {
+		if !registered[name] {
+			warn("PLUGINS_DISABLED names %q, which is not compiled in", name)
+		}
+	}
+	authMode, err := authmw.ParseAuthMode(cfg.AuthMode)
+	if err != nil {
+		fail("AUTH_MODE: %v", err)
+	} else if authMode == authmw.AuthDisabled {
+		warn("AUTH_MODE=disabled; every request is treated as an admin")
+	}
+	for _, p := range cfg.AuthPublicPaths {
+		if !strings.HasPrefix(p, "/") {
+			fail("AUTH_PUBLIC_PATHS entry %q must start with /", p)
+		}
+	}
+	if os.Getenv("JWT_SECRET") == "" {
+		warn("JWT_SECRET is not set; a random secret is generated on every start and tokens do not survive restarts")
+	}
+	for _, d := range []struct{ key, val string }{
+		{"UPLOAD_DIR", cfg.UploadDir},
+		{"ARTIFACT_DIR", cfg.ArtifactDir},
+		{"DATA_DIR", cfg.DataDir},
+	} {
+		info, err := os.Stat(d.val)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			warn("%s %q does not exist yet; it will be created on first use", d.key, d.val)
+		case err != nil:
+			fail("%s %q: %v", d.key, d.val, err)
+		case !info.IsDir():
+			fail("%s %q is not a directory", d.key, d.val)
+		}
+	}
+
+	fmt.Printf("listen:        %s\n", cfg.ListenAddr)
+	fmt.Printf("worker:        %s\n", cfg.WorkerAddr)
+	fmt.Printf("ollama:        %s\n", cfg.OllamaURL)
+	fmt.Printf("qdrant:        %s\n", qdrantSummary(cfg))
+	fmt.Printf("grpc task api: %s\n", orNone(cfg.GRPCListenAddr))
+	fmt.Printf("base path:     %s\n", orNone(cfg.BasePath))
+	fmt.Printf("auth mode:     %s\n", authMode)
+	fmt.Printf("upload dir:    %s (max %d MB, %s names)\n", cfg.UploadDir, cfg.MaxUploadSizeMB, cfg.UploadFilenames)
+	fmt.Printf("artifact dir:  %s\n", cfg.ArtifactDir)
+	fmt.Printf("data dir:      %s\n", cfg.DataDir)
+	fmt.Printf("default coll.: %s\n", orNone(cfg.DefaultCollection))
+	fmt.Printf("max tasks:     %d\n", cfg.MaxConcurrentTasks)
+	fmt.Printf("max pulls:     %d\n", cfg.MaxConcurrentPulls)
+	fmt.Printf("registry:      %s\n", registrySummary(cfg))
+	fmt.Printf("plugins:       %s\n", pluginSummary(cfg))
+	fmt.Printf("stall after:   %s\n", stallSummary(cfg))
+	fmt.Printf("guardrails:    %s\n", guardSummary(cfg))
+	fmt.Printf("kw fallback:   %t\n", cfg.KeywordFallback)
+	fmt.Printf("debug:         %t\n", cfg.DebugEndpoints)
+	fmt.Printf("drain:         %ds delay, %ds shutdown timeout\n", cfg.DrainDelay, cfg.ShutdownTimeout)
+
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", w)
+	}
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d configuration error(s)", len(problems))
+	}
+	fmt.Println("configuration OK")
+	return nil
+}
+
+func checkHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host")
+	}
+	return nil
+}
+
+func stallSummary(cfg *config.Config) string {
+	if cfg.TaskStallMinutes == 0 {
+		return "(disabled)"
+	}
+	s := fmt.Sprintf("%d min", cfg.TaskStallMinutes)
+	if cfg.TaskStallAutoCancel {
+		s += ", auto-cancel"
+	}
+	return s
+}
+
+// guardSummary describes the resource checks made before index tasks.
+func guardSummary(cfg *config.Config) string {
+	if cfg.GuardMode == config.GuardOff {
+		return "(disabled)"
+	}
+	s := fmt.Sprintf("%s below %d MB free disk", cfg.GuardMode, cfg.GuardMinFreeDiskMB)
+	if cfg.GuardMaxMemoryPct > 0 {
+		s += fmt.Sprintf(" or above %d%% memory", cfg.GuardMaxMemoryPct)
+	}
+	if cfg.GuardQdrantDir != "" {
+		s += ", qdrant storage " + cfg.GuardQdrantDir
+	}
+	return s
+}
+
+// registrySummary describes where models are pulled from.
+func registrySummary(cfg *config.Config) string {
+	if cfg.RegistryMirror == "" {
+		return "registry.ollama.ai"
+	}
+	s := "mirror " + cfg.RegistryMirror
+	if cfg.RegistryInsecure {
+		s += " (insecure)"
+	}
+	if cfg.RegistryCAFile != "" {
+		s += ", CA " + cfg.RegistryCAFile
+	}
+	return s
+}
+
+// qdrantSummary describes the Qdrant connection without revealing the key.
+func qdrantSummary(cfg *config.Config) string {
+	s := cfg.QdrantURL
+	if cfg.QdrantAPIKey != "" {
+		s += ", API key set"
+	}
+	if cfg.QdrantInsecure {
+		s += " (insecure)"
+	}
+	if cfg.QdrantCAFile != "" {
+		s += ", CA " + cfg.QdrantCAFile
+	}
+	return s
+}
+
+// pluginSummary lists the compiled-in plugins, marking disabled ones.
+func pluginSummary(cfg *config.Config) string {
+	disabled := map[string]bool{}
+	for _, name := range cfg.PluginsDisabled {
+		disabled[name] = true
+	}
+	var names []string
+	for _, p := range plugin.List() {
+		if disabled[p.Name] {
+			names = append(names, p.Name+" (disabled)")
+		} else {
+			names = append(names, p.Name)
+		}
+	}
+	return orNone(strings.Join(names, ", "))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// ── migrate-store ─────────────────────────────────────────
+
+func runMigrateStore(args []string) error {
+	flags := flag.NewFlagSet("migrate-store", flag.ContinueOnError)
+	from := flags.String("from", "", "import documents from another data directory (e.g. an old DATA_DIR)")
+	force := flags.Bool("force", false, "with -from, overwrite documents that already exist")
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: gateway migrate-store [flags]\n\nRun while the gateway is stopped.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	dst := store.New(cfg.DataDir)
+
+	var meta store.Meta
+	if _, err := dst.Load(store.MetaDoc, &meta); err != nil {
+		return err
+	}
+	if meta.SchemaVersion > store.SchemaVersion {
+		return fmt.Errorf("%s was written by a newer gateway (schema %d, this build supports %d)",
+			cfg.DataDir, meta.SchemaVersion, store.SchemaVersion)
+	}
+
+	if *from != "" {
+		if err := importStore(store.New(*from), dst, *force, *dryRun); err != nil {
+			return err
+		}
+	}
+
+	// Every document must still decode; a corrupt one would otherwise only
+	// surface as a warning in the server log.
+	names, err := dst.Names()
+	if err != nil {
+		return err
+	}
+	var bad int
+	for _, name := range names {
+		var raw json.RawMessage
+		if _, err := dst.Load(name, &raw); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			bad++
+		}
+	}
+	if bad > 0 {
+		return fmt.Errorf("%d unreadable document(s) in %s", bad, cfg.DataDir)
+	}
+
+	if *dryRun {
+		fmt.Printf("dry run: schema %d -> %d, %d document(s) checked\n", meta.SchemaVersion, store.SchemaVersion, len(names))
+		return nil
+	}
+	if n := authmw.NewSessionStore(dst).Prune(); n > 0 {
+		fmt.Printf("pruned %d expired session(s)\n", n)
+	}
+	if err := dst.Save(store.MetaDoc, store.Meta{SchemaVersion: store.SchemaVersion}); err != nil {
+		return err
+	}
+	fmt.Printf("store %s at schema %d, %d document(s) checked\n", cfg.DataDir, store.SchemaVersion, len(names))
+	return nil
+}
+
+func importStore(src, dst *store.Store, force, dryRun bool) error {
+	names, err := src.Names()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no documents found in %s", src.Dir())
+	}
+	for _, name := range names {
+		if name == store.MetaDoc {
+			continue
+		}
+		var existing json.RawMessage
+		exists, _ := dst.Load(name, &existing)
+		if exists && !force {
+			fmt.Printf("skip   %s (exists)\n", name)
+			continue
+		}
+		var raw json.RawMessage
+		if _, err := src.Load(name, &raw); err != nil {
+			return err
+		}
+		fmt.Printf("import %s\n", name)
+		if dryRun {
+			continue
+		}
+		if err := dst.Save(name, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ── create-admin-token ────────────────────────────────────
+
+func runCreateAdminToken(args []string) error {
+	flags := flag.NewFlagSet("create-admin-token", flag.ContinueOnError)
+	user := flags.String("user", "admin", "username embedded in the token")
+	role := flags.String("role", "admin", "role embedded in the token")
+	ttl := flags.Duration("ttl", authmw.TokenExpiry, "token lifetime")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *ttl <= 0 {
+		return fmt.Errorf("-ttl must be positive")
+	}
+
+	// Without a fixed secret the running gateway signs with a random key the
+	// token could never match.
+	if os.Getenv("JWT_SECRET") == "" {
+		return fmt.Errorf("JWT_SECRET must be set to the secret the gateway runs with")
+	}
+	cfg := config.Load()
+
+	// The session is written to DATA_DIR, where a running gateway picks it
+	// up on first use and it can be revoked like any login.
+	sess := authmw.NewSessionStore(store.New(cfg.DataDir)).Issue(*user, *role, "", "gateway create-admin-token", *ttl)
+	token, err := authmw.GenerateTokenWithExpiry(cfg.JWTSecret, *user, *role, sess.ID, *ttl)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "session %s for %s (%s), expires %s\n",
+		sess.ID, *user, *role, sess.ExpiresAt.Format(time.RFC3339))
+	fmt.Println(token)
+	return nil
+}
+
+// ── purge-uploads ─────────────────────────────────────────
+
+func runPurgeUploads(args []string) error {
+	flags := flag.NewFlagSet("purge-uploads", flag.ContinueOnError)
+	olderThan := flags.Duration("older-than", 30*24*time.Hour, "delete files last modified before now minus this duration")
+	dryRun := flags.Bool("dry-run", false, "list files without deleting them")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: gateway purge-uploads [flags]\n\n"+
+			"Hidden directories (.artifacts, .gateway, ...) are left alone.\n"+
+			"Vectors indexed from the deleted files stay in Qdrant.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *olderThan <= 0 {
+		return fmt.Errorf("-older-than must be positive")
+	}
+
+	cfg := config.Load()
+	root := cfg.UploadDir
+	cutoff := time.Now().Add(-*olderThan)
+
+	var files int
+	var bytes int64
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if path != root {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		fmt.Println(path)
+		files++
+		bytes += info.Size()
+		if *dryRun {
+			return nil
+		}
+		return os.Remove(path)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !*dryRun {
+		// Remove directories emptied by the purge, deepest first.
+		for i := len(dirs) - 1; i >= 0; i-- {
+			os.Remove(dirs[i]) // fails harmlessly if not empty
+		}
+	}
+
+	verb := "deleted"
+	if *dryRun {
+		verb = "would delete"
+	}
+	fmt.Fprintf(os.Stderr, "%s %d file(s), %.1f MB older than %s\n",
+		verb, files, float64(bytes)/(1<<20), cutoff.Format(time.RFC3339))
+	return nil
+}
+
+// ── drain ─────────────────────────────────────────────────
+
+func runDrain(args []string) error {
+	flags := flag.NewFlagSet("drain", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: gateway drain\n\n"+
+			"Asks the gateway running in this container to drain: /readyz starts\n"+
+			"failing and the command returns after DRAIN_DELAY_S. Meant for a\n"+
+			"Kubernetes preStop exec hook.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	host, port, err := net.SplitHostPort(cfg.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("LISTEN_ADDR %q: %w", cfg.ListenAddr, err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	target := "http://" + net.JoinHostPort(host, port) + cfg.BasePath + "/prestop"
+
+	client := &http.Client{Timeout: time.Duration(cfg.DrainDelay)*time.Second + 10*time.Second}
+	resp, err := client.Post(target, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s %s", target, resp.Status, strings.TrimSpace(string(body)))
+	}
+	fmt.Fprintln(os.Stderr, "gateway drained")
+	return nil
+}
@@ -1,80 +1,70 @@
 package main
 
 import (
-	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
-
-	"github.com/alfagnish/ollqd-gateway/internal/config"
-	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
-	"github.com/alfagnish/ollqd-gateway/internal/server"
-	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"strings"
 )
 
-func main() {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-
-	// 1. Load configuration from environment variables.
-	cfg := config.Load()
-	log.Printf("config: listen=%s worker=%s ollama=%s qdrant=%s",
-		cfg.ListenAddr, cfg.WorkerAddr, cfg.OllamaURL, cfg.QdrantURL)
+// command is a gateway subcommand. run receives the arguments following the
+// subcommand name.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
 
-	// 2. Connect to the Python gRPC worker.
-	log.Printf("connecting to gRPC worker at %s ...", cfg.WorkerAddr)
-	gc, err := grpcclient.NewClient(cfg.WorkerAddr)
-	if err != nil {
-		// Non-fatal: the gateway can still serve health checks, proxies,
-		// and static files without the gRPC worker. Handlers will return
-		// 503 for gRPC-dependent endpoints.
-		log.Printf("WARNING: gRPC worker unavailable: %v", err)
-		gc = &grpcclient.Client{} // empty client with nil stubs
-	} else {
-		defer gc.Close()
-		log.Println("gRPC worker connected")
-	}
+var commands = []command{
+	{"serve", "run the HTTP gateway (default)", runServe},
+	{"check-config", "validate the environment configuration and exit", runCheckConfig},
+	{"migrate-store", "upgrade (or import) the persisted settings in DATA_DIR", runMigrateStore},
+	{"create-admin-token", "issue an admin JWT without logging in", runCreateAdminToken},
+	{"purge-uploads", "delete uploaded files older than a cutoff", runPurgeUploads},
+	{"drain", "drain the local gateway before shutdown (preStop hook)", runDrain},
+}
 
-	// 3. Create the in-memory task manager.
-	tm := tasks.NewManager()
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	// 4. Set up the chi router with all handlers.
-	handler, err := server.New(cfg, gc, tm)
-	if err != nil {
-		log.Fatalf("failed to create server: %v", err)
+	// No subcommand (or only flags) keeps the historical behaviour of
+	// starting the server, so existing container entrypoints still work.
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	// 5. Start the HTTP server.
-	srv := &http.Server{
-		Addr:         cfg.ListenAddr,
-		Handler:      handler,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 0, // no write timeout to support streaming responses
-		IdleTimeout:  120 * time.Second,
+	if name == "help" {
+		usage()
+		return
 	}
-
-	// Graceful shutdown on SIGINT / SIGTERM.
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
-
-	go func() {
-		log.Printf("gateway listening on %s", cfg.ListenAddr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("server error: %v", err)
+	for _, c := range commands {
+		if c.name != name {
+			continue
 		}
-	}()
-
-	<-done
-	log.Println("shutting down...")
+		if err := c.run(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "gateway %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	fmt.Fprintf(os.Stderr, "gateway: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("graceful shutdown error: %v", err)
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: gateway [command] [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", c.name, c.usage)
 	}
-
-	log.Println("gateway stopped")
+	fmt.Fprintln(os.Stderr, "\nConfiguration is read from the same environment variables in every command.")
+	fmt.Fprintln(os.Stderr, "Run \"gateway <command> -h\" for command flags.")
 }
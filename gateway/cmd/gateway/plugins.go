@@ -0,0 +1,10 @@
+package main
+
+// Plugins compiled into the gateway. Each blank import registers its plugin
+// from init; see internal/plugin for the hooks. Registered plugins are on
+// unless named in PLUGINS_DISABLED.
+//
+// To add one, put its package in the module (for instance under plugins/)
+// and import it here:
+//
+//	import _ "github.com/alfagnish/ollqd-gateway/plugins/redact"
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/config"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/lifecycle"
+	"github.com/alfagnish/ollqd-gateway/internal/server"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+// runServe starts the HTTP gateway (and the optional gRPC task API) and
+// blocks until SIGINT / SIGTERM.
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// 1. Load configuration from environment variables.
+	cfg := config.Load()
+	log.Printf("config: listen=%s worker=%s ollama=%s qdrant=%s",
+		cfg.ListenAddr, cfg.WorkerAddr, cfg.OllamaURL, cfg.QdrantURL)
+
+	// 2. Connect to the Python gRPC worker.
+	log.Printf("connecting to gRPC worker at %s ...", cfg.WorkerAddr)
+	gc, err := grpcclient.NewClient(cfg.WorkerAddr)
+	if err != nil {
+		// Non-fatal: the gateway can still serve health checks, proxies,
+		// and static files without the gRPC worker. Handlers will return
+		// 503 for gRPC-dependent endpoints.
+		log.Printf("WARNING: gRPC worker unavailable: %v", err)
+		gc = &grpcclient.Client{} // empty client with nil stubs
+	} else {
+		defer gc.Close()
+		log.Println("gRPC worker connected")
+	}
+	// Record the worker's version and services whenever it (re)connects.
+	workerCtx, stopWorkerWatch := context.WithCancel(context.Background())
+	defer stopWorkerWatch()
+	go gc.WatchWorker(workerCtx)
+
+	// 3. Create the in-memory task manager.
+	tm := tasks.NewManager(cfg.ArtifactDir, cfg.MaxConcurrentTasks)
+	retention, err := tasks.ParseRetention(cfg.TaskParamsRetention)
+	if err != nil {
+		return fmt.Errorf("TASK_PARAMS_RETENTION: %w", err)
+	}
+	tm.SetParamPolicy(tasks.ParamPolicy{RedactKeys: cfg.TaskRedactParams, Retention: retention})
+	tm.SetRestartPolicy(tasks.RestartPolicy{
+		Retries: cfg.WorkerRestartRetries,
+		Backoff: time.Duration(cfg.WorkerRestartBackoff) * time.Millisecond,
+	})
+	watchdogCtx, stopWatchdog := context.WithCancel(context.Background())
+	defer stopWatchdog()
+	tm.StartWatchdog(watchdogCtx, tasks.WatchdogPolicy{
+		StallAfter: time.Duration(cfg.TaskStallMinutes) * time.Minute,
+		AutoCancel: cfg.TaskStallAutoCancel,
+	})
+
+	// 4. Set up the chi router with all handlers.
+	lc := lifecycle.New(time.Duration(cfg.DrainDelay) * time.Second)
+	handler, grpcSrv, err := server.New(cfg, gc, tm, lc)
+	if err != nil {
+		return fmt.Errorf("failed to create server: %w", err)
+	}
+
+	// 5. Start the HTTP server.
+	srv := &http.Server{
+		Addr:         cfg.ListenAddr,
+		Handler:      handler,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 0, // no write timeout to support streaming responses
+		IdleTimeout:  120 * time.Second,
+	}
+
+	// Graceful shutdown on SIGINT / SIGTERM.
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
+
+	httpLis, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("listen error: %w", err)
+	}
+	go func() {
+		log.Printf("gateway listening on %s", cfg.ListenAddr)
+		if err := srv.Serve(httpLis); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("server error: %v", err)
+		}
+	}()
+
+	// 6. Optionally start the gRPC task API on its own port.
+	if grpcSrv != nil {
+		lis, err := net.Listen("tcp", cfg.GRPCListenAddr)
+		if err != nil {
+			return fmt.Errorf("grpc listen error: %w", err)
+		}
+		go func() {
+			log.Printf("gateway gRPC task API listening on %s", cfg.GRPCListenAddr)
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Fatalf("grpc server error: %v", err)
+			}
+		}()
+	}
+
+	lc.MarkStarted()
+
+	// 7. Drain, then shut down. When the preStop hook already drained the
+	// gateway, the signal finds the drain finished and shutdown starts
+	// right away.
+	sig := <-done
+	<-lc.Drain(sig.String())
+	log.Println("shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("graceful shutdown error: %v", err)
+	}
+	if grpcSrv != nil {
+		grpcSrv.GracefulStop()
+	}
+
+	log.Println("gateway stopped")
+	return nil
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+	"github.com/alfagnish/ollqd-gateway/pkg/client"
+)
+
+// ── index ─────────────────────────────────────────────────
+
+func runIndex(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("index", flag.ContinueOnError)
+	collection := flags.String("collection", "", "target collection (default: the worker's for the kind)")
+	kind := flags.String("kind", "codebase", "indexing pipeline: codebase, documents or images")
+	incremental := flags.Bool("incremental", false, "skip files unchanged since the last run (codebase, images)")
+	priority := flags.String("priority", "", "task priority: high, normal or low")
+	lock := flags.String("lock", "", "collection lock: block, warn or none")
+	wait := flags.Bool("wait", false, "follow the task until it finishes; exit 1 unless it completes")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ollqdctl index PATH [flags]\n\nPATH is read by the worker, so it must be visible there, for example on a\nshared volume. A relative PATH is made absolute first.")
+		flags.PrintDefaults()
+	}
+	pos, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one PATH")
+	}
+	path, err := filepath.Abs(pos[0])
+	if err != nil {
+		return err
+	}
+
+	var accepted *api.TaskAccepted
+	switch *kind {
+	case "codebase":
+		accepted, err = c.IndexCodebase(ctx, api.IndexCodebaseRequest{
+			RootPath: path, Collection: *collection, Incremental: *incremental, Priority: *priority, Lock: *lock,
+		})
+	case "documents":
+		accepted, err = c.IndexDocuments(ctx, api.IndexDocumentsRequest{
+			Paths: []string{path}, Collection: *collection, Priority: *priority, Lock: *lock,
+		})
+	case "images":
+		accepted, err = c.IndexImages(ctx, api.IndexImagesRequest{
+			RootPath: path, Collection: *collection, Incremental: *incremental, Priority: *priority, Lock: *lock,
+		})
+	default:
+		return fmt.Errorf("unknown kind %q (want codebase, documents or images)", *kind)
+	}
+	if err != nil {
+		return err
+	}
+	if *jsonOut && !*wait {
+		return printJSON(accepted)
+	}
+	printAccepted(accepted.TaskID, accepted.Status, accepted.QueuePosition, accepted.Warnings)
+	if !*wait {
+		return nil
+	}
+	return watch(ctx, c, accepted.TaskID)
+}
+
+// ── search ────────────────────────────────────────────────
+
+func runSearch(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	collection := flags.String("collection", "", "collection to search (default: the search defaults')")
+	topK := flags.Int("top-k", 0, "number of results (default: the search defaults')")
+	mode := flags.String("mode", "", `"keyword" ranks stored text by BM25 instead of vector similarity`)
+	language := flags.String("language", "", "only hits in this language")
+	minScore := flags.Float64("min-score", 0, "drop hits scoring below this")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ollqdctl search QUERY [flags]")
+		flags.PrintDefaults()
+	}
+	pos, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected a QUERY")
+	}
+
+	req := api.SearchRequest{SearchQuery: api.SearchQuery{
+		Query: strings.Join(pos, " "), TopK: int32(*topK), Language: *language, Mode: *mode,
+	}}
+	if *minScore > 0 {
+		s := float32(*minScore)
+		req.ScoreThreshold = &s
+	}
+	var resp *api.SearchResponse
+	if *collection != "" {
+		resp, err = c.SearchCollection(ctx, *collection, req)
+	} else {
+		resp, err = c.Search(ctx, req)
+	}
+	if err != nil {
+		return err
+	}
+	if *jsonOut {
+		return printJSON(resp)
+	}
+
+	if resp.Degraded {
+		fmt.Fprintf(os.Stderr, "keyword fallback: %s\n", resp.Reason)
+	}
+	if len(resp.Results) == 0 {
+		fmt.Println("no results")
+		return nil
+	}
+	for i, hit := range resp.Results {
+		loc := hit.GetFilePath()
+		if hit.GetLines() != "" {
+			loc += ":" + hit.GetLines()
+		}
+		fmt.Printf("%2d. %.4f  %s\n", i+1, hit.GetScore(), loc)
+		for _, line := range snippet(hit.GetContent(), 3) {
+			fmt.Printf("      %s\n", line)
+		}
+	}
+	return nil
+}
+
+// snippet returns up to n non-blank lines of content.
+func snippet(content string, n int) []string {
+	var lines []string
+	for _, l := range strings.Split(content, "\n") {
+		if l = strings.TrimRight(l, " \t\r"); strings.TrimSpace(l) == "" {
+			continue
+		}
+		if r := []rune(l); len(r) > 100 {
+			l = string(r[:100]) + "…"
+		}
+		if lines = append(lines, l); len(lines) == n {
+			break
+		}
+	}
+	return lines
+}
+
+// ── upload ────────────────────────────────────────────────
+
+func runUpload(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("upload", flag.ContinueOnError)
+	collection := flags.String("collection", "", "target collection for files no routing rule matches")
+	sourceTag := flags.String("source-tag", "", "source tag stored with the files' points")
+	priority := flags.String("priority", "", "task priority: high, normal or low")
+	noRouting := flags.Bool("no-routing", false, "ignore the upload routing rules")
+	wait := flags.Bool("wait", false, "follow the tasks until they finish; exit 1 unless all complete")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ollqdctl upload FILE... [flags]")
+		flags.PrintDefaults()
+	}
+	files, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected at least one FILE")
+	}
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", f)
+		}
+	}
+
+	fields := api.UploadFields{Collection: *collection, SourceTag: *sourceTag, Priority: *priority}
+	if *noRouting {
+		routing := false
+		fields.Routing = &routing
+	}
+	accepted, err := c.Upload(ctx, fields, files...)
+	if err != nil {
+		return err
+	}
+	if *jsonOut && !*wait {
+		return printJSON(accepted)
+	}
+	fmt.Printf("uploaded %d file(s)\n", accepted.Count)
+	for _, w := range accepted.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	for _, r := range accepted.Routes {
+		dest := r.Pipeline
+		if r.Collection != "" {
+			dest += " -> " + r.Collection
+		}
+		fmt.Printf("task %s %s (%s, %d file(s))\n", r.TaskID, r.Status, dest, len(r.Files))
+	}
+	if !*wait {
+		return nil
+	}
+	var failed error
+	for _, r := range accepted.Routes {
+		if err := watch(ctx, c, r.TaskID); err != nil {
+			if !errors.Is(err, errTaskFailed) {
+				return err
+			}
+			failed = err
+		}
+	}
+	return failed
+}
+
+// ── tasks ─────────────────────────────────────────────────
+
+func runTasks(ctx context.Context, c *client.Client, args []string) error {
+	sub := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sub, args = args[0], args[1:]
+	}
+	flags := flag.NewFlagSet("tasks "+sub, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ollqdctl tasks [list | get ID | watch ID | cancel ID]")
+		flags.PrintDefaults()
+	}
+	pos, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if sub == "list" {
+		if len(pos) != 0 {
+			flags.Usage()
+			return fmt.Errorf("list takes no arguments")
+		}
+		return listTasks(ctx, c)
+	}
+	if len(pos) != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one task ID")
+	}
+	id := pos[0]
+
+	switch sub {
+	case "get":
+		t, err := c.Task(ctx, id)
+		if err != nil {
+			return err
+		}
+		if *jsonOut {
+			return printJSON(t)
+		}
+		printTask(t)
+		return nil
+	case "watch":
+		return watch(ctx, c, id)
+	case "cancel":
+		status, err := c.CancelTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("task %s %s\n", id, status)
+		return nil
+	}
+	flags.Usage()
+	return fmt.Errorf("unknown tasks command %q", sub)
+}
+
+func listTasks(ctx context.Context, c *client.Client) error {
+	list, err := c.Tasks(ctx)
+	if err != nil {
+		return err
+	}
+	if *jsonOut {
+		return printJSON(list)
+	}
+	if list.Count == 0 {
+		fmt.Println("no tasks")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTYPE\tSTATUS\tPROGRESS\tCREATED")
+	for _, t := range list.Tasks {
+		status := string(t.Status)
+		if t.Stalled {
+			status += " (stalled)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.0f%%\t%s\n", t.ID, t.Type, status, t.Progress, t.CreatedAt.Local().Format(time.DateTime))
+	}
+	return tw.Flush()
+}
+
+// ── output ────────────────────────────────────────────────
+
+func printAccepted(id, status string, queuePosition int, warnings []string) {
+	if queuePosition > 0 {
+		fmt.Printf("task %s %s (position %d)\n", id, status, queuePosition)
+	} else {
+		fmt.Printf("task %s %s\n", id, status)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+}
+
+func printTask(t *api.Task) {
+	fmt.Printf("task:     %s\n", t.ID)
+	fmt.Printf("type:     %s\n", t.Type)
+	fmt.Printf("status:   %s\n", t.Status)
+	fmt.Printf("progress: %.0f%%\n", t.Progress)
+	fmt.Printf("created:  %s\n", t.CreatedAt.Local().Format(time.DateTime))
+	if t.CompletedAt != nil {
+		fmt.Printf("finished: %s\n", t.CompletedAt.Local().Format(time.DateTime))
+	}
+	if t.Error != "" {
+		fmt.Printf("error:    %s\n", t.Error)
+	}
+	for k, v := range t.Result {
+		fmt.Printf("result:   %s=%s\n", k, v)
+	}
+}
+
+// watch prints the task's progress until it finishes and returns
+// errTaskFailed unless it completed. With -json only the final state is
+// printed.
+func watch(ctx context.Context, c *client.Client, id string) error {
+	var last *api.Task
+	for u := range c.WatchTask(ctx, id, 0) {
+		if u.Err != nil {
+			return u.Err
+		}
+		last = u.Task
+		if !*jsonOut {
+			fmt.Printf("%s  %-9s %3.0f%%\n", time.Now().Format(time.TimeOnly), last.Status, last.Progress)
+		}
+	}
+	if last == nil || !last.Status.Finished() {
+		return fmt.Errorf("stopped watching task %s; it keeps running", id)
+	}
+	if *jsonOut {
+		if err := printJSON(last); err != nil {
+			return err
+		}
+	} else {
+		printTask(last)
+	}
+	if last.Status != api.StatusCompleted {
+		return fmt.Errorf("%w: %s", errTaskFailed, last.Status)
+	}
+	return nil
+}
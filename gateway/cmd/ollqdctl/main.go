@@ -0,0 +1,125 @@
+// Command ollqdctl talks to a running gateway from the terminal: index a
+// directory, search, upload files, and follow tasks. It is built on
+// pkg/client and suited to CI pipelines.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/alfagnish/ollqd-gateway/pkg/client"
+)
+
+// command is an ollqdctl subcommand. run receives the arguments following
+// the subcommand name.
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, c *client.Client, args []string) error
+}
+
+var commands = []command{
+	{"index", "index a directory: index PATH [-collection NAME] [-kind codebase|documents|images] [-wait]", runIndex},
+	{"search", "search a collection: search QUERY [-collection NAME] [-top-k N] [-mode keyword]", runSearch},
+	{"upload", "upload and index files: upload FILE... [-collection NAME] [-wait]", runUpload},
+	{"tasks", "list or follow tasks: tasks [list | get ID | watch ID | cancel ID]", runTasks},
+}
+
+// Global flags, shared by every subcommand.
+var (
+	gatewayURL = flag.String("url", envOrDefault("OLLQD_URL", "http://localhost:8000"), "gateway base URL (OLLQD_URL)")
+	token      = flag.String("token", os.Getenv("OLLQD_TOKEN"), "bearer token from POST /api/auth/login (OLLQD_TOKEN)")
+	jsonOut    = flag.Bool("json", false, "print responses as JSON")
+)
+
+// errTaskFailed is returned when a waited-for task did not complete, so the
+// exit status tells CI pipelines.
+var errTaskFailed = errors.New("task did not complete")
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 || args[0] == "help" {
+		usage()
+		return
+	}
+
+	// Ctrl-C stops a wait or watch without cancelling the task.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c := client.New(*gatewayURL, client.WithToken(*token))
+	name := args[0]
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(ctx, c, args[1:])
+		stop()
+		if err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "ollqdctl %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "ollqdctl: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "usage: ollqdctl [flags] <command> [args]")
+	fmt.Fprintln(out, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(out, "\nflags:")
+	flag.PrintDefaults()
+}
+
+// parseArgs parses a subcommand's flags, which may come before or after
+// its positional arguments, and returns the positional ones.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := flags.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// printJSON writes v indented to stdout.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func envOrDefault(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
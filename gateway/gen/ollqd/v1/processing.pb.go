@@ -29,6 +29,10 @@ type IndexCodebaseRequest struct {
 	ChunkSize     int32                  `protobuf:"varint,4,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
 	ChunkOverlap  int32                  `protobuf:"varint,5,opt,name=chunk_overlap,json=chunkOverlap,proto3" json:"chunk_overlap,omitempty"`
 	ExtraSkipDirs []string               `protobuf:"bytes,6,rep,name=extra_skip_dirs,json=extraSkipDirs,proto3" json:"extra_skip_dirs,omitempty"`
+	// Root-relative paths to index instead of scanning the whole root. The
+	// worker then skips its own hash comparison.
+	Files         []string    `protobuf:"bytes,7,rep,name=files,proto3" json:"files,omitempty"`
+	Provenance    *Provenance `protobuf:"bytes,8,opt,name=provenance,proto3" json:"provenance,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -105,6 +109,20 @@ func (x *IndexCodebaseRequest) GetExtraSkipDirs() []string {
 	return nil
 }
 
+func (x *IndexCodebaseRequest) GetFiles() []string {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *IndexCodebaseRequest) GetProvenance() *Provenance {
+	if x != nil {
+		return x.Provenance
+	}
+	return nil
+}
+
 type IndexDocumentsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Paths         []string               `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
@@ -112,6 +130,7 @@ type IndexDocumentsRequest struct {
 	ChunkSize     int32                  `protobuf:"varint,3,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
 	ChunkOverlap  int32                  `protobuf:"varint,4,opt,name=chunk_overlap,json=chunkOverlap,proto3" json:"chunk_overlap,omitempty"`
 	SourceTag     string                 `protobuf:"bytes,5,opt,name=source_tag,json=sourceTag,proto3" json:"source_tag,omitempty"`
+	Provenance    *Provenance            `protobuf:"bytes,6,opt,name=provenance,proto3" json:"provenance,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -181,6 +200,13 @@ func (x *IndexDocumentsRequest) GetSourceTag() string {
 	return ""
 }
 
+func (x *IndexDocumentsRequest) GetProvenance() *Provenance {
+	if x != nil {
+		return x.Provenance
+	}
+	return nil
+}
+
 type IndexImagesRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	RootPath       string                 `protobuf:"bytes,1,opt,name=root_path,json=rootPath,proto3" json:"root_path,omitempty"`
@@ -190,6 +216,7 @@ type IndexImagesRequest struct {
 	Incremental    bool                   `protobuf:"varint,5,opt,name=incremental,proto3" json:"incremental,omitempty"`
 	MaxImageSizeKb int32                  `protobuf:"varint,6,opt,name=max_image_size_kb,json=maxImageSizeKb,proto3" json:"max_image_size_kb,omitempty"`
 	ExtraSkipDirs  []string               `protobuf:"bytes,7,rep,name=extra_skip_dirs,json=extraSkipDirs,proto3" json:"extra_skip_dirs,omitempty"`
+	Provenance     *Provenance            `protobuf:"bytes,8,opt,name=provenance,proto3" json:"provenance,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -273,6 +300,13 @@ func (x *IndexImagesRequest) GetExtraSkipDirs() []string {
 	return nil
 }
 
+func (x *IndexImagesRequest) GetProvenance() *Provenance {
+	if x != nil {
+		return x.Provenance
+	}
+	return nil
+}
+
 type IndexUploadsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SavedPaths    []string               `protobuf:"bytes,1,rep,name=saved_paths,json=savedPaths,proto3" json:"saved_paths,omitempty"`
@@ -282,6 +316,7 @@ type IndexUploadsRequest struct {
 	SourceTag     string                 `protobuf:"bytes,5,opt,name=source_tag,json=sourceTag,proto3" json:"source_tag,omitempty"`
 	VisionModel   string                 `protobuf:"bytes,6,opt,name=vision_model,json=visionModel,proto3" json:"vision_model,omitempty"`
 	CaptionPrompt string                 `protobuf:"bytes,7,opt,name=caption_prompt,json=captionPrompt,proto3" json:"caption_prompt,omitempty"`
+	Provenance    *Provenance            `protobuf:"bytes,8,opt,name=provenance,proto3" json:"provenance,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -365,6 +400,13 @@ func (x *IndexUploadsRequest) GetCaptionPrompt() string {
 	return ""
 }
 
+func (x *IndexUploadsRequest) GetProvenance() *Provenance {
+	if x != nil {
+		return x.Provenance
+	}
+	return nil
+}
+
 type IndexSMBFilesRequest struct {
 	state        protoimpl.MessageState `protogen:"open.v1"`
 	ShareId      string                 `protobuf:"bytes,1,opt,name=share_id,json=shareId,proto3" json:"share_id,omitempty"`
@@ -374,12 +416,20 @@ type IndexSMBFilesRequest struct {
 	ChunkOverlap int32                  `protobuf:"varint,5,opt,name=chunk_overlap,json=chunkOverlap,proto3" json:"chunk_overlap,omitempty"`
 	SourceTag    string                 `protobuf:"bytes,6,opt,name=source_tag,json=sourceTag,proto3" json:"source_tag,omitempty"`
 	// SMB connection info
-	Server        string `protobuf:"bytes,7,opt,name=server,proto3" json:"server,omitempty"`
-	Share         string `protobuf:"bytes,8,opt,name=share,proto3" json:"share,omitempty"`
-	Username      string `protobuf:"bytes,9,opt,name=username,proto3" json:"username,omitempty"`
-	Password      string `protobuf:"bytes,10,opt,name=password,proto3" json:"password,omitempty"`
-	Domain        string `protobuf:"bytes,11,opt,name=domain,proto3" json:"domain,omitempty"`
-	Port          int32  `protobuf:"varint,12,opt,name=port,proto3" json:"port,omitempty"`
+	Server   string `protobuf:"bytes,7,opt,name=server,proto3" json:"server,omitempty"`
+	Share    string `protobuf:"bytes,8,opt,name=share,proto3" json:"share,omitempty"`
+	Username string `protobuf:"bytes,9,opt,name=username,proto3" json:"username,omitempty"`
+	Password string `protobuf:"bytes,10,opt,name=password,proto3" json:"password,omitempty"`
+	Domain   string `protobuf:"bytes,11,opt,name=domain,proto3" json:"domain,omitempty"`
+	Port     int32  `protobuf:"varint,12,opt,name=port,proto3" json:"port,omitempty"`
+	// Sign-in: auth "" is NTLMv2 with username and password; "kerberos"
+	// signs in as username@realm with the keytab, if set, or the password.
+	// kdc, if set, replaces DNS discovery of the realm's KDCs.
+	Auth          string      `protobuf:"bytes,13,opt,name=auth,proto3" json:"auth,omitempty"`
+	Realm         string      `protobuf:"bytes,14,opt,name=realm,proto3" json:"realm,omitempty"`
+	Kdc           string      `protobuf:"bytes,15,opt,name=kdc,proto3" json:"kdc,omitempty"`
+	Keytab        []byte      `protobuf:"bytes,16,opt,name=keytab,proto3" json:"keytab,omitempty"`
+	Provenance    *Provenance `protobuf:"bytes,17,opt,name=provenance,proto3" json:"provenance,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -498,6 +548,149 @@ func (x *IndexSMBFilesRequest) GetPort() int32 {
 	return 0
 }
 
+func (x *IndexSMBFilesRequest) GetAuth() string {
+	if x != nil {
+		return x.Auth
+	}
+	return ""
+}
+
+func (x *IndexSMBFilesRequest) GetRealm() string {
+	if x != nil {
+		return x.Realm
+	}
+	return ""
+}
+
+func (x *IndexSMBFilesRequest) GetKdc() string {
+	if x != nil {
+		return x.Kdc
+	}
+	return ""
+}
+
+func (x *IndexSMBFilesRequest) GetKeytab() []byte {
+	if x != nil {
+		return x.Keytab
+	}
+	return nil
+}
+
+func (x *IndexSMBFilesRequest) GetProvenance() *Provenance {
+	if x != nil {
+		return x.Provenance
+	}
+	return nil
+}
+
+// Provenance records where indexed content came from and which run wrote
+// it. The gateway sets it on every index request; the worker stores it as
+// the "provenance" payload of each point it writes.
+type Provenance struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Schema     int32                  `protobuf:"varint,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	SourceType string                 `protobuf:"bytes,2,opt,name=source_type,json=sourceType,proto3" json:"source_type,omitempty"`
+	// SMB share or connector the files were read from.
+	ShareId string `protobuf:"bytes,3,opt,name=share_id,json=shareId,proto3" json:"share_id,omitempty"`
+	// User who started the run; empty for scheduled runs and gRPC callers.
+	Uploader string `protobuf:"bytes,4,opt,name=uploader,proto3" json:"uploader,omitempty"`
+	// When the run opened its worker stream, RFC 3339 UTC.
+	IndexedAt      string `protobuf:"bytes,5,opt,name=indexed_at,json=indexedAt,proto3" json:"indexed_at,omitempty"`
+	TaskId         string `protobuf:"bytes,6,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	GatewayVersion string `protobuf:"bytes,7,opt,name=gateway_version,json=gatewayVersion,proto3" json:"gateway_version,omitempty"`
+	// Paths the worker reads mapped to the original path or URL of each
+	// file. Files left out are recorded under their display name or path.
+	Sources       map[string]string `protobuf:"bytes,8,rep,name=sources,proto3" json:"sources,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Provenance) Reset() {
+	*x = Provenance{}
+	mi := &file_ollqd_v1_processing_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Provenance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Provenance) ProtoMessage() {}
+
+func (x *Provenance) ProtoReflect() protoreflect.Message {
+	mi := &file_ollqd_v1_processing_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Provenance.ProtoReflect.Descriptor instead.
+func (*Provenance) Descriptor() ([]byte, []int) {
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Provenance) GetSchema() int32 {
+	if x != nil {
+		return x.Schema
+	}
+	return 0
+}
+
+func (x *Provenance) GetSourceType() string {
+	if x != nil {
+		return x.SourceType
+	}
+	return ""
+}
+
+func (x *Provenance) GetShareId() string {
+	if x != nil {
+		return x.ShareId
+	}
+	return ""
+}
+
+func (x *Provenance) GetUploader() string {
+	if x != nil {
+		return x.Uploader
+	}
+	return ""
+}
+
+func (x *Provenance) GetIndexedAt() string {
+	if x != nil {
+		return x.IndexedAt
+	}
+	return ""
+}
+
+func (x *Provenance) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *Provenance) GetGatewayVersion() string {
+	if x != nil {
+		return x.GatewayVersion
+	}
+	return ""
+}
+
+func (x *Provenance) GetSources() map[string]string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
 type CancelTaskRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
@@ -507,7 +700,7 @@ type CancelTaskRequest struct {
 
 func (x *CancelTaskRequest) Reset() {
 	*x = CancelTaskRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[5]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -519,7 +712,7 @@ func (x *CancelTaskRequest) String() string {
 func (*CancelTaskRequest) ProtoMessage() {}
 
 func (x *CancelTaskRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[5]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -532,7 +725,7 @@ func (x *CancelTaskRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelTaskRequest.ProtoReflect.Descriptor instead.
 func (*CancelTaskRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{5}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *CancelTaskRequest) GetTaskId() string {
@@ -552,7 +745,7 @@ type CancelTaskResponse struct {
 
 func (x *CancelTaskResponse) Reset() {
 	*x = CancelTaskResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[6]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -564,7 +757,7 @@ func (x *CancelTaskResponse) String() string {
 func (*CancelTaskResponse) ProtoMessage() {}
 
 func (x *CancelTaskResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[6]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -577,7 +770,7 @@ func (x *CancelTaskResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelTaskResponse.ProtoReflect.Descriptor instead.
 func (*CancelTaskResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{6}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CancelTaskResponse) GetCancelled() bool {
@@ -606,7 +799,7 @@ type SearchRequest struct {
 
 func (x *SearchRequest) Reset() {
 	*x = SearchRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[7]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -618,7 +811,7 @@ func (x *SearchRequest) String() string {
 func (*SearchRequest) ProtoMessage() {}
 
 func (x *SearchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[7]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -631,7 +824,7 @@ func (x *SearchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
 func (*SearchRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{7}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *SearchRequest) GetQuery() string {
@@ -675,7 +868,7 @@ type SearchCollectionRequest struct {
 
 func (x *SearchCollectionRequest) Reset() {
 	*x = SearchCollectionRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[8]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -687,7 +880,7 @@ func (x *SearchCollectionRequest) String() string {
 func (*SearchCollectionRequest) ProtoMessage() {}
 
 func (x *SearchCollectionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[8]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -700,7 +893,7 @@ func (x *SearchCollectionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchCollectionRequest.ProtoReflect.Descriptor instead.
 func (*SearchCollectionRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{8}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *SearchCollectionRequest) GetCollection() string {
@@ -750,7 +943,7 @@ type SearchResponse struct {
 
 func (x *SearchResponse) Reset() {
 	*x = SearchResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[9]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -762,7 +955,7 @@ func (x *SearchResponse) String() string {
 func (*SearchResponse) ProtoMessage() {}
 
 func (x *SearchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[9]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -775,7 +968,7 @@ func (x *SearchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
 func (*SearchResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{9}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *SearchResponse) GetStatus() string {
@@ -807,18 +1000,32 @@ func (x *SearchResponse) GetResults() []*SearchHit {
 }
 
 type ChatRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
-	Collection    string                 `protobuf:"bytes,2,opt,name=collection,proto3" json:"collection,omitempty"`
-	Model         string                 `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
-	PiiEnabled    bool                   `protobuf:"varint,4,opt,name=pii_enabled,json=piiEnabled,proto3" json:"pii_enabled,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Message    string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Collection string                 `protobuf:"bytes,2,opt,name=collection,proto3" json:"collection,omitempty"`
+	Model      string                 `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	PiiEnabled bool                   `protobuf:"varint,4,opt,name=pii_enabled,json=piiEnabled,proto3" json:"pii_enabled,omitempty"`
+	// Per-request generation and context options. Unset fields fall back to
+	// the worker's configured defaults.
+	Temperature      *float64 `protobuf:"fixed64,5,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	TopP             *float64 `protobuf:"fixed64,6,opt,name=top_p,json=topP,proto3,oneof" json:"top_p,omitempty"`
+	MaxTokens        *int32   `protobuf:"varint,7,opt,name=max_tokens,json=maxTokens,proto3,oneof" json:"max_tokens,omitempty"`
+	TopK             *int32   `protobuf:"varint,8,opt,name=top_k,json=topK,proto3,oneof" json:"top_k,omitempty"`
+	SystemPrompt     string   `protobuf:"bytes,9,opt,name=system_prompt,json=systemPrompt,proto3" json:"system_prompt,omitempty"`
+	ContextMaxTokens *int32   `protobuf:"varint,10,opt,name=context_max_tokens,json=contextMaxTokens,proto3,oneof" json:"context_max_tokens,omitempty"`
+	SourceMaxTokens  *int32   `protobuf:"varint,11,opt,name=source_max_tokens,json=sourceMaxTokens,proto3,oneof" json:"source_max_tokens,omitempty"`
+	DedupeFiles      *bool    `protobuf:"varint,12,opt,name=dedupe_files,json=dedupeFiles,proto3,oneof" json:"dedupe_files,omitempty"`
+	ContextOrder     string   `protobuf:"bytes,13,opt,name=context_order,json=contextOrder,proto3" json:"context_order,omitempty"` // score, recency or "" for the default
+	// Earlier turns of the conversation, oldest first. The worker places
+	// them between the system prompt and the new message.
+	History       []*ChatTurn `protobuf:"bytes,14,rep,name=history,proto3" json:"history,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ChatRequest) Reset() {
 	*x = ChatRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[10]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -830,7 +1037,7 @@ func (x *ChatRequest) String() string {
 func (*ChatRequest) ProtoMessage() {}
 
 func (x *ChatRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[10]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -843,7 +1050,7 @@ func (x *ChatRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChatRequest.ProtoReflect.Descriptor instead.
 func (*ChatRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{10}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ChatRequest) GetMessage() string {
@@ -874,6 +1081,129 @@ func (x *ChatRequest) GetPiiEnabled() bool {
 	return false
 }
 
+func (x *ChatRequest) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *ChatRequest) GetTopP() float64 {
+	if x != nil && x.TopP != nil {
+		return *x.TopP
+	}
+	return 0
+}
+
+func (x *ChatRequest) GetMaxTokens() int32 {
+	if x != nil && x.MaxTokens != nil {
+		return *x.MaxTokens
+	}
+	return 0
+}
+
+func (x *ChatRequest) GetTopK() int32 {
+	if x != nil && x.TopK != nil {
+		return *x.TopK
+	}
+	return 0
+}
+
+func (x *ChatRequest) GetSystemPrompt() string {
+	if x != nil {
+		return x.SystemPrompt
+	}
+	return ""
+}
+
+func (x *ChatRequest) GetContextMaxTokens() int32 {
+	if x != nil && x.ContextMaxTokens != nil {
+		return *x.ContextMaxTokens
+	}
+	return 0
+}
+
+func (x *ChatRequest) GetSourceMaxTokens() int32 {
+	if x != nil && x.SourceMaxTokens != nil {
+		return *x.SourceMaxTokens
+	}
+	return 0
+}
+
+func (x *ChatRequest) GetDedupeFiles() bool {
+	if x != nil && x.DedupeFiles != nil {
+		return *x.DedupeFiles
+	}
+	return false
+}
+
+func (x *ChatRequest) GetContextOrder() string {
+	if x != nil {
+		return x.ContextOrder
+	}
+	return ""
+}
+
+func (x *ChatRequest) GetHistory() []*ChatTurn {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+// ChatTurn is an earlier message of a conversation.
+type ChatTurn struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Role          string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"` // user or assistant
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatTurn) Reset() {
+	*x = ChatTurn{}
+	mi := &file_ollqd_v1_processing_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatTurn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatTurn) ProtoMessage() {}
+
+func (x *ChatTurn) ProtoReflect() protoreflect.Message {
+	mi := &file_ollqd_v1_processing_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatTurn.ProtoReflect.Descriptor instead.
+func (*ChatTurn) Descriptor() ([]byte, []int) {
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ChatTurn) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *ChatTurn) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
 type ChatEvent struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	Type             string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // chunk, sources, done, error
@@ -887,7 +1217,7 @@ type ChatEvent struct {
 
 func (x *ChatEvent) Reset() {
 	*x = ChatEvent{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[11]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -899,7 +1229,7 @@ func (x *ChatEvent) String() string {
 func (*ChatEvent) ProtoMessage() {}
 
 func (x *ChatEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[11]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -912,7 +1242,7 @@ func (x *ChatEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChatEvent.ProtoReflect.Descriptor instead.
 func (*ChatEvent) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{11}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ChatEvent) GetType() string {
@@ -958,7 +1288,7 @@ type GetEmbeddingInfoRequest struct {
 
 func (x *GetEmbeddingInfoRequest) Reset() {
 	*x = GetEmbeddingInfoRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[12]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -970,7 +1300,7 @@ func (x *GetEmbeddingInfoRequest) String() string {
 func (*GetEmbeddingInfoRequest) ProtoMessage() {}
 
 func (x *GetEmbeddingInfoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[12]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -983,7 +1313,7 @@ func (x *GetEmbeddingInfoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetEmbeddingInfoRequest.ProtoReflect.Descriptor instead.
 func (*GetEmbeddingInfoRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{12}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{14}
 }
 
 type EmbeddingInfoResponse struct {
@@ -998,7 +1328,7 @@ type EmbeddingInfoResponse struct {
 
 func (x *EmbeddingInfoResponse) Reset() {
 	*x = EmbeddingInfoResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[13]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1010,7 +1340,7 @@ func (x *EmbeddingInfoResponse) String() string {
 func (*EmbeddingInfoResponse) ProtoMessage() {}
 
 func (x *EmbeddingInfoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[13]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1023,7 +1353,7 @@ func (x *EmbeddingInfoResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EmbeddingInfoResponse.ProtoReflect.Descriptor instead.
 func (*EmbeddingInfoResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{13}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *EmbeddingInfoResponse) GetModel() string {
@@ -1063,7 +1393,7 @@ type TestEmbedRequest struct {
 
 func (x *TestEmbedRequest) Reset() {
 	*x = TestEmbedRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[14]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1075,7 +1405,7 @@ func (x *TestEmbedRequest) String() string {
 func (*TestEmbedRequest) ProtoMessage() {}
 
 func (x *TestEmbedRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[14]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1088,7 +1418,7 @@ func (x *TestEmbedRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestEmbedRequest.ProtoReflect.Descriptor instead.
 func (*TestEmbedRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{14}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *TestEmbedRequest) GetText() string {
@@ -1113,7 +1443,7 @@ type TestEmbedResponse struct {
 
 func (x *TestEmbedResponse) Reset() {
 	*x = TestEmbedResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[15]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1125,7 +1455,7 @@ func (x *TestEmbedResponse) String() string {
 func (*TestEmbedResponse) ProtoMessage() {}
 
 func (x *TestEmbedResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[15]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1138,7 +1468,7 @@ func (x *TestEmbedResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestEmbedResponse.ProtoReflect.Descriptor instead.
 func (*TestEmbedResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{15}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *TestEmbedResponse) GetDimension() int32 {
@@ -1201,7 +1531,7 @@ type CompareModelsRequest struct {
 
 func (x *CompareModelsRequest) Reset() {
 	*x = CompareModelsRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[16]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1213,7 +1543,7 @@ func (x *CompareModelsRequest) String() string {
 func (*CompareModelsRequest) ProtoMessage() {}
 
 func (x *CompareModelsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[16]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1226,7 +1556,7 @@ func (x *CompareModelsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CompareModelsRequest.ProtoReflect.Descriptor instead.
 func (*CompareModelsRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{16}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *CompareModelsRequest) GetText() string {
@@ -1267,7 +1597,7 @@ type ModelTestResult struct {
 
 func (x *ModelTestResult) Reset() {
 	*x = ModelTestResult{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[17]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1279,7 +1609,7 @@ func (x *ModelTestResult) String() string {
 func (*ModelTestResult) ProtoMessage() {}
 
 func (x *ModelTestResult) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[17]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1292,7 +1622,7 @@ func (x *ModelTestResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ModelTestResult.ProtoReflect.Descriptor instead.
 func (*ModelTestResult) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{17}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *ModelTestResult) GetModel() string {
@@ -1369,7 +1699,7 @@ type CompareModelsResponse struct {
 
 func (x *CompareModelsResponse) Reset() {
 	*x = CompareModelsResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[18]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1381,7 +1711,7 @@ func (x *CompareModelsResponse) String() string {
 func (*CompareModelsResponse) ProtoMessage() {}
 
 func (x *CompareModelsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[18]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1394,7 +1724,7 @@ func (x *CompareModelsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CompareModelsResponse.ProtoReflect.Descriptor instead.
 func (*CompareModelsResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{18}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *CompareModelsResponse) GetModel1() *ModelTestResult {
@@ -1427,7 +1757,7 @@ type SetEmbedModelRequest struct {
 
 func (x *SetEmbedModelRequest) Reset() {
 	*x = SetEmbedModelRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[19]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1439,7 +1769,7 @@ func (x *SetEmbedModelRequest) String() string {
 func (*SetEmbedModelRequest) ProtoMessage() {}
 
 func (x *SetEmbedModelRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[19]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1452,7 +1782,7 @@ func (x *SetEmbedModelRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetEmbedModelRequest.ProtoReflect.Descriptor instead.
 func (*SetEmbedModelRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{19}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *SetEmbedModelRequest) GetModel() string {
@@ -1471,7 +1801,7 @@ type TestMaskingRequest struct {
 
 func (x *TestMaskingRequest) Reset() {
 	*x = TestMaskingRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[20]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1483,7 +1813,7 @@ func (x *TestMaskingRequest) String() string {
 func (*TestMaskingRequest) ProtoMessage() {}
 
 func (x *TestMaskingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[20]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1496,7 +1826,7 @@ func (x *TestMaskingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestMaskingRequest.ProtoReflect.Descriptor instead.
 func (*TestMaskingRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{20}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *TestMaskingRequest) GetText() string {
@@ -1516,7 +1846,7 @@ type PIIEntity struct {
 
 func (x *PIIEntity) Reset() {
 	*x = PIIEntity{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[21]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1528,7 +1858,7 @@ func (x *PIIEntity) String() string {
 func (*PIIEntity) ProtoMessage() {}
 
 func (x *PIIEntity) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[21]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1541,7 +1871,7 @@ func (x *PIIEntity) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PIIEntity.ProtoReflect.Descriptor instead.
 func (*PIIEntity) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{21}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *PIIEntity) GetToken() string {
@@ -1570,7 +1900,7 @@ type TestMaskingResponse struct {
 
 func (x *TestMaskingResponse) Reset() {
 	*x = TestMaskingResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[22]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1582,7 +1912,7 @@ func (x *TestMaskingResponse) String() string {
 func (*TestMaskingResponse) ProtoMessage() {}
 
 func (x *TestMaskingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[22]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1595,7 +1925,7 @@ func (x *TestMaskingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestMaskingResponse.ProtoReflect.Descriptor instead.
 func (*TestMaskingResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{22}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *TestMaskingResponse) GetOriginal() string {
@@ -1634,7 +1964,7 @@ type GetConfigRequest struct {
 
 func (x *GetConfigRequest) Reset() {
 	*x = GetConfigRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[23]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1646,7 +1976,7 @@ func (x *GetConfigRequest) String() string {
 func (*GetConfigRequest) ProtoMessage() {}
 
 func (x *GetConfigRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[23]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1659,7 +1989,7 @@ func (x *GetConfigRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetConfigRequest.ProtoReflect.Descriptor instead.
 func (*GetConfigRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{23}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{25}
 }
 
 type UpdateMountedPathsRequest struct {
@@ -1671,7 +2001,7 @@ type UpdateMountedPathsRequest struct {
 
 func (x *UpdateMountedPathsRequest) Reset() {
 	*x = UpdateMountedPathsRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[24]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1683,7 +2013,7 @@ func (x *UpdateMountedPathsRequest) String() string {
 func (*UpdateMountedPathsRequest) ProtoMessage() {}
 
 func (x *UpdateMountedPathsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[24]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1696,7 +2026,7 @@ func (x *UpdateMountedPathsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateMountedPathsRequest.ProtoReflect.Descriptor instead.
 func (*UpdateMountedPathsRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{24}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *UpdateMountedPathsRequest) GetPaths() []string {
@@ -1715,7 +2045,7 @@ type UpdateMountedPathsResponse struct {
 
 func (x *UpdateMountedPathsResponse) Reset() {
 	*x = UpdateMountedPathsResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[25]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1727,7 +2057,7 @@ func (x *UpdateMountedPathsResponse) String() string {
 func (*UpdateMountedPathsResponse) ProtoMessage() {}
 
 func (x *UpdateMountedPathsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[25]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1740,7 +2070,7 @@ func (x *UpdateMountedPathsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateMountedPathsResponse.ProtoReflect.Descriptor instead.
 func (*UpdateMountedPathsResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{25}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *UpdateMountedPathsResponse) GetMountedPaths() []string {
@@ -1762,7 +2092,7 @@ type UpdatePIIRequest struct {
 
 func (x *UpdatePIIRequest) Reset() {
 	*x = UpdatePIIRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[26]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1774,7 +2104,7 @@ func (x *UpdatePIIRequest) String() string {
 func (*UpdatePIIRequest) ProtoMessage() {}
 
 func (x *UpdatePIIRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[26]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1787,7 +2117,7 @@ func (x *UpdatePIIRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdatePIIRequest.ProtoReflect.Descriptor instead.
 func (*UpdatePIIRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{26}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *UpdatePIIRequest) GetEnabled() bool {
@@ -1831,7 +2161,7 @@ type PIIConfigResponse struct {
 
 func (x *PIIConfigResponse) Reset() {
 	*x = PIIConfigResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[27]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1843,7 +2173,7 @@ func (x *PIIConfigResponse) String() string {
 func (*PIIConfigResponse) ProtoMessage() {}
 
 func (x *PIIConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[27]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1856,7 +2186,7 @@ func (x *PIIConfigResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PIIConfigResponse.ProtoReflect.Descriptor instead.
 func (*PIIConfigResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{27}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *PIIConfigResponse) GetEnabled() bool {
@@ -1907,7 +2237,7 @@ type UpdateDoclingRequest struct {
 
 func (x *UpdateDoclingRequest) Reset() {
 	*x = UpdateDoclingRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[28]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1919,7 +2249,7 @@ func (x *UpdateDoclingRequest) String() string {
 func (*UpdateDoclingRequest) ProtoMessage() {}
 
 func (x *UpdateDoclingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[28]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1932,7 +2262,7 @@ func (x *UpdateDoclingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateDoclingRequest.ProtoReflect.Descriptor instead.
 func (*UpdateDoclingRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{28}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *UpdateDoclingRequest) GetEnabled() bool {
@@ -1985,7 +2315,7 @@ type DoclingConfigResponse struct {
 
 func (x *DoclingConfigResponse) Reset() {
 	*x = DoclingConfigResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[29]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1997,7 +2327,7 @@ func (x *DoclingConfigResponse) String() string {
 func (*DoclingConfigResponse) ProtoMessage() {}
 
 func (x *DoclingConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[29]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2010,7 +2340,7 @@ func (x *DoclingConfigResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DoclingConfigResponse.ProtoReflect.Descriptor instead.
 func (*DoclingConfigResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{29}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *DoclingConfigResponse) GetEnabled() bool {
@@ -2071,7 +2401,7 @@ type UpdateDistanceRequest struct {
 
 func (x *UpdateDistanceRequest) Reset() {
 	*x = UpdateDistanceRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[30]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2083,7 +2413,7 @@ func (x *UpdateDistanceRequest) String() string {
 func (*UpdateDistanceRequest) ProtoMessage() {}
 
 func (x *UpdateDistanceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[30]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2096,7 +2426,7 @@ func (x *UpdateDistanceRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateDistanceRequest.ProtoReflect.Descriptor instead.
 func (*UpdateDistanceRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{30}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *UpdateDistanceRequest) GetDistance() string {
@@ -2116,7 +2446,7 @@ type UpdateDistanceResponse struct {
 
 func (x *UpdateDistanceResponse) Reset() {
 	*x = UpdateDistanceResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[31]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2128,7 +2458,7 @@ func (x *UpdateDistanceResponse) String() string {
 func (*UpdateDistanceResponse) ProtoMessage() {}
 
 func (x *UpdateDistanceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[31]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2141,7 +2471,7 @@ func (x *UpdateDistanceResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateDistanceResponse.ProtoReflect.Descriptor instead.
 func (*UpdateDistanceResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{31}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *UpdateDistanceResponse) GetDistance() string {
@@ -2172,7 +2502,7 @@ type UpdateOllamaRequest struct {
 
 func (x *UpdateOllamaRequest) Reset() {
 	*x = UpdateOllamaRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[32]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2184,7 +2514,7 @@ func (x *UpdateOllamaRequest) String() string {
 func (*UpdateOllamaRequest) ProtoMessage() {}
 
 func (x *UpdateOllamaRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[32]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2197,7 +2527,7 @@ func (x *UpdateOllamaRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateOllamaRequest.ProtoReflect.Descriptor instead.
 func (*UpdateOllamaRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{32}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *UpdateOllamaRequest) GetBaseUrl() string {
@@ -2256,7 +2586,7 @@ type OllamaConfigResponse struct {
 
 func (x *OllamaConfigResponse) Reset() {
 	*x = OllamaConfigResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[33]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2268,7 +2598,7 @@ func (x *OllamaConfigResponse) String() string {
 func (*OllamaConfigResponse) ProtoMessage() {}
 
 func (x *OllamaConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[33]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2281,7 +2611,7 @@ func (x *OllamaConfigResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OllamaConfigResponse.ProtoReflect.Descriptor instead.
 func (*OllamaConfigResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{33}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *OllamaConfigResponse) GetBaseUrl() string {
@@ -2337,7 +2667,7 @@ type UpdateQdrantRequest struct {
 
 func (x *UpdateQdrantRequest) Reset() {
 	*x = UpdateQdrantRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[34]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2349,7 +2679,7 @@ func (x *UpdateQdrantRequest) String() string {
 func (*UpdateQdrantRequest) ProtoMessage() {}
 
 func (x *UpdateQdrantRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[34]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2362,7 +2692,7 @@ func (x *UpdateQdrantRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateQdrantRequest.ProtoReflect.Descriptor instead.
 func (*UpdateQdrantRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{34}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *UpdateQdrantRequest) GetUrl() string {
@@ -2397,7 +2727,7 @@ type QdrantConfigResponse struct {
 
 func (x *QdrantConfigResponse) Reset() {
 	*x = QdrantConfigResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[35]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2409,7 +2739,7 @@ func (x *QdrantConfigResponse) String() string {
 func (*QdrantConfigResponse) ProtoMessage() {}
 
 func (x *QdrantConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[35]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2422,7 +2752,7 @@ func (x *QdrantConfigResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QdrantConfigResponse.ProtoReflect.Descriptor instead.
 func (*QdrantConfigResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{35}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *QdrantConfigResponse) GetUrl() string {
@@ -2457,7 +2787,7 @@ type UpdateChunkingRequest struct {
 
 func (x *UpdateChunkingRequest) Reset() {
 	*x = UpdateChunkingRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[36]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2469,7 +2799,7 @@ func (x *UpdateChunkingRequest) String() string {
 func (*UpdateChunkingRequest) ProtoMessage() {}
 
 func (x *UpdateChunkingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[36]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2482,7 +2812,7 @@ func (x *UpdateChunkingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateChunkingRequest.ProtoReflect.Descriptor instead.
 func (*UpdateChunkingRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{36}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{38}
 }
 
 func (x *UpdateChunkingRequest) GetChunkSize() int32 {
@@ -2517,7 +2847,7 @@ type ChunkingConfigResponse struct {
 
 func (x *ChunkingConfigResponse) Reset() {
 	*x = ChunkingConfigResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[37]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2529,7 +2859,7 @@ func (x *ChunkingConfigResponse) String() string {
 func (*ChunkingConfigResponse) ProtoMessage() {}
 
 func (x *ChunkingConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[37]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2542,7 +2872,7 @@ func (x *ChunkingConfigResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChunkingConfigResponse.ProtoReflect.Descriptor instead.
 func (*ChunkingConfigResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{37}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{39}
 }
 
 func (x *ChunkingConfigResponse) GetChunkSize() int32 {
@@ -2576,7 +2906,7 @@ type UpdateImageRequest struct {
 
 func (x *UpdateImageRequest) Reset() {
 	*x = UpdateImageRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[38]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2588,7 +2918,7 @@ func (x *UpdateImageRequest) String() string {
 func (*UpdateImageRequest) ProtoMessage() {}
 
 func (x *UpdateImageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[38]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2601,7 +2931,7 @@ func (x *UpdateImageRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateImageRequest.ProtoReflect.Descriptor instead.
 func (*UpdateImageRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{38}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *UpdateImageRequest) GetMaxImageSizeKb() int32 {
@@ -2628,7 +2958,7 @@ type ImageConfigResponse struct {
 
 func (x *ImageConfigResponse) Reset() {
 	*x = ImageConfigResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[39]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2640,7 +2970,7 @@ func (x *ImageConfigResponse) String() string {
 func (*ImageConfigResponse) ProtoMessage() {}
 
 func (x *ImageConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[39]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2653,7 +2983,7 @@ func (x *ImageConfigResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ImageConfigResponse.ProtoReflect.Descriptor instead.
 func (*ImageConfigResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{39}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *ImageConfigResponse) GetMaxImageSizeKb() int32 {
@@ -2678,7 +3008,7 @@ type GetPIIConfigRequest struct {
 
 func (x *GetPIIConfigRequest) Reset() {
 	*x = GetPIIConfigRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[40]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2690,7 +3020,7 @@ func (x *GetPIIConfigRequest) String() string {
 func (*GetPIIConfigRequest) ProtoMessage() {}
 
 func (x *GetPIIConfigRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[40]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2703,7 +3033,7 @@ func (x *GetPIIConfigRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPIIConfigRequest.ProtoReflect.Descriptor instead.
 func (*GetPIIConfigRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{40}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{42}
 }
 
 type GetDoclingConfigRequest struct {
@@ -2714,7 +3044,7 @@ type GetDoclingConfigRequest struct {
 
 func (x *GetDoclingConfigRequest) Reset() {
 	*x = GetDoclingConfigRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[41]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2726,7 +3056,7 @@ func (x *GetDoclingConfigRequest) String() string {
 func (*GetDoclingConfigRequest) ProtoMessage() {}
 
 func (x *GetDoclingConfigRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[41]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2739,7 +3069,7 @@ func (x *GetDoclingConfigRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetDoclingConfigRequest.ProtoReflect.Descriptor instead.
 func (*GetDoclingConfigRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{41}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{43}
 }
 
 type ResetConfigRequest struct {
@@ -2752,7 +3082,7 @@ type ResetConfigRequest struct {
 
 func (x *ResetConfigRequest) Reset() {
 	*x = ResetConfigRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[42]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2764,7 +3094,7 @@ func (x *ResetConfigRequest) String() string {
 func (*ResetConfigRequest) ProtoMessage() {}
 
 func (x *ResetConfigRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[42]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2777,7 +3107,7 @@ func (x *ResetConfigRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ResetConfigRequest.ProtoReflect.Descriptor instead.
 func (*ResetConfigRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{42}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *ResetConfigRequest) GetSection() string {
@@ -2804,7 +3134,7 @@ type ResetConfigResponse struct {
 
 func (x *ResetConfigResponse) Reset() {
 	*x = ResetConfigResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[43]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2816,7 +3146,7 @@ func (x *ResetConfigResponse) String() string {
 func (*ResetConfigResponse) ProtoMessage() {}
 
 func (x *ResetConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[43]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2829,7 +3159,7 @@ func (x *ResetConfigResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ResetConfigResponse.ProtoReflect.Descriptor instead.
 func (*ResetConfigResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{43}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *ResetConfigResponse) GetSection() string {
@@ -2856,7 +3186,7 @@ type OverviewRequest struct {
 
 func (x *OverviewRequest) Reset() {
 	*x = OverviewRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[44]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2868,7 +3198,7 @@ func (x *OverviewRequest) String() string {
 func (*OverviewRequest) ProtoMessage() {}
 
 func (x *OverviewRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[44]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2881,7 +3211,7 @@ func (x *OverviewRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OverviewRequest.ProtoReflect.Descriptor instead.
 func (*OverviewRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{44}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{46}
 }
 
 func (x *OverviewRequest) GetCollection() string {
@@ -2916,7 +3246,7 @@ type VisNode struct {
 
 func (x *VisNode) Reset() {
 	*x = VisNode{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[45]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2928,7 +3258,7 @@ func (x *VisNode) String() string {
 func (*VisNode) ProtoMessage() {}
 
 func (x *VisNode) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[45]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2941,7 +3271,7 @@ func (x *VisNode) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VisNode.ProtoReflect.Descriptor instead.
 func (*VisNode) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{45}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{47}
 }
 
 func (x *VisNode) GetId() int32 {
@@ -3024,7 +3354,7 @@ type VisEdge struct {
 
 func (x *VisEdge) Reset() {
 	*x = VisEdge{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[46]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3036,7 +3366,7 @@ func (x *VisEdge) String() string {
 func (*VisEdge) ProtoMessage() {}
 
 func (x *VisEdge) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[46]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3049,7 +3379,7 @@ func (x *VisEdge) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VisEdge.ProtoReflect.Descriptor instead.
 func (*VisEdge) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{46}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *VisEdge) GetFrom() int32 {
@@ -3077,7 +3407,7 @@ type OverviewStats struct {
 
 func (x *OverviewStats) Reset() {
 	*x = OverviewStats{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[47]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3089,7 +3419,7 @@ func (x *OverviewStats) String() string {
 func (*OverviewStats) ProtoMessage() {}
 
 func (x *OverviewStats) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[47]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3102,7 +3432,7 @@ func (x *OverviewStats) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OverviewStats.ProtoReflect.Descriptor instead.
 func (*OverviewStats) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{47}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *OverviewStats) GetTotalFiles() int32 {
@@ -3137,7 +3467,7 @@ type OverviewResponse struct {
 
 func (x *OverviewResponse) Reset() {
 	*x = OverviewResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[48]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3149,7 +3479,7 @@ func (x *OverviewResponse) String() string {
 func (*OverviewResponse) ProtoMessage() {}
 
 func (x *OverviewResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[48]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3162,7 +3492,7 @@ func (x *OverviewResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OverviewResponse.ProtoReflect.Descriptor instead.
 func (*OverviewResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{48}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{50}
 }
 
 func (x *OverviewResponse) GetNodes() []*VisNode {
@@ -3196,7 +3526,7 @@ type FileTreeRequest struct {
 
 func (x *FileTreeRequest) Reset() {
 	*x = FileTreeRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[49]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3208,7 +3538,7 @@ func (x *FileTreeRequest) String() string {
 func (*FileTreeRequest) ProtoMessage() {}
 
 func (x *FileTreeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[49]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3221,7 +3551,7 @@ func (x *FileTreeRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FileTreeRequest.ProtoReflect.Descriptor instead.
 func (*FileTreeRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{49}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{51}
 }
 
 func (x *FileTreeRequest) GetCollection() string {
@@ -3250,7 +3580,7 @@ type FileTreeResponse struct {
 
 func (x *FileTreeResponse) Reset() {
 	*x = FileTreeResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[50]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3262,7 +3592,7 @@ func (x *FileTreeResponse) String() string {
 func (*FileTreeResponse) ProtoMessage() {}
 
 func (x *FileTreeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[50]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3275,7 +3605,7 @@ func (x *FileTreeResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FileTreeResponse.ProtoReflect.Descriptor instead.
 func (*FileTreeResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{50}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{52}
 }
 
 func (x *FileTreeResponse) GetNodes() []*VisNode {
@@ -3318,7 +3648,7 @@ type VectorsRequest struct {
 
 func (x *VectorsRequest) Reset() {
 	*x = VectorsRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[51]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3330,7 +3660,7 @@ func (x *VectorsRequest) String() string {
 func (*VectorsRequest) ProtoMessage() {}
 
 func (x *VectorsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[51]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3343,7 +3673,7 @@ func (x *VectorsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VectorsRequest.ProtoReflect.Descriptor instead.
 func (*VectorsRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{51}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{53}
 }
 
 func (x *VectorsRequest) GetCollection() string {
@@ -3389,7 +3719,7 @@ type VectorPoint struct {
 
 func (x *VectorPoint) Reset() {
 	*x = VectorPoint{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[52]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3401,7 +3731,7 @@ func (x *VectorPoint) String() string {
 func (*VectorPoint) ProtoMessage() {}
 
 func (x *VectorPoint) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[52]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3414,7 +3744,7 @@ func (x *VectorPoint) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VectorPoint.ProtoReflect.Descriptor instead.
 func (*VectorPoint) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{52}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{54}
 }
 
 func (x *VectorPoint) GetX() float64 {
@@ -3479,7 +3809,7 @@ type VectorsResponse struct {
 
 func (x *VectorsResponse) Reset() {
 	*x = VectorsResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[53]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3491,7 +3821,7 @@ func (x *VectorsResponse) String() string {
 func (*VectorsResponse) ProtoMessage() {}
 
 func (x *VectorsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[53]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3504,7 +3834,7 @@ func (x *VectorsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VectorsResponse.ProtoReflect.Descriptor instead.
 func (*VectorsResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{53}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{55}
 }
 
 func (x *VectorsResponse) GetPoints() []*VectorPoint {
@@ -3543,20 +3873,25 @@ func (x *VectorsResponse) GetTotalPoints() int32 {
 }
 
 type SMBTestRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
-	Share         string                 `protobuf:"bytes,2,opt,name=share,proto3" json:"share,omitempty"`
-	Username      string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
-	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
-	Domain        string                 `protobuf:"bytes,5,opt,name=domain,proto3" json:"domain,omitempty"`
-	Port          int32                  `protobuf:"varint,6,opt,name=port,proto3" json:"port,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Server   string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Share    string                 `protobuf:"bytes,2,opt,name=share,proto3" json:"share,omitempty"`
+	Username string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	Password string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
+	Domain   string                 `protobuf:"bytes,5,opt,name=domain,proto3" json:"domain,omitempty"`
+	Port     int32                  `protobuf:"varint,6,opt,name=port,proto3" json:"port,omitempty"`
+	// Sign-in, as in IndexSMBFilesRequest.
+	Auth          string `protobuf:"bytes,7,opt,name=auth,proto3" json:"auth,omitempty"`
+	Realm         string `protobuf:"bytes,8,opt,name=realm,proto3" json:"realm,omitempty"`
+	Kdc           string `protobuf:"bytes,9,opt,name=kdc,proto3" json:"kdc,omitempty"`
+	Keytab        []byte `protobuf:"bytes,10,opt,name=keytab,proto3" json:"keytab,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SMBTestRequest) Reset() {
 	*x = SMBTestRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[54]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3568,7 +3903,7 @@ func (x *SMBTestRequest) String() string {
 func (*SMBTestRequest) ProtoMessage() {}
 
 func (x *SMBTestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[54]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3581,7 +3916,7 @@ func (x *SMBTestRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SMBTestRequest.ProtoReflect.Descriptor instead.
 func (*SMBTestRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{54}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{56}
 }
 
 func (x *SMBTestRequest) GetServer() string {
@@ -3626,6 +3961,34 @@ func (x *SMBTestRequest) GetPort() int32 {
 	return 0
 }
 
+func (x *SMBTestRequest) GetAuth() string {
+	if x != nil {
+		return x.Auth
+	}
+	return ""
+}
+
+func (x *SMBTestRequest) GetRealm() string {
+	if x != nil {
+		return x.Realm
+	}
+	return ""
+}
+
+func (x *SMBTestRequest) GetKdc() string {
+	if x != nil {
+		return x.Kdc
+	}
+	return ""
+}
+
+func (x *SMBTestRequest) GetKeytab() []byte {
+	if x != nil {
+		return x.Keytab
+	}
+	return nil
+}
+
 type SMBTestResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
@@ -3636,7 +3999,7 @@ type SMBTestResponse struct {
 
 func (x *SMBTestResponse) Reset() {
 	*x = SMBTestResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[55]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3648,7 +4011,7 @@ func (x *SMBTestResponse) String() string {
 func (*SMBTestResponse) ProtoMessage() {}
 
 func (x *SMBTestResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[55]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3661,7 +4024,7 @@ func (x *SMBTestResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SMBTestResponse.ProtoReflect.Descriptor instead.
 func (*SMBTestResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{55}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{57}
 }
 
 func (x *SMBTestResponse) GetOk() bool {
@@ -3679,21 +4042,26 @@ func (x *SMBTestResponse) GetMessage() string {
 }
 
 type SMBBrowseRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
-	Share         string                 `protobuf:"bytes,2,opt,name=share,proto3" json:"share,omitempty"`
-	Username      string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
-	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
-	Domain        string                 `protobuf:"bytes,5,opt,name=domain,proto3" json:"domain,omitempty"`
-	Port          int32                  `protobuf:"varint,6,opt,name=port,proto3" json:"port,omitempty"`
-	Path          string                 `protobuf:"bytes,7,opt,name=path,proto3" json:"path,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Server   string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Share    string                 `protobuf:"bytes,2,opt,name=share,proto3" json:"share,omitempty"`
+	Username string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	Password string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
+	Domain   string                 `protobuf:"bytes,5,opt,name=domain,proto3" json:"domain,omitempty"`
+	Port     int32                  `protobuf:"varint,6,opt,name=port,proto3" json:"port,omitempty"`
+	Path     string                 `protobuf:"bytes,7,opt,name=path,proto3" json:"path,omitempty"`
+	// Sign-in, as in IndexSMBFilesRequest.
+	Auth          string `protobuf:"bytes,8,opt,name=auth,proto3" json:"auth,omitempty"`
+	Realm         string `protobuf:"bytes,9,opt,name=realm,proto3" json:"realm,omitempty"`
+	Kdc           string `protobuf:"bytes,10,opt,name=kdc,proto3" json:"kdc,omitempty"`
+	Keytab        []byte `protobuf:"bytes,11,opt,name=keytab,proto3" json:"keytab,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SMBBrowseRequest) Reset() {
 	*x = SMBBrowseRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[56]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3705,7 +4073,7 @@ func (x *SMBBrowseRequest) String() string {
 func (*SMBBrowseRequest) ProtoMessage() {}
 
 func (x *SMBBrowseRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[56]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3718,7 +4086,7 @@ func (x *SMBBrowseRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SMBBrowseRequest.ProtoReflect.Descriptor instead.
 func (*SMBBrowseRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{56}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{58}
 }
 
 func (x *SMBBrowseRequest) GetServer() string {
@@ -3770,6 +4138,34 @@ func (x *SMBBrowseRequest) GetPath() string {
 	return ""
 }
 
+func (x *SMBBrowseRequest) GetAuth() string {
+	if x != nil {
+		return x.Auth
+	}
+	return ""
+}
+
+func (x *SMBBrowseRequest) GetRealm() string {
+	if x != nil {
+		return x.Realm
+	}
+	return ""
+}
+
+func (x *SMBBrowseRequest) GetKdc() string {
+	if x != nil {
+		return x.Kdc
+	}
+	return ""
+}
+
+func (x *SMBBrowseRequest) GetKeytab() []byte {
+	if x != nil {
+		return x.Keytab
+	}
+	return nil
+}
+
 type SMBFileEntry struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -3782,7 +4178,7 @@ type SMBFileEntry struct {
 
 func (x *SMBFileEntry) Reset() {
 	*x = SMBFileEntry{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[57]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3794,7 +4190,7 @@ func (x *SMBFileEntry) String() string {
 func (*SMBFileEntry) ProtoMessage() {}
 
 func (x *SMBFileEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[57]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3807,7 +4203,7 @@ func (x *SMBFileEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SMBFileEntry.ProtoReflect.Descriptor instead.
 func (*SMBFileEntry) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{57}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{59}
 }
 
 func (x *SMBFileEntry) GetName() string {
@@ -3848,7 +4244,7 @@ type SMBBrowseResponse struct {
 
 func (x *SMBBrowseResponse) Reset() {
 	*x = SMBBrowseResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[58]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3860,7 +4256,7 @@ func (x *SMBBrowseResponse) String() string {
 func (*SMBBrowseResponse) ProtoMessage() {}
 
 func (x *SMBBrowseResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[58]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3873,7 +4269,7 @@ func (x *SMBBrowseResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SMBBrowseResponse.ProtoReflect.Descriptor instead.
 func (*SMBBrowseResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{58}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{60}
 }
 
 func (x *SMBBrowseResponse) GetFiles() []*SMBFileEntry {
@@ -3900,7 +4296,7 @@ type LoginRequest struct {
 
 func (x *LoginRequest) Reset() {
 	*x = LoginRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[59]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3912,7 +4308,7 @@ func (x *LoginRequest) String() string {
 func (*LoginRequest) ProtoMessage() {}
 
 func (x *LoginRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[59]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3925,7 +4321,7 @@ func (x *LoginRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
 func (*LoginRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{59}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{61}
 }
 
 func (x *LoginRequest) GetUsername() string {
@@ -3954,7 +4350,7 @@ type LoginResponse struct {
 
 func (x *LoginResponse) Reset() {
 	*x = LoginResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[60]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3966,7 +4362,7 @@ func (x *LoginResponse) String() string {
 func (*LoginResponse) ProtoMessage() {}
 
 func (x *LoginResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[60]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3979,7 +4375,7 @@ func (x *LoginResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LoginResponse.ProtoReflect.Descriptor instead.
 func (*LoginResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{60}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{62}
 }
 
 func (x *LoginResponse) GetSuccess() bool {
@@ -4019,7 +4415,7 @@ type ValidateTokenRequest struct {
 
 func (x *ValidateTokenRequest) Reset() {
 	*x = ValidateTokenRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[61]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4031,7 +4427,7 @@ func (x *ValidateTokenRequest) String() string {
 func (*ValidateTokenRequest) ProtoMessage() {}
 
 func (x *ValidateTokenRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[61]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4044,7 +4440,7 @@ func (x *ValidateTokenRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ValidateTokenRequest.ProtoReflect.Descriptor instead.
 func (*ValidateTokenRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{61}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{63}
 }
 
 func (x *ValidateTokenRequest) GetToken() string {
@@ -4065,7 +4461,7 @@ type ValidateTokenResponse struct {
 
 func (x *ValidateTokenResponse) Reset() {
 	*x = ValidateTokenResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[62]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4077,7 +4473,7 @@ func (x *ValidateTokenResponse) String() string {
 func (*ValidateTokenResponse) ProtoMessage() {}
 
 func (x *ValidateTokenResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[62]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4090,7 +4486,7 @@ func (x *ValidateTokenResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ValidateTokenResponse.ProtoReflect.Descriptor instead.
 func (*ValidateTokenResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{62}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{64}
 }
 
 func (x *ValidateTokenResponse) GetValid() bool {
@@ -4122,7 +4518,7 @@ type ListUsersRequest struct {
 
 func (x *ListUsersRequest) Reset() {
 	*x = ListUsersRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[63]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4134,7 +4530,7 @@ func (x *ListUsersRequest) String() string {
 func (*ListUsersRequest) ProtoMessage() {}
 
 func (x *ListUsersRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[63]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4147,7 +4543,7 @@ func (x *ListUsersRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListUsersRequest.ProtoReflect.Descriptor instead.
 func (*ListUsersRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{63}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{65}
 }
 
 type ListUsersResponse struct {
@@ -4159,7 +4555,7 @@ type ListUsersResponse struct {
 
 func (x *ListUsersResponse) Reset() {
 	*x = ListUsersResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[64]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[66]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4171,7 +4567,7 @@ func (x *ListUsersResponse) String() string {
 func (*ListUsersResponse) ProtoMessage() {}
 
 func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[64]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[66]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4184,7 +4580,7 @@ func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListUsersResponse.ProtoReflect.Descriptor instead.
 func (*ListUsersResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{64}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{66}
 }
 
 func (x *ListUsersResponse) GetUsers() []*User {
@@ -4205,7 +4601,7 @@ type CreateUserRequest struct {
 
 func (x *CreateUserRequest) Reset() {
 	*x = CreateUserRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[65]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[67]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4217,7 +4613,7 @@ func (x *CreateUserRequest) String() string {
 func (*CreateUserRequest) ProtoMessage() {}
 
 func (x *CreateUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[65]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[67]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4230,7 +4626,7 @@ func (x *CreateUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateUserRequest.ProtoReflect.Descriptor instead.
 func (*CreateUserRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{65}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{67}
 }
 
 func (x *CreateUserRequest) GetUsername() string {
@@ -4263,7 +4659,7 @@ type CreateUserResponse struct {
 
 func (x *CreateUserResponse) Reset() {
 	*x = CreateUserResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[66]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[68]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4275,7 +4671,7 @@ func (x *CreateUserResponse) String() string {
 func (*CreateUserResponse) ProtoMessage() {}
 
 func (x *CreateUserResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[66]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[68]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4288,7 +4684,7 @@ func (x *CreateUserResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateUserResponse.ProtoReflect.Descriptor instead.
 func (*CreateUserResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{66}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{68}
 }
 
 func (x *CreateUserResponse) GetUser() *User {
@@ -4307,7 +4703,7 @@ type DeleteUserRequest struct {
 
 func (x *DeleteUserRequest) Reset() {
 	*x = DeleteUserRequest{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[67]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[69]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4319,7 +4715,7 @@ func (x *DeleteUserRequest) String() string {
 func (*DeleteUserRequest) ProtoMessage() {}
 
 func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[67]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[69]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4332,7 +4728,7 @@ func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
 func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{67}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{69}
 }
 
 func (x *DeleteUserRequest) GetUsername() string {
@@ -4352,7 +4748,7 @@ type DeleteUserResponse struct {
 
 func (x *DeleteUserResponse) Reset() {
 	*x = DeleteUserResponse{}
-	mi := &file_ollqd_v1_processing_proto_msgTypes[68]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[70]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4364,7 +4760,7 @@ func (x *DeleteUserResponse) String() string {
 func (*DeleteUserResponse) ProtoMessage() {}
 
 func (x *DeleteUserResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ollqd_v1_processing_proto_msgTypes[68]
+	mi := &file_ollqd_v1_processing_proto_msgTypes[70]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4377,7 +4773,7 @@ func (x *DeleteUserResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteUserResponse.ProtoReflect.Descriptor instead.
 func (*DeleteUserResponse) Descriptor() ([]byte, []int) {
-	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{68}
+	return file_ollqd_v1_processing_proto_rawDescGZIP(), []int{70}
 }
 
 func (x *DeleteUserResponse) GetDeleted() bool {
@@ -4398,7 +4794,7 @@ var File_ollqd_v1_processing_proto protoreflect.FileDescriptor
 
 const file_ollqd_v1_processing_proto_rawDesc = "" +
 	"\n" +
-	"\x19ollqd/v1/processing.proto\x12\bollqd.v1\x1a\x14ollqd/v1/types.proto\"\xe1\x01\n" +
+	"\x19ollqd/v1/processing.proto\x12\bollqd.v1\x1a\x14ollqd/v1/types.proto\"\xad\x02\n" +
 	"\x14IndexCodebaseRequest\x12\x1b\n" +
 	"\troot_path\x18\x01 \x01(\tR\brootPath\x12\x1e\n" +
 	"\n" +
@@ -4408,7 +4804,11 @@ const file_ollqd_v1_processing_proto_rawDesc = "" +
 	"\n" +
 	"chunk_size\x18\x04 \x01(\x05R\tchunkSize\x12#\n" +
 	"\rchunk_overlap\x18\x05 \x01(\x05R\fchunkOverlap\x12&\n" +
-	"\x0fextra_skip_dirs\x18\x06 \x03(\tR\rextraSkipDirs\"\xb0\x01\n" +
+	"\x0fextra_skip_dirs\x18\x06 \x03(\tR\rextraSkipDirs\x12\x14\n" +
+	"\x05files\x18\a \x03(\tR\x05files\x124\n" +
+	"\n" +
+	"provenance\x18\b \x01(\v2\x14.ollqd.v1.ProvenanceR\n" +
+	"provenance\"\xe6\x01\n" +
 	"\x15IndexDocumentsRequest\x12\x14\n" +
 	"\x05paths\x18\x01 \x03(\tR\x05paths\x12\x1e\n" +
 	"\n" +
@@ -4418,7 +4818,10 @@ const file_ollqd_v1_processing_proto_rawDesc = "" +
 	"chunk_size\x18\x03 \x01(\x05R\tchunkSize\x12#\n" +
 	"\rchunk_overlap\x18\x04 \x01(\x05R\fchunkOverlap\x12\x1d\n" +
 	"\n" +
-	"source_tag\x18\x05 \x01(\tR\tsourceTag\"\x90\x02\n" +
+	"source_tag\x18\x05 \x01(\tR\tsourceTag\x124\n" +
+	"\n" +
+	"provenance\x18\x06 \x01(\v2\x14.ollqd.v1.ProvenanceR\n" +
+	"provenance\"\xc6\x02\n" +
 	"\x12IndexImagesRequest\x12\x1b\n" +
 	"\troot_path\x18\x01 \x01(\tR\brootPath\x12\x1e\n" +
 	"\n" +
@@ -4428,7 +4831,10 @@ const file_ollqd_v1_processing_proto_rawDesc = "" +
 	"\x0ecaption_prompt\x18\x04 \x01(\tR\rcaptionPrompt\x12 \n" +
 	"\vincremental\x18\x05 \x01(\bR\vincremental\x12)\n" +
 	"\x11max_image_size_kb\x18\x06 \x01(\x05R\x0emaxImageSizeKb\x12&\n" +
-	"\x0fextra_skip_dirs\x18\a \x03(\tR\rextraSkipDirs\"\x83\x02\n" +
+	"\x0fextra_skip_dirs\x18\a \x03(\tR\rextraSkipDirs\x124\n" +
+	"\n" +
+	"provenance\x18\b \x01(\v2\x14.ollqd.v1.ProvenanceR\n" +
+	"provenance\"\xb9\x02\n" +
 	"\x13IndexUploadsRequest\x12\x1f\n" +
 	"\vsaved_paths\x18\x01 \x03(\tR\n" +
 	"savedPaths\x12\x1e\n" +
@@ -4441,7 +4847,10 @@ const file_ollqd_v1_processing_proto_rawDesc = "" +
 	"\n" +
 	"source_tag\x18\x05 \x01(\tR\tsourceTag\x12!\n" +
 	"\fvision_model\x18\x06 \x01(\tR\vvisionModel\x12%\n" +
-	"\x0ecaption_prompt\x18\a \x01(\tR\rcaptionPrompt\"\xe9\x02\n" +
+	"\x0ecaption_prompt\x18\a \x01(\tR\rcaptionPrompt\x124\n" +
+	"\n" +
+	"provenance\x18\b \x01(\v2\x14.ollqd.v1.ProvenanceR\n" +
+	"provenance\"\xf3\x03\n" +
 	"\x14IndexSMBFilesRequest\x12\x19\n" +
 	"\bshare_id\x18\x01 \x01(\tR\ashareId\x12!\n" +
 	"\fremote_paths\x18\x02 \x03(\tR\vremotePaths\x12\x1e\n" +
@@ -4459,7 +4868,29 @@ const file_ollqd_v1_processing_proto_rawDesc = "" +
 	"\bpassword\x18\n" +
 	" \x01(\tR\bpassword\x12\x16\n" +
 	"\x06domain\x18\v \x01(\tR\x06domain\x12\x12\n" +
-	"\x04port\x18\f \x01(\x05R\x04port\",\n" +
+	"\x04port\x18\f \x01(\x05R\x04port\x12\x12\n" +
+	"\x04auth\x18\r \x01(\tR\x04auth\x12\x14\n" +
+	"\x05realm\x18\x0e \x01(\tR\x05realm\x12\x10\n" +
+	"\x03kdc\x18\x0f \x01(\tR\x03kdc\x12\x16\n" +
+	"\x06keytab\x18\x10 \x01(\fR\x06keytab\x124\n" +
+	"\n" +
+	"provenance\x18\x11 \x01(\v2\x14.ollqd.v1.ProvenanceR\n" +
+	"provenance\"\xd6\x02\n" +
+	"\n" +
+	"Provenance\x12\x16\n" +
+	"\x06schema\x18\x01 \x01(\x05R\x06schema\x12\x1f\n" +
+	"\vsource_type\x18\x02 \x01(\tR\n" +
+	"sourceType\x12\x19\n" +
+	"\bshare_id\x18\x03 \x01(\tR\ashareId\x12\x1a\n" +
+	"\buploader\x18\x04 \x01(\tR\buploader\x12\x1d\n" +
+	"\n" +
+	"indexed_at\x18\x05 \x01(\tR\tindexedAt\x12\x17\n" +
+	"\atask_id\x18\x06 \x01(\tR\x06taskId\x12'\n" +
+	"\x0fgateway_version\x18\a \x01(\tR\x0egatewayVersion\x12;\n" +
+	"\asources\x18\b \x03(\v2!.ollqd.v1.Provenance.SourcesEntryR\asources\x1a:\n" +
+	"\fSourcesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\",\n" +
 	"\x11CancelTaskRequest\x12\x17\n" +
 	"\atask_id\x18\x01 \x01(\tR\x06taskId\"L\n" +
 	"\x12CancelTaskResponse\x12\x1c\n" +
@@ -4484,7 +4915,7 @@ const file_ollqd_v1_processing_proto_rawDesc = "" +
 	"\n" +
 	"collection\x18\x03 \x01(\tR\n" +
 	"collection\x12-\n" +
-	"\aresults\x18\x04 \x03(\v2\x13.ollqd.v1.SearchHitR\aresults\"~\n" +
+	"\aresults\x18\x04 \x03(\v2\x13.ollqd.v1.SearchHitR\aresults\"\xf2\x04\n" +
 	"\vChatRequest\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1e\n" +
 	"\n" +
@@ -4492,7 +4923,29 @@ const file_ollqd_v1_processing_proto_rawDesc = "" +
 	"collection\x12\x14\n" +
 	"\x05model\x18\x03 \x01(\tR\x05model\x12\x1f\n" +
 	"\vpii_enabled\x18\x04 \x01(\bR\n" +
-	"piiEnabled\"\xb5\x01\n" +
+	"piiEnabled\x12%\n" +
+	"\vtemperature\x18\x05 \x01(\x01H\x00R\vtemperature\x88\x01\x01\x12\x18\n" +
+	"\x05top_p\x18\x06 \x01(\x01H\x01R\x04topP\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"max_tokens\x18\a \x01(\x05H\x02R\tmaxTokens\x88\x01\x01\x12\x18\n" +
+	"\x05top_k\x18\b \x01(\x05H\x03R\x04topK\x88\x01\x01\x12#\n" +
+	"\rsystem_prompt\x18\t \x01(\tR\fsystemPrompt\x121\n" +
+	"\x12context_max_tokens\x18\n" +
+	" \x01(\x05H\x04R\x10contextMaxTokens\x88\x01\x01\x12/\n" +
+	"\x11source_max_tokens\x18\v \x01(\x05H\x05R\x0fsourceMaxTokens\x88\x01\x01\x12&\n" +
+	"\fdedupe_files\x18\f \x01(\bH\x06R\vdedupeFiles\x88\x01\x01\x12#\n" +
+	"\rcontext_order\x18\r \x01(\tR\fcontextOrder\x12,\n" +
+	"\ahistory\x18\x0e \x03(\v2\x12.ollqd.v1.ChatTurnR\ahistoryB\x0e\n" +
+	"\f_temperatureB\b\n" +
+	"\x06_top_pB\r\n" +
+	"\v_max_tokensB\b\n" +
+	"\x06_top_kB\x15\n" +
+	"\x13_context_max_tokensB\x14\n" +
+	"\x12_source_max_tokensB\x0f\n" +
+	"\r_dedupe_files\"8\n" +
+	"\bChatTurn\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"\xb5\x01\n" +
 	"\tChatEvent\x12\x12\n" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x12\x18\n" +
 	"\acontent\x18\x02 \x01(\tR\acontent\x12-\n" +
@@ -4728,17 +5181,22 @@ const file_ollqd_v1_processing_proto_rawDesc = "" +
 	"\x06method\x18\x02 \x01(\tR\x06method\x12\x12\n" +
 	"\x04dims\x18\x03 \x01(\x05R\x04dims\x12#\n" +
 	"\roriginal_dims\x18\x04 \x01(\x05R\foriginalDims\x12!\n" +
-	"\ftotal_points\x18\x05 \x01(\x05R\vtotalPoints\"\xa2\x01\n" +
+	"\ftotal_points\x18\x05 \x01(\x05R\vtotalPoints\"\xf6\x01\n" +
 	"\x0eSMBTestRequest\x12\x16\n" +
 	"\x06server\x18\x01 \x01(\tR\x06server\x12\x14\n" +
 	"\x05share\x18\x02 \x01(\tR\x05share\x12\x1a\n" +
 	"\busername\x18\x03 \x01(\tR\busername\x12\x1a\n" +
 	"\bpassword\x18\x04 \x01(\tR\bpassword\x12\x16\n" +
 	"\x06domain\x18\x05 \x01(\tR\x06domain\x12\x12\n" +
-	"\x04port\x18\x06 \x01(\x05R\x04port\";\n" +
+	"\x04port\x18\x06 \x01(\x05R\x04port\x12\x12\n" +
+	"\x04auth\x18\a \x01(\tR\x04auth\x12\x14\n" +
+	"\x05realm\x18\b \x01(\tR\x05realm\x12\x10\n" +
+	"\x03kdc\x18\t \x01(\tR\x03kdc\x12\x16\n" +
+	"\x06keytab\x18\n" +
+	" \x01(\fR\x06keytab\";\n" +
 	"\x0fSMBTestResponse\x12\x0e\n" +
 	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"\xb8\x01\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x8c\x02\n" +
 	"\x10SMBBrowseRequest\x12\x16\n" +
 	"\x06server\x18\x01 \x01(\tR\x06server\x12\x14\n" +
 	"\x05share\x18\x02 \x01(\tR\x05share\x12\x1a\n" +
@@ -4746,7 +5204,12 @@ const file_ollqd_v1_processing_proto_rawDesc = "" +
 	"\bpassword\x18\x04 \x01(\tR\bpassword\x12\x16\n" +
 	"\x06domain\x18\x05 \x01(\tR\x06domain\x12\x12\n" +
 	"\x04port\x18\x06 \x01(\x05R\x04port\x12\x12\n" +
-	"\x04path\x18\a \x01(\tR\x04path\"a\n" +
+	"\x04path\x18\a \x01(\tR\x04path\x12\x12\n" +
+	"\x04auth\x18\b \x01(\tR\x04auth\x12\x14\n" +
+	"\x05realm\x18\t \x01(\tR\x05realm\x12\x10\n" +
+	"\x03kdc\x18\n" +
+	" \x01(\tR\x03kdc\x12\x16\n" +
+	"\x06keytab\x18\v \x01(\fR\x06keytab\"a\n" +
 	"\fSMBFileEntry\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x15\n" +
 	"\x06is_dir\x18\x02 \x01(\bR\x05isDir\x12\x12\n" +
@@ -4846,174 +5309,184 @@ func file_ollqd_v1_processing_proto_rawDescGZIP() []byte {
 	return file_ollqd_v1_processing_proto_rawDescData
 }
 
-var file_ollqd_v1_processing_proto_msgTypes = make([]protoimpl.MessageInfo, 69)
+var file_ollqd_v1_processing_proto_msgTypes = make([]protoimpl.MessageInfo, 72)
 var file_ollqd_v1_processing_proto_goTypes = []any{
 	(*IndexCodebaseRequest)(nil),       // 0: ollqd.v1.IndexCodebaseRequest
 	(*IndexDocumentsRequest)(nil),      // 1: ollqd.v1.IndexDocumentsRequest
 	(*IndexImagesRequest)(nil),         // 2: ollqd.v1.IndexImagesRequest
 	(*IndexUploadsRequest)(nil),        // 3: ollqd.v1.IndexUploadsRequest
 	(*IndexSMBFilesRequest)(nil),       // 4: ollqd.v1.IndexSMBFilesRequest
-	(*CancelTaskRequest)(nil),          // 5: ollqd.v1.CancelTaskRequest
-	(*CancelTaskResponse)(nil),         // 6: ollqd.v1.CancelTaskResponse
-	(*SearchRequest)(nil),              // 7: ollqd.v1.SearchRequest
-	(*SearchCollectionRequest)(nil),    // 8: ollqd.v1.SearchCollectionRequest
-	(*SearchResponse)(nil),             // 9: ollqd.v1.SearchResponse
-	(*ChatRequest)(nil),                // 10: ollqd.v1.ChatRequest
-	(*ChatEvent)(nil),                  // 11: ollqd.v1.ChatEvent
-	(*GetEmbeddingInfoRequest)(nil),    // 12: ollqd.v1.GetEmbeddingInfoRequest
-	(*EmbeddingInfoResponse)(nil),      // 13: ollqd.v1.EmbeddingInfoResponse
-	(*TestEmbedRequest)(nil),           // 14: ollqd.v1.TestEmbedRequest
-	(*TestEmbedResponse)(nil),          // 15: ollqd.v1.TestEmbedResponse
-	(*CompareModelsRequest)(nil),       // 16: ollqd.v1.CompareModelsRequest
-	(*ModelTestResult)(nil),            // 17: ollqd.v1.ModelTestResult
-	(*CompareModelsResponse)(nil),      // 18: ollqd.v1.CompareModelsResponse
-	(*SetEmbedModelRequest)(nil),       // 19: ollqd.v1.SetEmbedModelRequest
-	(*TestMaskingRequest)(nil),         // 20: ollqd.v1.TestMaskingRequest
-	(*PIIEntity)(nil),                  // 21: ollqd.v1.PIIEntity
-	(*TestMaskingResponse)(nil),        // 22: ollqd.v1.TestMaskingResponse
-	(*GetConfigRequest)(nil),           // 23: ollqd.v1.GetConfigRequest
-	(*UpdateMountedPathsRequest)(nil),  // 24: ollqd.v1.UpdateMountedPathsRequest
-	(*UpdateMountedPathsResponse)(nil), // 25: ollqd.v1.UpdateMountedPathsResponse
-	(*UpdatePIIRequest)(nil),           // 26: ollqd.v1.UpdatePIIRequest
-	(*PIIConfigResponse)(nil),          // 27: ollqd.v1.PIIConfigResponse
-	(*UpdateDoclingRequest)(nil),       // 28: ollqd.v1.UpdateDoclingRequest
-	(*DoclingConfigResponse)(nil),      // 29: ollqd.v1.DoclingConfigResponse
-	(*UpdateDistanceRequest)(nil),      // 30: ollqd.v1.UpdateDistanceRequest
-	(*UpdateDistanceResponse)(nil),     // 31: ollqd.v1.UpdateDistanceResponse
-	(*UpdateOllamaRequest)(nil),        // 32: ollqd.v1.UpdateOllamaRequest
-	(*OllamaConfigResponse)(nil),       // 33: ollqd.v1.OllamaConfigResponse
-	(*UpdateQdrantRequest)(nil),        // 34: ollqd.v1.UpdateQdrantRequest
-	(*QdrantConfigResponse)(nil),       // 35: ollqd.v1.QdrantConfigResponse
-	(*UpdateChunkingRequest)(nil),      // 36: ollqd.v1.UpdateChunkingRequest
-	(*ChunkingConfigResponse)(nil),     // 37: ollqd.v1.ChunkingConfigResponse
-	(*UpdateImageRequest)(nil),         // 38: ollqd.v1.UpdateImageRequest
-	(*ImageConfigResponse)(nil),        // 39: ollqd.v1.ImageConfigResponse
-	(*GetPIIConfigRequest)(nil),        // 40: ollqd.v1.GetPIIConfigRequest
-	(*GetDoclingConfigRequest)(nil),    // 41: ollqd.v1.GetDoclingConfigRequest
-	(*ResetConfigRequest)(nil),         // 42: ollqd.v1.ResetConfigRequest
-	(*ResetConfigResponse)(nil),        // 43: ollqd.v1.ResetConfigResponse
-	(*OverviewRequest)(nil),            // 44: ollqd.v1.OverviewRequest
-	(*VisNode)(nil),                    // 45: ollqd.v1.VisNode
-	(*VisEdge)(nil),                    // 46: ollqd.v1.VisEdge
-	(*OverviewStats)(nil),              // 47: ollqd.v1.OverviewStats
-	(*OverviewResponse)(nil),           // 48: ollqd.v1.OverviewResponse
-	(*FileTreeRequest)(nil),            // 49: ollqd.v1.FileTreeRequest
-	(*FileTreeResponse)(nil),           // 50: ollqd.v1.FileTreeResponse
-	(*VectorsRequest)(nil),             // 51: ollqd.v1.VectorsRequest
-	(*VectorPoint)(nil),                // 52: ollqd.v1.VectorPoint
-	(*VectorsResponse)(nil),            // 53: ollqd.v1.VectorsResponse
-	(*SMBTestRequest)(nil),             // 54: ollqd.v1.SMBTestRequest
-	(*SMBTestResponse)(nil),            // 55: ollqd.v1.SMBTestResponse
-	(*SMBBrowseRequest)(nil),           // 56: ollqd.v1.SMBBrowseRequest
-	(*SMBFileEntry)(nil),               // 57: ollqd.v1.SMBFileEntry
-	(*SMBBrowseResponse)(nil),          // 58: ollqd.v1.SMBBrowseResponse
-	(*LoginRequest)(nil),               // 59: ollqd.v1.LoginRequest
-	(*LoginResponse)(nil),              // 60: ollqd.v1.LoginResponse
-	(*ValidateTokenRequest)(nil),       // 61: ollqd.v1.ValidateTokenRequest
-	(*ValidateTokenResponse)(nil),      // 62: ollqd.v1.ValidateTokenResponse
-	(*ListUsersRequest)(nil),           // 63: ollqd.v1.ListUsersRequest
-	(*ListUsersResponse)(nil),          // 64: ollqd.v1.ListUsersResponse
-	(*CreateUserRequest)(nil),          // 65: ollqd.v1.CreateUserRequest
-	(*CreateUserResponse)(nil),         // 66: ollqd.v1.CreateUserResponse
-	(*DeleteUserRequest)(nil),          // 67: ollqd.v1.DeleteUserRequest
-	(*DeleteUserResponse)(nil),         // 68: ollqd.v1.DeleteUserResponse
-	(*SearchHit)(nil),                  // 69: ollqd.v1.SearchHit
-	(*User)(nil),                       // 70: ollqd.v1.User
-	(*TaskProgress)(nil),               // 71: ollqd.v1.TaskProgress
-	(*AppConfig)(nil),                  // 72: ollqd.v1.AppConfig
+	(*Provenance)(nil),                 // 5: ollqd.v1.Provenance
+	(*CancelTaskRequest)(nil),          // 6: ollqd.v1.CancelTaskRequest
+	(*CancelTaskResponse)(nil),         // 7: ollqd.v1.CancelTaskResponse
+	(*SearchRequest)(nil),              // 8: ollqd.v1.SearchRequest
+	(*SearchCollectionRequest)(nil),    // 9: ollqd.v1.SearchCollectionRequest
+	(*SearchResponse)(nil),             // 10: ollqd.v1.SearchResponse
+	(*ChatRequest)(nil),                // 11: ollqd.v1.ChatRequest
+	(*ChatTurn)(nil),                   // 12: ollqd.v1.ChatTurn
+	(*ChatEvent)(nil),                  // 13: ollqd.v1.ChatEvent
+	(*GetEmbeddingInfoRequest)(nil),    // 14: ollqd.v1.GetEmbeddingInfoRequest
+	(*EmbeddingInfoResponse)(nil),      // 15: ollqd.v1.EmbeddingInfoResponse
+	(*TestEmbedRequest)(nil),           // 16: ollqd.v1.TestEmbedRequest
+	(*TestEmbedResponse)(nil),          // 17: ollqd.v1.TestEmbedResponse
+	(*CompareModelsRequest)(nil),       // 18: ollqd.v1.CompareModelsRequest
+	(*ModelTestResult)(nil),            // 19: ollqd.v1.ModelTestResult
+	(*CompareModelsResponse)(nil),      // 20: ollqd.v1.CompareModelsResponse
+	(*SetEmbedModelRequest)(nil),       // 21: ollqd.v1.SetEmbedModelRequest
+	(*TestMaskingRequest)(nil),         // 22: ollqd.v1.TestMaskingRequest
+	(*PIIEntity)(nil),                  // 23: ollqd.v1.PIIEntity
+	(*TestMaskingResponse)(nil),        // 24: ollqd.v1.TestMaskingResponse
+	(*GetConfigRequest)(nil),           // 25: ollqd.v1.GetConfigRequest
+	(*UpdateMountedPathsRequest)(nil),  // 26: ollqd.v1.UpdateMountedPathsRequest
+	(*UpdateMountedPathsResponse)(nil), // 27: ollqd.v1.UpdateMountedPathsResponse
+	(*UpdatePIIRequest)(nil),           // 28: ollqd.v1.UpdatePIIRequest
+	(*PIIConfigResponse)(nil),          // 29: ollqd.v1.PIIConfigResponse
+	(*UpdateDoclingRequest)(nil),       // 30: ollqd.v1.UpdateDoclingRequest
+	(*DoclingConfigResponse)(nil),      // 31: ollqd.v1.DoclingConfigResponse
+	(*UpdateDistanceRequest)(nil),      // 32: ollqd.v1.UpdateDistanceRequest
+	(*UpdateDistanceResponse)(nil),     // 33: ollqd.v1.UpdateDistanceResponse
+	(*UpdateOllamaRequest)(nil),        // 34: ollqd.v1.UpdateOllamaRequest
+	(*OllamaConfigResponse)(nil),       // 35: ollqd.v1.OllamaConfigResponse
+	(*UpdateQdrantRequest)(nil),        // 36: ollqd.v1.UpdateQdrantRequest
+	(*QdrantConfigResponse)(nil),       // 37: ollqd.v1.QdrantConfigResponse
+	(*UpdateChunkingRequest)(nil),      // 38: ollqd.v1.UpdateChunkingRequest
+	(*ChunkingConfigResponse)(nil),     // 39: ollqd.v1.ChunkingConfigResponse
+	(*UpdateImageRequest)(nil),         // 40: ollqd.v1.UpdateImageRequest
+	(*ImageConfigResponse)(nil),        // 41: ollqd.v1.ImageConfigResponse
+	(*GetPIIConfigRequest)(nil),        // 42: ollqd.v1.GetPIIConfigRequest
+	(*GetDoclingConfigRequest)(nil),    // 43: ollqd.v1.GetDoclingConfigRequest
+	(*ResetConfigRequest)(nil),         // 44: ollqd.v1.ResetConfigRequest
+	(*ResetConfigResponse)(nil),        // 45: ollqd.v1.ResetConfigResponse
+	(*OverviewRequest)(nil),            // 46: ollqd.v1.OverviewRequest
+	(*VisNode)(nil),                    // 47: ollqd.v1.VisNode
+	(*VisEdge)(nil),                    // 48: ollqd.v1.VisEdge
+	(*OverviewStats)(nil),              // 49: ollqd.v1.OverviewStats
+	(*OverviewResponse)(nil),           // 50: ollqd.v1.OverviewResponse
+	(*FileTreeRequest)(nil),            // 51: ollqd.v1.FileTreeRequest
+	(*FileTreeResponse)(nil),           // 52: ollqd.v1.FileTreeResponse
+	(*VectorsRequest)(nil),             // 53: ollqd.v1.VectorsRequest
+	(*VectorPoint)(nil),                // 54: ollqd.v1.VectorPoint
+	(*VectorsResponse)(nil),            // 55: ollqd.v1.VectorsResponse
+	(*SMBTestRequest)(nil),             // 56: ollqd.v1.SMBTestRequest
+	(*SMBTestResponse)(nil),            // 57: ollqd.v1.SMBTestResponse
+	(*SMBBrowseRequest)(nil),           // 58: ollqd.v1.SMBBrowseRequest
+	(*SMBFileEntry)(nil),               // 59: ollqd.v1.SMBFileEntry
+	(*SMBBrowseResponse)(nil),          // 60: ollqd.v1.SMBBrowseResponse
+	(*LoginRequest)(nil),               // 61: ollqd.v1.LoginRequest
+	(*LoginResponse)(nil),              // 62: ollqd.v1.LoginResponse
+	(*ValidateTokenRequest)(nil),       // 63: ollqd.v1.ValidateTokenRequest
+	(*ValidateTokenResponse)(nil),      // 64: ollqd.v1.ValidateTokenResponse
+	(*ListUsersRequest)(nil),           // 65: ollqd.v1.ListUsersRequest
+	(*ListUsersResponse)(nil),          // 66: ollqd.v1.ListUsersResponse
+	(*CreateUserRequest)(nil),          // 67: ollqd.v1.CreateUserRequest
+	(*CreateUserResponse)(nil),         // 68: ollqd.v1.CreateUserResponse
+	(*DeleteUserRequest)(nil),          // 69: ollqd.v1.DeleteUserRequest
+	(*DeleteUserResponse)(nil),         // 70: ollqd.v1.DeleteUserResponse
+	nil,                                // 71: ollqd.v1.Provenance.SourcesEntry
+	(*SearchHit)(nil),                  // 72: ollqd.v1.SearchHit
+	(*User)(nil),                       // 73: ollqd.v1.User
+	(*TaskProgress)(nil),               // 74: ollqd.v1.TaskProgress
+	(*AppConfig)(nil),                  // 75: ollqd.v1.AppConfig
 }
 var file_ollqd_v1_processing_proto_depIdxs = []int32{
-	69, // 0: ollqd.v1.SearchResponse.results:type_name -> ollqd.v1.SearchHit
-	69, // 1: ollqd.v1.ChatEvent.sources:type_name -> ollqd.v1.SearchHit
-	17, // 2: ollqd.v1.CompareModelsResponse.model1:type_name -> ollqd.v1.ModelTestResult
-	17, // 3: ollqd.v1.CompareModelsResponse.model2:type_name -> ollqd.v1.ModelTestResult
-	21, // 4: ollqd.v1.TestMaskingResponse.entities:type_name -> ollqd.v1.PIIEntity
-	45, // 5: ollqd.v1.OverviewResponse.nodes:type_name -> ollqd.v1.VisNode
-	46, // 6: ollqd.v1.OverviewResponse.edges:type_name -> ollqd.v1.VisEdge
-	47, // 7: ollqd.v1.OverviewResponse.stats:type_name -> ollqd.v1.OverviewStats
-	45, // 8: ollqd.v1.FileTreeResponse.nodes:type_name -> ollqd.v1.VisNode
-	46, // 9: ollqd.v1.FileTreeResponse.edges:type_name -> ollqd.v1.VisEdge
-	52, // 10: ollqd.v1.VectorsResponse.points:type_name -> ollqd.v1.VectorPoint
-	57, // 11: ollqd.v1.SMBBrowseResponse.files:type_name -> ollqd.v1.SMBFileEntry
-	70, // 12: ollqd.v1.ListUsersResponse.users:type_name -> ollqd.v1.User
-	70, // 13: ollqd.v1.CreateUserResponse.user:type_name -> ollqd.v1.User
-	0,  // 14: ollqd.v1.IndexingService.IndexCodebase:input_type -> ollqd.v1.IndexCodebaseRequest
-	1,  // 15: ollqd.v1.IndexingService.IndexDocuments:input_type -> ollqd.v1.IndexDocumentsRequest
-	2,  // 16: ollqd.v1.IndexingService.IndexImages:input_type -> ollqd.v1.IndexImagesRequest
-	3,  // 17: ollqd.v1.IndexingService.IndexUploads:input_type -> ollqd.v1.IndexUploadsRequest
-	4,  // 18: ollqd.v1.IndexingService.IndexSMBFiles:input_type -> ollqd.v1.IndexSMBFilesRequest
-	5,  // 19: ollqd.v1.IndexingService.CancelTask:input_type -> ollqd.v1.CancelTaskRequest
-	7,  // 20: ollqd.v1.SearchService.Search:input_type -> ollqd.v1.SearchRequest
-	8,  // 21: ollqd.v1.SearchService.SearchCollection:input_type -> ollqd.v1.SearchCollectionRequest
-	10, // 22: ollqd.v1.ChatService.Chat:input_type -> ollqd.v1.ChatRequest
-	12, // 23: ollqd.v1.EmbeddingService.GetInfo:input_type -> ollqd.v1.GetEmbeddingInfoRequest
-	14, // 24: ollqd.v1.EmbeddingService.TestEmbed:input_type -> ollqd.v1.TestEmbedRequest
-	16, // 25: ollqd.v1.EmbeddingService.CompareModels:input_type -> ollqd.v1.CompareModelsRequest
-	19, // 26: ollqd.v1.EmbeddingService.SetModel:input_type -> ollqd.v1.SetEmbedModelRequest
-	20, // 27: ollqd.v1.PIIService.TestMasking:input_type -> ollqd.v1.TestMaskingRequest
-	23, // 28: ollqd.v1.ConfigService.GetConfig:input_type -> ollqd.v1.GetConfigRequest
-	24, // 29: ollqd.v1.ConfigService.UpdateMountedPaths:input_type -> ollqd.v1.UpdateMountedPathsRequest
-	26, // 30: ollqd.v1.ConfigService.UpdatePII:input_type -> ollqd.v1.UpdatePIIRequest
-	28, // 31: ollqd.v1.ConfigService.UpdateDocling:input_type -> ollqd.v1.UpdateDoclingRequest
-	30, // 32: ollqd.v1.ConfigService.UpdateDistance:input_type -> ollqd.v1.UpdateDistanceRequest
-	32, // 33: ollqd.v1.ConfigService.UpdateOllama:input_type -> ollqd.v1.UpdateOllamaRequest
-	34, // 34: ollqd.v1.ConfigService.UpdateQdrant:input_type -> ollqd.v1.UpdateQdrantRequest
-	36, // 35: ollqd.v1.ConfigService.UpdateChunking:input_type -> ollqd.v1.UpdateChunkingRequest
-	38, // 36: ollqd.v1.ConfigService.UpdateImage:input_type -> ollqd.v1.UpdateImageRequest
-	40, // 37: ollqd.v1.ConfigService.GetPIIConfig:input_type -> ollqd.v1.GetPIIConfigRequest
-	41, // 38: ollqd.v1.ConfigService.GetDoclingConfig:input_type -> ollqd.v1.GetDoclingConfigRequest
-	42, // 39: ollqd.v1.ConfigService.ResetConfig:input_type -> ollqd.v1.ResetConfigRequest
-	44, // 40: ollqd.v1.VisualizationService.Overview:input_type -> ollqd.v1.OverviewRequest
-	49, // 41: ollqd.v1.VisualizationService.FileTree:input_type -> ollqd.v1.FileTreeRequest
-	51, // 42: ollqd.v1.VisualizationService.Vectors:input_type -> ollqd.v1.VectorsRequest
-	54, // 43: ollqd.v1.SMBService.TestConnection:input_type -> ollqd.v1.SMBTestRequest
-	56, // 44: ollqd.v1.SMBService.Browse:input_type -> ollqd.v1.SMBBrowseRequest
-	59, // 45: ollqd.v1.AuthService.Login:input_type -> ollqd.v1.LoginRequest
-	61, // 46: ollqd.v1.AuthService.ValidateToken:input_type -> ollqd.v1.ValidateTokenRequest
-	63, // 47: ollqd.v1.AuthService.ListUsers:input_type -> ollqd.v1.ListUsersRequest
-	65, // 48: ollqd.v1.AuthService.CreateUser:input_type -> ollqd.v1.CreateUserRequest
-	67, // 49: ollqd.v1.AuthService.DeleteUser:input_type -> ollqd.v1.DeleteUserRequest
-	71, // 50: ollqd.v1.IndexingService.IndexCodebase:output_type -> ollqd.v1.TaskProgress
-	71, // 51: ollqd.v1.IndexingService.IndexDocuments:output_type -> ollqd.v1.TaskProgress
-	71, // 52: ollqd.v1.IndexingService.IndexImages:output_type -> ollqd.v1.TaskProgress
-	71, // 53: ollqd.v1.IndexingService.IndexUploads:output_type -> ollqd.v1.TaskProgress
-	71, // 54: ollqd.v1.IndexingService.IndexSMBFiles:output_type -> ollqd.v1.TaskProgress
-	6,  // 55: ollqd.v1.IndexingService.CancelTask:output_type -> ollqd.v1.CancelTaskResponse
-	9,  // 56: ollqd.v1.SearchService.Search:output_type -> ollqd.v1.SearchResponse
-	9,  // 57: ollqd.v1.SearchService.SearchCollection:output_type -> ollqd.v1.SearchResponse
-	11, // 58: ollqd.v1.ChatService.Chat:output_type -> ollqd.v1.ChatEvent
-	13, // 59: ollqd.v1.EmbeddingService.GetInfo:output_type -> ollqd.v1.EmbeddingInfoResponse
-	15, // 60: ollqd.v1.EmbeddingService.TestEmbed:output_type -> ollqd.v1.TestEmbedResponse
-	18, // 61: ollqd.v1.EmbeddingService.CompareModels:output_type -> ollqd.v1.CompareModelsResponse
-	13, // 62: ollqd.v1.EmbeddingService.SetModel:output_type -> ollqd.v1.EmbeddingInfoResponse
-	22, // 63: ollqd.v1.PIIService.TestMasking:output_type -> ollqd.v1.TestMaskingResponse
-	72, // 64: ollqd.v1.ConfigService.GetConfig:output_type -> ollqd.v1.AppConfig
-	25, // 65: ollqd.v1.ConfigService.UpdateMountedPaths:output_type -> ollqd.v1.UpdateMountedPathsResponse
-	27, // 66: ollqd.v1.ConfigService.UpdatePII:output_type -> ollqd.v1.PIIConfigResponse
-	29, // 67: ollqd.v1.ConfigService.UpdateDocling:output_type -> ollqd.v1.DoclingConfigResponse
-	31, // 68: ollqd.v1.ConfigService.UpdateDistance:output_type -> ollqd.v1.UpdateDistanceResponse
-	33, // 69: ollqd.v1.ConfigService.UpdateOllama:output_type -> ollqd.v1.OllamaConfigResponse
-	35, // 70: ollqd.v1.ConfigService.UpdateQdrant:output_type -> ollqd.v1.QdrantConfigResponse
-	37, // 71: ollqd.v1.ConfigService.UpdateChunking:output_type -> ollqd.v1.ChunkingConfigResponse
-	39, // 72: ollqd.v1.ConfigService.UpdateImage:output_type -> ollqd.v1.ImageConfigResponse
-	27, // 73: ollqd.v1.ConfigService.GetPIIConfig:output_type -> ollqd.v1.PIIConfigResponse
-	29, // 74: ollqd.v1.ConfigService.GetDoclingConfig:output_type -> ollqd.v1.DoclingConfigResponse
-	43, // 75: ollqd.v1.ConfigService.ResetConfig:output_type -> ollqd.v1.ResetConfigResponse
-	48, // 76: ollqd.v1.VisualizationService.Overview:output_type -> ollqd.v1.OverviewResponse
-	50, // 77: ollqd.v1.VisualizationService.FileTree:output_type -> ollqd.v1.FileTreeResponse
-	53, // 78: ollqd.v1.VisualizationService.Vectors:output_type -> ollqd.v1.VectorsResponse
-	55, // 79: ollqd.v1.SMBService.TestConnection:output_type -> ollqd.v1.SMBTestResponse
-	58, // 80: ollqd.v1.SMBService.Browse:output_type -> ollqd.v1.SMBBrowseResponse
-	60, // 81: ollqd.v1.AuthService.Login:output_type -> ollqd.v1.LoginResponse
-	62, // 82: ollqd.v1.AuthService.ValidateToken:output_type -> ollqd.v1.ValidateTokenResponse
-	64, // 83: ollqd.v1.AuthService.ListUsers:output_type -> ollqd.v1.ListUsersResponse
-	66, // 84: ollqd.v1.AuthService.CreateUser:output_type -> ollqd.v1.CreateUserResponse
-	68, // 85: ollqd.v1.AuthService.DeleteUser:output_type -> ollqd.v1.DeleteUserResponse
-	50, // [50:86] is the sub-list for method output_type
-	14, // [14:50] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	5,  // 0: ollqd.v1.IndexCodebaseRequest.provenance:type_name -> ollqd.v1.Provenance
+	5,  // 1: ollqd.v1.IndexDocumentsRequest.provenance:type_name -> ollqd.v1.Provenance
+	5,  // 2: ollqd.v1.IndexImagesRequest.provenance:type_name -> ollqd.v1.Provenance
+	5,  // 3: ollqd.v1.IndexUploadsRequest.provenance:type_name -> ollqd.v1.Provenance
+	5,  // 4: ollqd.v1.IndexSMBFilesRequest.provenance:type_name -> ollqd.v1.Provenance
+	71, // 5: ollqd.v1.Provenance.sources:type_name -> ollqd.v1.Provenance.SourcesEntry
+	72, // 6: ollqd.v1.SearchResponse.results:type_name -> ollqd.v1.SearchHit
+	12, // 7: ollqd.v1.ChatRequest.history:type_name -> ollqd.v1.ChatTurn
+	72, // 8: ollqd.v1.ChatEvent.sources:type_name -> ollqd.v1.SearchHit
+	19, // 9: ollqd.v1.CompareModelsResponse.model1:type_name -> ollqd.v1.ModelTestResult
+	19, // 10: ollqd.v1.CompareModelsResponse.model2:type_name -> ollqd.v1.ModelTestResult
+	23, // 11: ollqd.v1.TestMaskingResponse.entities:type_name -> ollqd.v1.PIIEntity
+	47, // 12: ollqd.v1.OverviewResponse.nodes:type_name -> ollqd.v1.VisNode
+	48, // 13: ollqd.v1.OverviewResponse.edges:type_name -> ollqd.v1.VisEdge
+	49, // 14: ollqd.v1.OverviewResponse.stats:type_name -> ollqd.v1.OverviewStats
+	47, // 15: ollqd.v1.FileTreeResponse.nodes:type_name -> ollqd.v1.VisNode
+	48, // 16: ollqd.v1.FileTreeResponse.edges:type_name -> ollqd.v1.VisEdge
+	54, // 17: ollqd.v1.VectorsResponse.points:type_name -> ollqd.v1.VectorPoint
+	59, // 18: ollqd.v1.SMBBrowseResponse.files:type_name -> ollqd.v1.SMBFileEntry
+	73, // 19: ollqd.v1.ListUsersResponse.users:type_name -> ollqd.v1.User
+	73, // 20: ollqd.v1.CreateUserResponse.user:type_name -> ollqd.v1.User
+	0,  // 21: ollqd.v1.IndexingService.IndexCodebase:input_type -> ollqd.v1.IndexCodebaseRequest
+	1,  // 22: ollqd.v1.IndexingService.IndexDocuments:input_type -> ollqd.v1.IndexDocumentsRequest
+	2,  // 23: ollqd.v1.IndexingService.IndexImages:input_type -> ollqd.v1.IndexImagesRequest
+	3,  // 24: ollqd.v1.IndexingService.IndexUploads:input_type -> ollqd.v1.IndexUploadsRequest
+	4,  // 25: ollqd.v1.IndexingService.IndexSMBFiles:input_type -> ollqd.v1.IndexSMBFilesRequest
+	6,  // 26: ollqd.v1.IndexingService.CancelTask:input_type -> ollqd.v1.CancelTaskRequest
+	8,  // 27: ollqd.v1.SearchService.Search:input_type -> ollqd.v1.SearchRequest
+	9,  // 28: ollqd.v1.SearchService.SearchCollection:input_type -> ollqd.v1.SearchCollectionRequest
+	11, // 29: ollqd.v1.ChatService.Chat:input_type -> ollqd.v1.ChatRequest
+	14, // 30: ollqd.v1.EmbeddingService.GetInfo:input_type -> ollqd.v1.GetEmbeddingInfoRequest
+	16, // 31: ollqd.v1.EmbeddingService.TestEmbed:input_type -> ollqd.v1.TestEmbedRequest
+	18, // 32: ollqd.v1.EmbeddingService.CompareModels:input_type -> ollqd.v1.CompareModelsRequest
+	21, // 33: ollqd.v1.EmbeddingService.SetModel:input_type -> ollqd.v1.SetEmbedModelRequest
+	22, // 34: ollqd.v1.PIIService.TestMasking:input_type -> ollqd.v1.TestMaskingRequest
+	25, // 35: ollqd.v1.ConfigService.GetConfig:input_type -> ollqd.v1.GetConfigRequest
+	26, // 36: ollqd.v1.ConfigService.UpdateMountedPaths:input_type -> ollqd.v1.UpdateMountedPathsRequest
+	28, // 37: ollqd.v1.ConfigService.UpdatePII:input_type -> ollqd.v1.UpdatePIIRequest
+	30, // 38: ollqd.v1.ConfigService.UpdateDocling:input_type -> ollqd.v1.UpdateDoclingRequest
+	32, // 39: ollqd.v1.ConfigService.UpdateDistance:input_type -> ollqd.v1.UpdateDistanceRequest
+	34, // 40: ollqd.v1.ConfigService.UpdateOllama:input_type -> ollqd.v1.UpdateOllamaRequest
+	36, // 41: ollqd.v1.ConfigService.UpdateQdrant:input_type -> ollqd.v1.UpdateQdrantRequest
+	38, // 42: ollqd.v1.ConfigService.UpdateChunking:input_type -> ollqd.v1.UpdateChunkingRequest
+	40, // 43: ollqd.v1.ConfigService.UpdateImage:input_type -> ollqd.v1.UpdateImageRequest
+	42, // 44: ollqd.v1.ConfigService.GetPIIConfig:input_type -> ollqd.v1.GetPIIConfigRequest
+	43, // 45: ollqd.v1.ConfigService.GetDoclingConfig:input_type -> ollqd.v1.GetDoclingConfigRequest
+	44, // 46: ollqd.v1.ConfigService.ResetConfig:input_type -> ollqd.v1.ResetConfigRequest
+	46, // 47: ollqd.v1.VisualizationService.Overview:input_type -> ollqd.v1.OverviewRequest
+	51, // 48: ollqd.v1.VisualizationService.FileTree:input_type -> ollqd.v1.FileTreeRequest
+	53, // 49: ollqd.v1.VisualizationService.Vectors:input_type -> ollqd.v1.VectorsRequest
+	56, // 50: ollqd.v1.SMBService.TestConnection:input_type -> ollqd.v1.SMBTestRequest
+	58, // 51: ollqd.v1.SMBService.Browse:input_type -> ollqd.v1.SMBBrowseRequest
+	61, // 52: ollqd.v1.AuthService.Login:input_type -> ollqd.v1.LoginRequest
+	63, // 53: ollqd.v1.AuthService.ValidateToken:input_type -> ollqd.v1.ValidateTokenRequest
+	65, // 54: ollqd.v1.AuthService.ListUsers:input_type -> ollqd.v1.ListUsersRequest
+	67, // 55: ollqd.v1.AuthService.CreateUser:input_type -> ollqd.v1.CreateUserRequest
+	69, // 56: ollqd.v1.AuthService.DeleteUser:input_type -> ollqd.v1.DeleteUserRequest
+	74, // 57: ollqd.v1.IndexingService.IndexCodebase:output_type -> ollqd.v1.TaskProgress
+	74, // 58: ollqd.v1.IndexingService.IndexDocuments:output_type -> ollqd.v1.TaskProgress
+	74, // 59: ollqd.v1.IndexingService.IndexImages:output_type -> ollqd.v1.TaskProgress
+	74, // 60: ollqd.v1.IndexingService.IndexUploads:output_type -> ollqd.v1.TaskProgress
+	74, // 61: ollqd.v1.IndexingService.IndexSMBFiles:output_type -> ollqd.v1.TaskProgress
+	7,  // 62: ollqd.v1.IndexingService.CancelTask:output_type -> ollqd.v1.CancelTaskResponse
+	10, // 63: ollqd.v1.SearchService.Search:output_type -> ollqd.v1.SearchResponse
+	10, // 64: ollqd.v1.SearchService.SearchCollection:output_type -> ollqd.v1.SearchResponse
+	13, // 65: ollqd.v1.ChatService.Chat:output_type -> ollqd.v1.ChatEvent
+	15, // 66: ollqd.v1.EmbeddingService.GetInfo:output_type -> ollqd.v1.EmbeddingInfoResponse
+	17, // 67: ollqd.v1.EmbeddingService.TestEmbed:output_type -> ollqd.v1.TestEmbedResponse
+	20, // 68: ollqd.v1.EmbeddingService.CompareModels:output_type -> ollqd.v1.CompareModelsResponse
+	15, // 69: ollqd.v1.EmbeddingService.SetModel:output_type -> ollqd.v1.EmbeddingInfoResponse
+	24, // 70: ollqd.v1.PIIService.TestMasking:output_type -> ollqd.v1.TestMaskingResponse
+	75, // 71: ollqd.v1.ConfigService.GetConfig:output_type -> ollqd.v1.AppConfig
+	27, // 72: ollqd.v1.ConfigService.UpdateMountedPaths:output_type -> ollqd.v1.UpdateMountedPathsResponse
+	29, // 73: ollqd.v1.ConfigService.UpdatePII:output_type -> ollqd.v1.PIIConfigResponse
+	31, // 74: ollqd.v1.ConfigService.UpdateDocling:output_type -> ollqd.v1.DoclingConfigResponse
+	33, // 75: ollqd.v1.ConfigService.UpdateDistance:output_type -> ollqd.v1.UpdateDistanceResponse
+	35, // 76: ollqd.v1.ConfigService.UpdateOllama:output_type -> ollqd.v1.OllamaConfigResponse
+	37, // 77: ollqd.v1.ConfigService.UpdateQdrant:output_type -> ollqd.v1.QdrantConfigResponse
+	39, // 78: ollqd.v1.ConfigService.UpdateChunking:output_type -> ollqd.v1.ChunkingConfigResponse
+	41, // 79: ollqd.v1.ConfigService.UpdateImage:output_type -> ollqd.v1.ImageConfigResponse
+	29, // 80: ollqd.v1.ConfigService.GetPIIConfig:output_type -> ollqd.v1.PIIConfigResponse
+	31, // 81: ollqd.v1.ConfigService.GetDoclingConfig:output_type -> ollqd.v1.DoclingConfigResponse
+	45, // 82: ollqd.v1.ConfigService.ResetConfig:output_type -> ollqd.v1.ResetConfigResponse
+	50, // 83: ollqd.v1.VisualizationService.Overview:output_type -> ollqd.v1.OverviewResponse
+	52, // 84: ollqd.v1.VisualizationService.FileTree:output_type -> ollqd.v1.FileTreeResponse
+	55, // 85: ollqd.v1.VisualizationService.Vectors:output_type -> ollqd.v1.VectorsResponse
+	57, // 86: ollqd.v1.SMBService.TestConnection:output_type -> ollqd.v1.SMBTestResponse
+	60, // 87: ollqd.v1.SMBService.Browse:output_type -> ollqd.v1.SMBBrowseResponse
+	62, // 88: ollqd.v1.AuthService.Login:output_type -> ollqd.v1.LoginResponse
+	64, // 89: ollqd.v1.AuthService.ValidateToken:output_type -> ollqd.v1.ValidateTokenResponse
+	66, // 90: ollqd.v1.AuthService.ListUsers:output_type -> ollqd.v1.ListUsersResponse
+	68, // 91: ollqd.v1.AuthService.CreateUser:output_type -> ollqd.v1.CreateUserResponse
+	70, // 92: ollqd.v1.AuthService.DeleteUser:output_type -> ollqd.v1.DeleteUserResponse
+	57, // [57:93] is the sub-list for method output_type
+	21, // [21:57] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_ollqd_v1_processing_proto_init() }
@@ -5022,19 +5495,20 @@ func file_ollqd_v1_processing_proto_init() {
 		return
 	}
 	file_ollqd_v1_types_proto_init()
-	file_ollqd_v1_processing_proto_msgTypes[26].OneofWrappers = []any{}
+	file_ollqd_v1_processing_proto_msgTypes[11].OneofWrappers = []any{}
 	file_ollqd_v1_processing_proto_msgTypes[28].OneofWrappers = []any{}
-	file_ollqd_v1_processing_proto_msgTypes[32].OneofWrappers = []any{}
+	file_ollqd_v1_processing_proto_msgTypes[30].OneofWrappers = []any{}
 	file_ollqd_v1_processing_proto_msgTypes[34].OneofWrappers = []any{}
 	file_ollqd_v1_processing_proto_msgTypes[36].OneofWrappers = []any{}
 	file_ollqd_v1_processing_proto_msgTypes[38].OneofWrappers = []any{}
+	file_ollqd_v1_processing_proto_msgTypes[40].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ollqd_v1_processing_proto_rawDesc), len(file_ollqd_v1_processing_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   69,
+			NumMessages:   72,
 			NumExtensions: 0,
 			NumServices:   9,
 		},
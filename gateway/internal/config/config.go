@@ -5,32 +5,192 @@ import (
 	"encoding/hex"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds all gateway configuration loaded from environment variables.
 type Config struct {
-	ListenAddr      string // HTTP listen address
-	WorkerAddr      string // Python gRPC worker address
-	OllamaURL       string // Ollama API base URL
-	QdrantURL       string // Qdrant API base URL
-	UploadDir       string // Directory for uploaded files
-	MaxUploadSizeMB int64  // Maximum upload size in megabytes
-	DockerSocket    string // Docker socket path for container management
-	JWTSecret       string // Secret key for signing JWT tokens
+	ListenAddr           string   // HTTP listen address
+	WorkerAddr           string   // Python gRPC worker address
+	OllamaURL            string   // Ollama API base URL
+	QdrantURL            string   // Qdrant API base URL
+	QdrantAPIKey         string   // API key sent to Qdrant as the api-key header ("" = none)
+	QdrantCAFile         string   // PEM CA bundle trusted for Qdrant's certificate
+	QdrantInsecure       bool     // Skip verification of Qdrant's certificate
+	OllamaTimeout        int64    // Seconds a non-streaming Ollama API call may take (0 = none)
+	QdrantTimeout        int64    // Seconds a request to Qdrant may take (0 = none)
+	HTTPMaxIdlePerHost   int      // Idle keep-alive connections kept per Ollama/Qdrant host
+	HTTPIdleTimeout      int64    // Seconds an idle Ollama/Qdrant connection is kept
+	HTTPDialTimeout      int64    // Seconds opening an Ollama/Qdrant connection may take
+	HTTPGetRetries       int      // Times a failed GET to Ollama/Qdrant is retried (0 = never)
+	UploadDir            string   // Directory for uploaded files
+	MaxUploadSizeMB      int64    // Maximum upload size in megabytes
+	DockerSocket         string   // Docker socket path for container management
+	OllamaUpdateCheck    bool     // Look up the latest Ollama release for GET /api/ollama/version
+	OllamaReleasesURL    string   // Latest-release endpoint the update check asks
+	JWTSecret            string   // Secret key for signing JWT tokens
+	AuthMode             string   // "disabled", "optional" or "required"
+	AuthPublicPaths      []string // Extra paths reachable without a token ("/x/*" = subtree)
+	ArtifactDir          string   // Directory for persisted task result artifacts
+	WorkerTimeout        int64    // Default deadline in seconds for unary worker calls (0 = none)
+	WorkerTimeoutMax     int64    // Upper bound in seconds for client-supplied X-Timeout-Seconds
+	TrustedProxies       []string // CIDRs/IPs whose X-Forwarded-* headers are honoured
+	BasePath             string   // URL prefix when mounted under a sub-path, e.g. "/ollqd"
+	MaxConcurrentTasks   int      // Index tasks allowed to run at once (0 = unlimited)
+	MaxConcurrentPulls   int      // Ollama model pulls allowed to run at once (0 = unlimited)
+	PullRetries          int      // Times an interrupted model pull is resumed automatically (0 = never)
+	RegistryMirror       string   // Registry host[:port] that models without a registry are pulled from ("" = none)
+	RegistryInsecure     bool     // Let Ollama pull from the mirror over plain HTTP or with an untrusted certificate
+	RegistryCAFile       string   // PEM CA bundle the gateway trusts when checking the mirror
+	URLFetchAllowPrivate bool     // Allow upload-from-URL to reach private/loopback addresses
+	DataDir              string   // Directory for persisted gateway settings
+	DefaultCollection    string   // Collection index requests use when none is given
+	GRPCListenAddr       string   // Listen address for the gateway gRPC task API ("" = disabled)
+	GRPCAuthToken        string   // Bearer token required by the gRPC task API ("" = none)
+	TaskRedactParams     []string // Extra task request param keys masked in task listings
+	TaskParamsRetention  string   // How long task params are kept after completion ("" = forever)
+	TaskStallMinutes     int64    // Minutes without progress before a running task is flagged stalled (0 = off)
+	TaskStallAutoCancel  bool     // Cancel tasks as soon as they are flagged stalled
+	WorkerRestartRetries int      // Times a task reopens its stream after the worker drops it (0 = fail at once)
+	WorkerRestartBackoff int64    // Milliseconds before the first reopen, doubling with each attempt
+	KeywordFallback      bool     // Answer searches with keyword results when the worker fails
+	SearchCheckTTL       int64    // Seconds a collection found to hold points skips the pre-search check (0 = no check)
+	DebugEndpoints       bool     // Serve pprof and runtime diagnostics under /api/system/debug (admin only)
+	UploadFilenames      string   // How uploads are named in UploadDir: UploadNamesUUID or UploadNamesPreserve
+	DrainDelay           int64    // Seconds /readyz fails before shutdown starts, so traffic moves away
+	ShutdownTimeout      int64    // Seconds in-flight requests get to finish on shutdown
+	DrainToken           string   // Token that lets non-loopback callers use /prestop ("" = loopback only)
+	PluginsDisabled      []string // Names of compiled-in plugins to leave off
+	ImageURLTTLMinutes   int64    // Minutes a signed /api/rag/image URL stays valid
+	GuardMode            string   // Resource guardrails before index tasks: GuardRefuse, GuardWarn or GuardOff
+	GuardMinFreeDiskMB   int64    // Free disk required in UPLOAD_DIR and the Qdrant storage dir (0 = no minimum)
+	GuardMaxMemoryPct    int64    // Memory use above which index tasks are refused, in percent (0 = unchecked)
+	GuardQdrantDir       string   // Qdrant storage directory as mounted in the gateway ("" = unchecked)
+	ImportMaxMB          int64    // Maximum collection archive size accepted by import, in megabytes
+	RedisURL             string   // Redis shared by gateway replicas in cluster mode ("" = single replica)
+	RedisPrefix          string   // Prefix of the gateway's Redis keys and channels
+	ClusterNodeID        string   // Name of this replica in cluster mode; unique per replica
+	ClusterTaskTTLHours  int64    // Hours a task's shared state outlives its last update
+	AuditMaxEntries      int      // Audit log entries kept and searchable (0 = no audit log)
+	CaptureMaxEntries    int      // Exchanges kept by the admin capture mode (0 = capture mode unavailable)
+	CaptureMaxBodyKB     int64    // Default size up to which capture mode keeps each request and response body
+	RetentionMinutes     int64    // Minutes between runs of the collection retention policies (0 = manual runs only)
+	ChatGroundingMinPct  int64    // Grounding score, in percent, below which a chat answer is flagged (0 = answers not scored)
+	ChatContextMaxTokens int64    // Default token budget of the retrieved chunks in a chat prompt (0 = no limit)
+	ChatSourceMaxTokens  int64    // Default tokens each retrieved chunk may fill in a chat prompt (0 = no limit)
+	ChatDedupeFiles      bool     // By default keep only the best chunk of each file in a chat prompt
+	ChatContextOrder     string   // Default order of the chunks in a chat prompt: "score" or "recency"
+	ChatFlushMS          int64    // Milliseconds streamed answer chunks are held to be sent together (0 = no time trigger)
+	ChatFlushBytes       int64    // Bytes of streamed answer chunks that are sent together (0 = no size trigger)
 }
 
+// Upload file naming modes (UPLOAD_FILENAMES).
+const (
+	// UploadNamesUUID saves every file as UPLOAD_DIR/<uuid>.<ext>.
+	UploadNamesUUID = "uuid"
+	// UploadNamesPreserve saves the files of one upload under
+	// UPLOAD_DIR/<uuid>/ with their sanitized original names and
+	// directories, so chunk sources show meaningful paths.
+	UploadNamesPreserve = "preserve"
+)
+
+// Resource guardrail modes (GUARD_MODE).
+const (
+	// GuardRefuse rejects index and upload tasks when disk or memory is
+	// short.
+	GuardRefuse = "refuse"
+	// GuardWarn starts them anyway and reports the shortage as task
+	// warnings.
+	GuardWarn = "warn"
+	// GuardOff skips the checks.
+	GuardOff = "off"
+)
+
 // Load reads configuration from environment variables, falling back to defaults.
 func Load() *Config {
 	return &Config{
-		ListenAddr:      envOrDefault("LISTEN_ADDR", ":8000"),
-		WorkerAddr:      envOrDefault("WORKER_ADDR", "localhost:50051"),
-		OllamaURL:       envOrDefault("OLLAMA_URL", "http://localhost:11434"),
-		QdrantURL:       envOrDefault("QDRANT_URL", "http://localhost:6333"),
-		UploadDir:       envOrDefault("UPLOAD_DIR", "/uploads"),
-		MaxUploadSizeMB: envOrDefaultInt64("MAX_UPLOAD_SIZE_MB", 50),
-		DockerSocket:    envOrDefault("DOCKER_SOCKET", "/var/run/docker.sock"),
-		JWTSecret:       envOrDefault("JWT_SECRET", randomSecret()),
+		ListenAddr:           envOrDefault("LISTEN_ADDR", ":8000"),
+		WorkerAddr:           envOrDefault("WORKER_ADDR", "localhost:50051"),
+		OllamaURL:            envOrDefault("OLLAMA_URL", "http://localhost:11434"),
+		QdrantURL:            envOrDefault("QDRANT_URL", "http://localhost:6333"),
+		QdrantAPIKey:         os.Getenv("QDRANT_API_KEY"),
+		QdrantCAFile:         os.Getenv("QDRANT_CA_FILE"),
+		QdrantInsecure:       os.Getenv("QDRANT_TLS_INSECURE") == "true",
+		OllamaTimeout:        envOrDefaultInt64("OLLAMA_HTTP_TIMEOUT_S", 60),
+		QdrantTimeout:        envOrDefaultInt64("QDRANT_HTTP_TIMEOUT_S", 60),
+		HTTPMaxIdlePerHost:   int(envOrDefaultInt64("HTTP_MAX_IDLE_CONNS_PER_HOST", 32)),
+		HTTPIdleTimeout:      envOrDefaultInt64("HTTP_IDLE_CONN_TIMEOUT_S", 90),
+		HTTPDialTimeout:      envOrDefaultInt64("HTTP_DIAL_TIMEOUT_S", 5),
+		HTTPGetRetries:       int(envOrDefaultInt64("HTTP_GET_RETRIES", 2)),
+		UploadDir:            envOrDefault("UPLOAD_DIR", "/uploads"),
+		MaxUploadSizeMB:      envOrDefaultInt64("MAX_UPLOAD_SIZE_MB", 50),
+		DockerSocket:         envOrDefault("DOCKER_SOCKET", "/var/run/docker.sock"),
+		OllamaUpdateCheck:    os.Getenv("OLLAMA_UPDATE_CHECK") == "true",
+		OllamaReleasesURL:    envOrDefault("OLLAMA_RELEASES_URL", "https://api.github.com/repos/ollama/ollama/releases/latest"),
+		JWTSecret:            envOrDefault("JWT_SECRET", randomSecret()),
+		AuthMode:             envOrDefault("AUTH_MODE", "required"),
+		AuthPublicPaths:      envList("AUTH_PUBLIC_PATHS"),
+		ArtifactDir:          envOrDefault("ARTIFACT_DIR", "/uploads/.artifacts"),
+		WorkerTimeout:        envOrDefaultInt64("WORKER_TIMEOUT_S", 60),
+		WorkerTimeoutMax:     envOrDefaultInt64("WORKER_TIMEOUT_MAX_S", 600),
+		TrustedProxies:       envList("TRUSTED_PROXIES"),
+		BasePath:             strings.TrimRight(os.Getenv("BASE_PATH"), "/"),
+		MaxConcurrentTasks:   int(envOrDefaultInt64("MAX_CONCURRENT_TASKS", 2)),
+		MaxConcurrentPulls:   int(envOrDefaultInt64("OLLAMA_PULL_CONCURRENCY", 1)),
+		PullRetries:          int(envOrDefaultInt64("OLLAMA_PULL_RETRIES", 3)),
+		RegistryMirror:       strings.TrimRight(strings.TrimSpace(os.Getenv("OLLAMA_REGISTRY_MIRROR")), "/"),
+		RegistryInsecure:     os.Getenv("OLLAMA_REGISTRY_INSECURE") == "true",
+		RegistryCAFile:       os.Getenv("OLLAMA_REGISTRY_CA_FILE"),
+		URLFetchAllowPrivate: os.Getenv("URL_FETCH_ALLOW_PRIVATE") == "true",
+		DataDir:              envOrDefault("DATA_DIR", "/uploads/.gateway"),
+		DefaultCollection:    os.Getenv("DEFAULT_COLLECTION"),
+		GRPCListenAddr:       os.Getenv("GRPC_LISTEN_ADDR"),
+		GRPCAuthToken:        os.Getenv("GRPC_AUTH_TOKEN"),
+		TaskRedactParams:     envList("TASK_REDACT_PARAMS"),
+		TaskParamsRetention:  os.Getenv("TASK_PARAMS_RETENTION"),
+		TaskStallMinutes:     envOrDefaultInt64("TASK_STALL_MINUTES", 15),
+		TaskStallAutoCancel:  os.Getenv("TASK_STALL_AUTO_CANCEL") == "true",
+		WorkerRestartRetries: int(envOrDefaultInt64("WORKER_RESTART_RETRIES", 3)),
+		WorkerRestartBackoff: envOrDefaultInt64("WORKER_RESTART_BACKOFF_MS", 1000),
+		KeywordFallback:      os.Getenv("SEARCH_KEYWORD_FALLBACK") == "true",
+		SearchCheckTTL:       envOrDefaultInt64("SEARCH_COLLECTION_CHECK_TTL_S", 30),
+		DebugEndpoints:       os.Getenv("DEBUG_ENDPOINTS") == "true",
+		UploadFilenames:      envOrDefault("UPLOAD_FILENAMES", UploadNamesUUID),
+		DrainDelay:           envOrDefaultInt64("DRAIN_DELAY_S", 5),
+		ShutdownTimeout:      envOrDefaultInt64("SHUTDOWN_TIMEOUT_S", 10),
+		DrainToken:           os.Getenv("DRAIN_TOKEN"),
+		PluginsDisabled:      envList("PLUGINS_DISABLED"),
+		ImageURLTTLMinutes:   envOrDefaultInt64("IMAGE_URL_TTL_MINUTES", 60),
+		GuardMode:            envOrDefault("GUARD_MODE", GuardRefuse),
+		GuardMinFreeDiskMB:   envOrDefaultInt64("GUARD_MIN_FREE_DISK_MB", 1024),
+		GuardMaxMemoryPct:    envOrDefaultInt64("GUARD_MAX_MEMORY_PERCENT", 95),
+		GuardQdrantDir:       os.Getenv("GUARD_QDRANT_STORAGE_DIR"),
+		ImportMaxMB:          envOrDefaultInt64("COLLECTION_IMPORT_MAX_MB", 2048),
+		RedisURL:             os.Getenv("REDIS_URL"),
+		RedisPrefix:          envOrDefault("REDIS_PREFIX", "ollqd"),
+		ClusterNodeID:        envOrDefault("CLUSTER_NODE_ID", hostname()),
+		ClusterTaskTTLHours:  envOrDefaultInt64("CLUSTER_TASK_TTL_HOURS", 24),
+		AuditMaxEntries:      int(envOrDefaultInt64("AUDIT_MAX_ENTRIES", 10000)),
+		CaptureMaxEntries:    int(envOrDefaultInt64("CAPTURE_MAX_ENTRIES", 200)),
+		CaptureMaxBodyKB:     envOrDefaultInt64("CAPTURE_MAX_BODY_KB", 64),
+		RetentionMinutes:     envOrDefaultInt64("RETENTION_INTERVAL_MINUTES", 15),
+		ChatGroundingMinPct:  envOrDefaultInt64("CHAT_GROUNDING_MIN_PERCENT", 50),
+		ChatContextMaxTokens: envOrDefaultInt64("CHAT_CONTEXT_MAX_TOKENS", 0),
+		ChatSourceMaxTokens:  envOrDefaultInt64("CHAT_SOURCE_MAX_TOKENS", 0),
+		ChatDedupeFiles:      os.Getenv("CHAT_CONTEXT_DEDUPE_FILES") == "true",
+		ChatContextOrder:     os.Getenv("CHAT_CONTEXT_ORDER"),
+		ChatFlushMS:          envOrDefaultInt64("CHAT_STREAM_FLUSH_MS", 0),
+		ChatFlushBytes:       envOrDefaultInt64("CHAT_STREAM_FLUSH_BYTES", 0),
+	}
+}
+
+// hostname returns the machine's host name, which is the pod name under
+// Kubernetes, or "gateway" if it cannot be read.
+func hostname() string {
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
 	}
+	return "gateway"
 }
 
 func randomSecret() string {
@@ -46,6 +206,18 @@ func envOrDefault(key, fallback string) string {
 	return fallback
 }
 
+// envList splits a comma-separated environment variable into trimmed,
+// non-empty entries.
+func envList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 func envOrDefaultInt64(key string, fallback int64) int64 {
 	v := os.Getenv(key)
 	if v == "" {
@@ -0,0 +1,276 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ContainerInfo describes a container and the image it runs.
+type ContainerInfo struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Image   string `json:"image"`    // reference it was created from, e.g. "ollama/ollama:latest"
+	ImageID string `json:"image_id"` // ID of the image it runs
+}
+
+// UpdateResult reports the outcome of UpdateContainer.
+type UpdateResult struct {
+	Updated         bool   `json:"updated"`
+	Image           string `json:"image"`
+	PreviousImageID string `json:"previous_image_id"`
+	ImageID         string `json:"image_id"`
+	ContainerID     string `json:"container_id"`
+}
+
+// containerJSON is the part of GET /containers/{id}/json the update needs.
+// Config and HostConfig are kept raw so they are recreated unchanged.
+type containerJSON struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Image  string `json:"Image"`
+	Config struct {
+		Image    string `json:"Image"`
+		Hostname string `json:"Hostname"`
+	} `json:"Config"`
+	State struct {
+		Status string `json:"Status"`
+	} `json:"State"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			Aliases []string `json:"Aliases"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// Inspect returns the named container and its image, or nil if there is
+// no such container.
+func (m *Manager) Inspect(ctx context.Context, name string) (*ContainerInfo, error) {
+	c, _, err := m.inspect(ctx, name)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	return &ContainerInfo{
+		ID:      c.ID,
+		Name:    strings.TrimPrefix(c.Name, "/"),
+		Status:  c.State.Status,
+		Image:   c.Config.Image,
+		ImageID: c.Image,
+	}, nil
+}
+
+// inspect returns the decoded and the raw inspect document of a container.
+func (m *Manager) inspect(ctx context.Context, name string) (*containerJSON, map[string]json.RawMessage, error) {
+	id, err := m.findContainer(ctx, name)
+	if err != nil || id == "" {
+		return nil, nil, err
+	}
+	resp, err := m.doRequest(ctx, "GET", fmt.Sprintf("/containers/%s/json", id), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("inspect container: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("inspect: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	var c containerJSON
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, nil, fmt.Errorf("decode inspect: %w", err)
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("decode inspect: %w", err)
+	}
+	return &c, raw, nil
+}
+
+// imageID returns the ID of a local image.
+func (m *Manager) imageID(ctx context.Context, ref string) (string, error) {
+	resp, err := m.doRequest(ctx, "GET", "/images/"+ref+"/json", nil)
+	if err != nil {
+		return "", fmt.Errorf("inspect image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("inspect image %s: unexpected status %d", ref, resp.StatusCode)
+	}
+	var img struct {
+		ID string `json:"Id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&img); err != nil {
+		return "", fmt.Errorf("decode image: %w", err)
+	}
+	return img.ID, nil
+}
+
+// splitImageRef splits "registry:5000/ns/name:tag" into the image and its
+// tag; a reference without a tag means "latest".
+func splitImageRef(ref string) (image, tag string) {
+	slash := strings.LastIndex(ref, "/")
+	if colon := strings.LastIndex(ref, ":"); colon > slash {
+		return ref[:colon], ref[colon+1:]
+	}
+	return ref, "latest"
+}
+
+// UpdateContainer pulls the image the named container runs, or image if
+// given, and recreates the container from it with the same configuration,
+// mounts and networks when the pulled image differs. The old container is
+// kept, renamed, until the new one runs; if the new one cannot be created
+// or started, the old one is restored.
+func (m *Manager) UpdateContainer(ctx context.Context, name, image string) (*UpdateResult, error) {
+	old, raw, err := m.inspect(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, fmt.Errorf("container %q not found", name)
+	}
+	if image == "" {
+		image = old.Config.Image
+	}
+	if strings.Contains(image, "@") {
+		return nil, fmt.Errorf("image %s is pinned by digest; give a tag to update to", image)
+	}
+	res := &UpdateResult{Image: image, PreviousImageID: old.Image, ContainerID: old.ID}
+
+	repo, tag := splitImageRef(image)
+	if err := m.pullImage(ctx, url.QueryEscape(repo), url.QueryEscape(tag)); err != nil {
+		return nil, fmt.Errorf("pull %s: %w", image, err)
+	}
+	if res.ImageID, err = m.imageID(ctx, repo+":"+tag); err != nil {
+		return nil, err
+	}
+	if res.ImageID == old.Image {
+		return res, nil
+	}
+
+	spec, err := recreateSpec(old, raw, repo+":"+tag)
+	if err != nil {
+		return nil, err
+	}
+	wasRunning := old.State.Status == "running"
+	if err := m.StopContainer(ctx, name); err != nil {
+		return nil, err
+	}
+	backup := fmt.Sprintf("%s-old-%d", name, time.Now().Unix())
+	if err := m.containerAction(ctx, "POST", fmt.Sprintf("/containers/%s/rename?name=%s", old.ID, backup), http.StatusNoContent); err != nil {
+		m.restore(ctx, old.ID, "", wasRunning)
+		return nil, err
+	}
+
+	newID, err := m.create(ctx, name, spec)
+	if err == nil {
+		err = m.containerAction(ctx, "POST", fmt.Sprintf("/containers/%s/start", newID), http.StatusNoContent, http.StatusNotModified)
+	}
+	if err != nil {
+		if newID != "" {
+			m.containerAction(ctx, "DELETE", fmt.Sprintf("/containers/%s?force=true", newID), http.StatusNoContent)
+		}
+		m.restore(ctx, old.ID, name, wasRunning)
+		return nil, fmt.Errorf("recreate container: %w; previous container restored", err)
+	}
+
+	// Volumes are kept: v=false leaves them for the new container.
+	m.containerAction(ctx, "DELETE", fmt.Sprintf("/containers/%s?v=false", old.ID), http.StatusNoContent)
+	res.Updated = true
+	res.ContainerID = newID
+	return res, nil
+}
+
+// recreateSpec builds the create request for a container like c running
+// image. Per-container values Docker derived from the old container, such
+// as its hostname and network aliases, are left for Docker to derive anew.
+func recreateSpec(c *containerJSON, raw map[string]json.RawMessage, image string) (map[string]interface{}, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal(raw["Config"], &config); err != nil {
+		return nil, fmt.Errorf("decode container config: %w", err)
+	}
+	config["Image"] = image
+	if strings.HasPrefix(c.ID, c.Config.Hostname) {
+		delete(config, "Hostname")
+	}
+	var hostConfig interface{}
+	if err := json.Unmarshal(raw["HostConfig"], &hostConfig); err != nil {
+		return nil, fmt.Errorf("decode host config: %w", err)
+	}
+	config["HostConfig"] = hostConfig
+
+	endpoints := make(map[string]interface{}, len(c.NetworkSettings.Networks))
+	for network, settings := range c.NetworkSettings.Networks {
+		var aliases []string
+		for _, a := range settings.Aliases {
+			if !strings.HasPrefix(c.ID, a) {
+				aliases = append(aliases, a)
+			}
+		}
+		endpoints[network] = map[string]interface{}{"Aliases": aliases}
+	}
+	config["NetworkingConfig"] = map[string]interface{}{"EndpointsConfig": endpoints}
+	return config, nil
+}
+
+// create creates a container and returns its ID.
+func (m *Manager) create(ctx context.Context, name string, spec map[string]interface{}) (string, error) {
+	body, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("marshal spec: %w", err)
+	}
+	resp, err := m.doRequest(ctx, "POST", fmt.Sprintf("/containers/create?name=%s", name), strings.NewReader(string(body)))
+	if err != nil {
+		return "", fmt.Errorf("create container: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("create: status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		return "", fmt.Errorf("decode create: %w", err)
+	}
+	return created.ID, nil
+}
+
+// restore gives the old container its name back, if name is set, and
+// starts it again if it was running. It runs after a failure, so errors
+// are not reported.
+func (m *Manager) restore(ctx context.Context, id, name string, start bool) {
+	if name != "" {
+		m.containerAction(ctx, "POST", fmt.Sprintf("/containers/%s/rename?name=%s", id, name), http.StatusNoContent)
+	}
+	if start {
+		m.containerAction(ctx, "POST", fmt.Sprintf("/containers/%s/start", id), http.StatusNoContent, http.StatusNotModified)
+	}
+}
+
+// containerAction sends a bodiless request and checks its status.
+func (m *Manager) containerAction(ctx context.Context, method, path string, ok ...int) error {
+	resp, err := m.doRequest(ctx, method, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	for _, code := range ok {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %s: status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+}
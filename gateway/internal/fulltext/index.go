@@ -0,0 +1,245 @@
+// Package fulltext is a small in-memory inverted index for searching the
+// gateway's own records, such as tasks and audit entries, by the words in
+// their fields. Documents are ranked with BM25.
+package fulltext
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Doc is a document to index. Fields maps field names to their text; At
+// is the time the document is about, used by the after: and before:
+// filters and to order equal scores.
+type Doc struct {
+	ID     string
+	At     time.Time
+	Fields map[string]string
+}
+
+// Hit is a document that matched a query. Fields are the fields any query
+// term was found in, sorted.
+type Hit struct {
+	ID     string
+	At     time.Time
+	Score  float64
+	Fields []string
+}
+
+// indexedDoc is the term counts of one document.
+type indexedDoc struct {
+	at     time.Time
+	length int
+	terms  map[string]map[string]int // term -> field -> count
+}
+
+// Index is a full-text index. It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[string]*indexedDoc
+	postings map[string]map[string]struct{} // term -> document IDs
+	total    int                            // sum of document lengths
+}
+
+// New creates an empty Index.
+func New() *Index {
+	return &Index{
+		docs:     make(map[string]*indexedDoc),
+		postings: make(map[string]map[string]struct{}),
+	}
+}
+
+// Len returns the number of indexed documents.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// Put indexes doc, replacing any document with the same ID.
+func (x *Index) Put(doc Doc) {
+	d := &indexedDoc{at: doc.At, terms: make(map[string]map[string]int)}
+	for field, text := range doc.Fields {
+		for _, term := range Tokenize(text) {
+			fields := d.terms[term]
+			if fields == nil {
+				fields = make(map[string]int)
+				d.terms[term] = fields
+			}
+			fields[field]++
+			d.length++
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeLocked(doc.ID)
+	x.docs[doc.ID] = d
+	x.total += d.length
+	for term := range d.terms {
+		ids := x.postings[term]
+		if ids == nil {
+			ids = make(map[string]struct{})
+			x.postings[term] = ids
+		}
+		ids[doc.ID] = struct{}{}
+	}
+}
+
+// Remove drops a document from the index.
+func (x *Index) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeLocked(id)
+}
+
+func (x *Index) removeLocked(id string) {
+	d, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	for term := range d.terms {
+		ids := x.postings[term]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(x.postings, term)
+		}
+	}
+	x.total -= d.length
+	delete(x.docs, id)
+}
+
+// IDs returns the IDs of all indexed documents.
+func (x *Index) IDs() []string {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	ids := make([]string, 0, len(x.docs))
+	for id := range x.docs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Search returns the documents matching q, best first, and how many
+// matched in all. Documents with equal scores, and all documents of a query
+// with only date filters, come newest first. At most limit hits are
+// returned; limit <= 0 returns them all.
+func (x *Index) Search(q Query, limit int) ([]Hit, int) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	var candidates map[string]struct{}
+	matches := make([]map[string]bool, len(q.Terms)) // per term: vocabulary terms it matches
+	for i, qt := range q.Terms {
+		matches[i] = x.vocabularyLocked(qt)
+		found := make(map[string]struct{})
+		for term := range matches[i] {
+			for id := range x.postings[term] {
+				if qt.Field == "" || x.docs[id].terms[term][qt.Field] > 0 {
+					found[id] = struct{}{}
+				}
+			}
+		}
+		if candidates == nil {
+			candidates = found
+			continue
+		}
+		for id := range candidates {
+			if _, ok := found[id]; !ok {
+				delete(candidates, id)
+			}
+		}
+	}
+	if len(q.Terms) == 0 {
+		candidates = make(map[string]struct{}, len(x.docs))
+		for id := range x.docs {
+			candidates[id] = struct{}{}
+		}
+	}
+
+	n := float64(len(x.docs))
+	avgLen := 1.0
+	if len(x.docs) > 0 && x.total > 0 {
+		avgLen = float64(x.total) / n
+	}
+	hits := make([]Hit, 0, len(candidates))
+	for id := range candidates {
+		d := x.docs[id]
+		if (!q.After.IsZero() && d.at.Before(q.After)) || (!q.Before.IsZero() && !d.at.Before(q.Before)) {
+			continue
+		}
+		hit := Hit{ID: id, At: d.at}
+		matched := make(map[string]bool)
+		for i, qt := range q.Terms {
+			for term := range matches[i] {
+				for field, tf := range d.terms[term] {
+					if qt.Field != "" && field != qt.Field {
+						continue
+					}
+					matched[field] = true
+					df := float64(len(x.postings[term]))
+					idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+					norm := 1 - bm25B + bm25B*float64(d.length)/avgLen
+					hit.Score += idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + bm25K1*norm)
+				}
+			}
+		}
+		for field := range matched {
+			hit.Fields = append(hit.Fields, field)
+		}
+		sort.Strings(hit.Fields)
+		hit.Score = math.Round(hit.Score*1000) / 1000
+		hits = append(hits, hit)
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !hits[i].At.Equal(hits[j].At) {
+			return hits[i].At.After(hits[j].At)
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	total := len(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, total
+}
+
+// vocabularyLocked returns the indexed terms a query term matches: itself,
+// or every term it is a prefix of.
+func (x *Index) vocabularyLocked(qt Term) map[string]bool {
+	out := make(map[string]bool)
+	if !qt.Prefix {
+		if _, ok := x.postings[qt.Text]; ok {
+			out[qt.Text] = true
+		}
+		return out
+	}
+	for term := range x.postings {
+		if strings.HasPrefix(term, qt.Text) {
+			out[term] = true
+		}
+	}
+	return out
+}
+
+// Tokenize splits text into lowercase terms at every character that is
+// not a letter or digit, so "/data/reports-2024" gives "data", "reports"
+// and "2024".
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
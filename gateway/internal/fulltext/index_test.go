@@ -0,0 +1,146 @@
+package fulltext
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var day = time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)
+
+func testIndex() *Index {
+	x := New()
+	x.Put(Doc{ID: "t1", At: day, Fields: map[string]string{"status": "failed", "error": "embedding model not found"}})
+	x.Put(Doc{ID: "t2", At: day.Add(time.Hour), Fields: map[string]string{"status": "completed", "path": "/data/reports-2024"}})
+	x.Put(Doc{ID: "t3", At: day.AddDate(0, 0, 1), Fields: map[string]string{"status": "failed", "error": "qdrant timeout; qdrant unreachable"}})
+	x.Put(Doc{ID: "t4", At: day.AddDate(0, 0, 2), Fields: map[string]string{"status": "completed", "path": "/data/qdrant/notes about the qdrant qdrant cluster setup and more"}})
+	return x
+}
+
+func hitIDs(hits []Hit) []string {
+	ids := make([]string, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", []string{}},
+		{"Hello, World", []string{"hello", "world"}},
+		{"/data/reports-2024", []string{"data", "reports", "2024"}},
+		{"Café_Menü", []string{"café", "menü"}},
+		{"  --  ", []string{}},
+	}
+	for _, tt := range tests {
+		got := Tokenize(tt.in)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	fields := []string{"status", "error", "path"}
+	tests := []struct {
+		query  string
+		want   []string
+		fields [][]string
+	}{
+		// Every term must match.
+		{"failed qdrant", []string{"t3"}, [][]string{{"error", "status"}}},
+		// More occurrences in a document of average length rank first;
+		// t4's long path dilutes its three.
+		{"qdrant", []string{"t3", "t4"}, nil},
+		{"embedding", []string{"t1"}, [][]string{{"error"}}},
+		// Field restriction; with equal counts the shorter document wins.
+		{"status:completed", []string{"t2", "t4"}, nil},
+		{"error:completed", []string{}, nil},
+		// Prefix terms.
+		{"embed*", []string{"t1"}, nil},
+		{"qd*", []string{"t3", "t4"}, nil},
+		// Paths split like indexed text.
+		{"/data/reports", []string{"t2"}, [][]string{{"path"}}},
+		// Date filters only: newest first.
+		{"after:2024-05-08", []string{"t4", "t3"}, nil},
+		{"after:2024-05-07 before:2024-05-07", []string{"t2", "t1"}, nil},
+		{"missing", []string{}, nil},
+	}
+	x := testIndex()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query, fields, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			hits, total := x.Search(q, 0)
+			if got := hitIDs(hits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search = %v, want %v", got, tt.want)
+			}
+			if total != len(tt.want) {
+				t.Errorf("total = %d, want %d", total, len(tt.want))
+			}
+			for i, f := range tt.fields {
+				if !reflect.DeepEqual(hits[i].Fields, f) {
+					t.Errorf("hit %s fields = %v, want %v", hits[i].ID, hits[i].Fields, f)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchLimit(t *testing.T) {
+	x := testIndex()
+	hits, total := x.Search(Query{}, 2)
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []string{"t4", "t3"}) {
+		t.Errorf("Search = %v, want the two newest", got)
+	}
+	if total != 4 {
+		t.Errorf("total = %d, want 4", total)
+	}
+}
+
+func TestPutReplacesAndRemoveDeletes(t *testing.T) {
+	x := testIndex()
+	x.Put(Doc{ID: "t1", At: day, Fields: map[string]string{"status": "completed"}})
+	x.Remove("t3")
+	x.Remove("nope")
+
+	if x.Len() != 3 {
+		t.Errorf("Len = %d, want 3", x.Len())
+	}
+	for query, want := range map[string][]string{
+		"embedding": {},
+		"failed":    {},
+		"timeout":   {},
+		"completed": {"t1", "t2", "t4"},
+	} {
+		hits, _ := x.Search(Query{Terms: []Term{{Text: query}}}, 0)
+		if got := hitIDs(hits); !reflect.DeepEqual(got, want) {
+			t.Errorf("Search(%q) = %v, want %v", query, got, want)
+		}
+	}
+	if _, ok := x.postings["timeout"]; ok {
+		t.Error("postings of a removed document remain")
+	}
+	total := 0
+	for _, d := range x.docs {
+		total += d.length
+	}
+	if x.total != total {
+		t.Errorf("total length = %d, want %d", x.total, total)
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	if _, err := ParseQuery("after:yesterday", nil, time.UTC); err == nil {
+		t.Error("ParseQuery accepted an invalid date")
+	}
+}
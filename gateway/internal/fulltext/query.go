@@ -0,0 +1,93 @@
+package fulltext
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Query is a parsed search query. A document matches when every term is
+// found in it and it lies within the date range.
+type Query struct {
+	Terms  []Term
+	After  time.Time // inclusive; zero = open
+	Before time.Time // exclusive; zero = open
+}
+
+// Term is one word of a query. Field restricts it to one field; Prefix
+// matches every word starting with Text.
+type Term struct {
+	Field  string
+	Text   string
+	Prefix bool
+}
+
+// Empty reports whether q matches every document.
+func (q Query) Empty() bool {
+	return len(q.Terms) == 0 && q.After.IsZero() && q.Before.IsZero()
+}
+
+// ParseQuery parses a query such as
+//
+//	status:failed /data/reports after:2024-05-07 embed*
+//
+// Words are split like indexed text, so "/data/reports" asks for both
+// "data" and "reports". "field:words" restricts the words to one of the
+// given fields; other words with a colon, like URLs, are plain words. A
+// trailing "*" matches by prefix. after: and before: take a date
+// (YYYY-MM-DD, in loc) or an RFC 3339 time; both include a date given as
+// such, so "after:2024-05-07 before:2024-05-07" is that day.
+func ParseQuery(raw string, fields []string, loc *time.Location) (Query, error) {
+	var q Query
+	for _, word := range strings.Fields(raw) {
+		field, text, _ := strings.Cut(word, ":")
+		field = strings.ToLower(field)
+		if field != "after" && field != "before" && !contains(fields, field) {
+			field, text = "", word
+		}
+
+		switch field {
+		case "after", "before":
+			at, day, err := parseTime(text, loc)
+			if err != nil {
+				return Query{}, fmt.Errorf("%s: %w", field, err)
+			}
+			if field == "after" {
+				q.After = at
+			} else {
+				if day {
+					at = at.AddDate(0, 0, 1)
+				}
+				q.Before = at
+			}
+			continue
+		}
+
+		prefix := strings.HasSuffix(text, "*")
+		tokens := Tokenize(strings.TrimSuffix(text, "*"))
+		for i, tok := range tokens {
+			q.Terms = append(q.Terms, Term{Field: field, Text: tok, Prefix: prefix && i == len(tokens)-1})
+		}
+	}
+	return q, nil
+}
+
+// parseTime parses a date or an RFC 3339 time. day is true for a date.
+func parseTime(s string, loc *time.Location) (t time.Time, day bool, err error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time %q; use YYYY-MM-DD or RFC 3339", s)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package grpc
+
+import (
+	"context"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// Orders in which retrieved chunks fill the prompt (ChatOptions.ContextOrder).
+const (
+	ContextOrderScore   = "score"   // best match first
+	ContextOrderRecency = "recency" // most recently indexed first
+)
+
+// ChatOptions holds optional model and retrieval parameters for a chat turn.
+// Nil/empty fields leave the worker defaults in place.
+type ChatOptions struct {
+	Temperature  *float64 `json:"temperature,omitempty"`
+	TopP         *float64 `json:"top_p,omitempty"`
+	MaxTokens    *int32   `json:"max_tokens,omitempty"`
+	TopK         *int32   `json:"top_k,omitempty"`
+	SystemPrompt string   `json:"system_prompt,omitempty"`
+
+	// Context packing: how the retrieved chunks fill the prompt. The worker
+	// estimates tokens at four characters each; 0 means no limit.
+	ContextMaxTokens *int32 `json:"context_max_tokens,omitempty"`
+	SourceMaxTokens  *int32 `json:"source_max_tokens,omitempty"`
+	DedupeFiles      *bool  `json:"dedupe_files,omitempty"`
+	ContextOrder     string `json:"context_order,omitempty"`
+}
+
+// Merge returns o with any unset fields filled from defaults.
+func (o ChatOptions) Merge(defaults ChatOptions) ChatOptions {
+	if o.Temperature == nil {
+		o.Temperature = defaults.Temperature
+	}
+	if o.TopP == nil {
+		o.TopP = defaults.TopP
+	}
+	if o.MaxTokens == nil {
+		o.MaxTokens = defaults.MaxTokens
+	}
+	if o.TopK == nil {
+		o.TopK = defaults.TopK
+	}
+	if o.SystemPrompt == "" {
+		o.SystemPrompt = defaults.SystemPrompt
+	}
+	if o.ContextMaxTokens == nil {
+		o.ContextMaxTokens = defaults.ContextMaxTokens
+	}
+	if o.SourceMaxTokens == nil {
+		o.SourceMaxTokens = defaults.SourceMaxTokens
+	}
+	if o.DedupeFiles == nil {
+		o.DedupeFiles = defaults.DedupeFiles
+	}
+	if o.ContextOrder == "" {
+		o.ContextOrder = defaults.ContextOrder
+	}
+	return o
+}
+
+// Apply sets the options on a chat request. They travel in the request
+// message rather than metadata, so the system prompt may hold any text.
+func (o ChatOptions) Apply(req *ChatRequest) {
+	req.Temperature = o.Temperature
+	req.TopP = o.TopP
+	req.MaxTokens = o.MaxTokens
+	req.TopK = o.TopK
+	req.SystemPrompt = o.SystemPrompt
+	req.ContextMaxTokens = o.ContextMaxTokens
+	req.SourceMaxTokens = o.SourceMaxTokens
+	req.DedupeFiles = o.DedupeFiles
+	req.ContextOrder = o.ContextOrder
+}
+
+// MDOllamaURL pins a chat turn or index run to an Ollama instance other than
+// the worker's configured one: the worker embeds and generates against this
+// base URL instead.
+const MDOllamaURL = "x-ollqd-ollama-url"
+
+// WithOllamaURL attaches an Ollama base URL to the outgoing gRPC metadata.
+func WithOllamaURL(ctx context.Context, baseURL string) context.Context {
+	if baseURL == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, MDOllamaURL, baseURL)
+}
+
+// ChatTurn is an earlier message of a conversation. Role is "user" or
+// "assistant".
+type ChatTurn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// SetChatHistory sets the earlier turns of a conversation, oldest first,
+// on a chat request. Like the options, they travel in the request message,
+// so no turn is dropped for size.
+func SetChatHistory(req *ChatRequest, turns []ChatTurn) {
+	req.History = make([]*pb.ChatTurn, len(turns))
+	for i, t := range turns {
+		req.History[i] = &pb.ChatTurn{Role: t.Role, Content: t.Content}
+	}
+}
+
+// MDChatCollections carries the collections a routed chat turn searches,
+// as a JSON list. The worker searches each and merges the hits by score;
+// ChatRequest.Collection is the first of them.
+const MDChatCollections = "x-ollqd-chat-collections"
+
+// WithChatCollections attaches the collections a chat turn searches. A
+// single collection needs no metadata, ChatRequest.Collection names it.
+func WithChatCollections(ctx context.Context, collections []string) context.Context {
+	if len(collections) < 2 {
+		return ctx
+	}
+	data, err := asciiJSON(collections)
+	if err != nil {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, MDChatCollections, data)
+}
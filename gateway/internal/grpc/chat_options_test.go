@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	"github.com/alfagnish/ollqd-gateway/internal/grpc/grpctest"
+	"google.golang.org/grpc"
+)
+
+// echoChat answers each chat turn with the system prompt it received.
+type echoChat struct {
+	pb.UnimplementedChatServiceServer
+	got chan *ChatRequest
+}
+
+func (s *echoChat) Chat(req *ChatRequest, stream pb.ChatService_ChatServer) error {
+	s.got <- req
+	return stream.Send(&ChatEvent{Type: "done", Content: req.GetSystemPrompt()})
+}
+
+// dialChat serves srv over an in-memory listener and returns a client for it.
+func dialChat(t *testing.T, srv pb.ChatServiceServer) ChatServiceClient {
+	t.Helper()
+	conn := grpctest.Dial(t, func(s *grpc.Server) { pb.RegisterChatServiceServer(s, srv) })
+	return &chatAdapter{inner: pb.NewChatServiceClient(conn)}
+}
+
+func TestChatOptionsApplySystemPrompt(t *testing.T) {
+	srv := &echoChat{got: make(chan *ChatRequest, 1)}
+	client := dialChat(t, srv)
+
+	temp := 0.2
+	dedupe := false
+	prompt := "Answer in French.\nUse the café's menu terms.\tBe brief."
+	opts := ChatOptions{Temperature: &temp, DedupeFiles: &dedupe, SystemPrompt: prompt}
+	req := &ChatRequest{Message: "hi", Collection: "docs"}
+	opts.Apply(req)
+
+	stream, err := client.Chat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	defer stream.Close()
+	ev, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if ev.GetContent() != prompt {
+		t.Errorf("worker saw system prompt %q, want %q", ev.GetContent(), prompt)
+	}
+
+	got := <-srv.got
+	if got.Temperature == nil || *got.Temperature != temp {
+		t.Errorf("temperature = %v, want %v", got.Temperature, temp)
+	}
+	if got.DedupeFiles == nil || *got.DedupeFiles {
+		t.Errorf("dedupe_files = %v, want explicit false", got.DedupeFiles)
+	}
+	if got.TopP != nil || got.MaxTokens != nil {
+		t.Errorf("unset options were sent: top_p=%v max_tokens=%v", got.TopP, got.MaxTokens)
+	}
+}
+
+func TestSetChatHistoryKeepsEveryTurn(t *testing.T) {
+	srv := &echoChat{got: make(chan *ChatRequest, 1)}
+	client := dialChat(t, srv)
+
+	// Far more than fits in gRPC metadata.
+	var turns []ChatTurn
+	for i := 0; i < 200; i++ {
+		turns = append(turns, ChatTurn{Role: "user", Content: strings.Repeat("q", 1000)})
+		turns = append(turns, ChatTurn{Role: "assistant", Content: strings.Repeat("é", 1000)})
+	}
+	req := &ChatRequest{Message: "and now?", Collection: "docs"}
+	SetChatHistory(req, turns)
+
+	stream, err := client.Chat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	defer stream.Close()
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+
+	got := (<-srv.got).GetHistory()
+	if len(got) != len(turns) {
+		t.Fatalf("worker saw %d turns, want %d", len(got), len(turns))
+	}
+	if got[1].GetRole() != "assistant" || got[1].GetContent() != turns[1].Content {
+		t.Errorf("turn 1 = %v, want %+v", got[1], turns[1])
+	}
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync/atomic"
+	"time"
 
 	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 // ──────────────────────────────────────────────────────────────
@@ -265,11 +270,18 @@ type AuthServiceClient interface {
 
 // --- indexingAdapter ---
 
+// indexingAdapter sets the provenance of each index request and refuses
+// calls without one; see WithProvenance.
 type indexingAdapter struct {
 	inner pb.IndexingServiceClient
 }
 
 func (a *indexingAdapter) IndexCodebase(ctx context.Context, req *IndexCodebaseRequest) (IndexingStream, error) {
+	prov, err := requireProvenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Provenance = prov
 	stream, err := a.inner.IndexCodebase(ctx, req)
 	if err != nil {
 		return nil, err
@@ -278,6 +290,11 @@ func (a *indexingAdapter) IndexCodebase(ctx context.Context, req *IndexCodebaseR
 }
 
 func (a *indexingAdapter) IndexDocuments(ctx context.Context, req *IndexDocumentsRequest) (IndexingStream, error) {
+	prov, err := requireProvenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Provenance = prov
 	stream, err := a.inner.IndexDocuments(ctx, req)
 	if err != nil {
 		return nil, err
@@ -286,6 +303,11 @@ func (a *indexingAdapter) IndexDocuments(ctx context.Context, req *IndexDocument
 }
 
 func (a *indexingAdapter) IndexImages(ctx context.Context, req *IndexImagesRequest) (IndexingStream, error) {
+	prov, err := requireProvenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Provenance = prov
 	stream, err := a.inner.IndexImages(ctx, req)
 	if err != nil {
 		return nil, err
@@ -294,6 +316,11 @@ func (a *indexingAdapter) IndexImages(ctx context.Context, req *IndexImagesReque
 }
 
 func (a *indexingAdapter) IndexUploads(ctx context.Context, req *IndexUploadsRequest) (IndexingStream, error) {
+	prov, err := requireProvenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Provenance = prov
 	stream, err := a.inner.IndexUploads(ctx, req)
 	if err != nil {
 		return nil, err
@@ -302,6 +329,11 @@ func (a *indexingAdapter) IndexUploads(ctx context.Context, req *IndexUploadsReq
 }
 
 func (a *indexingAdapter) IndexSMBFiles(ctx context.Context, req *IndexSMBFilesRequest) (IndexingStream, error) {
+	prov, err := requireProvenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Provenance = prov
 	stream, err := a.inner.IndexSMBFiles(ctx, req)
 	if err != nil {
 		return nil, err
@@ -344,7 +376,8 @@ func (a *chatAdapter) Chat(ctx context.Context, req *ChatRequest) (ChatStream, e
 // --- embeddingAdapter ---
 
 type embeddingAdapter struct {
-	inner pb.EmbeddingServiceClient
+	inner    pb.EmbeddingServiceClient
+	onChange func() // called after SetModel, which rewrites the worker config
 }
 
 func (a *embeddingAdapter) GetInfo(ctx context.Context) (*EmbeddingInfoResponse, error) {
@@ -360,6 +393,9 @@ func (a *embeddingAdapter) CompareModels(ctx context.Context, req *CompareModels
 }
 
 func (a *embeddingAdapter) SetModel(ctx context.Context, req *SetEmbedModelRequest) (*EmbeddingInfoResponse, error) {
+	if a.onChange != nil {
+		defer a.onChange()
+	}
 	return a.inner.SetModel(ctx, req)
 }
 
@@ -489,7 +525,7 @@ func (a *authAdapter) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*
 type Client struct {
 	conn *grpc.ClientConn
 
-	// Service stubs (all 9 services)
+	// Service stubs (all 13 services)
 	Indexing      IndexingServiceClient
 	Search        SearchServiceClient
 	Chat          ChatServiceClient
@@ -499,6 +535,13 @@ type Client struct {
 	Visualization VisualizationServiceClient
 	SMB           SMBServiceClient
 	Auth          AuthServiceClient
+	Preview       PreviewServiceClient
+	SMBSync       SMBSyncServiceClient
+	SMBBrowse     SMBBrowseServiceClient
+	OCR           OCRServiceClient
+
+	// worker is the last WorkerInfo negotiated; see WatchWorker.
+	worker atomic.Pointer[WorkerInfo]
 }
 
 // NewClient dials the gRPC worker at the given address and returns a Client
@@ -511,21 +554,31 @@ func NewClient(addr string) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("grpc dial %s: %w", addr, err)
 	}
+	return NewClientConn(conn), nil
+}
+
+// NewClientConn returns a Client with all service stubs initialized on an
+// existing connection. Close closes conn.
+func NewClientConn(conn *grpc.ClientConn) *Client {
+	// GetConfig is served from a cache that every config write invalidates.
+	config := newConfigCache(&configAdapter{inner: pb.NewConfigServiceClient(conn)}, ConfigCacheTTL)
 
-	c := &Client{
+	return &Client{
 		conn:          conn,
 		Indexing:      &indexingAdapter{inner: pb.NewIndexingServiceClient(conn)},
 		Search:        &searchAdapter{inner: pb.NewSearchServiceClient(conn)},
 		Chat:          &chatAdapter{inner: pb.NewChatServiceClient(conn)},
-		Embedding:     &embeddingAdapter{inner: pb.NewEmbeddingServiceClient(conn)},
+		Embedding:     &embeddingAdapter{inner: pb.NewEmbeddingServiceClient(conn), onChange: config.invalidate},
 		PII:           &piiAdapter{inner: pb.NewPIIServiceClient(conn)},
-		Config:        &configAdapter{inner: pb.NewConfigServiceClient(conn)},
+		Config:        config,
 		Visualization: &visualizationAdapter{inner: pb.NewVisualizationServiceClient(conn)},
 		SMB:           &smbAdapter{inner: pb.NewSMBServiceClient(conn)},
 		Auth:          &authAdapter{inner: pb.NewAuthServiceClient(conn)},
+		Preview:       &previewAdapter{inner: pb.NewPreviewServiceClient(conn)},
+		SMBSync:       &smbSyncAdapter{inner: pb.NewSMBSyncServiceClient(conn)},
+		SMBBrowse:     &smbBrowseAdapter{inner: pb.NewSMBBrowseServiceClient(conn)},
+		OCR:           &ocrAdapter{inner: pb.NewOCRServiceClient(conn), onChange: config.invalidate},
 	}
-
-	return c, nil
 }
 
 // Conn returns the underlying gRPC client connection. This can be used
@@ -541,3 +594,30 @@ func (c *Client) Close() error {
 	}
 	return nil
 }
+
+// IsUnavailable reports whether err means the worker could not be reached,
+// typically because it is restarting.
+func IsUnavailable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// WaitReady nudges the connection to reconnect and blocks until it is READY,
+// the timeout elapses, or ctx is done. It returns true if the worker is ready.
+func (c *Client) WaitReady(ctx context.Context, timeout time.Duration) bool {
+	if c.conn == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c.conn.Connect()
+	for {
+		state := c.conn.GetState()
+		if state == connectivity.Ready {
+			return true
+		}
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ConfigCacheTTL bounds how long a cached AppConfig is served. Writes made
+// through this client invalidate the cache immediately; the TTL only covers
+// changes the gateway cannot see, such as a worker restart.
+const ConfigCacheTTL = 30 * time.Second
+
+// configFetchTimeout caps a shared GetConfig call whose caller set no deadline.
+const configFetchTimeout = 30 * time.Second
+
+// configCache wraps a ConfigServiceClient and serves GetConfig from memory.
+// Concurrent misses share a single worker call.
+type configCache struct {
+	ConfigServiceClient
+	ttl time.Duration
+
+	mu        sync.Mutex
+	cfg       *AppConfig
+	fetchedAt time.Time
+	gen       uint64 // bumped by invalidate so in-flight fetches are discarded
+	inflight  *configFetch
+}
+
+type configFetch struct {
+	done chan struct{}
+	cfg  *AppConfig
+	err  error
+}
+
+func newConfigCache(inner ConfigServiceClient, ttl time.Duration) *configCache {
+	return &configCache{ConfigServiceClient: inner, ttl: ttl}
+}
+
+// GetConfig returns a copy of the cached config, fetching it from the worker
+// when the cache is empty or older than the TTL.
+func (c *configCache) GetConfig(ctx context.Context) (*AppConfig, error) {
+	c.mu.Lock()
+	if c.cfg != nil && time.Since(c.fetchedAt) < c.ttl {
+		cfg := c.cfg
+		c.mu.Unlock()
+		return proto.Clone(cfg).(*AppConfig), nil
+	}
+	f := c.inflight
+	if f == nil {
+		f = &configFetch{done: make(chan struct{})}
+		c.inflight = f
+		go c.fetch(ctx, f, c.gen)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return proto.Clone(f.cfg).(*AppConfig), nil
+}
+
+// fetch loads the config for every caller waiting on f. It runs detached
+// from the caller that started it so a disconnecting client does not fail
+// the others; that caller's deadline still applies, or configFetchTimeout
+// when it has none.
+func (c *configCache) fetch(caller context.Context, f *configFetch, gen uint64) {
+	deadline, ok := caller.Deadline()
+	if !ok {
+		deadline = time.Now().Add(configFetchTimeout)
+	}
+	ctx, cancel := context.WithDeadline(context.WithoutCancel(caller), deadline)
+	defer cancel()
+
+	f.cfg, f.err = c.ConfigServiceClient.GetConfig(ctx)
+
+	c.mu.Lock()
+	if c.inflight == f {
+		c.inflight = nil
+	}
+	if f.err == nil && c.gen == gen {
+		c.cfg, c.fetchedAt = f.cfg, time.Now()
+	}
+	c.mu.Unlock()
+	close(f.done)
+}
+
+// invalidate drops the cached config. It is called after every write, even
+// failed ones, since a write can apply on the worker and still time out.
+func (c *configCache) invalidate() {
+	c.mu.Lock()
+	c.cfg = nil
+	c.gen++
+	c.inflight = nil
+	c.mu.Unlock()
+}
+
+func (c *configCache) UpdateMountedPaths(ctx context.Context, req *UpdateMountedPathsRequest) (*UpdateMountedPathsResponse, error) {
+	defer c.invalidate()
+	return c.ConfigServiceClient.UpdateMountedPaths(ctx, req)
+}
+
+func (c *configCache) UpdatePII(ctx context.Context, req *UpdatePIIRequest) (*PIIConfigResponse, error) {
+	defer c.invalidate()
+	return c.ConfigServiceClient.UpdatePII(ctx, req)
+}
+
+func (c *configCache) UpdateDocling(ctx context.Context, req *UpdateDoclingRequest) (*DoclingConfigResponse, error) {
+	defer c.invalidate()
+	return c.ConfigServiceClient.UpdateDocling(ctx, req)
+}
+
+func (c *configCache) UpdateDistance(ctx context.Context, req *UpdateDistanceRequest) (*UpdateDistanceResponse, error) {
+	defer c.invalidate()
+	return c.ConfigServiceClient.UpdateDistance(ctx, req)
+}
+
+func (c *configCache) UpdateOllama(ctx context.Context, req *UpdateOllamaRequest) (*OllamaConfigResponse, error) {
+	defer c.invalidate()
+	return c.ConfigServiceClient.UpdateOllama(ctx, req)
+}
+
+func (c *configCache) UpdateQdrant(ctx context.Context, req *UpdateQdrantRequest) (*QdrantConfigResponse, error) {
+	defer c.invalidate()
+	return c.ConfigServiceClient.UpdateQdrant(ctx, req)
+}
+
+func (c *configCache) UpdateChunking(ctx context.Context, req *UpdateChunkingRequest) (*ChunkingConfigResponse, error) {
+	defer c.invalidate()
+	return c.ConfigServiceClient.UpdateChunking(ctx, req)
+}
+
+func (c *configCache) UpdateImage(ctx context.Context, req *UpdateImageRequest) (*ImageConfigResponse, error) {
+	defer c.invalidate()
+	return c.ConfigServiceClient.UpdateImage(ctx, req)
+}
+
+func (c *configCache) ResetConfig(ctx context.Context, req *ResetConfigRequest) (*ResetConfigResponse, error) {
+	defer c.invalidate()
+	return c.ConfigServiceClient.ResetConfig(ctx, req)
+}
@@ -0,0 +1,210 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Image metadata extracted by the gateway (EXIF, dimensions, format) travels
+// to IndexImages/IndexUploads either inline as a JSON object keyed by
+// absolute file path, or, when too large for metadata, as the path of a JSON
+// file with the same content on storage shared with the worker.
+const (
+	MDImageMeta     = "x-ollqd-image-meta"
+	MDImageMetaFile = "x-ollqd-image-meta-file"
+)
+
+// maxImageMeta bounds the inline image metadata map, which shares the
+// worker's metadata limit with the display names. Larger maps go through
+// a file.
+const maxImageMeta = 4096
+
+// WithImageMeta attaches an inline image metadata map. Non-ASCII characters
+// are escaped since metadata values must be ASCII. Maps too large for
+// metadata are not attached and false is returned.
+func WithImageMeta(ctx context.Context, metas interface{}) (context.Context, bool) {
+	data, err := asciiJSON(metas)
+	if err != nil || len(data) > maxImageMeta {
+		return ctx, false
+	}
+	return metadata.AppendToOutgoingContext(ctx, MDImageMeta, data), true
+}
+
+// WithImageMetaFile attaches the path of a JSON image metadata file.
+func WithImageMetaFile(ctx context.Context, path string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, MDImageMetaFile, path)
+}
+
+// MDSMBACLs asks IndexSMBFiles to read each file's owner and DACL and store
+// them in the "smb_acl" payload field.
+const MDSMBACLs = "x-ollqd-smb-acls"
+
+// WithSMBACLs asks the worker to capture SMB file ACLs when capture is set.
+func WithSMBACLs(ctx context.Context, capture bool) context.Context {
+	if !capture {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, MDSMBACLs, "true")
+}
+
+// MDDisplayNames carries the original names of IndexUploads files as a JSON
+// object keyed by saved path. The worker stores them as "display_name".
+const MDDisplayNames = "x-ollqd-display-names"
+
+// maxDisplayNames bounds the encoded display name map; the worker accepts
+// 64 KiB of metadata per call.
+const maxDisplayNames = 32 << 10
+
+// WithDisplayNames attaches the original names of uploaded files. Non-ASCII
+// characters are escaped since metadata values must be ASCII. Maps too
+// large for metadata are dropped and false is returned; SplitDisplayNames
+// keeps the files of one call within the limit.
+func WithDisplayNames(ctx context.Context, names map[string]string) (context.Context, bool) {
+	if len(names) == 0 {
+		return ctx, true
+	}
+	data, err := asciiJSON(names)
+	if err != nil || len(data) > maxDisplayNames {
+		return ctx, false
+	}
+	return metadata.AppendToOutgoingContext(ctx, MDDisplayNames, data), true
+}
+
+// SplitDisplayNames splits paths, in order, into runs whose display names,
+// of names keyed by path, fit the metadata of one call. A path whose name
+// alone is too large gets a run of its own.
+func SplitDisplayNames(paths []string, names map[string]string) [][]string {
+	var runs [][]string
+	start, size := 0, 2 // the braces
+	for i, p := range paths {
+		name, ok := names[p]
+		if !ok {
+			continue
+		}
+		// "path":"name" and a comma.
+		n := 2
+		for _, s := range []string{p, name} {
+			data, _ := asciiJSON(s)
+			n += len(data)
+		}
+		if size+n > maxDisplayNames+1 && i > start {
+			runs = append(runs, paths[start:i:i])
+			start, size = i, 2
+		}
+		size += n
+	}
+	if start < len(paths) {
+		runs = append(runs, paths[start:len(paths):len(paths)])
+	}
+	return runs
+}
+
+// asciiJSON encodes v as JSON with non-ASCII characters escaped, since
+// metadata values must be ASCII.
+func asciiJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, r := range string(data) {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, `\u%04x\u%04x`, r1, r2)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+	}
+	return b.String(), nil
+}
+
+// MDFileMeta carries extra payload fields of IndexUploads documents as a
+// JSON object keyed by saved path, each an object of string fields. The
+// worker adds them to every point of the file but never overwrites the
+// fields it sets itself.
+const MDFileMeta = "x-ollqd-file-meta"
+
+// maxFileMeta bounds the encoded file metadata map, which shares the
+// worker's metadata limit with the display names.
+const maxFileMeta = 24 << 10
+
+// WithFileMeta attaches payload fields of uploaded documents. Maps too large
+// for metadata are dropped and false is returned.
+func WithFileMeta(ctx context.Context, meta map[string]map[string]string) (context.Context, bool) {
+	if len(meta) == 0 {
+		return ctx, true
+	}
+	data, err := asciiJSON(meta)
+	if err != nil || len(data) > maxFileMeta {
+		return ctx, false
+	}
+	return metadata.AppendToOutgoingContext(ctx, MDFileMeta, data), true
+}
+
+// MDDoclingOCR turns docling OCR on ("true") or off ("false") for one
+// IndexUploads call, overriding the worker's DOCLING_OCR_ENABLED. Turning
+// it on also enables docling.
+const MDDoclingOCR = "x-ollqd-docling-ocr"
+
+// WithDoclingOCR attaches an OCR choice; nil leaves the worker's setting.
+func WithDoclingOCR(ctx context.Context, ocr *bool) context.Context {
+	if ocr == nil {
+		return ctx
+	}
+	v := "false"
+	if *ocr {
+		v = "true"
+	}
+	return metadata.AppendToOutgoingContext(ctx, MDDoclingOCR, v)
+}
+
+// MDTableOptions carries a JSON-encoded TableOptions for IndexUploads and
+// IndexDocuments. Without it the worker extracts spreadsheets as plain
+// text, and IndexDocuments skips them.
+const MDTableOptions = "x-ollqd-table-options"
+
+// Table chunking modes.
+const (
+	// TableModeRows chunks groups of rows, each row written as
+	// "column: value" pairs.
+	TableModeRows = "rows"
+	// TableModeSheet chunks each sheet as a Markdown table, split at the
+	// chunk size with the header repeated.
+	TableModeSheet = "sheet"
+)
+
+// TableOptions control how .csv, .tsv and .xlsx files are chunked.
+type TableOptions struct {
+	// Mode is TableModeRows (the default) or TableModeSheet.
+	Mode string `json:"mode,omitempty"`
+	// Header reports whether the first row names the columns; nil means
+	// it does. Header names label the values of every chunk.
+	Header *bool `json:"header,omitempty"`
+	// Columns keeps only these columns, by header name (case-insensitive)
+	// or 1-based position; empty keeps all.
+	Columns []string `json:"columns,omitempty"`
+	// RowsPerChunk bounds the rows of a rows-mode chunk; 0 fills the
+	// chunk size.
+	RowsPerChunk int `json:"rows_per_chunk,omitempty"`
+}
+
+// WithTableOptions attaches table chunking options; nil adds nothing.
+func WithTableOptions(ctx context.Context, opts *TableOptions) context.Context {
+	if opts == nil {
+		return ctx
+	}
+	data, err := asciiJSON(opts)
+	if err != nil {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, MDTableOptions, data)
+}
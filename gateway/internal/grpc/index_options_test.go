@@ -0,0 +1,56 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplitDisplayNames(t *testing.T) {
+	var paths []string
+	names := map[string]string{}
+	for i := 0; i < 100; i++ {
+		p := fmt.Sprintf("/uploads/%03d.pdf", i)
+		paths = append(paths, p)
+		// Escaped as \uXXXX, so each name takes about 3 KiB.
+		names[p] = strings.Repeat("é", 500)
+	}
+
+	runs := SplitDisplayNames(paths, names)
+	if len(runs) < 2 {
+		t.Fatalf("got %d runs, want the names split", len(runs))
+	}
+	var joined []string
+	for _, run := range runs {
+		m := map[string]string{}
+		for _, p := range run {
+			m[p] = names[p]
+		}
+		if _, ok := WithDisplayNames(context.Background(), m); !ok {
+			t.Errorf("run of %d files does not fit", len(run))
+		}
+		joined = append(joined, run...)
+	}
+	if strings.Join(joined, ",") != strings.Join(paths, ",") {
+		t.Error("runs do not hold every path in order")
+	}
+}
+
+func TestSplitDisplayNamesFits(t *testing.T) {
+	paths := []string{"/uploads/a.pdf", "/uploads/b.pdf", "/uploads/c.pdf"}
+	names := map[string]string{"/uploads/a.pdf": "Report.pdf"}
+	runs := SplitDisplayNames(paths, names)
+	if len(runs) != 1 || len(runs[0]) != 3 {
+		t.Errorf("SplitDisplayNames = %q, want one run", runs)
+	}
+	if runs := SplitDisplayNames(nil, names); len(runs) != 0 {
+		t.Errorf("SplitDisplayNames(nil) = %q", runs)
+	}
+
+	// A name too large on its own still gets its file a run.
+	big := map[string]string{"/uploads/a.pdf": strings.Repeat("x", maxDisplayNames), "/uploads/b.pdf": "b.pdf"}
+	if runs := SplitDisplayNames(paths, big); len(runs) != 2 || len(runs[0]) != 1 {
+		t.Errorf("SplitDisplayNames = %d runs, want the oversized name alone", len(runs))
+	}
+}
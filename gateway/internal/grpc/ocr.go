@@ -0,0 +1,44 @@
+package grpc
+
+import (
+	"context"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+)
+
+// OCRService message types (see proto/ollqd/v1/ocr.proto).
+type GetOCRConfigRequest = pb.GetOCRConfigRequest
+type OCRConfig = pb.OCRConfig
+type OCRLanguages = pb.OCRLanguages
+type UpdateOCRConfigRequest = pb.UpdateOCRConfigRequest
+type TestOCRRequest = pb.TestOCRRequest
+type TestOCRResponse = pb.TestOCRResponse
+
+// OCRServiceClient defines the OCRService RPC methods.
+type OCRServiceClient interface {
+	GetOCRConfig(ctx context.Context, req *GetOCRConfigRequest) (*OCRConfig, error)
+	UpdateOCRConfig(ctx context.Context, req *UpdateOCRConfigRequest) (*OCRConfig, error)
+	TestOCR(ctx context.Context, req *TestOCRRequest) (*TestOCRResponse, error)
+}
+
+// --- ocrAdapter ---
+
+type ocrAdapter struct {
+	inner    pb.OCRServiceClient
+	onChange func() // called after UpdateOCRConfig, which rewrites the docling config
+}
+
+func (a *ocrAdapter) GetOCRConfig(ctx context.Context, req *GetOCRConfigRequest) (*OCRConfig, error) {
+	return a.inner.GetOCRConfig(ctx, req)
+}
+
+func (a *ocrAdapter) UpdateOCRConfig(ctx context.Context, req *UpdateOCRConfigRequest) (*OCRConfig, error) {
+	if a.onChange != nil {
+		defer a.onChange()
+	}
+	return a.inner.UpdateOCRConfig(ctx, req)
+}
+
+func (a *ocrAdapter) TestOCR(ctx context.Context, req *TestOCRRequest) (*TestOCRResponse, error) {
+	return a.inner.TestOCR(ctx, req)
+}
@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"context"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+)
+
+// PreviewService message types (see proto/ollqd/v1/preview.proto).
+type PreviewUploadRequest = pb.PreviewUploadRequest
+type PreviewUploadResponse = pb.PreviewUploadResponse
+type PreviewChunk = pb.PreviewChunk
+
+// PreviewServiceClient defines the PreviewService RPC methods.
+type PreviewServiceClient interface {
+	PreviewUpload(ctx context.Context, req *PreviewUploadRequest) (*PreviewUploadResponse, error)
+}
+
+// --- previewAdapter ---
+
+type previewAdapter struct {
+	inner pb.PreviewServiceClient
+}
+
+func (a *previewAdapter) PreviewUpload(ctx context.Context, req *PreviewUploadRequest) (*PreviewUploadResponse, error) {
+	return a.inner.PreviewUpload(ctx, req)
+}
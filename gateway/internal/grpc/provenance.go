@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ProvenanceSchema is the version of the provenance payload. Bump it when
+// a field changes meaning.
+const ProvenanceSchema = 1
+
+// Provenance source types: how the indexed content reached the gateway.
+const (
+	SourceCodebase  = "codebase"
+	SourceDocuments = "documents"
+	SourceImages    = "images"
+	SourceUpload    = "upload"
+	SourceURL       = "url"
+	SourceSMB       = "smb"
+	SourceConnector = "connector"
+)
+
+// Provenance records where indexed content came from and which run wrote
+// it, so points can be filtered, audited and cleaned up the same way
+// whatever route ingested them.
+type Provenance struct {
+	Schema     int    `json:"schema"`
+	SourceType string `json:"source_type"`
+	// ShareID is the SMB share or connector the files were read from.
+	ShareID string `json:"share_id,omitempty"`
+	// Uploader is the user who started the run; empty for scheduled runs
+	// and gRPC callers.
+	Uploader string `json:"uploader,omitempty"`
+	// IndexedAt is when the run opened its worker stream, RFC 3339 UTC.
+	IndexedAt      string `json:"indexed_at"`
+	TaskID         string `json:"task_id"`
+	GatewayVersion string `json:"gateway_version"`
+	// Sources maps the paths the worker reads to the original path or URL
+	// of each file. Files it leaves out are recorded under their display
+	// name or path.
+	Sources map[string]string `json:"sources,omitempty"`
+}
+
+type provenanceKey struct{}
+
+// WithProvenance attaches p to the indexing call made with ctx, filling in
+// the schema version, the time and the gateway version. The indexing
+// client copies it onto the request, so the sources are never truncated.
+func WithProvenance(ctx context.Context, p Provenance) context.Context {
+	p.Schema = ProvenanceSchema
+	p.IndexedAt = time.Now().UTC().Format(time.RFC3339)
+	p.GatewayVersion = gatewayVersion()
+	return context.WithValue(ctx, provenanceKey{}, &pb.Provenance{
+		Schema:         int32(p.Schema),
+		SourceType:     p.SourceType,
+		ShareId:        p.ShareID,
+		Uploader:       p.Uploader,
+		IndexedAt:      p.IndexedAt,
+		TaskId:         p.TaskID,
+		GatewayVersion: p.GatewayVersion,
+		Sources:        p.Sources,
+	})
+}
+
+// requireProvenance returns the provenance attached to ctx. It rejects
+// indexing calls made without one, so no ingestion route writes points the
+// schema does not describe.
+func requireProvenance(ctx context.Context) (*pb.Provenance, error) {
+	p, _ := ctx.Value(provenanceKey{}).(*pb.Provenance)
+	if p == nil {
+		return nil, status.Error(codes.FailedPrecondition, "indexing call without provenance")
+	}
+	return p, nil
+}
+
+// GatewayVersion names the gateway build in provenance records. Release
+// builds set it with
+//
+//	-ldflags "-X github.com/alfagnish/ollqd-gateway/internal/grpc.GatewayVersion=v1.2.3"
+//
+// Otherwise the module version or VCS revision recorded by the Go
+// toolchain is used, or "dev" when there is neither.
+var GatewayVersion string
+
+var gatewayVersion = sync.OnceValue(func() string {
+	if GatewayVersion != "" {
+		return GatewayVersion
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if v := bi.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	var rev, dirty string
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				dirty = "-dirty"
+			}
+		}
+	}
+	if rev == "" {
+		return "dev"
+	}
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	return rev + dirty
+})
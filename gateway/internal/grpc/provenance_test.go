@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	"google.golang.org/grpc"
+)
+
+// recordIndexing records the uploads request it is sent.
+type recordIndexing struct {
+	pb.IndexingServiceClient
+	got *IndexUploadsRequest
+}
+
+func (c *recordIndexing) IndexUploads(_ context.Context, req *IndexUploadsRequest, _ ...grpc.CallOption) (pb.IndexingService_IndexUploadsClient, error) {
+	c.got = req
+	return nil, nil
+}
+
+func TestProvenanceKeepsSources(t *testing.T) {
+	inner := &recordIndexing{}
+	client := &indexingAdapter{inner: inner}
+
+	sources := map[string]string{}
+	for i := 0; i < 500; i++ {
+		sources[fmt.Sprintf("/uploads/%03d.pdf", i)] = fmt.Sprintf("https://example.com/reports/%03d.pdf", i)
+	}
+	ctx := WithProvenance(context.Background(), Provenance{SourceType: SourceURL, TaskID: "t1", Sources: sources})
+	if _, err := client.IndexUploads(ctx, &IndexUploadsRequest{}); err != nil {
+		t.Fatalf("IndexUploads: %v", err)
+	}
+
+	p := inner.got.GetProvenance()
+	if p.GetSchema() != ProvenanceSchema || p.GetSourceType() != SourceURL || p.GetTaskId() != "t1" {
+		t.Errorf("provenance = %v", p)
+	}
+	if p.GetIndexedAt() == "" || p.GetGatewayVersion() == "" {
+		t.Errorf("provenance has no time or gateway version: %v", p)
+	}
+	if len(p.GetSources()) != len(sources) {
+		t.Errorf("got %d sources, want %d", len(p.GetSources()), len(sources))
+	}
+}
+
+func TestIndexingRequiresProvenance(t *testing.T) {
+	inner := &recordIndexing{}
+	client := &indexingAdapter{inner: inner}
+	if _, err := client.IndexUploads(context.Background(), &IndexUploadsRequest{}); err == nil {
+		t.Error("IndexUploads without provenance succeeded")
+	}
+	if inner.got != nil {
+		t.Error("request without provenance reached the worker")
+	}
+}
@@ -0,0 +1,33 @@
+package grpc
+
+import (
+	"context"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+)
+
+// SMBBrowseService message types (see proto/ollqd/v1/smb_browse.proto).
+// Browse takes the SMBService browse messages.
+type ListSharesRequest = pb.ListSharesRequest
+type ListSharesResponse = pb.ListSharesResponse
+type SMBShareInfo = pb.SMBShareInfo
+
+// SMBBrowseServiceClient defines the SMBBrowseService RPC methods.
+type SMBBrowseServiceClient interface {
+	ListShares(ctx context.Context, req *ListSharesRequest) (*ListSharesResponse, error)
+	Browse(ctx context.Context, req *SMBBrowseRequest) (*SMBBrowseResponse, error)
+}
+
+// --- smbBrowseAdapter ---
+
+type smbBrowseAdapter struct {
+	inner pb.SMBBrowseServiceClient
+}
+
+func (a *smbBrowseAdapter) ListShares(ctx context.Context, req *ListSharesRequest) (*ListSharesResponse, error) {
+	return a.inner.ListShares(ctx, req)
+}
+
+func (a *smbBrowseAdapter) Browse(ctx context.Context, req *SMBBrowseRequest) (*SMBBrowseResponse, error) {
+	return a.inner.Browse(ctx, req)
+}
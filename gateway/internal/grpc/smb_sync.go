@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"context"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+)
+
+// SMBSyncService message types (see proto/ollqd/v1/smb_sync.proto).
+type ScanShareRequest = pb.ScanShareRequest
+type ScanShareResponse = pb.ScanShareResponse
+type ScannedFile = pb.ScannedFile
+
+// SMBSyncServiceClient defines the SMBSyncService RPC methods.
+type SMBSyncServiceClient interface {
+	ScanShare(ctx context.Context, req *ScanShareRequest) (*ScanShareResponse, error)
+}
+
+// --- smbSyncAdapter ---
+
+type smbSyncAdapter struct {
+	inner pb.SMBSyncServiceClient
+}
+
+func (a *smbSyncAdapter) ScanShare(ctx context.Context, req *ScanShareRequest) (*ScanShareResponse, error) {
+	return a.inner.ScanShare(ctx, req)
+}
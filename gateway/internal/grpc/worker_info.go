@@ -0,0 +1,207 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+// Worker service names, as reported by GetVersion.
+const (
+	ServiceIndexing      = "IndexingService"
+	ServiceSearch        = "SearchService"
+	ServiceChat          = "ChatService"
+	ServiceEmbedding     = "EmbeddingService"
+	ServicePII           = "PIIService"
+	ServiceConfig        = "ConfigService"
+	ServiceVisualization = "VisualizationService"
+	ServiceSMB           = "SMBService"
+	ServiceAuth          = "AuthService"
+	ServicePreview       = "PreviewService"
+	ServiceSMBSync       = "SMBSyncService"
+	ServiceSMBBrowse     = "SMBBrowseService"
+	ServiceOCR           = "OCRService"
+)
+
+// KnownServices lists the worker services the gateway calls.
+var KnownServices = []string{
+	ServiceIndexing, ServiceSearch, ServiceChat, ServiceEmbedding, ServicePII,
+	ServiceConfig, ServiceVisualization, ServiceSMB, ServiceAuth,
+	ServicePreview, ServiceSMBSync, ServiceSMBBrowse, ServiceOCR,
+}
+
+// Worker features, as reported by GetVersion: optional behaviour of
+// services the worker does implement.
+const (
+	// FeatureSMBKerberos: SMB calls honour Kerberos share settings (the auth
+	// fields of the SMB requests).
+	FeatureSMBKerberos = "smb_kerberos"
+	// FeatureChatHistory: Chat places the earlier turns of
+	// ChatRequest.history before the new message.
+	FeatureChatHistory = "chat_history"
+	// FeatureTableOptions: IndexUploads and IndexDocuments chunk
+	// spreadsheets as tables with the x-ollqd-table-options settings.
+	FeatureTableOptions = "table_options"
+	// FeatureTranscription: IndexUploads transcribes audio and video files
+	// into timestamped transcript chunks. Only reported by workers with a
+	// speech-to-text model installed and enabled.
+	FeatureTranscription = "transcription"
+)
+
+// Worker negotiation states.
+const (
+	// WorkerUnknown: the worker has not been reached yet.
+	WorkerUnknown = "unknown"
+	// WorkerNegotiated: the worker answered GetVersion.
+	WorkerNegotiated = "negotiated"
+	// WorkerLegacy: the worker predates GetVersion; every service is
+	// assumed to be available.
+	WorkerLegacy = "legacy"
+)
+
+// negotiateTimeout bounds one GetVersion call; negotiateRetry is the pause
+// before retrying a failed one while the connection stays ready.
+const (
+	negotiateTimeout = 5 * time.Second
+	negotiateRetry   = 10 * time.Second
+)
+
+// WorkerInfo is what the gateway knows about the connected worker.
+type WorkerInfo struct {
+	Status   string   `json:"status"`
+	Version  string   `json:"version,omitempty"`
+	Services []string `json:"services"`
+	Features []string `json:"features"`
+	// CheckedAt is when the worker last answered, or refused, GetVersion.
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// Supports reports whether the worker implements service. Until the worker
+// has negotiated, and for legacy workers, every service counts as supported
+// so that calls fail, if at all, with the worker's own error.
+func (wi WorkerInfo) Supports(service string) bool {
+	return wi.Status != WorkerNegotiated || slices.Contains(wi.Services, service)
+}
+
+// HasFeature reports whether the worker has feature. Until the worker has
+// negotiated every feature counts as present, like Supports; legacy workers
+// predate all features.
+func (wi WorkerInfo) HasFeature(feature string) bool {
+	switch wi.Status {
+	case WorkerNegotiated:
+		return slices.Contains(wi.Features, feature)
+	case WorkerLegacy:
+		return false
+	}
+	return true
+}
+
+// Missing returns the KnownServices the worker does not implement.
+func (wi WorkerInfo) Missing() []string {
+	out := []string{}
+	for _, s := range KnownServices {
+		if !wi.Supports(s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Worker returns what was last negotiated with the worker.
+func (c *Client) Worker() WorkerInfo {
+	if wi := c.worker.Load(); wi != nil {
+		return *wi
+	}
+	return WorkerInfo{Status: WorkerUnknown, Services: []string{}, Features: []string{}}
+}
+
+// Supports reports whether the connected worker implements service.
+func (c *Client) Supports(service string) bool {
+	return c.Worker().Supports(service)
+}
+
+// Negotiate asks the worker for its version and capabilities and records
+// the answer. A worker without WorkerInfoService is recorded as legacy.
+func (c *Client) Negotiate(ctx context.Context) (WorkerInfo, error) {
+	if c.conn == nil {
+		return c.Worker(), status.Error(codes.Unavailable, "worker not connected")
+	}
+	ctx, cancel := context.WithTimeout(ctx, negotiateTimeout)
+	defer cancel()
+
+	resp, err := pb.NewWorkerInfoServiceClient(c.conn).GetVersion(ctx, &pb.GetVersionRequest{})
+	now := time.Now().UTC()
+	wi := WorkerInfo{Status: WorkerNegotiated, Services: []string{}, Features: []string{}, CheckedAt: &now}
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		wi.Status = WorkerLegacy
+	case err != nil:
+		return c.Worker(), err
+	default:
+		wi.Version = resp.GetVersion()
+		wi.Services = nonEmpty(resp.GetServices())
+		wi.Features = nonEmpty(resp.GetFeatures())
+	}
+	c.worker.Store(&wi)
+	return wi, nil
+}
+
+// WatchWorker negotiates with the worker every time the connection becomes
+// ready, so a restarted or upgraded worker is picked up, until ctx is done.
+func (c *Client) WatchWorker(ctx context.Context) {
+	if c.conn == nil {
+		return
+	}
+	c.conn.Connect()
+	for {
+		state := c.conn.GetState()
+		if state == connectivity.Ready {
+			wi, err := c.Negotiate(ctx)
+			if err != nil {
+				log.Printf("WARNING: worker version check failed: %v", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(negotiateRetry):
+				}
+				continue
+			}
+			logWorker(wi)
+		}
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return
+		}
+		if c.conn.GetState() == connectivity.Idle {
+			c.conn.Connect()
+		}
+	}
+}
+
+func logWorker(wi WorkerInfo) {
+	if wi.Status == WorkerLegacy {
+		log.Println("worker predates version negotiation; assuming all services are available")
+		return
+	}
+	log.Printf("worker version %s, services: %s", wi.Version, strings.Join(wi.Services, ", "))
+	if missing := wi.Missing(); len(missing) > 0 {
+		log.Printf("WARNING: worker does not implement %s; their routes answer 501", strings.Join(missing, ", "))
+	}
+}
+
+// nonEmpty returns the non-empty strings of list.
+func nonEmpty(list []string) []string {
+	out := []string{}
+	for _, s := range list {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package grpc
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	"github.com/alfagnish/ollqd-gateway/internal/grpc/grpctest"
+	"google.golang.org/grpc"
+)
+
+// fixedWorkerInfo answers GetVersion with resp.
+type fixedWorkerInfo struct {
+	pb.UnimplementedWorkerInfoServiceServer
+	resp *pb.GetVersionResponse
+}
+
+func (s *fixedWorkerInfo) GetVersion(context.Context, *pb.GetVersionRequest) (*pb.GetVersionResponse, error) {
+	return s.resp, nil
+}
+
+func TestNegotiateRecordsWorker(t *testing.T) {
+	srv := &fixedWorkerInfo{resp: &pb.GetVersionResponse{
+		Version:  "0.3.0",
+		Services: []string{ServiceIndexing, "", ServiceSearch},
+		Features: []string{FeatureChatHistory},
+	}}
+	conn := grpctest.Dial(t, func(s *grpc.Server) { pb.RegisterWorkerInfoServiceServer(s, srv) })
+	c := NewClientConn(conn)
+
+	wi, err := c.Negotiate(context.Background())
+	if err != nil {
+		t.Fatalf("Negotiate: %v", err)
+	}
+	if wi.Status != WorkerNegotiated || wi.Version != "0.3.0" {
+		t.Errorf("worker = %+v", wi)
+	}
+	if !slices.Equal(wi.Services, []string{ServiceIndexing, ServiceSearch}) {
+		t.Errorf("services = %v", wi.Services)
+	}
+	if !c.Worker().HasFeature(FeatureChatHistory) || c.Worker().HasFeature(FeatureSMBKerberos) {
+		t.Errorf("features = %v", c.Worker().Features)
+	}
+	if c.Supports(ServiceOCR) {
+		t.Errorf("unreported %s counted as supported", ServiceOCR)
+	}
+}
+
+func TestNegotiateLegacyWorker(t *testing.T) {
+	conn := grpctest.Dial(t, func(*grpc.Server) {})
+	c := NewClientConn(conn)
+
+	wi, err := c.Negotiate(context.Background())
+	if err != nil {
+		t.Fatalf("Negotiate: %v", err)
+	}
+	if wi.Status != WorkerLegacy || !c.Supports(ServiceOCR) {
+		t.Errorf("worker = %+v, want legacy with every service", wi)
+	}
+}
@@ -0,0 +1,258 @@
+// Package grpcserver exposes the gateway's task API over gRPC for internal
+// services that want to trigger indexing without HTTP. See
+// proto/ollqd/v1/gateway.proto for the service definitions.
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/ignore"
+	"github.com/alfagnish/ollqd-gateway/internal/imagemeta"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MDPriority is the incoming metadata key selecting a task's queue priority.
+const MDPriority = "x-ollqd-priority"
+
+// ResolveFunc fills in the default collection and template chunking for an
+// index request, as the HTTP handlers do.
+type ResolveFunc func(collection string, chunkSize, chunkOverlap int32) (string, int32, int32)
+
+// AdmitFunc runs the disk and memory guardrails for a task expected to
+// write incoming bytes (0 when unknown). It returns the warnings to record
+// on the task, or an error when the task must be refused.
+type AdmitFunc func(ctx context.Context, incoming int64) ([]string, error)
+
+// SkipFunc merges the ignore profiles for a collection ("" = the worker's
+// default) into the extra_skip_dirs of a codebase index request, returning
+// the merged entries and the profiles applied.
+type SkipFunc func(collection string, extra []string) ([]string, []string)
+
+// Server implements TaskService and IndexService on top of the gateway's
+// task manager and worker client.
+type Server struct {
+	pb.UnimplementedTaskServiceServer
+	pb.UnimplementedIndexServiceServer
+
+	gc      *grpcclient.Client
+	tm      *tasks.Manager
+	resolve ResolveFunc
+	skip    SkipFunc
+	meta    *imagemeta.Attacher
+	admit   AdmitFunc
+	token   string
+}
+
+// New creates a gRPC server with TaskService and IndexService registered.
+// When token is non-empty, callers must send "authorization: Bearer <token>".
+func New(gc *grpcclient.Client, tm *tasks.Manager, resolve ResolveFunc, skip SkipFunc, meta *imagemeta.Attacher, admit AdmitFunc, token string) *grpc.Server {
+	s := &Server{gc: gc, tm: tm, resolve: resolve, skip: skip, meta: meta, admit: admit, token: token}
+	g := grpc.NewServer(grpc.UnaryInterceptor(s.authenticate))
+	pb.RegisterTaskServiceServer(g, s)
+	pb.RegisterIndexServiceServer(g, s)
+	return g
+}
+
+func (s *Server) authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if s.token == "" {
+		return handler(ctx, req)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		got := strings.TrimPrefix(v, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) == 1 {
+			return handler(ctx, req)
+		}
+	}
+	return nil, status.Error(codes.Unauthenticated, "invalid or missing token")
+}
+
+// ── TaskService ───────────────────────────────────────────
+
+// Create starts the task whose request is set.
+func (s *Server) Create(ctx context.Context, req *pb.CreateTaskRequest) (*pb.Task, error) {
+	priority, err := tasks.ParsePriority(req.GetPriority())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	switch p := req.GetParams().(type) {
+	case *pb.CreateTaskRequest_IndexCodebase:
+		return s.indexCodebase(ctx, p.IndexCodebase, priority)
+	case *pb.CreateTaskRequest_IndexDocuments:
+		return s.indexDocuments(ctx, p.IndexDocuments, priority)
+	case *pb.CreateTaskRequest_IndexImages:
+		return s.indexImages(ctx, p.IndexImages, priority)
+	}
+	return nil, status.Error(codes.InvalidArgument, "no task request set")
+}
+
+// Get returns a single task.
+func (s *Server) Get(ctx context.Context, req *pb.GetTaskRequest) (*pb.Task, error) {
+	t := s.tm.Get(req.GetTaskId())
+	if t == nil {
+		return nil, status.Errorf(codes.NotFound, "task %s not found", req.GetTaskId())
+	}
+	return toTask(t)
+}
+
+// List returns all tasks.
+func (s *Server) List(ctx context.Context, _ *pb.ListTasksRequest) (*pb.ListTasksResponse, error) {
+	resp := &pb.ListTasksResponse{}
+	for _, t := range s.tm.List() {
+		pt, err := toTask(t)
+		if err != nil {
+			return nil, err
+		}
+		resp.Tasks = append(resp.Tasks, pt)
+	}
+	return resp, nil
+}
+
+// Cancel cancels a running or queued task and returns it; a task that had
+// already finished keeps its state.
+func (s *Server) Cancel(ctx context.Context, req *grpcclient.CancelTaskRequest) (*pb.Task, error) {
+	id := req.GetTaskId()
+	if !s.tm.Cancel(id) {
+		return nil, status.Errorf(codes.NotFound, "task %s not found", id)
+	}
+	t := s.tm.Get(id)
+	if t == nil {
+		return &pb.Task{TaskId: id, Status: string(tasks.StatusCancelled)}, nil
+	}
+	return toTask(t)
+}
+
+// ── IndexService ──────────────────────────────────────────
+
+// IndexCodebase queues a codebase indexing task.
+func (s *Server) IndexCodebase(ctx context.Context, req *grpcclient.IndexCodebaseRequest) (*pb.Task, error) {
+	priority, err := priorityFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.indexCodebase(ctx, req, priority)
+}
+
+// IndexDocuments queues a document indexing task.
+func (s *Server) IndexDocuments(ctx context.Context, req *grpcclient.IndexDocumentsRequest) (*pb.Task, error) {
+	priority, err := priorityFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.indexDocuments(ctx, req, priority)
+}
+
+// IndexImages queues an image indexing task.
+func (s *Server) IndexImages(ctx context.Context, req *grpcclient.IndexImagesRequest) (*pb.Task, error) {
+	priority, err := priorityFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.indexImages(ctx, req, priority)
+}
+
+func (s *Server) indexCodebase(ctx context.Context, req *grpcclient.IndexCodebaseRequest, priority tasks.Priority) (*pb.Task, error) {
+	if _, err := ignore.New(req.ExtraSkipDirs); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "extra_skip_dirs: %v", err)
+	}
+	req.Collection, req.ChunkSize, req.ChunkOverlap = s.resolve(req.Collection, req.ChunkSize, req.ChunkOverlap)
+	var profiles []string
+	req.ExtraSkipDirs, profiles = s.skip(req.Collection, req.ExtraSkipDirs)
+	return s.launch(ctx, "index_codebase", grpcclient.SourceCodebase, priority, map[string]interface{}{
+		"root_path":       req.RootPath,
+		"collection":      req.Collection,
+		"incremental":     req.Incremental,
+		"chunk_size":      req.ChunkSize,
+		"chunk_overlap":   req.ChunkOverlap,
+		"extra_skip_dirs": req.ExtraSkipDirs,
+		"ignore_profiles": profiles,
+	}, func(ctx context.Context) (grpcclient.IndexingStream, error) {
+		return s.gc.Indexing.IndexCodebase(ctx, req)
+	}, nil)
+}
+
+func (s *Server) indexDocuments(ctx context.Context, req *grpcclient.IndexDocumentsRequest, priority tasks.Priority) (*pb.Task, error) {
+	req.Collection, req.ChunkSize, req.ChunkOverlap = s.resolve(req.Collection, req.ChunkSize, req.ChunkOverlap)
+	return s.launch(ctx, "index_documents", grpcclient.SourceDocuments, priority, map[string]interface{}{
+		"paths":         req.Paths,
+		"collection":    req.Collection,
+		"chunk_size":    req.ChunkSize,
+		"chunk_overlap": req.ChunkOverlap,
+		"source_tag":    req.SourceTag,
+	}, func(ctx context.Context) (grpcclient.IndexingStream, error) {
+		return s.gc.Indexing.IndexDocuments(ctx, req)
+	}, nil)
+}
+
+func (s *Server) indexImages(ctx context.Context, req *grpcclient.IndexImagesRequest, priority tasks.Priority) (*pb.Task, error) {
+	req.Collection, _, _ = s.resolve(req.Collection, 0, 0)
+	return s.launch(ctx, "index_images", grpcclient.SourceImages, priority, map[string]interface{}{
+		"root_path":         req.RootPath,
+		"collection":        req.Collection,
+		"vision_model":      req.VisionModel,
+		"caption_prompt":    req.CaptionPrompt,
+		"incremental":       req.Incremental,
+		"max_image_size_kb": req.MaxImageSizeKb,
+		"extra_skip_dirs":   req.ExtraSkipDirs,
+	}, func(ctx context.Context) (grpcclient.IndexingStream, error) {
+		return s.gc.Indexing.IndexImages(ctx, req)
+	}, func(ctx context.Context) (context.Context, func()) {
+		return s.meta.AttachDir(ctx, req.RootPath, req.ExtraSkipDirs)
+	})
+}
+
+// launch creates a task with the same params the HTTP handlers store (so
+// REST retries work) and queues its worker stream. prepare, if set, runs
+// when the task starts and may attach metadata to the stream context.
+// gRPC callers have no user, so the provenance names no uploader. Like the
+// HTTP routes, it refuses tasks the disk and memory guardrails reject.
+func (s *Server) launch(ctx context.Context, taskType, source string, priority tasks.Priority, params map[string]interface{}, open func(context.Context) (grpcclient.IndexingStream, error), prepare func(context.Context) (context.Context, func())) (*pb.Task, error) {
+	if s.gc.Indexing == nil {
+		return nil, status.Error(codes.Unavailable, "indexing service not available")
+	}
+	warnings, err := s.admit(ctx, 0)
+	if err != nil {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	params["priority"] = string(priority)
+
+	taskID := s.tm.Create(taskType, params)
+	s.tm.AddWarnings(taskID, warnings)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.tm.SetCancelFunc(taskID, cancel)
+
+	s.tm.Enqueue(taskID, priority, func() {
+		sctx := ctx
+		if prepare != nil {
+			var cleanup func()
+			sctx, cleanup = prepare(ctx)
+			defer cleanup()
+		}
+		s.tm.ConsumeIndexStream(ctx, s.gc, taskID, func() (grpcclient.IndexingStream, error) {
+			return open(grpcclient.WithProvenance(sctx, grpcclient.Provenance{SourceType: source, TaskID: taskID}))
+		})
+	})
+	return toTask(s.tm.Get(taskID))
+}
+
+func priorityFromMetadata(ctx context.Context) (tasks.Priority, error) {
+	var raw string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(MDPriority); len(v) > 0 {
+			raw = v[0]
+		}
+	}
+	p, err := tasks.ParsePriority(raw)
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	return p, nil
+}
@@ -0,0 +1,49 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/grpc/grpctest"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTaskServiceTypedTasks(t *testing.T) {
+	tm := tasks.NewManager(t.TempDir(), 1)
+	s := &Server{tm: tm}
+	conn := grpctest.Dial(t, func(g *grpc.Server) { pb.RegisterTaskServiceServer(g, s) })
+	client := pb.NewTaskServiceClient(conn)
+	ctx := context.Background()
+
+	id := tm.Create("index_documents", map[string]interface{}{"paths": []string{"/docs/a.md"}, "collection": "docs"})
+	tm.Complete(id, map[string]string{"indexed": "1"})
+
+	got, err := client.Get(ctx, &pb.GetTaskRequest{TaskId: id})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.GetStatus() != string(tasks.StatusCompleted) || got.GetResult()["indexed"] != "1" || got.GetCreatedAt() == "" {
+		t.Errorf("Get = %v", got)
+	}
+	paths := got.GetRequestParams().GetFields()["paths"].GetListValue().GetValues()
+	if len(paths) != 1 || paths[0].GetStringValue() != "/docs/a.md" {
+		t.Errorf("request_params = %v", got.GetRequestParams())
+	}
+
+	list, err := client.List(ctx, &pb.ListTasksRequest{})
+	if err != nil || len(list.GetTasks()) != 1 || list.GetTasks()[0].GetTaskId() != id {
+		t.Errorf("List = %v, %v", list, err)
+	}
+
+	if _, err := client.Cancel(ctx, &grpcclient.CancelTaskRequest{TaskId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Cancel(missing) = %v, want NotFound", err)
+	}
+	if _, err := client.Create(ctx, &pb.CreateTaskRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Create without a request = %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,79 @@
+package grpcserver
+
+import (
+	"encoding/json"
+	"time"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// toTask converts a task to its gRPC message, with the field names and
+// values of the REST API.
+func toTask(t *tasks.TaskInfo) (*pb.Task, error) {
+	out := &pb.Task{
+		TaskId:           t.ID,
+		Type:             t.Type,
+		Status:           string(t.Status),
+		Progress:         t.Progress,
+		Priority:         string(t.Priority),
+		Result:           t.Result,
+		Error:            t.Error,
+		CancelReason:     t.CancelReason,
+		CreatedAt:        formatTime(&t.CreatedAt),
+		StartedAt:        formatTime(t.StartedAt),
+		CompletedAt:      formatTime(t.CompletedAt),
+		ParamsDropped:    t.ParamsDropped,
+		WorkerTaskId:     t.WorkerTaskID,
+		LockedCollection: t.LockedCollection,
+		LastProgressAt:   formatTime(t.LastProgressAt),
+		Stalled:          t.Stalled,
+		StalledSince:     formatTime(t.StalledSince),
+		Warnings:         t.Warnings,
+		ErrorCount:       int32(t.ErrorCount),
+		ErrorsDropped:    int32(t.ErrorsDropped),
+		Node:             t.Node,
+	}
+	for _, a := range t.Artifacts {
+		out.Artifacts = append(out.Artifacts, &pb.TaskArtifact{
+			Name:        a.Name,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+			CreatedAt:   formatTime(&a.CreatedAt),
+		})
+	}
+	if t.RequestParams != nil {
+		params, err := toStruct(t.RequestParams)
+		if err != nil {
+			return nil, err
+		}
+		out.RequestParams = params
+	}
+	return out, nil
+}
+
+// formatTime formats t as RFC 3339 UTC, or "" when unset.
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// toStruct converts a JSON-serialisable value into a protobuf Struct using
+// the same field names as the REST API.
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	out := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, out); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return out, nil
+}
@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/fulltext"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// auditFile is the audit log in the data directory, one JSON entry per
+// line, oldest first.
+const auditFile = "audit.jsonl"
+
+// auditFields are the fields of an audit entry a query can name, as in
+// "user:alice".
+var auditFields = []string{"user", "method", "path", "route", "status", "ip"}
+
+// auditReadOnlyRoutes are POST routes that only read or compute. They are
+// left out of the audit log like GET requests.
+var auditReadOnlyRoutes = []string{
+	"/api/rag/search",
+	"/api/rag/index/estimate",
+	"/api/rag/image/caption",
+	"/api/ollama/embeddings",
+	"/api/ollama/models/show",
+	"/api/system/debug/",
+	"/v1/",
+	"/embed/",
+}
+
+// AuditEntry is one change made through the API.
+type AuditEntry struct {
+	ID       string    `json:"id"`
+	At       time.Time `json:"at"`
+	User     string    `json:"user"`
+	Role     string    `json:"role,omitempty"`
+	Method   string    `json:"method"`
+	Path     string    `json:"path"`
+	Route    string    `json:"route,omitempty"`
+	Status   int       `json:"status"`
+	RemoteIP string    `json:"remote_ip,omitempty"`
+}
+
+// AuditLog records every POST, PUT, PATCH and DELETE request with who made
+// it and how it ended, and keeps the most recent entries searchable.
+// Entries are appended to audit.jsonl in the data directory; the file is
+// compacted to the kept entries once it holds twice as many.
+type AuditLog struct {
+	path string
+	max  int
+
+	mu      sync.Mutex
+	entries []AuditEntry
+	lines   int // entries in the file
+	seq     uint64
+	index   *fulltext.Index
+}
+
+// NewAuditLog loads the audit log kept in dir and keeps the last max
+// entries (0 = none; recording is off). An empty dir keeps entries in
+// memory only.
+func NewAuditLog(dir string, max int) *AuditLog {
+	a := &AuditLog{max: max, index: fulltext.New()}
+	if dir != "" {
+		a.path = filepath.Join(dir, auditFile)
+	}
+	if max <= 0 || a.path == "" {
+		return a
+	}
+	f, err := os.Open(a.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("WARNING: reading audit log: %v", err)
+		}
+		return a
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		var e AuditEntry
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			continue
+		}
+		a.lines++
+		a.appendLocked(e)
+		if n, err := strconv.ParseUint(e.ID, 10, 64); err == nil && n > a.seq {
+			a.seq = n
+		}
+	}
+	if err := sc.Err(); err != nil {
+		log.Printf("WARNING: reading audit log: %v", err)
+	}
+	return a
+}
+
+// Record adds an entry, numbering it and stamping it with the current time.
+func (a *AuditLog) Record(e AuditEntry) {
+	if a == nil || a.max <= 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seq++
+	e.ID = strconv.FormatUint(a.seq, 10)
+	e.At = time.Now().UTC()
+	a.appendLocked(e)
+	if a.path == "" {
+		return
+	}
+	if err := a.writeLocked(e); err != nil {
+		log.Printf("WARNING: writing audit log: %v", err)
+	}
+}
+
+// appendLocked keeps e in memory and the index, dropping the oldest entry
+// beyond max. Callers must hold a.mu.
+func (a *AuditLog) appendLocked(e AuditEntry) {
+	a.entries = append(a.entries, e)
+	a.index.Put(auditDoc(e))
+	if len(a.entries) > a.max {
+		a.index.Remove(a.entries[0].ID)
+		a.entries = a.entries[1:]
+	}
+}
+
+// writeLocked appends e to the file, or rewrites the file with the kept
+// entries once it holds twice max. Callers must hold a.mu.
+func (a *AuditLog) writeLocked(e AuditEntry) error {
+	if a.lines+1 >= 2*a.max {
+		tmp := a.path + ".tmp"
+		f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(f)
+		enc := json.NewEncoder(w)
+		for _, kept := range a.entries {
+			enc.Encode(kept)
+		}
+		if err := w.Flush(); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, a.path); err != nil {
+			return err
+		}
+		a.lines = len(a.entries)
+		return nil
+	}
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(e); err != nil {
+		return err
+	}
+	a.lines++
+	return nil
+}
+
+// auditDoc is the searchable text of an audit entry.
+func auditDoc(e AuditEntry) fulltext.Doc {
+	return fulltext.Doc{
+		ID: e.ID,
+		At: e.At,
+		Fields: map[string]string{
+			"user":   e.User + " " + e.Role,
+			"method": e.Method,
+			"path":   e.Path,
+			"route":  e.Route,
+			"status": strconv.Itoa(e.Status) + " " + http.StatusText(e.Status),
+			"ip":     e.RemoteIP,
+		},
+	}
+}
+
+// entry returns the entry with the given ID.
+func (a *AuditLog) entry(id string) (AuditEntry, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// IDs grow with position, so search from the newest end.
+	for i := len(a.entries) - 1; i >= 0; i-- {
+		if a.entries[i].ID == id {
+			return a.entries[i], true
+		}
+	}
+	return AuditEntry{}, false
+}
+
+// auditReadOnly reports whether a request to the route pattern changes
+// nothing and stays out of the log.
+func auditReadOnly(r *http.Request, pattern string) bool {
+	if strings.HasSuffix(pattern, "/test") || strings.HasSuffix(pattern, "/compare") ||
+		r.URL.Query().Get("dry_run") == "true" {
+		return true
+	}
+	for _, prefix := range auditReadOnlyRoutes {
+		if strings.HasPrefix(pattern, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware records every request that may change something once it has
+// been answered, including refused ones. Requests to unknown routes and
+// read-only POST routes are not recorded. Query strings are left out, as
+// they can carry tokens.
+func (a *AuditLog) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if a == nil || a.max <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		rctx := chi.RouteContext(r.Context())
+		if rctx == nil {
+			return
+		}
+		pattern := rctx.RoutePattern()
+		if pattern == "" || auditReadOnly(r, pattern) {
+			return
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		a.Record(AuditEntry{
+			User:     middleware.UsernameFromContext(r.Context()),
+			Role:     middleware.RoleFromContext(r.Context()),
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			Route:    pattern,
+			Status:   status,
+			RemoteIP: ip,
+		})
+	})
+}
+
+// AuditHandler serves the audit log to admins.
+type AuditHandler struct {
+	log *AuditLog
+}
+
+// NewAuditHandler creates an AuditHandler for the given log.
+func NewAuditHandler(a *AuditLog) *AuditHandler {
+	return &AuditHandler{log: a}
+}
+
+// Routes registers the audit routes on the given chi router.
+func (h *AuditHandler) Routes(r chi.Router) {
+	r.Get("/", h.List)
+	r.Get("/search", h.Search)
+}
+
+// List returns the most recent audit entries, newest first. limit (default
+// 100) bounds them.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	h.log.mu.Lock()
+	total := len(h.log.entries)
+	out := make([]AuditEntry, 0, min(limit, total))
+	for i := total - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, h.log.entries[i])
+	}
+	h.log.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"entries": out,
+		"count":   len(out),
+		"total":   total,
+	})
+}
+
+// Search finds audit entries by user, method, path, route, status and
+// client IP.
+func (h *AuditHandler) Search(w http.ResponseWriter, r *http.Request) {
+	q, limit, ok := parseSearchRequest(w, r, auditFields)
+	if !ok {
+		return
+	}
+	hits, total := h.log.index.Search(q, limit)
+	results := make([]map[string]interface{}, 0, len(hits))
+	for _, hit := range hits {
+		e, ok := h.log.entry(hit.ID)
+		if !ok {
+			continue
+		}
+		results = append(results, map[string]interface{}{
+			"entry":   e,
+			"score":   hit.Score,
+			"matched": hit.Fields,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"query":   r.URL.Query().Get("q"),
+		"results": results,
+		"count":   len(results),
+		"total":   total,
+	})
+}
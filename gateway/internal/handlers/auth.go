@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 
 	"github.com/alfagnish/ollqd-gateway/internal/config"
@@ -13,20 +12,21 @@ import (
 
 // AuthHandler provides login/logout/me endpoints.
 type AuthHandler struct {
-	cfg  *config.Config
-	grpc *grpcclient.Client
+	cfg      *config.Config
+	grpc     *grpcclient.Client
+	sessions *middleware.SessionStore
 }
 
 // NewAuthHandler creates a new AuthHandler.
-func NewAuthHandler(cfg *config.Config, gc *grpcclient.Client) *AuthHandler {
-	return &AuthHandler{cfg: cfg, grpc: gc}
+func NewAuthHandler(cfg *config.Config, gc *grpcclient.Client, sessions *middleware.SessionStore) *AuthHandler {
+	return &AuthHandler{cfg: cfg, grpc: gc, sessions: sessions}
 }
 
 // Routes registers auth routes on the given chi router.
 func (h *AuthHandler) Routes(r chi.Router) {
 	r.Post("/login", h.Login)
 	r.Post("/logout", h.Logout)
-	r.With(middleware.RequireAuth(h.cfg.JWTSecret)).Get("/me", h.Me)
+	r.Get("/me", h.Me)
 }
 
 // Login authenticates a user and sets an HttpOnly cookie.
@@ -45,7 +45,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		Password: req.Password,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -54,8 +54,10 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := middleware.GenerateToken(h.cfg.JWTSecret, resp.Username, resp.Role)
+	sess := h.sessions.Create(resp.Username, resp.Role, r)
+	token, err := middleware.GenerateToken(h.cfg.JWTSecret, resp.Username, resp.Role, sess.ID)
 	if err != nil {
+		h.sessions.Revoke(sess.ID)
 		writeError(w, http.StatusInternalServerError, "failed to generate token")
 		return
 	}
@@ -76,8 +78,12 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Logout clears the auth cookie.
+// Logout ends the caller's session and clears the auth cookie.
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if claims, err := middleware.ParseToken(h.cfg.JWTSecret, middleware.TokenFromRequest(r)); err == nil {
+		h.sessions.Revoke(claims.ID)
+	}
+
 	http.SetCookie(w, &http.Cookie{
 		Name:     middleware.CookieName,
 		Value:    "",
@@ -89,7 +95,8 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "logged out"})
 }
 
-// Me returns the current authenticated user's info.
+// Me returns the current user's info. The auth policy applied in
+// server.New guarantees the identity is set before Me runs.
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{
 		"username": middleware.UsernameFromContext(r.Context()),
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// brandingDoc is the store document holding the branding settings; the
+// logo itself is kept next to it in brandingLogoFile.
+const (
+	brandingDoc      = "branding"
+	brandingLogoFile = "branding-logo"
+)
+
+const (
+	// maxLogoSize bounds an uploaded logo.
+	maxLogoSize = 1 << 20
+	// maxBrandingText bounds the title and subtitle, in characters.
+	maxBrandingText = 80
+)
+
+// logoTypes are the accepted logo content types.
+var logoTypes = map[string]bool{
+	"image/png":     true,
+	"image/jpeg":    true,
+	"image/gif":     true,
+	"image/webp":    true,
+	"image/svg+xml": true,
+	"image/x-icon":  true,
+}
+
+// brandingColors are the theme colours the SPA applies as CSS variables.
+var brandingColors = map[string]bool{
+	"primary":    true,
+	"accent":     true,
+	"background": true,
+	"text":       true,
+}
+
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// BrandingConfig customises the web UI. Empty fields keep the built-in
+// look.
+type BrandingConfig struct {
+	Title    string `json:"title,omitempty"`
+	Subtitle string `json:"subtitle,omitempty"`
+	// Colors maps "primary", "accent", "background" and "text" to hex
+	// colours.
+	Colors map[string]string `json:"colors,omitempty"`
+
+	// LogoType is the content type of the uploaded logo, if any.
+	LogoType  string     `json:"logo_type,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// validate checks c, returning an error naming the offending field.
+func (c *BrandingConfig) validate() error {
+	c.Title = strings.TrimSpace(c.Title)
+	c.Subtitle = strings.TrimSpace(c.Subtitle)
+	if utf8.RuneCountInString(c.Title) > maxBrandingText {
+		return fmt.Errorf("title must be at most %d characters", maxBrandingText)
+	}
+	if utf8.RuneCountInString(c.Subtitle) > maxBrandingText {
+		return fmt.Errorf("subtitle must be at most %d characters", maxBrandingText)
+	}
+	for name, color := range c.Colors {
+		if !brandingColors[name] {
+			return fmt.Errorf("unknown color %q (want primary, accent, background or text)", name)
+		}
+		if !hexColor.MatchString(color) {
+			return fmt.Errorf("color %s must be a hex colour such as #1d4ed8", name)
+		}
+	}
+	return nil
+}
+
+// Branding holds the UI branding, persisted in the gateway store.
+type Branding struct {
+	mu    sync.RWMutex
+	store *store.Store
+	data  BrandingConfig
+}
+
+// NewBranding loads the branding from st.
+func NewBranding(st *store.Store) *Branding {
+	b := &Branding{store: st}
+	if _, err := st.Load(brandingDoc, &b.data); err != nil {
+		log.Printf("WARNING: branding: %v", err)
+	}
+	return b
+}
+
+// Get returns the current branding.
+func (b *Branding) Get() BrandingConfig {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.data
+}
+
+// logoPath is where the logo is stored on disk.
+func (b *Branding) logoPath() string {
+	return filepath.Join(b.store.Dir(), brandingLogoFile)
+}
+
+// Set replaces the title, subtitle and colours, keeping the logo.
+func (b *Branding) Set(c BrandingConfig) (BrandingConfig, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c.LogoType = b.data.LogoType
+	return b.saveLocked(c)
+}
+
+// SetLogo stores a logo of the given content type.
+func (b *Branding) SetLogo(data []byte, contentType string) (BrandingConfig, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tmp := b.logoPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return b.data, err
+	}
+	if err := os.Rename(tmp, b.logoPath()); err != nil {
+		os.Remove(tmp)
+		return b.data, err
+	}
+	c := b.data
+	c.LogoType = contentType
+	return b.saveLocked(c)
+}
+
+// RemoveLogo deletes the logo, restoring the built-in one.
+func (b *Branding) RemoveLogo() (BrandingConfig, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := os.Remove(b.logoPath()); err != nil && !os.IsNotExist(err) {
+		return b.data, err
+	}
+	c := b.data
+	c.LogoType = ""
+	return b.saveLocked(c)
+}
+
+// Reset removes all branding.
+func (b *Branding) Reset() (BrandingConfig, error) {
+	if _, err := b.RemoveLogo(); err != nil {
+		return b.Get(), err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.saveLocked(BrandingConfig{})
+}
+
+// saveLocked persists c as the branding and returns it as saved.
+func (b *Branding) saveLocked(c BrandingConfig) (BrandingConfig, error) {
+	now := time.Now().UTC()
+	c.UpdatedAt = &now
+	if err := b.store.Save(brandingDoc, c); err != nil {
+		return b.data, err
+	}
+	b.data = c
+	return c, nil
+}
+
+// BrandingHandler serves /api/system/branding. Reading the branding is
+// public, since the login screen shows it; changing it is admin-only.
+type BrandingHandler struct {
+	branding *Branding
+}
+
+// NewBrandingHandler creates a new BrandingHandler.
+func NewBrandingHandler(b *Branding) *BrandingHandler {
+	return &BrandingHandler{branding: b}
+}
+
+// Routes registers the branding routes on the given chi router.
+func (h *BrandingHandler) Routes(r chi.Router) {
+	r.Get("/", h.Get)
+	r.Get("/logo", h.Logo)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireAdmin)
+		r.Put("/", h.Put)
+		r.Delete("/", h.Reset)
+		r.Put("/logo", h.PutLogo)
+		r.Delete("/logo", h.DeleteLogo)
+	})
+}
+
+// brandingResponse adds the logo URL to c. The URL changes with every
+// update so browsers do not keep showing a replaced logo.
+func brandingResponse(r *http.Request, c BrandingConfig) map[string]interface{} {
+	colors := c.Colors
+	if colors == nil {
+		colors = map[string]string{}
+	}
+	out := map[string]interface{}{
+		"title":      c.Title,
+		"subtitle":   c.Subtitle,
+		"colors":     colors,
+		"logo_url":   nil,
+		"updated_at": c.UpdatedAt,
+	}
+	if c.LogoType != "" && c.UpdatedAt != nil {
+		out["logo_url"] = middleware.ExternalURL(r, fmt.Sprintf("/api/system/branding/logo?v=%d", c.UpdatedAt.Unix()))
+	}
+	return out
+}
+
+// Get returns the branding.
+func (h *BrandingHandler) Get(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, brandingResponse(r, h.branding.Get()))
+}
+
+// Put replaces the title, subtitle and colours. Omitted fields are
+// cleared; the logo is kept.
+func (h *BrandingHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var req BrandingConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	saved, err := h.branding.Set(req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, brandingResponse(r, saved))
+}
+
+// Reset removes the branding and the logo.
+func (h *BrandingHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	saved, err := h.branding.Reset()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, brandingResponse(r, saved))
+}
+
+// PutLogo stores the logo sent as the multipart field "file". PNG, JPEG,
+// GIF, WebP, SVG and ICO files of up to 1 MB are accepted.
+func (h *BrandingHandler) PutLogo(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxLogoSize+64<<10)
+	if err := r.ParseMultipartForm(maxLogoSize); err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("logo exceeds maximum size of %d KB", maxLogoSize>>10))
+		return
+	}
+	f, hdr, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "missing 'file' field")
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxLogoSize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(data) > maxLogoSize {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("logo exceeds maximum size of %d KB", maxLogoSize>>10))
+		return
+	}
+
+	contentType := logoContentType(data, hdr.Filename)
+	if !logoTypes[contentType] {
+		writeError(w, http.StatusUnsupportedMediaType, "logo must be a PNG, JPEG, GIF, WebP, SVG or ICO image")
+		return
+	}
+	saved, err := h.branding.SetLogo(data, contentType)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, brandingResponse(r, saved))
+}
+
+// DeleteLogo removes the logo.
+func (h *BrandingHandler) DeleteLogo(w http.ResponseWriter, r *http.Request) {
+	saved, err := h.branding.RemoveLogo()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, brandingResponse(r, saved))
+}
+
+// Logo serves the uploaded logo. SVG logos are served with a CSP that keeps
+// any script in them from running when opened directly.
+func (h *BrandingHandler) Logo(w http.ResponseWriter, r *http.Request) {
+	c := h.branding.Get()
+	if c.LogoType == "" {
+		writeError(w, http.StatusNotFound, "no logo uploaded")
+		return
+	}
+	f, err := os.Open(h.branding.logoPath())
+	if err != nil {
+		writeError(w, http.StatusNotFound, "no logo uploaded")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", c.LogoType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.ServeContent(w, r, brandingLogoFile, info.ModTime(), f)
+}
+
+// logoContentType sniffs the content type of a logo. SVG and ICO are not
+// recognised by http.DetectContentType and are identified by their content
+// and file name.
+func logoContentType(data []byte, filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	head := bytes.ToLower(data[:min(len(data), 512)])
+	switch {
+	case ext == ".svg" && bytes.Contains(head, []byte("<svg")):
+		return "image/svg+xml"
+	case ext == ".ico" && bytes.HasPrefix(data, []byte{0, 0, 1, 0}):
+		return "image/x-icon"
+	}
+	return http.DetectContentType(data)
+}
@@ -0,0 +1,518 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// captureFile receives captured exchanges while file capture is on,
+	// one JSON entry per line.
+	captureFile = "captures.jsonl"
+	// captureFileMaxBytes is the size at which captures.jsonl is moved to
+	// captures.jsonl.1, replacing the previous one.
+	captureFileMaxBytes = 32 << 20
+	// captureMaxBodyLimit bounds the max_body_bytes an admin can set.
+	captureMaxBodyLimit = 1 << 20
+)
+
+// captureExcluded are route prefixes never captured: logins carry
+// credentials, and the captures would otherwise record themselves.
+var captureExcluded = []string{"/api/auth", "/api/system/captures"}
+
+// CaptureSettings selects what the capture mode records.
+type CaptureSettings struct {
+	Enabled bool `json:"enabled"`
+	// Routes are path prefixes, as in "/api/rag/search"; empty captures
+	// every route.
+	Routes       []string `json:"routes"`
+	MaxBodyBytes int      `json:"max_body_bytes"`
+	// ToFile also appends every exchange to captures.jsonl in the data
+	// directory.
+	ToFile bool `json:"to_file"`
+	// Until turns capturing off at that time; nil keeps it on.
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// active reports whether requests to path are captured at now.
+func (s CaptureSettings) active(path string, now time.Time) bool {
+	if !s.Enabled || (s.Until != nil && now.After(*s.Until)) {
+		return false
+	}
+	for _, prefix := range captureExcluded {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	if len(s.Routes) == 0 {
+		return true
+	}
+	for _, prefix := range s.Routes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CaptureBody is a captured request or response body. Body holds JSON
+// (redacted) as JSON and anything else as a string; Omitted says why a
+// body was not kept.
+type CaptureBody struct {
+	ContentType string          `json:"content_type,omitempty"`
+	Size        int64           `json:"size"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	Truncated   bool            `json:"truncated,omitempty"`
+	Omitted     string          `json:"omitted,omitempty"`
+}
+
+// CaptureEntry is one recorded exchange.
+type CaptureEntry struct {
+	ID         string      `json:"id"`
+	At         time.Time   `json:"at"`
+	DurationMS int64       `json:"duration_ms"`
+	User       string      `json:"user,omitempty"`
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	Query      string      `json:"query,omitempty"`
+	Route      string      `json:"route,omitempty"`
+	Status     int         `json:"status"`
+	Request    CaptureBody `json:"request"`
+	Response   CaptureBody `json:"response"`
+}
+
+// CaptureLog records request and response bodies of selected routes while
+// an admin has capture mode on, for reproducing mismatches between the UI,
+// the gateway and the worker. Sensitive fields are masked as in task
+// listings. The last max exchanges are kept in memory; the mode starts off
+// on every restart.
+type CaptureLog struct {
+	path       string
+	max        int
+	redactKeys []string
+
+	mu       sync.Mutex
+	settings CaptureSettings
+	entries  []CaptureEntry
+	seq      uint64
+}
+
+// NewCaptureLog creates a CaptureLog that keeps the last max exchanges
+// (0 = capture mode unavailable) with bodies of up to maxBody bytes by
+// default. Keys in redactKeys are masked besides the always-masked ones.
+// File capture writes to dir; an empty dir disables it.
+func NewCaptureLog(dir string, max, maxBody int, redactKeys []string) *CaptureLog {
+	c := &CaptureLog{max: max, redactKeys: redactKeys}
+	if dir != "" {
+		c.path = filepath.Join(dir, captureFile)
+	}
+	c.settings = CaptureSettings{Routes: []string{}, MaxBodyBytes: maxBody}
+	return c
+}
+
+// Settings returns the current capture settings. A capture past its Until
+// time reports itself off.
+func (c *CaptureLog) Settings() CaptureSettings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.settings
+	if s.Until != nil && time.Now().After(*s.Until) {
+		s.Enabled = false
+	}
+	return s
+}
+
+// record numbers and keeps an entry, and appends it to the file when file
+// capture is on.
+func (c *CaptureLog) record(e CaptureEntry, toFile bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	e.ID = strconv.FormatUint(c.seq, 10)
+	c.entries = append(c.entries, e)
+	if len(c.entries) > c.max {
+		c.entries = c.entries[1:]
+	}
+	if toFile && c.path != "" {
+		if err := c.writeLocked(e); err != nil {
+			log.Printf("WARNING: writing captures: %v", err)
+		}
+	}
+}
+
+// writeLocked appends e to the capture file, first rotating a file that
+// has grown past captureFileMaxBytes. Callers must hold c.mu.
+func (c *CaptureLog) writeLocked(e CaptureEntry) error {
+	if fi, err := os.Stat(c.path); err == nil && fi.Size() >= captureFileMaxBytes {
+		if err := os.Rename(c.path, c.path+".1"); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	if err := json.NewEncoder(w).Encode(e); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// captureBuffer keeps the first max bytes written to it and counts all.
+type captureBuffer struct {
+	max  int
+	buf  bytes.Buffer
+	size int64
+}
+
+func (b *captureBuffer) Write(p []byte) (int, error) {
+	b.size += int64(len(p))
+	if room := b.max - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
+
+// Middleware records the exchanges capture mode selects. The request body
+// is copied up to max_body_bytes as the handler reads it, the response
+// body as it is written; WebSocket upgrades are passed through.
+func (c *CaptureLog) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c == nil || c.max <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		s := c.Settings()
+		if !s.active(r.URL.Path, start) || strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reqBuf := &captureBuffer{max: s.MaxBodyBytes}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(r.Body, reqBuf), r.Body}
+		respBuf := &captureBuffer{max: s.MaxBodyBytes}
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(respBuf)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		var route string
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+		c.record(CaptureEntry{
+			At:         start.UTC(),
+			DurationMS: time.Since(start).Milliseconds(),
+			User:       middleware.UsernameFromContext(r.Context()),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Query:      c.redactQuery(r.URL.RawQuery),
+			Route:      route,
+			Status:     status,
+			Request:    c.captureBody(r.Header.Get("Content-Type"), reqBuf),
+			Response:   c.captureBody(ww.Header().Get("Content-Type"), respBuf),
+		}, s.ToFile)
+	})
+}
+
+// redactQuery masks sensitive query parameters.
+func (c *CaptureLog) redactQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	q, err := url.ParseQuery(raw)
+	if err != nil {
+		return ""
+	}
+	for k, vs := range q {
+		if tasks.IsSensitiveKey(k, c.redactKeys) {
+			for i := range vs {
+				vs[i] = tasks.RedactedValue
+			}
+		}
+	}
+	return q.Encode()
+}
+
+// captureBody turns a copied body into what is kept of it. JSON and
+// NDJSON are masked after decoding, which needs the whole document: cut
+// short JSON is omitted, and NDJSON keeps its complete lines. Form bodies
+// are masked by key; other text is kept as is, and binary and multipart
+// bodies are omitted.
+func (c *CaptureLog) captureBody(contentType string, b *captureBuffer) CaptureBody {
+	out := CaptureBody{ContentType: contentType, Size: b.size}
+	if b.size == 0 {
+		return out
+	}
+	data := b.buf.Bytes()
+	out.Truncated = int64(len(data)) < b.size
+	mt, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		if out.Truncated {
+			out.Truncated = false
+			out.Omitted = "JSON body larger than max_body_bytes"
+			return out
+		}
+		v, err := decodeCaptureJSON(data)
+		if err != nil {
+			out.Omitted = "invalid JSON"
+			return out
+		}
+		out.Body, _ = json.Marshal(tasks.RedactValue(v, c.redactKeys))
+	case mt == "application/x-ndjson":
+		if out.Truncated {
+			if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+				data = data[:i]
+			} else {
+				data = nil
+			}
+		}
+		lines := []interface{}{}
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			v, err := decodeCaptureJSON(line)
+			if err != nil {
+				out.Omitted = "invalid JSON line"
+				return out
+			}
+			lines = append(lines, tasks.RedactValue(v, c.redactKeys))
+		}
+		out.Body, _ = json.Marshal(lines)
+	case mt == "application/x-www-form-urlencoded":
+		if out.Truncated {
+			out.Truncated = false
+			out.Omitted = "form body larger than max_body_bytes"
+			return out
+		}
+		out.Body, _ = json.Marshal(c.redactQuery(string(data)))
+	case strings.HasPrefix(mt, "text/"):
+		out.Body, _ = json.Marshal(strings.ToValidUTF8(string(data), "�"))
+	default:
+		out.Truncated = false
+		out.Omitted = "binary or multipart body"
+	}
+	return out
+}
+
+// decodeCaptureJSON decodes one JSON document, keeping numbers as written.
+func decodeCaptureJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("trailing data")
+	}
+	return v, nil
+}
+
+// CaptureHandler serves capture mode to admins.
+type CaptureHandler struct {
+	log *CaptureLog
+}
+
+// NewCaptureHandler creates a CaptureHandler for the given log.
+func NewCaptureHandler(c *CaptureLog) *CaptureHandler {
+	return &CaptureHandler{log: c}
+}
+
+// Routes registers the capture routes on the given chi router.
+func (h *CaptureHandler) Routes(r chi.Router) {
+	r.Get("/", h.List)
+	r.Delete("/", h.Clear)
+	r.Get("/settings", h.GetSettings)
+	r.Put("/settings", h.UpdateSettings)
+	r.Get("/{id}", h.Get)
+}
+
+// captureSummary is an entry as listed, without its bodies.
+type captureSummary struct {
+	ID           string    `json:"id"`
+	At           time.Time `json:"at"`
+	DurationMS   int64     `json:"duration_ms"`
+	User         string    `json:"user,omitempty"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Route        string    `json:"route,omitempty"`
+	Status       int       `json:"status"`
+	RequestSize  int64     `json:"request_size"`
+	ResponseSize int64     `json:"response_size"`
+}
+
+// List returns the most recent exchanges, newest first, without their
+// bodies. path keeps those whose path starts with it; limit (default 50)
+// bounds them.
+func (h *CaptureHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	prefix := r.URL.Query().Get("path")
+
+	h.log.mu.Lock()
+	total := len(h.log.entries)
+	out := make([]captureSummary, 0, min(limit, total))
+	for i := total - 1; i >= 0 && len(out) < limit; i-- {
+		e := h.log.entries[i]
+		if !strings.HasPrefix(e.Path, prefix) {
+			continue
+		}
+		out = append(out, captureSummary{
+			ID: e.ID, At: e.At, DurationMS: e.DurationMS, User: e.User,
+			Method: e.Method, Path: e.Path, Route: e.Route, Status: e.Status,
+			RequestSize: e.Request.Size, ResponseSize: e.Response.Size,
+		})
+	}
+	h.log.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"captures": out,
+		"count":    len(out),
+		"total":    total,
+		"settings": h.log.Settings(),
+	})
+}
+
+// Get returns one exchange with its bodies.
+func (h *CaptureHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log.mu.Lock()
+	defer h.log.mu.Unlock()
+	for i := len(h.log.entries) - 1; i >= 0; i-- {
+		if h.log.entries[i].ID == id {
+			writeJSON(w, http.StatusOK, h.log.entries[i])
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "capture not found")
+}
+
+// Clear forgets the captured exchanges. The capture file is left alone.
+func (h *CaptureHandler) Clear(w http.ResponseWriter, r *http.Request) {
+	h.log.mu.Lock()
+	n := len(h.log.entries)
+	h.log.entries = nil
+	h.log.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cleared": n})
+}
+
+// GetSettings returns the capture settings.
+func (h *CaptureHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.log.Settings())
+}
+
+// UpdateSettings turns capture mode on or off. Omitted fields keep their
+// value; duration ("30m") turns capturing off again after that long.
+func (h *CaptureHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	if h.log.max <= 0 {
+		writeError(w, http.StatusBadRequest, "capture mode is disabled (CAPTURE_MAX_ENTRIES=0)")
+		return
+	}
+	var req struct {
+		Enabled      *bool     `json:"enabled"`
+		Routes       *[]string `json:"routes"`
+		MaxBodyBytes *int      `json:"max_body_bytes"`
+		ToFile       *bool     `json:"to_file"`
+		Duration     string    `json:"duration"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	var until *time.Time
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			writeError(w, http.StatusBadRequest, "duration must be a positive Go duration such as 30m")
+			return
+		}
+		t := time.Now().Add(d).UTC()
+		until = &t
+	}
+	if req.MaxBodyBytes != nil && (*req.MaxBodyBytes < 0 || *req.MaxBodyBytes > captureMaxBodyLimit) {
+		writeError(w, http.StatusBadRequest, "max_body_bytes must be between 0 and 1048576")
+		return
+	}
+	if req.ToFile != nil && *req.ToFile && h.log.path == "" {
+		writeError(w, http.StatusBadRequest, "file capture needs DATA_DIR")
+		return
+	}
+	routes := []string{}
+	if req.Routes != nil {
+		for _, p := range *req.Routes {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			if !strings.HasPrefix(p, "/") {
+				writeError(w, http.StatusBadRequest, "routes must be path prefixes starting with /")
+				return
+			}
+			routes = append(routes, p)
+		}
+	}
+
+	h.log.mu.Lock()
+	s := &h.log.settings
+	if s.Until != nil && time.Now().After(*s.Until) {
+		s.Enabled, s.Until = false, nil
+	}
+	if req.Enabled != nil {
+		s.Enabled = *req.Enabled
+		s.Until = nil
+	}
+	if req.Routes != nil {
+		s.Routes = routes
+	}
+	if req.MaxBodyBytes != nil {
+		s.MaxBodyBytes = *req.MaxBodyBytes
+	}
+	if req.ToFile != nil {
+		s.ToFile = *req.ToFile
+	}
+	if until != nil {
+		s.Until = until
+	}
+	h.log.mu.Unlock()
+	writeJSON(w, http.StatusOK, h.log.Settings())
+}
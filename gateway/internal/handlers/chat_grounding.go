@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+)
+
+// Answer grounding limits.
+const (
+	// maxGroundingSentences bounds the answer sentences that are scored;
+	// later ones are left out.
+	maxGroundingSentences = 64
+
+	// maxGroundingAnswer bounds the answer text kept for scoring.
+	maxGroundingAnswer = 64 << 10
+
+	// maxGroundingSource bounds the text of each source that is embedded.
+	maxGroundingSource = 4000
+
+	// minGroundingWords is the fewest words a sentence needs to be scored;
+	// shorter ones are headings, list markers or filler.
+	minGroundingWords = 4
+
+	// groundingTimeout bounds the scoring of one answer.
+	groundingTimeout = 15 * time.Second
+)
+
+// ChatGrounding reports how well a chat answer is supported by its
+// sources; see api.ChatGrounding.
+type ChatGrounding = api.ChatGrounding
+
+// GroundingScorer scores chat answers against the sources they were
+// generated from. Each answer sentence is embedded with the worker's
+// embedding model and matched with its most similar source; the answer's
+// score is the mean of those similarities. Answers scoring below the
+// threshold are flagged as likely to contain claims the sources do not
+// support.
+type GroundingScorer struct {
+	ollama    *OllamaHandler
+	threshold float32
+}
+
+// NewGroundingScorer creates a GroundingScorer that embeds through ollama
+// and flags answers scoring below minPercent percent. It returns nil, which
+// scores nothing, when minPercent is 0 or less.
+func NewGroundingScorer(ollama *OllamaHandler, minPercent int64) *GroundingScorer {
+	if minPercent <= 0 {
+		return nil
+	}
+	return &GroundingScorer{ollama: ollama, threshold: float32(minPercent) / 100}
+}
+
+// Score grounds answer in hits, embedding on the Ollama instance named by
+// instance. It returns nil when the answer has no sentence long enough to
+// score. An answer given without sources scores 0.
+func (gs *GroundingScorer) Score(ctx context.Context, answer string, hits []*grpcclient.SearchHit, instance string) (*ChatGrounding, error) {
+	sentences := answerSentences(answer)
+	if len(sentences) == 0 {
+		return nil, nil
+	}
+	g := &ChatGrounding{Threshold: gs.threshold, Sentences: len(sentences)}
+	var sources []string
+	for _, h := range hits {
+		if text := strings.TrimSpace(h.Content); text != "" {
+			sources = append(sources, truncateUTF8(text, maxGroundingSource))
+		}
+	}
+	if len(sources) == 0 {
+		g.Low = true
+		return g, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, groundingTimeout)
+	defer cancel()
+	inst, err := gs.ollama.instances.Resolve(instance)
+	if err != nil {
+		return nil, err
+	}
+	if gs.ollama.grpc == nil || gs.ollama.grpc.Embedding == nil {
+		return nil, errors.New("embedding service not available")
+	}
+	info, err := gs.ollama.grpc.Embedding.GetInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("embedding model: %w", err)
+	}
+	texts := append(append([]string{}, sentences...), sources...)
+	vecs, _, err := gs.ollama.embedBatch(ctx, inst.URL, info.Model, texts, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d texts", len(vecs), len(texts))
+	}
+
+	var sum float32
+	for i := range sentences {
+		var best float32
+		for j := range sources {
+			if s := cosine(vecs[i], vecs[len(sentences)+j]); s > best {
+				best = s
+			}
+		}
+		sum += best
+		if best >= gs.threshold {
+			g.Supported++
+		}
+	}
+	g.Score = sum / float32(len(sentences))
+	g.Low = g.Score < gs.threshold
+	return g, nil
+}
+
+// answerSentences splits an answer into the sentences worth scoring. Code
+// blocks are skipped: they quote the sources rather than make claims.
+func answerSentences(answer string) []string {
+	var out []string
+	inCode := false
+	for _, line := range strings.Split(answer, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		start := 0
+		for i, r := range line {
+			if r != '.' && r != '!' && r != '?' {
+				continue
+			}
+			// A sentence ends at punctuation followed by a space or the
+			// end of the line, which keeps "v1.2" and "main.go" whole.
+			next := i + 1
+			if next < len(line) && !unicode.IsSpace(rune(line[next])) {
+				continue
+			}
+			out = appendSentence(out, line[start:next])
+			start = next
+		}
+		out = appendSentence(out, line[start:])
+		if len(out) >= maxGroundingSentences {
+			return out[:maxGroundingSentences]
+		}
+	}
+	return out
+}
+
+// listMarker matches the Markdown heading, quote and list markers that
+// start a line.
+var listMarker = regexp.MustCompile(`^(?:[#>*+-]+|\d+[.)])\s+`)
+
+// appendSentence appends s, without its list marker, if it has enough
+// words to be scored.
+func appendSentence(out []string, s string) []string {
+	s = strings.TrimSpace(s)
+	for m := listMarker.FindString(s); m != ""; m = listMarker.FindString(s) {
+		s = s[len(m):]
+	}
+	if len(strings.Fields(s)) < minGroundingWords {
+		return out
+	}
+	return append(out, s)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxSystemPromptLen bounds system-prompt overrides sent to the worker.
+const maxSystemPromptLen = 8000
+
+// ChatPrefsStore keeps per-user default chat options in memory, on top of
+// the server's defaults.
+type ChatPrefsStore struct {
+	mu       sync.RWMutex
+	prefs    map[string]grpcclient.ChatOptions
+	defaults grpcclient.ChatOptions
+}
+
+// NewChatPrefsStore creates an empty preference store. defaults fill in
+// the options a user's preferences leave unset; out-of-range defaults are
+// ignored with a warning.
+func NewChatPrefsStore(defaults grpcclient.ChatOptions) *ChatPrefsStore {
+	if err := validateChatOptions(defaults); err != nil {
+		log.Printf("WARNING: chat defaults: %v; ignoring them", err)
+		defaults = grpcclient.ChatOptions{}
+	}
+	return &ChatPrefsStore{prefs: make(map[string]grpcclient.ChatOptions), defaults: defaults}
+}
+
+// Get returns the stored defaults for a user (zero value if none).
+func (s *ChatPrefsStore) Get(username string) grpcclient.ChatOptions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.prefs[username]
+}
+
+// Defaults returns the options a user's chat turns default to: their
+// stored preferences, then the server's defaults.
+func (s *ChatPrefsStore) Defaults(username string) grpcclient.ChatOptions {
+	return s.Get(username).Merge(s.defaults)
+}
+
+// Set replaces the stored defaults for a user.
+func (s *ChatPrefsStore) Set(username string, o grpcclient.ChatOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prefs[username] = o
+}
+
+// Delete removes the stored defaults for a user.
+func (s *ChatPrefsStore) Delete(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.prefs, username)
+}
+
+// validateChatOptions checks that every set option is within range.
+func validateChatOptions(o grpcclient.ChatOptions) error {
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2")
+	}
+	if o.TopP != nil && (*o.TopP <= 0 || *o.TopP > 1) {
+		return fmt.Errorf("top_p must be greater than 0 and at most 1")
+	}
+	if o.MaxTokens != nil && (*o.MaxTokens < 1 || *o.MaxTokens > 32768) {
+		return fmt.Errorf("max_tokens must be between 1 and 32768")
+	}
+	if o.TopK != nil && (*o.TopK < 1 || *o.TopK > 50) {
+		return fmt.Errorf("top_k must be between 1 and 50")
+	}
+	if len(o.SystemPrompt) > maxSystemPromptLen {
+		return fmt.Errorf("system_prompt exceeds %d characters", maxSystemPromptLen)
+	}
+	if o.ContextMaxTokens != nil && (*o.ContextMaxTokens < 0 || *o.ContextMaxTokens > 131072) {
+		return fmt.Errorf("context_max_tokens must be between 0 and 131072")
+	}
+	if o.SourceMaxTokens != nil && (*o.SourceMaxTokens < 0 || *o.SourceMaxTokens > 32768) {
+		return fmt.Errorf("source_max_tokens must be between 0 and 32768")
+	}
+	switch o.ContextOrder {
+	case "", grpcclient.ContextOrderScore, grpcclient.ContextOrderRecency:
+	default:
+		return fmt.Errorf("context_order must be %q or %q", grpcclient.ContextOrderScore, grpcclient.ContextOrderRecency)
+	}
+	return nil
+}
+
+// ChatPrefsHandler lets users read and edit their default chat options.
+type ChatPrefsHandler struct {
+	store *ChatPrefsStore
+}
+
+// NewChatPrefsHandler creates a new ChatPrefsHandler.
+func NewChatPrefsHandler(store *ChatPrefsStore) *ChatPrefsHandler {
+	return &ChatPrefsHandler{store: store}
+}
+
+// Routes registers chat preference routes on the given chi router.
+func (h *ChatPrefsHandler) Routes(r chi.Router) {
+	r.Get("/preferences", h.Get)
+	r.Put("/preferences", h.Update)
+	r.Delete("/preferences", h.Reset)
+}
+
+// Get returns the caller's default chat options.
+func (h *ChatPrefsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.store.Get(middleware.UsernameFromContext(r.Context())))
+}
+
+// Update validates and replaces the caller's default chat options.
+func (h *ChatPrefsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req grpcclient.ChatOptions
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := validateChatOptions(req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.store.Set(middleware.UsernameFromContext(r.Context()), req)
+	writeJSON(w, http.StatusOK, req)
+}
+
+// Reset clears the caller's default chat options.
+func (h *ChatPrefsHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	h.store.Delete(middleware.UsernameFromContext(r.Context()))
+	writeJSON(w, http.StatusOK, map[string]string{"status": "reset"})
+}
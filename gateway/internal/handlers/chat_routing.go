@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+)
+
+// Chat routing limits.
+const (
+	// maxRouteCollections bounds the collections one routed turn searches.
+	maxRouteCollections = 3
+
+	// routeMargin is how far below the best score another collection may
+	// score and still be searched with it.
+	routeMargin = 0.05
+
+	// routeTimeout bounds the routing of one question.
+	routeTimeout = 10 * time.Second
+
+	// maxRouteCache bounds the cached description vectors; the cache is
+	// emptied when it fills.
+	maxRouteCache = 1024
+)
+
+// errNoRoute is returned when no collection could be scored.
+var errNoRoute = errors.New("no collection matches the question")
+
+// ChatRoute reports the collections a routed chat turn searches; see
+// api.ChatRoute.
+type ChatRoute = api.ChatRoute
+
+// RouteCandidate is a collection scored by chat routing.
+type RouteCandidate = api.RouteCandidate
+
+// ChatRouter picks the collections a chat question is about, for chat
+// turns with route "auto". The question is embedded with the worker's
+// embedding model and compared with each collection's description and
+// with its best matching point; the collection scoring highest is
+// searched, with any that score within routeMargin of it.
+type ChatRouter struct {
+	colls     *CollectionSettings
+	ollama    *OllamaHandler
+	tm        *tasks.Manager
+	qdrantURL string
+	client    *http.Client
+
+	mu    sync.Mutex
+	cache map[string][]float64 // model + "\x00" + description text → vector
+}
+
+// NewChatRouter creates a ChatRouter. Descriptions come from colls, and
+// embeddings from Ollama through ollama; collections are listed and probed
+// in Qdrant at qdrantURL through client. Collections locked for a
+// reindex by tm are left out.
+func NewChatRouter(colls *CollectionSettings, ollama *OllamaHandler, tm *tasks.Manager, qdrantURL string, client *http.Client) *ChatRouter {
+	return &ChatRouter{
+		colls:     colls,
+		ollama:    ollama,
+		tm:        tm,
+		qdrantURL: qdrantURL,
+		client:    client,
+		cache:     make(map[string][]float64),
+	}
+}
+
+// Route scores the candidates, or every collection when there are none,
+// against question and returns at most max collections to search. The
+// question is embedded on the Ollama instance named by instance.
+func (cr *ChatRouter) Route(ctx context.Context, question, instance string, candidates []string, max int) (*ChatRoute, error) {
+	ctx, cancel := context.WithTimeout(ctx, routeTimeout)
+	defer cancel()
+
+	names, err := cr.candidates(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	inst, err := cr.ollama.instances.Resolve(instance)
+	if err != nil {
+		return nil, err
+	}
+	if cr.ollama.grpc == nil || cr.ollama.grpc.Embedding == nil {
+		return nil, errors.New("embedding service not available")
+	}
+	info, err := cr.ollama.grpc.Embedding.GetInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("embedding model: %w", err)
+	}
+
+	descriptions := make(map[string]string)
+	for _, name := range names {
+		if d := cr.colls.Description(name); d != "" {
+			descriptions[name] = name + ": " + d
+		}
+	}
+	vectors, err := cr.embed(ctx, inst.URL, info.Model, question, descriptions)
+	if err != nil {
+		return nil, err
+	}
+	query := vectors[question]
+	content := cr.probe(ctx, names, query)
+
+	route := &ChatRoute{}
+	for _, name := range names {
+		c := RouteCandidate{Collection: name}
+		var sum float32
+		var n int
+		if text, ok := descriptions[name]; ok {
+			s := cosine(query, vectors[text])
+			c.DescriptionScore = &s
+			sum, n = sum+s, n+1
+		}
+		if s, ok := content[name]; ok {
+			c.ContentScore = &s
+			sum, n = sum+s, n+1
+		}
+		if n == 0 {
+			continue
+		}
+		c.Score = sum / float32(n)
+		route.Candidates = append(route.Candidates, c)
+	}
+	if len(route.Candidates) == 0 {
+		return nil, errNoRoute
+	}
+	sort.SliceStable(route.Candidates, func(i, j int) bool { return route.Candidates[i].Score > route.Candidates[j].Score })
+	best := route.Candidates[0].Score
+	for _, c := range route.Candidates {
+		if len(route.Collections) == max || c.Score < best-routeMargin {
+			break
+		}
+		route.Collections = append(route.Collections, c.Collection)
+	}
+	return route, nil
+}
+
+// candidates returns the collections to score: the given ones or every
+// collection, without those a reindex blocks.
+func (cr *ChatRouter) candidates(ctx context.Context, given []string) ([]string, error) {
+	names := given
+	if len(names) == 0 {
+		var err error
+		if names, err = listQdrantCollections(ctx, cr.client, cr.qdrantURL); err != nil {
+			return nil, fmt.Errorf("list collections: %w", err)
+		}
+		sort.Strings(names)
+	}
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		if l, locked := cr.tm.CollectionLock(name); locked && l.Mode == tasks.LockBlock {
+			continue
+		}
+		if name != "" && !containsString(out, name) {
+			out = append(out, name)
+		}
+	}
+	if len(out) > multiSearchMaxCollections {
+		return nil, fmt.Errorf("%d collections exceed the routing limit of %d; list the candidates in route_collections",
+			len(out), multiSearchMaxCollections)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no collections to route to")
+	}
+	return out, nil
+}
+
+// embed returns the vectors of question and of the descriptions, keyed by
+// their text. Description vectors are cached per model.
+func (cr *ChatRouter) embed(ctx context.Context, baseURL, model, question string, descriptions map[string]string) (map[string][]float64, error) {
+	out := make(map[string][]float64, len(descriptions)+1)
+	texts := []string{question}
+	cr.mu.Lock()
+	for _, text := range descriptions {
+		if v, ok := cr.cache[model+"\x00"+text]; ok {
+			out[text] = v
+		} else if !containsString(texts, text) {
+			texts = append(texts, text)
+		}
+	}
+	cr.mu.Unlock()
+
+	vecs, _, err := cr.ollama.embedBatch(ctx, baseURL, model, texts, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d texts", len(vecs), len(texts))
+	}
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if len(cr.cache)+len(texts) > maxRouteCache {
+		cr.cache = make(map[string][]float64)
+	}
+	for i, text := range texts {
+		out[text] = vecs[i]
+		if i > 0 {
+			cr.cache[model+"\x00"+text] = vecs[i]
+		}
+	}
+	return out, nil
+}
+
+// probe returns the score of the best matching point of each collection.
+// Collections whose vectors do not match the query, such as those embedded
+// with another model, are left out.
+func (cr *ChatRouter) probe(ctx context.Context, names []string, query []float64) map[string]float32 {
+	body, _ := json.Marshal(map[string]interface{}{
+		"vector":       query,
+		"limit":        1,
+		"with_payload": false,
+	})
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		scores = make(map[string]float32)
+		sem    = make(chan struct{}, multiSearchConcurrency)
+	)
+	for _, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if s, ok := cr.topScore(ctx, name, body); ok {
+				mu.Lock()
+				scores[name] = s
+				mu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+	return scores
+}
+
+// topScore runs one probe search.
+func (cr *ChatRouter) topScore(ctx context.Context, collection string, body []byte) (float32, bool) {
+	req, err := http.NewRequestWithContext(ctx, "POST",
+		cr.qdrantURL+"/collections/"+url.PathEscape(collection)+"/points/search", bytes.NewReader(body))
+	if err != nil {
+		return 0, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := cr.client.Do(req)
+	if err != nil {
+		return 0, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, false
+	}
+	var out struct {
+		Result []struct {
+			Score float32 `json:"score"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || len(out.Result) == 0 {
+		return 0, false
+	}
+	return out.Result[0].Score, true
+}
+
+// cosine returns the cosine similarity of a and b, or 0 when their lengths
+// differ.
+func cosine(a, b []float64) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+)
+
+// citationLookupTimeout bounds the Qdrant payload lookup for one sources
+// event. Citations are still sent, without tags and pages, when it expires.
+const citationLookupTimeout = 3 * time.Second
+
+// Citation is one cited file in a chat answer; see api.Citation.
+type Citation = api.Citation
+
+// CitationAnchor locates one cited chunk within its file.
+type CitationAnchor = api.CitationAnchor
+
+// CitationEnricher turns the raw SearchHits of a chat sources event into
+// citations the UI can render as links. Source tags and PDF page ranges are
+// read from the point payloads in Qdrant.
+type CitationEnricher struct {
+	qdrantURL string
+	images    *ImageSigner
+	basePath  string
+	client    *http.Client
+}
+
+// NewCitationEnricher creates a CitationEnricher reading points from Qdrant
+// at qdrantURL through client. Uploaded files are linked with URLs signed
+// by images. URLs it builds are prefixed with basePath so they resolve when
+// the gateway is mounted under a sub-path.
+func NewCitationEnricher(qdrantURL string, client *http.Client, images *ImageSigner, basePath string) *CitationEnricher {
+	return &CitationEnricher{
+		qdrantURL: qdrantURL,
+		images:    images,
+		basePath:  basePath,
+		client:    client,
+	}
+}
+
+// citationPayload is the part of a point payload citations use.
+type citationPayload struct {
+	FilePath    string `json:"file_path"`
+	ChunkIndex  int    `json:"chunk_index"`
+	SourceTag   string `json:"source_tag"`
+	DisplayName string `json:"display_name"`
+	PageStart   int    `json:"page_start"`
+	PageEnd     int    `json:"page_end"`
+}
+
+type chunkKey struct {
+	filePath string
+	index    int
+}
+
+// Enrich groups hits by file and builds their citations. It never fails: a
+// Qdrant error only drops source tags and page anchors.
+func (e *CitationEnricher) Enrich(ctx context.Context, collection string, hits []*grpcclient.SearchHit) []Citation {
+	return e.EnrichAcross(ctx, []string{collection}, hits)
+}
+
+// EnrichAcross is Enrich for hits merged from several collections, as in a
+// routed chat turn. Each citation is attributed to the first collection
+// holding its chunks.
+func (e *CitationEnricher) EnrichAcross(ctx context.Context, collections []string, hits []*grpcclient.SearchHit) []Citation {
+	collection := collections[0]
+	var (
+		out    []Citation
+		byFile = make(map[string]int)
+		seen   = make(map[chunkKey]bool)
+		keys   []chunkKey
+	)
+	for _, hit := range hits {
+		if hit == nil || hit.FilePath == "" {
+			continue
+		}
+		idx := parseChunkInfo(hit.ChunkInfo)
+		key := chunkKey{hit.FilePath, idx}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+
+		i, ok := byFile[hit.FilePath]
+		if !ok {
+			i = len(out)
+			byFile[hit.FilePath] = i
+			out = append(out, e.newCitation(i+1, collection, hit))
+		}
+		c := &out[i]
+		if hit.Score > c.Score {
+			c.Score = hit.Score
+		}
+		if hit.Language != "image" {
+			start, end := parseLineRange(hit.Lines)
+			a := CitationAnchor{
+				ChunkIndex: idx,
+				Score:      hit.Score,
+				PreviewURL: e.previewURL(collection, hit.FilePath, idx),
+			}
+			if hit.Language == transcriptLanguage {
+				a.Media = &MediaRange{Start: start, End: end}
+				if c.URL != "" {
+					a.Media.URL = c.URL + mediaFragment(start, end)
+				}
+			} else {
+				a.StartLine, a.EndLine = start, end
+			}
+			c.Anchors = append(c.Anchors, a)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+
+	payloads := make(map[chunkKey]citationPayload, len(keys))
+	located := make(map[string]bool)
+	for _, coll := range collections {
+		found, err := e.lookup(ctx, coll, keys)
+		if err != nil {
+			log.Printf("WARNING: citation lookup in %s: %v", coll, err)
+		}
+		var rest []chunkKey
+		for _, k := range keys {
+			if p, ok := found[k]; ok {
+				payloads[k] = p
+				if !located[k.filePath] {
+					located[k.filePath] = true
+					e.attribute(out, coll, k.filePath)
+				}
+			} else {
+				rest = append(rest, k)
+			}
+		}
+		if keys = rest; len(keys) == 0 {
+			break
+		}
+	}
+	for i := range out {
+		c := &out[i]
+		for _, p := range payloads {
+			if p.FilePath != c.FilePath {
+				continue
+			}
+			if c.SourceTag == "" {
+				c.SourceTag = p.SourceTag
+			}
+			if p.DisplayName != "" {
+				c.Title = p.DisplayName
+			}
+		}
+		for j := range c.Anchors {
+			a := &c.Anchors[j]
+			if p, ok := payloads[chunkKey{c.FilePath, a.ChunkIndex}]; ok {
+				a.PageStart, a.PageEnd = p.PageStart, p.PageEnd
+			}
+			a.Label = anchorLabel(a)
+		}
+	}
+	return out
+}
+
+// attribute sets the collection of the citation of filePath.
+func (e *CitationEnricher) attribute(out []Citation, collection, filePath string) {
+	for i := range out {
+		c := &out[i]
+		if c.FilePath != filePath || c.Collection == collection {
+			continue
+		}
+		c.Collection = collection
+		for j := range c.Anchors {
+			c.Anchors[j].PreviewURL = e.previewURL(collection, c.FilePath, c.Anchors[j].ChunkIndex)
+		}
+	}
+}
+
+// newCitation starts the citation of a file from its first hit.
+func (e *CitationEnricher) newCitation(index int, collection string, hit *grpcclient.SearchHit) Citation {
+	c := Citation{
+		Index:      index,
+		FilePath:   hit.FilePath,
+		Title:      path.Base(filepath.ToSlash(hit.FilePath)),
+		Kind:       "file",
+		Collection: collection,
+		Language:   hit.Language,
+		Anchors:    []CitationAnchor{},
+	}
+	switch {
+	case strings.HasPrefix(hit.FilePath, "//"):
+		c.Kind = "smb"
+	case hit.Language == "image":
+		c.Kind = "image"
+	}
+
+	// Only files under UPLOAD_DIR can be served as-is.
+	file := hit.AbsPath
+	if file == "" {
+		file = hit.FilePath
+	}
+	if p, ok := e.images.PathFor(file); ok {
+		c.URL = e.basePath + p
+	}
+	return c
+}
+
+func (e *CitationEnricher) previewURL(collection, filePath string, chunkIndex int) string {
+	q := url.Values{}
+	q.Set("collection", collection)
+	q.Set("file_path", filePath)
+	q.Set("chunk_index", strconv.Itoa(chunkIndex))
+	return e.basePath + "/api/rag/preview?" + q.Encode()
+}
+
+// lookup fetches the payloads of the cited chunks in one scroll request.
+func (e *CitationEnricher) lookup(ctx context.Context, collection string, keys []chunkKey) (map[chunkKey]citationPayload, error) {
+	out := make(map[chunkKey]citationPayload, len(keys))
+	if e.qdrantURL == "" || len(keys) == 0 {
+		return out, nil
+	}
+
+	should := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		should = append(should, map[string]interface{}{
+			"must": []interface{}{
+				map[string]interface{}{"key": "file_path", "match": map[string]interface{}{"value": k.filePath}},
+				map[string]interface{}{"key": "chunk_index", "match": map[string]interface{}{"value": k.index}},
+			},
+		})
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"filter":       map[string]interface{}{"should": should},
+		"limit":        len(keys),
+		"with_payload": []string{"file_path", "chunk_index", "source_tag", "display_name", "page_start", "page_end"},
+		"with_vector":  false,
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, citationLookupTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST",
+		e.qdrantURL+"/collections/"+url.PathEscape(collection)+"/points/scroll", bytes.NewReader(body))
+	if err != nil {
+		return out, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return out, fmt.Errorf("qdrant status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Result struct {
+			Points []struct {
+				Payload citationPayload `json:"payload"`
+			} `json:"points"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return out, err
+	}
+	for _, p := range result.Result.Points {
+		out[chunkKey{p.Payload.FilePath, p.Payload.ChunkIndex}] = p.Payload
+	}
+	return out, nil
+}
+
+// parseChunkInfo turns a SearchHit chunk_info ("3/10", one-based) into a
+// zero-based chunk index.
+func parseChunkInfo(info string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(strings.SplitN(info, "/", 2)[0]))
+	if err != nil || n < 1 {
+		return 0
+	}
+	return n - 1
+}
+
+// parseLineRange parses a SearchHit lines field ("12-30"). Unknown bounds
+// come back as zero.
+func parseLineRange(lines string) (int, int) {
+	startStr, endStr, _ := strings.Cut(lines, "-")
+	start, _ := strconv.Atoi(strings.TrimSpace(startStr))
+	end, err := strconv.Atoi(strings.TrimSpace(endStr))
+	if err != nil {
+		end = start
+	}
+	return start, end
+}
+
+// anchorLabel prefers pages, which readers of PDFs can find, over lines.
+// Transcript anchors are labelled with their time range.
+func anchorLabel(a *CitationAnchor) string {
+	switch {
+	case a.Media != nil && a.Media.End > a.Media.Start:
+		return formatTimestamp(a.Media.Start) + "-" + formatTimestamp(a.Media.End)
+	case a.Media != nil:
+		return formatTimestamp(a.Media.Start)
+	case a.PageStart > 0 && a.PageEnd > a.PageStart:
+		return fmt.Sprintf("pp. %d-%d", a.PageStart, a.PageEnd)
+	case a.PageStart > 0:
+		return fmt.Sprintf("p. %d", a.PageStart)
+	case a.StartLine > 0 && a.EndLine > a.StartLine:
+		return fmt.Sprintf("L%d-%d", a.StartLine, a.EndLine)
+	case a.StartLine > 0:
+		return fmt.Sprintf("L%d", a.StartLine)
+	default:
+		return fmt.Sprintf("chunk %d", a.ChunkIndex+1)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// CollectionChecker checks that a collection exists and holds points before
+// a search is forwarded to the worker, which otherwise fails with errors
+// that do not say what is wrong. Collections found ready are remembered for
+// the cache TTL; missing and empty ones are looked up again on every search,
+// so a search right after the first index run goes through.
+type CollectionChecker struct {
+	baseURL string
+	client  *http.Client
+	ttl     time.Duration
+
+	mu    sync.Mutex
+	ready map[string]time.Time // collection -> when it was seen with points
+}
+
+// NewCollectionChecker creates a CollectionChecker talking to Qdrant at
+// baseURL through client. A ttl of zero or less turns the check off.
+func NewCollectionChecker(baseURL string, client *http.Client, ttl time.Duration) *CollectionChecker {
+	return &CollectionChecker{
+		baseURL: baseURL,
+		client:  client,
+		ttl:     ttl,
+		ready:   make(map[string]time.Time),
+	}
+}
+
+// collectionProblem is why a collection cannot answer searches.
+type collectionProblem struct {
+	status int
+	code   string
+	detail string
+}
+
+// write writes the error response for p.
+func (p *collectionProblem) write(w http.ResponseWriter) {
+	writeErrorCode(w, p.status, p.code, p.detail)
+}
+
+// check returns why collection cannot answer searches, or nil if it can or
+// Qdrant could not be asked; the search then goes ahead and the worker
+// reports any problem itself.
+func (c *CollectionChecker) check(ctx context.Context, collection string) *collectionProblem {
+	if c == nil || c.ttl <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	at, ok := c.ready[collection]
+	c.mu.Unlock()
+	if ok && time.Since(at) < c.ttl {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/collections/"+url.PathEscape(collection), nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		log.Printf("WARNING: checking collection %s before search: %v", collection, err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		c.forget(collection)
+		return &collectionProblem{http.StatusNotFound, CodeCollectionNotFound,
+			fmt.Sprintf("collection %s not found; index files into it first or search another collection", collection)}
+	default:
+		log.Printf("WARNING: checking collection %s before search: qdrant returned %s", collection, resp.Status)
+		return nil
+	}
+	var info struct {
+		Result struct {
+			PointsCount int64 `json:"points_count"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil
+	}
+	if info.Result.PointsCount == 0 {
+		c.forget(collection)
+		return &collectionProblem{http.StatusConflict, CodeCollectionEmpty,
+			fmt.Sprintf("collection %s exists but has 0 points; run an index into it first", collection)}
+	}
+
+	c.mu.Lock()
+	c.ready[collection] = time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+// forget drops collection from the cache.
+func (c *CollectionChecker) forget(collection string) {
+	c.mu.Lock()
+	delete(c.ready, collection)
+	c.mu.Unlock()
+}
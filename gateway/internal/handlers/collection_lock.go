@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+// StaleDataHeader is set on search responses for a collection that is being
+// reindexed under a "warn" lock. Its value names the reindex task.
+const StaleDataHeader = "X-Stale-Data"
+
+// Worker-side default collections, used as the lock target when an index
+// request leaves the collection empty.
+const (
+	workerDefaultDocumentsCollection = "documents"
+	workerDefaultImagesCollection    = "images"
+)
+
+// checkCollectionLock applies an active reindex lock to a search. It writes
+// a 423 and returns false for "block" locks, and adds StaleDataHeader for
+// "warn" locks.
+func checkCollectionLock(w http.ResponseWriter, tm *tasks.Manager, collection string) bool {
+	l, ok := tm.CollectionLock(collection)
+	if !ok {
+		return true
+	}
+	if l.Mode == tasks.LockBlock {
+		writeErrorCode(w, http.StatusLocked, CodeLocked,
+			fmt.Sprintf("collection %s is being reindexed (task %s)", collection, l.TaskID))
+		return false
+	}
+	w.Header().Set(StaleDataHeader, "reindexing; task="+l.TaskID)
+	return true
+}
+
+// lockIndexTarget takes a reindex lock for a freshly created task. If the
+// collection is already locked, the task is failed, a 423 is written and
+// false is returned.
+func lockIndexTarget(w http.ResponseWriter, tm *tasks.Manager, taskID, collection, workerDefault string, mode tasks.LockMode) bool {
+	if collection == "" {
+		collection = workerDefault
+	}
+	if err := tm.LockCollection(taskID, collection, mode); err != nil {
+		tm.Fail(taskID, err.Error())
+		writeErrorCode(w, http.StatusLocked, CodeLocked, err.Error())
+		return false
+	}
+	return true
+}
+
+// workerDefaultCollectionFor returns the collection the worker writes to
+// for a task type when the request leaves it empty.
+func workerDefaultCollectionFor(taskType string) string {
+	switch taskType {
+	case "index_codebase":
+		return workerDefaultCodebaseCollection
+	case "index_images":
+		return workerDefaultImagesCollection
+	default:
+		return workerDefaultDocumentsCollection
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+)
+
+// collectionSettingsDoc is the store document holding collection settings.
+const collectionSettingsDoc = "collections"
+
+// maxCollectionDescription bounds a collection description, in bytes.
+const maxCollectionDescription = 1000
+
+// PayloadIndexSpec is a payload index a template creates on new collections.
+type PayloadIndexSpec struct {
+	FieldName   string `json:"field_name"`
+	FieldSchema string `json:"field_schema"`
+}
+
+// ChunkingProfile holds chunking defaults applied when indexing into a
+// collection created from a template.
+type ChunkingProfile struct {
+	ChunkSize    int32 `json:"chunk_size,omitempty"`
+	ChunkOverlap int32 `json:"chunk_overlap,omitempty"`
+}
+
+// CollectionTemplate describes how to create a collection.
+type CollectionTemplate struct {
+	Name           string             `json:"name"`
+	Description    string             `json:"description,omitempty"`
+	VectorSize     int                `json:"vector_size"`
+	Distance       string             `json:"distance"`
+	PayloadIndexes []PayloadIndexSpec `json:"payload_indexes,omitempty"`
+	Chunking       ChunkingProfile    `json:"chunking,omitempty"`
+}
+
+// CollectionSettings holds the default collection and collection templates,
+// persisted in the gateway store. It is shared by the Qdrant handler (which
+// manages it) and the indexing handlers (which read it).
+type CollectionSettings struct {
+	mu    sync.RWMutex
+	store *store.Store
+	data  struct {
+		DefaultCollection string                        `json:"default_collection"`
+		Templates         map[string]CollectionTemplate `json:"templates"`
+		// Bindings maps collection name → template it was created from.
+		Bindings map[string]string `json:"bindings"`
+		// Descriptions maps collection name → what it holds, which chat
+		// routing matches questions against.
+		Descriptions map[string]string `json:"descriptions,omitempty"`
+	}
+}
+
+// NewCollectionSettings loads collection settings from st. fallbackDefault
+// is used as the default collection until one is configured explicitly.
+func NewCollectionSettings(st *store.Store, fallbackDefault string) *CollectionSettings {
+	s := &CollectionSettings{store: st}
+	if _, err := st.Load(collectionSettingsDoc, &s.data); err != nil {
+		log.Printf("WARNING: collection settings: %v", err)
+	}
+	if s.data.DefaultCollection == "" {
+		s.data.DefaultCollection = fallbackDefault
+	}
+	if s.data.Templates == nil {
+		s.data.Templates = make(map[string]CollectionTemplate)
+	}
+	if s.data.Bindings == nil {
+		s.data.Bindings = make(map[string]string)
+	}
+	if s.data.Descriptions == nil {
+		s.data.Descriptions = make(map[string]string)
+	}
+	return s
+}
+
+// DefaultCollection returns the configured default collection, or "" to
+// defer to the worker's default.
+func (s *CollectionSettings) DefaultCollection() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.DefaultCollection
+}
+
+// SetDefaultCollection changes the default collection.
+func (s *CollectionSettings) SetDefaultCollection(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.DefaultCollection = name
+	return s.saveLocked()
+}
+
+// Templates returns all templates sorted by name.
+func (s *CollectionSettings) Templates() []CollectionTemplate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]CollectionTemplate, 0, len(s.data.Templates))
+	for _, t := range s.data.Templates {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Template returns a template by name.
+func (s *CollectionSettings) Template(name string) (CollectionTemplate, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.data.Templates[name]
+	return t, ok
+}
+
+// PutTemplate validates and stores a template, replacing any existing one
+// with the same name.
+func (s *CollectionSettings) PutTemplate(t CollectionTemplate) error {
+	if err := validateTemplate(&t); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Templates[t.Name] = t
+	return s.saveLocked()
+}
+
+// validateTemplate checks t and fills in the default distance.
+func validateTemplate(t *CollectionTemplate) error {
+	if t.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if t.VectorSize <= 0 {
+		return fmt.Errorf("vector_size must be positive")
+	}
+	switch t.Distance {
+	case "":
+		t.Distance = "Cosine"
+	case "Cosine", "Euclid", "Dot", "Manhattan":
+	default:
+		return fmt.Errorf("distance must be one of: Cosine, Euclid, Dot, Manhattan")
+	}
+	for _, idx := range t.PayloadIndexes {
+		if idx.FieldName == "" || !payloadIndexTypes[idx.FieldSchema] {
+			return fmt.Errorf("invalid payload index %q (%s)", idx.FieldName, idx.FieldSchema)
+		}
+	}
+	if t.Chunking.ChunkSize < 0 || t.Chunking.ChunkOverlap < 0 {
+		return fmt.Errorf("chunking values must not be negative")
+	}
+	return nil
+}
+
+// DeleteTemplate removes a template. It returns false if it did not exist.
+func (s *CollectionSettings) DeleteTemplate(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Templates[name]; !ok {
+		return false, nil
+	}
+	delete(s.data.Templates, name)
+	return true, s.saveLocked()
+}
+
+// Bind records that a collection was created from a template.
+func (s *CollectionSettings) Bind(collection, template string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Bindings[collection] = template
+	return s.saveLocked()
+}
+
+// Unbind forgets a collection's template and description, e.g. after it
+// is deleted.
+func (s *CollectionSettings) Unbind(collection string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, bound := s.data.Bindings[collection]
+	_, described := s.data.Descriptions[collection]
+	if !bound && !described {
+		return
+	}
+	delete(s.data.Bindings, collection)
+	delete(s.data.Descriptions, collection)
+	if err := s.saveLocked(); err != nil {
+		log.Printf("WARNING: collection settings: %v", err)
+	}
+}
+
+// Description returns what a collection holds: its own description, or
+// that of the template it was created from.
+func (s *CollectionSettings) Description(collection string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if d := s.data.Descriptions[collection]; d != "" {
+		return d
+	}
+	if name, ok := s.data.Bindings[collection]; ok {
+		return s.data.Templates[name].Description
+	}
+	return ""
+}
+
+// SetDescription sets a collection's description; an empty one removes it.
+func (s *CollectionSettings) SetDescription(collection, description string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if description == "" {
+		delete(s.data.Descriptions, collection)
+	} else {
+		s.data.Descriptions[collection] = description
+	}
+	return s.saveLocked()
+}
+
+// ResolveIndex fills in the collection and chunking for an index request:
+// an empty collection becomes the default, and zero chunking values are
+// taken from the template the collection was created from.
+func (s *CollectionSettings) ResolveIndex(collection string, chunkSize, chunkOverlap int32) (string, int32, int32) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if collection == "" {
+		collection = s.data.DefaultCollection
+	}
+	if name, ok := s.data.Bindings[collection]; ok {
+		if t, ok := s.data.Templates[name]; ok {
+			if chunkSize == 0 {
+				chunkSize = t.Chunking.ChunkSize
+			}
+			if chunkOverlap == 0 {
+				chunkOverlap = t.Chunking.ChunkOverlap
+			}
+		}
+	}
+	return collection, chunkSize, chunkOverlap
+}
+
+func (s *CollectionSettings) saveLocked() error {
+	return s.store.Save(collectionSettingsDoc, &s.data)
+}
@@ -0,0 +1,542 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/notify"
+	"github.com/go-chi/chi/v5"
+)
+
+// ConfigBundleVersion is the schema version written by export. Import
+// rejects bundles from a newer gateway.
+const ConfigBundleVersion = 1
+
+// maxBundleSize bounds an uploaded bundle.
+const maxBundleSize = 4 << 20
+
+// ConfigBundle is a portable snapshot of gateway and worker settings, used
+// for backups and for moving a setup between environments. A nil section is
+// left untouched on import.
+type ConfigBundle struct {
+	Version             int                   `json:"version"`
+	ExportedAt          time.Time             `json:"exported_at"`
+	IncludesCredentials bool                  `json:"includes_credentials"`
+	AppConfig           *BundleAppConfig      `json:"app_config,omitempty"`
+	SMBShares           []SMBShare            `json:"smb_shares"`
+	CollectionTemplates []CollectionTemplate  `json:"collection_templates"`
+	DefaultCollection   *string               `json:"default_collection,omitempty"`
+	Notifications       *notify.Settings      `json:"notifications,omitempty"`
+	IgnoreProfiles      []IgnoreProfile       `json:"ignore_profiles"`
+	SearchDefaults      *SearchDefaultsConfig `json:"search_defaults,omitempty"`
+}
+
+// BundleAppConfig is the worker-side configuration in a bundle. Sections use
+// the partial-update request shapes so an imported section only changes the
+// fields it contains. Upload settings come from the environment and are not
+// part of the bundle.
+type BundleAppConfig struct {
+	Ollama       *grpcclient.UpdateOllamaRequest   `json:"ollama,omitempty"`
+	Qdrant       *grpcclient.UpdateQdrantRequest   `json:"qdrant,omitempty"`
+	Chunking     *grpcclient.UpdateChunkingRequest `json:"chunking,omitempty"`
+	Image        *grpcclient.UpdateImageRequest    `json:"image,omitempty"`
+	PII          *grpcclient.UpdatePIIRequest      `json:"pii,omitempty"`
+	Docling      *grpcclient.UpdateDoclingRequest  `json:"docling,omitempty"`
+	MountedPaths []string                          `json:"mounted_paths"`
+}
+
+// bundleSections are the top-level keys import understands.
+var bundleSections = map[string]bool{
+	"version": true, "exported_at": true, "includes_credentials": true,
+	"app_config": true, "smb_shares": true, "collection_templates": true,
+	"default_collection": true, "notifications": true, "ignore_profiles": true,
+	"search_defaults": true,
+}
+
+// ConfigBundleHandler exports and imports configuration bundles. All of its
+// routes are admin-only.
+type ConfigBundleHandler struct {
+	grpc     *grpcclient.Client
+	colls    *CollectionSettings
+	smb      *SMBHandler
+	notifier *notify.Notifier
+	ignores  *IgnoreProfiles
+	search   *SearchDefaults
+}
+
+// NewConfigBundleHandler creates a new ConfigBundleHandler.
+func NewConfigBundleHandler(gc *grpcclient.Client, colls *CollectionSettings, smb *SMBHandler, n *notify.Notifier, ignores *IgnoreProfiles, search *SearchDefaults) *ConfigBundleHandler {
+	return &ConfigBundleHandler{grpc: gc, colls: colls, smb: smb, notifier: n, ignores: ignores, search: search}
+}
+
+// Routes registers the bundle routes on the given chi router.
+func (h *ConfigBundleHandler) Routes(r chi.Router) {
+	r.Get("/config/export", h.Export)
+	r.Post("/config/import", h.Import)
+}
+
+// Export returns the current configuration as a bundle. SMB passwords and
+// notification secrets are only included with ?include_credentials=true. If
+// the worker is not connected the bundle is returned without app_config.
+func (h *ConfigBundleHandler) Export(w http.ResponseWriter, r *http.Request) {
+	withCreds, _ := strconv.ParseBool(r.URL.Query().Get("include_credentials"))
+
+	b := ConfigBundle{
+		Version:             ConfigBundleVersion,
+		ExportedAt:          time.Now().UTC(),
+		IncludesCredentials: withCreds,
+		SMBShares:           h.smb.Shares(withCreds),
+		CollectionTemplates: h.colls.Templates(),
+		IgnoreProfiles:      h.ignores.List(),
+	}
+	def := h.colls.DefaultCollection()
+	b.DefaultCollection = &def
+	notifications := h.notifier.Settings(withCreds)
+	b.Notifications = &notifications
+	search := h.search.Get()
+	search.UpdatedAt = nil
+	b.SearchDefaults = &search
+
+	if h.grpc.Config != nil {
+		cfg, err := h.grpc.Config.GetConfig(r.Context())
+		if err != nil {
+			writeGRPCError(w, err)
+			return
+		}
+		b.AppConfig = bundleFromAppConfig(cfg)
+	}
+
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="ollqd-config-%s.json"`, b.ExportedAt.Format("20060102-150405")))
+	writeJSON(w, http.StatusOK, b)
+}
+
+// importResult reports what an import changed, or would change for a dry
+// run.
+type importResult struct {
+	DryRun   bool     `json:"dry_run"`
+	Applied  []string `json:"applied"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Import validates a bundle and applies every section it contains. Nothing
+// is applied if validation fails; ?dry_run=true validates only. Templates,
+// ignore profiles and SMB shares are merged with existing ones by name and
+// ID.
+func (h *ConfigBundleHandler) Import(w http.ResponseWriter, r *http.Request) {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBundleSize)).Decode(&raw); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "bundle too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	res := importResult{DryRun: dryRun, Applied: []string{}}
+	var unknown []string
+	for k := range raw {
+		if !bundleSections[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("section %q is not supported by this gateway and was ignored", k))
+	}
+
+	// Re-encode the known keys and decode them into the typed bundle so
+	// field errors carry the section name.
+	var b ConfigBundle
+	for k, v := range raw {
+		if !bundleSections[k] {
+			continue
+		}
+		if err := decodeBundleSection(&b, k, v); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := validateBundle(&b); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if b.Notifications != nil {
+		prepared, warnings, err := h.notifier.Prepare(*b.Notifications)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "notifications: "+err.Error())
+			return
+		}
+		b.Notifications = &prepared
+		for _, msg := range warnings {
+			res.Warnings = append(res.Warnings, "notifications: "+msg)
+		}
+	}
+	if b.AppConfig != nil && h.grpc.Config == nil {
+		writeError(w, http.StatusServiceUnavailable, "config service not available")
+		return
+	}
+	if len(b.SMBShares) > 0 && !b.IncludesCredentials {
+		res.Warnings = append(res.Warnings, "bundle has no SMB credentials; passwords of existing shares are kept, new shares have none")
+	}
+
+	if dryRun {
+		res.Applied = bundleSectionNames(&b)
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
+	// Worker config goes first: it is the only step that can fail for
+	// reasons outside the bundle, and gateway-side state is cheap to retry.
+	if b.AppConfig != nil {
+		applied, err := h.applyAppConfig(r.Context(), b.AppConfig)
+		for _, s := range applied {
+			res.Applied = append(res.Applied, "app_config."+s)
+		}
+		if err != nil {
+			writeGRPCError(w, err)
+			return
+		}
+	}
+	for _, t := range b.CollectionTemplates {
+		if err := h.colls.PutTemplate(t); err != nil {
+			writeError(w, http.StatusInternalServerError, "saving templates: "+err.Error())
+			return
+		}
+	}
+	if b.CollectionTemplates != nil {
+		res.Applied = append(res.Applied, "collection_templates")
+	}
+	for _, p := range b.IgnoreProfiles {
+		if _, err := h.ignores.Put(p); err != nil {
+			writeError(w, http.StatusInternalServerError, "saving ignore profiles: "+err.Error())
+			return
+		}
+	}
+	if b.IgnoreProfiles != nil {
+		res.Applied = append(res.Applied, "ignore_profiles")
+	}
+	if b.DefaultCollection != nil {
+		if err := h.colls.SetDefaultCollection(*b.DefaultCollection); err != nil {
+			writeError(w, http.StatusInternalServerError, "saving default collection: "+err.Error())
+			return
+		}
+		res.Applied = append(res.Applied, "default_collection")
+	}
+	if b.SMBShares != nil {
+		h.smb.ImportShares(b.SMBShares)
+		res.Applied = append(res.Applied, "smb_shares")
+	}
+	if b.SearchDefaults != nil {
+		if _, err := h.search.Set(*b.SearchDefaults); err != nil {
+			writeError(w, http.StatusInternalServerError, "saving search defaults: "+err.Error())
+			return
+		}
+		res.Applied = append(res.Applied, "search_defaults")
+	}
+	if b.Notifications != nil {
+		if _, err := h.notifier.Update(*b.Notifications); err != nil {
+			writeError(w, http.StatusInternalServerError, "saving notifications: "+err.Error())
+			return
+		}
+		res.Applied = append(res.Applied, "notifications")
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func decodeBundleSection(b *ConfigBundle, key string, v json.RawMessage) error {
+	var dst interface{}
+	switch key {
+	case "version":
+		dst = &b.Version
+	case "exported_at":
+		dst = &b.ExportedAt
+	case "includes_credentials":
+		dst = &b.IncludesCredentials
+	case "app_config":
+		dst = &b.AppConfig
+	case "smb_shares":
+		dst = &b.SMBShares
+	case "collection_templates":
+		dst = &b.CollectionTemplates
+	case "default_collection":
+		dst = &b.DefaultCollection
+	case "notifications":
+		dst = &b.Notifications
+	case "ignore_profiles":
+		dst = &b.IgnoreProfiles
+	case "search_defaults":
+		dst = &b.SearchDefaults
+	}
+	if err := json.Unmarshal(v, dst); err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+	return nil
+}
+
+// validateBundle checks every section so an import is all-or-nothing for
+// anything the bundle itself could get wrong.
+func validateBundle(b *ConfigBundle) error {
+	switch {
+	case b.Version == 0:
+		return fmt.Errorf("version is required")
+	case b.Version > ConfigBundleVersion:
+		return fmt.Errorf("unsupported bundle version %d (this gateway reads up to %d)", b.Version, ConfigBundleVersion)
+	case b.Version < 0:
+		return fmt.Errorf("invalid bundle version %d", b.Version)
+	}
+
+	if c := b.AppConfig; c != nil {
+		if err := validateBundleAppConfig(c); err != nil {
+			return fmt.Errorf("app_config.%v", err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i := range b.CollectionTemplates {
+		t := &b.CollectionTemplates[i]
+		if err := validateTemplate(t); err != nil {
+			return fmt.Errorf("collection_templates[%d]: %v", i, err)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("collection_templates[%d]: duplicate template %q", i, t.Name)
+		}
+		names[t.Name] = true
+	}
+
+	if d := b.SearchDefaults; d != nil {
+		if err := d.validate(); err != nil {
+			return fmt.Errorf("search_defaults: %v", err)
+		}
+	}
+
+	profiles := make(map[string]bool)
+	for i := range b.IgnoreProfiles {
+		p := &b.IgnoreProfiles[i]
+		if err := validateIgnoreProfile(p); err != nil {
+			return fmt.Errorf("ignore_profiles[%d]: %v", i, err)
+		}
+		if profiles[p.Name] {
+			return fmt.Errorf("ignore_profiles[%d]: duplicate profile %q", i, p.Name)
+		}
+		profiles[p.Name] = true
+	}
+
+	ids := make(map[string]bool)
+	for i := range b.SMBShares {
+		s := &b.SMBShares[i]
+		if strings.TrimSpace(s.Server) == "" {
+			return fmt.Errorf("smb_shares[%d]: server is required", i)
+		}
+		if err := validateShareKind(s); err != nil {
+			return fmt.Errorf("smb_shares[%d]: %v", i, err)
+		}
+		if s.Port < 0 || s.Port > 65535 {
+			return fmt.Errorf("smb_shares[%d]: invalid port %d", i, s.Port)
+		}
+		if s.Sync != nil {
+			if err := validateSyncPolicy(s.Sync); err != nil {
+				return fmt.Errorf("smb_shares[%d].sync: %v", i, err)
+			}
+		}
+		if s.ID != "" {
+			if ids[s.ID] {
+				return fmt.Errorf("smb_shares[%d]: duplicate id %q", i, s.ID)
+			}
+			ids[s.ID] = true
+		}
+	}
+	return nil
+}
+
+func validateBundleAppConfig(c *BundleAppConfig) error {
+	if o := c.Ollama; o != nil {
+		if o.BaseUrl != nil {
+			if err := validateBundleURL(*o.BaseUrl); err != nil {
+				return fmt.Errorf("ollama.base_url: %v", err)
+			}
+		}
+		if o.TimeoutS != nil && *o.TimeoutS <= 0 {
+			return fmt.Errorf("ollama.timeout_s must be positive")
+		}
+	}
+	if q := c.Qdrant; q != nil {
+		if q.Url != nil {
+			if err := validateBundleURL(*q.Url); err != nil {
+				return fmt.Errorf("qdrant.url: %v", err)
+			}
+		}
+		if q.DefaultDistance != nil {
+			switch *q.DefaultDistance {
+			case "Cosine", "Euclid", "Dot", "Manhattan":
+			default:
+				return fmt.Errorf("qdrant.default_distance must be one of: Cosine, Euclid, Dot, Manhattan")
+			}
+		}
+	}
+	if ch := c.Chunking; ch != nil {
+		if ch.ChunkSize != nil && *ch.ChunkSize <= 0 {
+			return fmt.Errorf("chunking.chunk_size must be positive")
+		}
+		if ch.ChunkOverlap != nil && *ch.ChunkOverlap < 0 {
+			return fmt.Errorf("chunking.chunk_overlap must not be negative")
+		}
+		if ch.ChunkSize != nil && ch.ChunkOverlap != nil && *ch.ChunkOverlap >= *ch.ChunkSize {
+			return fmt.Errorf("chunking.chunk_overlap must be smaller than chunk_size")
+		}
+		if ch.MaxFileSizeKb != nil && *ch.MaxFileSizeKb <= 0 {
+			return fmt.Errorf("chunking.max_file_size_kb must be positive")
+		}
+	}
+	if im := c.Image; im != nil && im.MaxImageSizeKb != nil && *im.MaxImageSizeKb <= 0 {
+		return fmt.Errorf("image.max_image_size_kb must be positive")
+	}
+	if d := c.Docling; d != nil && d.TimeoutS != nil && *d.TimeoutS <= 0 {
+		return fmt.Errorf("docling.timeout_s must be positive")
+	}
+	for i, p := range c.MountedPaths {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("mounted_paths[%d]: %q is not an absolute path", i, p)
+		}
+	}
+	return nil
+}
+
+func validateBundleURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", s)
+	}
+	return nil
+}
+
+// applyAppConfig pushes each present section to the worker, returning the
+// sections applied before any error.
+func (h *ConfigBundleHandler) applyAppConfig(ctx context.Context, c *BundleAppConfig) ([]string, error) {
+	var applied []string
+	steps := []struct {
+		name    string
+		present bool
+		apply   func() error
+	}{
+		{"ollama", c.Ollama != nil, func() error { _, err := h.grpc.Config.UpdateOllama(ctx, c.Ollama); return err }},
+		{"qdrant", c.Qdrant != nil, func() error { _, err := h.grpc.Config.UpdateQdrant(ctx, c.Qdrant); return err }},
+		{"chunking", c.Chunking != nil, func() error { _, err := h.grpc.Config.UpdateChunking(ctx, c.Chunking); return err }},
+		{"image", c.Image != nil, func() error { _, err := h.grpc.Config.UpdateImage(ctx, c.Image); return err }},
+		{"pii", c.PII != nil, func() error { _, err := h.grpc.Config.UpdatePII(ctx, c.PII); return err }},
+		{"docling", c.Docling != nil, func() error { _, err := h.grpc.Config.UpdateDocling(ctx, c.Docling); return err }},
+		{"mounted_paths", c.MountedPaths != nil, func() error {
+			_, err := h.grpc.Config.UpdateMountedPaths(ctx, &grpcclient.UpdateMountedPathsRequest{Paths: c.MountedPaths})
+			return err
+		}},
+	}
+	for _, s := range steps {
+		if !s.present {
+			continue
+		}
+		if err := s.apply(); err != nil {
+			return applied, err
+		}
+		applied = append(applied, s.name)
+	}
+	return applied, nil
+}
+
+// bundleSectionNames lists the sections an import of b would apply.
+func bundleSectionNames(b *ConfigBundle) []string {
+	var out []string
+	if c := b.AppConfig; c != nil {
+		for _, s := range []struct {
+			name    string
+			present bool
+		}{
+			{"ollama", c.Ollama != nil}, {"qdrant", c.Qdrant != nil},
+			{"chunking", c.Chunking != nil}, {"image", c.Image != nil},
+			{"pii", c.PII != nil}, {"docling", c.Docling != nil},
+			{"mounted_paths", c.MountedPaths != nil},
+		} {
+			if s.present {
+				out = append(out, "app_config."+s.name)
+			}
+		}
+	}
+	if b.CollectionTemplates != nil {
+		out = append(out, "collection_templates")
+	}
+	if b.IgnoreProfiles != nil {
+		out = append(out, "ignore_profiles")
+	}
+	if b.DefaultCollection != nil {
+		out = append(out, "default_collection")
+	}
+	if b.SMBShares != nil {
+		out = append(out, "smb_shares")
+	}
+	if b.SearchDefaults != nil {
+		out = append(out, "search_defaults")
+	}
+	if b.Notifications != nil {
+		out = append(out, "notifications")
+	}
+	if out == nil {
+		out = []string{}
+	}
+	return out
+}
+
+// bundleFromAppConfig converts the worker config into update requests with
+// every field set, so zero values (e.g. pii.enabled=false) round-trip.
+func bundleFromAppConfig(cfg *grpcclient.AppConfig) *BundleAppConfig {
+	c := &BundleAppConfig{MountedPaths: cfg.MountedPaths}
+	if c.MountedPaths == nil {
+		c.MountedPaths = []string{}
+	}
+	if o := cfg.Ollama; o != nil {
+		c.Ollama = &grpcclient.UpdateOllamaRequest{
+			BaseUrl: &o.BaseUrl, ChatModel: &o.ChatModel, EmbedModel: &o.EmbedModel,
+			VisionModel: &o.VisionModel, TimeoutS: &o.TimeoutS, Local: &o.Local,
+		}
+	}
+	if q := cfg.Qdrant; q != nil {
+		c.Qdrant = &grpcclient.UpdateQdrantRequest{
+			Url: &q.Url, DefaultCollection: &q.DefaultCollection, DefaultDistance: &q.DefaultDistance,
+		}
+	}
+	if ch := cfg.Chunking; ch != nil {
+		c.Chunking = &grpcclient.UpdateChunkingRequest{
+			ChunkSize: &ch.ChunkSize, ChunkOverlap: &ch.ChunkOverlap, MaxFileSizeKb: &ch.MaxFileSizeKb,
+		}
+	}
+	if im := cfg.Image; im != nil {
+		c.Image = &grpcclient.UpdateImageRequest{
+			MaxImageSizeKb: &im.MaxImageSizeKb, CaptionPrompt: &im.CaptionPrompt,
+		}
+	}
+	if p := cfg.Pii; p != nil {
+		c.PII = &grpcclient.UpdatePIIRequest{
+			Enabled: &p.Enabled, UseSpacy: &p.UseSpacy, MaskEmbeddings: &p.MaskEmbeddings, EnabledTypes: &p.EnabledTypes,
+		}
+	}
+	if d := cfg.Docling; d != nil {
+		c.Docling = &grpcclient.UpdateDoclingRequest{
+			Enabled: &d.Enabled, OcrEnabled: &d.OcrEnabled, OcrEngine: &d.OcrEngine,
+			TableStructure: &d.TableStructure, TimeoutS: &d.TimeoutS,
+		}
+	}
+	return c
+}
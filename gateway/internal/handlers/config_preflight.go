@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/i18n"
+)
+
+// CodeConfirmRequired is returned when a config change would orphan or
+// break existing collections and the request did not set "confirm".
+const CodeConfirmRequired = "CONFIRM_REQUIRED"
+
+// preflightProbeText is embedded to learn the dimension of a model.
+const preflightProbeText = "dimension check"
+
+// configPreflight is what a config change would do to the existing
+// collections. A change with no warnings is applied without confirmation.
+type configPreflight struct {
+	Change      string   `json:"change"`
+	Warnings    []string `json:"warnings"`
+	Collections []string `json:"collections"` // affected collections
+}
+
+// warn records a warning about the given collections.
+func (p *configPreflight) warn(msg string, collections ...string) {
+	p.Warnings = append(p.Warnings, msg)
+	p.Collections = append(p.Collections, collections...)
+}
+
+// confirmRequiredError is the 409 body listing what an unconfirmed change
+// would do.
+type confirmRequiredError struct {
+	apiError
+	Preflight *configPreflight `json:"preflight"`
+}
+
+// guardConfigChange runs the preflight of a config change. With
+// ?dry_run=true it writes the preflight and returns false; if the change
+// has warnings and confirm is not set it writes a 409 listing them and
+// returns false. Otherwise the change may go ahead.
+func guardConfigChange(w http.ResponseWriter, r *http.Request, p *configPreflight, confirm bool) bool {
+	if p.Warnings == nil {
+		p.Warnings = []string{}
+	}
+	if p.Collections == nil {
+		p.Collections = []string{}
+	}
+	if r.URL.Query().Get("dry_run") == "true" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"dry_run":          true,
+			"preflight":        p,
+			"requires_confirm": len(p.Warnings) > 0,
+		})
+		return false
+	}
+	if len(p.Warnings) == 0 || confirm {
+		return true
+	}
+	msg := i18n.Localize(i18n.ResponseLang(w.Header()),
+		fmt.Sprintf("%s needs confirmation: %s", p.Change, strings.Join(p.Warnings, "; ")))
+	writeJSON(w, http.StatusConflict, confirmRequiredError{
+		apiError:  apiError{Detail: msg.Text, Code: CodeConfirmRequired, MessageKey: msg.Key, MessageArgs: msg.Args},
+		Preflight: p,
+	})
+	return false
+}
+
+// collectionVectors is the vector configuration of a collection.
+type collectionVectors struct {
+	Name     string
+	Size     int
+	Distance string
+}
+
+// shape describes the vectors, as in "Cosine/1024".
+func (c collectionVectors) shape() string {
+	return fmt.Sprintf("%s/%d", c.Distance, c.Size)
+}
+
+// qdrantVectors returns the vector configuration of every collection in
+// the Qdrant at baseURL. Collections with named vectors are left out.
+func qdrantVectors(ctx context.Context, client *http.Client, baseURL string) ([]collectionVectors, error) {
+	names, err := listQdrantCollections(ctx, client, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	out := make([]collectionVectors, 0, len(names))
+	for _, name := range names {
+		req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/collections/"+url.PathEscape(name), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var info struct {
+			Result struct {
+				Config struct {
+					Params struct {
+						Vectors json.RawMessage `json:"vectors"`
+					} `json:"params"`
+				} `json:"config"`
+			} `json:"result"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&info)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse qdrant response: %w", err)
+		}
+		var v struct {
+			Size     int    `json:"size"`
+			Distance string `json:"distance"`
+		}
+		if json.Unmarshal(info.Result.Config.Params.Vectors, &v) != nil || v.Size == 0 {
+			continue
+		}
+		out = append(out, collectionVectors{Name: name, Size: v.Size, Distance: v.Distance})
+	}
+	return out, nil
+}
+
+// groupByShape groups collections by their vector shape, in shape order.
+func groupByShape(colls []collectionVectors) ([]string, map[string][]string) {
+	groups := map[string][]string{}
+	var shapes []string
+	for _, c := range colls {
+		s := c.shape()
+		if groups[s] == nil {
+			shapes = append(shapes, s)
+		}
+		groups[s] = append(groups[s], c.Name)
+	}
+	sort.Strings(shapes)
+	return shapes, groups
+}
+
+// countCollections returns "1 collection" or "3 collections".
+func countCollections(n int) string {
+	if n == 1 {
+		return "1 collection"
+	}
+	return fmt.Sprintf("%d collections", n)
+}
+
+// workerQdrant returns the Qdrant URL and default distance the worker uses.
+func (h *SystemHandler) workerQdrant(ctx context.Context) (string, string, error) {
+	cfg, err := h.grpc.Config.GetConfig(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	u := cfg.GetQdrant().GetUrl()
+	if u == "" {
+		u = h.cfg.QdrantURL
+	}
+	return strings.TrimRight(u, "/"), cfg.GetQdrant().GetDefaultDistance(), nil
+}
+
+// modelDimension returns the dimension of the worker's current embedding
+// model, or 0 if it cannot be found out.
+func (h *SystemHandler) modelDimension(ctx context.Context) (string, int) {
+	if h.grpc.Embedding == nil {
+		return "", 0
+	}
+	info, err := h.grpc.Embedding.GetInfo(ctx)
+	if err != nil {
+		return "", 0
+	}
+	return info.Model, int(info.Dimension)
+}
+
+// preflightModel checks that model embeds and reports the collections the
+// current model can read but model cannot, as their dimension differs; they
+// could no longer be searched or indexed into.
+func (h *SystemHandler) preflightModel(ctx context.Context, model string) (*configPreflight, error) {
+	p := &configPreflight{Change: fmt.Sprintf("switching the embedding model to %s", model)}
+	current, currentDim := h.modelDimension(ctx)
+	if model == "" || normalizeModelName(model) == normalizeModelName(current) {
+		return p, nil
+	}
+	if current == "" {
+		current = model
+	}
+	resp, err := h.grpc.Embedding.CompareModels(ctx, &grpcclient.CompareModelsRequest{
+		Text:   preflightProbeText,
+		Model1: model,
+		Model2: current,
+	})
+	if err != nil {
+		return nil, err
+	}
+	probe := resp.GetModel1()
+	if probe.GetError() != "" || probe.GetDimension() == 0 {
+		p.warn(fmt.Sprintf("model %s could not embed a test text: %s", model, probe.GetError()))
+		return p, nil
+	}
+	dim := int(probe.GetDimension())
+
+	qdrantURL, _, err := h.workerQdrant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	colls, err := qdrantVectors(ctx, h.qdrantCli, qdrantURL)
+	if err != nil {
+		p.warn(fmt.Sprintf("could not check collections in Qdrant: %v", err))
+		return p, nil
+	}
+	var mismatched []collectionVectors
+	for _, c := range colls {
+		// Collections the current model cannot read either are not
+		// made worse by the switch.
+		if c.Size != dim && (currentDim == 0 || c.Size == currentDim) {
+			mismatched = append(mismatched, c)
+		}
+	}
+	shapes, groups := groupByShape(mismatched)
+	for _, s := range shapes {
+		p.warn(fmt.Sprintf("%s with vectors %s would become unreadable with %s (%d dimensions): %s",
+			countCollections(len(groups[s])), s, model, dim, strings.Join(groups[s], ", ")), groups[s]...)
+	}
+	return p, nil
+}
+
+// preflightDistance reports the collections whose distance differs from
+// distance. They keep theirs, so their scores stop being comparable with
+// those of collections created after the change.
+func (h *SystemHandler) preflightDistance(ctx context.Context, distance string) (*configPreflight, error) {
+	p := &configPreflight{Change: fmt.Sprintf("changing the default distance to %s", distance)}
+	qdrantURL, current, err := h.workerQdrant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if distance == "" || strings.EqualFold(distance, current) {
+		return p, nil
+	}
+	colls, err := qdrantVectors(ctx, h.qdrantCli, qdrantURL)
+	if err != nil {
+		p.warn(fmt.Sprintf("could not check collections in Qdrant: %v", err))
+		return p, nil
+	}
+	var other []collectionVectors
+	for _, c := range colls {
+		if !strings.EqualFold(c.Distance, distance) {
+			other = append(other, c)
+		}
+	}
+	shapes, groups := groupByShape(other)
+	for _, s := range shapes {
+		p.warn(fmt.Sprintf("%s with vectors %s would not change, while new collections use %s; their scores are not comparable in multi-collection search: %s",
+			countCollections(len(groups[s])), s, distance, strings.Join(groups[s], ", ")), groups[s]...)
+	}
+	return p, nil
+}
+
+// preflightQdrantURL checks that the Qdrant at newURL answers, and reports
+// the collections it lacks, which would no longer be found, and the ones
+// whose dimension the current embedding model cannot read.
+func (h *SystemHandler) preflightQdrantURL(ctx context.Context, newURL string) (*configPreflight, error) {
+	newURL = strings.TrimRight(newURL, "/")
+	p := &configPreflight{Change: fmt.Sprintf("switching Qdrant to %s", newURL)}
+	currentURL, _, err := h.workerQdrant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if newURL == "" || newURL == currentURL {
+		return p, nil
+	}
+	next, err := qdrantVectors(ctx, h.qdrantCli, newURL)
+	if err != nil {
+		p.warn(fmt.Sprintf("Qdrant at %s is not reachable: %v", newURL, err))
+		return p, nil
+	}
+	found := make(map[string]bool, len(next))
+	for _, c := range next {
+		found[c.Name] = true
+	}
+
+	if current, err := qdrantVectors(ctx, h.qdrantCli, currentURL); err == nil {
+		var missing []string
+		for _, c := range current {
+			if !found[c.Name] {
+				missing = append(missing, c.Name)
+			}
+		}
+		if len(missing) > 0 {
+			p.warn(fmt.Sprintf("%s from the current Qdrant would no longer be searched or indexed, as %s does not have them: %s",
+				countCollections(len(missing)), newURL, strings.Join(missing, ", ")), missing...)
+		}
+	}
+
+	model, dim := h.modelDimension(ctx)
+	if dim == 0 {
+		return p, nil
+	}
+	var mismatched []collectionVectors
+	for _, c := range next {
+		if c.Size != dim {
+			mismatched = append(mismatched, c)
+		}
+	}
+	shapes, groups := groupByShape(mismatched)
+	for _, s := range shapes {
+		p.warn(fmt.Sprintf("%s in %s with vectors %s would be unreadable with %s (%d dimensions): %s",
+			countCollections(len(groups[s])), newURL, s, model, dim, strings.Join(groups[s], ", ")), groups[s]...)
+	}
+	return p, nil
+}
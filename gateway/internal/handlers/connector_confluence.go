@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// confluencePageSize is how many pages one content request returns.
+const confluencePageSize = 50
+
+// ConfluenceSettings configure a "confluence" connector, which indexes the
+// current pages of Spaces. With Username set, APIToken is sent as its
+// basic-auth password (Confluence Cloud); without, as a bearer personal
+// access token (Data Center).
+type ConfluenceSettings struct {
+	BaseURL  string   `json:"base_url"`
+	Username string   `json:"username,omitempty"`
+	APIToken string   `json:"api_token,omitempty"`
+	Spaces   []string `json:"spaces"`
+	// HasToken is set in responses, which leave the token out.
+	HasToken bool `json:"has_token,omitempty"`
+}
+
+func validateConfluenceConnector(c *Connector) error {
+	s := c.Confluence
+	if s == nil {
+		return errors.New("confluence connectors need confluence settings")
+	}
+	base, err := normalizeURLs([]string{s.BaseURL})
+	if err != nil || len(base) == 0 {
+		return errors.New("confluence.base_url must be an absolute http(s) URL")
+	}
+	s.BaseURL = strings.TrimRight(base[0], "/")
+	spaces := make([]string, 0, len(s.Spaces))
+	for _, k := range s.Spaces {
+		if k = strings.TrimSpace(k); k != "" {
+			spaces = append(spaces, k)
+		}
+	}
+	if len(spaces) == 0 {
+		return errors.New("confluence.spaces must name at least one space key")
+	}
+	s.Spaces = spaces
+	s.Username = strings.TrimSpace(s.Username)
+	s.HasToken = false
+	c.Web, c.IMAP, c.Database = nil, nil, nil
+	return nil
+}
+
+// confluenceSource lists the pages of a connector's spaces through the
+// Confluence REST API.
+type confluenceSource struct {
+	settings ConfluenceSettings
+	maxPages int
+	maxBytes int64
+	client   *http.Client
+}
+
+func newConfluenceSource(c *Connector, env connectorEnv) connectorSource {
+	return &confluenceSource{settings: *c.Confluence, maxPages: c.MaxPages, maxBytes: env.maxBytes, client: env.client}
+}
+
+// confluenceContent is one page of a content listing.
+type confluenceContent struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version struct {
+		Number int `json:"number"`
+	} `json:"version"`
+	Body struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+func (s *confluenceSource) crawl(ctx context.Context, emit func(connectorPage) error) (bool, error) {
+	n := 0
+	for _, space := range s.settings.Spaces {
+		for start := 0; ; {
+			results, more, err := s.list(ctx, space, start)
+			if err != nil {
+				return false, fmt.Errorf("space %s: %w", space, err)
+			}
+			for _, p := range results {
+				if n == s.maxPages {
+					return true, nil
+				}
+				n++
+				if err := emit(s.page(p)); err != nil {
+					return false, err
+				}
+			}
+			if !more || len(results) == 0 {
+				break
+			}
+			start += len(results)
+		}
+	}
+	return false, nil
+}
+
+// list returns one batch of the current pages of space, with their bodies
+// in storage format, and whether more follow.
+func (s *confluenceSource) list(ctx context.Context, space string, start int) ([]confluenceContent, bool, error) {
+	q := url.Values{
+		"spaceKey": {space},
+		"type":     {"page"},
+		"status":   {"current"},
+		"expand":   {"body.storage,version"},
+		"start":    {strconv.Itoa(start)},
+		"limit":    {strconv.Itoa(confluencePageSize)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.settings.BaseURL+"/rest/api/content?"+q.Encode(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", connectorUserAgent)
+	if s.settings.Username != "" {
+		req.SetBasicAuth(s.settings.Username, s.settings.APIToken)
+	} else if s.settings.APIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.settings.APIToken)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, false, fmt.Errorf("confluence rejected the credentials (status %d)", resp.StatusCode)
+	case resp.StatusCode >= 300:
+		return nil, false, fmt.Errorf("confluence returned status %d", resp.StatusCode)
+	}
+	body, err := readLimited(resp.Body, s.maxBytes)
+	if err != nil {
+		return nil, false, err
+	}
+	var out struct {
+		Results []confluenceContent `json:"results"`
+		Links   struct {
+			Next string `json:"next"`
+		} `json:"_links"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, false, fmt.Errorf("decode content listing: %w", err)
+	}
+	return out.Results, out.Links.Next != "", nil
+}
+
+// page converts a listed page. Its version number tells changed pages
+// apart, so unchanged ones are not indexed again.
+func (s *confluenceSource) page(p confluenceContent) connectorPage {
+	pageURL := s.settings.BaseURL + p.Links.WebUI
+	page := connectorPage{
+		Key:     "confluence:" + p.ID,
+		Title:   p.Title,
+		URL:     pageURL,
+		Version: strconv.Itoa(p.Version.Number),
+		Ext:     ".md",
+	}
+	doc, err := parseHTML(strings.NewReader(p.Body.Storage.Value), nil)
+	if err != nil {
+		page.Failed = true
+		return page
+	}
+	page.Body = markdownPage(p.Title, pageURL, doc.Text)
+	return page
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Database drivers.
+const (
+	driverPostgres = "postgres"
+	driverMySQL    = "mysql"
+)
+
+const (
+	// Bounds on the rows one query returns.
+	defaultDatabaseBatch = 500
+	maxDatabaseBatch     = 10000
+
+	// maxDatabaseMetaValue bounds a payload field taken from a column, so
+	// a batch of rows fits the worker's metadata limit.
+	maxDatabaseMetaValue = 256
+
+	// databaseQueryTimeout bounds one batch query.
+	databaseQueryTimeout = 2 * time.Minute
+)
+
+// selectRe matches the start of a query that only reads.
+var selectRe = regexp.MustCompile(`(?is)^\s*(select|with)\s`)
+
+// DatabaseSettings configure a "database" connector, which indexes the rows
+// of a PostgreSQL or MySQL table, or of a SELECT query, one page per row.
+// Rows are read in KeyColumns order in batches of BatchSize and rendered
+// with Template; a row is indexed again when its rendered text changes, and
+// removed when its key disappears.
+type DatabaseSettings struct {
+	// Driver is "postgres" or "mysql".
+	Driver string `json:"driver"`
+	// DSN is a PostgreSQL connection URL or keyword string, or a MySQL
+	// DSN such as "user:pass@tcp(host:3306)/db".
+	DSN string `json:"dsn,omitempty"`
+	// Table or Query select the rows; Query is a SELECT run as a subquery.
+	Table string `json:"table,omitempty"`
+	Query string `json:"query,omitempty"`
+	// KeyColumns identify a row; they default to ["id"].
+	KeyColumns []string `json:"key_columns,omitempty"`
+	// Columns limit the columns the default template renders.
+	Columns []string `json:"columns,omitempty"`
+	// MetaColumns are copied into the payload of the row's points.
+	MetaColumns []string `json:"meta_columns,omitempty"`
+	// TitleTemplate and Template are text/template sources executed with
+	// the row, a map from column name to value.
+	TitleTemplate string `json:"title_template,omitempty"`
+	Template      string `json:"template,omitempty"`
+	BatchSize     int    `json:"batch_size,omitempty"`
+	// HasDSN is set in responses, which leave the DSN out.
+	HasDSN bool `json:"has_dsn,omitempty"`
+}
+
+func validateDatabaseConnector(c *Connector) error {
+	s := c.Database
+	if s == nil {
+		return errors.New("database connectors need database settings")
+	}
+	if s.Driver != driverPostgres && s.Driver != driverMySQL {
+		return fmt.Errorf("database.driver must be %s or %s", driverPostgres, driverMySQL)
+	}
+	if strings.TrimSpace(s.DSN) == "" {
+		return errors.New("database.dsn is required")
+	}
+	if _, err := databaseTarget(s.Driver, s.DSN); err != nil {
+		return fmt.Errorf("database.dsn: %v", err)
+	}
+	s.Table = strings.TrimSpace(s.Table)
+	s.Query = strings.TrimRight(strings.TrimSpace(s.Query), "; \t\n")
+	switch {
+	case (s.Table == "") == (s.Query == ""):
+		return errors.New("set one of database.table and database.query")
+	case s.Query != "" && !selectRe.MatchString(s.Query):
+		return errors.New("database.query must be a SELECT statement")
+	case s.Query != "" && strings.Contains(s.Query, ";"):
+		return errors.New("database.query must be a single statement")
+	}
+	s.KeyColumns = trimNames(s.KeyColumns)
+	if len(s.KeyColumns) == 0 {
+		s.KeyColumns = []string{"id"}
+	}
+	s.Columns = trimNames(s.Columns)
+	s.MetaColumns = trimNames(s.MetaColumns)
+	for name, src := range map[string]string{"title_template": s.TitleTemplate, "template": s.Template} {
+		if _, err := template.New(name).Parse(src); err != nil {
+			return fmt.Errorf("database.%s: %v", name, err)
+		}
+	}
+	if s.BatchSize == 0 {
+		s.BatchSize = defaultDatabaseBatch
+	}
+	if s.BatchSize < 1 || s.BatchSize > maxDatabaseBatch {
+		return fmt.Errorf("database.batch_size must be between 1 and %d", maxDatabaseBatch)
+	}
+	s.HasDSN = false
+	c.Confluence, c.Web, c.IMAP = nil, nil, nil
+	return nil
+}
+
+// trimNames trims column names and drops empty and repeated ones.
+func trimNames(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		if n = strings.TrimSpace(n); n != "" && !slices.Contains(out, n) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// databaseTarget returns where a DSN points, as "driver://host/database",
+// without its credentials.
+func databaseTarget(driver, dsn string) (string, error) {
+	switch driver {
+	case driverPostgres:
+		cfg, err := pgx.ParseConfig(dsn)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("postgres://%s/%s", cfg.Host, cfg.Database), nil
+	default:
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("mysql://%s/%s", cfg.Addr, cfg.DBName), nil
+	}
+}
+
+// databaseSource reads the rows of a connector's table or query.
+type databaseSource struct {
+	settings DatabaseSettings
+	maxPages int
+	env      connectorEnv
+	title    *template.Template
+	body     *template.Template
+}
+
+func newDatabaseSource(c *Connector, env connectorEnv) connectorSource {
+	s := &databaseSource{settings: *c.Database, maxPages: c.MaxPages, env: env}
+	// The templates parsed when the connector was saved.
+	if src := s.settings.TitleTemplate; src != "" {
+		s.title = template.Must(template.New("title").Option("missingkey=zero").Parse(src))
+	}
+	if src := s.settings.Template; src != "" {
+		s.body = template.Must(template.New("body").Option("missingkey=zero").Parse(src))
+	}
+	return s
+}
+
+// open connects through the connector dialer, so private addresses are
+// refused unless URL_FETCH_ALLOW_PRIVATE is set.
+func (s *databaseSource) open() (*sql.DB, error) {
+	if s.settings.Driver == driverPostgres {
+		cfg, err := pgx.ParseConfig(s.settings.DSN)
+		if err != nil {
+			return nil, err
+		}
+		cfg.DialFunc = s.env.dialer.DialContext
+		return stdlib.OpenDB(*cfg), nil
+	}
+	cfg, err := mysql.ParseDSN(s.settings.DSN)
+	if err != nil {
+		return nil, err
+	}
+	cfg.DialFunc = s.env.dialer.DialContext
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// crawl pages through the rows by key. Only new and changed rows count
+// against the page limit, so a large table is indexed over several syncs.
+func (s *databaseSource) crawl(ctx context.Context, emit func(connectorPage) error) (bool, error) {
+	target, err := databaseTarget(s.settings.Driver, s.settings.DSN)
+	if err != nil {
+		return false, err
+	}
+	db, err := s.open()
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	var after []any
+	changed := 0
+	for {
+		rows, err := s.batch(ctx, db, after)
+		if err != nil {
+			return false, err
+		}
+		for _, row := range rows {
+			page := s.page(target, row)
+			if old, ok := s.env.prev[page.Key]; !ok || old.Version != page.Version {
+				if changed == s.maxPages {
+					return true, nil
+				}
+				changed++
+			}
+			if err := emit(page); err != nil {
+				return false, err
+			}
+		}
+		if len(rows) < s.settings.BatchSize {
+			return false, nil
+		}
+		after = rows[len(rows)-1].key
+	}
+}
+
+// databaseRow is one row read by batch: its columns in result order, their
+// values as text, and the raw values of the key columns.
+type databaseRow struct {
+	columns []string
+	values  map[string]string
+	key     []any
+}
+
+// batch reads the rows following after, the key of the last row read, in
+// a read-only transaction.
+func (s *databaseSource) batch(ctx context.Context, db *sql.DB, after []any) ([]databaseRow, error) {
+	ctx, cancel := context.WithTimeout(ctx, databaseQueryTimeout)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query, args := s.query(after)
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	keyIdx := make([]int, len(s.settings.KeyColumns))
+	for i, k := range s.settings.KeyColumns {
+		if keyIdx[i] = slices.Index(columns, k); keyIdx[i] < 0 {
+			return nil, fmt.Errorf("key column %q is not in the result", k)
+		}
+	}
+
+	var out []databaseRow
+	for rows.Next() {
+		raw := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range raw {
+			ptrs[i] = &raw[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := databaseRow{columns: columns, values: make(map[string]string, len(columns))}
+		for i, c := range columns {
+			row.values[c] = databaseValue(raw[i])
+		}
+		for _, i := range keyIdx {
+			row.key = append(row.key, raw[i])
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, tx.Commit()
+}
+
+// query returns the batch query following after and its arguments. Rows
+// are compared by their key as a row value, which both databases support.
+func (s *databaseSource) query(after []any) (string, []any) {
+	src := s.settings.Query
+	if src == "" {
+		src = "SELECT * FROM " + s.quoteTable(s.settings.Table)
+	}
+	keys := make([]string, len(s.settings.KeyColumns))
+	params := make([]string, len(keys))
+	for i, k := range s.settings.KeyColumns {
+		keys[i] = s.quote(k)
+		params[i] = "?"
+		if s.settings.Driver == driverPostgres {
+			params[i] = "$" + strconv.Itoa(i+1)
+		}
+	}
+	q := "SELECT * FROM (" + src + ") AS ollqd_rows"
+	if after != nil {
+		q += " WHERE (" + strings.Join(keys, ", ") + ") > (" + strings.Join(params, ", ") + ")"
+	}
+	q += " ORDER BY " + strings.Join(keys, ", ") + " LIMIT " + strconv.Itoa(s.settings.BatchSize)
+	return q, after
+}
+
+// quote quotes an identifier for the driver.
+func (s *databaseSource) quote(name string) string {
+	if s.settings.Driver == driverMySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteTable quotes a table name that may be qualified by its schema.
+func (s *databaseSource) quoteTable(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = s.quote(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// databaseValue formats a scanned value as text.
+func databaseValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return fmt.Sprintf("(%d bytes)", len(v))
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// page renders a row. Its version is a hash of what is indexed, so the row
+// is indexed again only when that changes.
+func (s *databaseSource) page(target string, row databaseRow) connectorPage {
+	keyParts := make([]string, len(s.settings.KeyColumns))
+	for i, k := range s.settings.KeyColumns {
+		keyParts[i] = url.PathEscape(row.values[k])
+	}
+	keyPath := strings.Join(keyParts, "/")
+	source := s.settings.Table
+	if source == "" {
+		source = "query"
+	}
+	page := connectorPage{
+		Key: "database:" + source + ":" + keyPath,
+		URL: target + "/" + url.PathEscape(source) + "/" + keyPath,
+		Ext: ".md",
+	}
+
+	data := make(map[string]any, len(row.values))
+	for c, v := range row.values {
+		data[c] = v
+	}
+	page.Title = source + " " + strings.Join(keyParts, ", ")
+	if s.title != nil {
+		var b strings.Builder
+		if err := s.title.Execute(&b, data); err != nil {
+			page.Failed = true
+			return page
+		}
+		if t := strings.Join(strings.Fields(b.String()), " "); t != "" {
+			page.Title = t
+		}
+	}
+	var text strings.Builder
+	if s.body != nil {
+		if err := s.body.Execute(&text, data); err != nil {
+			page.Failed = true
+			return page
+		}
+	} else {
+		for _, c := range row.columns {
+			if v := row.values[c]; v != "" && (len(s.settings.Columns) == 0 || slices.Contains(s.settings.Columns, c)) {
+				fmt.Fprintf(&text, "%s: %s\n", c, v)
+			}
+		}
+	}
+	page.Body = markdownPage(page.Title, page.URL, strings.TrimSpace(text.String()))
+
+	if len(s.settings.MetaColumns) > 0 {
+		page.Meta = map[string]string{}
+		for _, c := range s.settings.MetaColumns {
+			if v, ok := row.values[c]; ok && v != "" {
+				page.Meta[c] = truncateUTF8(v, maxDatabaseMetaValue)
+			}
+		}
+	}
+	sum := sha256.New()
+	sum.Write(page.Body)
+	for _, c := range s.settings.MetaColumns {
+		fmt.Fprintf(sum, "\x00%s=%s", c, page.Meta[c])
+	}
+	page.Version = hex.EncodeToString(sum.Sum(nil))[:16]
+	return page
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// htmlSkipElements hold no page content: scripts, page chrome and
+// Confluence macro parameters.
+var htmlSkipElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"svg": true, "iframe": true, "nav": true, "header": true, "footer": true,
+	"aside": true, "form": true, "button": true, "select": true,
+	"ac:parameter": true,
+}
+
+// htmlBlockElements start and end on lines of their own.
+var htmlBlockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"ul": true, "ol": true, "dl": true, "dt": true, "dd": true, "table": true,
+	"blockquote": true, "figure": true, "figcaption": true, "details": true,
+	"summary": true, "address": true,
+}
+
+var htmlBlankLines = regexp.MustCompile(`\n{3,}`)
+
+// htmlDocument is the text of an HTML page or a Confluence storage-format
+// body, rendered as light Markdown so the worker chunks it by headings.
+type htmlDocument struct {
+	Title string
+	Text  string
+	// Links are the page's absolute http(s) links, without fragments, in
+	// page order. They are only collected when a base URL is given.
+	Links []string
+}
+
+// parseHTML renders the HTML read from r. Relative links are resolved
+// against base, which may be nil.
+func parseHTML(r io.Reader, base *url.URL) (*htmlDocument, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	w := &htmlWriter{base: base, seen: map[string]bool{}}
+	w.walk(root)
+
+	lines := strings.Split(w.b.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	text := htmlBlankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return &htmlDocument{
+		Title: strings.Join(strings.Fields(w.title), " "),
+		Text:  strings.TrimSpace(text),
+		Links: w.links,
+	}, nil
+}
+
+// htmlWriter accumulates the text of a parsed document.
+type htmlWriter struct {
+	b     strings.Builder
+	base  *url.URL
+	title string
+	links []string
+	seen  map[string]bool
+}
+
+func (w *htmlWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			w.walk(c)
+		}
+		return
+	}
+
+	name := n.Data
+	if htmlSkipElements[name] {
+		return
+	}
+	switch {
+	case name == "title":
+		if w.title == "" {
+			w.title = nodeText(n)
+		}
+		return
+	case name == "pre" || name == "ac:plain-text-body":
+		w.code(nodeText(n))
+		return
+	case len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6':
+		w.newline(2)
+		w.b.WriteString(strings.Repeat("#", int(name[1]-'0')) + " ")
+		w.children(n)
+		w.newline(2)
+		return
+	case name == "li":
+		w.newline(1)
+		w.b.WriteString("- ")
+		w.children(n)
+		w.newline(1)
+		return
+	case name == "tr":
+		w.newline(1)
+		w.children(n)
+		w.newline(1)
+		return
+	case name == "td" || name == "th":
+		if !w.atLineStart() {
+			w.b.WriteString(" | ")
+		}
+		w.children(n)
+		return
+	case name == "br":
+		w.newline(1)
+		return
+	case name == "hr":
+		w.newline(2)
+		return
+	case name == "a":
+		w.link(attr(n, "href"))
+	}
+
+	block := htmlBlockElements[name]
+	if block {
+		w.newline(2)
+	}
+	w.children(n)
+	if block {
+		w.newline(2)
+	}
+}
+
+func (w *htmlWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+// text writes s with its whitespace collapsed.
+func (w *htmlWriter) text(s string) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" && !w.atLineStart() && !strings.HasSuffix(w.b.String(), " ") {
+			w.b.WriteByte(' ')
+		}
+		return
+	}
+	if startsWithSpace(s) && !w.atLineStart() && !strings.HasSuffix(w.b.String(), " ") {
+		w.b.WriteByte(' ')
+	}
+	w.b.WriteString(strings.Join(fields, " "))
+	if strings.TrimRight(s, " \t\r\n") != s {
+		w.b.WriteByte(' ')
+	}
+}
+
+// code writes s verbatim as a fenced code block.
+func (w *htmlWriter) code(s string) {
+	s = strings.Trim(s, "\n")
+	if strings.TrimSpace(s) == "" {
+		return
+	}
+	w.newline(2)
+	w.b.WriteString("```\n" + s + "\n```")
+	w.newline(2)
+}
+
+// newline ends the current line and adds blank lines up to n line breaks.
+func (w *htmlWriter) newline(n int) {
+	s := w.b.String()
+	if strings.TrimSpace(s) == "" {
+		return
+	}
+	have := len(s) - len(strings.TrimRight(s, "\n"))
+	for ; have < n; have++ {
+		w.b.WriteByte('\n')
+	}
+}
+
+func (w *htmlWriter) atLineStart() bool {
+	s := strings.TrimRight(w.b.String(), " ")
+	return s == "" || strings.HasSuffix(s, "\n")
+}
+
+// link records href if it resolves to an http(s) URL.
+func (w *htmlWriter) link(href string) {
+	if w.base == nil || href == "" {
+		return
+	}
+	u, err := w.base.Parse(strings.TrimSpace(href))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	u.Fragment = ""
+	if s := u.String(); !w.seen[s] {
+		w.seen[s] = true
+		w.links = append(w.links, s)
+	}
+}
+
+// nodeText returns the text below n as is. The CDATA sections of Confluence
+// code macros, which the HTML parser keeps as comments, count as text.
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.CommentNode:
+			if s, ok := strings.CutPrefix(n.Data, "[CDATA["); ok {
+				b.WriteString(strings.TrimSuffix(s, "]]"))
+			}
+		case html.ElementNode:
+			if n.Data == "br" {
+				b.WriteByte('\n')
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.TrimLeft(s, " \t\r\n") != s
+}
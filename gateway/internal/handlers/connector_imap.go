@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/imap"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// maxMIMEDepth bounds nested multiparts and forwarded messages.
+	maxMIMEDepth = 10
+
+	// maxEmailRecipients bounds the addresses kept in the email_to and
+	// email_cc payload fields, so they fit the worker's metadata limit.
+	maxEmailRecipients = 10
+)
+
+// IMAPSettings configure an "imap" connector, which indexes the messages of
+// Folders and, with Attachments, their document attachments. Messages are
+// fetched once, by UID; a message that disappears from its folder is
+// removed from the collection.
+type IMAPSettings struct {
+	Host string `json:"host"`
+	// Port defaults to 993 with Security "tls" and 143 otherwise.
+	Port int `json:"port,omitempty"`
+	// Security is "tls" (default), "starttls" or "none".
+	Security string   `json:"security,omitempty"`
+	Username string   `json:"username"`
+	Password string   `json:"password,omitempty"`
+	Folders  []string `json:"folders,omitempty"`
+	// SinceDays limits a sync to messages received in the last SinceDays
+	// days; older ones are removed. 0 takes every message.
+	SinceDays   int   `json:"since_days,omitempty"`
+	Attachments *bool `json:"attachments,omitempty"`
+	// HasPassword is set in responses, which leave the password out.
+	HasPassword bool `json:"has_password,omitempty"`
+}
+
+func validateIMAPConnector(c *Connector) error {
+	s := c.IMAP
+	if s == nil {
+		return errors.New("imap connectors need imap settings")
+	}
+	if s.Host = strings.TrimSpace(s.Host); s.Host == "" {
+		return errors.New("imap.host is required")
+	}
+	switch s.Security {
+	case "":
+		s.Security = imap.SecurityTLS
+	case imap.SecurityTLS, imap.SecurityStartTLS, imap.SecurityNone:
+	default:
+		return fmt.Errorf("imap.security must be %s, %s or %s", imap.SecurityTLS, imap.SecurityStartTLS, imap.SecurityNone)
+	}
+	if s.Port == 0 {
+		s.Port = 143
+		if s.Security == imap.SecurityTLS {
+			s.Port = 993
+		}
+	}
+	if s.Port < 1 || s.Port > 65535 {
+		return errors.New("imap.port must be between 1 and 65535")
+	}
+	if s.Username = strings.TrimSpace(s.Username); s.Username == "" {
+		return errors.New("imap.username is required")
+	}
+	for _, v := range []string{s.Username, s.Password} {
+		for _, r := range v {
+			if r < 0x20 || r > 0x7e {
+				return errors.New("imap.username and imap.password must be printable ASCII")
+			}
+		}
+	}
+	folders := make([]string, 0, len(s.Folders))
+	for _, f := range s.Folders {
+		if f = strings.TrimSpace(f); f != "" && !slices.Contains(folders, f) {
+			folders = append(folders, f)
+		}
+	}
+	if len(folders) == 0 {
+		folders = []string{"INBOX"}
+	}
+	s.Folders = folders
+	if s.SinceDays < 0 {
+		return errors.New("imap.since_days must not be negative")
+	}
+	if s.Attachments == nil {
+		on := true
+		s.Attachments = &on
+	}
+	s.HasPassword = false
+	c.Confluence, c.Web, c.Database = nil, nil, nil
+	return nil
+}
+
+// imapSource fetches the messages of a mailbox that the previous sync did
+// not index. Messages are keyed by folder, UIDVALIDITY and UID, so a folder
+// whose UIDs were reassigned is indexed again from scratch.
+type imapSource struct {
+	settings IMAPSettings
+	maxPages int
+	env      connectorEnv
+}
+
+func newIMAPSource(c *Connector, env connectorEnv) connectorSource {
+	return &imapSource{settings: *c.IMAP, maxPages: c.MaxPages, env: env}
+}
+
+func (s *imapSource) crawl(ctx context.Context, emit func(connectorPage) error) (bool, error) {
+	addr := net.JoinHostPort(s.settings.Host, strconv.Itoa(s.settings.Port))
+	client, err := imap.Dial(ctx, s.env.dialer, addr, s.settings.Security)
+	if err != nil {
+		return false, fmt.Errorf("connect to %s: %w", addr, err)
+	}
+	defer client.Logout()
+	client.MaxLiteral = s.env.maxBytes
+	if err := client.Login(s.settings.Username, s.settings.Password); err != nil {
+		return false, err
+	}
+
+	var since time.Time
+	if s.settings.SinceDays > 0 {
+		since = time.Now().AddDate(0, 0, -s.settings.SinceDays)
+	}
+	fetched := 0
+	for _, folder := range s.settings.Folders {
+		mb, err := client.Examine(folder)
+		if err != nil {
+			return false, fmt.Errorf("folder %s: %w", folder, err)
+		}
+		uids, err := client.SearchUIDs(since)
+		if err != nil {
+			return false, fmt.Errorf("folder %s: %w", folder, err)
+		}
+
+		var fresh []uint32
+		for _, uid := range uids {
+			key := imapKey(folder, mb.UIDValidity, uid)
+			if _, ok := s.env.prev[key]; !ok {
+				fresh = append(fresh, uid)
+				continue
+			}
+			if err := emit(connectorPage{Key: key, Unchanged: true}); err != nil {
+				return false, err
+			}
+		}
+		// Newest first, so a sync stopped by max_pages has the recent mail.
+		slices.Sort(fresh)
+		slices.Reverse(fresh)
+		sizes, err := client.Sizes(fresh)
+		if err != nil {
+			return false, fmt.Errorf("folder %s: %w", folder, err)
+		}
+
+		for _, uid := range fresh {
+			if size := sizes[uid]; size > s.env.maxBytes {
+				log.Printf("WARNING: imap connector: skipping message %d in %s: %d bytes is over the upload limit", uid, folder, size)
+				continue
+			}
+			if fetched == s.maxPages {
+				return true, nil
+			}
+			fetched++
+			raw, err := client.Fetch(uid)
+			if err != nil {
+				return false, fmt.Errorf("folder %s: %w", folder, err)
+			}
+			page := s.page(folder, mb.UIDValidity, uid, raw)
+			if err := emit(page); err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, nil
+}
+
+func imapKey(folder string, validity, uid uint32) string {
+	return fmt.Sprintf("imap:%s:%d:%d", folder, validity, uid)
+}
+
+// page converts a raw message: its headers and text become the page, and
+// attachments the worker can extract become attachment files.
+func (s *imapSource) page(folder string, validity, uid uint32, raw []byte) connectorPage {
+	key := imapKey(folder, validity, uid)
+	page := connectorPage{
+		Key:     key,
+		URL:     fmt.Sprintf("imap://%s/%s;UIDVALIDITY=%d/;UID=%d", s.settings.Host, url.PathEscape(folder), validity, uid),
+		Version: "1", // messages do not change
+		Ext:     ".md",
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		page.Failed = true
+		return page
+	}
+
+	subject := decodeMailHeader(msg.Header.Get("Subject"))
+	if subject == "" {
+		subject = "(no subject)"
+	}
+	page.Title = subject
+	from := mailAddresses(msg.Header, "From")
+	to := mailAddresses(msg.Header, "To")
+	cc := mailAddresses(msg.Header, "Cc")
+
+	w := &mailWalker{attachments: *s.settings.Attachments, maxBytes: s.env.maxBytes}
+	w.part(textproto.MIMEHeader(msg.Header), msg.Body, 0)
+
+	page.Meta = map[string]string{
+		"email_subject": subject,
+		"email_folder":  folder,
+	}
+	var head strings.Builder
+	fmt.Fprintf(&head, "# %s\n\n", subject)
+	for _, f := range []struct{ name, key, value string }{
+		{"From", "email_from", strings.Join(from, ", ")},
+		{"To", "email_to", strings.Join(firstN(to, maxEmailRecipients), ", ")},
+		{"Cc", "email_cc", strings.Join(firstN(cc, maxEmailRecipients), ", ")},
+	} {
+		if f.value != "" {
+			fmt.Fprintf(&head, "%s: %s\n", f.name, f.value)
+			page.Meta[f.key] = f.value
+		}
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		fmt.Fprintf(&head, "Date: %s\n", date.Format(time.RFC1123Z))
+		page.Meta["email_date"] = date.UTC().Format(time.RFC3339)
+	}
+	if id := strings.Trim(strings.TrimSpace(msg.Header.Get("Message-Id")), "<>"); id != "" {
+		page.Meta["email_message_id"] = id
+	}
+	fmt.Fprintf(&head, "Folder: %s\n", folder)
+	if len(w.files) > 0 {
+		names := make([]string, len(w.files))
+		for i, f := range w.files {
+			names[i] = f.Name
+		}
+		fmt.Fprintf(&head, "Attachments: %s\n", strings.Join(names, ", "))
+	}
+	page.Body = []byte(head.String() + "\n" + strings.TrimSpace(w.text.String()) + "\n")
+	page.Attachments = w.files
+	return page
+}
+
+// mailWalker collects the text and the attachments of a message's MIME
+// tree.
+type mailWalker struct {
+	attachments bool
+	maxBytes    int64
+	text        strings.Builder
+	files       []connectorFile
+}
+
+// part adds one MIME part. Bodies in multipart/alternative are compared so
+// only one rendering of the text is kept, plain text first.
+func (w *mailWalker) part(header textproto.MIMEHeader, body io.Reader, depth int) {
+	if depth > maxMIMEDepth {
+		return
+	}
+	ct, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		ct, params = "text/plain", map[string]string{}
+	}
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := decodeMailHeader(dparams["filename"])
+	if name == "" {
+		name = decodeMailHeader(params["name"])
+	}
+	body = transferDecoder(header.Get("Content-Transfer-Encoding"), body)
+
+	switch {
+	case strings.HasPrefix(ct, "multipart/"):
+		mr := multipart.NewReader(body, params["boundary"])
+		alternative := ct == "multipart/alternative"
+		var best string
+		for {
+			p, err := mr.NextRawPart()
+			if err != nil {
+				break
+			}
+			if !alternative {
+				w.part(p.Header, p, depth+1)
+				continue
+			}
+			sub := &mailWalker{attachments: w.attachments, maxBytes: w.maxBytes}
+			sub.part(p.Header, p, depth+1)
+			w.files = append(w.files, sub.files...)
+			pct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+			if text := sub.text.String(); strings.TrimSpace(text) != "" && (best == "" || pct == "text/plain") {
+				best = text
+			}
+		}
+		w.write(best)
+	case disposition == "attachment" || (name != "" && !strings.HasPrefix(ct, "text/")):
+		w.attach(name, body)
+	case ct == "message/rfc822":
+		msg, err := mail.ReadMessage(body)
+		if err != nil {
+			return
+		}
+		w.write(fmt.Sprintf("---------- Forwarded message ----------\nFrom: %s\nSubject: %s\n",
+			strings.Join(mailAddresses(msg.Header, "From"), ", "), decodeMailHeader(msg.Header.Get("Subject"))))
+		w.part(textproto.MIMEHeader(msg.Header), msg.Body, depth+1)
+	case ct == "text/plain":
+		if data, err := readLimited(charsetReader(params["charset"], body), w.maxBytes); err == nil {
+			w.write(strings.ReplaceAll(string(data), "\r\n", "\n"))
+		}
+	case ct == "text/html":
+		if doc, err := parseHTML(charsetReader(params["charset"], body), nil); err == nil {
+			w.write(doc.Text)
+		}
+	}
+}
+
+// write appends a block of text.
+func (w *mailWalker) write(text string) {
+	if text = strings.TrimSpace(text); text == "" {
+		return
+	}
+	if w.text.Len() > 0 {
+		w.text.WriteString("\n\n")
+	}
+	w.text.WriteString(text)
+}
+
+// attach keeps an attachment the worker can extract text from. Images are
+// left out: they are mostly logos and signatures.
+func (w *mailWalker) attach(name string, body io.Reader) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if !w.attachments || !allowedExtensions[ext] || imageExtensions[ext] {
+		return
+	}
+	data, err := readLimited(body, w.maxBytes)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	w.files = append(w.files, connectorFile{
+		Name: filepath.Base(name),
+		Ext:  ext,
+		Body: data,
+		Meta: map[string]string{"email_attachment": filepath.Base(name)},
+	})
+}
+
+// transferDecoder undoes a part's Content-Transfer-Encoding.
+func transferDecoder(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// base64Cleaner drops the line breaks and padding whitespace of a base64
+// body, which base64.NewDecoder does not accept in every position.
+type base64Cleaner struct {
+	r io.Reader
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	for {
+		n, err := c.r.Read(p)
+		j := 0
+		for _, b := range p[:n] {
+			if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+				p[j] = b
+				j++
+			}
+		}
+		if j > 0 || err != nil {
+			return j, err
+		}
+	}
+}
+
+// charsetReader converts r from the named charset to UTF-8. Unknown
+// charsets are read as they are.
+func charsetReader(label string, r io.Reader) io.Reader {
+	if label == "" || strings.EqualFold(label, "utf-8") || strings.EqualFold(label, "us-ascii") {
+		return r
+	}
+	if cr, err := charset.NewReaderLabel(label, r); err == nil {
+		return cr
+	}
+	return r
+}
+
+var mailWordDecoder = &mime.WordDecoder{CharsetReader: func(label string, r io.Reader) (io.Reader, error) {
+	return charset.NewReaderLabel(label, r)
+}}
+
+// decodeMailHeader decodes the RFC 2047 encoded words of a header value.
+func decodeMailHeader(v string) string {
+	if decoded, err := mailWordDecoder.DecodeHeader(v); err == nil {
+		v = decoded
+	}
+	return strings.Join(strings.Fields(v), " ")
+}
+
+// mailAddresses returns the addresses of a header as "Name <address>", or
+// the raw value when it does not parse.
+func mailAddresses(h mail.Header, key string) []string {
+	raw := h.Get(key)
+	if raw == "" {
+		return nil
+	}
+	parser := mail.AddressParser{WordDecoder: mailWordDecoder}
+	list, err := parser.ParseList(raw)
+	if err != nil {
+		return []string{decodeMailHeader(raw)}
+	}
+	out := make([]string, len(list))
+	for i, a := range list {
+		if a.Name != "" {
+			out[i] = a.Name + " <" + a.Address + ">"
+		} else {
+			out[i] = a.Address
+		}
+	}
+	return out
+}
+
+func firstN(s []string, n int) []string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
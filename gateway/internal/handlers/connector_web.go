@@ -0,0 +1,460 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// connectorUserAgent identifies connector requests; robots.txt rules
+	// for "ollqd" or "*" apply to them.
+	connectorUserAgent = "ollqd-connector/1.0 (+https://github.com/leomarviegas/Ollqd)"
+	robotsAgent        = "ollqd"
+
+	// defaultWebMaxDepth is how many links away from a start URL a crawl
+	// goes when the connector does not say.
+	defaultWebMaxDepth = 3
+
+	// maxSitemapDepth bounds nested sitemap indexes.
+	maxSitemapDepth = 3
+)
+
+// errPageGone marks a page that no longer exists, so its points are
+// removed rather than kept.
+var errPageGone = errors.New("page not found")
+
+// WebCrawlSettings configure a "web" connector. Pages are read from the
+// Sitemaps and by following links from StartURLs, up to MaxDepth links
+// away. Only URLs starting with one of Scope are fetched; the default
+// scope is the site of each start URL and sitemap.
+type WebCrawlSettings struct {
+	StartURLs []string `json:"start_urls,omitempty"`
+	Sitemaps  []string `json:"sitemaps,omitempty"`
+	Scope     []string `json:"scope,omitempty"`
+	MaxDepth  *int     `json:"max_depth,omitempty"`
+	// DelayMS waits between requests to go easy on the site.
+	DelayMS int `json:"delay_ms,omitempty"`
+}
+
+func validateWebConnector(c *Connector) error {
+	s := c.Web
+	if s == nil || len(s.StartURLs)+len(s.Sitemaps) == 0 {
+		return errors.New("web connectors need web.start_urls or web.sitemaps")
+	}
+	var err error
+	if s.StartURLs, err = normalizeURLs(s.StartURLs); err != nil {
+		return err
+	}
+	if s.Sitemaps, err = normalizeURLs(s.Sitemaps); err != nil {
+		return err
+	}
+	if s.Scope, err = normalizeURLs(s.Scope); err != nil {
+		return err
+	}
+	if len(s.Scope) == 0 {
+		seen := map[string]bool{}
+		for _, raw := range append(append([]string{}, s.StartURLs...), s.Sitemaps...) {
+			u, _ := url.Parse(raw)
+			if site := u.Scheme + "://" + u.Host + "/"; !seen[site] {
+				seen[site] = true
+				s.Scope = append(s.Scope, site)
+			}
+		}
+	}
+	if s.MaxDepth == nil {
+		d := defaultWebMaxDepth
+		s.MaxDepth = &d
+	}
+	if *s.MaxDepth < 0 || *s.MaxDepth > 10 {
+		return errors.New("web.max_depth must be between 0 and 10")
+	}
+	if s.DelayMS < 0 || s.DelayMS > 60000 {
+		return errors.New("web.delay_ms must be between 0 and 60000")
+	}
+	c.Confluence, c.IMAP, c.Database = nil, nil, nil
+	return nil
+}
+
+// normalizeURLs trims raws, drops empty ones and checks the rest are
+// absolute http(s) URLs.
+func normalizeURLs(raws []string) ([]string, error) {
+	out := make([]string, 0, len(raws))
+	for _, raw := range raws {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%q is not an absolute http(s) URL", raw)
+		}
+		u.Fragment = ""
+		out = append(out, u.String())
+	}
+	return out, nil
+}
+
+// webSource crawls a site for a web connector.
+type webSource struct {
+	settings WebCrawlSettings
+	maxPages int
+	maxBytes int64
+	client   *http.Client
+	robots   map[string]*robotsRules // by scheme://host
+}
+
+func newWebSource(c *Connector, env connectorEnv) connectorSource {
+	return &webSource{settings: *c.Web, maxPages: c.MaxPages, maxBytes: env.maxBytes, client: env.client, robots: map[string]*robotsRules{}}
+}
+
+type crawlItem struct {
+	url   string
+	depth int
+}
+
+func (s *webSource) crawl(ctx context.Context, emit func(connectorPage) error) (bool, error) {
+	var queue []crawlItem
+	for _, u := range s.settings.StartURLs {
+		queue = append(queue, crawlItem{u, 0})
+	}
+	// Sitemap pages are indexed but their links not followed.
+	for _, sm := range s.settings.Sitemaps {
+		urls, err := s.sitemap(ctx, sm, 0)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			return false, fmt.Errorf("sitemap %s: %w", sm, err)
+		}
+		for _, u := range urls {
+			queue = append(queue, crawlItem{u, *s.settings.MaxDepth})
+		}
+	}
+
+	visited := map[string]bool{}
+	fetched := 0
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		if visited[item.url] || !s.inScope(item.url) {
+			continue
+		}
+		visited[item.url] = true
+		if !s.allowed(ctx, item.url) {
+			continue
+		}
+		if fetched == s.maxPages {
+			return true, nil
+		}
+		if fetched > 0 && s.settings.DelayMS > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(s.settings.DelayMS) * time.Millisecond):
+			}
+		}
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		fetched++
+
+		page, links, err := s.fetch(ctx, item.url)
+		switch {
+		case ctx.Err() != nil:
+			return false, ctx.Err()
+		case errors.Is(err, errPageGone):
+			continue
+		case err != nil:
+			page = connectorPage{Key: item.url, URL: item.url, Failed: true}
+		case page.Key != item.url:
+			// Redirected: skip the target if it was crawled already.
+			if visited[page.Key] {
+				continue
+			}
+			visited[page.Key] = true
+		}
+		if page.Ext != "" || page.Failed {
+			if err := emit(page); err != nil {
+				return false, err
+			}
+		}
+		if item.depth < *s.settings.MaxDepth {
+			for _, l := range links {
+				if !visited[l] {
+					queue = append(queue, crawlItem{l, item.depth + 1})
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// fetch reads one page. Pages of a type the worker cannot index come back
+// without Ext. HTML pages are converted to Markdown and return their links.
+func (s *webSource) fetch(ctx context.Context, raw string) (connectorPage, []string, error) {
+	resp, err := s.get(ctx, raw)
+	if err != nil {
+		return connectorPage{}, nil, err
+	}
+	defer resp.Body.Close()
+	final := *resp.Request.URL
+	final.Fragment = ""
+	page := connectorPage{Key: final.String(), URL: final.String()}
+	if page.Key != raw && !s.inScope(page.Key) {
+		return connectorPage{}, nil, errPageGone
+	}
+
+	ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch ct {
+	case "text/html", "application/xhtml+xml", "text/markdown":
+		page.Ext = ".md"
+	case "text/plain":
+		page.Ext = ".txt"
+	case "application/pdf":
+		page.Ext = ".pdf"
+	default:
+		return page, nil, nil
+	}
+	body, err := readLimited(resp.Body, s.maxBytes)
+	if err != nil {
+		return connectorPage{}, nil, err
+	}
+	sum := sha256.Sum256(body)
+	page.Version = hex.EncodeToString(sum[:])
+	page.Title = pageName(&final)
+
+	var links []string
+	if ct == "text/html" || ct == "application/xhtml+xml" {
+		doc, err := parseHTML(bytes.NewReader(body), &final)
+		if err != nil {
+			return connectorPage{}, nil, err
+		}
+		if doc.Title != "" {
+			page.Title = doc.Title
+		}
+		body = markdownPage(page.Title, page.URL, doc.Text)
+		links = doc.Links
+	}
+	page.Body = body
+	return page, links, nil
+}
+
+// get sends a GET with the connector's user agent. 404 and 410 come back
+// as errPageGone, other non-2xx statuses as errors.
+func (s *webSource) get(ctx context.Context, raw string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, raw, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", connectorUserAgent)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		resp.Body.Close()
+		return nil, errPageGone
+	case resp.StatusCode >= 300:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned status %d", raw, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func (s *webSource) inScope(raw string) bool {
+	for _, prefix := range s.settings.Scope {
+		if strings.HasPrefix(raw, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sitemap returns the page URLs listed by a sitemap, following sitemap
+// indexes. Gzipped sitemaps are read too.
+func (s *webSource) sitemap(ctx context.Context, raw string, depth int) ([]string, error) {
+	resp, err := s.get(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var r io.Reader = resp.Body
+	if strings.HasSuffix(resp.Request.URL.Path, ".gz") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	body, err := readLimited(r, s.maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		XMLName  xml.Name
+		URLs     []sitemapEntry `xml:"url"`
+		Sitemaps []sitemapEntry `xml:"sitemap"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("parse sitemap: %w", err)
+	}
+	var urls []string
+	for _, u := range doc.URLs {
+		if loc, err := normalizeURLs([]string{u.Loc}); err == nil && len(loc) == 1 {
+			urls = append(urls, loc[0])
+		}
+	}
+	if depth < maxSitemapDepth {
+		for _, sm := range doc.Sitemaps {
+			nested, err := s.sitemap(ctx, strings.TrimSpace(sm.Loc), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			urls = append(urls, nested...)
+		}
+	}
+	return urls, nil
+}
+
+type sitemapEntry struct {
+	Loc string `xml:"loc"`
+}
+
+// allowed reports whether robots.txt lets the connector fetch raw. A site
+// whose robots.txt cannot be read allows everything.
+func (s *webSource) allowed(ctx context.Context, raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	site := u.Scheme + "://" + u.Host
+	rules, ok := s.robots[site]
+	if !ok {
+		rules = &robotsRules{}
+		if resp, err := s.get(ctx, site+"/robots.txt"); err == nil {
+			rules = parseRobots(io.LimitReader(resp.Body, 512<<10), robotsAgent)
+			resp.Body.Close()
+		}
+		s.robots[site] = rules
+	}
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.allows(path)
+}
+
+// robotsRules are the Allow and Disallow lines of the robots.txt group
+// that applies to the connector.
+type robotsRules struct {
+	allow, disallow []string
+}
+
+// parseRobots reads the group for agent from a robots.txt, falling back to
+// the "*" group. Wildcards in paths are not supported and such rules are
+// ignored.
+func parseRobots(r io.Reader, agent string) *robotsRules {
+	groups := map[string]*robotsRules{}
+	var current []string
+	inRules := false
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, val = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(val)
+		switch key {
+		case "user-agent":
+			if inRules {
+				current, inRules = nil, false
+			}
+			current = append(current, strings.ToLower(val))
+		case "allow", "disallow":
+			inRules = true
+			if val == "" || strings.Contains(val, "*") {
+				continue
+			}
+			val = strings.TrimSuffix(val, "$")
+			for _, a := range current {
+				g := groups[a]
+				if g == nil {
+					g = &robotsRules{}
+					groups[a] = g
+				}
+				if key == "allow" {
+					g.allow = append(g.allow, val)
+				} else {
+					g.disallow = append(g.disallow, val)
+				}
+			}
+		}
+	}
+	if g := groups[agent]; g != nil {
+		return g
+	}
+	if g := groups["*"]; g != nil {
+		return g
+	}
+	return &robotsRules{}
+}
+
+// allows applies the longest matching rule; Allow wins ties.
+func (r *robotsRules) allows(path string) bool {
+	longest := func(rules []string) int {
+		n := -1
+		for _, p := range rules {
+			if strings.HasPrefix(path, p) && len(p) > n {
+				n = len(p)
+			}
+		}
+		return n
+	}
+	return longest(r.allow) >= longest(r.disallow)
+}
+
+// pageName names a page without a title after the last segment of its
+// path, or its host.
+func pageName(u *url.URL) string {
+	if name := path.Base(u.Path); name != "/" && name != "." {
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			return unescaped
+		}
+		return name
+	}
+	return u.Host
+}
+
+// readLimited reads r, failing once it exceeds max bytes.
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > max {
+		return nil, fmt.Errorf("larger than %d MB", max>>20)
+	}
+	return body, nil
+}
+
+// markdownPage is the saved file of a page converted to text: its title as
+// heading, its address, then the text.
+func markdownPage(title, pageURL, text string) []byte {
+	return []byte(fmt.Sprintf("# %s\n\nSource: %s\n\n%s\n", title, pageURL, text))
+}
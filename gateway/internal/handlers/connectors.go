@@ -0,0 +1,950 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/alfagnish/ollqd-gateway/internal/config"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// connectorsDoc is the store document holding the connectors. Credentials
+// are kept so scheduled syncs can sign in unattended.
+const connectorsDoc = "connectors"
+
+const (
+	// connectorSyncTick is how often the scheduler looks for connectors
+	// that are due.
+	connectorSyncTick = time.Minute
+
+	// Sync cadence lower bound in minutes; 0 syncs on request only.
+	minConnectorInterval = 15
+
+	// Bounds on the pages one sync fetches.
+	defaultConnectorMaxPages = 500
+	maxConnectorMaxPages     = 5000
+
+	// connectorIndexBatch is how many changed pages one IndexUploads
+	// stream indexes.
+	connectorIndexBatch = 32
+
+	// connectorDir is the directory below UPLOAD_DIR holding the pages
+	// fetched by each connector.
+	connectorDir = "connectors"
+)
+
+// eventConnectorCrawled is the task timeline event summing up a sync's
+// crawl.
+const eventConnectorCrawled = "connector_crawled"
+
+var (
+	// errConnectorNotFound is returned for unknown connector IDs.
+	errConnectorNotFound = errors.New("connector not found")
+
+	// errConnectorRunning is returned when a connector already has a sync
+	// queued or running.
+	errConnectorRunning = errors.New("a sync is already running for this connector")
+)
+
+// Connector pulls pages from an external source into a collection. Each
+// sync fetches the pages in scope, indexes new and changed ones, and
+// removes the points of pages that disappeared. Type selects the source
+// and which of the settings apply.
+type Connector struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Collection   string `json:"collection"`
+	SourceTag    string `json:"source_tag,omitempty"`
+	Priority     string `json:"priority,omitempty"`
+	ChunkSize    int32  `json:"chunk_size,omitempty"`
+	ChunkOverlap int32  `json:"chunk_overlap,omitempty"`
+	// IntervalMinutes schedules a sync that often; 0 syncs on request only.
+	IntervalMinutes int `json:"interval_minutes"`
+	// MaxPages bounds the pages one sync fetches. A sync stopped by it
+	// keeps the pages it did not reach.
+	MaxPages   int                 `json:"max_pages"`
+	Confluence *ConfluenceSettings `json:"confluence,omitempty"`
+	Web        *WebCrawlSettings   `json:"web,omitempty"`
+	IMAP       *IMAPSettings       `json:"imap,omitempty"`
+	Database   *DatabaseSettings   `json:"database,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+}
+
+// redacted returns a copy of c without credentials, for responses.
+func (c *Connector) redacted() Connector {
+	cp := *c
+	if c.Confluence != nil {
+		s := *c.Confluence
+		s.HasToken = s.APIToken != ""
+		s.APIToken = ""
+		cp.Confluence = &s
+	}
+	if c.IMAP != nil {
+		s := *c.IMAP
+		s.HasPassword = s.Password != ""
+		s.Password = ""
+		cp.IMAP = &s
+	}
+	if c.Database != nil {
+		s := *c.Database
+		s.HasDSN = s.DSN != ""
+		s.DSN = ""
+		cp.Database = &s
+	}
+	return cp
+}
+
+// ConnectorStatus reports the outcome of a connector's most recent sync.
+// Running is derived from the state of LastTaskID when the status is read.
+type ConnectorStatus struct {
+	Running    bool       `json:"running"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"`
+	LastTaskID string     `json:"last_task_id,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	Trigger    string     `json:"trigger,omitempty"`
+	Pages      int        `json:"pages"`
+	Changed    int        `json:"changed"`
+	Removed    int        `json:"removed"`
+	Failed     int        `json:"failed"`
+	Truncated  bool       `json:"truncated,omitempty"`
+}
+
+// connectorPage is one page fetched by a source. Key identifies the page
+// across syncs and Version changes whenever its content does. Body is
+// saved with extension Ext and indexed. A Failed page could not be fetched
+// this time; its previous version is kept.
+type connectorPage struct {
+	Key     string
+	Title   string
+	URL     string
+	Version string
+	Ext     string
+	Body    []byte
+	Failed  bool
+	// Unchanged marks a page the source found in the previous state and
+	// did not fetch again; only Key is set.
+	Unchanged bool
+	// Meta holds payload fields for the points of the page and of its
+	// attachments.
+	Meta map[string]string
+	// Attachments are files of the page indexed next to it.
+	Attachments []connectorFile
+}
+
+// connectorFile is an attachment of a page. Meta is added to the page's.
+type connectorFile struct {
+	Name string
+	Ext  string
+	Body []byte
+	Meta map[string]string
+}
+
+// connectorSource fetches the pages of a connector. crawl passes each page
+// to emit and reports whether it stopped at the connector's page limit.
+type connectorSource interface {
+	crawl(ctx context.Context, emit func(connectorPage) error) (truncated bool, err error)
+}
+
+// connectorEnv is what a source fetches with. client and dialer refuse
+// private addresses unless URL_FETCH_ALLOW_PRIVATE is set, maxBytes bounds
+// one response, and prev holds the pages of the last successful sync (none
+// on a full sync).
+type connectorEnv struct {
+	client   *http.Client
+	dialer   *net.Dialer
+	maxBytes int64
+	prev     map[string]connectorPageState
+}
+
+// connectorKind is a connector type: validate checks its settings and
+// fills in defaults, source returns the source of a sync.
+type connectorKind struct {
+	validate func(c *Connector) error
+	source   func(c *Connector, env connectorEnv) connectorSource
+}
+
+var connectorKinds = map[string]connectorKind{
+	"confluence": {validateConfluenceConnector, newConfluenceSource},
+	"web":        {validateWebConnector, newWebSource},
+	"imap":       {validateIMAPConnector, newIMAPSource},
+	"database":   {validateDatabaseConnector, newDatabaseSource},
+}
+
+// connectorState maps each page a connector indexed into a collection to
+// the file holding it, as of the last successful sync.
+type connectorState struct {
+	Collection string                        `json:"collection"`
+	Pages      map[string]connectorPageState `json:"pages"`
+	SyncedAt   time.Time                     `json:"synced_at"`
+}
+
+type connectorPageState struct {
+	Version     string   `json:"version"`
+	FilePath    string   `json:"file_path"`
+	Attachments []string `json:"attachments,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	URL         string   `json:"url,omitempty"`
+}
+
+// files returns the paths of the page's file and attachments.
+func (p connectorPageState) files() []string {
+	return append([]string{p.FilePath}, p.Attachments...)
+}
+
+func connectorStateDoc(id string) string {
+	return "connector-state-" + id
+}
+
+// ConnectorsHandler manages connectors and runs their syncs.
+type ConnectorsHandler struct {
+	cfg        *config.Config
+	grpc       *grpcclient.Client
+	tm         *tasks.Manager
+	colls      *CollectionSettings
+	diff       *DiffIndexer
+	store      *store.Store
+	mu         sync.RWMutex
+	connectors map[string]*Connector
+	syncs      map[string]*ConnectorStatus
+}
+
+// NewConnectorsHandler creates a ConnectorsHandler with the connectors
+// saved in st. Fetched pages are saved below UPLOAD_DIR.
+func NewConnectorsHandler(cfg *config.Config, gc *grpcclient.Client, tm *tasks.Manager, colls *CollectionSettings, st *store.Store, diff *DiffIndexer) *ConnectorsHandler {
+	h := &ConnectorsHandler{
+		cfg:        cfg,
+		grpc:       gc,
+		tm:         tm,
+		colls:      colls,
+		diff:       diff,
+		store:      st,
+		connectors: make(map[string]*Connector),
+		syncs:      make(map[string]*ConnectorStatus),
+	}
+	var saved []*Connector
+	if _, err := st.Load(connectorsDoc, &saved); err != nil {
+		log.Printf("WARNING: connectors: %v", err)
+	}
+	for _, c := range saved {
+		h.connectors[c.ID] = c
+		var state connectorState
+		if ok, err := st.Load(connectorStateDoc(c.ID), &state); err == nil && ok && !state.SyncedAt.IsZero() {
+			synced := state.SyncedAt
+			h.syncs[c.ID] = &ConnectorStatus{LastRunAt: &synced}
+		}
+	}
+	return h
+}
+
+// Routes registers the read-only connector routes on the given chi router.
+func (h *ConnectorsHandler) Routes(r chi.Router) {
+	r.Get("/", h.List)
+	r.Get("/{id}", h.Get)
+	r.Get("/{id}/pages", h.Pages)
+}
+
+// AdminRoutes registers the routes that change connectors or run them.
+// Connectors hold credentials and make the gateway fetch from the hosts
+// they name, so these are mounted admin-only.
+func (h *ConnectorsHandler) AdminRoutes(r chi.Router) {
+	r.Post("/", h.Create)
+	r.Put("/{id}", h.Update)
+	r.Delete("/{id}", h.Delete)
+	r.With(requireWorker(h.grpc, grpcclient.ServiceIndexing)).Post("/{id}/sync", h.Sync)
+}
+
+// saveLocked persists the connectors. Callers must hold h.mu.
+func (h *ConnectorsHandler) saveLocked() {
+	out := make([]*Connector, 0, len(h.connectors))
+	for _, c := range h.connectors {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if err := h.store.Save(connectorsDoc, out); err != nil {
+		log.Printf("WARNING: save connectors: %v", err)
+	}
+}
+
+// validateConnector checks c and fills in defaults.
+func validateConnector(c *Connector) error {
+	kind, ok := connectorKinds[c.Type]
+	if !ok {
+		return fmt.Errorf("unknown connector type %q (want confluence, web, imap or database)", c.Type)
+	}
+	if c.Name = strings.TrimSpace(c.Name); c.Name == "" {
+		return errors.New("name is required")
+	}
+	if c.IntervalMinutes != 0 && c.IntervalMinutes < minConnectorInterval {
+		return fmt.Errorf("interval_minutes must be 0 or at least %d", minConnectorInterval)
+	}
+	if c.MaxPages == 0 {
+		c.MaxPages = defaultConnectorMaxPages
+	}
+	if c.MaxPages < 0 || c.MaxPages > maxConnectorMaxPages {
+		return fmt.Errorf("max_pages must be between 1 and %d", maxConnectorMaxPages)
+	}
+	if c.ChunkSize < 0 || c.ChunkOverlap < 0 {
+		return errors.New("chunk_size and chunk_overlap must not be negative")
+	}
+	if _, err := tasks.ParsePriority(c.Priority); err != nil {
+		return err
+	}
+	if c.SourceTag == "" {
+		c.SourceTag = c.Type
+	}
+	return kind.validate(c)
+}
+
+// connectorResponseLocked returns a connector with its sync status.
+// Callers must hold h.mu.
+func (h *ConnectorsHandler) connectorResponseLocked(c *Connector) map[string]interface{} {
+	status := h.statusLocked(c.ID)
+	resp := map[string]interface{}{
+		"connector": c.redacted(),
+		"status":    status,
+	}
+	if c.IntervalMinutes > 0 {
+		resp["next_run_at"] = nextConnectorRun(c, status)
+	}
+	return resp
+}
+
+// List returns all connectors with their sync status.
+func (h *ConnectorsHandler) List(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	list := make([]*Connector, 0, len(h.connectors))
+	for _, c := range h.connectors {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].ID < list[j].ID
+	})
+	out := make([]map[string]interface{}, len(list))
+	for i, c := range list {
+		out[i] = h.connectorResponseLocked(c)
+	}
+	h.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"connectors": out,
+		"count":      len(out),
+	})
+}
+
+// Get returns one connector with its sync status.
+func (h *ConnectorsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.mu.RLock()
+	c, ok := h.connectors[id]
+	var resp map[string]interface{}
+	if ok {
+		resp = h.connectorResponseLocked(c)
+	}
+	h.mu.RUnlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("connector %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Create saves a new connector. It does not sync until asked to or its
+// interval is due.
+func (h *ConnectorsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var c Connector
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := validateConnector(&c); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.ID = uuid.New().String()
+	c.CreatedAt = time.Now().UTC()
+	c.UpdatedAt = c.CreatedAt
+
+	h.mu.Lock()
+	h.connectors[c.ID] = &c
+	h.saveLocked()
+	h.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, c.redacted())
+}
+
+// Update replaces a connector's settings. Its type cannot change. An
+// omitted credential (Confluence API token, IMAP password or database DSN)
+// keeps the saved one. A new collection is filled from scratch on the next
+// sync; the old one keeps its points.
+func (h *ConnectorsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var c Connector
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	prev, ok := h.connectors[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("connector %s not found", id))
+		return
+	}
+	if c.Type == "" {
+		c.Type = prev.Type
+	}
+	if c.Type != prev.Type {
+		writeError(w, http.StatusBadRequest, "a connector's type cannot change")
+		return
+	}
+	if c.Confluence != nil && c.Confluence.APIToken == "" && prev.Confluence != nil {
+		c.Confluence.APIToken = prev.Confluence.APIToken
+	}
+	if c.IMAP != nil && c.IMAP.Password == "" && prev.IMAP != nil {
+		c.IMAP.Password = prev.IMAP.Password
+	}
+	if c.Database != nil && c.Database.DSN == "" && prev.Database != nil {
+		c.Database.DSN = prev.Database.DSN
+	}
+	if err := validateConnector(&c); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.ID, c.CreatedAt, c.UpdatedAt = id, prev.CreatedAt, time.Now().UTC()
+	h.connectors[id] = &c
+	h.saveLocked()
+	writeJSON(w, http.StatusOK, c.redacted())
+}
+
+// Delete removes a connector. With ?purge=true the points and files of
+// its pages are removed too; otherwise they stay searchable.
+func (h *ConnectorsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	purge, _ := strconv.ParseBool(r.URL.Query().Get("purge"))
+
+	h.mu.Lock()
+	_, ok := h.connectors[id]
+	running := ok && h.statusLocked(id).Running
+	if ok && !running {
+		delete(h.connectors, id)
+		delete(h.syncs, id)
+		h.saveLocked()
+	}
+	h.mu.Unlock()
+
+	switch {
+	case !ok:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("connector %s not found", id))
+		return
+	case running:
+		writeError(w, http.StatusConflict, "cancel the running sync before deleting the connector")
+		return
+	}
+
+	resp := map[string]interface{}{"deleted": id}
+	if purge {
+		var state connectorState
+		if _, err := h.store.Load(connectorStateDoc(id), &state); err != nil {
+			log.Printf("WARNING: connector state for %s: %v", id, err)
+		}
+		paths := make([]string, 0, len(state.Pages))
+		for _, p := range state.Pages {
+			paths = append(paths, p.files()...)
+		}
+		if len(paths) > 0 {
+			if err := h.diff.removePoints(r.Context(), state.Collection, paths); err != nil {
+				writeError(w, http.StatusBadGateway, fmt.Sprintf("connector deleted, but removing its points failed: %v", err))
+				return
+			}
+		}
+		if err := os.RemoveAll(h.pageDir(id)); err != nil {
+			log.Printf("WARNING: remove pages of connector %s: %v", id, err)
+		}
+		resp["removed_pages"] = len(state.Pages)
+	}
+	if err := h.store.Save(connectorStateDoc(id), &connectorState{}); err != nil {
+		log.Printf("WARNING: reset connector state for %s: %v", id, err)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Sync starts a sync of a connector now. With ?full=true every page is
+// indexed again, not only new and changed ones.
+func (h *ConnectorsHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	full, _ := strconv.ParseBool(r.URL.Query().Get("full"))
+	taskID, err := h.startSync(id, "manual", middleware.UsernameFromContext(r.Context()), full)
+	switch {
+	case err == nil:
+		writeTaskAccepted(w, h.tm, taskID)
+	case errors.Is(err, errConnectorNotFound):
+		writeError(w, http.StatusNotFound, fmt.Sprintf("connector %s not found", id))
+	case errors.Is(err, errConnectorRunning):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// Pages lists the pages a connector has indexed, with the file_path their
+// points carry, as of its last successful sync.
+func (h *ConnectorsHandler) Pages(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.mu.RLock()
+	_, ok := h.connectors[id]
+	h.mu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("connector %s not found", id))
+		return
+	}
+
+	var state connectorState
+	if _, err := h.store.Load(connectorStateDoc(id), &state); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	type pageEntry struct {
+		Key string `json:"key"`
+		connectorPageState
+	}
+	pages := make([]pageEntry, 0, len(state.Pages))
+	for key, p := range state.Pages {
+		pages = append(pages, pageEntry{key, p})
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Key < pages[j].Key })
+	resp := map[string]interface{}{
+		"connector_id": id,
+		"collection":   state.Collection,
+		"pages":        pages,
+		"count":        len(pages),
+	}
+	if !state.SyncedAt.IsZero() {
+		resp["synced_at"] = state.SyncedAt
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// StartScheduler runs due connector syncs until ctx is done.
+func (h *ConnectorsHandler) StartScheduler(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(connectorSyncTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				h.runDueSyncs(now)
+			}
+		}
+	}()
+}
+
+// runDueSyncs starts a sync for every scheduled connector that is due.
+func (h *ConnectorsHandler) runDueSyncs(now time.Time) {
+	if h.grpc.Indexing == nil || !h.grpc.Supports(grpcclient.ServiceIndexing) {
+		return
+	}
+
+	h.mu.RLock()
+	var due []string
+	for id, c := range h.connectors {
+		if c.IntervalMinutes == 0 {
+			continue
+		}
+		status := h.statusLocked(id)
+		if !status.Running && !now.Before(nextConnectorRun(c, status)) {
+			due = append(due, id)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, id := range due {
+		if _, err := h.startSync(id, "schedule", "", false); err != nil && !errors.Is(err, errConnectorRunning) {
+			log.Printf("WARNING: scheduled sync of connector %s: %v", id, err)
+		}
+	}
+}
+
+// nextConnectorRun returns when a scheduled connector is next due:
+// immediately if it has never run, otherwise one interval after the last
+// run.
+func nextConnectorRun(c *Connector, status ConnectorStatus) time.Time {
+	if status.LastRunAt == nil {
+		return time.Time{}
+	}
+	return status.LastRunAt.Add(time.Duration(c.IntervalMinutes) * time.Minute)
+}
+
+// startSync creates and enqueues a sync_connector task. uploader, empty
+// for scheduled syncs, is recorded in the provenance of the pages.
+func (h *ConnectorsHandler) startSync(id, trigger, uploader string, full bool) (string, error) {
+	h.mu.Lock()
+	c, ok := h.connectors[id]
+	if !ok {
+		h.mu.Unlock()
+		return "", errConnectorNotFound
+	}
+	if h.statusLocked(id).Running {
+		h.mu.Unlock()
+		return "", errConnectorRunning
+	}
+	conn := *c
+	conn.Collection, conn.ChunkSize, conn.ChunkOverlap = h.colls.ResolveIndex(conn.Collection, conn.ChunkSize, conn.ChunkOverlap)
+	priority, _ := tasks.ParsePriority(conn.Priority)
+
+	taskID := h.tm.Create("sync_connector", map[string]interface{}{
+		"connector_id": id,
+		"name":         conn.Name,
+		"type":         conn.Type,
+		"collection":   conn.Collection,
+		"trigger":      trigger,
+		"full":         full,
+		"priority":     string(priority),
+	})
+	now := time.Now()
+	h.syncs[id] = &ConnectorStatus{LastRunAt: &now, LastTaskID: taskID, Trigger: trigger}
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.tm.SetCancelFunc(taskID, cancel)
+	h.tm.Enqueue(taskID, priority, func() {
+		h.runSync(ctx, taskID, &conn, uploader, full)
+	})
+	return taskID, nil
+}
+
+// runSync crawls the connector's source, saves new and changed pages below
+// UPLOAD_DIR, purges the points of changed and removed pages, and indexes
+// the changed ones. The page list is only saved once indexing has
+// completed, so a failed run is retried in full next time.
+func (h *ConnectorsHandler) runSync(ctx context.Context, taskID string, c *Connector, uploader string, full bool) {
+	var status ConnectorStatus
+	defer func() {
+		if t := h.tm.Get(taskID); t != nil && t.Status != tasks.StatusCompleted {
+			status.LastError = t.Error
+			if status.LastError == "" {
+				status.LastError = string(t.Status)
+			}
+		}
+		h.setStatus(c.ID, func(st *ConnectorStatus) {
+			st.LastError = status.LastError
+			st.Pages, st.Changed, st.Removed, st.Failed, st.Truncated = status.Pages, status.Changed, status.Removed, status.Failed, status.Truncated
+		})
+	}()
+
+	target := c.Collection
+	if target == "" {
+		target = workerDefaultDocumentsCollection
+	}
+	var prev connectorState
+	if _, err := h.store.Load(connectorStateDoc(c.ID), &prev); err != nil {
+		log.Printf("WARNING: connector state for %s: %v", c.ID, err)
+	}
+	if prev.Collection != target {
+		prev.Pages = nil // first sync into this collection
+	}
+	dir := h.pageDir(c.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		h.tm.Fail(taskID, fmt.Sprintf("create page directory: %v", err))
+		return
+	}
+
+	current := make(map[string]connectorPageState)
+	var changed, stale, superseded []string
+	names := map[string]string{}
+	fileMeta := map[string]map[string]string{}
+	sources := map[string]string{}
+	env := connectorEnv{
+		client:   newFetchClient(h.cfg.URLFetchAllowPrivate),
+		dialer:   newFetchDialer(h.cfg.URLFetchAllowPrivate),
+		maxBytes: h.cfg.MaxUploadSizeMB << 20,
+	}
+	if !full {
+		env.prev = prev.Pages
+	}
+	source := connectorKinds[c.Type].source(c, env)
+	truncated, err := source.crawl(ctx, func(p connectorPage) error {
+		if _, dup := current[p.Key]; dup {
+			return nil
+		}
+		old, known := prev.Pages[p.Key]
+		switch {
+		case p.Unchanged:
+			if known {
+				status.Pages++
+				current[p.Key] = old
+			}
+			return nil
+		case p.Failed:
+			status.Failed++
+			if known {
+				current[p.Key] = old
+			}
+			return nil
+		}
+		status.Pages++
+		if known && !full && old.Version == p.Version {
+			old.Title, old.URL = p.Title, p.URL
+			current[p.Key] = old
+			return nil
+		}
+		state, err := h.savePage(dir, p)
+		if err != nil {
+			return err
+		}
+		if known {
+			stale = append(stale, old.files()...)
+			for _, path := range old.files() {
+				if !slices.Contains(state.files(), path) {
+					superseded = append(superseded, path)
+				}
+			}
+		}
+		current[p.Key] = state
+		status.Changed++
+		for i, path := range state.files() {
+			changed = append(changed, path)
+			meta := maps.Clone(p.Meta)
+			if i == 0 {
+				names[path] = p.Title
+			} else {
+				a := p.Attachments[i-1]
+				names[path] = a.Name
+				if meta == nil {
+					meta = map[string]string{}
+				}
+				maps.Copy(meta, a.Meta)
+			}
+			if len(meta) > 0 {
+				fileMeta[path] = meta
+			}
+			if p.URL != "" {
+				sources[path] = p.URL
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			h.tm.Cancel(taskID)
+		} else {
+			h.tm.Fail(taskID, fmt.Sprintf("crawl: %v", err))
+		}
+		return
+	}
+
+	var removed []string
+	for key, p := range prev.Pages {
+		if _, ok := current[key]; ok {
+			continue
+		}
+		if truncated {
+			// A truncated crawl cannot tell a removed page from one it
+			// did not reach; keep tracking it.
+			current[key] = p
+			continue
+		}
+		removed = append(removed, p.files()...)
+		status.Removed++
+	}
+	status.Truncated = truncated
+	h.tm.RecordEvent(taskID, eventConnectorCrawled, fmt.Sprintf("%d pages: %d new or changed, %d removed, %d failed",
+		status.Pages, status.Changed, status.Removed, status.Failed))
+	h.tm.UpdateProgress(taskID, 0.05, "running")
+
+	// Changed pages are purged too so chunks beyond a shortened page's new
+	// length do not linger.
+	purge := append(append([]string{}, removed...), stale...)
+	if len(purge) > 0 {
+		if err := h.diff.removePoints(ctx, target, purge); err != nil {
+			h.tm.Fail(taskID, fmt.Sprintf("remove stale points: %v", err))
+			return
+		}
+	}
+	for _, path := range append(removed, superseded...) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("WARNING: remove page file %s: %v", path, err)
+		}
+	}
+
+	save := func() {
+		state := connectorState{Collection: target, Pages: current, SyncedAt: time.Now()}
+		if err := h.store.Save(connectorStateDoc(c.ID), &state); err != nil {
+			log.Printf("WARNING: save connector state for %s: %v", c.ID, err)
+		}
+	}
+
+	if len(changed) == 0 {
+		save()
+		h.tm.Complete(taskID, map[string]string{
+			"files":      "0",
+			"chunks":     "0",
+			"pages":      strconv.Itoa(status.Pages),
+			"skipped":    strconv.Itoa(status.Pages),
+			"removed":    strconv.Itoa(status.Removed),
+			"collection": target,
+		})
+		return
+	}
+
+	pending := changed
+	next := func(context.Context) ([]string, bool) {
+		if len(pending) == 0 {
+			return nil, false
+		}
+		n := min(connectorIndexBatch, len(pending))
+		batch := grpcclient.SplitDisplayNames(pending[:n:n], names)[0]
+		pending = pending[len(batch):]
+		return batch, true
+	}
+	h.tm.ConsumeIndexBatches(ctx, h.grpc, taskID, next, func(ctx context.Context, batch []string) (grpcclient.IndexingStream, error) {
+		batchMeta := map[string]map[string]string{}
+		for _, path := range batch {
+			if m := fileMeta[path]; m != nil {
+				batchMeta[path] = m
+			}
+		}
+		ctx = withFileMeta(withDisplayNames(ctx, batch, names), batchMeta)
+		ctx = grpcclient.WithProvenance(ctx, grpcclient.Provenance{
+			SourceType: grpcclient.SourceConnector,
+			ShareID:    c.ID,
+			Uploader:   uploader,
+			TaskID:     taskID,
+			Sources:    provenanceSources(sources, batch),
+		})
+		return h.grpc.Indexing.IndexUploads(ctx, &grpcclient.IndexUploadsRequest{
+			SavedPaths:   batch,
+			Collection:   c.Collection,
+			ChunkSize:    c.ChunkSize,
+			ChunkOverlap: c.ChunkOverlap,
+			SourceTag:    c.SourceTag,
+		})
+	})
+	if t := h.tm.Get(taskID); t != nil && t.Status == tasks.StatusCompleted {
+		save()
+	}
+}
+
+// savePage writes a page and its attachments below dir and returns its new
+// state. Attachments are named after the page's file.
+func (h *ConnectorsHandler) savePage(dir string, p connectorPage) (connectorPageState, error) {
+	state := connectorPageState{Version: p.Version, FilePath: filepath.Join(dir, connectorFileName(p)), Title: p.Title, URL: p.URL}
+	if err := os.WriteFile(state.FilePath, p.Body, 0o644); err != nil {
+		return state, fmt.Errorf("save page %s: %w", p.Title, err)
+	}
+	base := strings.TrimSuffix(state.FilePath, p.Ext)
+	for i, a := range p.Attachments {
+		path := fmt.Sprintf("%s-%d-%s%s", base, i+1, slugify(strings.TrimSuffix(a.Name, filepath.Ext(a.Name)), "attachment"), a.Ext)
+		if err := os.WriteFile(path, a.Body, 0o644); err != nil {
+			return state, fmt.Errorf("save attachment %s of %s: %w", a.Name, p.Title, err)
+		}
+		state.Attachments = append(state.Attachments, path)
+	}
+	return state, nil
+}
+
+// withFileMeta attaches the payload fields of a batch of page files. A map
+// over the metadata limit is dropped with a warning; the points are then
+// indexed without the fields.
+func withFileMeta(ctx context.Context, meta map[string]map[string]string) context.Context {
+	ctx, ok := grpcclient.WithFileMeta(ctx, meta)
+	if !ok {
+		log.Printf("WARNING: payload fields of %d connector files exceed the metadata limit; not sent", len(meta))
+	}
+	return ctx
+}
+
+// pageDir is the directory holding the pages of connector id.
+func (h *ConnectorsHandler) pageDir(id string) string {
+	return filepath.Join(h.cfg.UploadDir, connectorDir, id)
+}
+
+// connectorFileName names the file of a page after its title, made unique
+// by a hash of its key.
+func connectorFileName(p connectorPage) string {
+	sum := sha256.Sum256([]byte(p.Key))
+	return slugify(p.Title, "page") + "-" + hex.EncodeToString(sum[:4]) + p.Ext
+}
+
+// slugify lowercases s and keeps at most 60 of its ASCII letters and
+// digits, joining the runs with dashes. It returns fallback for an empty
+// result.
+func slugify(s, fallback string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 60 {
+			break
+		}
+	}
+	if slug := strings.Trim(b.String(), "-"); slug != "" {
+		return slug
+	}
+	return fallback
+}
+
+// statusLocked returns a copy of a connector's sync status with Running
+// filled in from its last task. Callers must hold h.mu.
+func (h *ConnectorsHandler) statusLocked(id string) ConnectorStatus {
+	var status ConnectorStatus
+	if st := h.syncs[id]; st != nil {
+		status = *st
+	}
+	if t := h.tm.Get(status.LastTaskID); t != nil {
+		status.Running = t.Status == tasks.StatusPending || t.Status == tasks.StatusRunning || t.Status == tasks.StatusReconnecting
+	}
+	return status
+}
+
+// setStatus applies fn to a connector's sync status under the lock.
+func (h *ConnectorsHandler) setStatus(id string, fn func(*ConnectorStatus)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.connectors[id]; !ok {
+		return
+	}
+	st := h.syncs[id]
+	if st == nil {
+		st = &ConnectorStatus{}
+		h.syncs[id] = st
+	}
+	fn(st)
+}
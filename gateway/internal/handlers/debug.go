@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"sync"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// processStart is when the gateway process started, for uptime reporting.
+var processStart = time.Now()
+
+// streamCounter counts open long-lived connections by kind.
+type streamCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// activeStreams counts the WebSockets and SSE streams the gateway is
+// serving, as reported by the debug endpoint.
+var activeStreams = &streamCounter{counts: make(map[string]int)}
+
+// track registers one open stream of kind and returns the func that
+// unregisters it.
+func (c *streamCounter) track(kind string) func() {
+	c.mu.Lock()
+	c.counts[kind]++
+	c.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			c.counts[kind]--
+			c.mu.Unlock()
+		})
+	}
+}
+
+func (c *streamCounter) snapshot() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]int, len(c.counts))
+	for k, v := range c.counts {
+		out[k] = v
+	}
+	return out
+}
+
+// DebugHandler serves runtime diagnostics and net/http/pprof. It is only
+// mounted when DEBUG_ENDPOINTS=true, behind RequireAdmin.
+type DebugHandler struct {
+	grpc *grpcclient.Client
+	tm   *tasks.Manager
+}
+
+// NewDebugHandler creates a new DebugHandler.
+func NewDebugHandler(gc *grpcclient.Client, tm *tasks.Manager) *DebugHandler {
+	return &DebugHandler{grpc: gc, tm: tm}
+}
+
+// Routes registers the debug routes on the given chi router.
+func (h *DebugHandler) Routes(r chi.Router) {
+	r.Use(middleware.NoCache)
+	r.Get("/", h.Runtime)
+	r.Get("/goroutines", h.Goroutines)
+
+	// pprof.Index serves named profiles only under /debug/pprof/, so each
+	// profile gets its own route.
+	r.Get("/pprof/", pprof.Index)
+	r.Get("/pprof/cmdline", pprof.Cmdline)
+	r.Get("/pprof/profile", pprof.Profile)
+	r.Get("/pprof/symbol", pprof.Symbol)
+	r.Post("/pprof/symbol", pprof.Symbol)
+	r.Get("/pprof/trace", pprof.Trace)
+	for _, name := range []string{"goroutine", "threadcreate", "mutex", "heap", "block", "allocs"} {
+		r.Get("/pprof/"+name, pprof.Handler(name).ServeHTTP)
+	}
+}
+
+// Runtime reports goroutine and memory statistics, open streams, the state
+// of the worker connection and task counts.
+func (h *DebugHandler) Runtime(w http.ResponseWriter, r *http.Request) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	grpcState := map[string]interface{}{"state": "none"}
+	if h.grpc != nil {
+		if conn := h.grpc.Conn(); conn != nil {
+			grpcState = map[string]interface{}{
+				"target": conn.Target(),
+				"state":  conn.GetState().String(),
+			}
+		}
+	}
+
+	taskCounts := map[string]int{}
+	if h.tm != nil {
+		for _, t := range h.tm.List() {
+			taskCounts[string(t.Status)]++
+		}
+	}
+
+	var lastGC string
+	if m.LastGC > 0 {
+		lastGC = time.Unix(0, int64(m.LastGC)).UTC().Format(time.RFC3339)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"go_version": runtime.Version(),
+		"uptime":     time.Since(processStart).Round(time.Second).String(),
+		"goroutines": runtime.NumGoroutine(),
+		"cpus":       runtime.NumCPU(),
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+		"memory": map[string]interface{}{
+			"heap_alloc_bytes":  m.HeapAlloc,
+			"heap_inuse_bytes":  m.HeapInuse,
+			"heap_objects":      m.HeapObjects,
+			"sys_bytes":         m.Sys,
+			"total_alloc_bytes": m.TotalAlloc,
+			"stack_inuse_bytes": m.StackInuse,
+			"num_gc":            m.NumGC,
+			"gc_pause_total":    time.Duration(m.PauseTotalNs).String(),
+			"last_gc":           lastGC,
+		},
+		"streams": activeStreams.snapshot(),
+		"grpc":    grpcState,
+		"tasks":   taskCounts,
+	})
+}
+
+// Goroutines writes a goroutine dump as text: stacks grouped by identical
+// trace, or every goroutine separately with ?full=true.
+func (h *DebugHandler) Goroutines(w http.ResponseWriter, r *http.Request) {
+	debug := 1
+	if r.URL.Query().Get("full") == "true" {
+		debug = 2
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	runtimepprof.Lookup("goroutine").WriteTo(w, debug)
+}
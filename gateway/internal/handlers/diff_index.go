@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/manifest"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+// workerDefaultCodebaseCollection is the collection the worker writes to
+// when an IndexCodebase request leaves it empty.
+const workerDefaultCodebaseCollection = "codebase"
+
+// eventDiffScanned is the task timeline event of a differential run's scan.
+const eventDiffScanned = "diff_scanned"
+
+// removeBatchSize is how many file paths go into one Qdrant delete filter.
+const removeBatchSize = 256
+
+// DiffIndexer computes differential re-index plans from gateway-side
+// manifests and removes points for files that disappeared from a root.
+type DiffIndexer struct {
+	manifests *manifest.Store
+	qdrantURL string
+	client    *http.Client
+}
+
+// NewDiffIndexer creates a DiffIndexer deleting points from Qdrant at
+// qdrantURL through client.
+func NewDiffIndexer(manifests *manifest.Store, qdrantURL string, client *http.Client) *DiffIndexer {
+	return &DiffIndexer{
+		manifests: manifests,
+		qdrantURL: qdrantURL,
+		client:    client,
+	}
+}
+
+// diffPlan is the outcome of scanning a root against its manifest.
+type diffPlan struct {
+	manifest *manifest.Manifest
+	known    bool // a manifest existed before this scan
+	current  map[string]string
+	diff     manifest.Diff
+}
+
+// plan scans root and diffs it against the stored manifest. It fails when
+// the root is not visible to the gateway.
+func (d *DiffIndexer) plan(collection, root string, extraSkipDirs []string) (*diffPlan, error) {
+	root = filepath.Clean(root)
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", root)
+	}
+
+	m, known, err := d.manifests.Load(collection, root)
+	if err != nil {
+		log.Printf("WARNING: manifest for %s: %v", root, err)
+	}
+	current, err := manifest.Scan(root, extraSkipDirs)
+	if err != nil {
+		return nil, err
+	}
+	return &diffPlan{manifest: m, known: known, current: current, diff: m.Diff(current)}, nil
+}
+
+// commit records the scanned hashes as the root's new manifest.
+func (d *DiffIndexer) commit(p *diffPlan) {
+	p.manifest.Files = p.current
+	if err := d.manifests.Save(p.manifest); err != nil {
+		log.Printf("WARNING: save manifest for %s: %v", p.manifest.Root, err)
+	}
+}
+
+// removePoints deletes all points whose file_path is in paths.
+func (d *DiffIndexer) removePoints(ctx context.Context, collection string, paths []string) error {
+	for start := 0; start < len(paths); start += removeBatchSize {
+		end := start + removeBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+		body, _ := json.Marshal(map[string]interface{}{
+			"filter": map[string]interface{}{
+				"must": []interface{}{
+					map[string]interface{}{
+						"key":   "file_path",
+						"match": map[string]interface{}{"any": paths[start:end]},
+					},
+				},
+			},
+		})
+		req, err := http.NewRequestWithContext(ctx, "POST",
+			d.qdrantURL+"/collections/"+url.PathEscape(collection)+"/points/delete?wait=true",
+			bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return err
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("qdrant status %d", resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// runDifferential indexes only what changed under root since the last
+// successful run. Removed files are purged here; changed files are passed
+// to the worker as an explicit list, the files argument of open. If the
+// gateway cannot see the root it falls back to a plain worker-side
+// incremental run.
+func (d *DiffIndexer) runDifferential(ctx context.Context, gc *grpcclient.Client, tm *tasks.Manager, taskID, collection, root string, extraSkipDirs []string, open func(ctx context.Context, files []string) (grpcclient.IndexingStream, error)) {
+	plan, err := d.plan(collection, root, extraSkipDirs)
+	if err != nil {
+		log.Printf("[task %s] differential index unavailable, using worker incremental: %v", taskID, err)
+		tm.ConsumeIndexStream(ctx, gc, taskID, func() (grpcclient.IndexingStream, error) { return open(ctx, nil) })
+		return
+	}
+
+	tm.RecordEvent(taskID, eventDiffScanned, fmt.Sprintf("%d files scanned, %d changed, %d removed",
+		len(plan.current), len(plan.diff.Changed), len(plan.diff.Removed)))
+
+	target := collection
+	if target == "" {
+		target = workerDefaultCodebaseCollection
+	}
+	if len(plan.diff.Removed) > 0 {
+		if err := d.removePoints(ctx, target, plan.diff.Removed); err != nil {
+			tm.Fail(taskID, fmt.Sprintf("remove deleted files: %v", err))
+			return
+		}
+	}
+
+	if plan.known && len(plan.diff.Changed) == 0 {
+		d.commit(plan)
+		tm.Complete(taskID, map[string]string{
+			"files":      "0",
+			"chunks":     "0",
+			"skipped":    strconv.Itoa(len(plan.current)),
+			"removed":    strconv.Itoa(len(plan.diff.Removed)),
+			"collection": target,
+		})
+		return
+	}
+
+	var files []string
+	if plan.known {
+		files = plan.diff.Changed
+	}
+	tm.ConsumeIndexStream(ctx, gc, taskID, func() (grpcclient.IndexingStream, error) { return open(ctx, files) })
+
+	if t := tm.Get(taskID); t != nil && t.Status == tasks.StatusCompleted {
+		d.commit(plan)
+	}
+}
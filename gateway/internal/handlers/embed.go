@@ -0,0 +1,654 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// embedTokensDoc is the store document holding the embed tokens.
+const embedTokensDoc = "embed-tokens"
+
+const (
+	// embedTokenHeader carries the embed token on widget requests.
+	embedTokenHeader = "X-Ollqd-Embed-Token"
+	// embedTokenPrefix starts every embed token, so leaked ones are easy to
+	// recognise.
+	embedTokenPrefix = "oqe_"
+
+	maxEmbedTokens      = 100
+	maxEmbedName        = 80
+	maxEmbedOrigins     = 20
+	maxEmbedCollections = 20
+	maxEmbedBody        = 64 << 10
+	maxEmbedMessage     = 4000
+	maxEmbedHistory     = 20
+	maxEmbedRateLimit   = 600
+
+	// embedDefaultRateLimit is the requests per minute a visitor may make
+	// with a token that does not set rate_limit.
+	embedDefaultRateLimit = 20
+	// embedUseInterval is how often a token's last use is persisted.
+	embedUseInterval = time.Minute
+)
+
+//go:embed embed_chat.js
+var embedChatJS []byte
+
+// EmbedToken is a public API token for the chat widget. It only reaches
+// POST /embed/chat, answers from its own collections, is accepted from its
+// own origins and is rate limited per visitor. Only a hash of the secret is
+// kept; the token itself is shown once, when it is created.
+type EmbedToken struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Hash        string     `json:"-"`
+	Collections []string   `json:"collections"`
+	Origins     []string   `json:"origins"`
+	RateLimit   int        `json:"rate_limit"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+// embedTokenRecord is how a token is persisted: with its hash, which is
+// never sent to clients.
+type embedTokenRecord struct {
+	EmbedToken
+	Hash string `json:"hash"`
+}
+
+// validate normalises and checks t, returning an error naming the offending
+// field.
+func (t *EmbedToken) validate() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" || len(t.Name) > maxEmbedName {
+		return fmt.Errorf("name must be 1-%d bytes", maxEmbedName)
+	}
+
+	colls := make([]string, 0, len(t.Collections))
+	for _, c := range t.Collections {
+		if c = strings.TrimSpace(c); c != "" && !containsString(colls, c) {
+			colls = append(colls, c)
+		}
+	}
+	if len(colls) == 0 || len(colls) > maxEmbedCollections {
+		return fmt.Errorf("collections must list 1-%d collections", maxEmbedCollections)
+	}
+	t.Collections = colls
+
+	origins := make([]string, 0, len(t.Origins))
+	for _, o := range t.Origins {
+		o, err := normalizeOrigin(o)
+		if err != nil {
+			return err
+		}
+		if o != "" && !containsString(origins, o) {
+			origins = append(origins, o)
+		}
+	}
+	if len(origins) == 0 || len(origins) > maxEmbedOrigins {
+		return fmt.Errorf("origins must list 1-%d origins", maxEmbedOrigins)
+	}
+	t.Origins = origins
+
+	if t.RateLimit == 0 {
+		t.RateLimit = embedDefaultRateLimit
+	}
+	if t.RateLimit < 1 || t.RateLimit > maxEmbedRateLimit {
+		return fmt.Errorf("rate_limit must be 1-%d requests per minute", maxEmbedRateLimit)
+	}
+	return nil
+}
+
+// normalizeOrigin checks that o is "*" or a scheme and host, such as
+// "https://portal.example.com" or "http://localhost:3000", and returns it
+// lower-cased without a trailing slash.
+func normalizeOrigin(o string) (string, error) {
+	o = strings.ToLower(strings.TrimRight(strings.TrimSpace(o), "/"))
+	if o == "" || o == "*" {
+		return o, nil
+	}
+	u, err := url.Parse(o)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.Path != "" || u.RawQuery != "" || u.User != nil {
+		return "", fmt.Errorf("origin %q must be a scheme and host, such as https://portal.example.com", o)
+	}
+	return o, nil
+}
+
+// allowsOrigin reports whether t accepts requests from origin. "*" accepts
+// any origin, including requests made outside a browser, which send none.
+func (t *EmbedToken) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, o := range t.Origins {
+		if o == "*" || (origin != "" && o == origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashEmbedToken returns the stored form of token.
+func hashEmbedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// EmbedTokens holds the embed tokens, persisted in the gateway store.
+type EmbedTokens struct {
+	mu     sync.Mutex
+	store  *store.Store
+	tokens map[string]*EmbedToken
+	saved  map[string]time.Time // last use persisted, by token ID
+}
+
+// NewEmbedTokens loads the embed tokens from st.
+func NewEmbedTokens(st *store.Store) *EmbedTokens {
+	t := &EmbedTokens{store: st, tokens: make(map[string]*EmbedToken), saved: make(map[string]time.Time)}
+	var records map[string]*embedTokenRecord
+	if _, err := st.Load(embedTokensDoc, &records); err != nil {
+		log.Printf("WARNING: embed tokens: %v", err)
+	}
+	for id, rec := range records {
+		tok := rec.EmbedToken
+		tok.Hash = rec.Hash
+		t.tokens[id] = &tok
+	}
+	return t
+}
+
+// saveLocked persists the tokens. The caller holds t.mu.
+func (t *EmbedTokens) saveLocked() error {
+	records := make(map[string]embedTokenRecord, len(t.tokens))
+	for id, tok := range t.tokens {
+		records[id] = embedTokenRecord{EmbedToken: *tok, Hash: tok.Hash}
+	}
+	return t.store.Save(embedTokensDoc, records)
+}
+
+// Create stores tok and returns its secret, which is not kept.
+func (t *EmbedTokens) Create(tok *EmbedToken) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	secret := embedTokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.tokens) >= maxEmbedTokens {
+		return "", fmt.Errorf("at most %d embed tokens can exist", maxEmbedTokens)
+	}
+	tok.ID = uuid.NewString()
+	tok.Prefix = secret[:len(embedTokenPrefix)+6]
+	tok.Hash = hashEmbedToken(secret)
+	stored := *tok
+	t.tokens[tok.ID] = &stored
+	return secret, t.saveLocked()
+}
+
+// Update replaces the settings of the token with id, keeping its secret.
+func (t *EmbedTokens) Update(id string, upd *EmbedToken) (*EmbedToken, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tok, ok := t.tokens[id]
+	if !ok {
+		return nil, false, nil
+	}
+	tok.Name, tok.Collections, tok.Origins, tok.RateLimit = upd.Name, upd.Collections, upd.Origins, upd.RateLimit
+	out := *tok
+	return &out, true, t.saveLocked()
+}
+
+// List returns copies of the tokens, newest first.
+func (t *EmbedTokens) List() []EmbedToken {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]EmbedToken, 0, len(t.tokens))
+	for _, tok := range t.tokens {
+		out = append(out, *tok)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Revoke deletes a token, reporting whether it existed.
+func (t *EmbedTokens) Revoke(id string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.tokens[id]; !ok {
+		return false, nil
+	}
+	delete(t.tokens, id)
+	delete(t.saved, id)
+	return true, t.saveLocked()
+}
+
+// Authorize returns a copy of the token whose secret is secret and records
+// its use. The use is persisted at most once per embedUseInterval.
+func (t *EmbedTokens) Authorize(secret string) (*EmbedToken, bool) {
+	if !strings.HasPrefix(secret, embedTokenPrefix) {
+		return nil, false
+	}
+	hash := []byte(hashEmbedToken(secret))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tok := range t.tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(tok.Hash)) != 1 {
+			continue
+		}
+		now := time.Now().UTC()
+		tok.LastUsedAt = &now
+		if now.Sub(t.saved[tok.ID]) >= embedUseInterval {
+			t.saved[tok.ID] = now
+			if err := t.saveLocked(); err != nil {
+				log.Printf("WARNING: embed tokens: %v", err)
+			}
+		}
+		out := *tok
+		return &out, true
+	}
+	return nil, false
+}
+
+// embedLimiter is a token bucket per key, refilled at rate per minute up
+// to rate.
+type embedLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*embedBucket
+	sweep   time.Time
+}
+
+type embedBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newEmbedLimiter() *embedLimiter {
+	return &embedLimiter{buckets: make(map[string]*embedBucket)}
+}
+
+// allow takes one request from key's bucket. When the bucket is empty it
+// returns false and how long until the next request is allowed.
+func (l *embedLimiter) allow(key string, rate int, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Full buckets carry no state; drop them now and then.
+	if now.Sub(l.sweep) > time.Minute {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > time.Minute {
+				delete(l.buckets, k)
+			}
+		}
+		l.sweep = now
+	}
+
+	capacity, perSec := float64(rate), float64(rate)/60
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &embedBucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*perSec)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / perSec * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// EmbedHandler serves the embeddable chat widget and manages its tokens.
+// The widget routes under /embed are public: their embed token is the
+// authorization, they answer browsers only from the token's origins and
+// they skip the gateway-wide CORS policy.
+type EmbedHandler struct {
+	tokens  *EmbedTokens
+	limiter *embedLimiter
+	grpc    *grpcclient.Client
+	prefs   *ChatPrefsStore
+	tm      *tasks.Manager
+	cite    *CitationEnricher
+	flush   StreamFlush
+}
+
+// NewEmbedHandler creates a new EmbedHandler. Widget chat answers with the
+// server's chat defaults from prefs, honours reindex locks in tm, cites
+// sources with cite and coalesces answer chunks as flush says.
+func NewEmbedHandler(tokens *EmbedTokens, gc *grpcclient.Client, prefs *ChatPrefsStore, tm *tasks.Manager, cite *CitationEnricher, flush StreamFlush) *EmbedHandler {
+	return &EmbedHandler{tokens: tokens, limiter: newEmbedLimiter(), grpc: gc, prefs: prefs, tm: tm, cite: cite, flush: flush}
+}
+
+// Routes registers the token management routes (under
+// /api/system/embed-tokens, admin only).
+func (h *EmbedHandler) Routes(r chi.Router) {
+	r.Get("/", h.ListTokens)
+	r.Post("/", h.CreateToken)
+	r.Put("/{id}", h.UpdateToken)
+	r.Delete("/{id}", h.RevokeToken)
+}
+
+// PublicRoutes registers the widget routes (under /embed).
+func (h *EmbedHandler) PublicRoutes(r chi.Router) {
+	r.Get("/chat.js", h.Script)
+	r.Options("/chat", h.Preflight)
+	r.Post("/chat", h.Chat)
+}
+
+// embedTokenRequest is the body of POST and PUT /api/system/embed-tokens.
+type embedTokenRequest struct {
+	Name        string   `json:"name"`
+	Collections []string `json:"collections"`
+	Origins     []string `json:"origins"`
+	RateLimit   int      `json:"rate_limit"`
+}
+
+func (req embedTokenRequest) token() *EmbedToken {
+	return &EmbedToken{Name: req.Name, Collections: req.Collections, Origins: req.Origins, RateLimit: req.RateLimit}
+}
+
+// ListTokens handles GET /api/system/embed-tokens. Secrets are never
+// listed, only their first characters.
+func (h *EmbedHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	tokens := h.tokens.List()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tokens": tokens, "count": len(tokens)})
+}
+
+// CreateToken handles POST /api/system/embed-tokens. The response holds the
+// token, which cannot be retrieved again, and a snippet embedding the
+// widget.
+func (h *EmbedHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	var req embedTokenRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEmbedBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	tok := req.token()
+	if err := tok.validate(); err != nil {
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation, err.Error())
+		return
+	}
+	tok.CreatedBy = middleware.UsernameFromContext(r.Context())
+	tok.CreatedAt = time.Now().UTC()
+
+	secret, err := h.tokens.Create(tok)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	script := middleware.ExternalURL(r, "/embed/chat.js")
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"embed_token": tok,
+		"token":       secret,
+		"script_url":  script,
+		"snippet":     fmt.Sprintf(`<script src="%s" data-token="%s" defer></script>`, script, secret),
+	})
+}
+
+// UpdateToken handles PUT /api/system/embed-tokens/{id}: new name,
+// collections, origins and rate limit for a token, which keeps working
+// with the same secret.
+func (h *EmbedHandler) UpdateToken(w http.ResponseWriter, r *http.Request) {
+	var req embedTokenRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEmbedBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	upd := req.token()
+	if err := upd.validate(); err != nil {
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation, err.Error())
+		return
+	}
+	tok, ok, err := h.tokens.Update(chi.URLParam(r, "id"), upd)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "embed token not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, tok)
+}
+
+// RevokeToken handles DELETE /api/system/embed-tokens/{id}. Widgets using
+// the token stop working at once.
+func (h *EmbedHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	ok, err := h.tokens.Revoke(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "embed token not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"revoked": id})
+}
+
+// Script handles GET /embed/chat.js: the widget, for any page to load.
+func (h *EmbedHandler) Script(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(embedChatJS)
+}
+
+// Preflight answers the CORS preflight of POST /embed/chat. Browsers do not
+// send the token on preflights, so any origin is allowed here; Chat checks
+// the origin against the token.
+func (h *EmbedHandler) Preflight(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", "POST")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+embedTokenHeader)
+		w.Header().Set("Access-Control-Max-Age", "600")
+		w.Header().Add("Vary", "Origin")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// embedChatRequest is the body of POST /embed/chat.
+type embedChatRequest struct {
+	Message    string                `json:"message"`
+	History    []grpcclient.ChatTurn `json:"history"`
+	Collection string                `json:"collection"`
+}
+
+// embedSource is a cited file as sent to the widget: no links, since the
+// widget's visitors cannot open the gateway's previews.
+type embedSource struct {
+	Index    int      `json:"index"`
+	Title    string   `json:"title"`
+	FilePath string   `json:"file_path"`
+	Labels   []string `json:"labels,omitempty"`
+	Score    float32  `json:"score"`
+}
+
+func embedSources(citations []Citation) []embedSource {
+	out := make([]embedSource, len(citations))
+	for i, c := range citations {
+		out[i] = embedSource{Index: c.Index, Title: c.Title, FilePath: c.FilePath, Score: c.Score}
+		for _, a := range c.Anchors {
+			if a.Label != "" {
+				out[i].Labels = append(out[i].Labels, a.Label)
+			}
+		}
+	}
+	return out
+}
+
+// embedClientKey is the rate limit key of a visitor using tok.
+func embedClientKey(tok *EmbedToken, r *http.Request) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return tok.ID + "|" + ip
+}
+
+// Chat handles POST /embed/chat: one chat turn answered from the token's
+// collections, streamed as server-sent events. The body holds the message,
+// the earlier turns as history and optionally one of the token's
+// collections (default: its first). Events are {"type":"chunk","content"},
+// {"type":"sources","sources"}, {"type":"done"} and
+// {"type":"error","message"}.
+func (h *EmbedHandler) Chat(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin != "" {
+		w.Header().Add("Vary", "Origin")
+	}
+	tok, ok := h.tokens.Authorize(r.Header.Get(embedTokenHeader))
+	if !ok {
+		writeErrorCode(w, http.StatusUnauthorized, CodeUnauthenticated, "missing or invalid embed token")
+		return
+	}
+	if !tok.allowsOrigin(origin) {
+		writeErrorCode(w, http.StatusForbidden, CodeForbidden, "this embed token is not allowed from this origin")
+		return
+	}
+	if origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if ok, wait := h.limiter.allow(embedClientKey(tok, r), tok.RateLimit, time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+		writeErrorCode(w, http.StatusTooManyRequests, CodeRateLimited, "too many requests; try again shortly")
+		return
+	}
+
+	var req embedChatRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEmbedBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	switch {
+	case req.Message == "":
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation, "message is required")
+		return
+	case len([]rune(req.Message)) > maxEmbedMessage:
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation, fmt.Sprintf("message must be at most %d characters", maxEmbedMessage))
+		return
+	}
+	for i, t := range req.History {
+		if t.Role != "user" && t.Role != "assistant" {
+			writeErrorCode(w, http.StatusBadRequest, CodeValidation, fmt.Sprintf("history[%d].role must be \"user\" or \"assistant\"", i))
+			return
+		}
+	}
+	if len(req.History) > maxEmbedHistory {
+		req.History = req.History[len(req.History)-maxEmbedHistory:]
+	}
+	collection := tok.Collections[0]
+	if req.Collection != "" {
+		if !containsString(tok.Collections, req.Collection) {
+			writeErrorCode(w, http.StatusForbidden, CodeForbidden, "this embed token cannot use collection "+req.Collection)
+			return
+		}
+		collection = req.Collection
+	}
+
+	if h.grpc.Chat == nil {
+		writeError(w, http.StatusServiceUnavailable, "chat service not available")
+		return
+	}
+	if svc, ok := unsupportedService(h.grpc, grpcclient.ServiceChat); !ok {
+		writeErrorCode(w, http.StatusNotImplemented, CodeWorkerUnsupported, unsupportedMessage(h.grpc, svc))
+		return
+	}
+	if l, locked := h.tm.CollectionLock(collection); locked && l.Mode == tasks.LockBlock {
+		writeError(w, http.StatusConflict, "the knowledge base is being updated; try again shortly")
+		return
+	}
+
+	ctx := r.Context()
+	chatReq := &grpcclient.ChatRequest{Message: req.Message, Collection: collection}
+	h.prefs.Defaults("").Apply(chatReq)
+	if len(req.History) > 0 && h.grpc.Worker().HasFeature(grpcclient.FeatureChatHistory) {
+		grpcclient.SetChatHistory(chatReq, req.History)
+	}
+	stream, err := h.grpc.Chat.Chat(ctx, chatReq)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "failed to start chat: "+err.Error())
+		return
+	}
+	defer stream.Close()
+
+	defer activeStreams.track("embed_chat_sse")()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	write := func(v map[string]interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			log.Printf("ERROR: encoding embed chat event: %v", err)
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	chunks := newChunkCoalescer(h.flush, func(s string) bool {
+		write(map[string]interface{}{"type": "chunk", "content": s})
+		return ctx.Err() == nil
+	})
+	defer chunks.close()
+	send := func(v map[string]interface{}) { chunks.do(func() { write(v) }) }
+
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				send(map[string]interface{}{"type": "error", "message": "the answer was interrupted"})
+			}
+			return
+		}
+		switch event.Type {
+		case "chunk":
+			chunks.add(event.Content)
+		case "sources":
+			send(map[string]interface{}{"type": "sources", "sources": embedSources(h.cite.Enrich(ctx, collection, event.Sources))})
+		case "error":
+			log.Printf("embed chat (token %s): %s", tok.Name, event.Content)
+			send(map[string]interface{}{"type": "error", "message": "the answer failed"})
+			return
+		case "cancelled":
+			return
+		}
+	}
+	send(map[string]interface{}{"type": "done"})
+}
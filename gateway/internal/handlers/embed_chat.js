@@ -0,0 +1,192 @@
+/*
+ * Ollqd chat widget.
+ *
+ * Embed with:
+ *   <script src="https://ollqd.example.com/embed/chat.js" data-token="oqe_..." defer></script>
+ *
+ * Optional attributes: data-collection (one of the token's collections),
+ * data-title, data-placeholder, data-greeting, data-position ("right" or
+ * "left") and data-color (the accent colour). The widget lives in a shadow
+ * root so the host page's styles do not reach it.
+ */
+(function () {
+  'use strict';
+
+  var script = document.currentScript;
+  if (!script || !script.src) return;
+  var attr = function (name, fallback) { return script.getAttribute('data-' + name) || fallback; };
+
+  var token = attr('token', '');
+  if (!token) {
+    console.warn('ollqd chat widget: data-token is missing');
+    return;
+  }
+  var endpoint = script.src.replace(/\/embed\/chat\.js(\?.*)?$/, '/embed/chat');
+  var collection = attr('collection', '');
+  var title = attr('title', 'Ask the docs');
+  var placeholder = attr('placeholder', 'Ask a question...');
+  var greeting = attr('greeting', '');
+  var side = attr('position', 'right') === 'left' ? 'left' : 'right';
+  var color = attr('color', '#2563eb');
+
+  var history = [];
+  var busy = false;
+
+  var host = document.createElement('div');
+  host.setAttribute('data-ollqd-chat', '');
+  var root = host.attachShadow({ mode: 'open' });
+  root.innerHTML =
+    '<style>' +
+    ':host{all:initial}' +
+    '*{box-sizing:border-box;font-family:system-ui,-apple-system,"Segoe UI",sans-serif}' +
+    '.btn{position:fixed;bottom:20px;' + side + ':20px;width:52px;height:52px;border-radius:50%;border:0;' +
+    'background:var(--c);color:#fff;font-size:24px;cursor:pointer;box-shadow:0 4px 14px rgba(0,0,0,.25);z-index:2147483646}' +
+    '.panel{position:fixed;bottom:84px;' + side + ':20px;width:360px;max-width:calc(100vw - 40px);height:520px;' +
+    'max-height:calc(100vh - 110px);display:none;flex-direction:column;background:#fff;color:#111;border-radius:12px;' +
+    'box-shadow:0 8px 30px rgba(0,0,0,.25);overflow:hidden;z-index:2147483647;font-size:14px}' +
+    '.panel.open{display:flex}' +
+    '.head{background:var(--c);color:#fff;padding:12px 14px;font-weight:600;display:flex;justify-content:space-between}' +
+    '.head button{background:none;border:0;color:#fff;font-size:18px;cursor:pointer;line-height:1}' +
+    '.log{flex:1;overflow-y:auto;padding:12px;display:flex;flex-direction:column;gap:8px}' +
+    '.msg{padding:8px 11px;border-radius:10px;max-width:85%;white-space:pre-wrap;word-wrap:break-word;line-height:1.4}' +
+    '.user{align-self:flex-end;background:var(--c);color:#fff}' +
+    '.bot{align-self:flex-start;background:#f1f3f5}' +
+    '.err{align-self:flex-start;background:#fdecea;color:#8a1c12}' +
+    '.src{margin-top:6px;font-size:12px;color:#555}' +
+    '.src li{margin:2px 0}' +
+    'form{display:flex;border-top:1px solid #e5e7eb}' +
+    'input{flex:1;border:0;padding:12px;font-size:14px;outline:none;background:#fff;color:#111}' +
+    'form button{border:0;background:none;color:var(--c);font-weight:600;padding:0 14px;cursor:pointer}' +
+    'form button:disabled{opacity:.5;cursor:default}' +
+    '</style>' +
+    '<button class="btn" type="button" aria-label="Open chat">&#128172;</button>' +
+    '<div class="panel" role="dialog">' +
+    '<div class="head"><span class="title"></span><button type="button" aria-label="Close">&times;</button></div>' +
+    '<div class="log" aria-live="polite"></div>' +
+    '<form><input type="text" maxlength="4000" autocomplete="off"><button type="submit">Send</button></form>' +
+    '</div>';
+  host.style.setProperty('--c', color);
+
+  var panel = root.querySelector('.panel');
+  var log = root.querySelector('.log');
+  var form = root.querySelector('form');
+  var input = root.querySelector('input');
+  var send = root.querySelector('form button');
+  root.querySelector('.title').textContent = title;
+  input.placeholder = placeholder;
+
+  var toggle = function (open) {
+    panel.classList.toggle('open', open);
+    if (open) input.focus();
+  };
+  root.querySelector('.btn').addEventListener('click', function () { toggle(!panel.classList.contains('open')); });
+  root.querySelector('.head button').addEventListener('click', function () { toggle(false); });
+
+  var bubble = function (cls, text) {
+    var el = document.createElement('div');
+    el.className = 'msg ' + cls;
+    el.textContent = text;
+    log.appendChild(el);
+    log.scrollTop = log.scrollHeight;
+    return el;
+  };
+  if (greeting) bubble('bot', greeting);
+
+  var showSources = function (el, sources) {
+    if (!sources || !sources.length) return;
+    var list = document.createElement('ol');
+    list.className = 'src';
+    sources.forEach(function (s) {
+      var li = document.createElement('li');
+      li.textContent = (s.title || s.file_path) + (s.labels && s.labels.length ? ' (' + s.labels.join(', ') + ')' : '');
+      list.appendChild(li);
+    });
+    el.appendChild(list);
+  };
+
+  var errorMessage = function (resp) {
+    if (resp.status === 429) {
+      var wait = resp.headers.get('Retry-After');
+      return 'Too many questions at once.' + (wait ? ' Try again in ' + wait + 's.' : '');
+    }
+    return resp.json().then(function (body) {
+      return (body && body.detail) || 'The chat is not available right now.';
+    }, function () { return 'The chat is not available right now.'; });
+  };
+
+  var ask = function (message) {
+    busy = true;
+    send.disabled = true;
+    bubble('user', message);
+    var answerEl = bubble('bot', '');
+    var answer = '';
+    var sources = null;
+    var failed = false;
+
+    var body = { message: message, history: history.slice(-20) };
+    if (collection) body.collection = collection;
+
+    fetch(endpoint, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', 'X-Ollqd-Embed-Token': token },
+      body: JSON.stringify(body),
+    }).then(function (resp) {
+      if (!resp.ok) {
+        return Promise.resolve(errorMessage(resp)).then(function (msg) { throw new Error(msg); });
+      }
+      var reader = resp.body.getReader();
+      var decoder = new TextDecoder();
+      var buf = '';
+      var handle = function (line) {
+        if (line.indexOf('data: ') !== 0) return;
+        var ev;
+        try { ev = JSON.parse(line.slice(6)); } catch (e) { return; }
+        if (ev.type === 'chunk') {
+          answer += ev.content;
+          answerEl.textContent = answer;
+          log.scrollTop = log.scrollHeight;
+        } else if (ev.type === 'sources') {
+          sources = ev.sources;
+        } else if (ev.type === 'error') {
+          failed = true;
+          answerEl.className = 'msg err';
+          answerEl.textContent = ev.message || 'The answer failed.';
+        }
+      };
+      var pump = function () {
+        return reader.read().then(function (r) {
+          if (r.done) return;
+          buf += decoder.decode(r.value, { stream: true });
+          var parts = buf.split('\n\n');
+          buf = parts.pop();
+          parts.forEach(handle);
+          return pump();
+        });
+      };
+      return pump();
+    }).then(function () {
+      if (failed) return;
+      showSources(answerEl, sources);
+      history.push({ role: 'user', content: message }, { role: 'assistant', content: answer });
+    }).catch(function (err) {
+      answerEl.className = 'msg err';
+      answerEl.textContent = err.message || 'The chat is not available right now.';
+    }).then(function () {
+      busy = false;
+      send.disabled = false;
+      log.scrollTop = log.scrollHeight;
+    });
+  };
+
+  form.addEventListener('submit', function (e) {
+    e.preventDefault();
+    var message = input.value.trim();
+    if (!message || busy) return;
+    input.value = '';
+    ask(message);
+  });
+
+  var mount = function () { document.body.appendChild(host); };
+  if (document.body) mount();
+  else document.addEventListener('DOMContentLoaded', mount);
+})();
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/alfagnish/ollqd-gateway/internal/i18n"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Machine-readable error codes returned in the "code" field of error
+// responses. "detail" stays a human-readable message.
+const (
+	CodeValidation         = "VALIDATION_ERROR"
+	CodeUnauthenticated    = "UNAUTHENTICATED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeNotFound           = "NOT_FOUND"
+	CodeCollectionNotFound = "COLLECTION_NOT_FOUND"
+	CodeCollectionEmpty    = "COLLECTION_EMPTY"
+	CodeConflict           = "CONFLICT"
+	CodeLocked             = "LOCKED"
+	CodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeNotImplemented     = "NOT_IMPLEMENTED"
+	CodeUpstream           = "UPSTREAM_ERROR"
+	CodeWorkerUnavailable  = "WORKER_UNAVAILABLE"
+	CodeWorkerTimeout      = "WORKER_TIMEOUT"
+	CodeWorkerError        = "WORKER_ERROR"
+	CodeWorkerUnsupported  = "WORKER_UNSUPPORTED"
+	CodeCancelled          = "CANCELLED"
+	// CodeInsufficientResources is returned when GUARD_MODE=refuse stops
+	// a task because disk space or memory is short.
+	CodeInsufficientResources = "INSUFFICIENT_RESOURCES"
+)
+
+// apiError is the JSON body of every error response; see api.Error.
+type apiError = api.Error
+
+// codeForStatus is the default error code for an HTTP status. Handlers pass
+// an explicit code via writeErrorCode where a more specific one applies.
+func codeForStatus(s int) string {
+	switch s {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeValidation
+	case http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return CodeConflict
+	case http.StatusLocked:
+		return CodeLocked
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusBadGateway:
+		return CodeUpstream
+	case http.StatusServiceUnavailable:
+		// Nil-service checks are the main source of 503s: the worker stub
+		// was never connected.
+		return CodeWorkerUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeWorkerTimeout
+	default:
+		if s >= 500 {
+			return CodeInternal
+		}
+		return CodeValidation
+	}
+}
+
+// grpcToHTTP maps a worker gRPC status code to an HTTP status and error code.
+func grpcToHTTP(c codes.Code) (int, string) {
+	switch c {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest, CodeValidation
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized, CodeUnauthenticated
+	case codes.PermissionDenied:
+		return http.StatusForbidden, CodeForbidden
+	case codes.NotFound:
+		return http.StatusNotFound, CodeNotFound
+	case codes.AlreadyExists, codes.Aborted, codes.FailedPrecondition:
+		return http.StatusConflict, CodeConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests, CodeRateLimited
+	case codes.Unimplemented:
+		return http.StatusNotImplemented, CodeNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable, CodeWorkerUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout, CodeWorkerTimeout
+	case codes.Canceled:
+		return http.StatusRequestTimeout, CodeCancelled
+	default:
+		return http.StatusBadGateway, CodeWorkerError
+	}
+}
+
+// grpcCodeName returns the canonical name of c, e.g. "INVALID_ARGUMENT".
+func grpcCodeName(c codes.Code) string {
+	if c == codes.Canceled {
+		return "CANCELLED"
+	}
+	name := c.String()
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(rune(name[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// writeGRPCError reports a failed worker call, translating its gRPC status
+// to the matching HTTP status. The body carries the worker's message and,
+// as grpc_code, its status code. Deadline expiry (from the request's
+// X-Timeout-Seconds budget or the gateway default) maps to 504.
+func writeGRPCError(w http.ResponseWriter, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		writeWorkerError(w, codes.DeadlineExceeded, CodeWorkerTimeout, "worker deadline exceeded")
+		return
+	}
+
+	st, _ := status.FromError(err)
+	_, code := grpcToHTTP(st.Code())
+	switch st.Code() {
+	case codes.DeadlineExceeded:
+		writeWorkerError(w, st.Code(), code, "worker deadline exceeded")
+	case codes.NotFound:
+		if strings.Contains(strings.ToLower(st.Message()), "collection") {
+			code = CodeCollectionNotFound
+		}
+		writeWorkerError(w, st.Code(), code, st.Message())
+	case codes.Unknown, codes.Internal, codes.DataLoss:
+		writeWorkerError(w, st.Code(), code, fmt.Sprintf("grpc error: %v", st.Message()))
+	default:
+		writeWorkerError(w, st.Code(), code, st.Message())
+	}
+}
+
+// writeWorkerError writes an error response for a worker call that failed
+// with the gRPC status code c.
+func writeWorkerError(w http.ResponseWriter, c codes.Code, code, detail string) {
+	httpStatus, _ := grpcToHTTP(c)
+	msg := i18n.Localize(i18n.ResponseLang(w.Header()), detail)
+	writeJSON(w, httpStatus, apiError{
+		Detail:      msg.Text,
+		Code:        code,
+		MessageKey:  msg.Key,
+		MessageArgs: msg.Args,
+		GRPCCode:    grpcCodeName(c),
+	})
+}
+
+// copyQdrantResponse relays a Qdrant response for a collection-scoped
+// request. A 404 becomes a COLLECTION_NOT_FOUND error; anything else is
+// passed through unchanged.
+func copyQdrantResponse(w http.ResponseWriter, resp *http.Response, collection string) {
+	if resp.StatusCode == http.StatusNotFound {
+		writeErrorCode(w, http.StatusNotFound, CodeCollectionNotFound,
+			fmt.Sprintf("collection %s not found", collection))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// Event types sent on /api/system/events.
+const (
+	EventConfigUpdated     = "config-updated"
+	EventModelChanged      = "model-changed"
+	EventCollectionCreated = "collection-created"
+)
+
+const (
+	// eventReplay is the number of recent events kept for clients that
+	// reconnect with Last-Event-ID.
+	eventReplay = 100
+	// eventSubBuffer is the number of events queued per client before
+	// further events are dropped for it.
+	eventSubBuffer = 32
+	// eventKeepalive is how often an idle stream gets a comment, so proxies
+	// do not close it.
+	eventKeepalive = 25 * time.Second
+)
+
+// Event is a change made through the gateway that open UIs should pick up.
+type Event struct {
+	ID   uint64            `json:"id"`
+	Type string            `json:"type"`
+	At   time.Time         `json:"at"`
+	By   string            `json:"by,omitempty"`
+	Data map[string]string `json:"data,omitempty"`
+	// Node is the gateway replica an event was relayed from, in cluster
+	// mode.
+	Node string `json:"node,omitempty"`
+}
+
+// EventBus broadcasts Events to every client of /api/system/events.
+// Events are published by handlers after successful mutations and, for the
+// configuration routes, by Middleware.
+type EventBus struct {
+	mu     sync.Mutex
+	seq    uint64
+	recent []Event
+	subs   map[chan Event]struct{}
+
+	// relay forwards events published here to the other gateway replicas
+	// (see Bridge); nil when running alone.
+	relay chan<- Event
+}
+
+// NewEventBus creates an EventBus with no clients.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[chan Event]struct{})}
+}
+
+// Publish sends an event to every client, dropping it for clients that fall
+// behind. by is the user who made the change.
+func (b *EventBus) Publish(typ, by string, data map[string]string) {
+	if b == nil {
+		return
+	}
+	b.broadcast(Event{Type: typ, At: time.Now().UTC(), By: by, Data: data}, true)
+}
+
+// broadcast numbers ev and sends it to every client. Events published on
+// this replica are relayed to the others if local is set.
+func (b *EventBus) broadcast(ev Event, local bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	ev.ID = b.seq
+	if local && b.relay != nil {
+		select {
+		case b.relay <- ev:
+		default:
+		}
+	}
+	b.recent = append(b.recent, ev)
+	if len(b.recent) > eventReplay {
+		b.recent = b.recent[len(b.recent)-eventReplay:]
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// subscribe registers a client and returns the recent events after the
+// given ID; an ID from before a gateway restart replays nothing.
+func (b *EventBus) subscribe(after uint64) (<-chan Event, []Event, func()) {
+	ch := make(chan Event, eventSubBuffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	var missed []Event
+	if after > 0 && after <= b.seq {
+		for _, ev := range b.recent {
+			if ev.ID > after {
+				missed = append(missed, ev)
+			}
+		}
+	}
+	b.mu.Unlock()
+	return ch, missed, func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+}
+
+// configEventRoutes maps route prefixes whose successful mutations publish
+// config-updated to the section named in the event.
+var configEventRoutes = []struct{ prefix, section string }{
+	{"/api/system/config/", ""}, // section from the path
+	{"/api/system/branding", "branding"},
+	{"/api/system/notifications", "notifications"},
+	{"/api/qdrant/collection-templates", "collection-templates"},
+	{"/api/qdrant/default-collection", "default-collection"},
+	{"/api/ollama/instances", "ollama-instances"},
+}
+
+// configEvent returns the event a successful request matching the route
+// pattern publishes. Tests and dry runs change nothing and publish none.
+func configEvent(r *http.Request, pattern string) (string, map[string]string, bool) {
+	if strings.HasSuffix(pattern, "/test") || strings.HasSuffix(pattern, "/compare") ||
+		r.URL.Query().Get("dry_run") == "true" {
+		return "", nil, false
+	}
+	for _, route := range configEventRoutes {
+		if !strings.HasPrefix(pattern, route.prefix) {
+			continue
+		}
+		section := route.section
+		if section == "" {
+			section, _, _ = strings.Cut(strings.TrimPrefix(pattern, route.prefix), "/")
+			if section == "{section}" {
+				section = chi.URLParam(r, "section")
+			}
+		}
+		if section == "embedding" {
+			return EventModelChanged, map[string]string{"kind": "embed", "path": r.URL.Path}, true
+		}
+		return EventConfigUpdated, map[string]string{"section": section, "path": r.URL.Path}, true
+	}
+	return "", nil, false
+}
+
+// Middleware publishes config-updated (model-changed for the embedding
+// model) after every successful change to the gateway or worker
+// configuration, so handlers of those routes need no hooks of their own.
+func (b *EventBus) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		rctx := chi.RouteContext(r.Context())
+		if status < 200 || status > 299 || rctx == nil {
+			return
+		}
+		if typ, data, ok := configEvent(r, rctx.RoutePattern()); ok {
+			b.Publish(typ, middleware.UsernameFromContext(r.Context()), data)
+		}
+	})
+}
+
+// Stream sends events as SSE until the client goes away. Each event has
+// its type as the SSE event name and its ID as the SSE id, so browsers
+// that reconnect send Last-Event-ID and are replayed what they missed.
+func (b *EventBus) Stream(w http.ResponseWriter, r *http.Request) {
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	after, _ := strconv.ParseUint(lastID, 10, 64)
+	events, missed, unsubscribe := b.subscribe(after)
+	defer unsubscribe()
+	defer activeStreams.track("system_events_sse")()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	send := func(ev Event) {
+		data, _ := json.Marshal(ev)
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+		flush()
+	}
+
+	fmt.Fprint(w, ": connected\n\n")
+	flush()
+	for _, ev := range missed {
+		send(ev)
+	}
+
+	keepalive := time.NewTicker(eventKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case ev := <-events:
+			send(ev)
+		case <-keepalive.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
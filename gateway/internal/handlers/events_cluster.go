@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/redis"
+)
+
+// eventRelayBuffer is the number of events queued for the other replicas
+// before further events are dropped for them.
+const eventRelayBuffer = 64
+
+// relayedEvent is an event on the Redis channel shared by the replicas.
+type relayedEvent struct {
+	Node  string `json:"node"`
+	Event Event  `json:"event"`
+}
+
+// Bridge relays the events published on this replica to the other gateway
+// replicas through the Redis channel, and theirs to the clients of this
+// one, until ctx is done. Relayed events are numbered by each replica, so
+// Last-Event-ID only replays events when a client reconnects to the same
+// replica.
+func (b *EventBus) Bridge(ctx context.Context, client *redis.Client, channel, node string) {
+	out := make(chan Event, eventRelayBuffer)
+	b.mu.Lock()
+	b.relay = out
+	b.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev := <-out:
+				ev.ID = 0
+				data, _ := json.Marshal(relayedEvent{Node: node, Event: ev})
+				pctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				if _, err := client.Do(pctx, "PUBLISH", channel, string(data)); err != nil {
+					log.Printf("WARNING: relaying %s event: %v", ev.Type, err)
+				}
+				cancel()
+			}
+		}
+	}()
+
+	go func() {
+		for ctx.Err() == nil {
+			subCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			sub, err := client.Subscribe(subCtx, channel)
+			cancel()
+			if err == nil {
+				stop := context.AfterFunc(ctx, func() { sub.Close() })
+				for {
+					msg, rerr := sub.Receive()
+					if rerr != nil {
+						err = rerr
+						break
+					}
+					var re relayedEvent
+					if json.Unmarshal([]byte(msg.Payload), &re) != nil || re.Node == node {
+						continue
+					}
+					re.Event.Node = re.Node
+					b.broadcast(re.Event, false)
+				}
+				stop()
+				sub.Close()
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("WARNING: event channel: %v; reconnecting", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(2 * time.Second):
+			}
+		}
+	}()
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/config"
+)
+
+const (
+	// indexSizeFactor estimates the Qdrant storage an upload needs from its
+	// size: vectors plus the chunk text kept in the payload.
+	indexSizeFactor = 2
+	// qdrantTelemetryTTL is how long a Qdrant memory reading is reused.
+	qdrantTelemetryTTL = 30 * time.Second
+	// qdrantTelemetryTimeout bounds the telemetry request.
+	qdrantTelemetryTimeout = 3 * time.Second
+	// hostMeminfo is where host memory is read from.
+	hostMeminfo = "/proc/meminfo"
+)
+
+// GuardrailOptions configures the resource checks made before index and
+// upload tasks start.
+type GuardrailOptions struct {
+	// Mode is config.GuardRefuse, config.GuardWarn or config.GuardOff.
+	Mode string
+	// MinFreeDiskMB is the free space, in MB, that must remain in UploadDir
+	// and QdrantDir after the task's estimated writes.
+	MinFreeDiskMB int64
+	// MaxMemoryPercent is the memory use, in percent, above which tasks are
+	// refused (0 = unchecked).
+	MaxMemoryPercent int64
+	UploadDir        string
+	// QdrantDir is Qdrant's storage directory as mounted in the gateway
+	// ("" = unchecked).
+	QdrantDir string
+	QdrantURL string
+	// QdrantClient is used for Qdrant's telemetry API.
+	QdrantClient *http.Client
+}
+
+// Guardrails checks disk space and memory before index and upload tasks
+// start, so they are refused up front instead of failing halfway through.
+// Disk is checked in UPLOAD_DIR and, when mounted, Qdrant's storage; memory
+// on the host (which the worker shares when it runs on the same machine or
+// Docker engine) and in Qdrant, from its telemetry.
+type Guardrails struct {
+	opts GuardrailOptions
+
+	mu          sync.Mutex
+	qdrantAt    time.Time
+	qdrantUsed  float64
+	qdrantKnown bool
+}
+
+// NewGuardrails creates Guardrails with the given options.
+func NewGuardrails(opts GuardrailOptions) *Guardrails {
+	return &Guardrails{opts: opts}
+}
+
+// guardProblem is one failed check and the HTTP status it is refused with.
+type guardProblem struct {
+	status int
+	msg    string
+}
+
+// check runs every check for a task expected to write incoming bytes to
+// UPLOAD_DIR (0 when unknown) and returns the failed ones.
+func (g *Guardrails) check(ctx context.Context, incoming int64) []guardProblem {
+	if g == nil || g.opts.Mode == config.GuardOff {
+		return nil
+	}
+	var problems []guardProblem
+	minFree := uint64(g.opts.MinFreeDiskMB) << 20
+	if p, ok := checkDisk("UPLOAD_DIR", g.opts.UploadDir, minFree, uint64(incoming)); !ok {
+		problems = append(problems, p)
+	}
+	if g.opts.QdrantDir != "" {
+		if p, ok := checkDisk("Qdrant storage", g.opts.QdrantDir, minFree, uint64(incoming)*indexSizeFactor); !ok {
+			problems = append(problems, p)
+		}
+	}
+	if limit := float64(g.opts.MaxMemoryPercent); limit > 0 {
+		if used, ok := hostMemoryUsed(); ok && used > limit {
+			problems = append(problems, guardProblem{http.StatusServiceUnavailable,
+				fmt.Sprintf("host memory is %.0f%% used (limit %.0f%%)", used, limit)})
+		}
+		if used, ok := g.qdrantMemoryUsed(ctx); ok && used > limit {
+			problems = append(problems, guardProblem{http.StatusServiceUnavailable,
+				fmt.Sprintf("qdrant memory is %.0f%% used (limit %.0f%%)", used, limit)})
+		}
+	}
+	return problems
+}
+
+// admit runs the checks for a task expected to write incoming bytes. In
+// refuse mode a failed check writes a 507 (disk) or 503 (memory) and
+// returns false; in warn mode the problems are logged and returned as
+// warnings for the task.
+func (g *Guardrails) admit(ctx context.Context, w http.ResponseWriter, incoming int64) ([]string, bool) {
+	problems := g.check(ctx, incoming)
+	msgs, ok := g.review(problems)
+	if !ok {
+		writeErrorCode(w, problems[0].status, CodeInsufficientResources, strings.Join(msgs, "; "))
+		return nil, false
+	}
+	return msgs, true
+}
+
+// Admit is admit for tasks started outside HTTP, such as over gRPC. In
+// refuse mode a failed check returns an error naming the problems.
+func (g *Guardrails) Admit(ctx context.Context, incoming int64) ([]string, error) {
+	msgs, ok := g.review(g.check(ctx, incoming))
+	if !ok {
+		return nil, errors.New(strings.Join(msgs, "; "))
+	}
+	return msgs, nil
+}
+
+// review returns the messages of the failed checks and whether the task
+// may start anyway, which it may in warn mode; the problems are then logged.
+func (g *Guardrails) review(problems []guardProblem) ([]string, bool) {
+	if len(problems) == 0 {
+		return nil, true
+	}
+	msgs := make([]string, len(problems))
+	for i, p := range problems {
+		msgs[i] = p.msg
+	}
+	if g.opts.Mode == config.GuardWarn {
+		log.Printf("WARNING: guardrails: starting task despite: %s", strings.Join(msgs, "; "))
+		return msgs, true
+	}
+	return msgs, false
+}
+
+// checkDisk reports whether dir has minFree bytes left after writing need
+// more. A directory that cannot be read passes; the task will report it.
+func checkDisk(label, dir string, minFree, need uint64) (guardProblem, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return guardProblem{}, true
+	}
+	free := uint64(st.Bavail) * uint64(st.Bsize)
+	if free >= minFree+need {
+		return guardProblem{}, true
+	}
+	msg := fmt.Sprintf("insufficient disk in %s: %d MB free, %d MB required", label, free>>20, minFree>>20)
+	if need > 0 {
+		msg = fmt.Sprintf("insufficient disk in %s for estimated index size: %d MB free, %d MB needed plus %d MB reserve",
+			label, free>>20, (need+1<<20-1)>>20, minFree>>20)
+	}
+	return guardProblem{http.StatusInsufficientStorage, msg}, false
+}
+
+// hostMemoryUsed returns the percentage of host memory in use, from
+// MemTotal and MemAvailable.
+func hostMemoryUsed() (float64, bool) {
+	f, err := os.Open(hostMeminfo)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	var total, avail float64
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = v
+		case "MemAvailable:":
+			avail = v
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return 100 * (total - avail) / total, true
+}
+
+// qdrantMemoryUsed returns the percentage of its machine's memory Qdrant's
+// process holds, from its telemetry. Readings are reused for
+// qdrantTelemetryTTL; Qdrant versions that do not report both figures are
+// not checked.
+func (g *Guardrails) qdrantMemoryUsed(ctx context.Context) (float64, bool) {
+	if g.opts.QdrantURL == "" || g.opts.QdrantClient == nil {
+		return 0, false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if time.Since(g.qdrantAt) < qdrantTelemetryTTL {
+		return g.qdrantUsed, g.qdrantKnown
+	}
+	g.qdrantAt = time.Now()
+	g.qdrantUsed, g.qdrantKnown = 0, false
+
+	ctx, cancel := context.WithTimeout(ctx, qdrantTelemetryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", g.opts.QdrantURL+"/telemetry", nil)
+	if err != nil {
+		return 0, false
+	}
+	resp, err := g.opts.QdrantClient.Do(req)
+	if err != nil {
+		return 0, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, false
+	}
+	var out struct {
+		Result struct {
+			App struct {
+				System struct {
+					RAMSize float64 `json:"ram_size"` // KiB
+				} `json:"system"`
+			} `json:"app"`
+			Memory struct {
+				ResidentBytes float64 `json:"resident_bytes"`
+			} `json:"memory"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, false
+	}
+	ram, resident := out.Result.App.System.RAMSize*1024, out.Result.Memory.ResidentBytes
+	if ram == 0 || resident == 0 {
+		return 0, false
+	}
+	g.qdrantUsed, g.qdrantKnown = 100*resident/ram, true
+	return g.qdrantUsed, true
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/alfagnish/ollqd-gateway/internal/i18n"
 )
 
 // writeJSON serialises v as JSON and writes it to the response with the
@@ -14,7 +16,15 @@ func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 }
 
 // writeError writes a standard JSON error response of the form
-// {"detail": "message"}.
+// {"detail": "message", "code": "NOT_FOUND"}, deriving the code from the
+// HTTP status.
 func writeError(w http.ResponseWriter, status int, detail string) {
-	writeJSON(w, status, map[string]string{"detail": detail})
+	writeErrorCode(w, status, codeForStatus(status), detail)
+}
+
+// writeErrorCode writes an error response with an explicit error code. The
+// detail is translated into the language i18n.Middleware negotiated.
+func writeErrorCode(w http.ResponseWriter, status int, code, detail string) {
+	msg := i18n.Localize(i18n.ResponseLang(w.Header()), detail)
+	writeJSON(w, status, apiError{Detail: msg.Text, Code: code, MessageKey: msg.Key, MessageArgs: msg.Args})
 }
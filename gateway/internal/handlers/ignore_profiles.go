@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/ignore"
+	"github.com/alfagnish/ollqd-gateway/internal/manifest"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// ignoreProfilesDoc is the store document holding ignore profiles.
+const ignoreProfilesDoc = "ignore-profiles"
+
+// maxIgnorePatterns bounds the patterns of one profile.
+const maxIgnorePatterns = 500
+
+var ignoreProfileName = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// IgnoreProfile is a named list of skip entries merged into codebase index
+// requests. Entries are directory names or gitignore patterns (see package
+// ignore). A profile without collections applies to every collection.
+type IgnoreProfile struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Patterns    []string  `json:"patterns"`
+	Collections []string  `json:"collections,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Global reports whether p applies to every collection.
+func (p *IgnoreProfile) Global() bool { return len(p.Collections) == 0 }
+
+// IgnoreProfiles holds the ignore profiles, persisted in the gateway store.
+// The indexing entry points read it through Merge.
+type IgnoreProfiles struct {
+	mu    sync.RWMutex
+	store *store.Store
+	data  struct {
+		Profiles map[string]IgnoreProfile `json:"profiles"`
+	}
+}
+
+// NewIgnoreProfiles loads ignore profiles from st.
+func NewIgnoreProfiles(st *store.Store) *IgnoreProfiles {
+	p := &IgnoreProfiles{store: st}
+	if _, err := st.Load(ignoreProfilesDoc, &p.data); err != nil {
+		log.Printf("WARNING: ignore profiles: %v", err)
+	}
+	if p.data.Profiles == nil {
+		p.data.Profiles = make(map[string]IgnoreProfile)
+	}
+	return p
+}
+
+// List returns all profiles sorted by name.
+func (p *IgnoreProfiles) List() []IgnoreProfile {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	out := make([]IgnoreProfile, 0, len(p.data.Profiles))
+	for _, prof := range p.data.Profiles {
+		out = append(out, prof)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Get returns a profile by name.
+func (p *IgnoreProfiles) Get(name string) (IgnoreProfile, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	prof, ok := p.data.Profiles[name]
+	return prof, ok
+}
+
+// Put validates and stores a profile, replacing any existing one with the
+// same name.
+func (p *IgnoreProfiles) Put(prof IgnoreProfile) (IgnoreProfile, error) {
+	if err := validateIgnoreProfile(&prof); err != nil {
+		return prof, err
+	}
+	prof.UpdatedAt = time.Now().UTC()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.data.Profiles[prof.Name] = prof
+	return prof, p.saveLocked()
+}
+
+// Delete removes a profile, reporting whether it existed.
+func (p *IgnoreProfiles) Delete(name string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.data.Profiles[name]; !ok {
+		return false, nil
+	}
+	delete(p.data.Profiles, name)
+	return true, p.saveLocked()
+}
+
+// Merge returns the skip entries for an index run into collection: global
+// profiles, then the collection's own profiles, each by name, then extra
+// from the request. Later entries win, so a request can re-include with
+// "!pattern" what a profile skips. An empty collection means the worker's
+// default codebase collection. It also returns the profiles applied.
+func (p *IgnoreProfiles) Merge(collection string, extra []string) ([]string, []string) {
+	if collection == "" {
+		collection = workerDefaultCodebaseCollection
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var global, scoped []IgnoreProfile
+	for _, prof := range p.data.Profiles {
+		switch {
+		case prof.Global():
+			global = append(global, prof)
+		case containsString(prof.Collections, collection):
+			scoped = append(scoped, prof)
+		}
+	}
+	byName := func(s []IgnoreProfile) {
+		sort.Slice(s, func(i, j int) bool { return s[i].Name < s[j].Name })
+	}
+	byName(global)
+	byName(scoped)
+
+	merged := []string{}
+	applied := []string{}
+	for _, prof := range append(global, scoped...) {
+		merged = append(merged, prof.Patterns...)
+		applied = append(applied, prof.Name)
+	}
+	return append(merged, extra...), applied
+}
+
+func (p *IgnoreProfiles) saveLocked() error {
+	return p.store.Save(ignoreProfilesDoc, &p.data)
+}
+
+// validateIgnoreProfile checks prof and normalises its lists.
+func validateIgnoreProfile(prof *IgnoreProfile) error {
+	if !ignoreProfileName.MatchString(prof.Name) {
+		return fmt.Errorf("name must be 1-64 letters, digits, '.', '_' or '-'")
+	}
+	if len(prof.Patterns) == 0 {
+		return fmt.Errorf("patterns must not be empty")
+	}
+	if len(prof.Patterns) > maxIgnorePatterns {
+		return fmt.Errorf("at most %d patterns per profile", maxIgnorePatterns)
+	}
+	for i, pat := range prof.Patterns {
+		pat = strings.TrimSpace(pat)
+		prof.Patterns[i] = pat
+		if err := validateSkipEntry(pat); err != nil {
+			return fmt.Errorf("patterns[%d]: %v", i, err)
+		}
+	}
+	collections := prof.Collections[:0]
+	for _, c := range prof.Collections {
+		if c = strings.TrimSpace(c); c != "" && !containsString(collections, c) {
+			collections = append(collections, c)
+		}
+	}
+	prof.Collections = collections
+	return nil
+}
+
+// validateSkipEntry checks one extra_skip_dirs entry.
+func validateSkipEntry(entry string) error {
+	if strings.TrimSpace(entry) == "" {
+		return fmt.Errorf("empty entry")
+	}
+	if !ignore.IsPattern(entry) {
+		return nil
+	}
+	_, err := ignore.Compile(entry)
+	return err
+}
+
+// validateSkipEntries checks the extra_skip_dirs of an index request.
+func validateSkipEntries(entries []string) error {
+	for i, e := range entries {
+		if err := validateSkipEntry(e); err != nil {
+			return fmt.Errorf("extra_skip_dirs[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// IgnoreProfilesHandler serves /api/system/config/ignore-profiles.
+type IgnoreProfilesHandler struct {
+	profiles *IgnoreProfiles
+	colls    *CollectionSettings
+}
+
+// NewIgnoreProfilesHandler creates a new IgnoreProfilesHandler. colls
+// resolves the default collection for the effective endpoint.
+func NewIgnoreProfilesHandler(profiles *IgnoreProfiles, colls *CollectionSettings) *IgnoreProfilesHandler {
+	return &IgnoreProfilesHandler{profiles: profiles, colls: colls}
+}
+
+// Routes registers the ignore profile routes on the given chi router.
+func (h *IgnoreProfilesHandler) Routes(r chi.Router) {
+	r.Get("/", h.List)
+	r.Get("/effective", h.Effective)
+	r.Get("/{name}", h.Get)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireAdmin)
+		r.Put("/{name}", h.Put)
+		r.Delete("/{name}", h.Delete)
+	})
+}
+
+// List returns all profiles and the worker's built-in skip lists, which
+// apply before any profile.
+func (h *IgnoreProfilesHandler) List(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"profiles": h.profiles.List(),
+		"defaults": map[string]interface{}{
+			"skip_dirs":  manifest.DefaultSkipDirs,
+			"skip_files": manifest.DefaultSkipFiles,
+		},
+	})
+}
+
+// Get returns one profile.
+func (h *IgnoreProfilesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	prof, ok := h.profiles.Get(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("ignore profile %s not found", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, prof)
+}
+
+// Put creates or replaces a profile after validating its patterns.
+func (h *IgnoreProfilesHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var prof IgnoreProfile
+	if err := json.NewDecoder(r.Body).Decode(&prof); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	prof.Name, _ = url.PathUnescape(chi.URLParam(r, "name"))
+	saved, err := h.profiles.Put(prof)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// Delete removes a profile.
+func (h *IgnoreProfilesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	ok, err := h.profiles.Delete(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("ignore profile %s not found", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": name})
+}
+
+// Effective returns the skip entries a codebase index run into
+// ?collection= (default: the default collection) would send the worker.
+func (h *IgnoreProfilesHandler) Effective(w http.ResponseWriter, r *http.Request) {
+	collection, _, _ := h.colls.ResolveIndex(r.URL.Query().Get("collection"), 0, 0)
+	if collection == "" {
+		collection = workerDefaultCodebaseCollection
+	}
+	merged, applied := h.profiles.Merge(collection, nil)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"collection":      collection,
+		"profiles":        applied,
+		"extra_skip_dirs": merged,
+	})
+}
@@ -1,35 +1,145 @@
 package handlers
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alfagnish/ollqd-gateway/internal/config"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
 	"github.com/go-chi/chi/v5"
 )
 
+// imageURLRounding is the step signed image URL expiries are rounded up to,
+// so repeated searches hand out identical URLs the browser can cache.
+const imageURLRounding = 5 * time.Minute
+
+// ImageSigner mints short-lived signed URLs for /api/rag/image. A signed URL
+// is its own authorization, so <img> tags can load images without a token.
+type ImageSigner struct {
+	key       []byte
+	ttl       time.Duration
+	uploadDir string
+}
+
+// NewImageSigner creates an ImageSigner for files under uploadDir. URLs
+// stay valid for ttl, rounded up to five minutes. They are signed with a
+// key derived from secret (JWT_SECRET), so they stop working when it
+// changes.
+func NewImageSigner(secret string, ttl time.Duration, uploadDir string) *ImageSigner {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("ollqd image urls"))
+	return &ImageSigner{key: mac.Sum(nil), ttl: ttl, uploadDir: uploadDir}
+}
+
+// Path returns the signed gateway path of the upload at rel, relative to
+// the upload directory. Callers prepend the base path or use
+// middleware.ExternalURL.
+func (s *ImageSigner) Path(rel string) string {
+	rel = filepath.Clean(rel)
+	exp := time.Now().Add(s.ttl).Truncate(imageURLRounding).Add(imageURLRounding).Unix()
+	q := url.Values{}
+	q.Set("path", rel)
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", s.sign(rel, exp))
+	return "/api/rag/image?" + q.Encode()
+}
+
+// PathFor returns the signed gateway path of file, an absolute path, if it
+// lies in the upload directory.
+func (s *ImageSigner) PathFor(file string) (string, bool) {
+	rel, ok := uploadDirRel(s.uploadDir, file)
+	if !ok {
+		return "", false
+	}
+	return s.Path(rel), true
+}
+
+func (s *ImageSigner) sign(rel string, exp int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(rel + "\x00" + strconv.FormatInt(exp, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:18])
+}
+
+// verify checks the exp and sig parameters of a signed URL for rel and
+// returns its expiry. Bad signatures and expired URLs look the same to the
+// caller.
+func (s *ImageSigner) verify(rel, exp, sig string) (time.Time, bool) {
+	e, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > e {
+		return time.Time{}, false
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.sign(rel, e))) {
+		return time.Time{}, false
+	}
+	return time.Unix(e, 0), true
+}
+
+// uploadDirRel returns p relative to uploadDir, as a slash path, when it lies
+// inside.
+func uploadDirRel(uploadDir, p string) (string, bool) {
+	if uploadDir == "" || !filepath.IsAbs(p) {
+		return "", false
+	}
+	root, err := filepath.Abs(uploadDir)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, filepath.Clean(p))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
 // ImageHandler serves static image files from the upload directory. This is
-// used by the frontend to display indexed images.
+// used by the frontend to display indexed images. It also runs single
+// caption tests against a vision model.
 type ImageHandler struct {
-	cfg *config.Config
+	cfg       *config.Config
+	signer    *ImageSigner
+	grpc      *grpcclient.Client
+	instances *OllamaInstances
+	ollama    *http.Client
 }
 
-// NewImageHandler creates a new ImageHandler.
-func NewImageHandler(cfg *config.Config) *ImageHandler {
-	return &ImageHandler{cfg: cfg}
+// NewImageHandler creates a new ImageHandler. Requests carrying a URL
+// signed by signer are served without a login; all others need one. The
+// gRPC client supplies the worker's vision model and caption prompt to
+// caption tests, which run on one of instances and reach it with ollama.
+func NewImageHandler(cfg *config.Config, signer *ImageSigner, gc *grpcclient.Client, instances *OllamaInstances, ollama *http.Client) *ImageHandler {
+	return &ImageHandler{cfg: cfg, signer: signer, grpc: gc, instances: instances, ollama: ollama}
 }
 
 // Routes registers image-serving routes.
 func (h *ImageHandler) Routes(r chi.Router) {
 	r.Get("/", h.ServeImage)
+	r.Post("/caption", h.Caption)
 }
 
 // ServeImage returns a file from the upload directory based on the `path`
 // query parameter. The path is sanitized to prevent directory traversal.
+// With `w` and/or `h` it returns a cached thumbnail instead (see
+// serveThumbnail); `format` selects jpeg (default) or webp.
+//
+// The route is public so that signed URLs (`exp` and `sig`, see
+// ImageSigner) work without a token; unsigned requests need a login.
 func (h *ImageHandler) ServeImage(w http.ResponseWriter, r *http.Request) {
-	relPath := r.URL.Query().Get("path")
+	q := r.URL.Query()
+	relPath := q.Get("path")
 	if relPath == "" {
 		writeError(w, http.StatusBadRequest, "missing 'path' query parameter")
 		return
@@ -42,12 +152,31 @@ func (h *ImageHandler) ServeImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	signed := q.Has("sig")
+	var expires time.Time
+	switch {
+	case signed:
+		var ok bool
+		if expires, ok = h.signer.verify(cleaned, q.Get("exp"), q.Get("sig")); !ok {
+			writeError(w, http.StatusForbidden, "invalid or expired image link")
+			return
+		}
+		// Signed links are for <img> tags and the media players of
+		// transcript hits; other uploads need a login.
+		if ext := strings.ToLower(filepath.Ext(cleaned)); !imageExtensions[ext] && !mediaExtensions[ext] {
+			writeError(w, http.StatusForbidden, "signed links only serve images and media")
+			return
+		}
+	case middleware.UsernameFromContext(r.Context()) == "":
+		writeError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
 	fullPath := filepath.Join(h.cfg.UploadDir, cleaned)
 
 	// Verify the resolved path is still under the upload directory.
-	absUpload, _ := filepath.Abs(h.cfg.UploadDir)
 	absFile, _ := filepath.Abs(fullPath)
-	if !strings.HasPrefix(absFile, absUpload) {
+	if _, ok := uploadDirRel(h.cfg.UploadDir, absFile); !ok {
 		writeError(w, http.StatusForbidden, "access denied")
 		return
 	}
@@ -59,5 +188,50 @@ func (h *ImageHandler) ServeImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// SVG uploads must not run script when opened directly.
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+
+	if wantsThumbnail(r) {
+		h.serveThumbnail(w, r, fullPath, info)
+		return
+	}
+
+	if signed {
+		// The URL itself stops working at its expiry; so should copies.
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(time.Until(expires).Seconds())))
+	}
+
 	http.ServeFile(w, r, fullPath)
 }
+
+// imageHit is a search hit with a signed URL for images in the upload
+// directory.
+type imageHit = api.SearchHit
+
+// imageURL returns the signed absolute URL of hit when it is an image in the
+// upload directory, or "".
+func (s *ImageSigner) imageURL(r *http.Request, hit *grpcclient.SearchHit) string {
+	if s == nil || hit.GetLanguage() != "image" {
+		return ""
+	}
+	file := hit.GetAbsPath()
+	if file == "" {
+		file = hit.GetFilePath()
+	}
+	p, ok := s.PathFor(file)
+	if !ok {
+		return ""
+	}
+	return middleware.ExternalURL(r, p)
+}
+
+// imageHits adds signed image URLs to hits, and time ranges to transcript
+// hits.
+func (s *ImageSigner) imageHits(r *http.Request, hits []*grpcclient.SearchHit) []imageHit {
+	out := make([]imageHit, len(hits))
+	for i, hit := range hits {
+		out[i] = imageHit{SearchHit: hit, ImageURL: s.imageURL(r, hit), Media: s.mediaRange(r, hit)}
+	}
+	return out
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// maxCaptionImage bounds an image sent to the caption test, in bytes.
+	maxCaptionImage = 20 << 20
+	// captionTimeout bounds one captioning round-trip, matching the
+	// worker's timeout for captions during indexing.
+	captionTimeout = 3 * time.Minute
+)
+
+// captionRequest is a caption test: an image, either uploaded or a path in
+// the upload directory, and the model and prompt to try on it.
+type captionRequest struct {
+	Path     string `json:"path"`
+	Model    string `json:"model"`
+	Prompt   string `json:"prompt"`
+	Instance string `json:"instance"`
+
+	image []byte
+}
+
+// Caption captions a single image the way IndexImages would, so the vision
+// model and caption prompt can be tuned before a full indexing run. It takes
+// either a multipart upload ("file" plus "model", "prompt" and "instance"
+// fields) or a JSON body naming an image in the upload directory by "path".
+// Model and prompt default to the worker's vision model and caption prompt.
+func (h *ImageHandler) Caption(w http.ResponseWriter, r *http.Request) {
+	req, status, err := h.readCaptionRequest(w, r)
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+	if !strings.HasPrefix(http.DetectContentType(req.image), "image/") {
+		writeError(w, http.StatusUnsupportedMediaType, "file is not an image")
+		return
+	}
+	inst, err := h.instances.Resolve(req.Instance)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Model == "" || req.Prompt == "" {
+		if h.grpc == nil || h.grpc.Config == nil {
+			writeError(w, http.StatusBadRequest, "model and prompt are required when the worker is unavailable")
+			return
+		}
+		cfg, err := h.grpc.Config.GetConfig(r.Context())
+		if err != nil {
+			writeGRPCError(w, err)
+			return
+		}
+		if req.Model == "" {
+			req.Model = cfg.GetOllama().GetVisionModel()
+		}
+		if req.Prompt == "" {
+			req.Prompt = cfg.GetImage().GetCaptionPrompt()
+		}
+		if req.Model == "" {
+			writeError(w, http.StatusBadRequest, "model is required: the worker has no vision model configured")
+			return
+		}
+	}
+
+	start := time.Now()
+	caption, status, err := h.caption(r.Context(), inst.URL, req)
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"caption":     caption,
+		"model":       req.Model,
+		"prompt":      req.Prompt,
+		"instance":    inst.Name,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+}
+
+// readCaptionRequest reads a multipart or JSON caption request and loads
+// its image. On failure it returns the HTTP status to report.
+func (h *ImageHandler) readCaptionRequest(w http.ResponseWriter, r *http.Request) (captionRequest, int, error) {
+	var req captionRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxCaptionImage+64<<10)
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxCaptionImage); err != nil {
+			return req, http.StatusRequestEntityTooLarge, fmt.Errorf("image exceeds maximum size of %d MB", maxCaptionImage>>20)
+		}
+		req.Model = strings.TrimSpace(r.FormValue("model"))
+		req.Prompt = strings.TrimSpace(r.FormValue("prompt"))
+		req.Instance = strings.TrimSpace(r.FormValue("instance"))
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			return req, http.StatusBadRequest, fmt.Errorf("missing 'file' field")
+		}
+		defer f.Close()
+		req.image, err = io.ReadAll(io.LimitReader(f, maxCaptionImage+1))
+		if err != nil {
+			return req, http.StatusBadRequest, err
+		}
+		if len(req.image) > maxCaptionImage {
+			return req, http.StatusRequestEntityTooLarge, fmt.Errorf("image exceeds maximum size of %d MB", maxCaptionImage>>20)
+		}
+		return req, http.StatusOK, nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, http.StatusBadRequest, fmt.Errorf("invalid JSON body")
+	}
+	req.Model, req.Prompt = strings.TrimSpace(req.Model), strings.TrimSpace(req.Prompt)
+	if req.Path == "" {
+		return req, http.StatusBadRequest, fmt.Errorf("upload a 'file' or give the 'path' of an image in the upload directory")
+	}
+	rel := filepath.Clean(req.Path)
+	if filepath.IsAbs(rel) {
+		var ok bool
+		if rel, ok = uploadDirRel(h.cfg.UploadDir, rel); !ok {
+			return req, http.StatusForbidden, fmt.Errorf("access denied")
+		}
+	} else if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return req, http.StatusBadRequest, fmt.Errorf("invalid path")
+	}
+	fullPath := filepath.Join(h.cfg.UploadDir, rel)
+	info, err := os.Stat(fullPath)
+	if err != nil || info.IsDir() {
+		return req, http.StatusNotFound, fmt.Errorf("file not found")
+	}
+	if info.Size() > maxCaptionImage {
+		return req, http.StatusRequestEntityTooLarge, fmt.Errorf("image exceeds maximum size of %d MB", maxCaptionImage>>20)
+	}
+	req.image, err = os.ReadFile(fullPath)
+	if err != nil {
+		return req, http.StatusInternalServerError, err
+	}
+	return req, http.StatusOK, nil
+}
+
+// caption sends one image to /api/chat on the Ollama at baseURL with the
+// same request the worker makes while indexing. On failure it returns the
+// HTTP status to report.
+func (h *ImageHandler) caption(ctx context.Context, baseURL string, req captionRequest) (string, int, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model": req.Model,
+		"messages": []map[string]interface{}{{
+			"role":    "user",
+			"content": req.Prompt,
+			"images":  []string{base64.StdEncoding.EncodeToString(req.image)},
+		}},
+		"stream": false,
+	})
+	ctx, cancel := context.WithTimeout(ctx, captionTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.ollama.Do(httpReq)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", http.StatusGatewayTimeout, fmt.Errorf("ollama did not answer within %s", captionTimeout)
+		}
+		return "", http.StatusBadGateway, fmt.Errorf("ollama error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Error string `json:"error"`
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		if json.Unmarshal(raw, &out) != nil || out.Error == "" {
+			out.Error = string(raw)
+		}
+		status := http.StatusBadGateway
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+			status = resp.StatusCode
+		}
+		return "", status, fmt.Errorf("ollama error: %s", out.Error)
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return "", http.StatusBadGateway, fmt.Errorf("failed to parse ollama response")
+	}
+	return strings.TrimSpace(out.Message.Content), http.StatusOK, nil
+}
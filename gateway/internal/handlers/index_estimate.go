@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/manifest"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+const (
+	// estimateMaxFiles bounds the files one estimate looks at; larger trees
+	// are reported as truncated.
+	estimateMaxFiles = 1_000_000
+	// estimateThroughputRuns is how many recent completed runs the
+	// throughput is measured over.
+	estimateThroughputRuns = 10
+	// estimateConfigTimeout bounds the worker config lookup.
+	estimateConfigTimeout = 5 * time.Second
+	// workerEmbedBatchSize is the number of chunks the worker embeds per
+	// Ollama call.
+	workerEmbedBatchSize = 32
+	// Worker chunking defaults, used when its config cannot be read.
+	workerDefaultChunkSize = 512
+	workerDefaultMaxFileKB = 512
+)
+
+// workerLanguages mirrors the worker's LANGUAGE_MAP: the extensions its
+// codebase discovery indexes, with their language.
+var workerLanguages = map[string]string{
+	".py": "python", ".pyi": "python",
+	".go": "go",
+	".js": "javascript", ".mjs": "javascript", ".cjs": "javascript",
+	".ts": "typescript", ".tsx": "typescript", ".jsx": "javascript",
+	".rs":   "rust",
+	".java": "java", ".kt": "kotlin", ".scala": "scala",
+	".c": "c", ".h": "c", ".cpp": "cpp", ".hpp": "cpp", ".cc": "cpp",
+	".cs":    "csharp",
+	".rb":    "ruby",
+	".php":   "php",
+	".swift": "swift",
+	".lua":   "lua",
+	".sh":    "shell", ".bash": "shell", ".zsh": "shell",
+	".sql":  "sql",
+	".r":    "r",
+	".html": "html", ".css": "css", ".scss": "scss",
+	".yml": "yaml", ".yaml": "yaml", ".toml": "toml", ".json": "json",
+	".md": "markdown", ".rst": "restructuredtext",
+	".tf": "terraform", ".hcl": "hcl",
+	".dockerfile": "dockerfile",
+	".proto":      "protobuf",
+	".graphql":    "graphql", ".gql": "graphql",
+}
+
+// workerDocumentLanguages are the extensions the worker's document indexer
+// reads, with the language it chunks them as.
+var workerDocumentLanguages = map[string]string{
+	".md": "markdown", ".rst": "markdown", ".txt": "text", ".html": "text",
+}
+
+// IndexEstimateHandler estimates what an index run would produce without
+// running it, so large ingestion runs can be planned.
+type IndexEstimateHandler struct {
+	grpc    *grpcclient.Client
+	colls   *CollectionSettings
+	ignores *IgnoreProfiles
+	reports *IndexReports
+}
+
+// NewIndexEstimateHandler creates a new IndexEstimateHandler. Collections
+// and skip lists resolve as they would for the run; the duration is
+// projected from the throughput of the runs in reports.
+func NewIndexEstimateHandler(gc *grpcclient.Client, colls *CollectionSettings, ignores *IgnoreProfiles, reports *IndexReports) *IndexEstimateHandler {
+	return &IndexEstimateHandler{grpc: gc, colls: colls, ignores: ignores, reports: reports}
+}
+
+// indexEstimateRequest is the body of POST /api/rag/index/estimate. Type
+// is "codebase" (the default), with RootPath and ExtraSkipDirs, or
+// "documents", with Paths.
+type indexEstimateRequest struct {
+	Type            string   `json:"type"`
+	RootPath        string   `json:"root_path"`
+	Paths           []string `json:"paths"`
+	Collection      string   `json:"collection"`
+	ChunkSize       int32    `json:"chunk_size"`
+	ChunkOverlap    int32    `json:"chunk_overlap"`
+	ExtraSkipDirs   []string `json:"extra_skip_dirs"`
+	CostPer1KTokens *float64 `json:"cost_per_1k_tokens"`
+}
+
+// languageEstimate is the share of one language in an estimate.
+type languageEstimate struct {
+	Files  int   `json:"files"`
+	Bytes  int64 `json:"bytes"`
+	Chunks int   `json:"chunks"`
+}
+
+// estimateSkipped counts the files the run would leave out.
+type estimateSkipped struct {
+	TooLarge    int `json:"too_large"`
+	Unsupported int `json:"unsupported"`
+}
+
+// indexThroughput is the embedding throughput measured over recent runs.
+// Scope is "collection" when it comes from runs into the same collection,
+// else "type" for runs of the same type into any collection.
+type indexThroughput struct {
+	Runs            int     `json:"runs"`
+	ChunksPerSecond float64 `json:"chunks_per_second"`
+	Scope           string  `json:"scope"`
+}
+
+// indexEstimate is the response of POST /api/rag/index/estimate.
+type indexEstimate struct {
+	Type             string                      `json:"type"`
+	Collection       string                      `json:"collection"`
+	ChunkSize        int32                       `json:"chunk_size"`
+	ChunkOverlap     int32                       `json:"chunk_overlap"`
+	Files            int                         `json:"files"`
+	Bytes            int64                       `json:"bytes"`
+	Chunks           int                         `json:"chunks"`
+	Tokens           int64                       `json:"tokens"`
+	EmbeddingCalls   int                         `json:"embedding_calls"`
+	Skipped          estimateSkipped             `json:"skipped"`
+	Languages        map[string]languageEstimate `json:"languages"`
+	Throughput       *indexThroughput            `json:"throughput"`
+	EstimatedSeconds *float64                    `json:"estimated_seconds"`
+	EstimatedCost    *float64                    `json:"estimated_cost,omitempty"`
+	Truncated        bool                        `json:"truncated"`
+	Warnings         []string                    `json:"warnings,omitempty"`
+}
+
+// Estimate walks the files an index run would read, applying the same skip
+// rules, extensions and size limit as the worker, and estimates the chunks,
+// tokens and embedding calls they make. With recent runs to measure, it
+// also projects the run's duration; with cost_per_1k_tokens, its cost.
+func (h *IndexEstimateHandler) Estimate(w http.ResponseWriter, r *http.Request) {
+	var req indexEstimateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	taskType := ""
+	switch req.Type {
+	case "", "codebase":
+		req.Type, taskType = "codebase", "index_codebase"
+		if req.RootPath == "" {
+			writeError(w, http.StatusBadRequest, "root_path is required")
+			return
+		}
+		if err := validateSkipEntries(req.ExtraSkipDirs); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	case "documents":
+		taskType = "index_documents"
+		if len(req.Paths) == 0 {
+			writeError(w, http.StatusBadRequest, "paths must not be empty")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "type must be \"codebase\" or \"documents\"")
+		return
+	}
+	if req.ChunkSize < 0 || req.ChunkOverlap < 0 {
+		writeError(w, http.StatusBadRequest, "chunk_size and chunk_overlap must not be negative")
+		return
+	}
+	if req.CostPer1KTokens != nil && *req.CostPer1KTokens < 0 {
+		writeError(w, http.StatusBadRequest, "cost_per_1k_tokens must not be negative")
+		return
+	}
+
+	est := &indexEstimate{Type: req.Type, Languages: map[string]languageEstimate{}}
+	est.Collection, req.ChunkSize, req.ChunkOverlap = h.colls.ResolveIndex(req.Collection, req.ChunkSize, req.ChunkOverlap)
+	if est.Collection == "" {
+		est.Collection = workerDefaultCollectionFor(taskType)
+	}
+	maxFileKB := h.workerChunking(r.Context(), &req, est)
+	est.ChunkSize, est.ChunkOverlap = req.ChunkSize, req.ChunkOverlap
+
+	var err error
+	if req.Type == "codebase" {
+		skip, _ := h.ignores.Merge(est.Collection, req.ExtraSkipDirs)
+		err = estimateCodebase(r.Context(), est, filepath.Clean(req.RootPath), skip, int64(maxFileKB)*1024)
+	} else {
+		err = estimateDocuments(r.Context(), est, req.Paths)
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		writeError(w, http.StatusNotFound, fmt.Sprintf("path not visible to the gateway: %v", err))
+		return
+	case err != nil && r.Context().Err() != nil:
+		return
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	est.EmbeddingCalls = (est.Chunks + workerEmbedBatchSize - 1) / workerEmbedBatchSize
+	if t := h.throughput(taskType, est.Collection); t != nil {
+		est.Throughput = t
+		secs := round3(float64(est.Chunks) / t.ChunksPerSecond)
+		est.EstimatedSeconds = &secs
+	} else {
+		est.Warnings = append(est.Warnings, "no completed runs to measure throughput from; duration not estimated")
+	}
+	if req.CostPer1KTokens != nil {
+		cost := round3(float64(est.Tokens) / 1000 * *req.CostPer1KTokens)
+		est.EstimatedCost = &cost
+	}
+	writeJSON(w, http.StatusOK, est)
+}
+
+// workerChunking fills the chunk size the request leaves unset from the
+// worker's config, as the worker itself does, and returns its file size
+// limit in KB. Without the worker it falls back to the worker's defaults
+// and says so in a warning.
+func (h *IndexEstimateHandler) workerChunking(ctx context.Context, req *indexEstimateRequest, est *indexEstimate) int32 {
+	size, maxKB := int32(workerDefaultChunkSize), int32(workerDefaultMaxFileKB)
+	if h.grpc != nil && h.grpc.Config != nil {
+		ctx, cancel := context.WithTimeout(ctx, estimateConfigTimeout)
+		cfg, err := h.grpc.Config.GetConfig(ctx)
+		cancel()
+		if err == nil {
+			if v := cfg.GetChunking().GetChunkSize(); v > 0 {
+				size = v
+			}
+			if v := cfg.GetChunking().GetMaxFileSizeKb(); v > 0 {
+				maxKB = v
+			}
+		} else {
+			est.Warnings = append(est.Warnings, "worker config unavailable; assuming its default chunking")
+		}
+	}
+	if req.ChunkSize == 0 {
+		req.ChunkSize = size
+	}
+	return maxKB
+}
+
+// errEstimateTruncated stops a walk at estimateMaxFiles.
+var errEstimateTruncated = errors.New("estimate truncated")
+
+// estimateCodebase adds the files under root that codebase discovery would
+// index. Like chunk_file, chunks hold about chunkSize tokens of four
+// characters, and each chunk after a file's first repeats the overlap.
+func estimateCodebase(ctx context.Context, est *indexEstimate, root string, skip []string, maxSize int64) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", root)
+	}
+	budget, overlap := int64(est.ChunkSize)*4, int64(est.ChunkOverlap)*4
+	err = manifest.Walk(root, skip, func(rel, path string, info fs.FileInfo) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := strings.ToLower(info.Name())
+		ext := filepath.Ext(name)
+		if name == "dockerfile" {
+			ext = ".dockerfile"
+		}
+		lang, ok := workerLanguages[ext]
+		switch {
+		case !ok:
+			est.Skipped.Unsupported++
+			return nil
+		case info.Size() > maxSize:
+			est.Skipped.TooLarge++
+			return nil
+		}
+		return est.add(lang, info.Size(), budget, overlap)
+	})
+	if errors.Is(err, errEstimateTruncated) {
+		est.Truncated = true
+		return nil
+	}
+	return err
+}
+
+// estimateDocuments adds the files under paths that the document indexer
+// would read. It splits text into chunks of chunkSize characters, without
+// overlap.
+func estimateDocuments(ctx context.Context, est *indexEstimate, paths []string) error {
+	budget := int64(est.ChunkSize)
+	for _, p := range paths {
+		root := filepath.Clean(p)
+		if _, err := os.Stat(root); err != nil {
+			return err
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			lang, ok := workerDocumentLanguages[strings.ToLower(filepath.Ext(path))]
+			if !ok || !d.Type().IsRegular() {
+				est.Skipped.Unsupported++
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			return est.add(lang, info.Size(), budget, 0)
+		})
+		if errors.Is(err, errEstimateTruncated) {
+			est.Truncated = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add counts a file of size bytes in lang, split into chunks of budget
+// characters that repeat overlap characters of the previous chunk.
+func (est *indexEstimate) add(lang string, size, budget, overlap int64) error {
+	if est.Files >= estimateMaxFiles {
+		return errEstimateTruncated
+	}
+	chunks := int64(0)
+	if size > 0 {
+		chunks = 1
+		if stride := budget - overlap; size > budget && stride > 0 {
+			chunks += (size - budget + stride - 1) / stride
+		}
+	}
+	embedded := size
+	if chunks > 1 {
+		embedded += (chunks - 1) * overlap
+	}
+	est.Files++
+	est.Bytes += size
+	est.Chunks += int(chunks)
+	est.Tokens += (embedded + 3) / 4
+
+	l := est.Languages[lang]
+	l.Files++
+	l.Bytes += size
+	l.Chunks += int(chunks)
+	est.Languages[lang] = l
+	return nil
+}
+
+// throughput measures chunks embedded per second over the recent completed
+// runs of taskType into collection, or into any collection if it has none.
+func (h *IndexEstimateHandler) throughput(taskType, collection string) *indexThroughput {
+	if h.reports == nil {
+		return nil
+	}
+	if t := measureThroughput(h.reports.runs(collection), taskType); t != nil {
+		t.Scope = "collection"
+		return t
+	}
+	var all []IndexRunReport
+	for _, c := range h.reports.collections() {
+		all = append(all, h.reports.runs(c)...)
+	}
+	if t := measureThroughput(all, taskType); t != nil {
+		t.Scope = "type"
+		return t
+	}
+	return nil
+}
+
+// measureThroughput returns the chunks per second of the latest completed
+// runs of taskType that produced chunks, or nil if there are none.
+func measureThroughput(runs []IndexRunReport, taskType string) *indexThroughput {
+	var latest []IndexRunReport
+	for _, run := range runs {
+		if run.Type == taskType && run.Status == tasks.StatusCompleted && run.Chunks > 0 && run.DurationSeconds > 0 {
+			latest = append(latest, run)
+		}
+	}
+	if len(latest) == 0 {
+		return nil
+	}
+	// Runs of several collections are not in completion order.
+	sort.SliceStable(latest, func(i, j int) bool { return latest[i].CompletedAt.Before(latest[j].CompletedAt) })
+	if len(latest) > estimateThroughputRuns {
+		latest = latest[len(latest)-estimateThroughputRuns:]
+	}
+	var chunks, secs float64
+	for _, run := range latest {
+		chunks += float64(run.Chunks)
+		secs += run.DurationSeconds
+	}
+	return &indexThroughput{Runs: len(latest), ChunksPerSecond: round3(chunks / math.Max(secs, 0.001))}
+}
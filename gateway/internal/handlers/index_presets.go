@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// indexPresetsDoc is the store document holding the index presets.
+const indexPresetsDoc = "index-presets"
+
+const (
+	// maxIndexPresets bounds the saved presets.
+	maxIndexPresets = 200
+	// maxPresetName bounds a preset name, in bytes.
+	maxPresetName = 80
+	// maxPresetDescription bounds a preset description, in bytes.
+	maxPresetDescription = 500
+	// maxPresetBody bounds a preset or run override request, in bytes.
+	maxPresetBody = 64 << 10
+)
+
+// Kinds of index preset, one per index endpoint.
+const (
+	presetKindCodebase  = "codebase"
+	presetKindDocuments = "documents"
+	presetKindImages    = "images"
+)
+
+// IndexPreset is a saved index configuration. Params holds the body of the
+// index endpoint named by Kind: POST /api/rag/index/codebase, documents or
+// images.
+type IndexPreset struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Kind        string          `json:"kind"`
+	Params      json.RawMessage `json:"params"`
+	CreatedBy   string          `json:"created_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	LastRunAt   *time.Time      `json:"last_run_at,omitempty"`
+	LastTaskID  string          `json:"last_task_id,omitempty"`
+}
+
+// validate normalises and checks p, returning an error naming the offending
+// field.
+func (p *IndexPreset) validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Description = strings.TrimSpace(p.Description)
+	if p.Name == "" || len(p.Name) > maxPresetName {
+		return fmt.Errorf("name must be 1-%d bytes", maxPresetName)
+	}
+	if len(p.Description) > maxPresetDescription {
+		return fmt.Errorf("description must be at most %d bytes", maxPresetDescription)
+	}
+	if len(bytes.TrimSpace(p.Params)) == 0 {
+		return errors.New("params is required")
+	}
+
+	var (
+		hasPath        bool
+		priority, lock string
+		skip           []string
+		err            error
+	)
+	switch p.Kind {
+	case presetKindCodebase:
+		var req indexCodebaseRequest
+		err = decodeStrict(p.Params, &req)
+		hasPath, priority, lock, skip = req.RootPath != "", req.Priority, req.Lock, req.ExtraSkipDirs
+	case presetKindDocuments:
+		var req indexDocumentsRequest
+		err = decodeStrict(p.Params, &req)
+		hasPath, priority, lock = len(req.Paths) > 0, req.Priority, req.Lock
+	case presetKindImages:
+		var req indexImagesRequest
+		err = decodeStrict(p.Params, &req)
+		hasPath, priority, lock, skip = req.RootPath != "", req.Priority, req.Lock, req.ExtraSkipDirs
+	default:
+		return fmt.Errorf("kind must be %s, %s or %s", presetKindCodebase, presetKindDocuments, presetKindImages)
+	}
+	if err != nil {
+		return fmt.Errorf("params: %v", err)
+	}
+	if !hasPath {
+		if p.Kind == presetKindDocuments {
+			return errors.New("params.paths is required")
+		}
+		return errors.New("params.root_path is required")
+	}
+	if _, err := tasks.ParsePriority(priority); err != nil {
+		return fmt.Errorf("params: %v", err)
+	}
+	if _, err := tasks.ParseLockMode(lock); err != nil {
+		return fmt.Errorf("params: %v", err)
+	}
+	if err := validateSkipEntries(skip); err != nil {
+		return fmt.Errorf("params: %v", err)
+	}
+	return nil
+}
+
+// decodeStrict decodes data into v, rejecting fields v does not have so a
+// misspelt parameter is not silently dropped from a preset.
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// IndexPresets holds the saved index presets, persisted in the gateway
+// store. Presets are shared by all users.
+type IndexPresets struct {
+	mu    sync.RWMutex
+	store *store.Store
+	data  struct {
+		Presets map[string]IndexPreset `json:"presets"`
+	}
+}
+
+// NewIndexPresets loads the presets from st.
+func NewIndexPresets(st *store.Store) *IndexPresets {
+	p := &IndexPresets{store: st}
+	if _, err := st.Load(indexPresetsDoc, &p.data); err != nil {
+		log.Printf("WARNING: index presets: %v", err)
+	}
+	if p.data.Presets == nil {
+		p.data.Presets = make(map[string]IndexPreset)
+	}
+	return p
+}
+
+// List returns every preset sorted by name.
+func (p *IndexPresets) List() []IndexPreset {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	out := make([]IndexPreset, 0, len(p.data.Presets))
+	for _, preset := range p.data.Presets {
+		out = append(out, preset)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Get returns the preset with the given ID.
+func (p *IndexPresets) Get(id string) (IndexPreset, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	preset, ok := p.data.Presets[id]
+	return preset, ok
+}
+
+// errPresetNotFound is returned by Put when replacing a missing preset.
+var errPresetNotFound = errors.New("index preset not found")
+
+// Put validates and stores preset. An empty ID creates a new preset;
+// otherwise the preset with that ID is replaced, keeping its creator and run
+// history.
+func (p *IndexPresets) Put(preset IndexPreset) (IndexPreset, error) {
+	if err := preset.validate(); err != nil {
+		return preset, err
+	}
+	now := time.Now().UTC()
+	preset.UpdatedAt = now
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, other := range p.data.Presets {
+		if other.ID != preset.ID && strings.EqualFold(other.Name, preset.Name) {
+			return preset, fmt.Errorf("an index preset named %q already exists", other.Name)
+		}
+	}
+	if preset.ID == "" {
+		if len(p.data.Presets) >= maxIndexPresets {
+			return preset, fmt.Errorf("at most %d index presets can be saved", maxIndexPresets)
+		}
+		preset.ID = uuid.New().String()
+		preset.CreatedAt = now
+		preset.LastRunAt, preset.LastTaskID = nil, ""
+	} else {
+		old, ok := p.data.Presets[preset.ID]
+		if !ok {
+			return preset, errPresetNotFound
+		}
+		preset.CreatedBy, preset.CreatedAt = old.CreatedBy, old.CreatedAt
+		preset.LastRunAt, preset.LastTaskID = old.LastRunAt, old.LastTaskID
+	}
+	p.data.Presets[preset.ID] = preset
+	return preset, p.store.Save(indexPresetsDoc, p.data)
+}
+
+// Delete removes a preset, reporting whether it existed.
+func (p *IndexPresets) Delete(id string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.data.Presets[id]; !ok {
+		return false, nil
+	}
+	delete(p.data.Presets, id)
+	return true, p.store.Save(indexPresetsDoc, p.data)
+}
+
+// RecordRun notes that the preset started taskID.
+func (p *IndexPresets) RecordRun(id, taskID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	preset, ok := p.data.Presets[id]
+	if !ok {
+		return nil
+	}
+	now := time.Now().UTC()
+	preset.LastRunAt, preset.LastTaskID = &now, taskID
+	p.data.Presets[id] = preset
+	return p.store.Save(indexPresetsDoc, p.data)
+}
+
+// IndexPresetsHandler serves /api/rag/presets: saved index configurations
+// that can be started again without re-entering their parameters.
+type IndexPresetsHandler struct {
+	presets *IndexPresets
+	rag     *RAGHandler
+}
+
+// NewIndexPresetsHandler creates a new IndexPresetsHandler that starts
+// presets through rag.
+func NewIndexPresetsHandler(presets *IndexPresets, rag *RAGHandler) *IndexPresetsHandler {
+	return &IndexPresetsHandler{presets: presets, rag: rag}
+}
+
+// Routes registers the preset routes on the given chi router.
+func (h *IndexPresetsHandler) Routes(r chi.Router) {
+	r.Get("/", h.List)
+	r.Post("/", h.Put)
+	r.Get("/{id}", h.Get)
+	r.Put("/{id}", h.Put)
+	r.Delete("/{id}", h.Delete)
+	r.With(requireWorker(h.rag.grpc, grpcclient.ServiceIndexing)).Post("/{id}/run", h.Run)
+}
+
+// List returns every preset.
+func (h *IndexPresetsHandler) List(w http.ResponseWriter, r *http.Request) {
+	presets := h.presets.List()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"presets": presets,
+		"count":   len(presets),
+	})
+}
+
+// Get returns one preset.
+func (h *IndexPresetsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	preset, ok := h.presets.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("index preset %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, preset)
+}
+
+// Put creates a preset (POST) or replaces the one in the path (PUT).
+func (h *IndexPresetsHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var req IndexPreset
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPresetBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	preset := IndexPreset{
+		ID:          chi.URLParam(r, "id"),
+		Name:        req.Name,
+		Description: req.Description,
+		Kind:        req.Kind,
+		Params:      req.Params,
+		CreatedBy:   middleware.UsernameFromContext(r.Context()),
+	}
+	saved, err := h.presets.Put(preset)
+	switch {
+	case errors.Is(err, errPresetNotFound):
+		writeError(w, http.StatusNotFound, fmt.Sprintf("index preset %s not found", preset.ID))
+		return
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, saved)
+}
+
+// Delete removes a preset. Tasks it already started are not affected.
+func (h *IndexPresetsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	found, err := h.presets.Delete(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("index preset %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": id})
+}
+
+// Run starts an index task with the preset's params. An optional body
+// overrides individual params for this run only, e.g.
+// {"incremental": false} to force a full re-index.
+func (h *IndexPresetsHandler) Run(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	preset, ok := h.presets.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("index preset %s not found", id))
+		return
+	}
+	override, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPresetBody))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	var (
+		taskID   string
+		started  bool
+		uploader = middleware.UsernameFromContext(r.Context())
+	)
+	switch preset.Kind {
+	case presetKindCodebase:
+		var req indexCodebaseRequest
+		if applyPresetParams(w, &req, preset.Params, override) {
+			taskID, started = h.rag.startIndexCodebase(w, req, uploader)
+		}
+	case presetKindDocuments:
+		var req indexDocumentsRequest
+		if applyPresetParams(w, &req, preset.Params, override) {
+			taskID, started = h.rag.startIndexDocuments(w, req, uploader)
+		}
+	case presetKindImages:
+		var req indexImagesRequest
+		if applyPresetParams(w, &req, preset.Params, override) {
+			taskID, started = h.rag.startIndexImages(w, req, uploader)
+		}
+	default:
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("index preset %s has unknown kind %q", id, preset.Kind))
+		return
+	}
+	if !started {
+		return
+	}
+	if err := h.presets.RecordRun(id, taskID); err != nil {
+		log.Printf("WARNING: index presets: recording run of %s: %v", id, err)
+	}
+	writeTaskAccepted(w, h.rag.tm, taskID)
+}
+
+// applyPresetParams decodes the preset params and then the run override into
+// req, writing an error and returning false if either is invalid.
+func applyPresetParams(w http.ResponseWriter, req interface{}, params json.RawMessage, override []byte) bool {
+	if err := json.Unmarshal(params, req); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("stored preset params: %v", err))
+		return false
+	}
+	if len(bytes.TrimSpace(override)) == 0 {
+		return true
+	}
+	if err := decodeStrict(override, req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid override: %v", err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+// indexReportsDoc is the store document holding the index run reports.
+const indexReportsDoc = "index-reports"
+
+const (
+	// maxIndexReports is the number of runs kept per collection.
+	maxIndexReports = 100
+	// defaultReportRuns is the number of runs listed per collection.
+	defaultReportRuns = 10
+	// reportConfigTimeout bounds the worker config lookup made when a run
+	// is recorded.
+	reportConfigTimeout = 5 * time.Second
+)
+
+// Thresholds above which a comparison between two runs warns.
+const (
+	reportChunksPerFileChange = 0.25 // relative change of chunks per file
+	reportSecondsPerFileRise  = 0.5  // relative rise of seconds per file
+)
+
+// indexTaskTypes are the task types that are reported on.
+var indexTaskTypes = map[string]bool{
+	"index_codebase":  true,
+	"index_documents": true,
+	"index_images":    true,
+	"index_uploads":   true,
+	"index_smb":       true,
+	"sync_smb":        true,
+}
+
+// IndexRunSettings are the settings a run indexed with. Changing them is
+// the usual cause of a change in chunk counts.
+type IndexRunSettings struct {
+	EmbedModel   string `json:"embed_model,omitempty"`
+	VisionModel  string `json:"vision_model,omitempty"`
+	ChunkSize    int32  `json:"chunk_size,omitempty"`
+	ChunkOverlap int32  `json:"chunk_overlap,omitempty"`
+}
+
+// IndexRunReport summarises one finished index task.
+type IndexRunReport struct {
+	TaskID      string           `json:"task_id"`
+	Type        string           `json:"type"`
+	Collection  string           `json:"collection"`
+	Status      tasks.TaskStatus `json:"status"`
+	Error       string           `json:"error,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	CompletedAt time.Time        `json:"completed_at"`
+	// QueuedSeconds is the time spent waiting for a slot, DurationSeconds
+	// the time spent running.
+	QueuedSeconds   float64 `json:"queued_seconds"`
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	Files   int `json:"files"`
+	Chunks  int `json:"chunks"`
+	Images  int `json:"images,omitempty"`
+	Skipped int `json:"skipped"`
+	Removed int `json:"removed,omitempty"`
+	// Errors counts files, images and batches that failed within the run.
+	Errors int `json:"errors"`
+
+	Settings IndexRunSettings `json:"settings"`
+}
+
+// chunksPerFile returns the chunks produced per indexed file, or 0.
+func (r IndexRunReport) chunksPerFile() float64 {
+	if r.Files == 0 {
+		return 0
+	}
+	return float64(r.Chunks) / float64(r.Files)
+}
+
+// secondsPerFile returns the run time per indexed file or image, or 0.
+func (r IndexRunReport) secondsPerFile() float64 {
+	if n := r.Files + r.Images; n > 0 {
+		return r.DurationSeconds / float64(n)
+	}
+	return 0
+}
+
+// IndexReports keeps a report of every finished index task, persisted in
+// the gateway store so trends survive restarts.
+type IndexReports struct {
+	mu    sync.RWMutex
+	store *store.Store
+	grpc  *grpcclient.Client
+	// data maps collections to their runs, oldest first.
+	data map[string][]IndexRunReport
+}
+
+// NewIndexReports loads the reports from st. gc is asked for the effective
+// models and chunking when a run is recorded.
+func NewIndexReports(st *store.Store, gc *grpcclient.Client) *IndexReports {
+	rep := &IndexReports{store: st, grpc: gc, data: map[string][]IndexRunReport{}}
+	if _, err := st.Load(indexReportsDoc, &rep.data); err != nil {
+		log.Printf("WARNING: index reports: %v", err)
+	}
+	return rep
+}
+
+// Record is a tasks.FinishFunc that reports on finished index tasks.
+func (rep *IndexReports) Record(t tasks.TaskInfo) {
+	if !indexTaskTypes[t.Type] {
+		return
+	}
+	run := IndexRunReport{
+		TaskID:     t.ID,
+		Type:       t.Type,
+		Collection: taskCollection(t),
+		Status:     t.Status,
+		Error:      t.Error,
+		CreatedAt:  t.CreatedAt,
+		Files:      resultInt(t.Result, "files"),
+		Chunks:     resultInt(t.Result, "chunks"),
+		Images:     resultInt(t.Result, "images_indexed"),
+		Skipped:    resultInt(t.Result, "skipped"),
+		Removed:    resultInt(t.Result, "removed"),
+		Errors:     resultInt(t.Result, "errors") + resultInt(t.Result, "images_failed"),
+		Settings:   rep.settings(t),
+	}
+	if t.CompletedAt != nil {
+		run.CompletedAt = *t.CompletedAt
+	}
+	if t.StartedAt != nil {
+		run.QueuedSeconds = roundSeconds(t.StartedAt.Sub(t.CreatedAt))
+		if t.CompletedAt != nil {
+			run.DurationSeconds = roundSeconds(t.CompletedAt.Sub(*t.StartedAt))
+		}
+	}
+
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	// Finish hooks run concurrently, so keep the runs in completion order.
+	runs := append(rep.data[run.Collection], run)
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].CompletedAt.Before(runs[j].CompletedAt) })
+	if len(runs) > maxIndexReports {
+		runs = runs[len(runs)-maxIndexReports:]
+	}
+	rep.data[run.Collection] = runs
+	if err := rep.store.Save(indexReportsDoc, rep.data); err != nil {
+		log.Printf("WARNING: save index report for task %s: %v", t.ID, err)
+	}
+}
+
+// taskCollection returns the collection an index task wrote to: the one
+// it reports, else the one it was asked for, else the worker's default.
+func taskCollection(t tasks.TaskInfo) string {
+	if c := t.Result["collection"]; c != "" {
+		return c
+	}
+	if c := stringParam(t.RequestParams, "collection"); c != "" {
+		return c
+	}
+	return workerDefaultCollectionFor(t.Type)
+}
+
+// settings returns the settings t ran with: those of its request, and the
+// worker's configuration for the rest.
+func (rep *IndexReports) settings(t tasks.TaskInfo) IndexRunSettings {
+	s := IndexRunSettings{
+		ChunkSize:    int32Param(t.RequestParams, "chunk_size"),
+		ChunkOverlap: int32Param(t.RequestParams, "chunk_overlap"),
+		VisionModel:  stringParam(t.RequestParams, "vision_model"),
+	}
+	if rep.grpc == nil || rep.grpc.Config == nil {
+		return s
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reportConfigTimeout)
+	defer cancel()
+	cfg, err := rep.grpc.Config.GetConfig(ctx)
+	if err != nil {
+		return s
+	}
+	s.EmbedModel = cfg.GetOllama().GetEmbedModel()
+	if s.ChunkSize == 0 {
+		s.ChunkSize = cfg.GetChunking().GetChunkSize()
+	}
+	if s.ChunkOverlap == 0 {
+		s.ChunkOverlap = cfg.GetChunking().GetChunkOverlap()
+	}
+	if s.VisionModel == "" && (t.Type == "index_images" || t.Type == "index_uploads") {
+		s.VisionModel = cfg.GetOllama().GetVisionModel()
+	}
+	return s
+}
+
+// runs returns a copy of the runs of a collection, oldest first.
+func (rep *IndexReports) runs(collection string) []IndexRunReport {
+	rep.mu.RLock()
+	defer rep.mu.RUnlock()
+	return append([]IndexRunReport(nil), rep.data[collection]...)
+}
+
+// collections returns the collections with reports, sorted.
+func (rep *IndexReports) collections() []string {
+	rep.mu.RLock()
+	defer rep.mu.RUnlock()
+	out := make([]string, 0, len(rep.data))
+	for c := range rep.data {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// clear drops the reports of collection, or of every collection if it is
+// empty, and returns the number of runs removed.
+func (rep *IndexReports) clear(collection string) (int, error) {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	n := 0
+	for c, runs := range rep.data {
+		if collection == "" || c == collection {
+			n += len(runs)
+			delete(rep.data, c)
+		}
+	}
+	return n, rep.store.Save(indexReportsDoc, rep.data)
+}
+
+// reportSummary aggregates the completed runs of a collection.
+type reportSummary struct {
+	Runs               int        `json:"runs"`
+	Completed          int        `json:"completed"`
+	Failed             int        `json:"failed"`
+	Cancelled          int        `json:"cancelled"`
+	AvgDurationSeconds float64    `json:"avg_duration_seconds"`
+	AvgFiles           float64    `json:"avg_files"`
+	AvgChunks          float64    `json:"avg_chunks"`
+	AvgChunksPerFile   float64    `json:"avg_chunks_per_file"`
+	TotalErrors        int        `json:"total_errors"`
+	LastCompletedAt    *time.Time `json:"last_completed_at,omitempty"`
+}
+
+func summarizeRuns(runs []IndexRunReport) reportSummary {
+	var s reportSummary
+	var dur, files, chunks float64
+	for _, r := range runs {
+		s.Runs++
+		s.TotalErrors += r.Errors
+		switch r.Status {
+		case tasks.StatusCompleted:
+			s.Completed++
+			dur += r.DurationSeconds
+			files += float64(r.Files)
+			chunks += float64(r.Chunks)
+			at := r.CompletedAt
+			s.LastCompletedAt = &at
+		case tasks.StatusFailed:
+			s.Failed++
+		case tasks.StatusCancelled:
+			s.Cancelled++
+		}
+	}
+	if s.Completed > 0 {
+		n := float64(s.Completed)
+		s.AvgDurationSeconds = round3(dur / n)
+		s.AvgFiles = round3(files / n)
+		s.AvgChunks = round3(chunks / n)
+	}
+	if files > 0 {
+		s.AvgChunksPerFile = round3(chunks / files)
+	}
+	return s
+}
+
+// metricChange is one metric of a run comparison.
+type metricChange struct {
+	From    float64  `json:"from"`
+	To      float64  `json:"to"`
+	Delta   float64  `json:"delta"`
+	Percent *float64 `json:"percent,omitempty"`
+}
+
+func newMetricChange(from, to float64) metricChange {
+	c := metricChange{From: round3(from), To: round3(to), Delta: round3(to - from)}
+	if from != 0 {
+		p := round3((to - from) / from * 100)
+		c.Percent = &p
+	}
+	return c
+}
+
+// settingChange is a setting that differs between two runs.
+type settingChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// runComparison compares the latest completed run of a type with the one
+// before it.
+type runComparison struct {
+	Type            string                   `json:"type"`
+	TaskID          string                   `json:"task_id"`
+	PreviousTaskID  string                   `json:"previous_task_id"`
+	Metrics         map[string]metricChange  `json:"metrics"`
+	SettingsChanged map[string]settingChange `json:"settings_changed"`
+	Warnings        []string                 `json:"warnings"`
+}
+
+// compareRuns compares cur with prev. Incremental runs index different
+// numbers of files, so warnings are based on per-file ratios.
+func compareRuns(prev, cur IndexRunReport) runComparison {
+	c := runComparison{
+		Type:           cur.Type,
+		TaskID:         cur.TaskID,
+		PreviousTaskID: prev.TaskID,
+		Metrics: map[string]metricChange{
+			"files":            newMetricChange(float64(prev.Files), float64(cur.Files)),
+			"chunks":           newMetricChange(float64(prev.Chunks), float64(cur.Chunks)),
+			"skipped":          newMetricChange(float64(prev.Skipped), float64(cur.Skipped)),
+			"errors":           newMetricChange(float64(prev.Errors), float64(cur.Errors)),
+			"duration_seconds": newMetricChange(prev.DurationSeconds, cur.DurationSeconds),
+			"chunks_per_file":  newMetricChange(prev.chunksPerFile(), cur.chunksPerFile()),
+			"seconds_per_file": newMetricChange(prev.secondsPerFile(), cur.secondsPerFile()),
+		},
+		SettingsChanged: map[string]settingChange{},
+		Warnings:        []string{},
+	}
+
+	ps, cs := prev.Settings, cur.Settings
+	if ps.EmbedModel != cs.EmbedModel {
+		c.SettingsChanged["embed_model"] = settingChange{ps.EmbedModel, cs.EmbedModel}
+	}
+	if ps.VisionModel != cs.VisionModel {
+		c.SettingsChanged["vision_model"] = settingChange{ps.VisionModel, cs.VisionModel}
+	}
+	if ps.ChunkSize != cs.ChunkSize {
+		c.SettingsChanged["chunk_size"] = settingChange{ps.ChunkSize, cs.ChunkSize}
+	}
+	if ps.ChunkOverlap != cs.ChunkOverlap {
+		c.SettingsChanged["chunk_overlap"] = settingChange{ps.ChunkOverlap, cs.ChunkOverlap}
+	}
+
+	if cur.Errors > prev.Errors {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("errors rose from %d to %d", prev.Errors, cur.Errors))
+	}
+	if p, q := prev.chunksPerFile(), cur.chunksPerFile(); p > 0 && q > 0 && math.Abs(q-p)/p > reportChunksPerFileChange {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("chunks per file changed from %.1f to %.1f", p, q))
+	}
+	if p, q := prev.secondsPerFile(), cur.secondsPerFile(); p > 0 && (q-p)/p > reportSecondsPerFileRise {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("seconds per file rose from %.2f to %.2f", p, q))
+	}
+	return c
+}
+
+// compareLatest compares, per task type, the latest two completed runs.
+func compareLatest(runs []IndexRunReport) []runComparison {
+	latest := map[string]*IndexRunReport{}
+	out := []runComparison{}
+	for i := len(runs) - 1; i >= 0; i-- {
+		r := runs[i]
+		if r.Status != tasks.StatusCompleted {
+			continue
+		}
+		cur, seen := latest[r.Type]
+		switch {
+		case !seen:
+			latest[r.Type] = &runs[i]
+		case cur != nil:
+			out = append(out, compareRuns(r, *cur))
+			latest[r.Type] = nil
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Type < out[j].Type })
+	return out
+}
+
+// Reports lists the index runs of each collection, newest first, with a
+// summary and a comparison of the latest two completed runs of each task
+// type. ?collection= and ?type= filter; ?limit= caps the runs listed per
+// collection (default 10).
+func (h *TasksHandler) Reports(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := defaultReportRuns
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxIndexReports {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxIndexReports))
+			return
+		}
+		limit = n
+	}
+	typ := q.Get("type")
+	if typ != "" && !indexTaskTypes[typ] {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("type %s is not an index task type", typ))
+		return
+	}
+
+	collections := h.reports.collections()
+	if c := q.Get("collection"); c != "" {
+		collections = []string{c}
+	}
+	out := make([]map[string]interface{}, 0, len(collections))
+	for _, c := range collections {
+		runs := h.reports.runs(c)
+		if typ != "" {
+			filtered := runs[:0]
+			for _, run := range runs {
+				if run.Type == typ {
+					filtered = append(filtered, run)
+				}
+			}
+			runs = filtered
+		}
+		if len(runs) == 0 {
+			continue
+		}
+		recent := make([]IndexRunReport, 0, limit)
+		for i := len(runs) - 1; i >= 0 && len(recent) < limit; i-- {
+			recent = append(recent, runs[i])
+		}
+		out = append(out, map[string]interface{}{
+			"collection":  c,
+			"summary":     summarizeRuns(runs),
+			"comparisons": compareLatest(runs),
+			"runs":        recent,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"collections": out})
+}
+
+// ClearReports deletes the index run reports of ?collection=, or all of
+// them.
+func (h *TasksHandler) ClearReports(w http.ResponseWriter, r *http.Request) {
+	n, err := h.reports.clear(r.URL.Query().Get("collection"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"cleared": n})
+}
+
+// resultInt parses an integer task result entry, or returns 0.
+func resultInt(result map[string]string, key string) int {
+	n, _ := strconv.Atoi(result[key])
+	return n
+}
+
+func roundSeconds(d time.Duration) float64 {
+	return round3(d.Seconds())
+}
+
+func round3(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
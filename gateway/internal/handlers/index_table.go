@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+)
+
+// Table chunking limits.
+const (
+	// maxTableColumns bounds the columns a table option selects.
+	maxTableColumns = 100
+	// maxTableRowsPerChunk bounds rows_per_chunk.
+	maxTableRowsPerChunk = 1000
+)
+
+// errTableOptionsUnsupported is returned for table options the connected
+// worker would ignore.
+var errTableOptionsUnsupported = errors.New("the connected worker does not support table options; upgrade the worker to use them")
+
+// checkTableOptions normalises and checks o, which may be nil, returning
+// an error naming the offending field.
+func checkTableOptions(gc *grpcclient.Client, o *grpcclient.TableOptions) error {
+	if o == nil {
+		return nil
+	}
+	o.Mode = strings.ToLower(strings.TrimSpace(o.Mode))
+	switch o.Mode {
+	case "":
+		o.Mode = grpcclient.TableModeRows
+	case grpcclient.TableModeRows, grpcclient.TableModeSheet:
+	default:
+		return fmt.Errorf("table.mode must be %s or %s", grpcclient.TableModeRows, grpcclient.TableModeSheet)
+	}
+	if len(o.Columns) > maxTableColumns {
+		return fmt.Errorf("table.columns can select at most %d columns", maxTableColumns)
+	}
+	cols := o.Columns[:0]
+	for _, c := range o.Columns {
+		c = strings.TrimSpace(c)
+		if len(c) > maxPreferenceName {
+			return fmt.Errorf("table.columns entries must be at most %d bytes", maxPreferenceName)
+		}
+		if c != "" {
+			cols = append(cols, c)
+		}
+	}
+	o.Columns = cols
+	if o.RowsPerChunk < 0 || o.RowsPerChunk > maxTableRowsPerChunk {
+		return fmt.Errorf("table.rows_per_chunk must be between 0 and %d", maxTableRowsPerChunk)
+	}
+	if o.RowsPerChunk > 0 && o.Mode != grpcclient.TableModeRows {
+		return fmt.Errorf("table.rows_per_chunk only applies to the %s mode", grpcclient.TableModeRows)
+	}
+	if !gc.Worker().HasFeature(grpcclient.FeatureTableOptions) {
+		return errTableOptionsUnsupported
+	}
+	return nil
+}
+
+// writeTableOptionsError writes the response for an error of
+// checkTableOptions or of the options parsed along with it.
+func writeTableOptionsError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errTableOptionsUnsupported) {
+		writeErrorCode(w, http.StatusNotImplemented, CodeWorkerUnsupported, err.Error())
+		return
+	}
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
+// parseTableFields reads the table options of an upload from its
+// table_mode, table_header, table_columns (comma-separated) and
+// table_rows_per_chunk form fields. It returns nil when none is set.
+func parseTableFields(fields map[string]string) (*grpcclient.TableOptions, error) {
+	mode, header, columns, rows := fields["table_mode"], fields["table_header"], fields["table_columns"], fields["table_rows_per_chunk"]
+	if mode == "" && header == "" && columns == "" && rows == "" {
+		return nil, nil
+	}
+	o := &grpcclient.TableOptions{Mode: mode}
+	if header != "" {
+		h, err := strconv.ParseBool(header)
+		if err != nil {
+			return nil, fmt.Errorf("invalid table_header %q (want true or false)", header)
+		}
+		o.Header = &h
+	}
+	if columns != "" {
+		o.Columns = strings.Split(columns, ",")
+	}
+	if rows != "" {
+		n, err := strconv.Atoi(rows)
+		if err != nil {
+			return nil, fmt.Errorf("invalid table_rows_per_chunk %q", rows)
+		}
+		o.RowsPerChunk = n
+	}
+	return o, nil
+}
+
+// tableParam returns the table options stored in task params, which hold
+// them as decoded JSON once a task has been persisted.
+func tableParam(params map[string]interface{}) *grpcclient.TableOptions {
+	switch v := params["table"].(type) {
+	case *grpcclient.TableOptions:
+		return v
+	case map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var o grpcclient.TableOptions
+		if json.Unmarshal(data, &o) != nil {
+			return nil
+		}
+		return &o
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Keyword search limits. Candidates are the chunks Qdrant's text filter
+// returns; only they are ranked, so the limit trades recall for latency.
+const (
+	keywordMaxTerms      = 16
+	keywordMinCandidates = 500
+	keywordMaxCandidates = 2000
+
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// errNoKeywordTerms is returned for queries without a usable search term.
+var errNoKeywordTerms = errors.New("query has no searchable terms")
+
+// KeywordSearcher ranks chunks by BM25 over their stored text without the
+// worker or Ollama. Qdrant's full-text filter on the "content" payload field
+// selects the candidates; term statistics come from Qdrant point counts.
+type KeywordSearcher struct {
+	baseURL string
+	client  *http.Client
+
+	// auto makes vector search fall back to keyword search when the worker
+	// cannot answer.
+	auto bool
+}
+
+// NewKeywordSearcher creates a KeywordSearcher talking to Qdrant at
+// baseURL through client. With auto set, failed vector searches are answered with keyword
+// results instead of an error.
+func NewKeywordSearcher(baseURL string, client *http.Client, auto bool) *KeywordSearcher {
+	return &KeywordSearcher{
+		baseURL: baseURL,
+		client:  client,
+		auto:    auto,
+	}
+}
+
+// fallbackFor reports whether a vector search error should be answered with
+// keyword results: only when fallback is enabled and the worker or its
+// embedding backend is unreachable or broken, not for bad requests.
+func (k *KeywordSearcher) fallbackFor(err error) bool {
+	if k == nil || !k.auto {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// keywordPayload is the part of a point payload keyword search reads.
+type keywordPayload struct {
+	FilePath    string `json:"file_path"`
+	AbsPath     string `json:"abs_path"`
+	Language    string `json:"language"`
+	Content     string `json:"content"`
+	Caption     string `json:"caption"`
+	ImageType   string `json:"image_type"`
+	Width       int32  `json:"width"`
+	Height      int32  `json:"height"`
+	StartLine   *int   `json:"start_line"`
+	EndLine     *int   `json:"end_line"`
+	ChunkIndex  int    `json:"chunk_index"`
+	TotalChunks *int   `json:"total_chunks"`
+}
+
+// Search returns the topK chunks of collection that best match query,
+// optionally restricted by language and file path like vector search.
+func (k *KeywordSearcher) Search(ctx context.Context, collection, query string, topK int, language, filePath string) ([]*grpcclient.SearchHit, error) {
+	terms := keywordTerms(query)
+	if len(terms) == 0 {
+		return nil, errNoKeywordTerms
+	}
+	if topK <= 0 {
+		topK = 5
+	}
+
+	must := []interface{}{}
+	if language != "" {
+		must = append(must, map[string]interface{}{"key": "language", "match": map[string]interface{}{"value": language}})
+	}
+	if filePath != "" {
+		must = append(must, map[string]interface{}{"key": "file_path", "match": map[string]interface{}{"value": filePath}})
+	}
+	termCond := func(t string) interface{} {
+		return map[string]interface{}{"key": "content", "match": map[string]interface{}{"text": t}}
+	}
+
+	// Corpus size and document frequency of each term, for IDF.
+	total, err := k.count(ctx, collection, map[string]interface{}{"must": must})
+	if err != nil {
+		return nil, err
+	}
+	df := make(map[string]int, len(terms))
+	should := make([]interface{}, 0, len(terms))
+	for _, t := range terms {
+		n, err := k.count(ctx, collection, map[string]interface{}{"must": append(must[:len(must):len(must)], termCond(t))})
+		if err != nil {
+			return nil, err
+		}
+		df[t] = n
+		should = append(should, termCond(t))
+	}
+
+	limit := topK * 50
+	if limit < keywordMinCandidates {
+		limit = keywordMinCandidates
+	}
+	if limit > keywordMaxCandidates {
+		limit = keywordMaxCandidates
+	}
+	var resp struct {
+		Result struct {
+			Points []struct {
+				Payload keywordPayload `json:"payload"`
+			} `json:"points"`
+		} `json:"result"`
+	}
+	err = k.post(ctx, "/collections/"+url.PathEscape(collection)+"/points/scroll", map[string]interface{}{
+		"filter":       map[string]interface{}{"must": must, "should": should},
+		"limit":        limit,
+		"with_payload": true,
+		"with_vector":  false,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		p     *keywordPayload
+		score float64
+	}
+	docs := make([]scored, 0, len(resp.Result.Points))
+	tfs := make([]map[string]int, 0, len(resp.Result.Points))
+	lens := make([]int, 0, len(resp.Result.Points))
+	var totalLen int
+	for i := range resp.Result.Points {
+		p := &resp.Result.Points[i].Payload
+		tf := make(map[string]int)
+		n := 0
+		for _, tok := range tokenize(p.Content + " " + p.Caption) {
+			tf[tok]++
+			n++
+		}
+		totalLen += n
+		docs = append(docs, scored{p: p})
+		tfs = append(tfs, tf)
+		lens = append(lens, n)
+	}
+	if len(docs) == 0 {
+		return []*grpcclient.SearchHit{}, nil
+	}
+	avgLen := float64(totalLen) / float64(len(docs))
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	for i := range docs {
+		dl := float64(lens[i])
+		for _, t := range terms {
+			f := float64(tfs[i][t])
+			if f == 0 {
+				continue
+			}
+			idf := math.Log(1 + (float64(total)-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			docs[i].score += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*dl/avgLen))
+		}
+	}
+	sort.SliceStable(docs, func(i, j int) bool { return docs[i].score > docs[j].score })
+	if len(docs) > topK {
+		docs = docs[:topK]
+	}
+
+	hits := make([]*grpcclient.SearchHit, 0, len(docs))
+	for _, d := range docs {
+		if d.score <= 0 {
+			break
+		}
+		hits = append(hits, keywordHit(d.p, float32(d.score)))
+	}
+	return hits, nil
+}
+
+// keywordHit formats a payload the way the worker formats vector hits.
+func keywordHit(p *keywordPayload, score float32) *grpcclient.SearchHit {
+	optional := func(v *int) string {
+		if v == nil {
+			return "?"
+		}
+		return fmt.Sprint(*v)
+	}
+	hit := &grpcclient.SearchHit{
+		Score:     score,
+		FilePath:  p.FilePath,
+		Language:  p.Language,
+		Lines:     optional(p.StartLine) + "-" + optional(p.EndLine),
+		ChunkInfo: fmt.Sprintf("%d/%s", p.ChunkIndex+1, optional(p.TotalChunks)),
+		Content:   p.Content,
+	}
+	if p.Language == "image" {
+		hit.AbsPath = p.AbsPath
+		hit.Caption = p.Caption
+		hit.ImageType = p.ImageType
+		hit.Width = p.Width
+		hit.Height = p.Height
+	}
+	return hit
+}
+
+// contentTextIndex is the schema of the full-text payload index on
+// "content" that keyword search filters with. Collections get it when they
+// are created; without it Qdrant still answers text filters, by substring
+// match over every point.
+var contentTextIndex = map[string]interface{}{
+	"type":          "text",
+	"tokenizer":     "word",
+	"lowercase":     true,
+	"min_token_len": 2,
+	"max_token_len": 40,
+}
+
+// count returns the approximate number of points matching filter.
+func (k *KeywordSearcher) count(ctx context.Context, collection string, filter map[string]interface{}) (int, error) {
+	var resp struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	err := k.post(ctx, "/collections/"+url.PathEscape(collection)+"/points/count", map[string]interface{}{
+		"filter": filter,
+		"exact":  false,
+	}, &resp)
+	return resp.Result.Count, err
+}
+
+func (k *KeywordSearcher) post(ctx context.Context, path string, body, out interface{}) error {
+	data, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, "POST", k.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errCollectionNotFound
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: status %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// errCollectionNotFound is returned by keyword search for unknown
+// collections.
+var errCollectionNotFound = errors.New("collection not found")
+
+// tokenize lowercases s and splits it into letter and digit runs of at
+// least two characters, matching the Qdrant text index settings.
+func tokenize(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if n := len([]rune(f)); n >= 2 && n <= 40 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// keywordTerms returns the distinct tokens of a query, at most
+// keywordMaxTerms of them.
+func keywordTerms(query string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, t := range tokenize(query) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+		if len(out) == keywordMaxTerms {
+			break
+		}
+	}
+	return out
+}
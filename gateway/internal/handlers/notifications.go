@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/alfagnish/ollqd-gateway/internal/notify"
+	"github.com/go-chi/chi/v5"
+)
+
+// NotificationsHandler manages task notification settings. All of its
+// routes are admin-only since the settings hold SMTP and Slack credentials.
+type NotificationsHandler struct {
+	notifier *notify.Notifier
+}
+
+// NewNotificationsHandler creates a new NotificationsHandler.
+func NewNotificationsHandler(n *notify.Notifier) *NotificationsHandler {
+	return &NotificationsHandler{notifier: n}
+}
+
+// Routes registers notification routes on the given chi router.
+func (h *NotificationsHandler) Routes(r chi.Router) {
+	r.Get("/", h.Get)
+	r.Put("/", h.Update)
+	r.Post("/test", h.Test)
+}
+
+// Get returns the settings with secrets masked, plus the last delivery
+// outcome per channel.
+func (h *NotificationsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"settings": h.notifier.Settings(false),
+		"status":   h.notifier.Status(),
+		"defaults": map[string]string{
+			"subject": notify.DefaultSubject,
+			"body":    notify.DefaultBody,
+		},
+	})
+}
+
+// Update replaces the settings. Masked secrets ("********") keep their
+// current values.
+func (h *NotificationsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req notify.Settings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	warnings, err := h.notifier.Update(req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"settings": h.notifier.Settings(false),
+		"warnings": warnings,
+	})
+}
+
+// Test sends a sample notification on one channel with the current
+// settings, rendered with the rule for task_type.
+func (h *NotificationsHandler) Test(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Channel  string `json:"channel"`
+		TaskType string `json:"task_type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Channel != notify.ChannelEmail && req.Channel != notify.ChannelSlack {
+		writeError(w, http.StatusBadRequest, "channel must be email or slack")
+		return
+	}
+
+	if err := h.notifier.Test(req.Channel, req.TaskType); err != nil {
+		writeError(w, http.StatusBadGateway, "notification failed: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sent": true, "channel": req.Channel})
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/google/uuid"
+)
+
+const (
+	maxOCRLanguages = 10
+	minOCRDPI       = 72
+	maxOCRDPI       = 600
+)
+
+// ocrLanguageRe matches OCR engine language codes such as "en", "eng",
+// "ch_sim" or "sr-Latn".
+var ocrLanguageRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+-]{0,19}$`)
+
+// ocrTestExtensions are the files the OCR test accepts: PDFs and the
+// raster image formats Docling reads.
+var ocrTestExtensions = map[string]bool{
+	".pdf":  true,
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".tif":  true,
+	".tiff": true,
+	".bmp":  true,
+	".gif":  true,
+}
+
+// ocrConfigRequest is the body of PUT /config/ocr. Absent fields keep
+// their current value.
+type ocrConfigRequest struct {
+	Languages *[]string `json:"languages,omitempty"`
+	DPI       *int      `json:"dpi,omitempty"`
+	ForceOCR  *bool     `json:"force_ocr,omitempty"`
+}
+
+// ocrConfig is the response of GET and PUT /config/ocr.
+type ocrConfig struct {
+	Engine           string   `json:"engine"`
+	OCREnabled       bool     `json:"ocr_enabled"`
+	Languages        []string `json:"languages"`
+	DPI              int32    `json:"dpi"`
+	ForceOCR         bool     `json:"force_ocr"`
+	DoclingAvailable bool     `json:"docling_available"`
+}
+
+func newOCRConfig(c *grpcclient.OCRConfig) ocrConfig {
+	return ocrConfig{
+		Engine:           c.GetEngine(),
+		OCREnabled:       c.GetOcrEnabled(),
+		Languages:        nonNil(c.GetLanguages()),
+		DPI:              c.GetDpi(),
+		ForceOCR:         c.GetForceOcr(),
+		DoclingAvailable: c.GetDoclingAvailable(),
+	}
+}
+
+// ocrTestResult is the response of POST /config/ocr/test.
+type ocrTestResult struct {
+	Filename  string   `json:"filename"`
+	Size      int64    `json:"size"`
+	Page      int32    `json:"page"`
+	Pages     int32    `json:"pages"`
+	Text      string   `json:"text"`
+	Chars     int32    `json:"chars"`
+	TookMS    int64    `json:"took_ms"`
+	Engine    string   `json:"engine"`
+	Languages []string `json:"languages"`
+	DPI       int32    `json:"dpi"`
+}
+
+// nonNil returns list, or an empty list for nil, so it encodes as [].
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// ocrLanguages trims langs and drops empty entries, or returns an error for
+// a malformed code or too many languages.
+func ocrLanguages(langs []string) (*grpcclient.OCRLanguages, error) {
+	out := make([]string, 0, len(langs))
+	for _, l := range langs {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if !ocrLanguageRe.MatchString(l) {
+			return nil, fmt.Errorf("invalid language code %q", l)
+		}
+		out = append(out, l)
+	}
+	if len(out) > maxOCRLanguages {
+		return nil, fmt.Errorf("at most %d languages are allowed", maxOCRLanguages)
+	}
+	return &grpcclient.OCRLanguages{Codes: out}, nil
+}
+
+func validateOCRDPI(dpi int) error {
+	if dpi != 0 && (dpi < minOCRDPI || dpi > maxOCRDPI) {
+		return fmt.Errorf("dpi must be 0 (Docling's default) or between %d and %d", minOCRDPI, maxOCRDPI)
+	}
+	return nil
+}
+
+// GetOCRConfig returns the OCR engine, languages, DPI and force-OCR flag
+// the worker's Docling conversion uses.
+func (h *SystemHandler) GetOCRConfig(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.grpc.OCR.GetOCRConfig(r.Context(), &grpcclient.GetOCRConfigRequest{})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newOCRConfig(resp))
+}
+
+// UpdateOCRConfig updates the OCR languages, DPI and force-OCR flag. The
+// worker persists them with its Docling settings, so resetting the docling
+// section resets them too.
+func (h *SystemHandler) UpdateOCRConfig(w http.ResponseWriter, r *http.Request) {
+	var req ocrConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if req.Languages == nil && req.DPI == nil && req.ForceOCR == nil {
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation, "at least one of languages, dpi or force_ocr is required")
+		return
+	}
+	in := &grpcclient.UpdateOCRConfigRequest{ForceOcr: req.ForceOCR}
+	if req.Languages != nil {
+		langs, err := ocrLanguages(*req.Languages)
+		if err != nil {
+			writeErrorCode(w, http.StatusBadRequest, CodeValidation, err.Error())
+			return
+		}
+		in.Languages = langs
+	}
+	if req.DPI != nil {
+		if err := validateOCRDPI(*req.DPI); err != nil {
+			writeErrorCode(w, http.StatusBadRequest, CodeValidation, err.Error())
+			return
+		}
+		dpi := int32(*req.DPI)
+		in.Dpi = &dpi
+	}
+
+	resp, err := h.grpc.OCR.UpdateOCRConfig(r.Context(), in)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newOCRConfig(resp))
+}
+
+// TestOCR runs OCR on one page of an uploaded PDF or image and returns the
+// recognised text, so OCR settings can be checked before a large index
+// run. Optional form fields: page (1-based, default 1), languages
+// (comma-separated) and dpi, which override the saved settings for this
+// run only. The page is always OCRed in full; the staged file is removed
+// before returning.
+func (h *SystemHandler) TestOCR(w http.ResponseWriter, r *http.Request) {
+	maxBytes := h.cfg.MaxUploadSizeMB << 20
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("upload exceeds maximum size of %d MB", h.cfg.MaxUploadSizeMB))
+		return
+	}
+
+	files := r.MultipartForm.File["file"]
+	if len(files) != 1 {
+		writeError(w, http.StatusBadRequest, "exactly one file is required in the 'file' field")
+		return
+	}
+	fh := files[0]
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	if !ocrTestExtensions[ext] {
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation, "OCR test needs a PDF or image file")
+		return
+	}
+
+	req := &grpcclient.TestOCRRequest{Page: 1}
+	if raw := r.FormValue("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			writeErrorCode(w, http.StatusBadRequest, CodeValidation, "page must be an integer >= 1")
+			return
+		}
+		req.Page = int32(page)
+	}
+	if raw := r.FormValue("languages"); raw != "" {
+		langs, err := ocrLanguages(strings.Split(raw, ","))
+		if err != nil {
+			writeErrorCode(w, http.StatusBadRequest, CodeValidation, err.Error())
+			return
+		}
+		req.Languages = langs
+	}
+	if raw := r.FormValue("dpi"); raw != "" {
+		dpi, err := strconv.Atoi(raw)
+		if err != nil {
+			err = fmt.Errorf("dpi must be an integer")
+		} else {
+			err = validateOCRDPI(dpi)
+		}
+		if err != nil {
+			writeErrorCode(w, http.StatusBadRequest, CodeValidation, err.Error())
+			return
+		}
+		dpi32 := int32(dpi)
+		req.Dpi = &dpi32
+	}
+
+	dir := filepath.Join(h.cfg.UploadDir, previewDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create upload directory")
+		return
+	}
+	destPath := filepath.Join(dir, uuid.New().String()+ext)
+	if err := saveMultipartFile(fh, destPath); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save uploaded file")
+		return
+	}
+	defer os.Remove(destPath)
+	req.Path = destPath
+
+	out, err := h.grpc.OCR.TestOCR(r.Context(), req)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ocrTestResult{
+		Filename:  fh.Filename,
+		Size:      fh.Size,
+		Page:      out.GetPage(),
+		Pages:     out.GetPages(),
+		Text:      out.GetText(),
+		Chars:     out.GetChars(),
+		TookMS:    out.GetTookMs(),
+		Engine:    out.GetEngine(),
+		Languages: nonNil(out.GetLanguages()),
+		DPI:       out.GetDpi(),
+	})
+}
@@ -2,51 +2,116 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/alfagnish/ollqd-gateway/internal/docker"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/proxy"
 	"github.com/go-chi/chi/v5"
 )
 
 // OllamaHandler provides both a reverse proxy for raw Ollama API access and
 // dedicated REST handlers that translate clean URLs to Ollama's actual API.
+//
+// Every route accepts ?instance=<name> to talk to one of the registered
+// Ollama instances instead of the default one at OLLAMA_URL.
 type OllamaHandler struct {
-	proxy   *httputil.ReverseProxy
-	baseURL string
-	client  *http.Client
+	proxy     *httputil.ReverseProxy
+	instances *OllamaInstances
+	clients   *OllamaClients
+	grpc      *grpcclient.Client
+	pulls     *pullManager
+	events    *EventBus
+	releases  *OllamaReleases
+	docker    *docker.Manager
 }
 
 // NewOllamaHandler wraps an existing Ollama reverse proxy and adds
-// dedicated model-management handlers.
-func NewOllamaHandler(proxy *httputil.ReverseProxy, baseURL string) *OllamaHandler {
+// dedicated model-management handlers, which reach Ollama through clients.
+// The gRPC client is used to look up the worker's embedding model; pulls
+// configures the model pull queue. Pulled, copied and deleted models are
+// announced on events. releases (nil = no update check) and dm serve the
+// version endpoint.
+func NewOllamaHandler(ollamaProxy *httputil.ReverseProxy, instances *OllamaInstances, clients *OllamaClients, gc *grpcclient.Client, events *EventBus, pulls PullOptions, releases *OllamaReleases, dm *docker.Manager) *OllamaHandler {
 	return &OllamaHandler{
-		proxy:   proxy,
-		baseURL: baseURL,
-		client:  &http.Client{Timeout: 0}, // no timeout for streaming (pull)
+		proxy:     ollamaProxy,
+		instances: instances,
+		clients:   clients,
+		grpc:      gc,
+		pulls:     newPullManager(clients.Stream, pulls, events),
+		events:    events,
+		releases:  releases,
+		docker:    dm,
+	}
+}
+
+// OllamaClients are the HTTP clients for requests to Ollama instances. They
+// share one pooled transport, so keep-alive connections are reused.
+type OllamaClients struct {
+	// Stream has no overall timeout. It serves pulls, progress streams and
+	// calls that bound themselves with their context.
+	Stream *http.Client
+	// API bounds quick API calls such as listing, copying or deleting
+	// models.
+	API *http.Client
+}
+
+// NewOllamaClients creates the Ollama clients on transport; timeout bounds
+// the API client (0 = none).
+func NewOllamaClients(transport http.RoundTripper, timeout time.Duration) *OllamaClients {
+	return &OllamaClients{
+		Stream: &http.Client{Transport: transport},
+		API:    &http.Client{Transport: transport, Timeout: timeout},
 	}
 }
 
 // Routes registers model-management routes and the catch-all proxy.
 // Specific routes are matched first; everything else falls through to the proxy.
 func (h *OllamaHandler) Routes(r chi.Router) {
+	r.Route("/instances", h.instanceRoutes)
 	r.Get("/models", h.ListModels)
 	r.Get("/ps", h.RunningModels)
 	r.Post("/models/show", h.ShowModel)
 	r.Post("/models/pull", h.PullModel)
+	r.Get("/models/pulls", h.ListPulls)
+	r.Delete("/models/pulls/{name}", h.CancelPull)
+	r.Post("/models/pulls/{name}/resume", h.ResumePull)
+	r.Get("/registry", h.Registry)
+	r.Get("/version", h.Version)
+	r.Post("/models/copy", h.CopyModel)
+	r.Post("/models/create", h.CreateModel)
 	r.Delete("/models/{name}", h.DeleteModel)
+	r.Post("/embeddings", h.Embeddings)
 	r.HandleFunc("/*", func(w http.ResponseWriter, r *http.Request) {
-		h.proxy.ServeHTTP(w, r)
+		inst, ok := h.instance(w, r)
+		if !ok {
+			return
+		}
+		target, err := url.Parse(inst.URL)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		h.proxy.ServeHTTP(w, r.WithContext(proxy.WithOllamaTarget(r.Context(), target)))
 	})
 }
 
 // ListModels translates GET /api/ollama/models → GET /api/tags on Ollama.
 func (h *OllamaHandler) ListModels(w http.ResponseWriter, r *http.Request) {
-	resp, err := h.client.Get(h.baseURL + "/api/tags")
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	resp, err := h.clients.API.Get(inst.URL + "/api/tags")
 	if err != nil {
 		writeError(w, http.StatusBadGateway, fmt.Sprintf("ollama error: %v", err))
 		return
@@ -59,7 +124,11 @@ func (h *OllamaHandler) ListModels(w http.ResponseWriter, r *http.Request) {
 
 // RunningModels translates GET /api/ollama/ps → GET /api/ps on Ollama.
 func (h *OllamaHandler) RunningModels(w http.ResponseWriter, r *http.Request) {
-	resp, err := h.client.Get(h.baseURL + "/api/ps")
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	resp, err := h.clients.API.Get(inst.URL + "/api/ps")
 	if err != nil {
 		writeError(w, http.StatusBadGateway, fmt.Sprintf("ollama error: %v", err))
 		return
@@ -72,7 +141,11 @@ func (h *OllamaHandler) RunningModels(w http.ResponseWriter, r *http.Request) {
 
 // ShowModel translates POST /api/ollama/models/show → POST /api/show on Ollama.
 func (h *OllamaHandler) ShowModel(w http.ResponseWriter, r *http.Request) {
-	resp, err := h.client.Post(h.baseURL+"/api/show", "application/json", r.Body)
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	resp, err := h.clients.API.Post(inst.URL+"/api/show", "application/json", r.Body)
 	if err != nil {
 		writeError(w, http.StatusBadGateway, fmt.Sprintf("ollama error: %v", err))
 		return
@@ -83,25 +156,131 @@ func (h *OllamaHandler) ShowModel(w http.ResponseWriter, r *http.Request) {
 	io.Copy(w, resp.Body)
 }
 
-// PullModel translates POST /api/ollama/models/pull → POST /api/pull on Ollama.
-// The response is streamed back as SSE for progress tracking.
-func (h *OllamaHandler) PullModel(w http.ResponseWriter, r *http.Request) {
-	// Read and wrap the request body — Ollama expects {"name": "..."} with
-	// optional "stream": true. We forward the body as-is.
-	body, err := io.ReadAll(r.Body)
+// CopyModel translates POST /api/ollama/models/copy {source, destination} →
+// POST /api/copy on Ollama.
+func (h *OllamaHandler) CopyModel(w http.ResponseWriter, r *http.Request) {
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Source == "" || req.Destination == "" {
+		writeError(w, http.StatusBadRequest, "source and destination are required")
+		return
+	}
+
+	body, _ := json.Marshal(req)
+	httpReq, err := http.NewRequestWithContext(r.Context(), "POST", inst.URL+"/api/copy", bytes.NewReader(body))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "failed to read body")
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	httpReq.Header.Set("Content-Type", "application/json")
 
-	req, err := http.NewRequestWithContext(r.Context(), "POST", h.baseURL+"/api/pull", bytes.NewReader(body))
+	resp, err := h.clients.API.Do(httpReq)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("ollama error: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	// Ollama replies 200 with an empty body on success.
+	if resp.StatusCode == http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		h.events.Publish(EventModelChanged, middleware.UsernameFromContext(r.Context()), map[string]string{
+			"action": "copied", "model": req.Destination, "source": req.Source, "instance": inst.Name,
+		})
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status":      "copied",
+			"source":      req.Source,
+			"destination": req.Destination,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// CreateModel translates POST /api/ollama/models/create → POST /api/create on
+// Ollama. The body names the new model and carries either a raw Modelfile or
+// the structured from/system/parameters fields. Progress is streamed back as
+// SSE.
+func (h *OllamaHandler) CreateModel(w http.ResponseWriter, r *http.Request) {
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name       string                 `json:"name"`
+		Model      string                 `json:"model"`
+		Modelfile  string                 `json:"modelfile"`
+		From       string                 `json:"from"`
+		System     string                 `json:"system"`
+		Template   string                 `json:"template"`
+		Parameters map[string]interface{} `json:"parameters"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Model == "" {
+		req.Model = req.Name
+	}
+	if req.Model == "" {
+		writeError(w, http.StatusBadRequest, "model name is required")
+		return
+	}
+	if req.Modelfile == "" && req.From == "" {
+		writeError(w, http.StatusBadRequest, "either modelfile or from is required")
+		return
+	}
+
+	payload := map[string]interface{}{
+		"model":  req.Model,
+		"name":   req.Model, // older Ollama releases still read "name"
+		"stream": true,
+	}
+	if req.Modelfile != "" {
+		payload["modelfile"] = req.Modelfile
+	}
+	if req.From != "" {
+		payload["from"] = req.From
+	}
+	if req.System != "" {
+		payload["system"] = req.System
+	}
+	if req.Template != "" {
+		payload["template"] = req.Template
+	}
+	if len(req.Parameters) > 0 {
+		payload["parameters"] = req.Parameters
+	}
+
+	body, _ := json.Marshal(payload)
+	h.streamAsSSE(w, r, inst.URL+"/api/create", body)
+}
+
+// streamAsSSE POSTs body to the given Ollama URL and relays the
+// newline-delimited JSON progress stream to the client as SSE events,
+// terminated by a [DONE] marker.
+func (h *OllamaHandler) streamAsSSE(w http.ResponseWriter, r *http.Request, target string, body []byte) {
+	req, err := http.NewRequestWithContext(r.Context(), "POST", target, bytes.NewReader(body))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := h.client.Do(req)
+	resp, err := h.clients.Stream.Do(req)
 	if err != nil {
 		writeError(w, http.StatusBadGateway, fmt.Sprintf("ollama error: %v", err))
 		return
@@ -109,6 +288,7 @@ func (h *OllamaHandler) PullModel(w http.ResponseWriter, r *http.Request) {
 	defer resp.Body.Close()
 
 	// Stream the response as SSE events.
+	defer activeStreams.track("ollama_chat_sse")()
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
@@ -146,7 +326,9 @@ func (h *OllamaHandler) PullModel(w http.ResponseWriter, r *http.Request) {
 }
 
 // DeleteModel translates DELETE /api/ollama/models/{name} → DELETE /api/delete
-// on Ollama with body {"name": "..."}.
+// on Ollama with body {"name": "..."}. Models the worker is configured to use
+// are refused with 409 unless ?force=true; if the worker cannot be asked, the
+// delete is refused with 503 instead.
 func (h *OllamaHandler) DeleteModel(w http.ResponseWriter, r *http.Request) {
 	rawName := chi.URLParam(r, "name")
 	if rawName == "" {
@@ -154,23 +336,95 @@ func (h *OllamaHandler) DeleteModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	name, _ := url.PathUnescape(rawName)
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("force") != "true" {
+		features, err := h.modelDependents(r, name)
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable,
+				fmt.Sprintf("cannot check whether %s is in use: %v; pass ?force=true to delete anyway", name, err))
+			return
+		}
+		if len(features) > 0 {
+			writeError(w, http.StatusConflict,
+				fmt.Sprintf("model %s is in use by the worker for %s; pass ?force=true to delete anyway",
+					name, strings.Join(features, ", ")))
+			return
+		}
+	}
 
 	body, _ := json.Marshal(map[string]string{"name": name})
-	req, err := http.NewRequestWithContext(r.Context(), "DELETE", h.baseURL+"/api/delete", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(r.Context(), "DELETE", inst.URL+"/api/delete", bytes.NewReader(body))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	resp, err := h.clients.API.Do(req)
 	if err != nil {
 		writeError(w, http.StatusBadGateway, fmt.Sprintf("ollama error: %v", err))
 		return
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusOK {
+		h.events.Publish(EventModelChanged, middleware.UsernameFromContext(r.Context()), map[string]string{
+			"action": "deleted", "model": name, "instance": inst.Name,
+		})
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(resp.StatusCode)
 	io.Copy(w, resp.Body)
 }
+
+// modelDependents returns the worker features that depend on model, from the
+// worker's Ollama config and its live embedding model.
+func (h *OllamaHandler) modelDependents(r *http.Request, model string) ([]string, error) {
+	if h.grpc == nil || h.grpc.Config == nil {
+		return nil, fmt.Errorf("worker not available")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), embedInfoTimeout)
+	defer cancel()
+	cfg, err := h.grpc.Config.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	embed := cfg.GetOllama().GetEmbedModel()
+	// The embedding model can be switched at runtime, ahead of the cached
+	// config.
+	if h.grpc.Embedding != nil {
+		if info, err := h.grpc.Embedding.GetInfo(ctx); err == nil && info.Model != "" {
+			embed = info.Model
+		}
+	}
+
+	want := normalizeModelName(model)
+	var features []string
+	if normalizeModelName(embed) == want {
+		features = append(features, "embedding (search, indexing, RAG chat)")
+	}
+	if normalizeModelName(cfg.GetOllama().GetChatModel()) == want {
+		features = append(features, "chat")
+	}
+	if normalizeModelName(cfg.GetOllama().GetVisionModel()) == want {
+		features = append(features, "vision (image captioning)")
+	}
+	return features, nil
+}
+
+// normalizeModelName lowercases an Ollama model name and adds the implicit
+// ":latest" tag, so "llama3" and "llama3:latest" compare equal.
+func normalizeModelName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+	if !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		name += ":latest"
+	}
+	return name
+}
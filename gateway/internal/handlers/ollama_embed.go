@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultEmbedBatchSize is how many texts go into one /api/embed call.
+	defaultEmbedBatchSize = 32
+	maxEmbedBatchSize     = 256
+	// maxEmbedTexts bounds a single embeddings request.
+	maxEmbedTexts = 4096
+	// embedInfoTimeout bounds the lookup of the worker's embedding model.
+	embedInfoTimeout = 15 * time.Second
+	// embedBatchTimeout bounds one /api/embed call, which loads the model
+	// on first use.
+	embedBatchTimeout = 5 * time.Minute
+)
+
+// Embeddings handles POST /api/ollama/embeddings {texts, model, batch_size}.
+// Texts are sent to Ollama's /api/embed in batches. Without a model
+// override the worker's current embedding model is used, so vectors match
+// the ones stored in the collections.
+func (h *OllamaHandler) Embeddings(w http.ResponseWriter, r *http.Request) {
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Texts     []string `json:"texts"`
+		Model     string   `json:"model"`
+		BatchSize int      `json:"batch_size"`
+		Truncate  *bool    `json:"truncate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if len(req.Texts) == 0 {
+		writeError(w, http.StatusBadRequest, "texts is required")
+		return
+	}
+	if len(req.Texts) > maxEmbedTexts {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d texts per request", maxEmbedTexts))
+		return
+	}
+	if req.BatchSize <= 0 {
+		req.BatchSize = defaultEmbedBatchSize
+	}
+	if req.BatchSize > maxEmbedBatchSize {
+		req.BatchSize = maxEmbedBatchSize
+	}
+
+	if req.Model == "" {
+		if h.grpc == nil || h.grpc.Embedding == nil {
+			writeError(w, http.StatusBadRequest, "model is required when the embedding service is unavailable")
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), embedInfoTimeout)
+		info, err := h.grpc.Embedding.GetInfo(ctx)
+		cancel()
+		if err != nil {
+			writeGRPCError(w, err)
+			return
+		}
+		req.Model = info.Model
+	}
+
+	embeddings := make([][]float64, 0, len(req.Texts))
+	for start := 0; start < len(req.Texts); start += req.BatchSize {
+		end := start + req.BatchSize
+		if end > len(req.Texts) {
+			end = len(req.Texts)
+		}
+		vecs, status, err := h.embedBatch(r.Context(), inst.URL, req.Model, req.Texts[start:end], req.Truncate)
+		if err != nil {
+			writeError(w, status, err.Error())
+			return
+		}
+		if len(vecs) != end-start {
+			writeError(w, http.StatusBadGateway,
+				fmt.Sprintf("ollama returned %d embeddings for %d texts", len(vecs), end-start))
+			return
+		}
+		embeddings = append(embeddings, vecs...)
+	}
+
+	dimension := 0
+	if len(embeddings) > 0 {
+		dimension = len(embeddings[0])
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"model":      req.Model,
+		"dimension":  dimension,
+		"count":      len(embeddings),
+		"embeddings": embeddings,
+	})
+}
+
+// embedBatch calls /api/embed on the Ollama at baseURL for one batch. On failure it returns
+// the HTTP status to report.
+func (h *OllamaHandler) embedBatch(ctx context.Context, baseURL, model string, texts []string, truncate *bool) ([][]float64, int, error) {
+	payload := map[string]interface{}{
+		"model": model,
+		"input": texts,
+	}
+	if truncate != nil {
+		payload["truncate"] = *truncate
+	}
+	body, _ := json.Marshal(payload)
+
+	ctx, cancel := context.WithTimeout(ctx, embedBatchTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.clients.Stream.Do(httpReq)
+	if err != nil {
+		return nil, http.StatusBadGateway, fmt.Errorf("ollama error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var oe struct {
+			Error string `json:"error"`
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(raw, &oe) != nil || oe.Error == "" {
+			oe.Error = string(raw)
+		}
+		status := http.StatusBadGateway
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+			status = resp.StatusCode
+		}
+		return nil, status, fmt.Errorf("ollama error: %s", oe.Error)
+	}
+
+	var out struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, http.StatusBadGateway, fmt.Errorf("failed to parse ollama response")
+	}
+	return out.Embeddings, http.StatusOK, nil
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/proxy"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// ollamaInstancesDoc is the store document holding the extra Ollama
+// instances.
+const ollamaInstancesDoc = "ollama-instances"
+
+const (
+	// DefaultOllamaInstance names the instance at OLLAMA_URL. It is always
+	// present and cannot be changed through the API.
+	DefaultOllamaInstance = "default"
+	// maxOllamaInstances bounds the extra instances.
+	maxOllamaInstances = 32
+	// maxInstanceLabel bounds an instance label, in bytes.
+	maxInstanceLabel = 80
+)
+
+var ollamaInstanceName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// OllamaInstance is an Ollama server the gateway can send requests to.
+type OllamaInstance struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Label is a human-readable description such as "GPU box".
+	Label     string     `json:"label,omitempty"`
+	Default   bool       `json:"default,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// validate normalises and checks inst, returning an error naming the
+// offending field.
+func (inst *OllamaInstance) validate() error {
+	inst.Name = strings.TrimSpace(inst.Name)
+	inst.URL = strings.TrimRight(strings.TrimSpace(inst.URL), "/")
+	inst.Label = strings.TrimSpace(inst.Label)
+	if !ollamaInstanceName.MatchString(inst.Name) {
+		return fmt.Errorf("name must be 1-32 lowercase letters, digits, '-' or '_'")
+	}
+	if inst.Name == DefaultOllamaInstance {
+		return fmt.Errorf("%q is the instance at OLLAMA_URL and cannot be changed", DefaultOllamaInstance)
+	}
+	u, err := url.Parse(inst.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http or https URL such as http://gpu-box:11434")
+	}
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("url must not carry credentials, a query or a fragment")
+	}
+	if len(inst.Label) > maxInstanceLabel {
+		return fmt.Errorf("label must be at most %d bytes", maxInstanceLabel)
+	}
+	return nil
+}
+
+// OllamaInstances is the registry of Ollama instances: the one at
+// OLLAMA_URL, named "default", plus any added by an admin, persisted in the
+// gateway store.
+type OllamaInstances struct {
+	defaultURL string
+
+	mu    sync.RWMutex
+	store *store.Store
+	data  struct {
+		Instances map[string]OllamaInstance `json:"instances"`
+	}
+}
+
+// NewOllamaInstances loads the extra instances from st; defaultURL is
+// OLLAMA_URL.
+func NewOllamaInstances(st *store.Store, defaultURL string) *OllamaInstances {
+	o := &OllamaInstances{store: st, defaultURL: strings.TrimRight(defaultURL, "/")}
+	if _, err := st.Load(ollamaInstancesDoc, &o.data); err != nil {
+		log.Printf("WARNING: ollama instances: %v", err)
+	}
+	if o.data.Instances == nil {
+		o.data.Instances = make(map[string]OllamaInstance)
+	}
+	return o
+}
+
+// defaultInstance returns the instance at OLLAMA_URL.
+func (o *OllamaInstances) defaultInstance() OllamaInstance {
+	return OllamaInstance{Name: DefaultOllamaInstance, URL: o.defaultURL, Label: "OLLAMA_URL", Default: true}
+}
+
+// List returns the default instance followed by the others sorted by name.
+func (o *OllamaInstances) List() []OllamaInstance {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	out := make([]OllamaInstance, 0, len(o.data.Instances)+1)
+	for _, inst := range o.data.Instances {
+		out = append(out, inst)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return append([]OllamaInstance{o.defaultInstance()}, out...)
+}
+
+// Resolve returns the instance called name; an empty name selects the
+// default instance.
+func (o *OllamaInstances) Resolve(name string) (OllamaInstance, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == DefaultOllamaInstance {
+		return o.defaultInstance(), nil
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	inst, ok := o.data.Instances[name]
+	if !ok {
+		return OllamaInstance{}, fmt.Errorf("unknown ollama instance %q", name)
+	}
+	return inst, nil
+}
+
+// Put validates and stores inst, replacing any instance with the same name.
+func (o *OllamaInstances) Put(inst OllamaInstance) (OllamaInstance, error) {
+	if err := inst.validate(); err != nil {
+		return inst, err
+	}
+	inst.Default = false
+	now := time.Now().UTC()
+	inst.UpdatedAt = &now
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.data.Instances[inst.Name]; !ok && len(o.data.Instances) >= maxOllamaInstances {
+		return inst, fmt.Errorf("at most %d ollama instances can be added", maxOllamaInstances)
+	}
+	o.data.Instances[inst.Name] = inst
+	return inst, o.store.Save(ollamaInstancesDoc, o.data)
+}
+
+// Delete removes an instance, reporting whether it existed.
+func (o *OllamaInstances) Delete(name string) (bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.data.Instances[name]; !ok {
+		return false, nil
+	}
+	delete(o.data.Instances, name)
+	return true, o.store.Save(ollamaInstancesDoc, o.data)
+}
+
+// pin resolves name for a chat or index request that pins an instance and
+// attaches its URL to ctx for the worker. An empty name leaves the worker on
+// its own Ollama URL.
+func (o *OllamaInstances) pin(ctx context.Context, name string) (context.Context, error) {
+	if name == "" || o == nil {
+		return ctx, nil
+	}
+	inst, err := o.Resolve(name)
+	if err != nil {
+		return ctx, err
+	}
+	if inst.Default {
+		return ctx, nil
+	}
+	return grpcclient.WithOllamaURL(ctx, inst.URL), nil
+}
+
+// instance resolves the ?instance= selector of r, writing a 400 and
+// returning false if it names no instance.
+func (h *OllamaHandler) instance(w http.ResponseWriter, r *http.Request) (OllamaInstance, bool) {
+	inst, err := h.instances.Resolve(r.URL.Query().Get(proxy.InstanceParam))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return inst, false
+	}
+	return inst, true
+}
+
+// instanceRoutes registers the instance registry routes. Listing is open
+// to every user so the UI can offer the instances; changes are admin-only.
+func (h *OllamaHandler) instanceRoutes(r chi.Router) {
+	r.Get("/", h.ListInstances)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireAdmin)
+		r.Post("/", h.PutInstance)
+		r.Put("/{name}", h.PutInstance)
+		r.Delete("/{name}", h.DeleteInstance)
+	})
+}
+
+// ListInstances returns the registered Ollama instances, the default first.
+func (h *OllamaHandler) ListInstances(w http.ResponseWriter, r *http.Request) {
+	instances := h.instances.List()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"instances": instances,
+		"count":     len(instances),
+	})
+}
+
+// PutInstance adds an instance (POST) or replaces the one named in the path
+// (PUT).
+func (h *OllamaHandler) PutInstance(w http.ResponseWriter, r *http.Request) {
+	var inst OllamaInstance
+	if err := json.NewDecoder(r.Body).Decode(&inst); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if name := chi.URLParam(r, "name"); name != "" {
+		if inst.Name != "" && inst.Name != name {
+			writeError(w, http.StatusBadRequest, "name in body does not match the path")
+			return
+		}
+		inst.Name = name
+	} else if _, err := h.instances.Resolve(inst.Name); err == nil && inst.Name != "" {
+		writeError(w, http.StatusConflict, fmt.Sprintf("ollama instance %s already exists", inst.Name))
+		return
+	}
+	saved, err := h.instances.Put(inst)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, saved)
+}
+
+// DeleteInstance removes an instance. Pulls already running on it finish.
+func (h *OllamaHandler) DeleteInstance(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if name == DefaultOllamaInstance {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%q is the instance at OLLAMA_URL and cannot be deleted", DefaultOllamaInstance))
+		return
+	}
+	found, err := h.instances.Delete(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("ollama instance %s not found", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": name})
+}
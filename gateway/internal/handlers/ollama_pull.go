@@ -0,0 +1,622 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/proxy"
+	"github.com/go-chi/chi/v5"
+)
+
+// Pull states reported by /api/ollama/models/pulls.
+const (
+	pullQueued    = "queued"
+	pullRunning   = "pulling"
+	pullRetrying  = "retrying"
+	pullCompleted = "completed"
+	pullFailed    = "failed"
+	pullCancelled = "cancelled"
+)
+
+const (
+	// pullHistoryTTL is how long finished pulls stay listed.
+	pullHistoryTTL = time.Hour
+
+	// pullSubBuffer is the per-client event buffer. Progress lines for a
+	// client that falls behind are dropped; the next line supersedes them.
+	pullSubBuffer = 64
+
+	// pullRetryBase and pullRetryMax bound the wait before an interrupted
+	// pull is resumed; it doubles with every retry.
+	pullRetryBase = 5 * time.Second
+	pullRetryMax  = time.Minute
+)
+
+// permanentPullErrors are Ollama error fragments that retrying cannot fix.
+var permanentPullErrors = []string{"file does not exist", "not found", "unauthorized", "denied", "invalid"}
+
+// permanentPullError is a pull failure that is not retried.
+type permanentPullError struct{ msg string }
+
+func (e *permanentPullError) Error() string { return e.msg }
+
+// pullError classifies an error reported by Ollama.
+func pullError(msg string) error {
+	lower := strings.ToLower(msg)
+	for _, frag := range permanentPullErrors {
+		if strings.Contains(lower, frag) {
+			return &permanentPullError{msg}
+		}
+	}
+	return errors.New(msg)
+}
+
+// modelPull is one Ollama pull shared by every client asking for the same
+// model while it is queued or running.
+type modelPull struct {
+	Model      string     `json:"model"`
+	Instance   string     `json:"instance"`
+	Source     string     `json:"source,omitempty"` // reference Ollama pulls, when the mirror rewrote it
+	State      string     `json:"state"`
+	Status     string     `json:"status,omitempty"` // last status line from Ollama
+	Digest     string     `json:"digest,omitempty"`
+	Completed  int64      `json:"completed"`
+	Total      int64      `json:"total"`
+	Error      string     `json:"error,omitempty"`
+	Clients    int        `json:"clients"`
+	Position   int        `json:"position,omitempty"` // 1-based place in the queue
+	Attempts   int        `json:"attempts"`
+	Resumed    bool       `json:"resumed,omitempty"` // restarted from a failed or cancelled pull
+	QueuedAt   time.Time  `json:"queued_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	RetryAt    *time.Time `json:"retry_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	name     string // as requested
+	baseURL  string // of the instance pulling
+	insecure bool
+	subs     map[chan []byte]struct{}
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func (p *modelPull) finished() bool {
+	return p.FinishedAt != nil
+}
+
+// ref returns the reference sent to Ollama.
+func (p *modelPull) ref() string {
+	if p.Source != "" {
+		return p.Source
+	}
+	return p.name
+}
+
+// pullManager serialises model pulls (or caps their concurrency) across all
+// Ollama instances and dedupes identical in-flight pulls on an instance so
+// several clients share one download.
+// Interrupted pulls are resumed: Ollama keeps partially downloaded layers
+// and continues them when the same model is pulled again.
+type pullManager struct {
+	client *http.Client
+	opts   PullOptions
+	sem    chan struct{} // nil = unlimited
+	events *EventBus
+
+	mu    sync.Mutex
+	pulls map[string]*modelPull // by pullID
+}
+
+func newPullManager(client *http.Client, opts PullOptions, events *EventBus) *pullManager {
+	m := &pullManager{
+		client: client,
+		opts:   opts,
+		events: events,
+		pulls:  make(map[string]*modelPull),
+	}
+	if opts.MaxConcurrent > 0 {
+		m.sem = make(chan struct{}, opts.MaxConcurrent)
+	}
+	return m
+}
+
+// pullKey normalises a model reference so "llama3" and "llama3:latest"
+// share a pull.
+func pullKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		name += ":latest"
+	}
+	return name
+}
+
+// pullID identifies the pull of model name on an instance.
+func pullID(instance, name string) string {
+	return instance + "/" + pullKey(name)
+}
+
+// start returns the in-flight pull for name on inst, or queues a new one.
+// joined reports whether an existing pull was reused.
+func (m *pullManager) start(inst OllamaInstance, name string, insecure bool) (p *modelPull, joined bool) {
+	id := pullID(inst.Name, name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+
+	if p, ok := m.pulls[id]; ok && !p.finished() {
+		return p, true
+	}
+	return m.startLocked(id, inst, name, insecure, false), false
+}
+
+// errPullNotResumable is returned by resume for pulls that are not failed
+// or cancelled.
+var errPullNotResumable = errors.New("only failed or cancelled pulls can be resumed")
+
+// resume restarts a failed or cancelled pull on inst with its original
+// options. It returns nil if the manager has no record of the pull.
+func (m *pullManager) resume(inst OllamaInstance, name string) (*modelPull, error) {
+	id := pullID(inst.Name, name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+
+	old, ok := m.pulls[id]
+	if !ok {
+		return nil, nil
+	}
+	if old.State != pullFailed && old.State != pullCancelled {
+		return nil, errPullNotResumable
+	}
+	return m.startLocked(id, inst, old.name, old.insecure, true), nil
+}
+
+func (m *pullManager) startLocked(id string, inst OllamaInstance, name string, insecure, resumed bool) *modelPull {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &modelPull{
+		Model:    pullKey(name),
+		Instance: inst.Name,
+		State:    pullQueued,
+		Resumed:  resumed,
+		QueuedAt: time.Now(),
+		name:     name,
+		baseURL:  inst.URL,
+		insecure: insecure,
+		subs:     make(map[chan []byte]struct{}),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	if src := mirrorRef(name, m.opts.Mirror); src != name {
+		p.Source = src
+		p.insecure = insecure || m.opts.MirrorInsecure
+	}
+	m.pulls[id] = p
+	go m.run(ctx, p)
+	return p
+}
+
+// subscribe registers a client for p's progress lines.
+func (m *pullManager) subscribe(p *modelPull) (<-chan []byte, func()) {
+	ch := make(chan []byte, pullSubBuffer)
+	m.mu.Lock()
+	p.subs[ch] = struct{}{}
+	p.Clients++
+	m.mu.Unlock()
+
+	return ch, func() {
+		m.mu.Lock()
+		delete(p.subs, ch)
+		p.Clients--
+		m.mu.Unlock()
+	}
+}
+
+// cancelPull aborts a queued or running pull on instance. It returns false if
+// there is no such pull in flight.
+func (m *pullManager) cancelPull(instance, name string) bool {
+	m.mu.Lock()
+	p, ok := m.pulls[pullID(instance, name)]
+	m.mu.Unlock()
+	if !ok || p.finished() {
+		return false
+	}
+	p.cancel()
+	return true
+}
+
+// list returns snapshots of in-flight and recently finished pulls, queued
+// pulls in queue order first.
+func (m *pullManager) list() []modelPull {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+
+	out := make([]modelPull, 0, len(m.pulls))
+	for _, p := range m.pulls {
+		out = append(out, m.snapshotLocked(p))
+	}
+	rank := map[string]int{pullRunning: 0, pullRetrying: 0, pullQueued: 1}
+	sort.Slice(out, func(i, j int) bool {
+		ri, iok := rank[out[i].State]
+		rj, jok := rank[out[j].State]
+		if !iok {
+			ri = 2
+		}
+		if !jok {
+			rj = 2
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return out[i].QueuedAt.Before(out[j].QueuedAt)
+	})
+	return out
+}
+
+func (m *pullManager) snapshot(p *modelPull) modelPull {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshotLocked(p)
+}
+
+func (m *pullManager) snapshotLocked(p *modelPull) modelPull {
+	cp := *p
+	cp.subs, cp.cancel, cp.done = nil, nil, nil
+	if p.State == pullQueued {
+		cp.Position = 1
+		for _, o := range m.pulls {
+			if o != p && o.State == pullQueued && o.QueuedAt.Before(p.QueuedAt) {
+				cp.Position++
+			}
+		}
+	}
+	return cp
+}
+
+// pruneLocked forgets pulls that finished more than pullHistoryTTL ago.
+func (m *pullManager) pruneLocked() {
+	cutoff := time.Now().Add(-pullHistoryTTL)
+	for k, p := range m.pulls {
+		if p.finished() && p.FinishedAt.Before(cutoff) {
+			delete(m.pulls, k)
+		}
+	}
+}
+
+// run pulls p, resuming it up to opts.Retries times after interruptions.
+// Between attempts the pull gives up its concurrency slot.
+func (m *pullManager) run(ctx context.Context, p *modelPull) {
+	defer p.cancel()
+
+	for {
+		err := m.attempt(ctx, p)
+		var permanent *permanentPullError
+		switch {
+		case err == nil:
+			m.finish(p, pullCompleted, "")
+			m.events.Publish(EventModelChanged, "", map[string]string{
+				"action": "pulled", "model": p.Model, "instance": p.Instance,
+			})
+			return
+		case ctx.Err() != nil:
+			m.finish(p, pullCancelled, "pull cancelled")
+			return
+		case errors.As(err, &permanent):
+			m.finish(p, pullFailed, err.Error())
+			return
+		case p.Attempts > m.opts.Retries:
+			msg := err.Error()
+			if p.Attempts > 1 {
+				msg = fmt.Sprintf("%s (gave up after %d attempts)", msg, p.Attempts)
+			}
+			m.finish(p, pullFailed, msg)
+			return
+		}
+
+		delay := min(pullRetryBase<<(p.Attempts-1), pullRetryMax)
+		m.mu.Lock()
+		retryAt := time.Now().Add(delay)
+		p.State, p.RetryAt = pullRetrying, &retryAt
+		p.Status = fmt.Sprintf("interrupted: %v; resuming in %s (retry %d of %d)", err, delay, p.Attempts, m.opts.Retries)
+		msg, _ := json.Marshal(map[string]interface{}{"status": p.Status, "retrying": true})
+		m.broadcastLocked(p, msg)
+		m.mu.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			m.finish(p, pullCancelled, "pull cancelled")
+			return
+		}
+	}
+}
+
+// attempt waits for a concurrency slot and runs one Ollama pull of p.
+func (m *pullManager) attempt(ctx context.Context, p *modelPull) error {
+	if m.sem != nil {
+		m.mu.Lock()
+		p.State = pullQueued
+		m.mu.Unlock()
+		select {
+		case m.sem <- struct{}{}:
+			defer func() { <-m.sem }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	m.mu.Lock()
+	now := time.Now()
+	p.State, p.RetryAt = pullRunning, nil
+	p.Attempts++
+	if p.StartedAt == nil {
+		p.StartedAt = &now
+	}
+	m.mu.Unlock()
+
+	return m.stream(ctx, p)
+}
+
+// stream runs the Ollama pull and relays each progress line to p's
+// subscribers. An error line from Ollama, or a stream that ends before
+// Ollama reports success, is returned as an error.
+func (m *pullManager) stream(ctx context.Context, p *modelPull) error {
+	ref := p.ref()
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":    ref,
+		"name":     ref, // older Ollama releases still read "name"
+		"insecure": p.insecure,
+		"stream":   true,
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/pull", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		msg := fmt.Sprintf("ollama returned status %d", resp.StatusCode)
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			msg = e.Error
+		}
+		if resp.StatusCode < 500 {
+			return &permanentPullError{msg}
+		}
+		return errors.New(msg)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	success := false
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var ev struct {
+			Status    string `json:"status"`
+			Digest    string `json:"digest"`
+			Total     int64  `json:"total"`
+			Completed int64  `json:"completed"`
+			Error     string `json:"error"`
+		}
+		json.Unmarshal(line, &ev)
+		if ev.Error != "" {
+			// Reported to clients once the pull gives up, not per attempt.
+			return pullError(ev.Error)
+		}
+		success = ev.Status == "success"
+
+		m.mu.Lock()
+		if ev.Status != "" {
+			p.Status = ev.Status
+		}
+		if ev.Digest != "" {
+			p.Digest, p.Total, p.Completed = ev.Digest, ev.Total, ev.Completed
+		}
+		m.broadcastLocked(p, append([]byte(nil), line...))
+		m.mu.Unlock()
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("ollama stream interrupted: %v", err)
+	}
+	if !success {
+		return errors.New("ollama stream ended before the pull completed")
+	}
+	return nil
+}
+
+// broadcastLocked sends msg to p's subscribers, dropping it for clients
+// that fall behind.
+func (m *pullManager) broadcastLocked(p *modelPull, msg []byte) {
+	for ch := range p.subs {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+func (m *pullManager) finish(p *modelPull, state, errMsg string) {
+	m.mu.Lock()
+	now := time.Now()
+	p.State, p.Error, p.FinishedAt = state, errMsg, &now
+	m.mu.Unlock()
+	close(p.done)
+}
+
+// PullModel pulls a model through the pull queue and streams progress as
+// SSE, terminated by a [DONE] marker. A request for a model that is already
+// queued or pulling joins that pull instead of starting another download.
+// The pull keeps running if the client disconnects.
+func (h *OllamaHandler) PullModel(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name     string `json:"name"`
+		Model    string `json:"model"`
+		Insecure bool   `json:"insecure"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Model == "" {
+		req.Model = req.Name
+	}
+	if strings.TrimSpace(req.Model) == "" {
+		writeError(w, http.StatusBadRequest, "model name is required")
+		return
+	}
+
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	p, joined := h.pulls.start(inst, req.Model, req.Insecure)
+	h.streamPull(w, r, p, joined)
+}
+
+// streamPull relays p's progress to the client as SSE until the pull
+// finishes or the client goes away.
+func (h *OllamaHandler) streamPull(w http.ResponseWriter, r *http.Request, p *modelPull, joined bool) {
+	events, unsubscribe := h.pulls.subscribe(p)
+	defer unsubscribe()
+	defer activeStreams.track("ollama_pull_sse")()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	send := func(data []byte) {
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	// Tell the client where it stands before the first Ollama line.
+	snap := h.pulls.snapshot(p)
+	initial := map[string]interface{}{"status": snap.Status, "queued": snap.State == pullQueued, "joined": joined}
+	if snap.Source != "" {
+		initial["source"] = snap.Source
+	}
+	if snap.Resumed {
+		initial["resumed"] = true
+	}
+	switch {
+	case snap.State == pullQueued:
+		initial["status"] = fmt.Sprintf("queued (position %d)", snap.Position)
+	case snap.Status == "":
+		initial["status"] = snap.State
+	case snap.Digest != "":
+		initial["digest"], initial["total"], initial["completed"] = snap.Digest, snap.Total, snap.Completed
+	}
+	data, _ := json.Marshal(initial)
+	send(data)
+
+	for {
+		select {
+		case line := <-events:
+			send(line)
+		case <-p.done:
+		drain:
+			for {
+				select {
+				case line := <-events:
+					send(line)
+				default:
+					break drain
+				}
+			}
+			if final := h.pulls.snapshot(p); final.Error != "" {
+				data, _ := json.Marshal(map[string]string{"error": final.Error})
+				send(data)
+			}
+			send([]byte("[DONE]"))
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// ListPulls returns queued, running and recently finished model pulls on
+// every instance, or only on the one selected with ?instance=.
+func (h *OllamaHandler) ListPulls(w http.ResponseWriter, r *http.Request) {
+	pulls := h.pulls.list()
+	if r.URL.Query().Has(proxy.InstanceParam) {
+		inst, ok := h.instance(w, r)
+		if !ok {
+			return
+		}
+		kept := pulls[:0]
+		for _, p := range pulls {
+			if p.Instance == inst.Name {
+				kept = append(kept, p)
+			}
+		}
+		pulls = kept
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"pulls": pulls,
+		"count": len(pulls),
+	})
+}
+
+// ResumePull restarts a failed or cancelled pull with its original options
+// and streams its progress like PullModel. Layers Ollama had already
+// downloaded are not fetched again.
+func (h *OllamaHandler) ResumePull(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	p, err := h.pulls.resume(inst, name)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if p == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no failed or cancelled pull for %s; start it again with POST /api/ollama/models/pull", name))
+		return
+	}
+	h.streamPull(w, r, p, false)
+}
+
+// CancelPull aborts a queued or running pull for every client sharing it.
+func (h *OllamaHandler) CancelPull(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	if !h.pulls.cancelPull(inst.Name, name) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no pull in progress for %s", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"cancelled": pullKey(name)})
+}
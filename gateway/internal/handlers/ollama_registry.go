@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/proxy"
+)
+
+// PullOptions configures how the gateway pulls Ollama models.
+type PullOptions struct {
+	// MaxConcurrent caps the pulls running at once (0 = unlimited).
+	MaxConcurrent int
+	// Retries is how often an interrupted pull is resumed before it fails.
+	Retries int
+	// Mirror is a registry host[:port] that models naming no registry are
+	// pulled from instead of registry.ollama.ai.
+	Mirror string
+	// MirrorInsecure lets Ollama reach the mirror over plain HTTP or with a
+	// certificate it does not trust.
+	MirrorInsecure bool
+	// CAFile is a PEM bundle the gateway trusts when checking the mirror.
+	// Ollama itself needs the same CA in its system store.
+	CAFile string
+}
+
+// registryCheckTimeout bounds GET /api/ollama/registry.
+const registryCheckTimeout = 10 * time.Second
+
+// CheckRegistryMirror validates an OLLAMA_REGISTRY_MIRROR value: a bare
+// host[:port], since Ollama model references have no scheme.
+func CheckRegistryMirror(mirror string) error {
+	switch {
+	case mirror == "":
+		return nil
+	case strings.Contains(mirror, "://"):
+		return fmt.Errorf("%q must be host[:port] without a scheme; set OLLAMA_REGISTRY_INSECURE=true for plain HTTP", mirror)
+	case strings.ContainsAny(mirror, "/ "):
+		return fmt.Errorf("%q must be host[:port] without a path", mirror)
+	}
+	return nil
+}
+
+// hasRegistry reports whether a model reference names its registry, as in
+// "registry.example.com/team/model:tag" or "localhost:5000/model".
+func hasRegistry(name string) bool {
+	host, _, ok := strings.Cut(name, "/")
+	return ok && (strings.ContainsAny(host, ".:") || host == "localhost")
+}
+
+// mirrorRef rewrites a model reference without a registry to the mirror,
+// following Ollama's own expansion: "llama3" is "library/llama3". References
+// that name a registry are pulled as given.
+func mirrorRef(name, mirror string) string {
+	if mirror == "" || hasRegistry(name) {
+		return name
+	}
+	if !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	return mirror + "/" + name
+}
+
+// Registry reports the pull configuration and, with a mirror set, whether
+// the mirror answers the registry API. 401 counts as reachable: the
+// registry is up and wants credentials Ollama presents itself.
+func (h *OllamaHandler) Registry(w http.ResponseWriter, r *http.Request) {
+	opts := h.pulls.opts
+	out := map[string]interface{}{
+		"mirror":         opts.Mirror,
+		"insecure":       opts.MirrorInsecure,
+		"ca_file":        opts.CAFile,
+		"retries":        opts.Retries,
+		"max_concurrent": opts.MaxConcurrent,
+	}
+	if opts.Mirror == "" {
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), registryCheckTimeout)
+	defer cancel()
+	url, err := h.probeMirror(ctx, opts)
+	out["reachable"] = err == nil
+	if err != nil {
+		out["message"] = err.Error()
+	} else {
+		out["url"] = url
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// probeMirror requests /v2/ from the mirror over HTTPS and, if the mirror is
+// insecure, falls back to HTTP. It returns the URL that answered.
+func (h *OllamaHandler) probeMirror(ctx context.Context, opts PullOptions) (string, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: opts.MirrorInsecure}
+	if opts.CAFile != "" {
+		pool, err := proxy.LoadCAFile(opts.CAFile)
+		if err != nil {
+			return "", fmt.Errorf("OLLAMA_REGISTRY_CA_FILE: %v", err)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg, Proxy: http.ProxyFromEnvironment}}
+
+	schemes := []string{"https"}
+	if opts.MirrorInsecure {
+		schemes = append(schemes, "http")
+	}
+	var lastErr error
+	for _, scheme := range schemes {
+		url := scheme + "://" + opts.Mirror + "/v2/"
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusUnauthorized {
+			return url, nil
+		}
+		lastErr = fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return "", lastErr
+}
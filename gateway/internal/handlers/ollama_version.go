@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// releaseCheckTimeout bounds one request for the latest Ollama release.
+	releaseCheckTimeout = 10 * time.Second
+	// releaseCacheTTL is how long the latest release is remembered, and
+	// releaseRetryTTL how long a failed check is before it is tried again,
+	// so the release API's rate limit is not hit.
+	releaseCacheTTL = time.Hour
+	releaseRetryTTL = 5 * time.Minute
+)
+
+// Update check states reported by GET /api/ollama/version.
+const (
+	updateCheckDisabled = "disabled"
+	updateCheckOK       = "ok"
+	updateCheckFailed   = "failed"
+)
+
+// OllamaReleases looks up the latest Ollama release, remembering it for an
+// hour. A nil *OllamaReleases never checks.
+type OllamaReleases struct {
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	tag       string
+	htmlURL   string
+	err       error
+	checkedAt time.Time
+}
+
+// NewOllamaReleases creates an OllamaReleases asking url, an endpoint that
+// answers like GitHub's "latest release" API. An empty url disables the
+// check and returns nil.
+func NewOllamaReleases(url string) *OllamaReleases {
+	if url == "" {
+		return nil
+	}
+	return &OllamaReleases{url: url, client: &http.Client{Timeout: releaseCheckTimeout}}
+}
+
+// Latest returns the tag and page of the latest release and when they were
+// looked up. refresh skips the remembered answer.
+func (o *OllamaReleases) Latest(ctx context.Context, refresh bool) (tag, htmlURL string, checkedAt time.Time, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ttl := releaseCacheTTL
+	if o.err != nil {
+		ttl = releaseRetryTTL
+	}
+	if refresh || o.checkedAt.IsZero() || time.Since(o.checkedAt) > ttl {
+		o.tag, o.htmlURL, o.err = o.fetch(ctx)
+		o.checkedAt = time.Now().UTC()
+	}
+	return o.tag, o.htmlURL, o.checkedAt, o.err
+}
+
+func (o *OllamaReleases) fetch(ctx context.Context) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", o.url, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "ollqd-gateway")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return "", "", fmt.Errorf("release check returned status %d", resp.StatusCode)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", "", fmt.Errorf("decode release: %w", err)
+	}
+	if release.TagName == "" {
+		return "", "", fmt.Errorf("release check answered without tag_name")
+	}
+	return release.TagName, release.HTMLURL, nil
+}
+
+// compareVersions compares two Ollama versions such as "0.5.7", "v0.6.0"
+// or "0.6.0-rc1" and returns -1, 0 or 1. A pre-release is older than the
+// release it precedes; pre-releases compare as text.
+func compareVersions(a, b string) int {
+	a, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	b, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return strings.Compare(aPre, bPre)
+}
+
+// Version reports the version of an Ollama instance and, when the update
+// check is on, the latest release and whether it is newer. For the default
+// instance it also reports the Docker container the gateway manages, if
+// there is one, which POST /api/system/ollama/container/update updates.
+// refresh=true looks the latest release up again.
+func (h *OllamaHandler) Version(w http.ResponseWriter, r *http.Request) {
+	inst, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	resp, err := h.clients.API.Get(inst.URL + "/api/version")
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("ollama error: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("ollama returned status %d", resp.StatusCode))
+		return
+	}
+	var v struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("decode ollama version: %v", err))
+		return
+	}
+
+	out := map[string]interface{}{
+		"instance":     inst.Name,
+		"version":      v.Version,
+		"update_check": updateCheckDisabled,
+		"managed":      false,
+	}
+	if h.releases != nil {
+		tag, page, checkedAt, err := h.releases.Latest(r.Context(), r.URL.Query().Get("refresh") == "true")
+		out["checked_at"] = checkedAt
+		if err != nil {
+			out["update_check"] = updateCheckFailed
+			out["message"] = err.Error()
+		} else {
+			out["update_check"] = updateCheckOK
+			out["latest"] = strings.TrimPrefix(tag, "v")
+			out["release_url"] = page
+			out["update_available"] = compareVersions(v.Version, tag) < 0
+		}
+	}
+	if inst.Name == DefaultOllamaInstance && h.docker != nil {
+		if c, err := h.docker.Inspect(r.Context(), ollamaContainerName); err == nil && c != nil {
+			out["managed"] = true
+			out["container"] = c
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
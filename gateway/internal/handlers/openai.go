@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// openAIDefaultModel is the model name that stands for the worker's
+	// chat model. "@collection" may follow it, as it may any model name.
+	openAIDefaultModel = "ollqd"
+	// openAICollectionHeader selects the collection, overriding the model
+	// name.
+	openAICollectionHeader = "X-Ollqd-Collection"
+	// openAIInstanceHeader pins the answer to an Ollama instance.
+	openAIInstanceHeader = "X-Ollqd-Instance"
+	// openAIMaxMessages bounds the messages of one request.
+	openAIMaxMessages = 256
+)
+
+// OpenAIHandler serves an OpenAI-compatible chat completions API over the
+// RAG chat pipeline, so OpenAI SDK clients and tools can use retrieval-
+// augmented chat without a bespoke integration.
+type OpenAIHandler struct {
+	grpc      *grpcclient.Client
+	prefs     *ChatPrefsStore
+	tm        *tasks.Manager
+	cite      *CitationEnricher
+	instances *OllamaInstances
+	qdrantURL string
+	qdrant    *http.Client
+	flush     StreamFlush
+}
+
+// NewOpenAIHandler creates a new OpenAIHandler. Like WebSocket chat, it
+// fills unset options from the user's prefs, honours reindex locks in tm,
+// cites sources with cite, may pin one of instances and coalesces streamed
+// chunks as flush says. The collections offered as models are listed from
+// Qdrant at qdrantURL.
+func NewOpenAIHandler(gc *grpcclient.Client, prefs *ChatPrefsStore, tm *tasks.Manager, cite *CitationEnricher, instances *OllamaInstances, qdrantURL string, qdrant *http.Client, flush StreamFlush) *OpenAIHandler {
+	return &OpenAIHandler{grpc: gc, prefs: prefs, tm: tm, cite: cite, instances: instances, qdrantURL: qdrantURL, qdrant: qdrant, flush: flush}
+}
+
+// Routes registers the OpenAI-compatible routes under /v1.
+func (h *OpenAIHandler) Routes(r chi.Router) {
+	r.Get("/models", h.Models)
+	r.Post("/chat/completions", h.ChatCompletions)
+}
+
+// openAIMessage is a message of a chat completion request. Content is a
+// string or a list of content parts, of which only text parts are
+// supported.
+type openAIMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// text returns the text of m's content.
+func (m openAIMessage) text() (string, error) {
+	if len(m.Content) == 0 || string(m.Content) == "null" {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(m.Content, &s); err == nil {
+		return s, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(m.Content, &parts); err != nil {
+		return "", errors.New("content must be a string or a list of content parts")
+	}
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p.Type != "text" {
+			return "", fmt.Errorf("content parts of type %q are not supported", p.Type)
+		}
+		texts = append(texts, p.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// openAIChatRequest is the body of POST /v1/chat/completions. Fields it
+// does not list are ignored.
+type openAIChatRequest struct {
+	Model               string          `json:"model"`
+	Messages            []openAIMessage `json:"messages"`
+	Stream              bool            `json:"stream"`
+	Temperature         *float64        `json:"temperature"`
+	TopP                *float64        `json:"top_p"`
+	MaxTokens           *int32          `json:"max_tokens"`
+	MaxCompletionTokens *int32          `json:"max_completion_tokens"`
+	N                   *int            `json:"n"`
+}
+
+// openAIChat is a chat completion request translated for the worker.
+type openAIChat struct {
+	query   string
+	history []grpcclient.ChatTurn
+	opts    grpcclient.ChatOptions
+}
+
+// parse validates req and splits its messages: system and developer
+// messages make up the system prompt, the last message is the query and
+// must come from the user, and the turns before it are the history.
+func (req *openAIChatRequest) parse() (openAIChat, error) {
+	var c openAIChat
+	switch {
+	case req.Model == "":
+		return c, errors.New("model is required")
+	case len(req.Messages) == 0:
+		return c, errors.New("messages must not be empty")
+	case len(req.Messages) > openAIMaxMessages:
+		return c, fmt.Errorf("at most %d messages per request", openAIMaxMessages)
+	case req.N != nil && *req.N != 1:
+		return c, errors.New("n must be 1")
+	}
+
+	var system []string
+	for i, m := range req.Messages {
+		text, err := m.text()
+		if err != nil {
+			return c, fmt.Errorf("messages[%d]: %v", i, err)
+		}
+		last := i == len(req.Messages)-1
+		switch m.Role {
+		case "system", "developer":
+			if last {
+				return c, errors.New("the last message must come from the user")
+			}
+			system = append(system, text)
+		case "user":
+			if last {
+				c.query = text
+			} else {
+				c.history = append(c.history, grpcclient.ChatTurn{Role: "user", Content: text})
+			}
+		case "assistant":
+			if last {
+				return c, errors.New("the last message must come from the user")
+			}
+			c.history = append(c.history, grpcclient.ChatTurn{Role: "assistant", Content: text})
+		default:
+			return c, fmt.Errorf("messages[%d]: role %q is not supported", i, m.Role)
+		}
+	}
+	if strings.TrimSpace(c.query) == "" {
+		return c, errors.New("the last message must not be empty")
+	}
+
+	c.opts = grpcclient.ChatOptions{
+		Temperature:  req.Temperature,
+		TopP:         req.TopP,
+		MaxTokens:    req.MaxTokens,
+		SystemPrompt: strings.Join(system, "\n\n"),
+	}
+	if req.MaxCompletionTokens != nil {
+		c.opts.MaxTokens = req.MaxCompletionTokens
+	}
+	return c, nil
+}
+
+// parseOpenAIModel splits a model name of the form model[@collection]
+// into the chat model and collection; openAIDefaultModel, or nothing before
+// the "@", leaves the worker's chat model.
+func parseOpenAIModel(name string) (model, collection string) {
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		name, collection = name[:i], name[i+1:]
+	}
+	if name == openAIDefaultModel {
+		name = ""
+	}
+	return name, collection
+}
+
+// openAIDelta is the message or delta of a choice.
+type openAIDelta struct {
+	Role    string  `json:"role,omitempty"`
+	Content *string `json:"content,omitempty"`
+}
+
+// openAIChoice is a choice of a completion or completion chunk.
+type openAIChoice struct {
+	Index        int          `json:"index"`
+	Message      *openAIDelta `json:"message,omitempty"`
+	Delta        *openAIDelta `json:"delta,omitempty"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+// openAIRAG is the non-standard "ollqd" field of a response: the collection
+// answered from and the cited sources.
+type openAIRAG struct {
+	Collection string     `json:"collection,omitempty"`
+	Citations  []Citation `json:"citations"`
+	Warnings   []string   `json:"warnings,omitempty"`
+	PIIMasked  bool       `json:"pii_masked,omitempty"`
+}
+
+// openAICompletion is a chat completion or, when streaming, one chunk of
+// it.
+type openAICompletion struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []openAIChoice `json:"choices"`
+	RAG     *openAIRAG     `json:"ollqd,omitempty"`
+}
+
+// writeOpenAIError writes an error in the OpenAI error format, which
+// OpenAI SDKs parse.
+func writeOpenAIError(w http.ResponseWriter, status int, typ, msg string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{"message": msg, "type": typ, "code": nil},
+	})
+}
+
+// ChatCompletions answers an OpenAI chat completion request through the
+// RAG chat pipeline, streamed as server-sent events when "stream" is set.
+// The collection comes from the X-Ollqd-Collection header or the model
+// name (model@collection). X-Ollqd-Flush-Ms and X-Ollqd-Flush-Bytes
+// override how streamed chunks are coalesced.
+func (h *OpenAIHandler) ChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req openAIChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON body")
+		return
+	}
+	chat, err := req.parse()
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	username := middleware.UsernameFromContext(r.Context())
+	chat.opts = chat.opts.Merge(h.prefs.Defaults(username))
+	if err := validateChatOptions(chat.opts); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	flush, err := h.flush.overrideFromHeaders(r)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if h.grpc.Chat == nil {
+		writeOpenAIError(w, http.StatusServiceUnavailable, "server_error", "chat service not available")
+		return
+	}
+	if svc, ok := unsupportedService(h.grpc, grpcclient.ServiceChat); !ok {
+		writeOpenAIError(w, http.StatusNotImplemented, "server_error", unsupportedMessage(h.grpc, svc))
+		return
+	}
+
+	model, collection := parseOpenAIModel(req.Model)
+	if c := r.Header.Get(openAICollectionHeader); c != "" {
+		collection = c
+	}
+	rag := &openAIRAG{Collection: collection, Citations: []Citation{}}
+	lockColl := collection
+	if lockColl == "" {
+		lockColl = workerDefaultCodebaseCollection
+	}
+	if l, locked := h.tm.CollectionLock(lockColl); locked {
+		if l.Mode == tasks.LockBlock {
+			writeOpenAIError(w, http.StatusConflict, "invalid_request_error",
+				fmt.Sprintf("collection %s is being reindexed (task %s)", lockColl, l.TaskID))
+			return
+		}
+		rag.Warnings = append(rag.Warnings, "collection "+lockColl+" is being reindexed; results may be incomplete")
+	}
+
+	ctx, err := h.instances.pin(r.Context(), r.Header.Get(openAIInstanceHeader))
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	chatReq := &grpcclient.ChatRequest{
+		Message:    chat.query,
+		Collection: collection,
+		Model:      model,
+	}
+	chat.opts.Apply(chatReq)
+	if len(chat.history) > 0 {
+		if h.grpc.Worker().HasFeature(grpcclient.FeatureChatHistory) {
+			grpcclient.SetChatHistory(chatReq, chat.history)
+		} else {
+			rag.Warnings = append(rag.Warnings, fmt.Sprintf("%d earlier messages were left out of the conversation", len(chat.history)))
+		}
+	}
+	stream, err := h.grpc.Chat.Chat(ctx, chatReq)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, "server_error", "failed to start chat: "+err.Error())
+		return
+	}
+	defer stream.Close()
+
+	base := openAICompletion{
+		ID:      "chatcmpl-" + uuid.New().String(),
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+	}
+	cite := func(hits []*grpcclient.SearchHit) {
+		rag.Citations = h.cite.Enrich(ctx, lockColl, hits)
+	}
+	if req.Stream {
+		h.streamCompletion(ctx, w, stream, base, rag, flush, cite)
+		return
+	}
+
+	var answer strings.Builder
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeOpenAIError(w, http.StatusBadGateway, "server_error", "stream error: "+err.Error())
+			return
+		}
+		switch event.Type {
+		case "chunk":
+			answer.WriteString(event.Content)
+		case "sources":
+			cite(event.Sources)
+		case "done":
+			rag.PIIMasked = event.PiiMasked
+		case "error":
+			writeOpenAIError(w, http.StatusBadGateway, "server_error", event.Content)
+			return
+		}
+	}
+
+	content, stop := answer.String(), "stop"
+	base.Object = "chat.completion"
+	base.Choices = []openAIChoice{{Message: &openAIDelta{Role: "assistant", Content: &content}, FinishReason: &stop}}
+	base.RAG = rag
+	writeJSON(w, http.StatusOK, base)
+}
+
+// streamCompletion relays a chat stream as chat.completion.chunk events,
+// ending with a chunk that carries the finish reason and citations, then
+// "[DONE]". Content deltas are coalesced as flush says. Errors after the
+// first event are sent as an error event; a client that went away (ctx
+// cancelled) is sent nothing more.
+func (h *OpenAIHandler) streamCompletion(ctx context.Context, w http.ResponseWriter, stream grpcclient.ChatStream, base openAICompletion, rag *openAIRAG, flush StreamFlush, cite func([]*grpcclient.SearchHit)) {
+	defer activeStreams.track("openai_chat_sse")()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	base.Object = "chat.completion.chunk"
+	send := func(v interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			log.Printf("ERROR: encoding chat completion chunk: %v", err)
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	chunk := func(delta openAIDelta, finish *string) openAICompletion {
+		c := base
+		c.Choices = []openAIChoice{{Delta: &delta, FinishReason: finish}}
+		return c
+	}
+	// Deltas may be sent from the coalescer's timer, so every write goes
+	// through it.
+	deltas := newChunkCoalescer(flush, func(content string) bool {
+		send(chunk(openAIDelta{Content: &content}, nil))
+		return ctx.Err() == nil
+	})
+	defer deltas.close()
+	fail := func(msg string) {
+		deltas.do(func() {
+			send(map[string]interface{}{"error": map[string]interface{}{"message": msg, "type": "server_error", "code": nil}})
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		})
+	}
+
+	empty := ""
+	send(chunk(openAIDelta{Role: "assistant", Content: &empty}, nil))
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fail("stream error: " + err.Error())
+			return
+		}
+		switch event.Type {
+		case "chunk":
+			deltas.add(event.Content)
+		case "sources":
+			cite(event.Sources)
+		case "done":
+			rag.PIIMasked = event.PiiMasked
+		case "error":
+			fail(event.Content)
+			return
+		case "cancelled":
+			return
+		}
+	}
+
+	stop := "stop"
+	last := chunk(openAIDelta{}, &stop)
+	last.RAG = rag
+	deltas.do(func() {
+		send(last)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+}
+
+// openAIModel is an entry of GET /v1/models.
+type openAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// Models lists the model names chat completions accept without a chat
+// model of their own: "ollqd" for the worker's default collection, then
+// "ollqd@<collection>" for each Qdrant collection. Any Ollama chat model
+// name works as well, with or without "@<collection>".
+func (h *OpenAIHandler) Models(w http.ResponseWriter, r *http.Request) {
+	models := []openAIModel{{ID: openAIDefaultModel, Object: "model", OwnedBy: "ollqd"}}
+
+	req, err := http.NewRequestWithContext(r.Context(), "GET", h.qdrantURL+"/collections", nil)
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	resp, err := h.qdrant.Do(req)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, "server_error", fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+	var list struct {
+		Result struct {
+			Collections []struct {
+				Name string `json:"name"`
+			} `json:"collections"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, "server_error", "failed to parse qdrant response")
+		return
+	}
+	for _, c := range list.Result.Collections {
+		models = append(models, openAIModel{ID: openAIDefaultModel + "@" + c.Name, Object: "model", OwnedBy: "ollqd"})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   models,
+	})
+}
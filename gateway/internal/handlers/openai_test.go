@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/grpc/grpctest"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// recordChat records the chat request and metadata it receives.
+type recordChat struct {
+	pb.UnimplementedChatServiceServer
+	got chan *grpcclient.ChatRequest
+	md  chan metadata.MD
+}
+
+func (s *recordChat) Chat(req *grpcclient.ChatRequest, stream pb.ChatService_ChatServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	s.got <- req
+	s.md <- md
+	if err := stream.Send(&grpcclient.ChatEvent{Type: "chunk", Content: "Soupe du jour."}); err != nil {
+		return err
+	}
+	return stream.Send(&grpcclient.ChatEvent{Type: "done"})
+}
+
+// newOpenAITest returns an OpenAIHandler whose worker is srv.
+func newOpenAITest(t *testing.T, srv pb.ChatServiceServer) *OpenAIHandler {
+	t.Helper()
+	conn := grpctest.Dial(t, func(s *grpc.Server) { pb.RegisterChatServiceServer(s, srv) })
+	return NewOpenAIHandler(grpcclient.NewClientConn(conn), NewChatPrefsStore(grpcclient.ChatOptions{}),
+		tasks.NewManager(t.TempDir(), 1), nil, nil, "", nil, StreamFlush{})
+}
+
+func TestOpenAISystemMessagesReachWorker(t *testing.T) {
+	srv := &recordChat{got: make(chan *grpcclient.ChatRequest, 1), md: make(chan metadata.MD, 1)}
+	h := newOpenAITest(t, srv)
+
+	body := `{
+		"model": "ollqd@docs",
+		"messages": [
+			{"role": "system", "content": "Answer in French."},
+			{"role": "user", "content": "Hello"},
+			{"role": "assistant", "content": "Bonjour."},
+			{"role": "developer", "content": "Cite the café's menu."},
+			{"role": "user", "content": "What is on the menu?"}
+		]
+	}`
+	w := httptest.NewRecorder()
+	h.ChatCompletions(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var resp openAICompletion
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message == nil || *resp.Choices[0].Message.Content != "Soupe du jour." {
+		t.Errorf("choices = %+v, want the worker's answer", resp.Choices)
+	}
+
+	got := <-srv.got
+	want := "Answer in French.\n\nCite the café's menu."
+	if got.GetSystemPrompt() != want {
+		t.Errorf("worker saw system prompt %q, want %q", got.GetSystemPrompt(), want)
+	}
+	if got.GetMessage() != "What is on the menu?" || got.GetCollection() != "docs" {
+		t.Errorf("worker saw message %q in %q", got.GetMessage(), got.GetCollection())
+	}
+	history := got.GetHistory()
+	if len(history) != 2 || history[0].GetRole() != "user" || history[1].GetContent() != "Bonjour." {
+		t.Errorf("worker saw history %v, want the user and assistant turns", history)
+	}
+	for k := range <-srv.md {
+		if k == "x-ollqd-system-prompt" || k == "x-ollqd-chat-history" {
+			t.Errorf("%s was also sent as metadata", k)
+		}
+	}
+}
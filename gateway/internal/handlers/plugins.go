@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/plugin"
+)
+
+// ListPlugins returns the compiled-in plugins and the hooks they implement.
+func ListPlugins(w http.ResponseWriter, r *http.Request) {
+	plugins := plugin.List()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"plugins": plugins,
+		"count":   len(plugins),
+	})
+}
+
+// processSearch passes hits through the plugins' search processors. On
+// failure it writes a 500 response, without the plugin's error, which may
+// quote the content being scrubbed.
+func processSearch(w http.ResponseWriter, r *http.Request, search plugin.Search, hits []*grpcclient.SearchHit) ([]*grpcclient.SearchHit, bool) {
+	hits, err := plugin.ProcessSearch(r.Context(), search, hits)
+	if err != nil {
+		log.Printf("ERROR: search post-processing in %s: %v", search.Collection, err)
+		writeErrorCode(w, http.StatusInternalServerError, CodeInternal, "search post-processing failed")
+		return nil, false
+	}
+	return hits, true
+}
@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alfagnish/ollqd-gateway/internal/config"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/manifest"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// maxPreviewFile is the largest file returned whole; bigger files are
+	// cut to a window of previewContextLines around the chunk.
+	maxPreviewFile      = 1 << 20
+	previewContextLines = 200
+)
+
+// binaryDocExts are formats the worker extracts text from. Their chunks
+// cannot be mapped back to file offsets, so the stored chunk is shown.
+var binaryDocExts = map[string]bool{
+	".pdf": true, ".docx": true, ".xlsx": true, ".pptx": true,
+}
+
+// PreviewHandler returns the original text of an indexed document with the
+// byte range of one chunk marked, for "view in context" on search results.
+type PreviewHandler struct {
+	cfg       *config.Config
+	grpc      *grpcclient.Client
+	colls     *CollectionSettings
+	manifests *manifest.Store
+	baseURL   string
+	client    *http.Client
+}
+
+// NewPreviewHandler creates a new PreviewHandler reading points from Qdrant
+// at qdrantURL through client.
+func NewPreviewHandler(cfg *config.Config, gc *grpcclient.Client, colls *CollectionSettings, manifests *manifest.Store, qdrantURL string, client *http.Client) *PreviewHandler {
+	return &PreviewHandler{
+		cfg:       cfg,
+		grpc:      gc,
+		colls:     colls,
+		manifests: manifests,
+		baseURL:   qdrantURL,
+		client:    client,
+	}
+}
+
+// Routes registers the preview route.
+func (h *PreviewHandler) Routes(r chi.Router) {
+	r.Get("/", h.Preview)
+}
+
+// previewHighlight is the marked chunk as byte offsets into Text.
+type previewHighlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Match says how the range was found: "exact", "anchored" (first and
+	// last chunk lines), "lines" (stored line numbers), "chunk" (Text is
+	// the chunk itself) or "none".
+	Match string `json:"match"`
+}
+
+type previewResponse struct {
+	Collection  string           `json:"collection"`
+	FilePath    string           `json:"file_path"`
+	ChunkID     interface{}      `json:"chunk_id"`
+	ChunkIndex  int              `json:"chunk_index"`
+	TotalChunks int              `json:"total_chunks"`
+	StartLine   int              `json:"start_line"`
+	EndLine     int              `json:"end_line"`
+	Language    string           `json:"language"`
+	Source      string           `json:"source"` // "file" or "chunk"
+	Text        string           `json:"text"`
+	Highlight   previewHighlight `json:"highlight"`
+	WindowStart int64            `json:"window_start"` // byte offset of Text in the file
+	Truncated   bool             `json:"truncated"`
+	Stale       bool             `json:"stale,omitempty"` // file changed since indexing
+	Note        string           `json:"note,omitempty"`
+}
+
+// Preview handles GET /api/rag/preview. The chunk is identified by
+// ?chunk_id= (Qdrant point ID) or by ?file_path= and ?chunk_index=
+// (zero-based, as in search results' chunk_info minus one). ?collection=
+// defaults to the default collection.
+func (h *PreviewHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	collection := q.Get("collection")
+	if collection == "" {
+		collection = h.colls.DefaultCollection()
+	}
+	if collection == "" {
+		writeError(w, http.StatusBadRequest, "collection is required")
+		return
+	}
+	filePath := q.Get("file_path")
+
+	var (
+		point *previewPoint
+		err   error
+	)
+	switch {
+	case q.Get("chunk_id") != "":
+		point, err = h.pointByID(r.Context(), collection, q.Get("chunk_id"))
+	case filePath != "" && q.Get("chunk_index") != "":
+		idx, perr := strconv.Atoi(q.Get("chunk_index"))
+		if perr != nil || idx < 0 {
+			writeError(w, http.StatusBadRequest, "chunk_index must be a non-negative integer")
+			return
+		}
+		point, err = h.pointByIndex(r.Context(), collection, filePath, idx)
+	default:
+		writeError(w, http.StatusBadRequest, "chunk_id, or file_path and chunk_index, are required")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+	if point == nil {
+		writeError(w, http.StatusNotFound, "chunk not found")
+		return
+	}
+	if filePath != "" && point.Payload.FilePath != filePath {
+		writeError(w, http.StatusBadRequest, "chunk does not belong to file_path")
+		return
+	}
+
+	p := point.Payload
+	resp := previewResponse{
+		Collection:  collection,
+		FilePath:    p.FilePath,
+		ChunkID:     point.ID,
+		ChunkIndex:  p.ChunkIndex,
+		TotalChunks: p.TotalChunks,
+		StartLine:   p.StartLine,
+		EndLine:     p.EndLine,
+		Language:    p.Language,
+	}
+
+	chunkOnly := func(note string) {
+		resp.Source = "chunk"
+		resp.Text = p.Content
+		resp.Highlight = previewHighlight{Start: 0, End: len(p.Content), Match: "chunk"}
+		resp.Note = note
+		writeJSON(w, http.StatusOK, resp)
+	}
+
+	if p.Language == "image" {
+		chunkOnly("image: text is the caption")
+		return
+	}
+	if binaryDocExts[strings.ToLower(filepath.Ext(p.FilePath))] {
+		chunkOnly("extracted document: showing the indexed text")
+		return
+	}
+	full, err := h.resolve(r.Context(), collection, p.FilePath)
+	if err != nil {
+		chunkOnly("original file not accessible: " + err.Error())
+		return
+	}
+
+	text, windowStart, truncated, err := readPreviewText(full, p.StartLine, p.EndLine)
+	if err != nil {
+		chunkOnly("original file not readable: " + err.Error())
+		return
+	}
+	if bytes.IndexByte([]byte(text[:min(len(text), 8192)]), 0) >= 0 {
+		chunkOnly("binary file: showing the indexed text")
+		return
+	}
+
+	resp.Source = "file"
+	resp.Text = text
+	resp.WindowStart = windowStart
+	resp.Truncated = truncated
+	resp.Highlight = alignChunk(text, p.Content, p.StartLine, p.EndLine, windowStart == 0)
+	if !truncated && p.ContentHash != "" {
+		sum := sha256.Sum256([]byte(text))
+		resp.Stale = hex.EncodeToString(sum[:]) != p.ContentHash
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// previewPoint is the subset of a Qdrant point the preview needs.
+type previewPoint struct {
+	ID      interface{} `json:"id"`
+	Payload struct {
+		FilePath    string `json:"file_path"`
+		Language    string `json:"language"`
+		ChunkIndex  int    `json:"chunk_index"`
+		TotalChunks int    `json:"total_chunks"`
+		StartLine   int    `json:"start_line"`
+		EndLine     int    `json:"end_line"`
+		Content     string `json:"content"`
+		ContentHash string `json:"content_hash"`
+	} `json:"payload"`
+}
+
+func (h *PreviewHandler) pointByID(ctx context.Context, collection, id string) (*previewPoint, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		h.baseURL+"/collections/"+url.PathEscape(collection)+"/points/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Qdrant answers 404 for a missing point and 400 for a malformed ID;
+	// both mean there is no such chunk.
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		return nil, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("get point: status %d", resp.StatusCode)
+	}
+	var out struct {
+		Result *previewPoint `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out.Result, nil
+}
+
+func (h *PreviewHandler) pointByIndex(ctx context.Context, collection, filePath string, idx int) (*previewPoint, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"limit":        1,
+		"with_payload": true,
+		"with_vector":  false,
+		"filter": map[string]interface{}{
+			"must": []map[string]interface{}{
+				{"key": "file_path", "match": map[string]interface{}{"value": filePath}},
+				{"key": "chunk_index", "match": map[string]interface{}{"value": idx}},
+			},
+		},
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST",
+		h.baseURL+"/collections/"+url.PathEscape(collection)+"/points/scroll", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("scroll: status %d", resp.StatusCode)
+	}
+	var out struct {
+		Result struct {
+			Points []*previewPoint `json:"points"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	if len(out.Result.Points) == 0 {
+		return nil, nil
+	}
+	return out.Result.Points[0], nil
+}
+
+// resolve maps a payload file_path to a file the gateway may read. Absolute
+// paths (uploads) must lie under an allowed root; relative paths (codebase
+// and document indexing) are tried under each root indexed into the
+// collection and each mounted path.
+func (h *PreviewHandler) resolve(ctx context.Context, collection, filePath string) (string, error) {
+	roots := []string{h.cfg.UploadDir}
+	if indexed, err := h.manifests.Roots(collection); err == nil {
+		roots = append(roots, indexed...)
+	}
+	if h.grpc.Config != nil {
+		if cfg, err := h.grpc.Config.GetConfig(ctx); err == nil {
+			roots = append(roots, cfg.MountedPaths...)
+		}
+	}
+
+	var resolvedRoots []string
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		if abs, err := filepath.EvalSymlinks(root); err == nil {
+			resolvedRoots = append(resolvedRoots, abs)
+		}
+	}
+
+	var candidates []string
+	if filepath.IsAbs(filePath) {
+		candidates = []string{filePath}
+	} else {
+		cleaned := filepath.Clean(filepath.FromSlash(filePath))
+		if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("invalid path")
+		}
+		for _, root := range resolvedRoots {
+			candidates = append(candidates, filepath.Join(root, cleaned))
+		}
+	}
+
+	for _, c := range candidates {
+		full, err := filepath.EvalSymlinks(c)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(full)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		for _, root := range resolvedRoots {
+			if full == root || strings.HasPrefix(full, root+string(filepath.Separator)) {
+				return full, nil
+			}
+		}
+		return "", fmt.Errorf("outside the indexed roots")
+	}
+	return "", fmt.Errorf("not found")
+}
+
+// readPreviewText returns the file's text, or for large files a window of
+// lines around startLine..endLine along with the window's byte offset.
+func readPreviewText(path string, startLine, endLine int) (string, int64, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", 0, false, err
+	}
+	if info.Size() <= maxPreviewFile {
+		data, err := io.ReadAll(f)
+		return string(data), 0, false, err
+	}
+	if startLine <= 0 {
+		data, err := io.ReadAll(io.LimitReader(f, maxPreviewFile))
+		return string(data), 0, true, err
+	}
+
+	first := max(1, startLine-previewContextLines)
+	last := max(endLine, startLine) + previewContextLines
+
+	var (
+		buf    bytes.Buffer
+		offset int64
+		start  int64 = -1
+	)
+	br := bufio.NewReader(f)
+	for line := 1; line <= last && buf.Len() < maxPreviewFile; line++ {
+		b, err := br.ReadBytes('\n')
+		if line >= first {
+			if start < 0 {
+				start = offset
+			}
+			buf.Write(b)
+		}
+		offset += int64(len(b))
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, false, err
+		}
+	}
+	if start < 0 {
+		start = offset
+	}
+	return buf.String(), start, true, nil
+}
+
+// alignChunk finds chunk in text. Chunkers strip and rejoin paragraphs, so
+// an exact match is tried first, then a range anchored on the chunk's first
+// and last lines, then the stored line numbers. lineOneAtStart reports
+// whether text begins at line 1 of the file; otherwise line numbers are
+// not used as a search hint.
+func alignChunk(text, chunk string, startLine, endLine int, lineOneAtStart bool) previewHighlight {
+	hint := -1
+	if lineOneAtStart && startLine > 0 {
+		hint = lineOffset(text, startLine)
+	}
+
+	if chunk != "" {
+		if i := indexNear(text, chunk, hint); i >= 0 {
+			return previewHighlight{Start: i, End: i + len(chunk), Match: "exact"}
+		}
+
+		lines := strings.Split(strings.TrimSpace(chunk), "\n")
+		firstLine := strings.TrimSpace(lines[0])
+		lastLine := strings.TrimSpace(lines[len(lines)-1])
+		if firstLine != "" && lastLine != "" {
+			if i := indexNear(text, firstLine, hint); i >= 0 {
+				if j := strings.Index(text[i:], lastLine); j >= 0 && j <= 2*len(chunk) {
+					return previewHighlight{Start: i, End: i + j + len(lastLine), Match: "anchored"}
+				}
+			}
+		}
+	}
+
+	if lineOneAtStart && startLine > 0 && hint >= 0 {
+		end := lineOffset(text, max(endLine, startLine)+1)
+		if end < 0 {
+			end = len(text)
+		}
+		return previewHighlight{Start: hint, End: end, Match: "lines"}
+	}
+	return previewHighlight{Match: "none"}
+}
+
+// lineOffset returns the byte offset where 1-based line n starts, or -1 if
+// text has fewer lines.
+func lineOffset(text string, n int) int {
+	off := 0
+	for line := 1; line < n; line++ {
+		i := strings.IndexByte(text[off:], '\n')
+		if i < 0 {
+			return -1
+		}
+		off += i + 1
+	}
+	return off
+}
+
+// indexNear returns the occurrence of sub in text closest to hint, or the
+// first one if hint is negative.
+func indexNear(text, sub string, hint int) int {
+	best := -1
+	for from := 0; from <= len(text); {
+		i := strings.Index(text[from:], sub)
+		if i < 0 {
+			break
+		}
+		pos := from + i
+		if hint < 0 {
+			return pos
+		}
+		if best < 0 || absInt(pos-hint) < absInt(best-hint) {
+			best = pos
+		}
+		if pos > hint {
+			break // later matches are only further away
+		}
+		from = pos + 1
+	}
+	return best
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
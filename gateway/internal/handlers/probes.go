@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/lifecycle"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ProbesHandler serves the Kubernetes probes and the preStop hook. Unlike
+// /api/health, which reports on Ollama and Qdrant, the probes only answer
+// whether this gateway process should be restarted or receive traffic.
+type ProbesHandler struct {
+	grpc       *grpcclient.Client
+	lc         *lifecycle.State
+	drainToken string
+}
+
+// NewProbesHandler creates a new ProbesHandler. drainToken, when set, lets
+// non-loopback callers trigger the preStop drain.
+func NewProbesHandler(gc *grpcclient.Client, lc *lifecycle.State, drainToken string) *ProbesHandler {
+	return &ProbesHandler{grpc: gc, lc: lc, drainToken: drainToken}
+}
+
+// Routes registers the probe routes on the given chi router.
+func (h *ProbesHandler) Routes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.NoCache)
+		r.Get("/livez", h.Livez)
+		r.Get("/readyz", h.Readyz)
+		r.Get("/startupz", h.Startupz)
+		r.Get("/prestop", h.PreStop)
+		r.Post("/prestop", h.PreStop)
+	})
+}
+
+// Livez reports that the process is serving HTTP. It never checks
+// dependencies, so an unreachable worker does not get the gateway
+// restarted.
+func (h *ProbesHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Startupz succeeds once the gateway has finished starting up.
+func (h *ProbesHandler) Startupz(w http.ResponseWriter, r *http.Request) {
+	if !h.lc.Started() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "starting"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz succeeds while the gateway should receive traffic: it has
+// started, is not draining and can reach the worker.
+func (h *ProbesHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]string{
+		"started":  "ok",
+		"draining": "ok",
+		"worker":   h.workerCheck(),
+	}
+	if !h.lc.Started() {
+		checks["started"] = "starting"
+	}
+	if h.lc.Draining() {
+		checks["draining"] = "draining"
+	}
+
+	code, overall := http.StatusOK, "ok"
+	for _, c := range checks {
+		if c != "ok" {
+			code, overall = http.StatusServiceUnavailable, "unavailable"
+		}
+	}
+	writeJSON(w, code, map[string]interface{}{"status": overall, "checks": checks})
+}
+
+// workerCheck returns "ok" when the worker connection is usable, or its
+// connectivity state otherwise. An idle connection counts as usable; it
+// is asked to reconnect so the next probe sees the real state.
+func (h *ProbesHandler) workerCheck() string {
+	if h.grpc == nil || h.grpc.Conn() == nil {
+		return "not connected"
+	}
+	conn := h.grpc.Conn()
+	switch state := conn.GetState(); state {
+	case connectivity.Ready:
+		return "ok"
+	case connectivity.Idle:
+		conn.Connect()
+		return "ok"
+	default:
+		return strings.ToLower(state.String())
+	}
+}
+
+// PreStop starts the drain and blocks until the drain delay has passed,
+// which holds back the kubelet's SIGTERM for as long. Only loopback callers
+// (the "gateway drain" exec hook) or, if DRAIN_TOKEN is set, callers
+// presenting it in X-Drain-Token may drain the gateway.
+func (h *ProbesHandler) PreStop(w http.ResponseWriter, r *http.Request) {
+	if !h.mayDrain(r) {
+		writeError(w, http.StatusForbidden, "draining is only allowed from loopback or with X-Drain-Token")
+		return
+	}
+	select {
+	case <-h.lc.Drain("preStop hook"):
+		writeJSON(w, http.StatusOK, map[string]string{"status": "drained"})
+	case <-r.Context().Done():
+	}
+}
+
+func (h *ProbesHandler) mayDrain(r *http.Request) bool {
+	if token := r.Header.Get("X-Drain-Token"); token != "" && h.drainToken != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(h.drainToken)) == 1
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -2,15 +2,22 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 
 	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/plugin"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -21,16 +28,42 @@ type QdrantHandler struct {
 	baseURL string
 	client  *http.Client
 	grpc    *grpcclient.Client
+	colls   *CollectionSettings
+	tm      *tasks.Manager
+	bulkKey []byte // signs bulk-delete confirm tokens
+
+	searchDefaults *SearchDefaults
+	searchTerms    *SearchTerms
+	// reports tell exports which model a collection was embedded with.
+	reports *IndexReports
+	// events announces created collections.
+	events *EventBus
+	// importMax bounds an imported collection archive, in bytes.
+	importMax int64
 }
 
 // NewQdrantHandler wraps an existing Qdrant reverse proxy and adds
-// dedicated collection-management handlers.
-func NewQdrantHandler(proxy *httputil.ReverseProxy, baseURL string, gc *grpcclient.Client) *QdrantHandler {
+// dedicated collection-management handlers, which call Qdrant at baseURL
+// through client. Collection searches take their
+// top_k and score threshold defaults from searchDefaults and rewrite their
+// query with the synonyms and stopwords of searchTerms. Exports record
+// the embedding model of the collection's latest run in reports; imports
+// accept archives of up to importMaxMB. Created collections are announced
+// on events.
+func NewQdrantHandler(proxy *httputil.ReverseProxy, baseURL string, client *http.Client, gc *grpcclient.Client, colls *CollectionSettings, tm *tasks.Manager, searchDefaults *SearchDefaults, searchTerms *SearchTerms, reports *IndexReports, events *EventBus, importMaxMB int64) *QdrantHandler {
 	return &QdrantHandler{
-		proxy:   proxy,
-		baseURL: baseURL,
-		client:  &http.Client{},
-		grpc:    gc,
+		proxy:          proxy,
+		baseURL:        baseURL,
+		client:         client,
+		grpc:           gc,
+		colls:          colls,
+		tm:             tm,
+		bulkKey:        newBulkDeleteKey(),
+		searchDefaults: searchDefaults,
+		searchTerms:    searchTerms,
+		reports:        reports,
+		events:         events,
+		importMax:      importMaxMB << 20,
 	}
 }
 
@@ -38,9 +71,25 @@ func NewQdrantHandler(proxy *httputil.ReverseProxy, baseURL string, gc *grpcclie
 func (h *QdrantHandler) Routes(r chi.Router) {
 	r.Get("/collections", h.ListCollections)
 	r.Post("/collections", h.CreateCollection)
+	r.With(middleware.RequireAdmin).Post("/collections/bulk-delete", h.BulkDeleteCollections)
 	r.Delete("/collections/{name}", h.DeleteCollection)
 	r.Get("/collections/{name}/points", h.BrowsePoints)
-	r.Post("/collections/{name}/search", h.SearchCollection)
+	r.Get("/collections/{name}/export", h.ExportCollection)
+	r.With(middleware.RequireAdmin).Post("/collections/{name}/import", h.ImportCollection)
+	r.With(requireWorker(h.grpc, grpcclient.ServiceSearch)).Post("/collections/{name}/search", h.SearchCollection)
+	r.Get("/collections/{name}/schema", h.CollectionSchema)
+	r.Get("/collections/{name}/description", h.GetDescription)
+	r.Put("/collections/{name}/description", h.SetDescription)
+	r.Get("/collections/{name}/indexes", h.ListPayloadIndexes)
+	r.Post("/collections/{name}/indexes", h.CreatePayloadIndex)
+	r.Delete("/collections/{name}/indexes/{field}", h.DeletePayloadIndex)
+	r.Get("/collection-templates", h.ListTemplates)
+	r.Post("/collection-templates", h.PutTemplate)
+	r.Get("/collection-templates/{name}", h.GetTemplate)
+	r.Put("/collection-templates/{name}", h.PutTemplate)
+	r.Delete("/collection-templates/{name}", h.DeleteTemplate)
+	r.Get("/default-collection", h.GetDefaultCollection)
+	r.Put("/default-collection", h.SetDefaultCollection)
 	r.HandleFunc("/*", func(w http.ResponseWriter, r *http.Request) {
 		h.proxy.ServeHTTP(w, r)
 	})
@@ -78,6 +127,9 @@ func (h *QdrantHandler) ListCollections(w http.ResponseWriter, r *http.Request)
 			"status":       "unknown",
 			"config":       map[string]interface{}{},
 		}
+		if d := h.colls.Description(name); d != "" {
+			entry["description"] = d
+		}
 
 		// Fetch per-collection info for points_count, status, config
 		if infoResp, err := h.client.Get(h.baseURL + "/collections/" + url.PathEscape(name)); err == nil {
@@ -114,12 +166,15 @@ func (h *QdrantHandler) ListCollections(w http.ResponseWriter, r *http.Request)
 }
 
 // CreateCollection translates POST {name, vector_size, distance} →
-// PUT /collections/{name} {vectors: {size, distance}} on Qdrant.
+// PUT /collections/{name} {vectors: {size, distance}} on Qdrant. When a
+// template is named, its vector settings fill any omitted fields and its
+// payload indexes are created on the new collection.
 func (h *QdrantHandler) CreateCollection(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Name       string `json:"name"`
 		VectorSize int    `json:"vector_size"`
 		Distance   string `json:"distance"`
+		Template   string `json:"template"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
@@ -129,6 +184,22 @@ func (h *QdrantHandler) CreateCollection(w http.ResponseWriter, r *http.Request)
 		writeError(w, http.StatusBadRequest, "name is required")
 		return
 	}
+
+	var tmpl *CollectionTemplate
+	if req.Template != "" {
+		t, ok := h.colls.Template(req.Template)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("template %s not found", req.Template))
+			return
+		}
+		tmpl = &t
+		if req.VectorSize <= 0 {
+			req.VectorSize = t.VectorSize
+		}
+		if req.Distance == "" {
+			req.Distance = t.Distance
+		}
+	}
 	if req.VectorSize <= 0 {
 		req.VectorSize = 1024
 	}
@@ -159,9 +230,70 @@ func (h *QdrantHandler) CreateCollection(w http.ResponseWriter, r *http.Request)
 	}
 	defer resp.Body.Close()
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, resp.Body)
+	if resp.StatusCode == http.StatusOK {
+		data := map[string]string{"collection": req.Name}
+		if tmpl != nil {
+			data["template"] = tmpl.Name
+		}
+		h.events.Publish(EventCollectionCreated, middleware.UsernameFromContext(r.Context()), data)
+		if err := h.putPayloadIndex(r.Context(), req.Name, "content", contentTextIndex); err != nil {
+			log.Printf("WARNING: text index on %s: %v", req.Name, err)
+		}
+	}
+	if tmpl == nil || resp.StatusCode != http.StatusOK {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+		return
+	}
+
+	var indexErrors []string
+	for _, idx := range tmpl.PayloadIndexes {
+		if err := h.putPayloadIndex(r.Context(), req.Name, idx.FieldName, idx.FieldSchema); err != nil {
+			indexErrors = append(indexErrors, fmt.Sprintf("%s: %v", idx.FieldName, err))
+		}
+	}
+	if err := h.colls.Bind(req.Name, tmpl.Name); err != nil {
+		log.Printf("WARNING: bind collection %s to template %s: %v", req.Name, tmpl.Name, err)
+	}
+
+	out := map[string]interface{}{
+		"result":      true,
+		"status":      "ok",
+		"name":        req.Name,
+		"template":    tmpl.Name,
+		"vector_size": req.VectorSize,
+		"distance":    req.Distance,
+	}
+	if len(indexErrors) > 0 {
+		out["index_errors"] = indexErrors
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// putPayloadIndex creates a payload index and waits for it to be applied.
+func (h *QdrantHandler) putPayloadIndex(ctx context.Context, collection, field string, schema interface{}) error {
+	body, _ := json.Marshal(map[string]interface{}{
+		"field_name":   field,
+		"field_schema": schema,
+	})
+	httpReq, err := http.NewRequestWithContext(ctx, "PUT",
+		h.baseURL+"/collections/"+url.PathEscape(collection)+"/index?wait=true", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("qdrant status %d", resp.StatusCode)
+	}
+	return nil
 }
 
 // DeleteCollection translates DELETE /collections/{name} to Qdrant.
@@ -183,9 +315,11 @@ func (h *QdrantHandler) DeleteCollection(w http.ResponseWriter, r *http.Request)
 	}
 	defer resp.Body.Close()
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, resp.Body)
+	if resp.StatusCode == http.StatusOK {
+		h.colls.Unbind(name)
+	}
+
+	copyQdrantResponse(w, resp, name)
 }
 
 // BrowsePoints translates GET /collections/{name}/points?limit=N&offset=X →
@@ -222,6 +356,11 @@ func (h *QdrantHandler) BrowsePoints(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		copyQdrantResponse(w, resp, name)
+		return
+	}
+
 	var raw map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
 		writeError(w, http.StatusBadGateway, "failed to parse qdrant response")
@@ -248,29 +387,324 @@ func (h *QdrantHandler) SearchCollection(w http.ResponseWriter, r *http.Request)
 	var req struct {
 		Query string `json:"query"`
 		TopK  int32  `json:"top_k"`
+		searchTuning
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
-	if req.TopK <= 0 {
-		req.TopK = 10
+	if msg := req.validate(); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	defaults := h.searchDefaults.Get()
+	req.TopK = defaults.topK(req.TopK, 10)
+	if req.ScoreThreshold == nil {
+		req.ScoreThreshold = defaults.MinScore
 	}
 
 	if h.grpc.Search == nil {
 		writeError(w, http.StatusServiceUnavailable, "search service not available")
 		return
 	}
+	if !checkCollectionLock(w, h.tm, name) {
+		return
+	}
 
+	query := req.Query
+	rewrite := h.searchTerms.Rewrite(name, req.Query)
+	if rewrite != nil {
+		query = rewrite.Query
+	}
 	resp, err := h.grpc.Search.SearchCollection(r.Context(), &grpcclient.SearchCollectionRequest{
 		Collection: name,
-		Query:      req.Query,
-		TopK:       req.TopK,
+		Query:      query,
+		TopK:       req.fetchK(req.TopK),
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
+		return
+	}
+	if req.active() {
+		resp.Results = req.apply(resp.GetResults(), req.TopK)
+	}
+	results, ok := processSearch(w, r, plugin.Search{Query: req.Query, Collection: name, Mode: "vector"}, resp.GetResults())
+	if !ok {
+		return
+	}
+	resp.Results = results
+
+	writeJSON(w, http.StatusOK, struct {
+		*grpcclient.SearchResponse
+		QueryRewrite *QueryRewrite `json:"query_rewrite,omitempty"`
+	}{resp, rewrite})
+}
+
+// payloadIndexTypes are the field schemas Qdrant accepts for payload indexes.
+var payloadIndexTypes = map[string]bool{
+	"keyword":  true,
+	"integer":  true,
+	"float":    true,
+	"bool":     true,
+	"geo":      true,
+	"datetime": true,
+	"text":     true,
+	"uuid":     true,
+}
+
+// ListPayloadIndexes returns the payload indexes of a collection, taken from
+// the payload_schema section of GET /collections/{name} on Qdrant.
+func (h *QdrantHandler) ListPayloadIndexes(w http.ResponseWriter, r *http.Request) {
+	rawName := chi.URLParam(r, "name")
+	name, _ := url.PathUnescape(rawName)
+
+	httpReq, err := http.NewRequestWithContext(r.Context(), "GET",
+		h.baseURL+"/collections/"+url.PathEscape(name), nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		copyQdrantResponse(w, resp, name)
+		return
+	}
+
+	var raw struct {
+		Result struct {
+			PayloadSchema map[string]struct {
+				DataType string                 `json:"data_type"`
+				Params   map[string]interface{} `json:"params,omitempty"`
+				Points   int                    `json:"points"`
+			} `json:"payload_schema"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		writeError(w, http.StatusBadGateway, "failed to parse qdrant response")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	indexes := make([]map[string]interface{}, 0, len(raw.Result.PayloadSchema))
+	for field, schema := range raw.Result.PayloadSchema {
+		entry := map[string]interface{}{
+			"field_name": field,
+			"data_type":  schema.DataType,
+			"points":     schema.Points,
+		}
+		if len(schema.Params) > 0 {
+			entry["params"] = schema.Params
+		}
+		indexes = append(indexes, entry)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i]["field_name"].(string) < indexes[j]["field_name"].(string)
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"collection": name,
+		"indexes":    indexes,
+	})
+}
+
+// CreatePayloadIndex translates POST {field_name, field_schema, params} →
+// PUT /collections/{name}/index on Qdrant. params carries type-specific
+// options such as a text index tokenizer.
+func (h *QdrantHandler) CreatePayloadIndex(w http.ResponseWriter, r *http.Request) {
+	rawName := chi.URLParam(r, "name")
+	name, _ := url.PathUnescape(rawName)
+
+	var req struct {
+		FieldName   string                 `json:"field_name"`
+		FieldSchema string                 `json:"field_schema"`
+		Params      map[string]interface{} `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.FieldName == "" {
+		writeError(w, http.StatusBadRequest, "field_name is required")
+		return
+	}
+	if !payloadIndexTypes[req.FieldSchema] {
+		writeError(w, http.StatusBadRequest,
+			"field_schema must be one of: keyword, integer, float, bool, geo, datetime, text, uuid")
+		return
+	}
+
+	var schema interface{} = req.FieldSchema
+	if len(req.Params) > 0 {
+		params := map[string]interface{}{"type": req.FieldSchema}
+		for k, v := range req.Params {
+			params[k] = v
+		}
+		schema = params
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"field_name":   req.FieldName,
+		"field_schema": schema,
+	})
+
+	httpReq, err := http.NewRequestWithContext(r.Context(), "PUT",
+		h.baseURL+"/collections/"+url.PathEscape(name)+"/index?wait=true", bytes.NewReader(body))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	copyQdrantResponse(w, resp, name)
+}
+
+// DeletePayloadIndex translates DELETE /collections/{name}/indexes/{field} →
+// DELETE /collections/{name}/index/{field} on Qdrant.
+func (h *QdrantHandler) DeletePayloadIndex(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	field, _ := url.PathUnescape(chi.URLParam(r, "field"))
+
+	httpReq, err := http.NewRequestWithContext(r.Context(), "DELETE",
+		h.baseURL+"/collections/"+url.PathEscape(name)+"/index/"+url.PathEscape(field)+"?wait=true", nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	copyQdrantResponse(w, resp, name)
+}
+
+// ListTemplates returns all collection templates.
+func (h *QdrantHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates := h.colls.Templates()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"templates": templates,
+		"count":     len(templates),
+	})
+}
+
+// GetTemplate returns a single collection template.
+func (h *QdrantHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	t, ok := h.colls.Template(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("template %s not found", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// PutTemplate creates (POST) or replaces (PUT /{name}) a collection template.
+func (h *QdrantHandler) PutTemplate(w http.ResponseWriter, r *http.Request) {
+	var t CollectionTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if name := chi.URLParam(r, "name"); name != "" {
+		t.Name, _ = url.PathUnescape(name)
+	}
+	if err := h.colls.PutTemplate(t); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	saved, _ := h.colls.Template(t.Name)
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// DeleteTemplate removes a collection template.
+func (h *QdrantHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	ok, err := h.colls.DeleteTemplate(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("template %s not found", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": name})
+}
+
+// GetDefaultCollection returns the collection index requests fall back to.
+func (h *QdrantHandler) GetDefaultCollection(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"default_collection": h.colls.DefaultCollection(),
+	})
+}
+
+// GetDescription returns what a collection holds, as chat routing sees it.
+func (h *QdrantHandler) GetDescription(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	writeJSON(w, http.StatusOK, map[string]string{
+		"collection":  name,
+		"description": h.colls.Description(name),
+	})
+}
+
+// SetDescription describes what a collection holds, so chat routing can
+// send questions about it there. An empty description falls back to that
+// of the collection's template.
+func (h *QdrantHandler) SetDescription(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	var req struct {
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	if len(req.Description) > maxCollectionDescription {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("description must be at most %d bytes", maxCollectionDescription))
+		return
+	}
+	if err := h.colls.SetDescription(name, req.Description); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"collection":  name,
+		"description": h.colls.Description(name),
+	})
+}
+
+// SetDefaultCollection changes the collection index requests fall back to.
+func (h *QdrantHandler) SetDefaultCollection(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DefaultCollection string `json:"default_collection"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := h.colls.SetDefaultCollection(req.DefaultCollection); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"default_collection": req.DefaultCollection,
+	})
 }
@@ -0,0 +1,528 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// archiveFormat and archiveVersion identify a collection archive.
+	archiveFormat  = "ollqd-collection"
+	archiveVersion = 1
+	// archiveManifest and archivePoints are the files in an archive.
+	archiveManifest = "manifest.json"
+	archivePoints   = "points.jsonl"
+	// archivePageSize is the number of points scrolled or upserted per
+	// Qdrant request.
+	archivePageSize = 256
+	// archiveMaxLine bounds one point in points.jsonl, in bytes.
+	archiveMaxLine = 64 << 20
+)
+
+// archiveIndex is a payload index recorded in a collection archive.
+type archiveIndex struct {
+	FieldName string                 `json:"field_name"`
+	DataType  string                 `json:"data_type"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+}
+
+// schema returns the field_schema Qdrant takes to recreate the index.
+func (i archiveIndex) schema() interface{} {
+	if len(i.Params) == 0 {
+		return i.DataType
+	}
+	schema := map[string]interface{}{"type": i.DataType}
+	for k, v := range i.Params {
+		schema[k] = v
+	}
+	return schema
+}
+
+// collectionManifest describes the collection an archive was exported from.
+// Vectors and SparseVectors are Qdrant's vector configuration as is, so
+// collections with named vectors round-trip; VectorSize and Distance are
+// filled in for the usual single unnamed vector.
+type collectionManifest struct {
+	Format         string          `json:"format"`
+	Version        int             `json:"version"`
+	Collection     string          `json:"collection"`
+	ExportedAt     time.Time       `json:"exported_at"`
+	PointsCount    int             `json:"points_count"`
+	VectorSize     int             `json:"vector_size,omitempty"`
+	Distance       string          `json:"distance,omitempty"`
+	Vectors        json.RawMessage `json:"vectors"`
+	SparseVectors  json.RawMessage `json:"sparse_vectors,omitempty"`
+	EmbedModel     string          `json:"embed_model,omitempty"`
+	PayloadIndexes []archiveIndex  `json:"payload_indexes,omitempty"`
+}
+
+// archivePoint is one line of points.jsonl.
+type archivePoint struct {
+	ID      json.RawMessage `json:"id"`
+	Vector  json.RawMessage `json:"vector,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// collectionInfo reads the vector configuration and payload indexes of a
+// collection into a manifest. It returns Qdrant's status when the request
+// was answered but failed.
+func (h *QdrantHandler) collectionInfo(ctx context.Context, name string) (collectionManifest, int, error) {
+	m := collectionManifest{Format: archiveFormat, Version: archiveVersion, Collection: name}
+	req, err := http.NewRequestWithContext(ctx, "GET", h.baseURL+"/collections/"+url.PathEscape(name), nil)
+	if err != nil {
+		return m, http.StatusInternalServerError, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return m, http.StatusBadGateway, fmt.Errorf("qdrant error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return m, resp.StatusCode, fmt.Errorf("qdrant status %d", resp.StatusCode)
+	}
+
+	var raw struct {
+		Result struct {
+			Config struct {
+				Params struct {
+					Vectors       json.RawMessage `json:"vectors"`
+					SparseVectors json.RawMessage `json:"sparse_vectors"`
+				} `json:"params"`
+			} `json:"config"`
+			PayloadSchema map[string]struct {
+				DataType string                 `json:"data_type"`
+				Params   map[string]interface{} `json:"params"`
+			} `json:"payload_schema"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return m, http.StatusBadGateway, errors.New("failed to parse qdrant response")
+	}
+	params := raw.Result.Config.Params
+	m.Vectors = params.Vectors
+	if len(params.SparseVectors) > 0 && string(params.SparseVectors) != "null" {
+		m.SparseVectors = params.SparseVectors
+	}
+	var single struct {
+		Size     int    `json:"size"`
+		Distance string `json:"distance"`
+	}
+	if json.Unmarshal(params.Vectors, &single) == nil {
+		m.VectorSize, m.Distance = single.Size, single.Distance
+	}
+	for field, s := range raw.Result.PayloadSchema {
+		m.PayloadIndexes = append(m.PayloadIndexes, archiveIndex{FieldName: field, DataType: s.DataType, Params: s.Params})
+	}
+	sort.Slice(m.PayloadIndexes, func(i, j int) bool { return m.PayloadIndexes[i].FieldName < m.PayloadIndexes[j].FieldName })
+	return m, http.StatusOK, nil
+}
+
+// embedModel returns the model a collection was embedded with: that of its
+// latest index run, or the worker's current embedding model.
+func (h *QdrantHandler) embedModel(ctx context.Context, collection string) string {
+	if h.reports != nil {
+		runs := h.reports.runs(collection)
+		for i := len(runs) - 1; i >= 0; i-- {
+			if m := runs[i].Settings.EmbedModel; m != "" {
+				return m
+			}
+		}
+	}
+	return h.workerEmbedModel(ctx)
+}
+
+// workerEmbedModel returns the worker's embedding model, or "" when the
+// worker cannot be asked.
+func (h *QdrantHandler) workerEmbedModel(ctx context.Context) string {
+	if h.grpc == nil || h.grpc.Config == nil {
+		return ""
+	}
+	cfg, err := h.grpc.Config.GetConfig(ctx)
+	if err != nil {
+		return ""
+	}
+	return cfg.GetOllama().GetEmbedModel()
+}
+
+// ExportCollection streams a collection as a zip archive holding
+// points.jsonl, one point with its vector and payload per line, and
+// manifest.json with the vector configuration, payload indexes and
+// embedding model needed to recreate it. The archive can be loaded into
+// another deployment with ImportCollection.
+func (h *QdrantHandler) ExportCollection(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	manifest, status, err := h.collectionInfo(r.Context(), name)
+	if status == http.StatusNotFound {
+		writeErrorCode(w, http.StatusNotFound, CodeCollectionNotFound, fmt.Sprintf("collection %s not found", name))
+		return
+	}
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+	manifest.EmbedModel = h.embedModel(r.Context(), name)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + ".zip"}))
+	manifest.ExportedAt = time.Now().UTC()
+	zw := zip.NewWriter(w)
+	pw, err := zw.CreateHeader(&zip.FileHeader{Name: archivePoints, Method: zip.Deflate, Modified: manifest.ExportedAt})
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	enc := json.NewEncoder(pw)
+	manifest.PointsCount, err = h.scrollPoints(r.Context(), name, true, func(p archivePoint) error {
+		return enc.Encode(p)
+	})
+	if err != nil {
+		// The response has started: drop the connection so the client sees a
+		// truncated download rather than an archive missing points.
+		log.Printf("WARNING: export of collection %s: %v", name, err)
+		panic(http.ErrAbortHandler)
+	}
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: archiveManifest, Method: zip.Deflate, Modified: manifest.ExportedAt})
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	menc := json.NewEncoder(mw)
+	menc.SetIndent("", "  ")
+	if err := menc.Encode(manifest); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("WARNING: export of collection %s: %v", name, err)
+	}
+}
+
+// scrollPoints calls fn with every point of a collection, vectors included
+// if withVector is set, and returns the number of points.
+func (h *QdrantHandler) scrollPoints(ctx context.Context, name string, withVector bool, fn func(archivePoint) error) (int, error) {
+	var (
+		offset json.RawMessage
+		count  int
+	)
+	for {
+		body := map[string]interface{}{
+			"limit":        archivePageSize,
+			"with_payload": true,
+			"with_vector":  withVector,
+		}
+		if offset != nil {
+			body["offset"] = offset
+		}
+		raw, _ := json.Marshal(body)
+		req, err := http.NewRequestWithContext(ctx, "POST",
+			h.baseURL+"/collections/"+url.PathEscape(name)+"/points/scroll", bytes.NewReader(raw))
+		if err != nil {
+			return count, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := h.client.Do(req)
+		if err != nil {
+			return count, fmt.Errorf("qdrant error: %v", err)
+		}
+		var page struct {
+			Result struct {
+				Points         []archivePoint  `json:"points"`
+				NextPageOffset json.RawMessage `json:"next_page_offset"`
+			} `json:"result"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return count, fmt.Errorf("qdrant status %d", resp.StatusCode)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return count, errors.New("failed to parse qdrant response")
+		}
+		for _, p := range page.Result.Points {
+			if err := fn(p); err != nil {
+				return count, err
+			}
+			count++
+		}
+		next := page.Result.NextPageOffset
+		if len(next) == 0 || string(next) == "null" {
+			return count, nil
+		}
+		offset = next
+	}
+}
+
+// ImportCollection creates a collection from an archive made by
+// ExportCollection, sent as the raw request body or as the "file" field of
+// a multipart form. The collection must not exist; it is created with the
+// archived vector configuration and payload indexes and filled with the
+// archived points. If loading the points fails the new collection is
+// deleted again.
+func (h *QdrantHandler) ImportCollection(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	// Archives can take longer than the server's read timeout to upload.
+	http.NewResponseController(w).SetReadDeadline(time.Time{})
+
+	tmp, status, err := h.spoolArchive(w, r)
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	info, err := tmp.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	zr, err := zip.NewReader(tmp, info.Size())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "body is not a collection archive")
+		return
+	}
+	manifest, points, err := openArchive(zr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer points.Close()
+
+	if _, status, err := h.collectionInfo(r.Context(), name); err == nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("collection %s already exists", name))
+		return
+	} else if status != http.StatusNotFound {
+		writeError(w, status, err.Error())
+		return
+	}
+	if status, err := h.createFromManifest(r.Context(), name, manifest); err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+
+	var indexErrors []string
+	for _, idx := range manifest.PayloadIndexes {
+		if err := h.putPayloadIndex(r.Context(), name, idx.FieldName, idx.schema()); err != nil {
+			indexErrors = append(indexErrors, fmt.Sprintf("%s: %v", idx.FieldName, err))
+		}
+	}
+
+	imported, err := h.upsertArchive(r.Context(), name, points)
+	if err != nil {
+		h.dropCollection(name)
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("importing points: %v; collection %s was removed", err, name))
+		return
+	}
+
+	h.events.Publish(EventCollectionCreated, middleware.UsernameFromContext(r.Context()),
+		map[string]string{"collection": name, "source": "import"})
+
+	out := map[string]interface{}{
+		"collection":        name,
+		"source_collection": manifest.Collection,
+		"points_imported":   imported,
+		"embed_model":       manifest.EmbedModel,
+	}
+	if len(indexErrors) > 0 {
+		out["index_errors"] = indexErrors
+	}
+	if current := h.workerEmbedModel(r.Context()); manifest.EmbedModel != "" && current != "" && current != manifest.EmbedModel {
+		out["warnings"] = []string{fmt.Sprintf(
+			"archive was embedded with %s but the worker embeds with %s; searches need the same model", manifest.EmbedModel, current)}
+	}
+	writeJSON(w, http.StatusCreated, out)
+}
+
+// spoolArchive copies the uploaded archive to a temporary file, which zip
+// needs for random access. The caller removes the file.
+func (h *QdrantHandler) spoolArchive(w http.ResponseWriter, r *http.Request) (*os.File, int, error) {
+	tooLarge := fmt.Errorf("archive exceeds maximum size of %d MB", h.importMax>>20)
+	body := http.MaxBytesReader(w, r.Body, h.importMax)
+	var src io.Reader = body
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "multipart/form-data" {
+		r.Body = body
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, http.StatusBadRequest, errors.New("invalid multipart body")
+		}
+		for {
+			part, err := mr.NextPart()
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return nil, http.StatusRequestEntityTooLarge, tooLarge
+			}
+			if err != nil {
+				return nil, http.StatusBadRequest, errors.New("missing 'file' field")
+			}
+			if part.FormName() == "file" {
+				src = part
+				break
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp("", "ollqd-import-*.zip")
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, http.StatusRequestEntityTooLarge, tooLarge
+		}
+		return nil, http.StatusBadRequest, fmt.Errorf("reading archive: %v", err)
+	}
+	return tmp, http.StatusOK, nil
+}
+
+// openArchive reads and checks the manifest of an archive and opens its
+// points.
+func openArchive(zr *zip.Reader) (collectionManifest, io.ReadCloser, error) {
+	var manifest collectionManifest
+	mf, err := zr.Open(archiveManifest)
+	if err != nil {
+		return manifest, nil, fmt.Errorf("archive has no %s", archiveManifest)
+	}
+	err = json.NewDecoder(mf).Decode(&manifest)
+	mf.Close()
+	if err != nil {
+		return manifest, nil, fmt.Errorf("invalid %s: %v", archiveManifest, err)
+	}
+	if manifest.Format != archiveFormat {
+		return manifest, nil, fmt.Errorf("archive format %q is not %q", manifest.Format, archiveFormat)
+	}
+	if manifest.Version < 1 || manifest.Version > archiveVersion {
+		return manifest, nil, fmt.Errorf("archive version %d is not supported (want %d)", manifest.Version, archiveVersion)
+	}
+	if len(bytes.TrimSpace(manifest.Vectors)) == 0 {
+		return manifest, nil, fmt.Errorf("%s has no vectors configuration", archiveManifest)
+	}
+	points, err := zr.Open(archivePoints)
+	if err != nil {
+		return manifest, nil, fmt.Errorf("archive has no %s", archivePoints)
+	}
+	return manifest, points, nil
+}
+
+// createFromManifest creates a collection with the archived vector
+// configuration.
+func (h *QdrantHandler) createFromManifest(ctx context.Context, name string, m collectionManifest) (int, error) {
+	spec := map[string]json.RawMessage{"vectors": m.Vectors}
+	if len(m.SparseVectors) > 0 {
+		spec["sparse_vectors"] = m.SparseVectors
+	}
+	body, _ := json.Marshal(spec)
+	req, err := http.NewRequestWithContext(ctx, "PUT", h.baseURL+"/collections/"+url.PathEscape(name), bytes.NewReader(body))
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return http.StatusBadGateway, fmt.Errorf("qdrant error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return http.StatusBadGateway, fmt.Errorf("creating collection: qdrant status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return http.StatusOK, nil
+}
+
+// upsertArchive loads the points of points.jsonl into a collection in
+// batches and returns the number loaded.
+func (h *QdrantHandler) upsertArchive(ctx context.Context, name string, points io.Reader) (int, error) {
+	sc := bufio.NewScanner(points)
+	sc.Buffer(make([]byte, 0, 1<<20), archiveMaxLine)
+	batch := make([]json.RawMessage, 0, archivePageSize)
+	count, line := 0, 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := h.upsertPoints(ctx, name, batch); err != nil {
+			return err
+		}
+		count += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for sc.Scan() {
+		line++
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var p archivePoint
+		if err := json.Unmarshal(raw, &p); err != nil || len(p.ID) == 0 {
+			return count, fmt.Errorf("%s line %d is not a point", archivePoints, line)
+		}
+		batch = append(batch, append(json.RawMessage(nil), raw...))
+		if len(batch) == archivePageSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return count, fmt.Errorf("reading %s: %v", archivePoints, err)
+	}
+	return count, flush()
+}
+
+// upsertPoints writes one batch of points and waits for it to be applied.
+func (h *QdrantHandler) upsertPoints(ctx context.Context, name string, points []json.RawMessage) error {
+	body, _ := json.Marshal(map[string]interface{}{"points": points})
+	req, err := http.NewRequestWithContext(ctx, "PUT",
+		h.baseURL+"/collections/"+url.PathEscape(name)+"/points?wait=true", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("qdrant error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("qdrant status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// dropCollection deletes a partially imported collection.
+func (h *QdrantHandler) dropCollection(name string) {
+	req, err := http.NewRequest("DELETE", h.baseURL+"/collections/"+url.PathEscape(name), nil)
+	if err != nil {
+		return
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		log.Printf("WARNING: removing partially imported collection %s: %v", name, err)
+		return
+	}
+	resp.Body.Close()
+}
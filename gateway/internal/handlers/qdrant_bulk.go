@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bulkDeleteTokenTTL is how long a bulk-delete confirmation token stays
+// valid.
+const bulkDeleteTokenTTL = 5 * time.Minute
+
+// newBulkDeleteKey returns the per-process key confirmation tokens are
+// signed with, so tokens do not survive a gateway restart.
+func newBulkDeleteKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("bulk delete key: %v", err))
+	}
+	return key
+}
+
+// bulkDeleteToken binds a confirmation to the exact set of matched
+// collections and the time it was issued.
+func (h *QdrantHandler) bulkDeleteToken(issued int64, names []string) string {
+	mac := hmac.New(sha256.New, h.bulkKey)
+	fmt.Fprintf(mac, "%d\x00%s", issued, strings.Join(names, "\x00"))
+	return strconv.FormatInt(issued, 10) + "." + hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// checkBulkDeleteToken verifies token against names and its age.
+func (h *QdrantHandler) checkBulkDeleteToken(token string, names []string) error {
+	issuedStr, _, ok := strings.Cut(token, ".")
+	issued, err := strconv.ParseInt(issuedStr, 10, 64)
+	if !ok || err != nil {
+		return fmt.Errorf("malformed confirm token")
+	}
+	if time.Since(time.Unix(issued, 0)) > bulkDeleteTokenTTL {
+		return fmt.Errorf("confirm token expired, request a new one")
+	}
+	if !hmac.Equal([]byte(token), []byte(h.bulkDeleteToken(issued, names))) {
+		return fmt.Errorf("confirm token does not match the collections the pattern selects now, request a new one")
+	}
+	return nil
+}
+
+// BulkDeleteCollections deletes every collection whose name matches a glob
+// pattern. It works in two steps: a request without "confirm" only lists
+// the matches and returns a confirm_token; repeating the request with that
+// token performs the deletion. The token is rejected if the matched set
+// changed in between or after bulkDeleteTokenTTL. Collections locked by a
+// running index task are skipped.
+func (h *QdrantHandler) BulkDeleteCollections(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Pattern string `json:"pattern"`
+		Confirm string `json:"confirm"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	req.Pattern = strings.TrimSpace(req.Pattern)
+	if req.Pattern == "" {
+		writeError(w, http.StatusBadRequest, "pattern is required")
+		return
+	}
+	if _, err := path.Match(req.Pattern, ""); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid pattern: %v", err))
+		return
+	}
+
+	all, err := listQdrantCollections(r.Context(), h.client, h.baseURL)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+	matched := []string{}
+	for _, name := range all {
+		if ok, _ := path.Match(req.Pattern, name); ok {
+			matched = append(matched, name)
+		}
+	}
+	sort.Strings(matched)
+
+	if req.Confirm == "" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"dry_run":       true,
+			"pattern":       req.Pattern,
+			"matched":       matched,
+			"count":         len(matched),
+			"confirm_token": h.bulkDeleteToken(time.Now().Unix(), matched),
+			"expires_in_s":  int(bulkDeleteTokenTTL.Seconds()),
+		})
+		return
+	}
+	if err := h.checkBulkDeleteToken(req.Confirm, matched); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	deleted := []string{}
+	skipped := map[string]string{}
+	failed := map[string]string{}
+	for _, name := range matched {
+		if l, locked := h.tm.CollectionLock(name); locked {
+			skipped[name] = fmt.Sprintf("locked by task %s", l.TaskID)
+			continue
+		}
+		if err := h.deleteCollection(r, name); err != nil {
+			failed[name] = err.Error()
+			continue
+		}
+		h.colls.Unbind(name)
+		deleted = append(deleted, name)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"dry_run": false,
+		"pattern": req.Pattern,
+		"deleted": deleted,
+		"skipped": skipped,
+		"failed":  failed,
+	})
+}
+
+// deleteCollection drops one collection in Qdrant.
+func (h *QdrantHandler) deleteCollection(r *http.Request, name string) error {
+	httpReq, err := http.NewRequestWithContext(r.Context(), "DELETE",
+		h.baseURL+"/collections/"+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("qdrant status %d", resp.StatusCode)
+	}
+	return nil
+}
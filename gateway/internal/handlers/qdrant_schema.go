@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// schemaDefaultSample and schemaMaxSample bound the points sampled by
+	// GET /collections/{name}/schema.
+	schemaDefaultSample = 500
+	schemaMaxSample     = 5000
+	// schemaPageSize is the scroll page size used while sampling.
+	schemaPageSize = 250
+	// schemaDefaultExamples and schemaMaxExamples bound the example values
+	// listed per field.
+	schemaDefaultExamples = 3
+	schemaMaxExamples     = 10
+	// schemaMaxDistinct is the number of distinct values counted per field
+	// before the count is reported as truncated.
+	schemaMaxDistinct = 100
+	// schemaExampleRunes truncates long string examples such as chunk text.
+	schemaExampleRunes = 80
+	// schemaTextRunes is the string length from which a field is taken for
+	// free text and a text index is suggested instead of a keyword index.
+	schemaTextRunes = 120
+)
+
+// payloadField accumulates what was observed of one payload field.
+type payloadField struct {
+	Field             string         `json:"field"`
+	Types             map[string]int `json:"types"`
+	Count             int            `json:"count"`
+	Coverage          float64        `json:"coverage"`
+	Array             bool           `json:"array,omitempty"`
+	Examples          []interface{}  `json:"examples"`
+	Distinct          int            `json:"distinct"`
+	DistinctTruncated bool           `json:"distinct_truncated,omitempty"`
+	Min               *float64       `json:"min,omitempty"`
+	Max               *float64       `json:"max,omitempty"`
+	Indexed           string         `json:"indexed,omitempty"`
+	SuggestedIndex    string         `json:"suggested_index,omitempty"`
+
+	distinct map[string]struct{}
+	text     bool // some value is at least schemaTextRunes long
+	points   int  // number of the sampled point the field was last counted for
+}
+
+// payloadSchema collects payloadFields over sampled points.
+type payloadSchema struct {
+	fields   map[string]*payloadField
+	examples int
+	point    int
+}
+
+func newPayloadSchema(examples int) *payloadSchema {
+	return &payloadSchema{fields: make(map[string]*payloadField), examples: examples}
+}
+
+// add records one point's payload.
+func (s *payloadSchema) add(payload map[string]interface{}) {
+	s.point++
+	s.walk("", payload, false)
+}
+
+// walk records the fields of obj under prefix. Objects nest with ".", and
+// objects inside arrays with "[].", matching Qdrant's filter key syntax.
+func (s *payloadSchema) walk(prefix string, obj map[string]interface{}, inArray bool) {
+	for k, v := range obj {
+		s.value(prefix+k, v, inArray)
+	}
+}
+
+func (s *payloadSchema) value(key string, v interface{}, inArray bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		s.walk(key+".", v, inArray)
+	case []interface{}:
+		for _, e := range v {
+			if obj, ok := e.(map[string]interface{}); ok {
+				s.walk(key+"[].", obj, true)
+			} else {
+				s.value(key, e, true)
+			}
+		}
+		if len(v) == 0 {
+			s.field(key).Array = true
+		}
+	default:
+		f := s.field(key)
+		f.Array = f.Array || inArray
+		typ := payloadType(v)
+		f.Types[typ]++
+		if typ == "null" {
+			return
+		}
+		if n, ok := v.(float64); ok {
+			if f.Min == nil || n < *f.Min {
+				f.Min = &n
+			}
+			if f.Max == nil || n > *f.Max {
+				f.Max = &n
+			}
+		}
+		if str, ok := v.(string); ok && len([]rune(str)) >= schemaTextRunes {
+			f.text = true
+		}
+		id := fmt.Sprint(v)
+		if _, seen := f.distinct[id]; seen {
+			return
+		}
+		if len(f.distinct) < schemaMaxDistinct {
+			f.distinct[id] = struct{}{}
+		} else {
+			f.DistinctTruncated = true
+		}
+		if len(f.Examples) < s.examples {
+			if str, ok := v.(string); ok && len([]rune(str)) > schemaExampleRunes {
+				v = string([]rune(str)[:schemaExampleRunes]) + "…"
+			}
+			f.Examples = append(f.Examples, v)
+		}
+	}
+}
+
+// field returns the accumulator for key, counting the current point once.
+func (s *payloadSchema) field(key string) *payloadField {
+	f, ok := s.fields[key]
+	if !ok {
+		f = &payloadField{Field: key, Types: make(map[string]int), Examples: []interface{}{}, distinct: make(map[string]struct{})}
+		s.fields[key] = f
+	}
+	if f.points != s.point {
+		f.points = s.point
+		f.Count++
+	}
+	return f
+}
+
+// result finalises the fields, sorted by name. indexed maps field names to
+// the data type of their Qdrant payload index.
+func (s *payloadSchema) result(indexed map[string]string) []*payloadField {
+	out := make([]*payloadField, 0, len(s.fields))
+	for _, f := range s.fields {
+		f.Distinct = len(f.distinct)
+		if s.point > 0 {
+			f.Coverage = math.Round(float64(f.Count)/float64(s.point)*1000) / 1000
+		}
+		f.Indexed = indexed[f.Field]
+		if f.Indexed == "" {
+			f.Indexed = indexed[strings.ReplaceAll(f.Field, "[]", "")]
+		}
+		f.SuggestedIndex = suggestIndex(f)
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Field < out[j].Field })
+	return out
+}
+
+// payloadType names the JSON type of a decoded payload value. Whole
+// numbers count as integers.
+func payloadType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return "integer"
+		}
+		return "float"
+	case string:
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			return "datetime"
+		}
+		return "string"
+	default:
+		return "unknown"
+	}
+}
+
+// suggestIndex proposes the payload index type for a field with a single
+// observed type, or none for mixed fields.
+func suggestIndex(f *payloadField) string {
+	var typ string
+	for t := range f.Types {
+		switch {
+		case t == "null":
+		case typ == "":
+			typ = t
+		case typ == "integer" && t == "float" || typ == "float" && t == "integer":
+			typ = "float"
+		default:
+			return ""
+		}
+	}
+	switch typ {
+	case "string":
+		if f.text {
+			return "text"
+		}
+		return "keyword"
+	case "integer", "float", "bool", "datetime":
+		return typ
+	}
+	return ""
+}
+
+// CollectionSchema samples up to ?sample= points (default 500, max 5000) of
+// a collection and reports the payload fields observed, with their types,
+// coverage, example values and payload index.
+func (h *QdrantHandler) CollectionSchema(w http.ResponseWriter, r *http.Request) {
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+
+	sample, examples := schemaDefaultSample, schemaDefaultExamples
+	if v := r.URL.Query().Get("sample"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > schemaMaxSample {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("sample must be between 1 and %d", schemaMaxSample))
+			return
+		}
+		sample = n
+	}
+	if v := r.URL.Query().Get("examples"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > schemaMaxExamples {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("examples must be between 0 and %d", schemaMaxExamples))
+			return
+		}
+		examples = n
+	}
+
+	infoReq, err := http.NewRequestWithContext(r.Context(), "GET", h.baseURL+"/collections/"+url.PathEscape(name), nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp, err := h.client.Do(infoReq)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		copyQdrantResponse(w, resp, name)
+		return
+	}
+	var info struct {
+		Result struct {
+			PointsCount   int64 `json:"points_count"`
+			PayloadSchema map[string]struct {
+				DataType string `json:"data_type"`
+			} `json:"payload_schema"`
+		} `json:"result"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "failed to parse qdrant response")
+		return
+	}
+	indexed := make(map[string]string, len(info.Result.PayloadSchema))
+	for field, s := range info.Result.PayloadSchema {
+		indexed[field] = s.DataType
+	}
+
+	schema := newPayloadSchema(examples)
+	var offset interface{}
+	for schema.point < sample {
+		scroll := map[string]interface{}{
+			"limit":        min(schemaPageSize, sample-schema.point),
+			"with_payload": true,
+			"with_vector":  false,
+		}
+		if offset != nil {
+			scroll["offset"] = offset
+		}
+		body, _ := json.Marshal(scroll)
+		req, err := http.NewRequestWithContext(r.Context(), "POST",
+			h.baseURL+"/collections/"+url.PathEscape(name)+"/points/scroll", bytes.NewReader(body))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := h.client.Do(req)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			copyQdrantResponse(w, resp, name)
+			return
+		}
+		var page struct {
+			Result struct {
+				Points []struct {
+					Payload map[string]interface{} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			} `json:"result"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			writeError(w, http.StatusBadGateway, "failed to parse qdrant response")
+			return
+		}
+		for _, p := range page.Result.Points {
+			schema.add(p.Payload)
+		}
+		offset = page.Result.NextPageOffset
+		if offset == nil || len(page.Result.Points) == 0 {
+			break
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"collection":   name,
+		"points_count": info.Result.PointsCount,
+		"sampled":      schema.point,
+		"fields":       schema.result(indexed),
+	})
+}
@@ -3,129 +3,298 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"strconv"
 
 	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/imagemeta"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/plugin"
 	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
 	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc/status"
 )
 
 // RAGHandler provides endpoints for search, indexing, and visualization.
 // Long-running indexing operations are tracked as background tasks.
 type RAGHandler struct {
-	grpc *grpcclient.Client
-	tm   *tasks.Manager
+	grpc  *grpcclient.Client
+	tm    *tasks.Manager
+	colls *CollectionSettings
+	diff  *DiffIndexer
+	meta  *imagemeta.Attacher
+
+	keyword   *KeywordSearcher
+	ignores   *IgnoreProfiles
+	defaults  *SearchDefaults
+	terms     *SearchTerms
+	instances *OllamaInstances
+	images    *ImageSigner
+	guard     *Guardrails
+	check     *CollectionChecker
 }
 
-// NewRAGHandler creates a new RAGHandler.
-func NewRAGHandler(gc *grpcclient.Client, tm *tasks.Manager) *RAGHandler {
-	return &RAGHandler{grpc: gc, tm: tm}
+// NewRAGHandler creates a new RAGHandler. Incremental codebase runs use
+// diff to send the worker only the files that changed; image runs attach
+// metadata extracted by meta. Keyword searches, requested or as a fallback,
+// go through keyword; codebase runs skip what ignores lists. Searches are
+// completed from defaults and rewritten with the synonyms and stopwords of
+// terms before they are forwarded, and image hits get URLs signed by images. Index runs may pin one of instances for embedding and
+// captioning, and only start once guard finds enough disk and memory.
+// Searches in a missing or empty collection are turned away by check.
+func NewRAGHandler(gc *grpcclient.Client, tm *tasks.Manager, colls *CollectionSettings, diff *DiffIndexer, meta *imagemeta.Attacher, keyword *KeywordSearcher, ignores *IgnoreProfiles, defaults *SearchDefaults, terms *SearchTerms, instances *OllamaInstances, images *ImageSigner, guard *Guardrails, check *CollectionChecker) *RAGHandler {
+	return &RAGHandler{grpc: gc, tm: tm, colls: colls, diff: diff, meta: meta, keyword: keyword, ignores: ignores, defaults: defaults, terms: terms, instances: instances, images: images, guard: guard, check: check}
 }
 
 // Routes registers all RAG routes on the given chi router.
 func (h *RAGHandler) Routes(r chi.Router) {
-	r.Post("/search", h.Search)
-	r.Post("/search/{collection}", h.SearchCollection)
-	r.Post("/index/codebase", h.IndexCodebase)
-	r.Post("/index/documents", h.IndexDocuments)
-	r.Post("/index/images", h.IndexImages)
-	r.Get("/visualize/{collection}/overview", h.VisualizeOverview)
-	r.Get("/visualize/{collection}/file-tree", h.VisualizeFileTree)
-	r.Get("/visualize/{collection}/vectors", h.VisualizeVectors)
+	search := r.With(requireWorker(h.grpc, grpcclient.ServiceSearch))
+	search.Post("/search", h.Search)
+	search.Post("/search/multi", h.SearchMulti)
+	search.Post("/search/batch", h.SearchBatch)
+	search.Post("/search/{collection}", h.SearchCollection)
+	r.Get("/locks", h.ListLocks)
+	r.Get("/related", h.Related)
+	index := r.With(requireWorker(h.grpc, grpcclient.ServiceIndexing))
+	index.Post("/index/codebase", h.IndexCodebase)
+	index.Post("/index/documents", h.IndexDocuments)
+	index.Post("/index/images", h.IndexImages)
+	vis := r.With(requireWorker(h.grpc, grpcclient.ServiceVisualization))
+	vis.Get("/visualize/{collection}/overview", h.VisualizeOverview)
+	vis.Get("/visualize/{collection}/overview/export", h.VisualizeOverviewExport)
+	vis.Get("/visualize/{collection}/file-tree", h.VisualizeFileTree)
+	vis.Get("/visualize/{collection}/vectors", h.VisualizeVectors)
 }
 
-// Search performs a global vector search across the default collection.
+// searchRequest is the body of both search endpoints. Mode "keyword" skips
+// the worker and ranks stored chunk text by BM25 instead.
+type searchRequest struct {
+	api.SearchQuery
+	searchTuning
+
+	rewrite *QueryRewrite // set by the collection's synonyms and stopwords
+}
+
+// searchQuery returns the query to search for: the rewritten one, if the
+// collection's lists changed it.
+func (req searchRequest) searchQuery() string {
+	if req.rewrite != nil {
+		return req.rewrite.Query
+	}
+	return req.Query
+}
+
+// Search performs a global vector search across the default collection:
+// the search defaults' collection if set, otherwise the worker's.
 func (h *RAGHandler) Search(w http.ResponseWriter, r *http.Request) {
-	if h.grpc.Search == nil {
-		writeError(w, http.StatusServiceUnavailable, "search service not available")
+	var req searchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
-
-	var req struct {
-		Query    string `json:"query"`
-		TopK     int32  `json:"top_k"`
-		Language string `json:"language"`
-		FilePath string `json:"file_path"`
+	defaults := h.defaults.Get()
+	defaults.fill(&req, searchDefaultTopK)
+	if defaults.Collection != "" {
+		h.searchCollection(w, r, defaults.Collection, req)
+		return
 	}
+	h.search(w, r, workerDefaultCodebaseCollection, req, func(query string, topK int32) (*grpcclient.SearchResponse, error) {
+		return h.grpc.Search.Search(r.Context(), &grpcclient.SearchRequest{
+			Query:    query,
+			TopK:     topK,
+			Language: req.Language,
+			FilePath: req.FilePath,
+		})
+	})
+}
+
+// SearchCollection performs a vector search scoped to a specific collection.
+func (h *RAGHandler) SearchCollection(w http.ResponseWriter, r *http.Request) {
+	collection := chi.URLParam(r, "collection")
+
+	var req searchRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
+	h.defaults.Get().fill(&req, searchDefaultTopK)
+	h.searchCollection(w, r, collection, req)
+}
 
-	resp, err := h.grpc.Search.Search(r.Context(), &grpcclient.SearchRequest{
-		Query:    req.Query,
-		TopK:     req.TopK,
-		Language: req.Language,
-		FilePath: req.FilePath,
+// searchCollection runs a search, already completed from the defaults, in
+// collection.
+func (h *RAGHandler) searchCollection(w http.ResponseWriter, r *http.Request, collection string, req searchRequest) {
+	h.search(w, r, collection, req, func(query string, topK int32) (*grpcclient.SearchResponse, error) {
+		return h.grpc.Search.SearchCollection(r.Context(), &grpcclient.SearchCollectionRequest{
+			Collection: collection,
+			Query:      query,
+			TopK:       topK,
+			Language:   req.Language,
+			FilePath:   req.FilePath,
+		})
 	})
-	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+}
+
+// search runs vector search through the worker, or keyword search when the
+// request asks for it or the worker fails and fallback is enabled. vector
+// is called with the rewritten query and the number of hits to fetch, which
+// exceeds top_k when the results are diversified.
+func (h *RAGHandler) search(w http.ResponseWriter, r *http.Request, collection string, req searchRequest, vector func(query string, topK int32) (*grpcclient.SearchResponse, error)) {
+	switch req.Mode {
+	case "", "vector", "keyword":
+	default:
+		writeError(w, http.StatusBadRequest, "mode must be \"vector\" or \"keyword\"")
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if !checkCollectionLock(w, h.tm, collection) {
+		return
+	}
+	if p := h.check.check(r.Context(), collection); p != nil {
+		p.write(w)
+		return
+	}
+	req.rewrite = h.terms.Rewrite(collection, req.Query)
+	if req.Mode == "keyword" {
+		h.keywordSearch(w, r, collection, req, "")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, resp)
-}
-
-// SearchCollection performs a vector search scoped to a specific collection.
-func (h *RAGHandler) SearchCollection(w http.ResponseWriter, r *http.Request) {
 	if h.grpc.Search == nil {
+		if h.keyword != nil && h.keyword.auto {
+			h.keywordSearch(w, r, collection, req, "search service not available")
+			return
+		}
 		writeError(w, http.StatusServiceUnavailable, "search service not available")
 		return
 	}
-
-	collection := chi.URLParam(r, "collection")
-
-	var req struct {
-		Query    string `json:"query"`
-		TopK     int32  `json:"top_k"`
-		Language string `json:"language"`
-		FilePath string `json:"file_path"`
+	resp, err := vector(req.searchQuery(), req.fetchK(req.TopK))
+	if err != nil {
+		if h.keyword.fallbackFor(err) {
+			log.Printf("WARNING: vector search in %s failed, using keyword search: %v", collection, err)
+			h.keywordSearch(w, r, collection, req, status.Convert(err).Message())
+			return
+		}
+		writeGRPCError(w, err)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid JSON body")
+	if req.active() {
+		resp.Results = req.apply(resp.GetResults(), req.TopK)
+	}
+	results, ok := processSearch(w, r, plugin.Search{Query: req.Query, Collection: collection, Mode: "vector"}, resp.GetResults())
+	if !ok {
 		return
 	}
+	resp.Results = results
 
-	resp, err := h.grpc.Search.SearchCollection(r.Context(), &grpcclient.SearchCollectionRequest{
-		Collection: collection,
-		Query:      req.Query,
-		TopK:       req.TopK,
-		Language:   req.Language,
-		FilePath:   req.FilePath,
+	writeJSON(w, http.StatusOK, api.SearchResponse{
+		Status:       resp.GetStatus(),
+		Query:        resp.GetQuery(),
+		Collection:   resp.GetCollection(),
+		Results:      h.images.imageHits(r, results),
+		QueryRewrite: req.rewrite,
 	})
-	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+}
+
+// keywordSearch answers a search request from Qdrant payloads alone. A
+// non-empty reason marks the response as a degraded fallback.
+func (h *RAGHandler) keywordSearch(w http.ResponseWriter, r *http.Request, collection string, req searchRequest, reason string) {
+	if h.keyword == nil {
+		writeError(w, http.StatusServiceUnavailable, "keyword search not available")
+		return
+	}
+	hits, err := h.keyword.Search(r.Context(), collection, req.searchQuery(), int(req.fetchK(req.TopK)), req.Language, req.FilePath)
+	switch {
+	case errors.Is(err, errNoKeywordTerms):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, errCollectionNotFound):
+		writeErrorCode(w, http.StatusNotFound, CodeCollectionNotFound, fmt.Sprintf("collection %q not found", collection))
+		return
+	case err != nil:
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+	if req.active() {
+		hits = req.apply(hits, req.TopK)
+	}
+	hits, ok := processSearch(w, r, plugin.Search{Query: req.Query, Collection: collection, Mode: "keyword"}, hits)
+	if !ok {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	out := map[string]interface{}{
+		"status":     "ok",
+		"query":      req.Query,
+		"collection": collection,
+		"results":    h.images.imageHits(r, hits),
+		"mode":       "keyword",
+		"degraded":   reason != "",
+	}
+	if reason != "" {
+		out["reason"] = reason
+	}
+	if req.rewrite != nil {
+		out["query_rewrite"] = req.rewrite
+	}
+	writeJSON(w, http.StatusOK, out)
 }
 
+// The index request bodies, also the params of index presets.
+type (
+	indexCodebaseRequest  = api.IndexCodebaseRequest
+	indexDocumentsRequest = api.IndexDocumentsRequest
+	indexImagesRequest    = api.IndexImagesRequest
+)
+
 // IndexCodebase starts a background codebase indexing task.
 func (h *RAGHandler) IndexCodebase(w http.ResponseWriter, r *http.Request) {
+	var req indexCodebaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if taskID, ok := h.startIndexCodebase(w, req, middleware.UsernameFromContext(r.Context())); ok {
+		writeTaskAccepted(w, h.tm, taskID)
+	}
+}
+
+// startIndexCodebase validates req and queues its task, recording uploader
+// in its provenance. It writes the error response and returns false when
+// the task cannot start.
+func (h *RAGHandler) startIndexCodebase(w http.ResponseWriter, req indexCodebaseRequest, uploader string) (string, bool) {
 	if h.grpc.Indexing == nil {
 		writeError(w, http.StatusServiceUnavailable, "indexing service not available")
-		return
+		return "", false
 	}
 
-	var req struct {
-		RootPath      string   `json:"root_path"`
-		Collection    string   `json:"collection"`
-		Incremental   bool     `json:"incremental"`
-		ChunkSize     int32    `json:"chunk_size"`
-		ChunkOverlap  int32    `json:"chunk_overlap"`
-		ExtraSkipDirs []string `json:"extra_skip_dirs"`
+	priority, err := tasks.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid JSON body")
-		return
+	lockMode, err := tasks.ParseLockMode(req.Lock)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	if err := validateSkipEntries(req.ExtraSkipDirs); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
 	}
+	req.Collection, req.ChunkSize, req.ChunkOverlap = h.colls.ResolveIndex(req.Collection, req.ChunkSize, req.ChunkOverlap)
+	var profiles []string
+	req.ExtraSkipDirs, profiles = h.ignores.Merge(req.Collection, req.ExtraSkipDirs)
 
-	// Store params for potential retry.
+	// Store params for potential retry. The merged skip list is kept so a
+	// retry skips the same files even if profiles changed since.
 	params := map[string]interface{}{
 		"root_path":       req.RootPath,
 		"collection":      req.Collection,
@@ -133,101 +302,178 @@ func (h *RAGHandler) IndexCodebase(w http.ResponseWriter, r *http.Request) {
 		"chunk_size":      req.ChunkSize,
 		"chunk_overlap":   req.ChunkOverlap,
 		"extra_skip_dirs": req.ExtraSkipDirs,
+		"ignore_profiles": profiles,
+		"priority":        string(priority),
+		"lock":            string(lockMode),
+		"ollama_instance": req.Instance,
 	}
 
-	taskID := h.tm.Create("index_codebase", params)
-	h.tm.Start(taskID)
-
-	// Launch the gRPC stream in a background goroutine.
+	// Queue the gRPC stream; it runs in the background once a slot is free.
 	ctx, cancel := context.WithCancel(context.Background())
+	ctx, err = h.instances.pin(ctx, req.Instance)
+	if err != nil {
+		cancel()
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	warnings, ok := h.guard.admit(ctx, w, 0)
+	if !ok {
+		cancel()
+		return "", false
+	}
+
+	taskID := h.tm.Create("index_codebase", params)
+	h.tm.AddWarnings(taskID, warnings)
+	if !lockIndexTarget(w, h.tm, taskID, req.Collection, workerDefaultCodebaseCollection, lockMode) {
+		cancel()
+		return "", false
+	}
 	h.tm.SetCancelFunc(taskID, cancel)
 
-	go h.runIndexStream(ctx, taskID, func() (grpcclient.IndexingStream, error) {
-		return h.grpc.Indexing.IndexCodebase(ctx, &grpcclient.IndexCodebaseRequest{
+	prov := grpcclient.Provenance{SourceType: grpcclient.SourceCodebase, Uploader: uploader, TaskID: taskID}
+	open := func(ctx context.Context, files []string) (grpcclient.IndexingStream, error) {
+		return h.grpc.Indexing.IndexCodebase(grpcclient.WithProvenance(ctx, prov), &grpcclient.IndexCodebaseRequest{
 			RootPath:      req.RootPath,
 			Collection:    req.Collection,
 			Incremental:   req.Incremental,
 			ChunkSize:     req.ChunkSize,
 			ChunkOverlap:  req.ChunkOverlap,
 			ExtraSkipDirs: req.ExtraSkipDirs,
+			Files:         files,
 		})
+	}
+	h.tm.Enqueue(taskID, priority, func() {
+		if req.Incremental && h.diff != nil {
+			h.diff.runDifferential(ctx, h.grpc, h.tm, taskID, req.Collection, req.RootPath, req.ExtraSkipDirs, open)
+			return
+		}
+		h.runIndexStream(ctx, taskID, func() (grpcclient.IndexingStream, error) { return open(ctx, nil) })
 	})
 
-	writeJSON(w, http.StatusAccepted, map[string]interface{}{
-		"task_id": taskID,
-		"status":  "started",
-	})
+	return taskID, true
 }
 
 // IndexDocuments starts a background document indexing task.
 func (h *RAGHandler) IndexDocuments(w http.ResponseWriter, r *http.Request) {
+	var req indexDocumentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if taskID, ok := h.startIndexDocuments(w, req, middleware.UsernameFromContext(r.Context())); ok {
+		writeTaskAccepted(w, h.tm, taskID)
+	}
+}
+
+// startIndexDocuments validates req and queues its task, recording uploader
+// in its provenance. It writes the error response and returns false when
+// the task cannot start.
+func (h *RAGHandler) startIndexDocuments(w http.ResponseWriter, req indexDocumentsRequest, uploader string) (string, bool) {
 	if h.grpc.Indexing == nil {
 		writeError(w, http.StatusServiceUnavailable, "indexing service not available")
-		return
+		return "", false
 	}
 
-	var req struct {
-		Paths        []string `json:"paths"`
-		Collection   string   `json:"collection"`
-		ChunkSize    int32    `json:"chunk_size"`
-		ChunkOverlap int32    `json:"chunk_overlap"`
-		SourceTag    string   `json:"source_tag"`
+	priority, err := tasks.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid JSON body")
-		return
+	lockMode, err := tasks.ParseLockMode(req.Lock)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	if err := checkTableOptions(h.grpc, req.Table); err != nil {
+		writeTableOptionsError(w, err)
+		return "", false
 	}
+	req.Collection, req.ChunkSize, req.ChunkOverlap = h.colls.ResolveIndex(req.Collection, req.ChunkSize, req.ChunkOverlap)
 
 	params := map[string]interface{}{
-		"paths":         req.Paths,
-		"collection":    req.Collection,
-		"chunk_size":    req.ChunkSize,
-		"chunk_overlap": req.ChunkOverlap,
-		"source_tag":    req.SourceTag,
+		"paths":           req.Paths,
+		"collection":      req.Collection,
+		"chunk_size":      req.ChunkSize,
+		"chunk_overlap":   req.ChunkOverlap,
+		"source_tag":      req.SourceTag,
+		"priority":        string(priority),
+		"lock":            string(lockMode),
+		"ollama_instance": req.Instance,
+	}
+	if req.Table != nil {
+		params["table"] = req.Table
 	}
-
-	taskID := h.tm.Create("index_documents", params)
-	h.tm.Start(taskID)
 
 	ctx, cancel := context.WithCancel(context.Background())
+	ctx, err = h.instances.pin(ctx, req.Instance)
+	if err != nil {
+		cancel()
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	warnings, ok := h.guard.admit(ctx, w, 0)
+	if !ok {
+		cancel()
+		return "", false
+	}
+
+	taskID := h.tm.Create("index_documents", params)
+	h.tm.AddWarnings(taskID, warnings)
+	if !lockIndexTarget(w, h.tm, taskID, req.Collection, workerDefaultDocumentsCollection, lockMode) {
+		cancel()
+		return "", false
+	}
 	h.tm.SetCancelFunc(taskID, cancel)
 
-	go h.runIndexStream(ctx, taskID, func() (grpcclient.IndexingStream, error) {
-		return h.grpc.Indexing.IndexDocuments(ctx, &grpcclient.IndexDocumentsRequest{
-			Paths:        req.Paths,
-			Collection:   req.Collection,
-			ChunkSize:    req.ChunkSize,
-			ChunkOverlap: req.ChunkOverlap,
-			SourceTag:    req.SourceTag,
+	h.tm.Enqueue(taskID, priority, func() {
+		h.runIndexStream(ctx, taskID, func() (grpcclient.IndexingStream, error) {
+			mctx := grpcclient.WithTableOptions(ctx, req.Table)
+			mctx = grpcclient.WithProvenance(mctx, grpcclient.Provenance{SourceType: grpcclient.SourceDocuments, Uploader: uploader, TaskID: taskID})
+			return h.grpc.Indexing.IndexDocuments(mctx, &grpcclient.IndexDocumentsRequest{
+				Paths:        req.Paths,
+				Collection:   req.Collection,
+				ChunkSize:    req.ChunkSize,
+				ChunkOverlap: req.ChunkOverlap,
+				SourceTag:    req.SourceTag,
+			})
 		})
 	})
 
-	writeJSON(w, http.StatusAccepted, map[string]interface{}{
-		"task_id": taskID,
-		"status":  "started",
-	})
+	return taskID, true
 }
 
 // IndexImages starts a background image indexing task.
 func (h *RAGHandler) IndexImages(w http.ResponseWriter, r *http.Request) {
+	var req indexImagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if taskID, ok := h.startIndexImages(w, req, middleware.UsernameFromContext(r.Context())); ok {
+		writeTaskAccepted(w, h.tm, taskID)
+	}
+}
+
+// startIndexImages validates req and queues its task, recording uploader
+// in its provenance. It writes the error response and returns false when
+// the task cannot start.
+func (h *RAGHandler) startIndexImages(w http.ResponseWriter, req indexImagesRequest, uploader string) (string, bool) {
 	if h.grpc.Indexing == nil {
 		writeError(w, http.StatusServiceUnavailable, "indexing service not available")
-		return
+		return "", false
 	}
 
-	var req struct {
-		RootPath       string   `json:"root_path"`
-		Collection     string   `json:"collection"`
-		VisionModel    string   `json:"vision_model"`
-		CaptionPrompt  string   `json:"caption_prompt"`
-		Incremental    bool     `json:"incremental"`
-		MaxImageSizeKB int32    `json:"max_image_size_kb"`
-		ExtraSkipDirs  []string `json:"extra_skip_dirs"`
+	priority, err := tasks.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid JSON body")
-		return
+	lockMode, err := tasks.ParseLockMode(req.Lock)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
 	}
+	req.Collection, _, _ = h.colls.ResolveIndex(req.Collection, 0, 0)
 
 	params := map[string]interface{}{
 		"root_path":         req.RootPath,
@@ -237,77 +483,56 @@ func (h *RAGHandler) IndexImages(w http.ResponseWriter, r *http.Request) {
 		"incremental":       req.Incremental,
 		"max_image_size_kb": req.MaxImageSizeKB,
 		"extra_skip_dirs":   req.ExtraSkipDirs,
+		"priority":          string(priority),
+		"lock":              string(lockMode),
+		"ollama_instance":   req.Instance,
 	}
 
-	taskID := h.tm.Create("index_images", params)
-	h.tm.Start(taskID)
-
 	ctx, cancel := context.WithCancel(context.Background())
+	ctx, err = h.instances.pin(ctx, req.Instance)
+	if err != nil {
+		cancel()
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	warnings, ok := h.guard.admit(ctx, w, 0)
+	if !ok {
+		cancel()
+		return "", false
+	}
+
+	taskID := h.tm.Create("index_images", params)
+	h.tm.AddWarnings(taskID, warnings)
+	if !lockIndexTarget(w, h.tm, taskID, req.Collection, workerDefaultImagesCollection, lockMode) {
+		cancel()
+		return "", false
+	}
 	h.tm.SetCancelFunc(taskID, cancel)
 
-	go h.runIndexStream(ctx, taskID, func() (grpcclient.IndexingStream, error) {
-		return h.grpc.Indexing.IndexImages(ctx, &grpcclient.IndexImagesRequest{
-			RootPath:       req.RootPath,
-			Collection:     req.Collection,
-			VisionModel:    req.VisionModel,
-			CaptionPrompt:  req.CaptionPrompt,
-			Incremental:    req.Incremental,
-			MaxImageSizeKb: req.MaxImageSizeKB,
-			ExtraSkipDirs:  req.ExtraSkipDirs,
+	h.tm.Enqueue(taskID, priority, func() {
+		mctx, cleanup := h.meta.AttachDir(ctx, req.RootPath, req.ExtraSkipDirs)
+		defer cleanup()
+		h.runIndexStream(ctx, taskID, func() (grpcclient.IndexingStream, error) {
+			pctx := grpcclient.WithProvenance(mctx, grpcclient.Provenance{SourceType: grpcclient.SourceImages, Uploader: uploader, TaskID: taskID})
+			return h.grpc.Indexing.IndexImages(pctx, &grpcclient.IndexImagesRequest{
+				RootPath:       req.RootPath,
+				Collection:     req.Collection,
+				VisionModel:    req.VisionModel,
+				CaptionPrompt:  req.CaptionPrompt,
+				Incremental:    req.Incremental,
+				MaxImageSizeKb: req.MaxImageSizeKB,
+				ExtraSkipDirs:  req.ExtraSkipDirs,
+			})
 		})
 	})
 
-	writeJSON(w, http.StatusAccepted, map[string]interface{}{
-		"task_id": taskID,
-		"status":  "started",
-	})
+	return taskID, true
 }
 
 // runIndexStream consumes a gRPC server stream and updates the task manager
 // with progress events. On completion or error the task is marked accordingly.
 func (h *RAGHandler) runIndexStream(ctx context.Context, taskID string, openStream func() (grpcclient.IndexingStream, error)) {
-	stream, err := openStream()
-	if err != nil {
-		h.tm.Fail(taskID, fmt.Sprintf("failed to open stream: %v", err))
-		return
-	}
-	defer stream.Close()
-
-	for {
-		select {
-		case <-ctx.Done():
-			h.tm.Fail(taskID, "cancelled")
-			return
-		default:
-		}
-
-		progress, err := stream.Recv()
-		if err == io.EOF {
-			// Stream ended without an explicit completed message; mark done.
-			h.tm.Complete(taskID, nil)
-			return
-		}
-		if err != nil {
-			h.tm.Fail(taskID, fmt.Sprintf("stream error: %v", err))
-			return
-		}
-
-		switch progress.Status {
-		case "running":
-			h.tm.UpdateProgress(taskID, float64(progress.Progress), "running")
-		case "completed":
-			h.tm.Complete(taskID, progress.Result)
-			return
-		case "failed":
-			h.tm.Fail(taskID, progress.Error)
-			return
-		case "cancelled":
-			h.tm.Cancel(taskID)
-			return
-		default:
-			log.Printf("[task %s] unknown status: %s", taskID, progress.Status)
-		}
-	}
+	h.tm.ConsumeIndexStream(ctx, h.grpc, taskID, openStream)
 }
 
 // VisualizeOverview returns a force-graph overview for a collection.
@@ -330,7 +555,7 @@ func (h *RAGHandler) VisualizeOverview(w http.ResponseWriter, r *http.Request) {
 		Limit:      limit,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -352,7 +577,7 @@ func (h *RAGHandler) VisualizeFileTree(w http.ResponseWriter, r *http.Request) {
 		FilePath:   filePath,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -391,9 +616,18 @@ func (h *RAGHandler) VisualizeVectors(w http.ResponseWriter, r *http.Request) {
 		Limit:      limit,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, resp)
 }
+
+// ListLocks returns the collections currently locked by reindex tasks.
+func (h *RAGHandler) ListLocks(w http.ResponseWriter, r *http.Request) {
+	locks := h.tm.Locks()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"locks": locks,
+		"count": len(locks),
+	})
+}
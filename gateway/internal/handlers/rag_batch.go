@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// batchSearchMaxQueries bounds the queries of one batch search.
+const batchSearchMaxQueries = 32
+
+// batchSearchQuery is one query of POST /api/rag/search/batch. Collection
+// defaults to the search defaults' collection, else the worker's.
+type batchSearchQuery struct {
+	searchRequest
+	Collection string `json:"collection"`
+}
+
+// batchSearchRequest is the body of POST /api/rag/search/batch.
+type batchSearchRequest struct {
+	searchBudget
+	Queries []batchSearchQuery `json:"queries"`
+}
+
+// batchSearchResult is the outcome of one query, in request order. Status
+// is "ok", "error" or "timeout".
+type batchSearchResult struct {
+	Index int    `json:"index"`
+	Query string `json:"query"`
+	*multiSearchSource
+	Status  string     `json:"status"`
+	Results []imageHit `json:"results"`
+}
+
+// SearchBatch runs several queries concurrently, each in its own
+// collection, and answers them together. A failing query is reported in its
+// result and does not fail the request; with budget_ms, queries still
+// running when the budget runs out are reported as "timeout" and the
+// response is "partial".
+func (h *RAGHandler) SearchBatch(w http.ResponseWriter, r *http.Request) {
+	var req batchSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if msg := req.searchBudget.validate(); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	switch {
+	case len(req.Queries) == 0:
+		writeError(w, http.StatusBadRequest, "queries must not be empty")
+		return
+	case len(req.Queries) > batchSearchMaxQueries:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d queries per batch", batchSearchMaxQueries))
+		return
+	}
+	defaults := h.defaults.Get()
+	for i := range req.Queries {
+		q := &req.Queries[i]
+		switch q.Mode {
+		case "", "vector", "keyword":
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("queries[%d]: mode must be \"vector\" or \"keyword\"", i))
+			return
+		}
+		if msg := q.validate(); msg != "" {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("queries[%d]: %s", i, msg))
+			return
+		}
+		defaults.fill(&q.searchRequest, searchDefaultTopK)
+		if q.Collection == "" {
+			q.Collection = defaults.Collection
+		}
+		if q.Collection == "" {
+			q.Collection = workerDefaultCodebaseCollection
+		}
+	}
+
+	sources := runBudgeted(r.Context(), req.searchBudget, len(req.Queries), func(ctx context.Context, i int) *multiSearchSource {
+		q := req.Queries[i]
+		return h.multiSearchOne(ctx, q.Collection, multiSearchRequest{searchRequest: q.searchRequest, PerCollectionTopK: q.TopK})
+	})
+
+	results := make([]batchSearchResult, len(sources))
+	timedOut := []int{}
+	for i, src := range sources {
+		q := req.Queries[i]
+		res := batchSearchResult{Index: i, Query: q.Query, multiSearchSource: src, Status: "ok", Results: []imageHit{}}
+		switch {
+		case src == nil:
+			res.multiSearchSource = req.searchBudget.timedOut(q.Collection)
+			res.Status = "timeout"
+			timedOut = append(timedOut, i)
+		case src.Error != "":
+			res.Status = "error"
+		default:
+			src.Count = len(src.hits)
+			res.Results = h.images.imageHits(r, src.hits)
+		}
+		results[i] = res
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    "ok",
+		"results":   results,
+		"partial":   len(timedOut) > 0,
+		"timed_out": timedOut,
+	})
+}
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+)
+
+// Dedupe report limits and defaults.
+const (
+	dedupeDefaultThreshold = 0.98
+	dedupeMinThreshold     = 0.5
+	dedupeDefaultMaxPoints = 10000
+	dedupeMaxPoints        = 100000
+	dedupeDefaultMaxGroups = 100
+	dedupeMaxGroups        = 1000
+	// dedupeSearchBatch is how many points are looked up per batch search.
+	dedupeSearchBatch = 64
+	// dedupeNeighbours is how many duplicates one search can find.
+	dedupeNeighbours = 64
+	// dedupeDeleteBatch is how many points one delete request removes.
+	dedupeDeleteBatch = 256
+)
+
+// errDedupeLimit stops a scan once max_points points were read.
+var errDedupeLimit = errors.New("dedupe scan limit reached")
+
+// DedupeHandler finds near-duplicate chunks in a collection and removes
+// the extra copies.
+type DedupeHandler struct {
+	qdrant *QdrantHandler
+	tm     *tasks.Manager
+}
+
+// NewDedupeHandler creates a DedupeHandler that reads and deletes points
+// through qdrant and runs deletions as tasks of tm.
+func NewDedupeHandler(qdrant *QdrantHandler, tm *tasks.Manager) *DedupeHandler {
+	return &DedupeHandler{qdrant: qdrant, tm: tm}
+}
+
+// Routes registers the dedupe routes under /collections.
+func (h *DedupeHandler) Routes(r chi.Router) {
+	r.Post("/{name}/dedupe-report", h.Report)
+}
+
+// dedupeRequest is the body of POST /collections/{name}/dedupe-report.
+type dedupeRequest struct {
+	Method    string  `json:"method"`
+	Threshold float64 `json:"threshold"`
+	MaxPoints int     `json:"max_points"`
+	MaxGroups int     `json:"max_groups"`
+	Delete    bool    `json:"delete"`
+	Priority  string  `json:"priority"`
+	Lock      string  `json:"lock"`
+}
+
+// dedupeMember is a point of a duplicate group.
+type dedupeMember struct {
+	PointID    json.RawMessage `json:"point_id"`
+	FilePath   string          `json:"file_path"`
+	ChunkIndex int             `json:"chunk_index"`
+	// Score is the similarity to the kept point.
+	Score float32 `json:"score,omitempty"`
+}
+
+// dedupeGroup is a kept point and the points duplicating it.
+type dedupeGroup struct {
+	Keep       dedupeMember   `json:"keep"`
+	Duplicates []dedupeMember `json:"duplicates"`
+}
+
+// dedupePayload is the part of a payload a dedupe scan reads.
+type dedupePayload struct {
+	FilePath   string `json:"file_path"`
+	ChunkIndex int    `json:"chunk_index"`
+	Content    string `json:"content"`
+}
+
+// Report handles POST /api/rag/collections/{name}/dedupe-report. It scans
+// up to max_points points and groups near-duplicates: with method "vector"
+// (default) the points whose cosine similarity to a group's first point is
+// at least threshold, with method "text" the points whose content is the
+// same after case and whitespace are folded. Each group keeps the point it
+// was found from, the first in Qdrant's ID order; with delete set, a
+// dedupe_collection task then removes the other points of every group and
+// the response is 202 with its task_id.
+func (h *DedupeHandler) Report(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	req := dedupeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Method == "" {
+		req.Method = "vector"
+	}
+	if req.Method != "vector" && req.Method != "text" {
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation, "method must be \"vector\" or \"text\"")
+		return
+	}
+	if req.Threshold == 0 {
+		req.Threshold = dedupeDefaultThreshold
+	}
+	if req.Threshold < dedupeMinThreshold || req.Threshold > 1 {
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation,
+			fmt.Sprintf("threshold must be between %g and 1", dedupeMinThreshold))
+		return
+	}
+	if req.MaxPoints == 0 {
+		req.MaxPoints = dedupeDefaultMaxPoints
+	}
+	if req.MaxPoints < 1 || req.MaxPoints > dedupeMaxPoints {
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation,
+			fmt.Sprintf("max_points must be between 1 and %d", dedupeMaxPoints))
+		return
+	}
+	if req.MaxGroups == 0 {
+		req.MaxGroups = dedupeDefaultMaxGroups
+	}
+	if req.MaxGroups < 1 || req.MaxGroups > dedupeMaxGroups {
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation,
+			fmt.Sprintf("max_groups must be between 1 and %d", dedupeMaxGroups))
+		return
+	}
+	priority, err := tasks.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	lockMode, err := tasks.ParseLockMode(req.Lock)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	info, status, err := h.qdrant.collectionInfo(r.Context(), name)
+	if status == http.StatusNotFound {
+		writeErrorCode(w, http.StatusNotFound, CodeCollectionNotFound, fmt.Sprintf("collection %s not found", name))
+		return
+	}
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+	if req.Method == "vector" {
+		if info.VectorSize == 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("collection %s has named vectors; use method \"text\"", name))
+			return
+		}
+		if info.Distance != "Cosine" {
+			writeError(w, http.StatusBadRequest,
+				fmt.Sprintf("collection %s uses %s distance; threshold needs Cosine, use method \"text\"", name, info.Distance))
+			return
+		}
+	}
+	if !checkCollectionLock(w, h.tm, name) {
+		return
+	}
+
+	var (
+		groups  []*dedupeGroup
+		scanned int
+	)
+	if req.Method == "vector" {
+		groups, scanned, err = h.vectorGroups(r.Context(), name, req)
+	} else {
+		groups, scanned, err = h.textGroups(r.Context(), name, req)
+	}
+	truncated := errors.Is(err, errDedupeLimit)
+	if truncated {
+		err = nil
+	}
+	if err != nil {
+		if r.Context().Err() != nil {
+			writeError(w, http.StatusGatewayTimeout, "scan did not finish in time; lower max_points or raise X-Timeout-Seconds")
+			return
+		}
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i].Duplicates) > len(groups[j].Duplicates) })
+	duplicates := 0
+	for _, g := range groups {
+		duplicates += len(g.Duplicates)
+	}
+	shown := append([]*dedupeGroup{}, groups...)
+	if len(shown) > req.MaxGroups {
+		shown = shown[:req.MaxGroups]
+	}
+	resp := map[string]interface{}{
+		"collection":       name,
+		"method":           req.Method,
+		"threshold":        req.Threshold,
+		"scanned":          scanned,
+		"truncated":        truncated,
+		"group_count":      len(groups),
+		"duplicate_count":  duplicates,
+		"groups":           shown,
+		"groups_truncated": len(groups) > len(shown),
+	}
+	if req.Method == "text" {
+		delete(resp, "threshold")
+	}
+	if !req.Delete || duplicates == 0 {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	ids := make([]json.RawMessage, 0, duplicates)
+	for _, g := range groups {
+		for _, d := range g.Duplicates {
+			ids = append(ids, d.PointID)
+		}
+	}
+	taskID := h.tm.Create("dedupe_collection", map[string]interface{}{
+		"collection": name,
+		"method":     req.Method,
+		"threshold":  req.Threshold,
+		"groups":     len(groups),
+		"duplicates": duplicates,
+		"priority":   string(priority),
+		"lock":       string(lockMode),
+	})
+	if !lockIndexTarget(w, h.tm, taskID, name, name, lockMode) {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	h.tm.SetCancelFunc(taskID, cancel)
+	h.tm.Enqueue(taskID, priority, func() {
+		h.deleteDuplicates(ctx, taskID, name, ids, len(groups))
+	})
+	resp["task_id"] = taskID
+	resp["status"] = taskStartStatus(h.tm, taskID)
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// vectorGroups groups points by vector similarity. Every point not yet in
+// a group is searched for; its unassigned neighbours at or above the
+// threshold form its group.
+func (h *DedupeHandler) vectorGroups(ctx context.Context, name string, req dedupeRequest) ([]*dedupeGroup, int, error) {
+	type pending struct {
+		id      json.RawMessage
+		payload dedupePayload
+		vector  json.RawMessage
+	}
+	var (
+		groups   []*dedupeGroup
+		assigned = map[string]bool{}
+		batch    []pending
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		searches := make([]map[string]interface{}, len(batch))
+		for i, p := range batch {
+			searches[i] = map[string]interface{}{
+				"vector":          p.vector,
+				"limit":           dedupeNeighbours + 1,
+				"score_threshold": req.Threshold,
+				"with_payload":    []string{"file_path", "chunk_index"},
+			}
+		}
+		var out struct {
+			Result [][]struct {
+				ID      json.RawMessage `json:"id"`
+				Score   float32         `json:"score"`
+				Payload dedupePayload   `json:"payload"`
+			} `json:"result"`
+		}
+		if err := h.dedupePost(ctx, "/collections/"+url.PathEscape(name)+"/points/search/batch",
+			map[string]interface{}{"searches": searches}, &out); err != nil {
+			return err
+		}
+		if len(out.Result) != len(batch) {
+			return fmt.Errorf("qdrant returned %d results for %d searches", len(out.Result), len(batch))
+		}
+		for i, p := range batch {
+			if assigned[string(p.id)] {
+				continue
+			}
+			assigned[string(p.id)] = true
+			g := &dedupeGroup{Keep: dedupeMember{PointID: p.id, FilePath: p.payload.FilePath, ChunkIndex: p.payload.ChunkIndex}}
+			for _, n := range out.Result[i] {
+				if assigned[string(n.ID)] {
+					continue
+				}
+				assigned[string(n.ID)] = true
+				g.Duplicates = append(g.Duplicates, dedupeMember{
+					PointID: n.ID, FilePath: n.Payload.FilePath, ChunkIndex: n.Payload.ChunkIndex, Score: n.Score,
+				})
+			}
+			if len(g.Duplicates) > 0 {
+				groups = append(groups, g)
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	scanned := 0
+	_, err := h.qdrant.scrollPoints(ctx, name, true, func(p archivePoint) error {
+		if scanned == req.MaxPoints {
+			return errDedupeLimit
+		}
+		scanned++
+		if assigned[string(p.ID)] {
+			return nil
+		}
+		var payload dedupePayload
+		json.Unmarshal(p.Payload, &payload)
+		batch = append(batch, pending{id: p.ID, payload: payload, vector: p.Vector})
+		if len(batch) < dedupeSearchBatch {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil || errors.Is(err, errDedupeLimit) {
+		if ferr := flush(); ferr != nil {
+			err = ferr
+		}
+	}
+	return groups, scanned, err
+}
+
+// textGroups groups points whose content is equal once lowercased and
+// with whitespace collapsed. Points without content are ignored.
+func (h *DedupeHandler) textGroups(ctx context.Context, name string, req dedupeRequest) ([]*dedupeGroup, int, error) {
+	var (
+		groups []*dedupeGroup
+		byText = map[[sha256.Size]byte]*dedupeGroup{}
+	)
+	scanned := 0
+	_, err := h.qdrant.scrollPoints(ctx, name, false, func(p archivePoint) error {
+		if scanned == req.MaxPoints {
+			return errDedupeLimit
+		}
+		scanned++
+		var payload dedupePayload
+		json.Unmarshal(p.Payload, &payload)
+		text := strings.Join(strings.Fields(strings.ToLower(payload.Content)), " ")
+		if text == "" {
+			return nil
+		}
+		m := dedupeMember{PointID: p.ID, FilePath: payload.FilePath, ChunkIndex: payload.ChunkIndex}
+		key := sha256.Sum256([]byte(text))
+		g, ok := byText[key]
+		if !ok {
+			byText[key] = &dedupeGroup{Keep: m}
+			return nil
+		}
+		if len(g.Duplicates) == 0 {
+			groups = append(groups, g)
+		}
+		m.Score = 1
+		g.Duplicates = append(g.Duplicates, m)
+		return nil
+	})
+	return groups, scanned, err
+}
+
+// deleteDuplicates runs a dedupe_collection task: it deletes ids from the
+// collection in batches.
+func (h *DedupeHandler) deleteDuplicates(ctx context.Context, taskID, name string, ids []json.RawMessage, groups int) {
+	deleted := 0
+	for start := 0; start < len(ids); start += dedupeDeleteBatch {
+		batch := ids[start:min(start+dedupeDeleteBatch, len(ids))]
+		err := h.dedupePost(ctx, "/collections/"+url.PathEscape(name)+"/points/delete?wait=true",
+			map[string]interface{}{"points": batch}, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				h.tm.Cancel(taskID)
+				return
+			}
+			h.tm.Fail(taskID, fmt.Sprintf("delete points: %v (%d of %d deleted)", err, deleted, len(ids)))
+			return
+		}
+		deleted += len(batch)
+		h.tm.UpdateProgress(taskID, float64(deleted)/float64(len(ids)), "running")
+	}
+	h.tm.Complete(taskID, map[string]string{
+		"collection": name,
+		"groups":     strconv.Itoa(groups),
+		"deleted":    strconv.Itoa(deleted),
+	})
+}
+
+// dedupePost sends a JSON body to Qdrant and decodes the response into out
+// (if non-nil).
+func (h *DedupeHandler) dedupePost(ctx context.Context, path string, body, out interface{}) error {
+	data, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, "POST", h.qdrant.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.qdrant.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("qdrant error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("qdrant status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,449 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// migrateStatsInterval is how often a migration records its throughput
+	// on the task timeline.
+	migrateStatsInterval = 30 * time.Second
+	// migrateTextField is the payload field holding a point's text: the
+	// chunk content, or the caption of an image.
+	migrateTextField = "content"
+)
+
+// MigrationHandler re-embeds whole collections with another embedding
+// model. The worker has no RPC that returns vectors, so texts are embedded
+// through Ollama's /api/embed, as POST /api/ollama/embeddings does.
+type MigrationHandler struct {
+	qdrant *QdrantHandler
+	ollama *OllamaHandler
+	tm     *tasks.Manager
+}
+
+// NewMigrationHandler creates a MigrationHandler that reads and writes
+// collections through qdrant and embeds through the instances of ollama.
+func NewMigrationHandler(qdrant *QdrantHandler, ollama *OllamaHandler, tm *tasks.Manager) *MigrationHandler {
+	return &MigrationHandler{qdrant: qdrant, ollama: ollama, tm: tm}
+}
+
+// Routes registers the migration routes.
+func (h *MigrationHandler) Routes(r chi.Router) {
+	r.Post("/", h.Migrate)
+}
+
+// migration is one re-embedding run.
+type migration struct {
+	Source     string
+	Target     string
+	Alias      string
+	DropSource bool
+	Model      string
+	InstURL    string
+	BatchSize  int
+}
+
+// Migrate starts a migrate_collection task that copies every point of a
+// collection into a new one, re-embedding its text with model, and then
+// points an alias at the new collection:
+//
+//   - if collection is an alias, that alias is moved;
+//   - otherwise alias names the alias to create or move;
+//   - without alias, drop_source deletes the old collection once the copy
+//     is complete and puts an alias with its name in its place.
+//
+// The old collection is otherwise kept. Points without text are not
+// copied and are counted as skipped.
+func (h *MigrationHandler) Migrate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Collection string `json:"collection"`
+		Target     string `json:"target"`
+		Alias      string `json:"alias"`
+		DropSource bool   `json:"drop_source"`
+		Model      string `json:"model"`
+		Instance   string `json:"instance"`
+		BatchSize  int    `json:"batch_size"`
+		Priority   string `json:"priority"`
+		Lock       string `json:"lock"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Collection == "" {
+		writeError(w, http.StatusBadRequest, "collection is required")
+		return
+	}
+	priority, err := tasks.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	lockMode, err := tasks.ParseLockMode(req.Lock)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	inst, err := h.ollama.instances.Resolve(req.Instance)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.BatchSize <= 0 {
+		req.BatchSize = defaultEmbedBatchSize
+	}
+	req.BatchSize = min(req.BatchSize, maxEmbedBatchSize)
+
+	if req.Model == "" {
+		if h.ollama.grpc == nil || h.ollama.grpc.Embedding == nil {
+			writeError(w, http.StatusBadRequest, "model is required when the embedding service is unavailable")
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), embedInfoTimeout)
+		info, err := h.ollama.grpc.Embedding.GetInfo(ctx)
+		cancel()
+		if err != nil {
+			writeGRPCError(w, err)
+			return
+		}
+		req.Model = info.Model
+	}
+
+	aliases, err := h.qdrant.aliases(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	m := migration{Source: req.Collection, Target: req.Target, Alias: req.Alias, DropSource: req.DropSource,
+		Model: req.Model, InstURL: inst.URL, BatchSize: req.BatchSize}
+	if real, ok := aliases[req.Collection]; ok {
+		m.Source = real
+		if m.Alias == "" {
+			m.Alias = req.Collection
+		}
+		m.DropSource = false
+	}
+	switch {
+	case m.Alias == "" && !m.DropSource:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf(
+			"collection %s is not an alias; set alias to name the alias for the migrated collection, or drop_source to replace the collection by an alias", req.Collection))
+		return
+	case m.Alias == "":
+		m.Alias = m.Source
+	case m.DropSource:
+		writeError(w, http.StatusBadRequest, "drop_source only applies without alias")
+		return
+	}
+	if m.Target == "" {
+		m.Target = m.Source + "_" + strings.NewReplacer(":", "-", "/", "-").Replace(m.Model)
+	}
+	if m.Target == m.Source {
+		writeError(w, http.StatusBadRequest, "target must differ from the source collection")
+		return
+	}
+	if _, isAlias := aliases[m.Alias]; !isAlias && !m.DropSource {
+		if _, status, _ := h.qdrant.collectionInfo(r.Context(), m.Alias); status == http.StatusOK {
+			writeError(w, http.StatusConflict, fmt.Sprintf("alias %s is the name of a collection", m.Alias))
+			return
+		}
+	}
+
+	source, status, err := h.qdrant.collectionInfo(r.Context(), m.Source)
+	if status == http.StatusNotFound {
+		writeErrorCode(w, http.StatusNotFound, CodeCollectionNotFound, fmt.Sprintf("collection %s not found", m.Source))
+		return
+	}
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+	if source.VectorSize == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("collection %s has named vectors, which cannot be migrated", m.Source))
+		return
+	}
+	if _, status, _ := h.qdrant.collectionInfo(r.Context(), m.Target); status == http.StatusOK {
+		writeError(w, http.StatusConflict, fmt.Sprintf("collection %s already exists", m.Target))
+		return
+	}
+
+	taskID := h.tm.Create("migrate_collection", map[string]interface{}{
+		"collection":  req.Collection,
+		"source":      m.Source,
+		"target":      m.Target,
+		"alias":       m.Alias,
+		"drop_source": m.DropSource,
+		"model":       m.Model,
+		"instance":    inst.Name,
+		"batch_size":  m.BatchSize,
+		"priority":    string(priority),
+		"lock":        string(lockMode),
+	})
+	if !lockIndexTarget(w, h.tm, taskID, m.Source, m.Source, lockMode) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.tm.SetCancelFunc(taskID, cancel)
+	h.tm.Enqueue(taskID, priority, func() {
+		h.run(ctx, taskID, m, source)
+	})
+	writeTaskAccepted(w, h.tm, taskID)
+}
+
+// run copies the points, re-embedding them batch by batch, and swaps the
+// alias. A failed or cancelled run deletes the partial target.
+func (h *MigrationHandler) run(ctx context.Context, taskID string, m migration, source collectionManifest) {
+	start := time.Now()
+	total, err := h.qdrant.countPoints(ctx, m.Source)
+	if err != nil {
+		h.tm.Fail(taskID, fmt.Sprintf("count points: %v", err))
+		return
+	}
+
+	var (
+		created         bool
+		copied, skipped int
+		dimension       int
+		lastStats       = start
+		batch           []archivePoint
+		texts           []string
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		vecs, _, err := h.ollama.embedBatch(ctx, m.InstURL, m.Model, texts, nil)
+		if err != nil {
+			return err
+		}
+		if len(vecs) != len(texts) {
+			return fmt.Errorf("ollama returned %d embeddings for %d texts", len(vecs), len(texts))
+		}
+		if !created {
+			dimension = len(vecs[0])
+			if err := h.createTarget(ctx, m.Target, source, dimension); err != nil {
+				return err
+			}
+			created = true
+		}
+		points := make([]json.RawMessage, len(batch))
+		for i, p := range batch {
+			points[i], _ = json.Marshal(map[string]interface{}{"id": p.ID, "vector": vecs[i], "payload": p.Payload})
+		}
+		if err := h.qdrant.upsertPoints(ctx, m.Target, points); err != nil {
+			return err
+		}
+		copied += len(batch)
+		batch, texts = batch[:0], texts[:0]
+
+		if total > 0 {
+			h.tm.UpdateProgress(taskID, float64(copied+skipped)/float64(total), "running")
+		}
+		if time.Since(lastStats) >= migrateStatsInterval {
+			lastStats = time.Now()
+			h.tm.RecordEvent(taskID, tasks.EventBatch, fmt.Sprintf("%d of %d points copied, %.1f points/s",
+				copied, total, float64(copied)/time.Since(start).Seconds()))
+		}
+		return nil
+	}
+
+	_, err = h.qdrant.scrollPoints(ctx, m.Source, false, func(p archivePoint) error {
+		var payload map[string]interface{}
+		json.Unmarshal(p.Payload, &payload)
+		text, _ := payload[migrateTextField].(string)
+		if strings.TrimSpace(text) == "" {
+			skipped++
+			return nil
+		}
+		batch = append(batch, p)
+		texts = append(texts, text)
+		if len(batch) < m.BatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err == nil && !created {
+		err = errors.New("no point of the collection has text to embed")
+	}
+	if err == nil {
+		err = h.qdrant.swapAlias(ctx, m)
+	}
+	if err != nil {
+		if created {
+			h.qdrant.dropCollection(m.Target)
+		}
+		if ctx.Err() != nil {
+			h.tm.Cancel(taskID)
+			return
+		}
+		h.tm.Fail(taskID, err.Error())
+		return
+	}
+
+	elapsed := time.Since(start).Seconds()
+	h.tm.Complete(taskID, map[string]string{
+		"source":            m.Source,
+		"target":            m.Target,
+		"alias":             m.Alias,
+		"model":             m.Model,
+		"dimension":         strconv.Itoa(dimension),
+		"points":            strconv.Itoa(copied),
+		"skipped":           strconv.Itoa(skipped),
+		"seconds":           strconv.FormatFloat(elapsed, 'f', 1, 64),
+		"points_per_second": strconv.FormatFloat(float64(copied)/max(elapsed, 0.001), 'f', 1, 64),
+	})
+}
+
+// createTarget creates the migrated collection with the source's distance
+// and payload indexes and the new model's dimension.
+func (h *MigrationHandler) createTarget(ctx context.Context, name string, source collectionManifest, dimension int) error {
+	spec := source
+	spec.SparseVectors = nil
+	spec.Vectors, _ = json.Marshal(map[string]interface{}{"size": dimension, "distance": source.Distance})
+	if _, err := h.qdrant.createFromManifest(ctx, name, spec); err != nil {
+		return err
+	}
+	for _, idx := range source.PayloadIndexes {
+		if err := h.qdrant.putPayloadIndex(ctx, name, idx.FieldName, idx.schema()); err != nil {
+			log.Printf("WARNING: migrating to %s: payload index %s: %v", name, idx.FieldName, err)
+		}
+	}
+	return nil
+}
+
+// aliases returns every alias with the collection it points at.
+func (h *QdrantHandler) aliases(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", h.baseURL+"/aliases", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("qdrant error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("qdrant status %d", resp.StatusCode)
+	}
+	var raw struct {
+		Result struct {
+			Aliases []struct {
+				AliasName      string `json:"alias_name"`
+				CollectionName string `json:"collection_name"`
+			} `json:"aliases"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, errors.New("failed to parse qdrant response")
+	}
+	out := make(map[string]string, len(raw.Result.Aliases))
+	for _, a := range raw.Result.Aliases {
+		out[a.AliasName] = a.CollectionName
+	}
+	return out, nil
+}
+
+// countPoints returns the exact number of points in a collection.
+func (h *QdrantHandler) countPoints(ctx context.Context, name string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", h.baseURL+"/collections/"+url.PathEscape(name)+"/points/count",
+		strings.NewReader(`{"exact":true}`))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("qdrant error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return 0, fmt.Errorf("qdrant status %d", resp.StatusCode)
+	}
+	var raw struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return 0, errors.New("failed to parse qdrant response")
+	}
+	return raw.Result.Count, nil
+}
+
+// swapAlias points the migration's alias at its target. With DropSource
+// the source collection is deleted first, as an alias cannot share its
+// name; otherwise the alias moves in one atomic update.
+func (h *QdrantHandler) swapAlias(ctx context.Context, m migration) error {
+	if m.DropSource {
+		req, err := http.NewRequestWithContext(ctx, "DELETE", h.baseURL+"/collections/"+url.PathEscape(m.Source), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := h.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("delete source: qdrant error: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("delete source: qdrant status %d", resp.StatusCode)
+		}
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"actions": []interface{}{
+			map[string]interface{}{"delete_alias": map[string]string{"alias_name": m.Alias}},
+			map[string]interface{}{"create_alias": map[string]string{"collection_name": m.Target, "alias_name": m.Alias}},
+		},
+	})
+	if _, err := h.updateAliases(ctx, body); err == nil {
+		return nil
+	}
+	// Deleting an alias that does not exist fails the whole update.
+	body, _ = json.Marshal(map[string]interface{}{
+		"actions": []interface{}{
+			map[string]interface{}{"create_alias": map[string]string{"collection_name": m.Target, "alias_name": m.Alias}},
+		},
+	})
+	_, err := h.updateAliases(ctx, body)
+	return err
+}
+
+// updateAliases applies alias actions.
+func (h *QdrantHandler) updateAliases(ctx context.Context, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", h.baseURL+"/collections/aliases?timeout=60", bytes.NewReader(body))
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return http.StatusBadGateway, fmt.Errorf("qdrant error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return resp.StatusCode, fmt.Errorf("update aliases: qdrant status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return http.StatusOK, nil
+}
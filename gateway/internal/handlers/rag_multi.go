@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/plugin"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Multi-collection search limits.
+const (
+	multiSearchMaxCollections = 32
+	multiSearchConcurrency    = 8
+	multiSearchDefaultTopK    = 10
+)
+
+// multiSearchRequest is the body of POST /api/rag/search/multi.
+// Collections is a list of names or the string "all".
+type multiSearchRequest struct {
+	searchRequest
+	searchBudget
+	Collections       json.RawMessage `json:"collections"`
+	PerCollectionTopK int32           `json:"per_collection_top_k"`
+}
+
+// multiSearchHit is a worker hit attributed to its collection. Score is
+// normalised against the collection's best hit so rankings from different
+// collections (and from BM25) can be merged; RawScore is the original.
+type multiSearchHit struct {
+	*grpcclient.SearchHit
+	Collection string      `json:"collection"`
+	Score      float32     `json:"score"`
+	RawScore   float32     `json:"raw_score"`
+	ImageURL   string      `json:"image_url,omitempty"`
+	Media      *MediaRange `json:"media,omitempty"`
+}
+
+// multiSearchSource summarises the search in one collection.
+type multiSearchSource struct {
+	Collection string `json:"collection"`
+	Count      int    `json:"count"`
+	Mode       string `json:"mode,omitempty"`
+	Degraded   bool   `json:"degraded,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Stale      bool   `json:"stale,omitempty"`
+	// TimedOut is set when the search missed the request's budget_ms.
+	TimedOut bool   `json:"timed_out,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// QueryRewrite is how the collection's synonyms and stopwords changed
+	// the query.
+	QueryRewrite *QueryRewrite `json:"query_rewrite,omitempty"`
+
+	hits []*grpcclient.SearchHit
+	// grpcCode is the worker's status code when the vector search failed.
+	grpcCode codes.Code
+}
+
+// SearchMulti searches several collections concurrently and merges the
+// hits into one ranking. Each collection contributes at most
+// per_collection_top_k hits; a failing or locked collection is reported in
+// "collections" and does not fail the request, nor does one that misses
+// budget_ms, which makes the response "partial". score_threshold and mmr
+// are applied in each collection, and mmr once more to the merged ranking.
+func (h *RAGHandler) SearchMulti(w http.ResponseWriter, r *http.Request) {
+	var req multiSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	switch req.Mode {
+	case "", "vector", "keyword":
+	default:
+		writeError(w, http.StatusBadRequest, "mode must be \"vector\" or \"keyword\"")
+		return
+	}
+	if msg := req.searchRequest.validate(); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if msg := req.searchBudget.validate(); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	h.defaults.Get().fill(&req.searchRequest, multiSearchDefaultTopK)
+	if req.PerCollectionTopK <= 0 {
+		req.PerCollectionTopK = req.TopK
+	}
+
+	collections, ok := h.multiSearchCollections(w, r, req.Collections)
+	if !ok {
+		return
+	}
+
+	sources := runBudgeted(r.Context(), req.searchBudget, len(collections), func(ctx context.Context, i int) *multiSearchSource {
+		return h.multiSearchOne(ctx, collections[i], req)
+	})
+	timedOut := []string{}
+	for i, src := range sources {
+		if src == nil {
+			sources[i] = req.searchBudget.timedOut(collections[i])
+			timedOut = append(timedOut, collections[i])
+		}
+	}
+
+	results := []multiSearchHit{}
+	failed := 0
+	for _, src := range sources {
+		if src.Error != "" {
+			failed++
+			continue
+		}
+		var best float32
+		for _, hit := range src.hits {
+			if hit.Score > best {
+				best = hit.Score
+			}
+		}
+		for _, hit := range src.hits {
+			score := hit.Score
+			if best > 0 {
+				score /= best
+			}
+			results = append(results, multiSearchHit{SearchHit: hit, Collection: src.Collection, Score: score, RawScore: hit.Score, ImageURL: h.images.imageURL(r, hit), Media: h.images.mediaRange(r, hit)})
+		}
+		src.Count = len(src.hits)
+	}
+	if failed == len(sources) && len(timedOut) == 0 {
+		detail := fmt.Sprintf("search failed in every collection (%s: %s)", sources[0].Collection, sources[0].Error)
+		if c, ok := commonGRPCCode(sources); ok {
+			_, code := grpcToHTTP(c)
+			writeWorkerError(w, c, code, detail)
+			return
+		}
+		writeError(w, http.StatusBadGateway, detail)
+		return
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if req.MMR {
+		results = diversifyMulti(results, int(req.TopK), req.lambda())
+	}
+	if len(results) > int(req.TopK) {
+		results = results[:req.TopK]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "ok",
+		"query":       req.Query,
+		"results":     results,
+		"collections": sources,
+		"partial":     len(timedOut) > 0,
+		"timed_out":   timedOut,
+	})
+}
+
+// multiSearchOne searches one collection and post-processes its hits: the
+// request's filters to per_collection_top_k, then the search plugins.
+func (h *RAGHandler) multiSearchOne(ctx context.Context, collection string, req multiSearchRequest) *multiSearchSource {
+	src := &multiSearchSource{Collection: collection}
+	h.searchOne(ctx, src, req)
+	if src.Error != "" {
+		return src
+	}
+	if req.active() {
+		src.hits = req.apply(src.hits, req.PerCollectionTopK)
+	}
+	hits, err := plugin.ProcessSearch(ctx, plugin.Search{Query: req.Query, Collection: src.Collection, Mode: src.Mode}, src.hits)
+	if err != nil {
+		log.Printf("ERROR: search post-processing in %s: %v", src.Collection, err)
+		src.Error, src.hits = "search post-processing failed", nil
+		return src
+	}
+	src.hits = hits
+	return src
+}
+
+// searchOne fills src with the hits of one collection, following the same
+// locking and keyword fallback rules as single-collection search.
+func (h *RAGHandler) searchOne(ctx context.Context, src *multiSearchSource, req multiSearchRequest) {
+	if l, ok := h.tm.CollectionLock(src.Collection); ok {
+		if l.Mode == tasks.LockBlock {
+			src.Error = fmt.Sprintf("collection is being reindexed (task %s)", l.TaskID)
+			return
+		}
+		src.Stale = true
+	}
+	if p := h.check.check(ctx, src.Collection); p != nil {
+		src.Error = p.detail
+		return
+	}
+	req.rewrite = h.terms.Rewrite(src.Collection, req.Query)
+	src.QueryRewrite = req.rewrite
+
+	keyword := func(reason string) {
+		src.Mode = "keyword"
+		src.Degraded = reason != ""
+		src.Reason = reason
+		if h.keyword == nil {
+			src.Error = "keyword search not available"
+			return
+		}
+		hits, err := h.keyword.Search(ctx, src.Collection, req.searchQuery(), int(req.fetchK(req.PerCollectionTopK)), req.Language, req.FilePath)
+		if err != nil {
+			src.Error = err.Error()
+			return
+		}
+		src.hits = hits
+	}
+
+	switch {
+	case req.Mode == "keyword":
+		keyword("")
+		return
+	case h.grpc.Search == nil:
+		if h.keyword != nil && h.keyword.auto {
+			keyword("search service not available")
+			return
+		}
+		src.Error = "search service not available"
+		return
+	}
+
+	src.Mode = "vector"
+	resp, err := h.grpc.Search.SearchCollection(ctx, &grpcclient.SearchCollectionRequest{
+		Collection: src.Collection,
+		Query:      req.searchQuery(),
+		TopK:       req.fetchK(req.PerCollectionTopK),
+		Language:   req.Language,
+		FilePath:   req.FilePath,
+	})
+	if err != nil {
+		if h.keyword.fallbackFor(err) {
+			log.Printf("WARNING: vector search in %s failed, using keyword search: %v", src.Collection, err)
+			keyword(status.Convert(err).Message())
+			return
+		}
+		st := status.Convert(err)
+		src.Error, src.grpcCode = st.Message(), st.Code()
+		return
+	}
+	src.hits = resp.GetResults()
+}
+
+// multiSearchCollections resolves the "collections" field: a non-empty list
+// of names, or "all" for every Qdrant collection. It writes the error
+// response itself when it fails.
+func (h *RAGHandler) multiSearchCollections(w http.ResponseWriter, r *http.Request, raw json.RawMessage) ([]string, bool) {
+	const usage = "collections must be a list of names or \"all\""
+	var all string
+	if err := json.Unmarshal(raw, &all); err == nil {
+		if all != "all" {
+			writeError(w, http.StatusBadRequest, usage)
+			return nil, false
+		}
+		if h.keyword == nil {
+			writeError(w, http.StatusServiceUnavailable, "collection listing not available")
+			return nil, false
+		}
+		names, err := listQdrantCollections(r.Context(), h.keyword.client, h.keyword.baseURL)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+			return nil, false
+		}
+		if len(names) == 0 {
+			writeError(w, http.StatusNotFound, "no collections to search")
+			return nil, false
+		}
+		if len(names) > multiSearchMaxCollections {
+			writeError(w, http.StatusBadRequest,
+				fmt.Sprintf("%d collections exceed the limit of %d; list them explicitly", len(names), multiSearchMaxCollections))
+			return nil, false
+		}
+		sort.Strings(names)
+		return names, true
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil || len(list) == 0 {
+		writeError(w, http.StatusBadRequest, usage)
+		return nil, false
+	}
+	out := make([]string, 0, len(list))
+	for _, c := range list {
+		if c == "" {
+			writeError(w, http.StatusBadRequest, "collection names must not be empty")
+			return nil, false
+		}
+		if !containsString(out, c) {
+			out = append(out, c)
+		}
+	}
+	if len(out) > multiSearchMaxCollections {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d collections per search", multiSearchMaxCollections))
+		return nil, false
+	}
+	return out, true
+}
+
+// diversifyMulti re-selects k of the merged hits by MMR on their
+// normalised scores.
+func diversifyMulti(results []multiSearchHit, k int, lambda float32) []multiSearchHit {
+	hits := make([]*grpcclient.SearchHit, len(results))
+	scores := make([]float32, len(results))
+	for i, res := range results {
+		hits[i] = res.SearchHit
+		scores[i] = res.Score
+	}
+	order := mmrSelect(hits, scores, k, lambda)
+	out := make([]multiSearchHit, len(order))
+	for i, j := range order {
+		out[i] = results[j]
+	}
+	return out
+}
+
+// commonGRPCCode returns the worker status code shared by every failed
+// source, so a request failing the same way everywhere is reported with
+// that status rather than a generic 502.
+func commonGRPCCode(sources []*multiSearchSource) (codes.Code, bool) {
+	c := sources[0].grpcCode
+	if c == codes.OK {
+		return c, false
+	}
+	for _, src := range sources[1:] {
+		if src.grpcCode != c {
+			return c, false
+		}
+	}
+	return c, true
+}
@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/plugin"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+const (
+	relatedDefaultLimit = 10
+	relatedMaxLimit     = 50
+)
+
+// relatedPoint is a Qdrant point with its vector.
+type relatedPoint struct {
+	ID      interface{}     `json:"id"`
+	Payload keywordPayload  `json:"payload"`
+	Vector  json.RawMessage `json:"vector"`
+}
+
+// relatedSource describes the point whose neighbours were searched.
+type relatedSource struct {
+	PointID    interface{} `json:"point_id"`
+	Collection string      `json:"collection"`
+	FilePath   string      `json:"file_path"`
+	ChunkIndex int         `json:"chunk_index"`
+	Language   string      `json:"language"`
+}
+
+// relatedHit is a neighbour of the source point. PointID can be passed
+// back to /related to keep navigating.
+type relatedHit struct {
+	*grpcclient.SearchHit
+	PointID    interface{} `json:"point_id"`
+	Collection string      `json:"collection"`
+	ImageURL   string      `json:"image_url,omitempty"`
+	Media      *MediaRange `json:"media,omitempty"`
+}
+
+// relatedQuery is the neighbour search run in every target collection.
+type relatedQuery struct {
+	vector      []float64
+	exclude     interface{} // source point ID
+	excludeFile string      // with exclude_file, the source's file_path
+	limit       int
+	minScore    *float64
+}
+
+// Related handles GET /api/rag/related: the nearest neighbours of an
+// existing point by vector similarity, for "more like this" navigation.
+// The point is given by ?point_id=, or by ?file_path= and ?chunk_index=
+// (zero-based, default 0) as search hits and citations carry them, in
+// ?collection= (default: the default collection). ?collections= searches
+// other collections too, as a comma-separated list or "all"; their scores
+// are comparable only if they were indexed with the same embedding model,
+// and a collection with a different vector size is reported as failed.
+// ?limit= (default 10, max 50), ?min_score= and ?exclude_file=true (skip
+// the source's own file) narrow the results. Qdrant is asked directly, so
+// the worker need not be running.
+func (h *RAGHandler) Related(w http.ResponseWriter, r *http.Request) {
+	if h.keyword == nil {
+		writeError(w, http.StatusServiceUnavailable, "related search not available")
+		return
+	}
+	q := r.URL.Query()
+	collection := q.Get("collection")
+	if collection == "" {
+		collection = h.defaults.Get().Collection
+	}
+	if collection == "" {
+		collection = workerDefaultCodebaseCollection
+	}
+
+	rq := relatedQuery{limit: relatedDefaultLimit}
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > relatedMaxLimit {
+			writeErrorCode(w, http.StatusBadRequest, CodeValidation,
+				fmt.Sprintf("limit must be between 1 and %d", relatedMaxLimit))
+			return
+		}
+		rq.limit = n
+	}
+	if raw := q.Get("min_score"); raw != "" {
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			writeErrorCode(w, http.StatusBadRequest, CodeValidation, "min_score must be a number")
+			return
+		}
+		rq.minScore = &f
+	}
+	excludeFile := false
+	if raw := q.Get("exclude_file"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeErrorCode(w, http.StatusBadRequest, CodeValidation, "exclude_file must be true or false")
+			return
+		}
+		excludeFile = b
+	}
+
+	collections := []string{collection}
+	if raw := strings.TrimSpace(q.Get("collections")); raw != "" {
+		var list json.RawMessage
+		if raw == "all" {
+			list, _ = json.Marshal(raw)
+		} else {
+			names := []string{}
+			for _, c := range strings.Split(raw, ",") {
+				names = append(names, strings.TrimSpace(c))
+			}
+			list, _ = json.Marshal(names)
+		}
+		var ok bool
+		if collections, ok = h.multiSearchCollections(w, r, list); !ok {
+			return
+		}
+	}
+
+	if !checkCollectionLock(w, h.tm, collection) {
+		return
+	}
+	if p := h.check.check(r.Context(), collection); p != nil {
+		p.write(w)
+		return
+	}
+
+	filePath := q.Get("file_path")
+	var (
+		point *relatedPoint
+		err   error
+	)
+	switch {
+	case q.Get("point_id") != "":
+		point, err = h.relatedPointByID(r.Context(), collection, q.Get("point_id"))
+	case filePath != "":
+		idx := 0
+		if raw := q.Get("chunk_index"); raw != "" {
+			idx, err = strconv.Atoi(raw)
+			if err != nil || idx < 0 {
+				writeErrorCode(w, http.StatusBadRequest, CodeValidation, "chunk_index must be a non-negative integer")
+				return
+			}
+		}
+		point, err = h.relatedPointByIndex(r.Context(), collection, filePath, idx)
+	default:
+		writeErrorCode(w, http.StatusBadRequest, CodeValidation, "point_id, or file_path and chunk_index, are required")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+	if point == nil {
+		writeError(w, http.StatusNotFound, "point not found")
+		return
+	}
+	if filePath != "" && point.Payload.FilePath != filePath {
+		writeError(w, http.StatusBadRequest, "point does not belong to file_path")
+		return
+	}
+	if err := json.Unmarshal(point.Vector, &rq.vector); err != nil || len(rq.vector) == 0 {
+		writeErrorCode(w, http.StatusUnprocessableEntity, CodeValidation,
+			"the point has no single unnamed vector; collections with named vectors are not supported")
+		return
+	}
+	rq.exclude = point.ID
+	if excludeFile {
+		rq.excludeFile = point.Payload.FilePath
+	}
+
+	sources := make([]*multiSearchSource, len(collections))
+	ids := make([]map[*grpcclient.SearchHit]interface{}, len(collections))
+	sem := make(chan struct{}, multiSearchConcurrency)
+	var wg sync.WaitGroup
+	for i, c := range collections {
+		wg.Add(1)
+		go func(i int, c string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			sources[i], ids[i] = h.relatedOne(r.Context(), c, rq)
+		}(i, c)
+	}
+	wg.Wait()
+
+	results := []relatedHit{}
+	for i, src := range sources {
+		for _, hit := range src.hits {
+			results = append(results, relatedHit{
+				SearchHit:  hit,
+				PointID:    ids[i][hit],
+				Collection: src.Collection,
+				ImageURL:   h.images.imageURL(r, hit),
+				Media:      h.images.mediaRange(r, hit),
+			})
+		}
+		src.Count = len(src.hits)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > rq.limit {
+		results = results[:rq.limit]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"source": relatedSource{
+			PointID:    point.ID,
+			Collection: collection,
+			FilePath:   point.Payload.FilePath,
+			ChunkIndex: point.Payload.ChunkIndex,
+			Language:   point.Payload.Language,
+		},
+		"results":     results,
+		"collections": sources,
+	})
+}
+
+// relatedOne searches one collection for the neighbours of rq's vector and
+// runs the search plugins over them. It returns the hits' point IDs along
+// with the source summary; a failure is reported in the summary.
+func (h *RAGHandler) relatedOne(ctx context.Context, collection string, rq relatedQuery) (*multiSearchSource, map[*grpcclient.SearchHit]interface{}) {
+	src := &multiSearchSource{Collection: collection, Mode: "related"}
+	if l, ok := h.tm.CollectionLock(collection); ok {
+		if l.Mode == tasks.LockBlock {
+			src.Error = fmt.Sprintf("collection is being reindexed (task %s)", l.TaskID)
+			return src, nil
+		}
+		src.Stale = true
+	}
+	if p := h.check.check(ctx, collection); p != nil {
+		src.Error = p.detail
+		return src, nil
+	}
+
+	mustNot := []interface{}{map[string]interface{}{"has_id": []interface{}{rq.exclude}}}
+	if rq.excludeFile != "" {
+		mustNot = append(mustNot, map[string]interface{}{"key": "file_path", "match": map[string]interface{}{"value": rq.excludeFile}})
+	}
+	body := map[string]interface{}{
+		"vector":       rq.vector,
+		"limit":        rq.limit,
+		"with_payload": true,
+		"filter":       map[string]interface{}{"must_not": mustNot},
+	}
+	if rq.minScore != nil {
+		body["score_threshold"] = *rq.minScore
+	}
+	var out struct {
+		Result []struct {
+			ID      interface{}    `json:"id"`
+			Score   float32        `json:"score"`
+			Payload keywordPayload `json:"payload"`
+		} `json:"result"`
+	}
+	if err := h.relatedPost(ctx, "/collections/"+url.PathEscape(collection)+"/points/search", body, &out); err != nil {
+		src.Error = err.Error()
+		return src, nil
+	}
+
+	ids := make(map[*grpcclient.SearchHit]interface{}, len(out.Result))
+	hits := make([]*grpcclient.SearchHit, 0, len(out.Result))
+	for i := range out.Result {
+		hit := keywordHit(&out.Result[i].Payload, out.Result[i].Score)
+		ids[hit] = out.Result[i].ID
+		hits = append(hits, hit)
+	}
+	hits, err := plugin.ProcessSearch(ctx, plugin.Search{Collection: collection, Mode: "related"}, hits)
+	if err != nil {
+		log.Printf("ERROR: search post-processing in %s: %v", collection, err)
+		src.Error = "search post-processing failed"
+		return src, nil
+	}
+	src.hits = hits
+	return src, ids
+}
+
+func (h *RAGHandler) relatedPointByID(ctx context.Context, collection, id string) (*relatedPoint, error) {
+	// Qdrant takes numeric IDs as JSON numbers and UUIDs as strings.
+	var pid interface{} = id
+	if n, err := strconv.ParseUint(id, 10, 64); err == nil {
+		pid = n
+	}
+	var out struct {
+		Result []*relatedPoint `json:"result"`
+	}
+	err := h.relatedPost(ctx, "/collections/"+url.PathEscape(collection)+"/points", map[string]interface{}{
+		"ids":          []interface{}{pid},
+		"with_payload": true,
+		"with_vector":  true,
+	}, &out)
+	// Qdrant answers 404 for a missing collection and 400 for a malformed
+	// ID; both mean there is no such point.
+	var qe *qdrantError
+	if errors.As(err, &qe) && (qe.status == http.StatusNotFound || qe.status == http.StatusBadRequest) {
+		return nil, nil
+	}
+	if err != nil || len(out.Result) == 0 {
+		return nil, err
+	}
+	return out.Result[0], nil
+}
+
+func (h *RAGHandler) relatedPointByIndex(ctx context.Context, collection, filePath string, idx int) (*relatedPoint, error) {
+	var out struct {
+		Result struct {
+			Points []*relatedPoint `json:"points"`
+		} `json:"result"`
+	}
+	err := h.relatedPost(ctx, "/collections/"+url.PathEscape(collection)+"/points/scroll", map[string]interface{}{
+		"limit":        1,
+		"with_payload": true,
+		"with_vector":  true,
+		"filter": map[string]interface{}{
+			"must": []map[string]interface{}{
+				{"key": "file_path", "match": map[string]interface{}{"value": filePath}},
+				{"key": "chunk_index", "match": map[string]interface{}{"value": idx}},
+			},
+		},
+	}, &out)
+	var qe *qdrantError
+	if errors.As(err, &qe) && qe.status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil || len(out.Result.Points) == 0 {
+		return nil, err
+	}
+	return out.Result.Points[0], nil
+}
+
+// qdrantError is a non-2xx answer from Qdrant, with its error message.
+type qdrantError struct {
+	status  int
+	message string
+}
+
+func (e *qdrantError) Error() string { return e.message }
+
+// relatedPost sends a JSON body to Qdrant and decodes the response into
+// out. Non-2xx statuses are returned as a *qdrantError carrying Qdrant's
+// message, which explains a vector size mismatch.
+func (h *RAGHandler) relatedPost(ctx context.Context, path string, body, out interface{}) error {
+	data, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, "POST", h.keyword.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.keyword.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var qe struct {
+			Status struct {
+				Error string `json:"error"`
+			} `json:"status"`
+		}
+		json.NewDecoder(resp.Body).Decode(&qe)
+		if qe.Status.Error == "" {
+			qe.Status.Error = resp.Status
+		}
+		return &qdrantError{status: resp.StatusCode, message: qe.Status.Error}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+)
+
+// retentionDoc is the store document holding the retention policies.
+const retentionDoc = "retention"
+
+const (
+	// retentionField is the payload field the worker dates points with.
+	retentionField = "indexed_at"
+	// retentionDeleteBatch bounds the points one eviction request deletes.
+	retentionDeleteBatch = 1000
+)
+
+var (
+	errRetentionRunning = errors.New("retention is already running for this collection")
+	errNoCollection     = errors.New("collection not found")
+)
+
+// RetentionPolicy bounds the points a collection keeps. Points are dated
+// by their indexed_at payload, which the worker sets on every upsert.
+type RetentionPolicy struct {
+	// MaxAge deletes points indexed longer ago, as a Go duration or a
+	// number of days ("30d"). Empty keeps points of any age.
+	MaxAge string `json:"max_age,omitempty"`
+	// MaxPoints evicts the oldest points beyond this many (0 = no limit).
+	MaxPoints int `json:"max_points,omitempty"`
+
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// RetentionRun is the outcome of enforcing a policy once.
+type RetentionRun struct {
+	At         time.Time `json:"at"`
+	Trigger    string    `json:"trigger"` // "schedule" or "manual"
+	DryRun     bool      `json:"dry_run,omitempty"`
+	Expired    int       `json:"expired"` // deleted for their age
+	Evicted    int       `json:"evicted"` // deleted beyond max_points
+	PointsLeft int       `json:"points_left"`
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// parseRetentionAge parses a max_age: a Go duration ("72h") or a number
+// of days ("30d").
+func parseRetentionAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("max_age %q: days must be a positive integer", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("max_age %q must be a positive duration like 72h or 30d", s)
+	}
+	return d, nil
+}
+
+// RetentionPolicies holds the retention policy of each collection,
+// persisted in the gateway store, with the outcome of their last runs.
+type RetentionPolicies struct {
+	mu      sync.RWMutex
+	store   *store.Store
+	data    map[string]RetentionPolicy
+	runs    map[string]RetentionRun
+	running map[string]bool
+}
+
+// NewRetentionPolicies loads the policies from st.
+func NewRetentionPolicies(st *store.Store) *RetentionPolicies {
+	p := &RetentionPolicies{store: st, data: make(map[string]RetentionPolicy),
+		runs: make(map[string]RetentionRun), running: make(map[string]bool)}
+	if _, err := st.Load(retentionDoc, &p.data); err != nil {
+		log.Printf("WARNING: retention policies: %v", err)
+	}
+	if p.data == nil {
+		p.data = make(map[string]RetentionPolicy)
+	}
+	return p
+}
+
+// Get returns the policy of collection.
+func (p *RetentionPolicies) Get(collection string) (RetentionPolicy, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	pol, ok := p.data[collection]
+	return pol, ok
+}
+
+// set saves the policy of collection, or removes it when pol is nil.
+func (p *RetentionPolicies) set(collection string, pol *RetentionPolicy) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pol == nil {
+		delete(p.data, collection)
+		delete(p.runs, collection)
+	} else {
+		now := time.Now().UTC()
+		pol.UpdatedAt = &now
+		p.data[collection] = *pol
+	}
+	return p.store.Save(retentionDoc, p.data)
+}
+
+// lastRun returns the outcome of the last run for collection.
+func (p *RetentionPolicies) lastRun(collection string) *RetentionRun {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if run, ok := p.runs[collection]; ok {
+		return &run
+	}
+	return nil
+}
+
+// begin marks collection as being enforced; it fails if a run is already
+// going on.
+func (p *RetentionPolicies) begin(collection string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running[collection] {
+		return errRetentionRunning
+	}
+	p.running[collection] = true
+	return nil
+}
+
+// end records the outcome of a run that begin started. Dry runs are not
+// kept as the last run.
+func (p *RetentionPolicies) end(collection string, run RetentionRun) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.running, collection)
+	if _, ok := p.data[collection]; ok && !run.DryRun {
+		p.runs[collection] = run
+	}
+}
+
+// RetentionHandler serves the retention policies under /api/qdrant and
+// enforces them in the background.
+type RetentionHandler struct {
+	policies *RetentionPolicies
+	qdrant   *QdrantHandler
+	tm       *tasks.Manager
+}
+
+// NewRetentionHandler creates a RetentionHandler that deletes points
+// through qdrant.
+func NewRetentionHandler(policies *RetentionPolicies, qdrant *QdrantHandler, tm *tasks.Manager) *RetentionHandler {
+	return &RetentionHandler{policies: policies, qdrant: qdrant, tm: tm}
+}
+
+// Routes registers the read-only retention routes on the /api/qdrant
+// router.
+func (h *RetentionHandler) Routes(r chi.Router) {
+	r.Get("/retention", h.List)
+	r.Get("/collections/{name}/retention", h.Get)
+}
+
+// AdminRoutes registers the retention routes that set policies or delete
+// points, which are mounted admin-only.
+func (h *RetentionHandler) AdminRoutes(r chi.Router) {
+	r.Put("/collections/{name}/retention", h.Put)
+	r.Delete("/collections/{name}/retention", h.Delete)
+	r.Post("/collections/{name}/retention/run", h.Run)
+}
+
+// retentionView is a policy as served, with its last run.
+type retentionView struct {
+	RetentionPolicy
+	LastRun *RetentionRun `json:"last_run,omitempty"`
+}
+
+// List returns the policy of every collection that has one.
+func (h *RetentionHandler) List(w http.ResponseWriter, r *http.Request) {
+	h.policies.mu.RLock()
+	out := make(map[string]retentionView, len(h.policies.data))
+	for name, pol := range h.policies.data {
+		v := retentionView{RetentionPolicy: pol}
+		if run, ok := h.policies.runs[name]; ok {
+			v.LastRun = &run
+		}
+		out[name] = v
+	}
+	h.policies.mu.RUnlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"collections": out})
+}
+
+// Get returns the policy of one collection.
+func (h *RetentionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	pol, ok := h.policies.Get(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("collection %s has no retention policy", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, retentionView{RetentionPolicy: pol, LastRun: h.policies.lastRun(name)})
+}
+
+// Put replaces the policy of one collection and creates the datetime
+// index on indexed_at that eviction orders points by.
+func (h *RetentionHandler) Put(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	var pol RetentionPolicy
+	if err := json.NewDecoder(r.Body).Decode(&pol); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	pol.MaxAge = strings.TrimSpace(pol.MaxAge)
+	if pol.MaxAge == "" && pol.MaxPoints == 0 {
+		writeError(w, http.StatusBadRequest, "set max_age or max_points; DELETE removes the policy")
+		return
+	}
+	if pol.MaxAge != "" {
+		if _, err := parseRetentionAge(pol.MaxAge); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if pol.MaxPoints < 0 {
+		writeError(w, http.StatusBadRequest, "max_points must not be negative")
+		return
+	}
+
+	if _, status, err := h.qdrant.collectionInfo(r.Context(), name); status == http.StatusNotFound {
+		writeErrorCode(w, http.StatusNotFound, CodeCollectionNotFound, fmt.Sprintf("collection %s not found", name))
+		return
+	} else if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+	if err := h.qdrant.putPayloadIndex(r.Context(), name, retentionField, "datetime"); err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("creating the %s index: %v", retentionField, err))
+		return
+	}
+	if err := h.policies.set(name, &pol); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, retentionView{RetentionPolicy: pol, LastRun: h.policies.lastRun(name)})
+}
+
+// Delete removes the policy of one collection. Its points are kept.
+func (h *RetentionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if _, ok := h.policies.Get(name); !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("collection %s has no retention policy", name))
+		return
+	}
+	if err := h.policies.set(name, nil); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted", "collection": name})
+}
+
+// Run enforces the policy of one collection now. With ?dry_run=true it
+// only counts the points that would be deleted.
+func (h *RetentionHandler) Run(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	pol, ok := h.policies.Get(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("collection %s has no retention policy", name))
+		return
+	}
+	run, err := h.enforce(r.Context(), name, pol, "manual", r.URL.Query().Get("dry_run") == "true")
+	switch {
+	case errors.Is(err, errRetentionRunning):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, errNoCollection):
+		writeErrorCode(w, http.StatusNotFound, CodeCollectionNotFound, fmt.Sprintf("collection %s not found", name))
+	case err != nil:
+		writeError(w, http.StatusBadGateway, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, run)
+	}
+}
+
+// StartRetention enforces every policy once per interval until ctx is
+// done.
+func (h *RetentionHandler) StartRetention(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.enforceAll(ctx)
+			}
+		}
+	}()
+}
+
+// enforceAll enforces every policy in turn. Collections locked by a task
+// wait for the next interval, so indexing does not race the deletes.
+func (h *RetentionHandler) enforceAll(ctx context.Context) {
+	h.policies.mu.RLock()
+	due := make(map[string]RetentionPolicy, len(h.policies.data))
+	for name, pol := range h.policies.data {
+		due[name] = pol
+	}
+	h.policies.mu.RUnlock()
+
+	for name, pol := range due {
+		if _, locked := h.tm.CollectionLock(name); locked {
+			continue
+		}
+		run, err := h.enforce(ctx, name, pol, "schedule", false)
+		if errors.Is(err, errRetentionRunning) || errors.Is(err, errNoCollection) {
+			continue
+		}
+		if err != nil {
+			log.Printf("WARNING: retention of collection %s: %v", name, err)
+			continue
+		}
+		if run.Expired+run.Evicted > 0 {
+			log.Printf("retention of collection %s: %d expired, %d evicted, %d left",
+				name, run.Expired, run.Evicted, run.PointsLeft)
+		}
+	}
+}
+
+// enforce deletes the points of collection pol no longer keeps: first
+// those indexed more than max_age ago, then the oldest beyond max_points.
+// Points without indexed_at, indexed before the worker dated points,
+// count as the oldest and are never expired by age.
+func (h *RetentionHandler) enforce(ctx context.Context, collection string, pol RetentionPolicy, trigger string, dryRun bool) (RetentionRun, error) {
+	if err := h.policies.begin(collection); err != nil {
+		return RetentionRun{}, err
+	}
+	start := time.Now()
+	run := RetentionRun{At: start.UTC(), Trigger: trigger, DryRun: dryRun}
+	err := h.enforceOnce(ctx, collection, pol, &run, start)
+	run.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		run.Error = err.Error()
+	}
+	h.policies.end(collection, run)
+	return run, err
+}
+
+func (h *RetentionHandler) enforceOnce(ctx context.Context, collection string, pol RetentionPolicy, run *RetentionRun, now time.Time) error {
+	total, err := h.countFiltered(ctx, collection, nil)
+	if err != nil {
+		return err
+	}
+
+	if pol.MaxAge != "" {
+		age, err := parseRetentionAge(pol.MaxAge)
+		if err != nil {
+			return err
+		}
+		expired := map[string]interface{}{
+			"must": []interface{}{map[string]interface{}{
+				"key":   retentionField,
+				"range": map[string]string{"lt": now.Add(-age).UTC().Format(time.RFC3339)},
+			}},
+		}
+		if run.Expired, err = h.countFiltered(ctx, collection, expired); err != nil {
+			return err
+		}
+		if run.Expired > 0 && !run.DryRun {
+			if err := h.deletePoints(ctx, collection, map[string]interface{}{"filter": expired}); err != nil {
+				return err
+			}
+		}
+		total -= run.Expired
+	}
+
+	if pol.MaxPoints > 0 && total > pol.MaxPoints {
+		excess := total - pol.MaxPoints
+		if run.DryRun {
+			run.Evicted = excess
+		} else {
+			// Undated points first, then the oldest by indexed_at; the
+			// order_by scroll needs the datetime index Put creates.
+			undated := map[string]interface{}{"must": []interface{}{
+				map[string]interface{}{"is_empty": map[string]string{"key": retentionField}},
+			}}
+			for _, page := range []map[string]interface{}{
+				{"filter": undated},
+				{"order_by": map[string]string{"key": retentionField, "direction": "asc"}},
+			} {
+				for run.Evicted < excess {
+					ids, err := h.scrollIDs(ctx, collection, page, min(excess-run.Evicted, retentionDeleteBatch))
+					if err != nil {
+						return err
+					}
+					if len(ids) == 0 {
+						break
+					}
+					if err := h.deletePoints(ctx, collection, map[string]interface{}{"points": ids}); err != nil {
+						return err
+					}
+					run.Evicted += len(ids)
+				}
+			}
+		}
+		total -= run.Evicted
+	}
+	run.PointsLeft = total
+	return nil
+}
+
+// countFiltered counts the points of collection matching filter (nil
+// counts all).
+func (h *RetentionHandler) countFiltered(ctx context.Context, collection string, filter map[string]interface{}) (int, error) {
+	body := map[string]interface{}{"exact": true}
+	if filter != nil {
+		body["filter"] = filter
+	}
+	var out struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	err := h.pointsRequest(ctx, collection, "count", body, &out)
+	return out.Result.Count, err
+}
+
+// scrollIDs returns up to limit point IDs of collection selected by the
+// scroll options in page.
+func (h *RetentionHandler) scrollIDs(ctx context.Context, collection string, page map[string]interface{}, limit int) ([]json.RawMessage, error) {
+	body := map[string]interface{}{"limit": limit, "with_payload": false, "with_vector": false}
+	for k, v := range page {
+		body[k] = v
+	}
+	var out struct {
+		Result struct {
+			Points []struct {
+				ID json.RawMessage `json:"id"`
+			} `json:"points"`
+		} `json:"result"`
+	}
+	if err := h.pointsRequest(ctx, collection, "scroll", body, &out); err != nil {
+		return nil, err
+	}
+	ids := make([]json.RawMessage, len(out.Result.Points))
+	for i, p := range out.Result.Points {
+		ids[i] = p.ID
+	}
+	return ids, nil
+}
+
+// deletePoints deletes the points body selects, by "filter" or "points".
+func (h *RetentionHandler) deletePoints(ctx context.Context, collection string, body map[string]interface{}) error {
+	return h.pointsRequest(ctx, collection, "delete?wait=true", body, nil)
+}
+
+// pointsRequest POSTs body to /collections/{collection}/points/{op} and
+// decodes the response into out when it is not nil.
+func (h *RetentionHandler) pointsRequest(ctx context.Context, collection, op string, body map[string]interface{}, out interface{}) error {
+	raw, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, "POST",
+		h.qdrant.baseURL+"/collections/"+url.PathEscape(collection)+"/points/"+op, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.qdrant.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("qdrant error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		io.Copy(io.Discard, resp.Body)
+		return errNoCollection
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("qdrant status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.New("failed to parse qdrant response")
+	}
+	return nil
+}
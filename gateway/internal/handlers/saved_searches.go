@@ -0,0 +1,508 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/notify"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// savedSearchesDoc is the store document holding every user's saved
+// searches.
+const savedSearchesDoc = "saved-searches"
+
+const (
+	// maxSavedSearches bounds the saved searches of one user.
+	maxSavedSearches = 50
+	// maxSavedSearchBody bounds a saved search request, in bytes.
+	maxSavedSearchBody = 16 << 10
+	// maxSavedSearchQuery bounds the query of a saved search, in bytes.
+	maxSavedSearchQuery = 2000
+
+	// defaultAlertMinScore is the score a new chunk needs to trigger an
+	// alert when none is set.
+	defaultAlertMinScore = 0.6
+	// defaultAlertMaxResults and maxAlertResults bound the matches listed
+	// in one alert.
+	defaultAlertMaxResults = 5
+	maxAlertResults        = 20
+
+	// savedSearchTimeout bounds one run of a saved search.
+	savedSearchTimeout = 30 * time.Second
+)
+
+// SearchAlert makes a saved search notify when an index task adds content
+// that matches it.
+type SearchAlert struct {
+	Enabled bool `json:"enabled"`
+	// MinScore is the similarity (0-1] a newly indexed chunk needs to
+	// count as a match.
+	MinScore float32 `json:"min_score"`
+	// MaxResults bounds the matches listed in one notification.
+	MaxResults int `json:"max_results"`
+	// Channels restricts delivery to these notification channels; empty
+	// uses every enabled one.
+	Channels []string `json:"channels,omitempty"`
+}
+
+// SavedSearchAlertRun is the outcome of the last alert a saved search
+// raised or failed to raise.
+type SavedSearchAlertRun struct {
+	At      time.Time `json:"at"`
+	TaskID  string    `json:"task_id"`
+	Matches int       `json:"matches"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// SavedSearch is a named search a user keeps, optionally with an alert.
+type SavedSearch struct {
+	ID         string               `json:"id"`
+	Name       string               `json:"name"`
+	Query      string               `json:"query"`
+	Collection string               `json:"collection"`
+	Alert      *SearchAlert         `json:"alert,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	LastAlert  *SavedSearchAlertRun `json:"last_alert,omitempty"`
+}
+
+// validate normalises and checks s, returning an error naming the
+// offending field.
+func (s *SavedSearch) validate() error {
+	s.Name = strings.TrimSpace(s.Name)
+	s.Query = strings.TrimSpace(s.Query)
+	s.Collection = strings.TrimSpace(s.Collection)
+	switch {
+	case s.Query == "" || len(s.Query) > maxSavedSearchQuery:
+		return fmt.Errorf("query must be 1-%d bytes", maxSavedSearchQuery)
+	case s.Collection == "" || len(s.Collection) > maxPreferenceName:
+		return fmt.Errorf("collection must be 1-%d bytes", maxPreferenceName)
+	case len(s.Name) > maxPreferenceName:
+		return fmt.Errorf("name must be at most %d bytes", maxPreferenceName)
+	}
+	if s.Name == "" {
+		s.Name = s.Query
+	}
+	a := s.Alert
+	if a == nil {
+		return nil
+	}
+	if a.MinScore == 0 {
+		a.MinScore = defaultAlertMinScore
+	}
+	if a.MaxResults == 0 {
+		a.MaxResults = defaultAlertMaxResults
+	}
+	switch {
+	case a.MinScore < 0 || a.MinScore > 1:
+		return errors.New("alert.min_score must be between 0 and 1")
+	case a.MaxResults < 1 || a.MaxResults > maxAlertResults:
+		return fmt.Errorf("alert.max_results must be between 1 and %d", maxAlertResults)
+	}
+	for _, ch := range a.Channels {
+		if !notify.ValidChannel(ch) {
+			return fmt.Errorf("alert.channels: unknown channel %q (use email or slack)", ch)
+		}
+	}
+	return nil
+}
+
+// SavedSearches holds every user's saved searches, persisted in the
+// gateway store.
+type SavedSearches struct {
+	mu    sync.RWMutex
+	store *store.Store
+	data  struct {
+		// Users maps usernames to their searches by ID.
+		Users map[string]map[string]SavedSearch `json:"users"`
+	}
+}
+
+// NewSavedSearches loads the saved searches from st.
+func NewSavedSearches(st *store.Store) *SavedSearches {
+	s := &SavedSearches{store: st}
+	if _, err := st.Load(savedSearchesDoc, &s.data); err != nil {
+		log.Printf("WARNING: saved searches: %v", err)
+	}
+	if s.data.Users == nil {
+		s.data.Users = make(map[string]map[string]SavedSearch)
+	}
+	return s
+}
+
+// List returns a user's saved searches sorted by name.
+func (s *SavedSearches) List(username string) []SavedSearch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]SavedSearch, 0, len(s.data.Users[username]))
+	for _, ss := range s.data.Users[username] {
+		out = append(out, ss)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Get returns one of a user's saved searches.
+func (s *SavedSearches) Get(username, id string) (SavedSearch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ss, ok := s.data.Users[username][id]
+	return ss, ok
+}
+
+// errSavedSearchNotFound is returned by Put when replacing a missing
+// search.
+var errSavedSearchNotFound = errors.New("saved search not found")
+
+// Put validates and stores one of a user's searches. An empty ID creates a
+// new search; otherwise the search with that ID is replaced, keeping its
+// alert history.
+func (s *SavedSearches) Put(username string, ss SavedSearch) (SavedSearch, error) {
+	if err := ss.validate(); err != nil {
+		return ss, err
+	}
+	now := time.Now().UTC()
+	ss.UpdatedAt = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	searches := s.data.Users[username]
+	for _, other := range searches {
+		if other.ID != ss.ID && strings.EqualFold(other.Name, ss.Name) {
+			return ss, fmt.Errorf("a saved search named %q already exists", other.Name)
+		}
+	}
+	if ss.ID == "" {
+		if len(searches) >= maxSavedSearches {
+			return ss, fmt.Errorf("at most %d searches can be saved", maxSavedSearches)
+		}
+		ss.ID = uuid.New().String()
+		ss.CreatedAt = now
+		ss.LastAlert = nil
+	} else {
+		old, ok := searches[ss.ID]
+		if !ok {
+			return ss, errSavedSearchNotFound
+		}
+		ss.CreatedAt, ss.LastAlert = old.CreatedAt, old.LastAlert
+	}
+	if searches == nil {
+		searches = make(map[string]SavedSearch)
+		s.data.Users[username] = searches
+	}
+	searches[ss.ID] = ss
+	return ss, s.store.Save(savedSearchesDoc, s.data)
+}
+
+// Delete removes one of a user's searches, reporting whether it existed.
+func (s *SavedSearches) Delete(username, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Users[username][id]; !ok {
+		return false, nil
+	}
+	delete(s.data.Users[username], id)
+	if len(s.data.Users[username]) == 0 {
+		delete(s.data.Users, username)
+	}
+	return true, s.store.Save(savedSearchesDoc, s.data)
+}
+
+// DeleteUser removes every search of a user.
+func (s *SavedSearches) DeleteUser(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Users[username]; !ok {
+		return nil
+	}
+	delete(s.data.Users, username)
+	return s.store.Save(savedSearchesDoc, s.data)
+}
+
+// savedAlert is an alerting search with its owner.
+type savedAlert struct {
+	username string
+	search   SavedSearch
+}
+
+// alerts returns the searches with an enabled alert on collection.
+func (s *SavedSearches) alerts(collection string) []savedAlert {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []savedAlert
+	for username, searches := range s.data.Users {
+		for _, ss := range searches {
+			if ss.Alert != nil && ss.Alert.Enabled && ss.Collection == collection {
+				out = append(out, savedAlert{username: username, search: ss})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].username != out[j].username {
+			return out[i].username < out[j].username
+		}
+		return out[i].search.Name < out[j].search.Name
+	})
+	return out
+}
+
+// recordAlert stores the outcome of an alert check. Searches deleted in
+// the meantime are left deleted.
+func (s *SavedSearches) recordAlert(username, id string, run SavedSearchAlertRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss, ok := s.data.Users[username][id]
+	if !ok {
+		return nil
+	}
+	ss.LastAlert = &run
+	s.data.Users[username][id] = ss
+	return s.store.Save(savedSearchesDoc, s.data)
+}
+
+// SavedSearchesHandler serves /api/users/me/searches, where users save
+// named searches, and runs the alerts of those searches when index tasks
+// finish.
+type SavedSearchesHandler struct {
+	searches  *SavedSearches
+	ollama    *OllamaHandler
+	notifier  *notify.Notifier
+	qdrantURL string
+	client    *http.Client
+}
+
+// NewSavedSearchesHandler creates a new SavedSearchesHandler that embeds
+// queries through ollama, searches Qdrant at qdrantURL and delivers alerts
+// through notifier.
+func NewSavedSearchesHandler(searches *SavedSearches, ollama *OllamaHandler, notifier *notify.Notifier, qdrantURL string, client *http.Client) *SavedSearchesHandler {
+	return &SavedSearchesHandler{searches: searches, ollama: ollama, notifier: notifier, qdrantURL: qdrantURL, client: client}
+}
+
+// Routes registers the saved search routes on the given chi router.
+func (h *SavedSearchesHandler) Routes(r chi.Router) {
+	r.Get("/", h.List)
+	r.Post("/", h.Put)
+	r.Get("/{id}", h.Get)
+	r.Put("/{id}", h.Put)
+	r.Delete("/{id}", h.Delete)
+	r.Post("/{id}/test", h.Test)
+}
+
+// List returns the caller's saved searches.
+func (h *SavedSearchesHandler) List(w http.ResponseWriter, r *http.Request) {
+	searches := h.searches.List(middleware.UsernameFromContext(r.Context()))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"searches": searches,
+		"count":    len(searches),
+	})
+}
+
+// Get returns one of the caller's saved searches.
+func (h *SavedSearchesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	ss, ok := h.searches.Get(middleware.UsernameFromContext(r.Context()), id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("saved search %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, ss)
+}
+
+// Put creates a saved search (POST) or replaces the one in the path (PUT).
+func (h *SavedSearchesHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var req SavedSearch
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSavedSearchBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	ss := SavedSearch{
+		ID:         chi.URLParam(r, "id"),
+		Name:       req.Name,
+		Query:      req.Query,
+		Collection: req.Collection,
+		Alert:      req.Alert,
+	}
+	saved, err := h.searches.Put(middleware.UsernameFromContext(r.Context()), ss)
+	switch {
+	case errors.Is(err, errSavedSearchNotFound):
+		writeError(w, http.StatusNotFound, fmt.Sprintf("saved search %s not found", ss.ID))
+		return
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		status = http.StatusCreated
+	}
+	resp := map[string]interface{}{"search": saved}
+	if saved.Alert != nil && saved.Alert.Enabled && !h.notifier.Enabled() {
+		resp["warnings"] = []string{"no notification channel is enabled; alerts are not delivered until an admin enables one"}
+	}
+	writeJSON(w, status, resp)
+}
+
+// Delete removes one of the caller's saved searches.
+func (h *SavedSearchesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	found, err := h.searches.Delete(middleware.UsernameFromContext(r.Context()), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("saved search %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": id})
+}
+
+// Test runs a saved search against its whole collection with its alert's
+// threshold, so the threshold can be tuned before new content arrives.
+// Nothing is sent.
+func (h *SavedSearchesHandler) Test(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	ss, ok := h.searches.Get(middleware.UsernameFromContext(r.Context()), id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("saved search %s not found", id))
+		return
+	}
+	hits, err := h.matches(r.Context(), ss, nil)
+	if err != nil {
+		writeErrorCode(w, http.StatusBadGateway, CodeUpstream, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"results":   hits,
+		"count":     len(hits),
+		"min_score": alertOf(ss).MinScore,
+	})
+}
+
+// alertOf returns the alert settings of ss, defaulted if it has none.
+func alertOf(ss SavedSearch) SearchAlert {
+	if ss.Alert != nil {
+		return *ss.Alert
+	}
+	return SearchAlert{MinScore: defaultAlertMinScore, MaxResults: defaultAlertMaxResults}
+}
+
+// matches runs ss against its collection with its alert's threshold. A
+// non-nil since restricts it to points indexed at or after that time.
+func (h *SavedSearchesHandler) matches(ctx context.Context, ss SavedSearch, since *time.Time) ([]*grpcclient.SearchHit, error) {
+	ctx, cancel := context.WithTimeout(ctx, savedSearchTimeout)
+	defer cancel()
+	vec, err := h.embedQuery(ctx, ss.Query)
+	if err != nil {
+		return nil, err
+	}
+	alert := alertOf(ss)
+	body := map[string]interface{}{
+		"vector":          vec,
+		"limit":           alert.MaxResults,
+		"score_threshold": alert.MinScore,
+		"with_payload":    true,
+	}
+	if since != nil {
+		body["filter"] = map[string]interface{}{"must": []interface{}{map[string]interface{}{
+			"key":   retentionField,
+			"range": map[string]string{"gte": since.UTC().Truncate(time.Second).Format(time.RFC3339)},
+		}}}
+	}
+	return searchPoints(ctx, h.client, h.qdrantURL, ss.Collection, body)
+}
+
+// embedQuery embeds query with the worker's embedding model on the default
+// Ollama instance.
+func (h *SavedSearchesHandler) embedQuery(ctx context.Context, query string) ([]float64, error) {
+	if h.ollama.grpc == nil || h.ollama.grpc.Embedding == nil {
+		return nil, errors.New("embedding service not available")
+	}
+	info, err := h.ollama.grpc.Embedding.GetInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("embedding model: %w", err)
+	}
+	inst, err := h.ollama.instances.Resolve("")
+	if err != nil {
+		return nil, err
+	}
+	vecs, _, err := h.ollama.embedBatch(ctx, inst.URL, info.Model, []string{query}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != 1 {
+		return nil, fmt.Errorf("ollama returned %d embeddings for 1 text", len(vecs))
+	}
+	return vecs[0], nil
+}
+
+// CheckAlerts is a tasks.FinishFunc that runs the alerting saved searches
+// of the collection a completed index task wrote to against the points it
+// indexed, and notifies for those with matches.
+func (h *SavedSearchesHandler) CheckAlerts(t tasks.TaskInfo) {
+	if !indexTaskTypes[t.Type] || t.Status != tasks.StatusCompleted {
+		return
+	}
+	collection := taskCollection(t)
+	alerts := h.searches.alerts(collection)
+	if len(alerts) == 0 {
+		return
+	}
+	since := t.CreatedAt
+	if t.StartedAt != nil {
+		since = *t.StartedAt
+	}
+	for _, a := range alerts {
+		run := SavedSearchAlertRun{At: time.Now().UTC(), TaskID: t.ID}
+		hits, err := h.matches(context.Background(), a.search, &since)
+		if err == nil && len(hits) > 0 {
+			run.Matches = len(hits)
+			subject, body := alertMessage(a.username, a.search, t.ID, hits)
+			err = h.notifier.Alert(a.search.Alert.Channels, subject, body)
+		}
+		if err != nil {
+			run.Error = err.Error()
+			log.Printf("WARNING: saved search %s of %s: %v", a.search.ID, a.username, err)
+		}
+		if run.Matches == 0 && run.Error == "" {
+			continue
+		}
+		if err := h.searches.recordAlert(a.username, a.search.ID, run); err != nil {
+			log.Printf("WARNING: saved searches: recording alert of %s: %v", a.search.ID, err)
+		}
+	}
+}
+
+// alertMessage renders the notification for the matches of a saved
+// search after index task taskID.
+func alertMessage(username string, ss SavedSearch, taskID string, hits []*grpcclient.SearchHit) (string, string) {
+	subject := fmt.Sprintf("[Ollqd] Saved search %q: %d new match", ss.Name, len(hits))
+	if len(hits) != 1 {
+		subject += "es"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Newly indexed content in %s matches the saved search %q of %s.\n", ss.Collection, ss.Name, username)
+	fmt.Fprintf(&b, "Query: %s\nTask: %s\n\n", ss.Query, taskID)
+	for _, hit := range hits {
+		fmt.Fprintf(&b, "%.2f  %s", hit.Score, hit.FilePath)
+		if hit.Lines != "" && hit.Lines != "?-?" {
+			fmt.Fprintf(&b, " (lines %s)", hit.Lines)
+		}
+		b.WriteString("\n")
+	}
+	return subject, b.String()
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// searchBudgetMaxMS bounds the budget_ms of multi-collection and batch
+// searches.
+const searchBudgetMaxMS = 60000
+
+// searchBudget is the budget_ms field of multi-collection and batch
+// searches: how long the slowest part may take before the response is sent
+// without it. Zero waits for every part.
+type searchBudget struct {
+	BudgetMS int `json:"budget_ms"`
+}
+
+// validate returns an error message for an out-of-range budget.
+func (b searchBudget) validate() string {
+	if b.BudgetMS < 0 || b.BudgetMS > searchBudgetMaxMS {
+		return fmt.Sprintf("budget_ms must be between 0 and %d", searchBudgetMaxMS)
+	}
+	return ""
+}
+
+// timedOut returns the source reported for a part that missed the budget.
+func (b searchBudget) timedOut(collection string) *multiSearchSource {
+	return &multiSearchSource{
+		Collection: collection,
+		TimedOut:   true,
+		Error:      fmt.Sprintf("timed out after the %dms budget", b.BudgetMS),
+	}
+}
+
+// runBudgeted runs n searches concurrently, at most multiSearchConcurrency
+// at a time, and returns their sources in order. With a budget, searches
+// still running or queued when it runs out are cancelled and their entries
+// left nil; a search that failed because of the cancellation counts as
+// running.
+func runBudgeted(ctx context.Context, b searchBudget, n int, run func(ctx context.Context, i int) *multiSearchSource) []*multiSearchSource {
+	// expired stays nil without a budget, so every search is waited for
+	// even if the request itself is cancelled.
+	var expired <-chan struct{}
+	if b.BudgetMS > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(b.BudgetMS)*time.Millisecond)
+		defer cancel()
+		expired = ctx.Done()
+	}
+
+	type result struct {
+		i   int
+		src *multiSearchSource
+	}
+	// Buffered so searches finishing after the budget do not block.
+	done := make(chan result, n)
+	sem := make(chan struct{}, multiSearchConcurrency)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			select {
+			case sem <- struct{}{}:
+			case <-expired:
+				done <- result{i, nil}
+				return
+			}
+			defer func() { <-sem }()
+			src := run(ctx, i)
+			if expired != nil && src.Error != "" && ctx.Err() != nil {
+				src = nil
+			}
+			done <- result{i, src}
+		}(i)
+	}
+
+	out := make([]*multiSearchSource, n)
+	for got := 0; got < n; got++ {
+		select {
+		case res := <-done:
+			out[res.i] = res.src
+		case <-expired:
+			return out
+		}
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// searchDefaultsDoc is the store document holding the search defaults.
+const searchDefaultsDoc = "search-defaults"
+
+// maxSearchTopK bounds the configured default top_k.
+const maxSearchTopK = 100
+
+// searchAny as a request's language or file_path turns off the configured
+// default filter.
+const searchAny = "*"
+
+// SearchDefaultsConfig holds the values the gateway fills into search
+// requests that leave them unset. Zero values leave the built-in behaviour
+// alone.
+type SearchDefaultsConfig struct {
+	// TopK replaces a missing or zero top_k.
+	TopK int32 `json:"top_k,omitempty"`
+	// MinScore is the score_threshold of requests that set none.
+	MinScore *float32 `json:"min_score,omitempty"`
+	// Collection is searched by POST /api/rag/search instead of the
+	// worker's default codebase collection.
+	Collection string `json:"collection,omitempty"`
+	// Language and FilePath are the filters of requests that set none.
+	Language string `json:"language,omitempty"`
+	FilePath string `json:"file_path,omitempty"`
+
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// validate checks c, returning an error naming the offending field.
+func (c *SearchDefaultsConfig) validate() error {
+	if c.TopK < 0 || c.TopK > maxSearchTopK {
+		return fmt.Errorf("top_k must be between 1 and %d, or 0 for the built-in default", maxSearchTopK)
+	}
+	if c.MinScore != nil && (*c.MinScore < -1 || *c.MinScore > 1) {
+		return fmt.Errorf("min_score must be between -1 and 1")
+	}
+	if c.Language == searchAny || c.FilePath == searchAny {
+		return fmt.Errorf("%q is only meaningful in requests; leave the default empty instead", searchAny)
+	}
+	return nil
+}
+
+// topK returns the top_k for a request asking for requested, falling back
+// to the configured default and then to builtin.
+func (c SearchDefaultsConfig) topK(requested, builtin int32) int32 {
+	switch {
+	case requested > 0:
+		return requested
+	case c.TopK > 0:
+		return c.TopK
+	default:
+		return builtin
+	}
+}
+
+// fill completes req from the defaults: top_k (builtin when neither sets
+// it), score_threshold and the language and file_path filters. "*" as a
+// request filter means no filter.
+func (c SearchDefaultsConfig) fill(req *searchRequest, builtin int32) {
+	req.TopK = c.topK(req.TopK, builtin)
+	if req.ScoreThreshold == nil {
+		req.ScoreThreshold = c.MinScore
+	}
+	req.Language = withDefaultFilter(req.Language, c.Language)
+	req.FilePath = withDefaultFilter(req.FilePath, c.FilePath)
+}
+
+func withDefaultFilter(requested, def string) string {
+	switch requested {
+	case "":
+		return def
+	case searchAny:
+		return ""
+	default:
+		return requested
+	}
+}
+
+// SearchDefaults holds the search defaults, persisted in the gateway store.
+// The search handlers read it through Get.
+type SearchDefaults struct {
+	mu    sync.RWMutex
+	store *store.Store
+	data  SearchDefaultsConfig
+}
+
+// NewSearchDefaults loads the search defaults from st.
+func NewSearchDefaults(st *store.Store) *SearchDefaults {
+	d := &SearchDefaults{store: st}
+	if _, err := st.Load(searchDefaultsDoc, &d.data); err != nil {
+		log.Printf("WARNING: search defaults: %v", err)
+	}
+	return d
+}
+
+// Get returns the current defaults. A nil SearchDefaults has none.
+func (d *SearchDefaults) Get() SearchDefaultsConfig {
+	if d == nil {
+		return SearchDefaultsConfig{}
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.data
+}
+
+// Set validates and replaces the defaults.
+func (d *SearchDefaults) Set(c SearchDefaultsConfig) (SearchDefaultsConfig, error) {
+	if err := c.validate(); err != nil {
+		return c, err
+	}
+	now := time.Now().UTC()
+	c.UpdatedAt = &now
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.data = c
+	return c, d.store.Save(searchDefaultsDoc, d.data)
+}
+
+// SearchDefaultsHandler serves /api/system/config/search.
+type SearchDefaultsHandler struct {
+	defaults *SearchDefaults
+}
+
+// NewSearchDefaultsHandler creates a new SearchDefaultsHandler.
+func NewSearchDefaultsHandler(defaults *SearchDefaults) *SearchDefaultsHandler {
+	return &SearchDefaultsHandler{defaults: defaults}
+}
+
+// Routes registers the search defaults routes on the given chi router.
+func (h *SearchDefaultsHandler) Routes(r chi.Router) {
+	r.Get("/", h.Get)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireAdmin)
+		r.Put("/", h.Put)
+		r.Delete("/", h.Reset)
+	})
+}
+
+// Get returns the search defaults.
+func (h *SearchDefaultsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.defaults.Get())
+}
+
+// Put replaces the search defaults. Omitted fields are cleared.
+func (h *SearchDefaultsHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var req SearchDefaultsConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	saved, err := h.defaults.Set(req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// Reset clears every search default.
+func (h *SearchDefaultsHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	saved, err := h.defaults.Set(SearchDefaultsConfig{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
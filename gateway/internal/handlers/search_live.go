@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/plugin"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+)
+
+// Live search limits.
+const (
+	// liveSearchDebounce is how long a live search waits before it runs. A
+	// newer query arriving meanwhile replaces it, so a search runs once the
+	// user pauses typing.
+	liveSearchDebounce = 150 * time.Millisecond
+
+	// liveSearchTimeout bounds one live search.
+	liveSearchTimeout = 15 * time.Second
+
+	// maxLiveQuery bounds the length of a live search query.
+	maxLiveQuery = 1000
+
+	// maxLiveVectors bounds the query embeddings a connection keeps; the
+	// cache is emptied when it fills.
+	maxLiveVectors = 64
+)
+
+// LiveSearchHandler serves search-as-you-type over a WebSocket. Each query
+// is answered in phases: BM25 keyword hits, which need neither the worker
+// nor Ollama and arrive first, then vector hits. The gateway embeds the
+// query itself so a connection can reuse the embedding of a query it has
+// already searched, which is common while a user types and deletes.
+type LiveSearchHandler struct {
+	rag       *RAGHandler
+	ollama    *OllamaHandler
+	sessions  *middleware.SessionStore
+	qdrantURL string
+	client    *http.Client
+}
+
+// NewLiveSearchHandler creates a LiveSearchHandler. Searches are completed,
+// rewritten, checked and post-processed like those of rag; queries are
+// embedded through ollama and searched in Qdrant at qdrantURL through
+// client. Connections are registered with sessions so revoking a session
+// disconnects them.
+func NewLiveSearchHandler(rag *RAGHandler, ollama *OllamaHandler, sessions *middleware.SessionStore, qdrantURL string, client *http.Client) *LiveSearchHandler {
+	return &LiveSearchHandler{rag: rag, ollama: ollama, sessions: sessions, qdrantURL: qdrantURL, client: client}
+}
+
+// Routes registers the live search WebSocket endpoint.
+func (h *LiveSearchHandler) Routes(r chi.Router) {
+	r.Get("/", h.HandleWS)
+}
+
+// liveSearchConn is the state of one live search connection.
+type liveSearchConn struct {
+	conn *websocket.Conn
+	wmu  sync.Mutex // serialises writes
+
+	mu      sync.Mutex
+	model   string               // the worker's embedding model, once known
+	vectors map[string][]float64 // normalised query → embedding
+}
+
+// send writes evt, reporting whether it could.
+func (c *liveSearchConn) send(evt api.LiveSearchEvent) bool {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteJSON(evt) == nil
+}
+
+// liveSearch is a live search in progress.
+type liveSearch struct {
+	cancel    context.CancelFunc
+	cancelled atomic.Bool // set when the client asked to stop
+	done      chan struct{}
+}
+
+// stop cancels s, if any, and waits for it to return.
+func (s *liveSearch) stop() {
+	if s != nil {
+		s.cancel()
+		<-s.done
+	}
+}
+
+// HandleWS upgrades the connection and reads search messages. Each
+// "search" message replaces the search in progress, which ends without
+// further events; "cancel" stops it with a "cancelled" event.
+func (h *LiveSearchHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("websocket upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+	defer activeStreams.track("websocket")()
+
+	untrack := h.sessions.TrackConn(middleware.SessionIDFromContext(r.Context()), func() {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked"),
+			time.Now().Add(time.Second))
+		conn.Close()
+	})
+	defer untrack()
+
+	lc := &liveSearchConn{conn: conn, vectors: make(map[string][]float64)}
+	var current *liveSearch
+	defer func() { current.stop() }()
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("websocket read error: %v", err)
+			}
+			return
+		}
+		var msg api.LiveSearchMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			lc.send(api.LiveSearchEvent{Type: api.LiveSearchEventError, Content: "invalid JSON message"})
+			continue
+		}
+
+		switch msg.Type {
+		case api.LiveSearchMessageCancel:
+			if current != nil {
+				current.cancelled.Store(true)
+				current.stop()
+				current = nil
+			}
+		case api.LiveSearchMessageSearch, "":
+			current.stop()
+			ctx, cancel := context.WithCancel(r.Context())
+			s := &liveSearch{cancel: cancel, done: make(chan struct{})}
+			current = s
+			go func() {
+				defer close(s.done)
+				defer cancel()
+				h.run(ctx, r, lc, msg, s)
+			}()
+		default:
+			lc.send(api.LiveSearchEvent{Type: api.LiveSearchEventError, ID: msg.ID, Content: `type must be "search" or "cancel"`})
+		}
+	}
+}
+
+// run answers one search message after the debounce delay. Its phases run
+// concurrently and each sends its hits as soon as they are ready.
+func (h *LiveSearchHandler) run(ctx context.Context, r *http.Request, lc *liveSearchConn, msg api.LiveSearchMessage, s *liveSearch) {
+	select {
+	case <-time.After(liveSearchDebounce):
+	case <-ctx.Done():
+		if s.cancelled.Load() {
+			lc.send(api.LiveSearchEvent{Type: api.LiveSearchEventCancelled, ID: msg.ID})
+		}
+		return
+	}
+	start := time.Now()
+	sctx, cancel := context.WithTimeout(ctx, liveSearchTimeout)
+	defer cancel()
+	fail := func(code, content string) {
+		lc.send(api.LiveSearchEvent{Type: api.LiveSearchEventError, ID: msg.ID, Code: code, Content: content})
+	}
+
+	req := searchRequest{SearchQuery: msg.SearchQuery, searchTuning: searchTuning{msg.SearchTuning}}
+	defaults := h.rag.defaults.Get()
+	defaults.fill(&req, searchDefaultTopK)
+	collection := msg.Collection
+	if collection == "" {
+		collection = defaults.Collection
+	}
+	if collection == "" {
+		collection = workerDefaultCodebaseCollection
+	}
+	switch req.Mode {
+	case "", "vector", "keyword":
+	default:
+		fail(CodeValidation, `mode must be "vector" or "keyword"`)
+		return
+	}
+	if m := req.validate(); m != "" {
+		fail(CodeValidation, m)
+		return
+	}
+	if req.TopK < 1 || req.TopK > maxSearchTopK {
+		fail(CodeValidation, fmt.Sprintf("top_k must be between 1 and %d", maxSearchTopK))
+		return
+	}
+	req.Query = strings.TrimSpace(req.Query)
+	if len(req.Query) > maxLiveQuery {
+		fail(CodeValidation, fmt.Sprintf("query exceeds %d bytes", maxLiveQuery))
+		return
+	}
+	if req.Query == "" {
+		lc.send(api.LiveSearchEvent{Type: api.LiveSearchEventDone, ID: msg.ID, Collection: collection})
+		return
+	}
+	if l, locked := h.rag.tm.CollectionLock(collection); locked && l.Mode == tasks.LockBlock {
+		fail(CodeLocked, fmt.Sprintf("collection %s is being reindexed (task %s)", collection, l.TaskID))
+		return
+	}
+	if p := h.rag.check.check(sctx, collection); p != nil {
+		fail(p.code, p.detail)
+		return
+	}
+	req.rewrite = h.rag.terms.Rewrite(collection, req.Query)
+
+	// deliver post-processes the hits of one phase and sends them.
+	deliver := func(phase string, hits []*grpcclient.SearchHit, cached bool, err error) {
+		if sctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			lc.send(api.LiveSearchEvent{Type: api.LiveSearchEventError, ID: msg.ID, Phase: phase, Content: err.Error()})
+			return
+		}
+		if req.active() {
+			hits = req.apply(hits, req.TopK)
+		}
+		hits, err = plugin.ProcessSearch(sctx, plugin.Search{Query: req.Query, Collection: collection, Mode: phase}, hits)
+		if err != nil {
+			log.Printf("ERROR: search post-processing in %s: %v", collection, err)
+			lc.send(api.LiveSearchEvent{Type: api.LiveSearchEventError, ID: msg.ID, Phase: phase, Code: CodeInternal, Content: "search post-processing failed"})
+			return
+		}
+		lc.send(api.LiveSearchEvent{
+			Type:         api.LiveSearchEventHits,
+			ID:           msg.ID,
+			Phase:        phase,
+			Collection:   collection,
+			Results:      h.rag.images.imageHits(r, hits),
+			QueryRewrite: req.rewrite,
+			Cached:       cached,
+			TookMS:       time.Since(start).Milliseconds(),
+		})
+	}
+
+	var wg sync.WaitGroup
+	if req.Mode != "vector" && h.rag.keyword != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hits, err := h.rag.keyword.Search(sctx, collection, req.searchQuery(), int(req.fetchK(req.TopK)), req.Language, req.FilePath)
+			if errors.Is(err, errNoKeywordTerms) && req.Mode == "" {
+				return // the vector phase still answers
+			}
+			deliver(api.LiveSearchPhaseKeyword, hits, false, err)
+		}()
+	} else if req.Mode == "keyword" {
+		fail(CodeNotImplemented, "keyword search not available")
+		return
+	}
+	if req.Mode != "keyword" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hits, cached, err := h.vectorSearch(sctx, lc, collection, req)
+			deliver(api.LiveSearchPhaseVector, hits, cached, err)
+		}()
+	}
+	wg.Wait()
+
+	switch {
+	case s.cancelled.Load():
+		lc.send(api.LiveSearchEvent{Type: api.LiveSearchEventCancelled, ID: msg.ID})
+	case ctx.Err() != nil:
+		// Replaced by a newer search, or the connection closed.
+	case sctx.Err() != nil:
+		fail(CodeUpstream, "search timed out")
+	default:
+		lc.send(api.LiveSearchEvent{Type: api.LiveSearchEventDone, ID: msg.ID, Collection: collection, TookMS: time.Since(start).Milliseconds()})
+	}
+}
+
+// vectorSearch embeds the query, reusing a cached embedding if the
+// connection has searched the same query before, and searches Qdrant. It
+// reports whether the embedding was reused.
+func (h *LiveSearchHandler) vectorSearch(ctx context.Context, lc *liveSearchConn, collection string, req searchRequest) ([]*grpcclient.SearchHit, bool, error) {
+	vec, cached, err := h.embed(ctx, lc, req.searchQuery())
+	if err != nil {
+		return nil, false, err
+	}
+
+	body := map[string]interface{}{
+		"vector":       vec,
+		"limit":        req.fetchK(req.TopK),
+		"with_payload": true,
+	}
+	var must []interface{}
+	if req.Language != "" {
+		must = append(must, map[string]interface{}{"key": "language", "match": map[string]interface{}{"value": req.Language}})
+	}
+	if req.FilePath != "" {
+		must = append(must, map[string]interface{}{"key": "file_path", "match": map[string]interface{}{"value": req.FilePath}})
+	}
+	if len(must) > 0 {
+		body["filter"] = map[string]interface{}{"must": must}
+	}
+	hits, err := searchPoints(ctx, h.client, h.qdrantURL, collection, body)
+	if err != nil {
+		return nil, false, err
+	}
+	return hits, cached, nil
+}
+
+// searchPoints runs a Qdrant /points/search request and returns its points
+// as search hits.
+func searchPoints(ctx context.Context, client *http.Client, qdrantURL, collection string, body map[string]interface{}) ([]*grpcclient.SearchHit, error) {
+	data, _ := json.Marshal(body)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST",
+		qdrantURL+"/collections/"+url.PathEscape(collection)+"/points/search", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("qdrant error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var qe struct {
+			Status struct {
+				Error string `json:"error"`
+			} `json:"status"`
+		}
+		json.NewDecoder(resp.Body).Decode(&qe)
+		if qe.Status.Error == "" {
+			qe.Status.Error = resp.Status
+		}
+		return nil, fmt.Errorf("qdrant error: %s", qe.Status.Error)
+	}
+	var out struct {
+		Result []struct {
+			Score   float32        `json:"score"`
+			Payload keywordPayload `json:"payload"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("qdrant error: %w", err)
+	}
+	hits := make([]*grpcclient.SearchHit, 0, len(out.Result))
+	for i := range out.Result {
+		hits = append(hits, keywordHit(&out.Result[i].Payload, out.Result[i].Score))
+	}
+	return hits, nil
+}
+
+// embed returns the embedding of query with the worker's embedding model.
+// Queries differing only in whitespace share an embedding.
+func (h *LiveSearchHandler) embed(ctx context.Context, lc *liveSearchConn, query string) ([]float64, bool, error) {
+	key := strings.Join(strings.Fields(query), " ")
+	lc.mu.Lock()
+	model := lc.model
+	vec, ok := lc.vectors[key]
+	lc.mu.Unlock()
+	if ok {
+		return vec, true, nil
+	}
+
+	if model == "" {
+		if h.ollama.grpc == nil || h.ollama.grpc.Embedding == nil {
+			return nil, false, errors.New("embedding service not available")
+		}
+		info, err := h.ollama.grpc.Embedding.GetInfo(ctx)
+		if err != nil {
+			return nil, false, fmt.Errorf("embedding model: %w", err)
+		}
+		model = info.Model
+	}
+	inst, err := h.ollama.instances.Resolve("")
+	if err != nil {
+		return nil, false, err
+	}
+	vecs, _, err := h.ollama.embedBatch(ctx, inst.URL, model, []string{key}, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(vecs) != 1 {
+		return nil, false, fmt.Errorf("ollama returned %d embeddings for 1 text", len(vecs))
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.model = model
+	if len(lc.vectors) >= maxLiveVectors {
+		lc.vectors = make(map[string][]float64)
+	}
+	lc.vectors[key] = vecs[0]
+	return vecs[0], false, nil
+}
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+	"github.com/go-chi/chi/v5"
+)
+
+// searchTermsDoc is the store document holding the synonym and stopword
+// lists.
+const searchTermsDoc = "search-terms"
+
+// Bounds on the lists of one collection.
+const (
+	maxSynonymEntries    = 1000
+	maxSynonymExpansions = 20
+	maxStopwords         = 500
+	// maxQueryExpansions bounds the words one rewrite adds to a query, so
+	// a broad list cannot drown the words the user typed.
+	maxQueryExpansions = 16
+)
+
+// SearchTermLists are the query rewriting lists of one collection.
+type SearchTermLists struct {
+	// Synonyms maps a word or phrase to the terms added to queries that
+	// contain it, e.g. "k8s" -> ["kubernetes"]. Matching ignores case and
+	// punctuation; an entry works in one direction only.
+	Synonyms map[string][]string `json:"synonyms,omitempty"`
+	// Stopwords are words removed from queries.
+	Stopwords []string `json:"stopwords,omitempty"`
+
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func (l SearchTermLists) empty() bool {
+	return len(l.Synonyms) == 0 && len(l.Stopwords) == 0
+}
+
+// QueryRewrite describes how the lists changed a query.
+type QueryRewrite = api.QueryRewrite
+
+// normalizeSynonyms validates synonyms and returns them with keys
+// lowercased and expansions trimmed and deduplicated; expansions keep their
+// case, which embedding models may tell apart.
+func normalizeSynonyms(synonyms map[string][]string) (map[string][]string, error) {
+	if len(synonyms) > maxSynonymEntries {
+		return nil, fmt.Errorf("at most %d synonym entries per collection", maxSynonymEntries)
+	}
+	out := make(map[string][]string, len(synonyms))
+	for key, expansions := range synonyms {
+		k := strings.Join(termTokens(key), " ")
+		if k == "" {
+			return nil, fmt.Errorf("synonym %q has no words", key)
+		}
+		if len(expansions) == 0 {
+			return nil, fmt.Errorf("synonym %q needs at least one expansion", key)
+		}
+		if len(expansions) > maxSynonymExpansions {
+			return nil, fmt.Errorf("synonym %q: at most %d expansions", key, maxSynonymExpansions)
+		}
+		seen := map[string]bool{k: true}
+		for _, e := range out[k] {
+			seen[strings.ToLower(e)] = true
+		}
+		for _, e := range expansions {
+			e = strings.Join(strings.Fields(e), " ")
+			if len(termTokens(e)) == 0 {
+				return nil, fmt.Errorf("synonym %q: expansion %q has no words", key, e)
+			}
+			if !seen[strings.ToLower(e)] {
+				seen[strings.ToLower(e)] = true
+				out[k] = append(out[k], e)
+			}
+		}
+	}
+	return out, nil
+}
+
+// normalizeStopwords validates stopwords and returns them lowercased,
+// deduplicated and sorted.
+func normalizeStopwords(stopwords []string) ([]string, error) {
+	if len(stopwords) > maxStopwords {
+		return nil, fmt.Errorf("at most %d stopwords per collection", maxStopwords)
+	}
+	seen := make(map[string]bool, len(stopwords))
+	out := make([]string, 0, len(stopwords))
+	for _, s := range stopwords {
+		toks := termTokens(s)
+		if len(toks) != 1 {
+			return nil, fmt.Errorf("stopword %q must be a single word", s)
+		}
+		if !seen[toks[0]] {
+			seen[toks[0]] = true
+			out = append(out, toks[0])
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// termTokens lowercases s and splits it into letter and digit runs.
+func termTokens(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// rewriteQuery applies lists to query. Synonyms are matched against the
+// query as typed, so a phrase may contain stopwords; their expansions are
+// appended after the stopwords are removed. A query made only of
+// stopwords is left whole. It returns nil when nothing changed.
+func rewriteQuery(query string, lists SearchTermLists) *QueryRewrite {
+	if lists.empty() || strings.TrimSpace(query) == "" {
+		return nil
+	}
+	toks := termTokens(query)
+	present := " " + strings.Join(toks, " ") + " "
+
+	var expanded []string
+	keys := make([]string, 0, len(lists.Synonyms))
+	for k := range lists.Synonyms {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !strings.Contains(present, " "+k+" ") {
+			continue
+		}
+		for _, e := range lists.Synonyms[k] {
+			if len(expanded) == maxQueryExpansions {
+				break
+			}
+			norm := " " + strings.Join(termTokens(e), " ") + " "
+			if strings.Contains(present, norm) {
+				continue
+			}
+			present += strings.TrimPrefix(norm, " ")
+			expanded = append(expanded, e)
+		}
+	}
+
+	var removed []string
+	words := strings.Fields(query)
+	if len(lists.Stopwords) > 0 {
+		stop := make(map[string]bool, len(lists.Stopwords))
+		for _, s := range lists.Stopwords {
+			stop[s] = true
+		}
+		kept := make([]string, 0, len(words))
+		for _, w := range words {
+			if wt := termTokens(w); len(wt) == 1 && stop[wt[0]] {
+				removed = append(removed, w)
+				continue
+			}
+			kept = append(kept, w)
+		}
+		if len(kept) == 0 {
+			removed = nil
+		} else {
+			words = kept
+		}
+	}
+
+	if len(expanded) == 0 && len(removed) == 0 {
+		return nil
+	}
+	return &QueryRewrite{
+		Query:    strings.Join(append(words, expanded...), " "),
+		Expanded: expanded,
+		Removed:  removed,
+	}
+}
+
+// SearchTerms holds the synonym and stopword lists of each collection,
+// persisted in the gateway store. The lists under "*" apply to every
+// collection; a collection's own synonyms for the same word replace them,
+// its stopwords add to them.
+type SearchTerms struct {
+	mu    sync.RWMutex
+	store *store.Store
+	data  map[string]SearchTermLists
+}
+
+// NewSearchTerms loads the lists from st.
+func NewSearchTerms(st *store.Store) *SearchTerms {
+	t := &SearchTerms{store: st, data: make(map[string]SearchTermLists)}
+	if _, err := st.Load(searchTermsDoc, &t.data); err != nil {
+		log.Printf("WARNING: search terms: %v", err)
+	}
+	if t.data == nil {
+		t.data = make(map[string]SearchTermLists)
+	}
+	return t
+}
+
+// All returns the lists of every collection that has any.
+func (t *SearchTerms) All() map[string]SearchTermLists {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make(map[string]SearchTermLists, len(t.data))
+	for c, l := range t.data {
+		out[c] = l
+	}
+	return out
+}
+
+// Get returns the lists set for collection itself.
+func (t *SearchTerms) Get(collection string) SearchTermLists {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.data[collection]
+}
+
+// update changes the lists of collection with fn and saves them,
+// dropping collections left without lists.
+func (t *SearchTerms) update(collection string, fn func(*SearchTermLists)) (SearchTermLists, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l := t.data[collection]
+	fn(&l)
+	if l.empty() {
+		delete(t.data, collection)
+		l = SearchTermLists{}
+	} else {
+		now := time.Now().UTC()
+		l.UpdatedAt = &now
+		t.data[collection] = l
+	}
+	return l, t.store.Save(searchTermsDoc, t.data)
+}
+
+// effective merges the lists of "*" and collection.
+func (t *SearchTerms) effective(collection string) SearchTermLists {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	global, own := t.data[searchAny], t.data[collection]
+	if collection == searchAny || global.empty() {
+		return own
+	}
+	if own.empty() {
+		return global
+	}
+	out := SearchTermLists{Synonyms: make(map[string][]string, len(global.Synonyms)+len(own.Synonyms))}
+	for k, v := range global.Synonyms {
+		out.Synonyms[k] = v
+	}
+	for k, v := range own.Synonyms {
+		out.Synonyms[k] = v
+	}
+	out.Stopwords = append(append(out.Stopwords, global.Stopwords...), own.Stopwords...)
+	return out
+}
+
+// Rewrite applies the lists in effect for collection to query. It returns
+// nil when they leave the query unchanged; a nil SearchTerms never
+// changes it.
+func (t *SearchTerms) Rewrite(collection, query string) *QueryRewrite {
+	if t == nil {
+		return nil
+	}
+	return rewriteQuery(query, t.effective(collection))
+}
+
+// SearchTermsHandler serves the synonym and stopword lists under
+// /api/system/config/search.
+type SearchTermsHandler struct {
+	terms *SearchTerms
+}
+
+// NewSearchTermsHandler creates a new SearchTermsHandler.
+func NewSearchTermsHandler(terms *SearchTerms) *SearchTermsHandler {
+	return &SearchTermsHandler{terms: terms}
+}
+
+// Routes registers the synonym and stopword routes on the given chi router.
+func (h *SearchTermsHandler) Routes(r chi.Router) {
+	r.Get("/synonyms", h.ListSynonyms)
+	r.Get("/synonyms/{collection}", h.GetSynonyms)
+	r.Get("/stopwords", h.ListStopwords)
+	r.Get("/stopwords/{collection}", h.GetStopwords)
+	r.Post("/rewrite/test", h.TestRewrite)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireAdmin)
+		r.Put("/synonyms/{collection}", h.PutSynonyms)
+		r.Delete("/synonyms/{collection}", h.DeleteSynonyms)
+		r.Put("/stopwords/{collection}", h.PutStopwords)
+		r.Delete("/stopwords/{collection}", h.DeleteStopwords)
+	})
+}
+
+// ListSynonyms returns the synonyms of every collection that has any.
+func (h *SearchTermsHandler) ListSynonyms(w http.ResponseWriter, r *http.Request) {
+	out := make(map[string]map[string][]string)
+	for c, l := range h.terms.All() {
+		if len(l.Synonyms) > 0 {
+			out[c] = l.Synonyms
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"collections": out})
+}
+
+// GetSynonyms returns the synonyms set for one collection.
+func (h *SearchTermsHandler) GetSynonyms(w http.ResponseWriter, r *http.Request) {
+	collection := chi.URLParam(r, "collection")
+	synonyms := h.terms.Get(collection).Synonyms
+	if synonyms == nil {
+		synonyms = map[string][]string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"collection": collection, "synonyms": synonyms})
+}
+
+// PutSynonyms replaces the synonyms of one collection.
+func (h *SearchTermsHandler) PutSynonyms(w http.ResponseWriter, r *http.Request) {
+	collection := chi.URLParam(r, "collection")
+	var req struct {
+		Synonyms map[string][]string `json:"synonyms"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	synonyms, err := normalizeSynonyms(req.Synonyms)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.save(w, collection, func(l *SearchTermLists) { l.Synonyms = synonyms })
+}
+
+// DeleteSynonyms clears the synonyms of one collection.
+func (h *SearchTermsHandler) DeleteSynonyms(w http.ResponseWriter, r *http.Request) {
+	h.save(w, chi.URLParam(r, "collection"), func(l *SearchTermLists) { l.Synonyms = nil })
+}
+
+// ListStopwords returns the stopwords of every collection that has any.
+func (h *SearchTermsHandler) ListStopwords(w http.ResponseWriter, r *http.Request) {
+	out := make(map[string][]string)
+	for c, l := range h.terms.All() {
+		if len(l.Stopwords) > 0 {
+			out[c] = l.Stopwords
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"collections": out})
+}
+
+// GetStopwords returns the stopwords set for one collection.
+func (h *SearchTermsHandler) GetStopwords(w http.ResponseWriter, r *http.Request) {
+	collection := chi.URLParam(r, "collection")
+	stopwords := h.terms.Get(collection).Stopwords
+	if stopwords == nil {
+		stopwords = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"collection": collection, "stopwords": stopwords})
+}
+
+// PutStopwords replaces the stopwords of one collection.
+func (h *SearchTermsHandler) PutStopwords(w http.ResponseWriter, r *http.Request) {
+	collection := chi.URLParam(r, "collection")
+	var req struct {
+		Stopwords []string `json:"stopwords"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	stopwords, err := normalizeStopwords(req.Stopwords)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.save(w, collection, func(l *SearchTermLists) { l.Stopwords = stopwords })
+}
+
+// DeleteStopwords clears the stopwords of one collection.
+func (h *SearchTermsHandler) DeleteStopwords(w http.ResponseWriter, r *http.Request) {
+	h.save(w, chi.URLParam(r, "collection"), func(l *SearchTermLists) { l.Stopwords = nil })
+}
+
+func (h *SearchTermsHandler) save(w http.ResponseWriter, collection string, fn func(*SearchTermLists)) {
+	saved, err := h.terms.update(collection, fn)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if saved.Synonyms == nil {
+		saved.Synonyms = map[string][]string{}
+	}
+	if saved.Stopwords == nil {
+		saved.Stopwords = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"collection": collection,
+		"synonyms":   saved.Synonyms,
+		"stopwords":  saved.Stopwords,
+		"updated_at": saved.UpdatedAt,
+	})
+}
+
+// TestRewrite shows how a query would be rewritten for a collection
+// without searching.
+func (h *SearchTermsHandler) TestRewrite(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Collection string `json:"collection"`
+		Query      string `json:"query"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+	if req.Collection == "" {
+		req.Collection = workerDefaultCodebaseCollection
+	}
+	rw := h.terms.Rewrite(req.Collection, req.Query)
+	if rw == nil {
+		rw = &QueryRewrite{Query: req.Query}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"collection": req.Collection,
+		"original":   req.Query,
+		"query":      rw.Query,
+		"changed":    rw.Query != req.Query,
+		"expanded":   rw.Expanded,
+		"removed":    rw.Removed,
+	})
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"fmt"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+)
+
+// Result tuning defaults and limits. With MMR the worker is asked for
+// mmrFetchFactor times the requested hits (at most mmrMaxFetch) so there is
+// something to choose from.
+const (
+	searchDefaultTopK   = 5 // the worker's default top_k
+	mmrDefaultLambda    = 0.5
+	mmrFetchFactor      = 4
+	mmrMaxFetch         = 200
+	mmrSameFileMinSim   = 0.5
+	mmrMaxContentTokens = 512
+)
+
+// searchTuning holds the result filters shared by the search endpoints.
+// They run in the gateway on what the worker (or keyword search) returns.
+type searchTuning struct {
+	api.SearchTuning
+}
+
+// validate returns a message describing an invalid setting, or "".
+func (t searchTuning) validate() string {
+	if t.MMRLambda != nil && (*t.MMRLambda < 0 || *t.MMRLambda > 1) {
+		return "mmr_lambda must be between 0 and 1"
+	}
+	if t.FetchK < 0 || t.FetchK > mmrMaxFetch {
+		return fmt.Sprintf("fetch_k must be between 0 and %d (0 = auto)", mmrMaxFetch)
+	}
+	return ""
+}
+
+// active reports whether any filter is set.
+func (t searchTuning) active() bool {
+	return t.ScoreThreshold != nil || t.MMR
+}
+
+// fetchK returns how many hits to request for a result of topK.
+func (t searchTuning) fetchK(topK int32) int32 {
+	if !t.MMR {
+		return topK
+	}
+	n := t.FetchK
+	if n <= 0 {
+		n = min(topK*mmrFetchFactor, mmrMaxFetch)
+	}
+	return max(n, topK)
+}
+
+// keep reports whether a hit scoring score passes the threshold.
+func (t searchTuning) keep(score float32) bool {
+	return t.ScoreThreshold == nil || score >= *t.ScoreThreshold
+}
+
+func (t searchTuning) lambda() float32 {
+	if t.MMRLambda != nil {
+		return *t.MMRLambda
+	}
+	return mmrDefaultLambda
+}
+
+// apply filters hits, which are ordered by score, and returns at most topK.
+func (t searchTuning) apply(hits []*grpcclient.SearchHit, topK int32) []*grpcclient.SearchHit {
+	kept := hits[:0:0]
+	for _, hit := range hits {
+		if t.keep(hit.Score) {
+			kept = append(kept, hit)
+		}
+	}
+	if !t.MMR {
+		if len(kept) > int(topK) {
+			kept = kept[:topK]
+		}
+		return kept
+	}
+
+	scores := make([]float32, len(kept))
+	for i, hit := range kept {
+		scores[i] = hit.Score
+	}
+	order := mmrSelect(kept, scores, int(topK), t.lambda())
+	out := make([]*grpcclient.SearchHit, len(order))
+	for i, j := range order {
+		out[i] = kept[j]
+	}
+	return out
+}
+
+// mmrSelect picks k of hits by maximal marginal relevance and returns their
+// indexes in pick order. Relevance is scores divided by the best score.
+// Hits carry no vectors, so two hits are as similar as the Jaccard overlap
+// of their content tokens, and chunks of the same file are at least
+// mmrSameFileMinSim alike.
+func mmrSelect(hits []*grpcclient.SearchHit, scores []float32, k int, lambda float32) []int {
+	if k > len(hits) {
+		k = len(hits)
+	}
+	var best float32
+	for _, s := range scores {
+		best = max(best, s)
+	}
+	tokens := make([]map[string]bool, len(hits))
+	for i, hit := range hits {
+		text := hit.Content
+		if text == "" {
+			text = hit.Caption
+		}
+		tokens[i] = make(map[string]bool)
+		for j, tok := range tokenize(text) {
+			if j == mmrMaxContentTokens {
+				break
+			}
+			tokens[i][tok] = true
+		}
+	}
+	similarity := func(a, b int) float32 {
+		var sim float32
+		if inter := intersectSize(tokens[a], tokens[b]); inter > 0 {
+			sim = float32(inter) / float32(len(tokens[a])+len(tokens[b])-inter)
+		}
+		if hits[a].FilePath != "" && hits[a].FilePath == hits[b].FilePath {
+			sim = max(sim, mmrSameFileMinSim)
+		}
+		return sim
+	}
+
+	picked := make([]int, 0, k)
+	used := make([]bool, len(hits))
+	maxSim := make([]float32, len(hits)) // to the hits picked so far
+	for len(picked) < k {
+		pick := -1
+		var pickValue float32
+		for i := range hits {
+			if used[i] {
+				continue
+			}
+			rel := scores[i]
+			if best > 0 {
+				rel /= best
+			}
+			value := lambda*rel - (1-lambda)*maxSim[i]
+			if pick < 0 || value > pickValue {
+				pick, pickValue = i, value
+			}
+		}
+		used[pick] = true
+		picked = append(picked, pick)
+		for i := range hits {
+			if !used[i] {
+				maxSim[i] = max(maxSim[i], similarity(i, pick))
+			}
+		}
+	}
+	return picked
+}
+
+func intersectSize(a, b map[string]bool) int {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	n := 0
+	for t := range a {
+		if b[t] {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+)
+
+func tuningHits() []*grpcclient.SearchHit {
+	return []*grpcclient.SearchHit{
+		{FilePath: "a.go", Score: 0.9, Content: "open the config file"},
+		{FilePath: "a.go", Score: 0.85, Content: "parse the config values"},
+		{FilePath: "b.go", Score: 0.8, Content: "dial the worker over grpc"},
+		{FilePath: "c.md", Score: 0.4, Content: "release notes"},
+	}
+}
+
+func hitPaths(hits []*grpcclient.SearchHit) []string {
+	out := make([]string, len(hits))
+	for i, h := range hits {
+		out[i] = h.FilePath
+	}
+	return out
+}
+
+func TestSearchTuningApply(t *testing.T) {
+	one := float32(1)
+	zero := float32(0)
+	high := float32(0.95)
+	mid := float32(0.5)
+	tests := []struct {
+		name   string
+		tuning api.SearchTuning
+		topK   int32
+		want   []string
+	}{
+		{"no filters", api.SearchTuning{}, 3, []string{"a.go", "a.go", "b.go"}},
+		{"threshold", api.SearchTuning{ScoreThreshold: &mid}, 5, []string{"a.go", "a.go", "b.go"}},
+		{"threshold drops all", api.SearchTuning{ScoreThreshold: &high}, 5, []string{}},
+		{"threshold drops all with mmr", api.SearchTuning{ScoreThreshold: &high, MMR: true}, 5, []string{}},
+		{"lambda 1 keeps score order", api.SearchTuning{MMR: true, MMRLambda: &one}, 4, []string{"a.go", "a.go", "b.go", "c.md"}},
+		{"same file demoted", api.SearchTuning{MMR: true}, 2, []string{"a.go", "b.go"}},
+		{"lambda 0 picks least similar", api.SearchTuning{MMR: true, MMRLambda: &zero}, 3, []string{"a.go", "c.md", "b.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hitPaths(searchTuning{tt.tuning}.apply(tuningHits(), tt.topK))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apply = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMMRSelectLambdaOne(t *testing.T) {
+	hits := tuningHits()
+	scores := []float32{0.9, 0.85, 0.8, 0.4}
+	if got := mmrSelect(hits, scores, 10, 1); !reflect.DeepEqual(got, []int{0, 1, 2, 3}) {
+		t.Errorf("mmrSelect = %v, want score order", got)
+	}
+}
+
+func TestSearchTuningFetchK(t *testing.T) {
+	tests := []struct {
+		name   string
+		tuning api.SearchTuning
+		topK   int32
+		want   int32
+	}{
+		{"no mmr", api.SearchTuning{FetchK: 50}, 5, 5},
+		{"auto", api.SearchTuning{MMR: true}, 5, 5 * mmrFetchFactor},
+		{"auto capped", api.SearchTuning{MMR: true}, 100, mmrMaxFetch},
+		{"explicit", api.SearchTuning{MMR: true, FetchK: 30}, 5, 30},
+		{"never below top_k", api.SearchTuning{MMR: true, FetchK: 3}, 10, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (searchTuning{tt.tuning}).fetchK(tt.topK); got != tt.want {
+				t.Errorf("fetchK(%d) = %d, want %d", tt.topK, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchTuningValidate(t *testing.T) {
+	bad := float32(1.5)
+	tests := []struct {
+		name   string
+		tuning api.SearchTuning
+		ok     bool
+	}{
+		{"defaults", api.SearchTuning{}, true},
+		{"fetch_k auto", api.SearchTuning{MMR: true, FetchK: 0}, true},
+		{"fetch_k max", api.SearchTuning{MMR: true, FetchK: mmrMaxFetch}, true},
+		{"fetch_k too large", api.SearchTuning{MMR: true, FetchK: mmrMaxFetch + 1}, false},
+		{"fetch_k negative", api.SearchTuning{MMR: true, FetchK: -1}, false},
+		{"lambda out of range", api.SearchTuning{MMR: true, MMRLambda: &bad}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := searchTuning{tt.tuning}.validate()
+			if (msg == "") != tt.ok {
+				t.Errorf("validate = %q, want ok=%v", msg, tt.ok)
+			}
+		})
+	}
+}
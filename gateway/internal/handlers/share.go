@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// shareLinksDoc is the store document holding share links.
+const shareLinksDoc = "share-links"
+
+// Share link limits.
+const (
+	shareDefaultTTL    = 24 * time.Hour
+	shareMaxTTL        = 30 * 24 * time.Hour
+	maxShareBody       = 2 << 20 // bytes of a mint request
+	maxShareResults    = 200
+	maxShareMessages   = 500
+	maxShareTitleRunes = 200
+)
+
+// Kinds of shared content.
+const (
+	// shareKindFile is an indexed file, served from disk when viewed.
+	shareKindFile = "file"
+	// shareKindSearch is a snapshot of a search result set.
+	shareKindSearch = "search"
+	// shareKindConversation is a snapshot of a chat conversation.
+	shareKindConversation = "conversation"
+)
+
+// ShareLink is a shared file, search result set or conversation. Search
+// results and conversations are stored as sent when the link was minted, so
+// the link shows what its creator saw.
+type ShareLink struct {
+	ID         string            `json:"id"`
+	Kind       string            `json:"kind"`
+	Title      string            `json:"title,omitempty"`
+	CreatedBy  string            `json:"created_by"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+	Collection string            `json:"collection,omitempty"`
+	FilePath   string            `json:"file_path,omitempty"`
+	Query      string            `json:"query,omitempty"`
+	Results    []json.RawMessage `json:"results,omitempty"`
+	Messages   []shareMessage    `json:"messages,omitempty"`
+}
+
+// shareMessage is one turn of a shared conversation.
+type shareMessage struct {
+	Role      string          `json:"role"`
+	Content   string          `json:"content"`
+	Citations json.RawMessage `json:"citations,omitempty"`
+}
+
+// ShareLinks holds the minted share links, persisted in the gateway store.
+// Expired links are dropped when the set is loaded or changed.
+type ShareLinks struct {
+	mu    sync.Mutex
+	store *store.Store
+	links map[string]*ShareLink
+}
+
+// NewShareLinks loads unexpired share links from st.
+func NewShareLinks(st *store.Store) *ShareLinks {
+	s := &ShareLinks{store: st, links: make(map[string]*ShareLink)}
+	if _, err := st.Load(shareLinksDoc, &s.links); err != nil {
+		log.Printf("WARNING: share links: %v", err)
+	}
+	s.mu.Lock()
+	s.pruneLocked(time.Now())
+	s.mu.Unlock()
+	return s
+}
+
+// Add stores a new link.
+func (s *ShareLinks) Add(link *ShareLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(time.Now())
+	s.links[link.ID] = link
+	return s.store.Save(shareLinksDoc, s.links)
+}
+
+// Get returns an unexpired link by ID.
+func (s *ShareLinks) Get(id string) (*ShareLink, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	link, ok := s.links[id]
+	if !ok || time.Now().After(link.ExpiresAt) {
+		return nil, false
+	}
+	return link, true
+}
+
+// List returns the unexpired links created by username, or every link if
+// username is empty, newest first.
+func (s *ShareLinks) List(username string) []*ShareLink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	out := make([]*ShareLink, 0, len(s.links))
+	for _, link := range s.links {
+		if now.After(link.ExpiresAt) || (username != "" && link.CreatedBy != username) {
+			continue
+		}
+		out = append(out, link)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Revoke deletes a link, reporting whether it existed.
+func (s *ShareLinks) Revoke(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.links[id]; !ok {
+		return false, nil
+	}
+	delete(s.links, id)
+	s.pruneLocked(time.Now())
+	return true, s.store.Save(shareLinksDoc, s.links)
+}
+
+func (s *ShareLinks) pruneLocked(now time.Time) {
+	for id, link := range s.links {
+		if now.After(link.ExpiresAt) {
+			delete(s.links, id)
+		}
+	}
+}
+
+// ShareHandler mints share links and serves them. Minting and managing
+// links needs a login; the links themselves are public and authorized by
+// their signed token alone.
+type ShareHandler struct {
+	links    *ShareLinks
+	preview  *PreviewHandler
+	key      []byte
+	basePath string
+}
+
+// NewShareHandler creates a new ShareHandler. Tokens are signed with a key
+// derived from secret (JWT_SECRET), so links stop working when it changes.
+// Shared files are resolved like previews.
+func NewShareHandler(links *ShareLinks, preview *PreviewHandler, secret, basePath string) *ShareHandler {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("ollqd share links"))
+	return &ShareHandler{links: links, preview: preview, key: mac.Sum(nil), basePath: basePath}
+}
+
+// Routes registers the link management routes (under /api/rag/share).
+func (h *ShareHandler) Routes(r chi.Router) {
+	r.Post("/", h.CreateShare)
+	r.Get("/", h.ListShares)
+	r.Delete("/{id}", h.RevokeShare)
+}
+
+// PublicRoutes registers the routes serving shared content (under
+// /api/share).
+func (h *ShareHandler) PublicRoutes(r chi.Router) {
+	r.Get("/{token}", h.ViewShare)
+	r.Get("/{token}/file", h.ServeSharedFile)
+}
+
+// token returns the URL token of link: its ID, expiry and a signature over
+// both.
+func (h *ShareHandler) token(link *ShareLink) string {
+	payload := link.ID + "." + strconv.FormatInt(link.ExpiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:18])
+}
+
+// linkForToken verifies token and returns its link. Bad signatures,
+// expired and revoked links all look the same to the caller.
+func (h *ShareHandler) linkForToken(token string) (*ShareLink, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return nil, false
+	}
+	link, ok := h.links.Get(parts[0])
+	if !ok || link.ExpiresAt.Unix() != exp {
+		return nil, false
+	}
+	if !hmac.Equal([]byte(token), []byte(h.token(link))) {
+		return nil, false
+	}
+	return link, true
+}
+
+func (h *ShareHandler) linkURL(link *ShareLink) string {
+	return h.basePath + "/api/share/" + h.token(link)
+}
+
+// shareView is a link as returned to the users managing it.
+type shareView struct {
+	*ShareLink
+	URL string `json:"url"`
+}
+
+// shareSummary leaves the snapshot out of link listings.
+func (h *ShareHandler) shareSummary(link *ShareLink) shareView {
+	summary := *link
+	summary.Results, summary.Messages = nil, nil
+	return shareView{ShareLink: &summary, URL: h.linkURL(link)}
+}
+
+// CreateShare handles POST /api/rag/share. The body names the kind and its
+// content: collection and file_path for "file", query and results for
+// "search", messages for "conversation". ttl_hours sets the lifetime
+// (default 24, at most 720).
+func (h *ShareHandler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Kind       string            `json:"kind"`
+		Title      string            `json:"title"`
+		TTLHours   float64           `json:"ttl_hours"`
+		Collection string            `json:"collection"`
+		FilePath   string            `json:"file_path"`
+		Query      string            `json:"query"`
+		Results    []json.RawMessage `json:"results"`
+		Messages   []shareMessage    `json:"messages"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxShareBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	ttl := shareDefaultTTL
+	if req.TTLHours != 0 {
+		ttl = time.Duration(req.TTLHours * float64(time.Hour))
+		if ttl < time.Minute || ttl > shareMaxTTL {
+			writeError(w, http.StatusBadRequest,
+				fmt.Sprintf("ttl_hours must be at least one minute and at most %d", int(shareMaxTTL.Hours())))
+			return
+		}
+	}
+	if len([]rune(req.Title)) > maxShareTitleRunes {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("title must be at most %d characters", maxShareTitleRunes))
+		return
+	}
+
+	now := time.Now().UTC()
+	link := &ShareLink{
+		ID:        uuid.NewString(),
+		Kind:      req.Kind,
+		Title:     strings.TrimSpace(req.Title),
+		CreatedBy: middleware.UsernameFromContext(r.Context()),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl).Truncate(time.Second),
+	}
+
+	switch req.Kind {
+	case shareKindFile:
+		if req.FilePath == "" {
+			writeError(w, http.StatusBadRequest, "file_path is required")
+			return
+		}
+		link.Collection = req.Collection
+		if link.Collection == "" {
+			link.Collection = h.preview.colls.DefaultCollection()
+		}
+		if link.Collection == "" {
+			writeError(w, http.StatusBadRequest, "collection is required")
+			return
+		}
+		if _, err := h.preview.resolve(r.Context(), link.Collection, req.FilePath); err != nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("file %s: %v", req.FilePath, err))
+			return
+		}
+		link.FilePath = req.FilePath
+	case shareKindSearch:
+		if strings.TrimSpace(req.Query) == "" {
+			writeError(w, http.StatusBadRequest, "query is required")
+			return
+		}
+		if len(req.Results) > maxShareResults {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d results can be shared", maxShareResults))
+			return
+		}
+		link.Collection, link.Query = req.Collection, req.Query
+		link.Results = req.Results
+		if link.Results == nil {
+			link.Results = []json.RawMessage{}
+		}
+	case shareKindConversation:
+		if len(req.Messages) == 0 {
+			writeError(w, http.StatusBadRequest, "messages is required")
+			return
+		}
+		if len(req.Messages) > maxShareMessages {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d messages can be shared", maxShareMessages))
+			return
+		}
+		for i, m := range req.Messages {
+			if m.Role != "user" && m.Role != "assistant" {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("messages[%d].role must be \"user\" or \"assistant\"", i))
+				return
+			}
+		}
+		link.Collection = req.Collection
+		link.Messages = req.Messages
+	default:
+		writeError(w, http.StatusBadRequest,
+			fmt.Sprintf("kind must be %q, %q or %q", shareKindFile, shareKindSearch, shareKindConversation))
+		return
+	}
+
+	if err := h.links.Add(link); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, h.shareSummary(link))
+}
+
+// ListShares handles GET /api/rag/share: the caller's unexpired links, or
+// with ?all=true every link (admins only).
+func (h *ShareHandler) ListShares(w http.ResponseWriter, r *http.Request) {
+	owner := middleware.UsernameFromContext(r.Context())
+	if r.URL.Query().Get("all") == "true" {
+		if middleware.RoleFromContext(r.Context()) != "admin" {
+			writeError(w, http.StatusForbidden, "admin access required")
+			return
+		}
+		owner = ""
+	}
+	links := h.links.List(owner)
+	out := make([]shareView, len(links))
+	for i, link := range links {
+		out[i] = h.shareSummary(link)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"links": out, "count": len(out)})
+}
+
+// RevokeShare handles DELETE /api/rag/share/{id}. Only the creator of a
+// link or an admin may revoke it.
+func (h *ShareHandler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	link, ok := h.links.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "share link not found")
+		return
+	}
+	if link.CreatedBy != middleware.UsernameFromContext(r.Context()) &&
+		middleware.RoleFromContext(r.Context()) != "admin" {
+		writeError(w, http.StatusForbidden, "only the creator of a share link or an admin can revoke it")
+		return
+	}
+	if _, err := h.links.Revoke(id); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"revoked": id})
+}
+
+// ViewShare handles GET /api/share/{token}: the shared content, without a
+// login. A shared file is described here and downloaded from file_url.
+func (h *ShareHandler) ViewShare(w http.ResponseWriter, r *http.Request) {
+	link, ok := h.linkForToken(chi.URLParam(r, "token"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "share link not found or expired")
+		return
+	}
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	out := map[string]interface{}{
+		"kind":       link.Kind,
+		"title":      link.Title,
+		"created_by": link.CreatedBy,
+		"created_at": link.CreatedAt,
+		"expires_at": link.ExpiresAt,
+		"collection": link.Collection,
+	}
+	switch link.Kind {
+	case shareKindFile:
+		out["file_path"] = link.FilePath
+		out["file_name"] = filepath.Base(link.FilePath)
+		out["file_url"] = h.linkURL(link) + "/file"
+	case shareKindSearch:
+		out["query"] = link.Query
+		out["results"] = link.Results
+	case shareKindConversation:
+		out["messages"] = link.Messages
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// ServeSharedFile handles GET /api/share/{token}/file: the shared file's
+// current content. ?download=true asks the browser to save it.
+func (h *ShareHandler) ServeSharedFile(w http.ResponseWriter, r *http.Request) {
+	link, ok := h.linkForToken(chi.URLParam(r, "token"))
+	if !ok || link.Kind != shareKindFile {
+		writeError(w, http.StatusNotFound, "share link not found or expired")
+		return
+	}
+	full, err := h.preview.resolve(r.Context(), link.Collection, link.FilePath)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+	disposition := "inline"
+	if r.URL.Query().Get("download") == "true" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Disposition",
+		mime.FormatMediaType(disposition, map[string]string{"filename": filepath.Base(link.FilePath)}))
+	w.Header().Set("Cache-Control", "private, no-store")
+	// Shared HTML or SVG must not run script on the gateway's origin.
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeFile(w, r, full)
+}
@@ -4,44 +4,96 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"sort"
 	"sync"
 
 	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
 	"github.com/alfagnish/ollqd-gateway/internal/tasks"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
-// SMBShare represents a saved SMB share configuration stored in memory.
+// smbSharesDoc is the store document holding saved SMB shares. Passwords and
+// keytabs are kept so scheduled syncs can connect unattended.
+const smbSharesDoc = "smb-shares"
+
+// SMBShare represents a saved SMB share configuration. Kind "server" saves
+// a whole host instead of one share; Share is then empty.
 type SMBShare struct {
-	ID       string `json:"id"`
-	Server   string `json:"server"`
-	Share    string `json:"share"`
-	Username string `json:"username"`
-	Password string `json:"password,omitempty"`
-	Domain   string `json:"domain"`
-	Port     int32  `json:"port"`
-	Label    string `json:"label"`
+	ID       string         `json:"id"`
+	Kind     string         `json:"kind,omitempty"`
+	Server   string         `json:"server"`
+	Share    string         `json:"share"`
+	Username string         `json:"username"`
+	Password string         `json:"password,omitempty"`
+	Domain   string         `json:"domain"`
+	Port     int32          `json:"port"`
+	Label    string         `json:"label"`
+	Sync     *SMBSyncPolicy `json:"sync,omitempty"`
+	// CaptureACLs stores each indexed file's owner and DACL in its points,
+	// for filtering search results by share permissions.
+	CaptureACLs bool `json:"capture_acls,omitempty"`
+	// Auth is how the worker signs in: "" (NTLMv2) or "kerberos", which
+	// uses Realm, the optional KDC and the keytab or password.
+	Auth   string `json:"auth,omitempty"`
+	Realm  string `json:"realm,omitempty"`
+	KDC    string `json:"kdc,omitempty"`
+	Keytab []byte `json:"keytab,omitempty"`
+	// HasKeytab is set in responses, which leave the keytab out.
+	HasKeytab bool `json:"has_keytab,omitempty"`
 }
 
-// SMBHandler manages SMB share configurations and proxies browse/test
-// requests to the gRPC SMBService.
+// SMBHandler manages SMB share configurations, proxies browse/test requests
+// to the gRPC SMBService and runs scheduled share syncs.
 type SMBHandler struct {
 	grpc   *grpcclient.Client
 	tm     *tasks.Manager
+	colls  *CollectionSettings
+	diff   *DiffIndexer
+	guard  *Guardrails
+	store  *store.Store
 	mu     sync.RWMutex
 	shares map[string]*SMBShare
+	syncs  map[string]*SMBSyncStatus
 }
 
-// NewSMBHandler creates a new SMBHandler with an empty share store.
-func NewSMBHandler(gc *grpcclient.Client, tm *tasks.Manager) *SMBHandler {
-	return &SMBHandler{
+// NewSMBHandler creates a new SMBHandler with the shares saved in st.
+// Index requests are checked against guard before they start.
+func NewSMBHandler(gc *grpcclient.Client, tm *tasks.Manager, colls *CollectionSettings, st *store.Store, diff *DiffIndexer, guard *Guardrails) *SMBHandler {
+	h := &SMBHandler{
 		grpc:   gc,
 		tm:     tm,
+		colls:  colls,
+		diff:   diff,
+		guard:  guard,
+		store:  st,
 		shares: make(map[string]*SMBShare),
+		syncs:  make(map[string]*SMBSyncStatus),
+	}
+	var saved []*SMBShare
+	if _, err := st.Load(smbSharesDoc, &saved); err != nil {
+		log.Printf("WARNING: smb shares: %v", err)
+	}
+	for _, s := range saved {
+		h.shares[s.ID] = s
+	}
+	h.loadSyncStatusLocked()
+	return h
+}
+
+// saveLocked persists the shares. Callers must hold h.mu.
+func (h *SMBHandler) saveLocked() {
+	out := make([]*SMBShare, 0, len(h.shares))
+	for _, s := range h.shares {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if err := h.store.Save(smbSharesDoc, out); err != nil {
+		log.Printf("WARNING: save smb shares: %v", err)
 	}
 }
 
@@ -50,9 +102,15 @@ func (h *SMBHandler) Routes(r chi.Router) {
 	r.Get("/shares", h.ListShares)
 	r.Post("/shares", h.AddShare)
 	r.Delete("/shares/{id}", h.RemoveShare)
-	r.Post("/shares/test", h.TestConnection)
-	r.Post("/shares/{id}/browse", h.Browse)
-	r.Post("/shares/{id}/index", h.Index)
+	r.Put("/shares/{id}/keytab", h.PutKeytab)
+	r.Delete("/shares/{id}/keytab", h.DeleteKeytab)
+	r.With(requireWorker(h.grpc, grpcclient.ServiceSMBBrowse)).Post("/shares/test", h.TestConnection)
+	r.With(requireWorker(h.grpc, grpcclient.ServiceSMBBrowse)).Post("/shares/{id}/browse", h.Browse)
+	r.With(requireWorker(h.grpc, grpcclient.ServiceIndexing)).Post("/shares/{id}/index", h.Index)
+	r.Get("/shares/{id}/sync", h.GetSync)
+	r.Put("/shares/{id}/sync", h.PutSync)
+	r.Delete("/shares/{id}/sync", h.DeleteSync)
+	r.With(requireWorker(h.grpc, grpcclient.ServiceSMBSync, grpcclient.ServiceIndexing)).Post("/shares/{id}/sync/run", h.RunSync)
 }
 
 // ListShares returns all saved SMB shares.
@@ -60,12 +118,10 @@ func (h *SMBHandler) ListShares(w http.ResponseWriter, r *http.Request) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	shares := make([]*SMBShare, 0, len(h.shares))
+	shares := make([]SMBShare, 0, len(h.shares))
 	for _, s := range h.shares {
-		// Return a copy without the password.
-		cp := *s
-		cp.Password = ""
-		shares = append(shares, &cp)
+		// Return a copy without the password and keytab.
+		shares = append(shares, s.redacted())
 	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
@@ -86,16 +142,28 @@ func (h *SMBHandler) AddShare(w http.ResponseWriter, r *http.Request) {
 	if req.Port == 0 {
 		req.Port = 445
 	}
+	if err := validateShareKind(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateShareAuth(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Sync != nil {
+		if err := validateSyncPolicy(req.Sync); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
 	h.mu.Lock()
 	h.shares[req.ID] = &req
+	h.saveLocked()
 	h.mu.Unlock()
 
-	// Return without password.
-	resp := req
-	resp.Password = ""
-
-	writeJSON(w, http.StatusCreated, resp)
+	// Return without password and keytab.
+	writeJSON(w, http.StatusCreated, req.redacted())
 }
 
 // RemoveShare deletes a saved SMB share by ID.
@@ -106,6 +174,8 @@ func (h *SMBHandler) RemoveShare(w http.ResponseWriter, r *http.Request) {
 	_, exists := h.shares[id]
 	if exists {
 		delete(h.shares, id)
+		delete(h.syncs, id)
+		h.saveLocked()
 	}
 	h.mu.Unlock()
 
@@ -113,17 +183,68 @@ func (h *SMBHandler) RemoveShare(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, fmt.Sprintf("share %s not found", id))
 		return
 	}
+	h.forgetSyncState(id)
 
 	writeJSON(w, http.StatusOK, map[string]string{"deleted": id})
 }
 
-// TestConnection tests connectivity to an SMB share via gRPC.
-func (h *SMBHandler) TestConnection(w http.ResponseWriter, r *http.Request) {
-	if h.grpc.SMB == nil {
-		writeError(w, http.StatusServiceUnavailable, "smb service not available")
-		return
+// Shares returns copies of all saved shares sorted by label and ID. Passwords
+// and keytabs are cleared unless withCredentials is set.
+func (h *SMBHandler) Shares(withCredentials bool) []SMBShare {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	out := make([]SMBShare, 0, len(h.shares))
+	for _, s := range h.shares {
+		cp := *s
+		if !withCredentials {
+			cp = s.redacted()
+		}
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Label != out[j].Label {
+			return out[i].Label < out[j].Label
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// ImportShares adds or replaces shares by ID. A share imported without a
+// password or keytab keeps that of the existing share with the same ID, so a
+// bundle exported without credentials does not wipe them.
+func (h *SMBHandler) ImportShares(shares []SMBShare) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, s := range shares {
+		s := s
+		if s.ID == "" {
+			s.ID = uuid.New().String()
+		}
+		if s.Port == 0 {
+			s.Port = 445
+		}
+		if old, ok := h.shares[s.ID]; ok {
+			if s.Password == "" {
+				s.Password = old.Password
+			}
+			if len(s.Keytab) == 0 && old.Auth == s.Auth {
+				s.Keytab = old.Keytab
+			}
+		}
+		s.HasKeytab = false
+		h.shares[s.ID] = &s
 	}
+	h.saveLocked()
+}
 
+// TestConnection tests the credentials of an unsaved entry through the
+// worker by listing the root of the share, or for a server entry (no share)
+// the host's shares. The body takes the fields of a saved share, including
+// the Kerberos settings with an inline base64 keytab.
+func (h *SMBHandler) TestConnection(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Server   string `json:"server"`
 		Share    string `json:"share"`
@@ -131,31 +252,49 @@ func (h *SMBHandler) TestConnection(w http.ResponseWriter, r *http.Request) {
 		Password string `json:"password"`
 		Domain   string `json:"domain"`
 		Port     int32  `json:"port"`
+		Auth     string `json:"auth"`
+		Realm    string `json:"realm"`
+		KDC      string `json:"kdc"`
+		Keytab   []byte `json:"keytab"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxKeytabBody)).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
-
-	resp, err := h.grpc.SMB.TestConnection(r.Context(), &grpcclient.SMBTestRequest{
+	share := &SMBShare{
 		Server:   req.Server,
 		Share:    req.Share,
 		Username: req.Username,
 		Password: req.Password,
 		Domain:   req.Domain,
 		Port:     req.Port,
-	})
-	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		Auth:     req.Auth,
+		Realm:    req.Realm,
+		KDC:      req.KDC,
+		Keytab:   req.Keytab,
+	}
+	if share.Port == 0 {
+		share.Port = 445
+	}
+	if err := validateShareAuth(share); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-
-	writeJSON(w, http.StatusOK, resp)
+	if err := h.checkShareAuth(share); err != nil {
+		writeShareAuthError(w, err)
+		return
+	}
+	if req.Share == "" {
+		h.testServer(w, r, share)
+		return
+	}
+	h.testShare(w, r, share)
 }
 
 // Browse lists files in a remote SMB path using a saved share's credentials.
+// DFS links are followed; for a server entry, "/" lists the host's shares.
 func (h *SMBHandler) Browse(w http.ResponseWriter, r *http.Request) {
-	if h.grpc.SMB == nil {
+	if h.grpc.SMBBrowse == nil {
 		writeError(w, http.StatusServiceUnavailable, "smb service not available")
 		return
 	}
@@ -170,6 +309,10 @@ func (h *SMBHandler) Browse(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, fmt.Sprintf("share %s not found", id))
 		return
 	}
+	if err := h.checkShareAuth(share); err != nil {
+		writeShareAuthError(w, err)
+		return
+	}
 
 	var req struct {
 		Path string `json:"path"`
@@ -179,17 +322,9 @@ func (h *SMBHandler) Browse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.grpc.SMB.Browse(r.Context(), &grpcclient.SMBBrowseRequest{
-		Server:   share.Server,
-		Share:    share.Share,
-		Username: share.Username,
-		Password: share.Password,
-		Domain:   share.Domain,
-		Port:     share.Port,
-		Path:     req.Path,
-	})
+	resp, err := h.browse(r.Context(), share, req.Path)
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -213,6 +348,10 @@ func (h *SMBHandler) Index(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, fmt.Sprintf("share %s not found", id))
 		return
 	}
+	if err := h.checkShareAuth(share); err != nil {
+		writeShareAuthError(w, err)
+		return
+	}
 
 	var req struct {
 		RemotePaths  []string `json:"remote_paths"`
@@ -220,92 +359,89 @@ func (h *SMBHandler) Index(w http.ResponseWriter, r *http.Request) {
 		ChunkSize    int32    `json:"chunk_size"`
 		ChunkOverlap int32    `json:"chunk_overlap"`
 		SourceTag    string   `json:"source_tag"`
+		Priority     string   `json:"priority"`
+		Lock         string   `json:"lock"`
+		CaptureACLs  *bool    `json:"capture_acls"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
+	captureACLs := share.CaptureACLs
+	if req.CaptureACLs != nil {
+		captureACLs = *req.CaptureACLs
+	}
+	priority, err := tasks.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	lockMode, err := tasks.ParseLockMode(req.Lock)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	shareName, remotePaths, ok := indexTarget(w, share, req.RemotePaths)
+	if !ok {
+		return
+	}
+	req.Collection, req.ChunkSize, req.ChunkOverlap = h.colls.ResolveIndex(req.Collection, req.ChunkSize, req.ChunkOverlap)
 
 	params := map[string]interface{}{
 		"share_id":      id,
-		"remote_paths":  req.RemotePaths,
+		"remote_paths":  remotePaths,
 		"collection":    req.Collection,
 		"chunk_size":    req.ChunkSize,
 		"chunk_overlap": req.ChunkOverlap,
 		"source_tag":    req.SourceTag,
 		"server":        share.Server,
-		"share":         share.Share,
+		"share":         shareName,
 		"username":      share.Username,
 		"password":      share.Password,
 		"domain":        share.Domain,
 		"port":          share.Port,
+		"priority":      string(priority),
+		"lock":          string(lockMode),
+		"capture_acls":  captureACLs,
 	}
+	share.addAuthFields(params)
 
+	warnings, ok := h.guard.admit(r.Context(), w, 0)
+	if !ok {
+		return
+	}
 	taskID := h.tm.Create("index_smb", params)
-	h.tm.Start(taskID)
+	h.tm.AddWarnings(taskID, warnings)
+	if !lockIndexTarget(w, h.tm, taskID, req.Collection, workerDefaultDocumentsCollection, lockMode) {
+		return
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	h.tm.SetCancelFunc(taskID, cancel)
 
-	go func() {
-		stream, err := h.grpc.Indexing.IndexSMBFiles(ctx, &grpcclient.IndexSMBFilesRequest{
-			ShareId:      id,
-			RemotePaths:  req.RemotePaths,
-			Collection:   req.Collection,
-			ChunkSize:    req.ChunkSize,
-			ChunkOverlap: req.ChunkOverlap,
-			SourceTag:    req.SourceTag,
-			Server:       share.Server,
-			Share:        share.Share,
-			Username:     share.Username,
-			Password:     share.Password,
-			Domain:       share.Domain,
-			Port:         share.Port,
-		})
-		if err != nil {
-			h.tm.Fail(taskID, fmt.Sprintf("failed to open stream: %v", err))
-			return
-		}
-		defer stream.Close()
-
-		for {
-			select {
-			case <-ctx.Done():
-				h.tm.Fail(taskID, "cancelled")
-				return
-			default:
-			}
-
-			progress, err := stream.Recv()
-			if err != nil {
-				if err == io.EOF {
-					h.tm.Complete(taskID, nil)
-				} else {
-					h.tm.Fail(taskID, fmt.Sprintf("stream error: %v", err))
-				}
-				return
-			}
-
-			switch progress.Status {
-			case "running":
-				h.tm.UpdateProgress(taskID, float64(progress.Progress), "running")
-			case "completed":
-				h.tm.Complete(taskID, progress.Result)
-				return
-			case "failed":
-				h.tm.Fail(taskID, progress.Error)
-				return
-			case "cancelled":
-				h.tm.Cancel(taskID)
-				return
-			default:
-				log.Printf("[smb task %s] unknown status: %s", taskID, progress.Status)
+	uploader := middleware.UsernameFromContext(r.Context())
+	h.tm.Enqueue(taskID, priority, func() {
+		h.tm.ConsumeIndexStream(ctx, h.grpc, taskID, func() (grpcclient.IndexingStream, error) {
+			mctx := grpcclient.WithSMBACLs(ctx, captureACLs)
+			mctx = grpcclient.WithProvenance(mctx, grpcclient.Provenance{SourceType: grpcclient.SourceSMB, ShareID: id, Uploader: uploader, TaskID: taskID})
+			indexReq := &grpcclient.IndexSMBFilesRequest{
+				ShareId:      id,
+				RemotePaths:  remotePaths,
+				Collection:   req.Collection,
+				ChunkSize:    req.ChunkSize,
+				ChunkOverlap: req.ChunkOverlap,
+				SourceTag:    req.SourceTag,
+				Server:       share.Server,
+				Share:        shareName,
+				Username:     share.Username,
+				Password:     share.Password,
+				Domain:       share.Domain,
+				Port:         share.Port,
 			}
-		}
-	}()
-
-	writeJSON(w, http.StatusAccepted, map[string]interface{}{
-		"task_id": taskID,
-		"status":  "started",
+			share.setRequestAuth(indexReq)
+			return h.grpc.Indexing.IndexSMBFiles(mctx, indexReq)
+		})
 	})
+
+	writeTaskAccepted(w, h.tm, taskID)
 }
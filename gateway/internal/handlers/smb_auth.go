@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/go-chi/chi/v5"
+)
+
+// Ways the worker signs in to a share.
+const (
+	// smbAuthNTLMv2 is NTLMv2 with the share's username and password, the
+	// default. It is stored as "".
+	smbAuthNTLMv2 = "ntlmv2"
+	// smbAuthKerberos is Kerberos, for domains that disable NTLM. The
+	// principal is username@realm; its key comes from the keytab if one is
+	// set, else from the password.
+	smbAuthKerberos = "kerberos"
+)
+
+const (
+	// maxKeytabBytes bounds a share's keytab. It travels to the worker in
+	// each SMB request, and a keytab for one principal is well under 1 KiB.
+	maxKeytabBytes = 4 << 10
+	// maxKeytabBody bounds a keytab upload, multipart framing included.
+	maxKeytabBody = 64 << 10
+)
+
+var (
+	// errSMBKerberosUnsupported is returned for a Kerberos share when the
+	// worker would silently fall back to NTLM.
+	errSMBKerberosUnsupported = errors.New("the connected worker does not support Kerberos SMB auth; upgrade the worker to use this share")
+	// errSMBKerberosCredentials is returned for a Kerberos share with
+	// neither a password nor a keytab.
+	errSMBKerberosCredentials = errors.New("kerberos auth needs a password or a keytab; upload one with PUT /api/smb/shares/{id}/keytab")
+)
+
+// validateShareAuth checks the auth settings of a share entry being saved
+// or tested and normalizes them: the default auth is stored as "", and a
+// Kerberos realm defaults to the upper-cased domain. Credentials are not
+// required here, so a keytab can be uploaded after the share is saved.
+func validateShareAuth(s *SMBShare) error {
+	s.Realm = strings.TrimSpace(s.Realm)
+	s.KDC = strings.TrimSpace(s.KDC)
+	switch strings.ToLower(strings.TrimSpace(s.Auth)) {
+	case "", smbAuthNTLMv2:
+		s.Auth = ""
+		if s.Realm != "" || s.KDC != "" || len(s.Keytab) > 0 {
+			return fmt.Errorf("realm, kdc and keytab only apply to auth %q", smbAuthKerberos)
+		}
+		return nil
+	case smbAuthKerberos:
+		s.Auth = smbAuthKerberos
+	default:
+		return fmt.Errorf("auth must be %q or %q", smbAuthNTLMv2, smbAuthKerberos)
+	}
+
+	if s.Share == "" {
+		// Listing a host's shares needs an RPC the worker only makes over
+		// NTLM sessions.
+		return errors.New("server entries cannot use kerberos auth; save each share on its own")
+	}
+	if s.Realm == "" {
+		s.Realm = s.Domain
+	}
+	s.Realm = strings.ToUpper(s.Realm)
+	if s.Realm == "" {
+		return errors.New("kerberos auth needs a realm (or a domain to derive it from)")
+	}
+	if strings.ContainsAny(s.Realm, " \t/@") {
+		return errors.New("realm must be a Kerberos realm such as CORP.EXAMPLE.COM")
+	}
+	if s.Username == "" {
+		return errors.New("kerberos auth needs a username (the principal, without the realm)")
+	}
+	if s.KDC != "" && (strings.ContainsAny(s.KDC, " \t/@,") || strings.HasPrefix(s.KDC, ":")) {
+		return errors.New("kdc must be a host name or host:port")
+	}
+	if len(s.Keytab) > 0 {
+		return validateKeytab(s.Keytab)
+	}
+	return nil
+}
+
+// validateKeytab checks that data looks like an MIT keytab file: version
+// 0x0501 or 0x0502, as written by ktpass and ktutil.
+func validateKeytab(data []byte) error {
+	if len(data) > maxKeytabBytes {
+		return fmt.Errorf("keytab must be at most %d bytes", maxKeytabBytes)
+	}
+	if len(data) < 2 || data[0] != 0x05 || (data[1] != 0x01 && data[1] != 0x02) {
+		return errors.New("keytab is not a Kerberos keytab file")
+	}
+	return nil
+}
+
+// redacted returns a copy of s that is safe to show: without the password
+// or keytab, and with HasKeytab telling whether a keytab is set.
+func (s *SMBShare) redacted() SMBShare {
+	cp := *s
+	cp.Password = ""
+	cp.HasKeytab = len(s.Keytab) > 0
+	cp.Keytab = nil
+	return cp
+}
+
+// setRequestAuth sets the auth settings of s on an IndexSMBFiles request.
+func (s *SMBShare) setRequestAuth(req *grpcclient.IndexSMBFilesRequest) {
+	req.Auth = s.Auth
+	req.Realm = s.Realm
+	req.Kdc = s.KDC
+	req.Keytab = s.Keytab
+}
+
+// addAuthFields adds the auth settings of s to task params. The keytab is
+// base64-encoded.
+func (s *SMBShare) addAuthFields(fields map[string]interface{}) {
+	if s.Auth == "" {
+		return
+	}
+	fields["auth"] = s.Auth
+	fields["realm"] = s.Realm
+	fields["kdc"] = s.KDC
+	if len(s.Keytab) > 0 {
+		fields["keytab"] = base64.StdEncoding.EncodeToString(s.Keytab)
+	}
+}
+
+// smbAuthParam rebuilds, as a share holding only them, the auth settings
+// stored in task params by addAuthFields.
+func smbAuthParam(params map[string]interface{}) *SMBShare {
+	keytab, _ := base64.StdEncoding.DecodeString(stringParam(params, "keytab"))
+	return &SMBShare{
+		Auth:   stringParam(params, "auth"),
+		Realm:  stringParam(params, "realm"),
+		KDC:    stringParam(params, "kdc"),
+		Keytab: keytab,
+	}
+}
+
+// checkShareAuth reports why s cannot sign in as configured: Kerberos
+// without credentials, or with a worker that lacks Kerberos support.
+func (h *SMBHandler) checkShareAuth(s *SMBShare) error {
+	if s.Auth != smbAuthKerberos {
+		return nil
+	}
+	if s.Password == "" && len(s.Keytab) == 0 {
+		return errSMBKerberosCredentials
+	}
+	if !h.grpc.Worker().HasFeature(grpcclient.FeatureSMBKerberos) {
+		return errSMBKerberosUnsupported
+	}
+	return nil
+}
+
+// writeShareAuthError writes the response for an error of checkShareAuth.
+func writeShareAuthError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errSMBKerberosUnsupported) {
+		writeErrorCode(w, http.StatusNotImplemented, CodeWorkerUnsupported, err.Error())
+		return
+	}
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
+// PutKeytab sets the keytab of a Kerberos share, sent as the raw body or as
+// the "file" field of a multipart form.
+func (h *SMBHandler) PutKeytab(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxKeytabBody)
+	var src io.Reader = r.Body
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "multipart/form-data" {
+		if err := r.ParseMultipartForm(maxKeytabBody); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid multipart body")
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "missing 'file' field")
+			return
+		}
+		defer file.Close()
+		src = file
+	}
+	data, err := io.ReadAll(src)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("keytab must be at most %d bytes", maxKeytabBytes))
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("reading keytab: %v", err))
+		return
+	}
+	if err := validateKeytab(data); err != nil {
+		code := http.StatusBadRequest
+		if len(data) > maxKeytabBytes {
+			code = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, code, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	s, exists := h.shares[id]
+	switch {
+	case !exists:
+		h.mu.Unlock()
+		writeError(w, http.StatusNotFound, fmt.Sprintf("share %s not found", id))
+		return
+	case s.Auth != smbAuthKerberos:
+		h.mu.Unlock()
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("share %s does not use kerberos auth", id))
+		return
+	}
+	s.Keytab = data
+	h.saveLocked()
+	resp := s.redacted()
+	h.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DeleteKeytab removes the keytab of a share, which then signs in with its
+// password.
+func (h *SMBHandler) DeleteKeytab(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	h.mu.Lock()
+	s, exists := h.shares[id]
+	if !exists {
+		h.mu.Unlock()
+		writeError(w, http.StatusNotFound, fmt.Sprintf("share %s not found", id))
+		return
+	}
+	s.Keytab = nil
+	h.saveLocked()
+	resp := s.redacted()
+	h.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
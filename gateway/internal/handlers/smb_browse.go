@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Kinds of saved SMB entries.
+const (
+	// smbKindShare is a single share; paths are relative to it.
+	smbKindShare = "share"
+	// smbKindServer is a whole file server. Its namespace has the host's
+	// shares at the top level, so paths read "/<share>/<path>".
+	smbKindServer = "server"
+)
+
+// validateShareKind checks the kind of a share entry being saved and fills
+// in the default.
+func validateShareKind(s *SMBShare) error {
+	switch s.Kind {
+	case "", smbKindShare:
+		s.Kind = ""
+		if strings.Trim(s.Share, "/\\") == "" {
+			return errors.New("share is required (or set kind to \"server\" to browse every share of the host)")
+		}
+	case smbKindServer:
+		if s.Share != "" {
+			return errors.New("a server entry must not name a share")
+		}
+		if s.Sync != nil {
+			return errors.New("server entries cannot sync; save the share to sync on its own")
+		}
+	default:
+		return fmt.Errorf("kind must be %q or %q", smbKindShare, smbKindServer)
+	}
+	if s.Server == "" {
+		return errors.New("server is required")
+	}
+	return nil
+}
+
+// isServer reports whether s is a server-level entry.
+func (s *SMBShare) isServer() bool { return s.Kind == smbKindServer }
+
+// splitServerPath splits a path in a server entry's namespace into the
+// share and the path inside it: "/docs/a/b.md" gives "docs", "/a/b.md".
+func splitServerPath(p string) (share, rest string) {
+	p = strings.Trim(p, "/")
+	share, rest, _ = strings.Cut(p, "/")
+	return share, "/" + rest
+}
+
+// smbBrowseResult is the listing of one directory in an entry's namespace.
+type smbBrowseResult struct {
+	Files []smbBrowseEntry `json:"files"`
+	Path  string           `json:"path"`
+}
+
+// smbBrowseEntry is a file or directory of a share, or for a server entry
+// one of the host's shares.
+type smbBrowseEntry struct {
+	Name     string `json:"name"`
+	IsDir    bool   `json:"is_dir"`
+	Size     int64  `json:"size"`
+	Path     string `json:"path"`
+	IsShare  bool   `json:"is_share,omitempty"`
+	Comments string `json:"comments,omitempty"`
+}
+
+// listSharesRequest builds a ListShares request carrying the credentials
+// of s.
+func listSharesRequest(s *SMBShare) *grpcclient.ListSharesRequest {
+	return &grpcclient.ListSharesRequest{
+		Server:   s.Server,
+		Username: s.Username,
+		Password: s.Password,
+		Domain:   s.Domain,
+		Port:     s.Port,
+		Auth:     s.Auth,
+		Realm:    s.Realm,
+		Kdc:      s.KDC,
+		Keytab:   s.Keytab,
+	}
+}
+
+// browse lists path in the namespace of a saved entry: the directory of a
+// share, or for a server entry the host's shares at "/" and the directories
+// of a share below. Errors are gRPC errors from the worker.
+func (h *SMBHandler) browse(ctx context.Context, s *SMBShare, path string) (*smbBrowseResult, error) {
+	if !s.isServer() {
+		return h.browseShare(ctx, s, s.Share, path)
+	}
+
+	share, rest := splitServerPath(path)
+	if share == "" {
+		resp, err := h.grpc.SMBBrowse.ListShares(ctx, listSharesRequest(s))
+		if err != nil {
+			return nil, err
+		}
+		out := &smbBrowseResult{Files: []smbBrowseEntry{}, Path: "/"}
+		for _, sh := range resp.GetShares() {
+			out.Files = append(out.Files, smbBrowseEntry{
+				Name:     sh.GetName(),
+				IsDir:    true,
+				Path:     "/" + sh.GetName(),
+				IsShare:  true,
+				Comments: sh.GetComments(),
+			})
+		}
+		return out, nil
+	}
+
+	out, err := h.browseShare(ctx, s, share, rest)
+	if err != nil {
+		return nil, err
+	}
+	// Rebase share-relative paths into the server namespace.
+	prefix := "/" + share
+	for i := range out.Files {
+		out.Files[i].Path = prefix + "/" + strings.TrimPrefix(out.Files[i].Path, "/")
+	}
+	out.Path = strings.TrimSuffix(prefix+"/"+strings.TrimPrefix(out.Path, "/"), "/")
+	return out, nil
+}
+
+// testServer checks the credentials of a server entry by listing the
+// host's shares.
+func (h *SMBHandler) testServer(w http.ResponseWriter, r *http.Request, s *SMBShare) {
+	if h.grpc.SMBBrowse == nil {
+		writeError(w, http.StatusServiceUnavailable, "smb service not available")
+		return
+	}
+	resp, err := h.grpc.SMBBrowse.ListShares(r.Context(), listSharesRequest(s))
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"ok": false, "message": status.Convert(err).Message()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ok":      true,
+		"message": fmt.Sprintf("%d shares on %s", len(resp.GetShares()), s.Server),
+	})
+}
+
+// testShare checks the credentials of a share entry by listing the root of
+// the share.
+func (h *SMBHandler) testShare(w http.ResponseWriter, r *http.Request, s *SMBShare) {
+	if h.grpc.SMBBrowse == nil {
+		writeError(w, http.StatusServiceUnavailable, "smb service not available")
+		return
+	}
+	out, err := h.browseShare(r.Context(), s, s.Share, "/")
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"ok": false, "message": status.Convert(err).Message()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ok":      true,
+		"message": fmt.Sprintf("%d entries in //%s/%s", len(out.Files), s.Server, s.Share),
+	})
+}
+
+// browseShare lists one directory of share with the credentials of s.
+func (h *SMBHandler) browseShare(ctx context.Context, s *SMBShare, share, path string) (*smbBrowseResult, error) {
+	resp, err := h.grpc.SMBBrowse.Browse(ctx, &grpcclient.SMBBrowseRequest{
+		Server:   s.Server,
+		Share:    share,
+		Username: s.Username,
+		Password: s.Password,
+		Domain:   s.Domain,
+		Port:     s.Port,
+		Path:     path,
+		Auth:     s.Auth,
+		Realm:    s.Realm,
+		Kdc:      s.KDC,
+		Keytab:   s.Keytab,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := &smbBrowseResult{Files: make([]smbBrowseEntry, 0, len(resp.GetFiles())), Path: resp.GetPath()}
+	for _, f := range resp.GetFiles() {
+		out.Files = append(out.Files, smbBrowseEntry{
+			Name:  f.GetName(),
+			IsDir: f.GetIsDir(),
+			Size:  f.GetSize(),
+			Path:  f.GetPath(),
+		})
+	}
+	return out, nil
+}
+
+// indexTarget resolves the share and share-relative paths to index for a
+// saved entry. A server entry's paths must all lie in one share. It writes
+// the error response itself when the paths do not resolve.
+func indexTarget(w http.ResponseWriter, s *SMBShare, paths []string) (string, []string, bool) {
+	if !s.isServer() {
+		return s.Share, paths, true
+	}
+	var share string
+	rel := make([]string, 0, len(paths))
+	for _, p := range paths {
+		sh, rest := splitServerPath(p)
+		if sh == "" || rest == "/" {
+			writeError(w, http.StatusBadRequest,
+				fmt.Sprintf("remote path %q of a server entry must be a file inside a share (/<share>/<path>)", p))
+			return "", nil, false
+		}
+		if share != "" && !strings.EqualFold(sh, share) {
+			writeError(w, http.StatusBadRequest, "remote paths of a server entry must all be in one share")
+			return "", nil, false
+		}
+		share = sh
+		rel = append(rel, rest)
+	}
+	if share == "" {
+		writeError(w, http.StatusBadRequest, "remote_paths is required for a server entry")
+		return "", nil, false
+	}
+	return share, rel, true
+}
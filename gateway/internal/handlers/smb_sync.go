@@ -0,0 +1,538 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// smbSyncTick is how often the scheduler looks for shares that are due.
+	smbSyncTick = time.Minute
+
+	// smbSyncScanTimeout bounds a single share scan on the worker.
+	smbSyncScanTimeout = 10 * time.Minute
+
+	// Sync cadence bounds and default, in minutes.
+	minSMBSyncInterval     = 5
+	defaultSMBSyncInterval = 60
+)
+
+var (
+	// errSyncRunning is returned when a share already has a sync queued or
+	// running.
+	errSyncRunning = errors.New("a sync is already running for this share")
+
+	// errNoSyncPolicy is returned for unknown shares and shares without a
+	// sync policy.
+	errNoSyncPolicy = errors.New("share not found or has no sync policy")
+)
+
+// SMBSyncPolicy makes a saved share a continuously ingested source: every
+// IntervalMinutes the gateway scans Paths and indexes files that are new or
+// whose size or mtime changed since the last successful sync.
+type SMBSyncPolicy struct {
+	Enabled         bool     `json:"enabled"`
+	Paths           []string `json:"paths"`
+	IntervalMinutes int      `json:"interval_minutes"`
+	Collection      string   `json:"collection"`
+	ChunkSize       int32    `json:"chunk_size,omitempty"`
+	ChunkOverlap    int32    `json:"chunk_overlap,omitempty"`
+	SourceTag       string   `json:"source_tag,omitempty"`
+	Priority        string   `json:"priority,omitempty"`
+}
+
+// SMBSyncStatus reports the outcome of a share's most recent sync. Running
+// is derived from the state of LastTaskID when the status is read.
+type SMBSyncStatus struct {
+	Running    bool       `json:"running"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"`
+	LastTaskID string     `json:"last_task_id,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	Trigger    string     `json:"trigger,omitempty"`
+	Scanned    int        `json:"scanned"`
+	Changed    int        `json:"changed"`
+	Removed    int        `json:"removed"`
+	Truncated  bool       `json:"truncated,omitempty"`
+}
+
+// smbSyncState is the persisted file list of a share after its last
+// successful sync, keyed by share path.
+type smbSyncState struct {
+	Collection string                  `json:"collection"`
+	Files      map[string]smbFileStamp `json:"files"`
+	SyncedAt   time.Time               `json:"synced_at"`
+}
+
+type smbFileStamp struct {
+	Size  int64 `json:"size"`
+	MTime int64 `json:"mtime"`
+}
+
+func smbSyncDoc(shareID string) string {
+	return "smb-sync-" + shareID
+}
+
+// smbFileLabel mirrors the worker's smb_file_label: the file_path recorded
+// on the points of an indexed SMB file.
+func smbFileLabel(s *SMBShare, path string) string {
+	return fmt.Sprintf("//%s/%s/%s", s.Server, s.Share, strings.TrimPrefix(path, "/"))
+}
+
+// validateSyncPolicy checks a policy and fills in defaults.
+func validateSyncPolicy(p *SMBSyncPolicy) error {
+	if p.IntervalMinutes == 0 {
+		p.IntervalMinutes = defaultSMBSyncInterval
+	}
+	if p.IntervalMinutes < minSMBSyncInterval {
+		return fmt.Errorf("interval_minutes must be at least %d", minSMBSyncInterval)
+	}
+	if p.ChunkSize < 0 || p.ChunkOverlap < 0 {
+		return fmt.Errorf("chunk_size and chunk_overlap must not be negative")
+	}
+	if _, err := tasks.ParsePriority(p.Priority); err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(p.Paths))
+	for _, path := range p.Paths {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, "/"+strings.Trim(path, "/"))
+		}
+	}
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+	sort.Strings(paths)
+	p.Paths = paths
+	return nil
+}
+
+// GetSync returns a share's sync policy and last run status.
+func (h *SMBHandler) GetSync(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	h.mu.RLock()
+	share, exists := h.shares[id]
+	var policy *SMBSyncPolicy
+	var status SMBSyncStatus
+	if exists {
+		policy = share.Sync
+		status = h.syncStatusLocked(id)
+	}
+	h.mu.RUnlock()
+
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("share %s not found", id))
+		return
+	}
+
+	resp := map[string]interface{}{
+		"share_id": id,
+		"policy":   policy,
+		"status":   status,
+	}
+	if policy != nil && policy.Enabled {
+		resp["next_run_at"] = h.nextRun(policy, status)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// PutSync sets or replaces a share's sync policy.
+func (h *SMBHandler) PutSync(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var p SMBSyncPolicy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := validateSyncPolicy(&p); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	share, exists := h.shares[id]
+	server := exists && share.isServer()
+	if exists && !server {
+		share.Sync = &p
+		h.saveLocked()
+	}
+	h.mu.Unlock()
+
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("share %s not found", id))
+		return
+	}
+	if server {
+		writeError(w, http.StatusBadRequest, "server entries cannot sync; save the share to sync on its own")
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// DeleteSync removes a share's sync policy and forgets which files it has
+// synced. Points already indexed are kept.
+func (h *SMBHandler) DeleteSync(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	h.mu.Lock()
+	share, exists := h.shares[id]
+	hadPolicy := exists && share.Sync != nil
+	if hadPolicy {
+		share.Sync = nil
+		delete(h.syncs, id)
+		h.saveLocked()
+	}
+	h.mu.Unlock()
+
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("share %s not found", id))
+		return
+	}
+	if !hadPolicy {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("share %s has no sync policy", id))
+		return
+	}
+	h.forgetSyncState(id)
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": id})
+}
+
+// RunSync starts a sync of a share now, regardless of its cadence. The
+// policy does not have to be enabled.
+func (h *SMBHandler) RunSync(w http.ResponseWriter, r *http.Request) {
+	if h.grpc.SMBSync == nil || h.grpc.Indexing == nil {
+		writeError(w, http.StatusServiceUnavailable, "smb sync service not available")
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	taskID, err := h.startSync(id, "manual", middleware.UsernameFromContext(r.Context()))
+	switch {
+	case err == nil:
+		writeTaskAccepted(w, h.tm, taskID)
+	case errors.Is(err, errNoSyncPolicy):
+		writeError(w, http.StatusNotFound, fmt.Sprintf("share %s not found or has no sync policy", id))
+	case errors.Is(err, errSyncRunning):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, errSMBKerberosCredentials), errors.Is(err, errSMBKerberosUnsupported):
+		writeShareAuthError(w, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// StartSyncScheduler runs due share syncs until ctx is done.
+func (h *SMBHandler) StartSyncScheduler(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(smbSyncTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				h.runDueSyncs(now)
+			}
+		}
+	}()
+}
+
+// runDueSyncs starts a sync for every enabled policy whose next run is due.
+func (h *SMBHandler) runDueSyncs(now time.Time) {
+	if h.grpc.SMBSync == nil || h.grpc.Indexing == nil || !h.grpc.Supports(grpcclient.ServiceSMBSync) {
+		return
+	}
+
+	h.mu.RLock()
+	var due []string
+	for id, s := range h.shares {
+		if s.Sync == nil || !s.Sync.Enabled {
+			continue
+		}
+		status := h.syncStatusLocked(id)
+		if !status.Running && !now.Before(h.nextRun(s.Sync, status)) {
+			due = append(due, id)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, id := range due {
+		if _, err := h.startSync(id, "schedule", ""); err != nil && !errors.Is(err, errSyncRunning) {
+			log.Printf("WARNING: scheduled sync of smb share %s: %v", id, err)
+		}
+	}
+}
+
+// nextRun returns when a policy is next due: immediately if it has never
+// run, otherwise one interval after the last run.
+func (h *SMBHandler) nextRun(p *SMBSyncPolicy, status SMBSyncStatus) time.Time {
+	if status.LastRunAt == nil {
+		return time.Time{}
+	}
+	return status.LastRunAt.Add(time.Duration(p.IntervalMinutes) * time.Minute)
+}
+
+// startSync creates and enqueues a sync_smb task for a share. uploader,
+// empty for scheduled syncs, is recorded in the provenance of the files.
+func (h *SMBHandler) startSync(id, trigger, uploader string) (string, error) {
+	h.mu.Lock()
+	s, exists := h.shares[id]
+	if !exists || s.Sync == nil {
+		h.mu.Unlock()
+		return "", errNoSyncPolicy
+	}
+	if err := h.checkShareAuth(s); err != nil {
+		h.mu.Unlock()
+		return "", err
+	}
+	if h.syncStatusLocked(id).Running {
+		h.mu.Unlock()
+		return "", errSyncRunning
+	}
+	share := *s
+	policy := *s.Sync
+	policy.Collection, policy.ChunkSize, policy.ChunkOverlap = h.colls.ResolveIndex(policy.Collection, policy.ChunkSize, policy.ChunkOverlap)
+	priority, _ := tasks.ParsePriority(policy.Priority)
+
+	taskID := h.tm.Create("sync_smb", map[string]interface{}{
+		"share_id":   id,
+		"server":     share.Server,
+		"share":      share.Share,
+		"paths":      policy.Paths,
+		"collection": policy.Collection,
+		"trigger":    trigger,
+		"priority":   string(priority),
+	})
+	now := time.Now()
+	h.syncs[id] = &SMBSyncStatus{LastRunAt: &now, LastTaskID: taskID, Trigger: trigger}
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.tm.SetCancelFunc(taskID, cancel)
+	h.tm.Enqueue(taskID, priority, func() {
+		h.runSync(ctx, taskID, &share, &policy, uploader)
+	})
+	return taskID, nil
+}
+
+// runSync scans the share, purges points of removed and changed files and
+// indexes the changed ones. The file list is only saved once indexing has
+// completed, so a failed run is retried in full next time.
+func (h *SMBHandler) runSync(ctx context.Context, taskID string, share *SMBShare, policy *SMBSyncPolicy, uploader string) {
+	var status SMBSyncStatus
+	defer func() {
+		if t := h.tm.Get(taskID); t != nil && t.Status != tasks.StatusCompleted {
+			status.LastError = t.Error
+			if status.LastError == "" {
+				status.LastError = string(t.Status)
+			}
+		}
+		h.setSyncStatus(share.ID, func(st *SMBSyncStatus) {
+			st.LastError = status.LastError
+			st.Scanned, st.Changed, st.Removed, st.Truncated = status.Scanned, status.Changed, status.Removed, status.Truncated
+		})
+	}()
+
+	target := policy.Collection
+	if target == "" {
+		target = workerDefaultDocumentsCollection
+	}
+
+	files, truncated, err := h.scanShare(ctx, share, policy.Paths)
+	if err != nil {
+		if ctx.Err() != nil {
+			h.tm.Cancel(taskID)
+		} else {
+			h.tm.Fail(taskID, fmt.Sprintf("scan share: %v", err))
+		}
+		return
+	}
+	h.tm.UpdateProgress(taskID, 0.05, "running")
+
+	var prev smbSyncState
+	if _, err := h.store.Load(smbSyncDoc(share.ID), &prev); err != nil {
+		log.Printf("WARNING: smb sync state for %s: %v", share.ID, err)
+	}
+	if prev.Collection != target {
+		prev.Files = nil // first sync into this collection
+	}
+
+	current := make(map[string]smbFileStamp, len(files))
+	var changed []string
+	for _, f := range files {
+		stamp := smbFileStamp{Size: f.GetSize(), MTime: f.GetMtime()}
+		current[f.GetPath()] = stamp
+		if old, ok := prev.Files[f.GetPath()]; !ok || old != stamp {
+			changed = append(changed, f.GetPath())
+		}
+	}
+	var removed []string
+	if !truncated {
+		for path := range prev.Files {
+			if _, ok := current[path]; !ok {
+				removed = append(removed, path)
+			}
+		}
+	} else {
+		// A truncated scan cannot tell a removed file from an unlisted
+		// one; keep tracking files it did not reach.
+		for path, stamp := range prev.Files {
+			if _, ok := current[path]; !ok {
+				current[path] = stamp
+			}
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	status.Scanned, status.Changed, status.Removed, status.Truncated = len(files), len(changed), len(removed), truncated
+
+	// Changed files are purged too so chunks beyond a shrunken file's new
+	// length do not linger.
+	purge := make([]string, 0, len(changed)+len(removed))
+	for _, path := range append(append([]string{}, removed...), changed...) {
+		if _, known := prev.Files[path]; known {
+			purge = append(purge, smbFileLabel(share, path))
+		}
+	}
+	if len(purge) > 0 {
+		if err := h.diff.removePoints(ctx, target, purge); err != nil {
+			h.tm.Fail(taskID, fmt.Sprintf("remove stale points: %v", err))
+			return
+		}
+	}
+
+	save := func() {
+		state := smbSyncState{Collection: target, Files: current, SyncedAt: time.Now()}
+		if err := h.store.Save(smbSyncDoc(share.ID), &state); err != nil {
+			log.Printf("WARNING: save smb sync state for %s: %v", share.ID, err)
+		}
+	}
+
+	if len(changed) == 0 {
+		save()
+		h.tm.Complete(taskID, map[string]string{
+			"files":      "0",
+			"chunks":     "0",
+			"scanned":    strconv.Itoa(len(files)),
+			"skipped":    strconv.Itoa(len(files)),
+			"removed":    strconv.Itoa(len(removed)),
+			"collection": target,
+		})
+		return
+	}
+
+	h.tm.ConsumeIndexStream(ctx, h.grpc, taskID, func() (grpcclient.IndexingStream, error) {
+		mctx := grpcclient.WithSMBACLs(ctx, share.CaptureACLs)
+		mctx = grpcclient.WithProvenance(mctx, grpcclient.Provenance{SourceType: grpcclient.SourceSMB, ShareID: share.ID, Uploader: uploader, TaskID: taskID})
+		req := &grpcclient.IndexSMBFilesRequest{
+			ShareId:      share.ID,
+			RemotePaths:  changed,
+			Collection:   policy.Collection,
+			ChunkSize:    policy.ChunkSize,
+			ChunkOverlap: policy.ChunkOverlap,
+			SourceTag:    policy.SourceTag,
+			Server:       share.Server,
+			Share:        share.Share,
+			Username:     share.Username,
+			Password:     share.Password,
+			Domain:       share.Domain,
+			Port:         share.Port,
+		}
+		share.setRequestAuth(req)
+		return h.grpc.Indexing.IndexSMBFiles(mctx, req)
+	})
+	if t := h.tm.Get(taskID); t != nil && t.Status == tasks.StatusCompleted {
+		save()
+	}
+}
+
+// scanShare lists the indexable files under paths through the worker.
+func (h *SMBHandler) scanShare(ctx context.Context, share *SMBShare, paths []string) ([]*grpcclient.ScannedFile, bool, error) {
+	req := &grpcclient.ScanShareRequest{
+		Server:   share.Server,
+		Share:    share.Share,
+		Username: share.Username,
+		Password: share.Password,
+		Domain:   share.Domain,
+		Port:     share.Port,
+		Paths:    paths,
+		Auth:     share.Auth,
+		Realm:    share.Realm,
+		Kdc:      share.KDC,
+		Keytab:   share.Keytab,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smbSyncScanTimeout)
+	defer cancel()
+	resp, err := h.grpc.SMBSync.ScanShare(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.GetFiles(), resp.GetTruncated(), nil
+}
+
+// syncStatusLocked returns a copy of a share's sync status with Running
+// filled in from its last task. Callers must hold h.mu.
+func (h *SMBHandler) syncStatusLocked(id string) SMBSyncStatus {
+	var status SMBSyncStatus
+	if st := h.syncs[id]; st != nil {
+		status = *st
+	}
+	if t := h.tm.Get(status.LastTaskID); t != nil {
+		status.Running = t.Status == tasks.StatusPending || t.Status == tasks.StatusRunning || t.Status == tasks.StatusReconnecting
+	}
+	return status
+}
+
+// loadSyncStatusLocked seeds the last run time of each synced share from its
+// persisted file list, so a restart does not make every share due at once.
+// Callers must hold h.mu.
+func (h *SMBHandler) loadSyncStatusLocked() {
+	for id, s := range h.shares {
+		if s.Sync == nil {
+			continue
+		}
+		var state smbSyncState
+		if ok, err := h.store.Load(smbSyncDoc(id), &state); err != nil || !ok || state.SyncedAt.IsZero() {
+			continue
+		}
+		synced := state.SyncedAt
+		h.syncs[id] = &SMBSyncStatus{LastRunAt: &synced}
+	}
+}
+
+// setSyncStatus applies fn to a share's sync status under the lock.
+func (h *SMBHandler) setSyncStatus(id string, fn func(*SMBSyncStatus)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.shares[id]; !ok {
+		return
+	}
+	st := h.syncs[id]
+	if st == nil {
+		st = &SMBSyncStatus{}
+		h.syncs[id] = st
+	}
+	fn(st)
+}
+
+// forgetSyncState drops the persisted file list of a share so its next
+// sync indexes everything again.
+func (h *SMBHandler) forgetSyncState(id string) {
+	if err := h.store.Save(smbSyncDoc(id), &smbSyncState{}); err != nil {
+		log.Printf("WARNING: reset smb sync state for %s: %v", id, err)
+	}
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SourcesHandler exposes source tags as logical document sets spanning
+// collections. Tags are read from the "source_tag" payload field the worker
+// writes on every indexed chunk.
+type SourcesHandler struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewSourcesHandler creates a new SourcesHandler talking to Qdrant at
+// baseURL through client.
+func NewSourcesHandler(baseURL string, client *http.Client) *SourcesHandler {
+	return &SourcesHandler{
+		baseURL: baseURL,
+		client:  client,
+	}
+}
+
+// Routes registers source-tag routes on the given chi router.
+func (h *SourcesHandler) Routes(r chi.Router) {
+	r.Get("/", h.ListSources)
+	r.Delete("/{tag}", h.DeleteSource)
+}
+
+// sourceSummary aggregates the points carrying one source tag.
+type sourceSummary struct {
+	Tag         string         `json:"tag"`
+	Documents   int            `json:"documents"`
+	Points      int            `json:"points"`
+	Collections map[string]int `json:"collections"`
+
+	files map[string]struct{}
+}
+
+// ListSources scrolls every collection (or ?collection=) and returns all
+// source tags with distinct document and point counts.
+func (h *SourcesHandler) ListSources(w http.ResponseWriter, r *http.Request) {
+	collections, err := h.targetCollections(r)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+
+	byTag := make(map[string]*sourceSummary)
+	for _, coll := range collections {
+		err := h.scrollPayloads(r.Context(), coll, []string{"source_tag", "file_path"}, func(payload map[string]interface{}) {
+			tag, _ := payload["source_tag"].(string)
+			if tag == "" {
+				return
+			}
+			s, ok := byTag[tag]
+			if !ok {
+				s = &sourceSummary{Tag: tag, Collections: map[string]int{}, files: map[string]struct{}{}}
+				byTag[tag] = s
+			}
+			s.Points++
+			s.Collections[coll]++
+			if fp, _ := payload["file_path"].(string); fp != "" {
+				s.files[coll+"\x00"+fp] = struct{}{}
+			}
+		})
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+			return
+		}
+	}
+
+	sources := make([]*sourceSummary, 0, len(byTag))
+	for _, s := range byTag {
+		s.Documents = len(s.files)
+		sources = append(sources, s)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Tag < sources[j].Tag })
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sources": sources,
+		"count":   len(sources),
+	})
+}
+
+// DeleteSource removes every point carrying the given source tag from all
+// collections (or only ?collection=).
+func (h *SourcesHandler) DeleteSource(w http.ResponseWriter, r *http.Request) {
+	tag, _ := url.PathUnescape(chi.URLParam(r, "tag"))
+	if tag == "" {
+		writeError(w, http.StatusBadRequest, "source tag is required")
+		return
+	}
+
+	collections, err := h.targetCollections(r)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return
+	}
+
+	filter := map[string]interface{}{
+		"must": []map[string]interface{}{
+			{"key": "source_tag", "match": map[string]interface{}{"value": tag}},
+		},
+	}
+
+	deleted := make(map[string]int)
+	for _, coll := range collections {
+		n, err := h.countPoints(r.Context(), coll, filter)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+			return
+		}
+		if n == 0 {
+			continue
+		}
+		if err := h.qdrantPost(r.Context(), "/collections/"+url.PathEscape(coll)+"/points/delete?wait=true",
+			map[string]interface{}{"filter": filter}, nil); err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+			return
+		}
+		deleted[coll] = n
+	}
+
+	total := 0
+	for _, n := range deleted {
+		total += n
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tag":            tag,
+		"deleted_points": total,
+		"collections":    deleted,
+	})
+}
+
+// targetCollections returns ?collection= if set, otherwise every collection.
+func (h *SourcesHandler) targetCollections(r *http.Request) ([]string, error) {
+	if c := r.URL.Query().Get("collection"); c != "" {
+		return []string{c}, nil
+	}
+	return listQdrantCollections(r.Context(), h.client, h.baseURL)
+}
+
+// scrollPayloads walks every point in a collection, passing the requested
+// payload fields to fn.
+func (h *SourcesHandler) scrollPayloads(ctx context.Context, collection string, fields []string, fn func(map[string]interface{})) error {
+	var offset interface{}
+	for {
+		body := map[string]interface{}{
+			"limit":        1000,
+			"with_payload": fields,
+			"with_vector":  false,
+		}
+		if offset != nil {
+			body["offset"] = offset
+		}
+
+		var resp struct {
+			Result struct {
+				Points []struct {
+					Payload map[string]interface{} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			} `json:"result"`
+		}
+		if err := h.qdrantPost(ctx, "/collections/"+url.PathEscape(collection)+"/points/scroll", body, &resp); err != nil {
+			return err
+		}
+		for _, p := range resp.Result.Points {
+			fn(p.Payload)
+		}
+		if resp.Result.NextPageOffset == nil {
+			return nil
+		}
+		offset = resp.Result.NextPageOffset
+	}
+}
+
+// countPoints returns the exact number of points matching filter.
+func (h *SourcesHandler) countPoints(ctx context.Context, collection string, filter map[string]interface{}) (int, error) {
+	var resp struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	err := h.qdrantPost(ctx, "/collections/"+url.PathEscape(collection)+"/points/count",
+		map[string]interface{}{"filter": filter, "exact": true}, &resp)
+	return resp.Result.Count, err
+}
+
+// qdrantPost sends a JSON body to Qdrant and decodes the response into out
+// (if non-nil). Non-2xx statuses are returned as errors.
+func (h *SourcesHandler) qdrantPost(ctx context.Context, path string, body, out interface{}) error {
+	data, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, "POST", h.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: status %d", path, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// listQdrantCollections returns the names of all collections in Qdrant.
+func listQdrantCollections(ctx context.Context, client *http.Client, baseURL string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/collections", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var raw struct {
+		Result struct {
+			Collections []struct {
+				Name string `json:"name"`
+			} `json:"collections"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse qdrant response: %w", err)
+	}
+
+	names := make([]string, 0, len(raw.Result.Collections))
+	for _, c := range raw.Result.Collections {
+		names = append(names, c.Name)
+	}
+	return names, nil
+}
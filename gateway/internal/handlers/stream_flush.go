@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bounds of a stream flush setting.
+const (
+	maxStreamFlushMS    = 2000
+	maxStreamFlushBytes = 64 << 10
+)
+
+// Headers overriding the stream flush of an SSE chat request.
+const (
+	streamFlushMSHeader    = "X-Ollqd-Flush-Ms"
+	streamFlushBytesHeader = "X-Ollqd-Flush-Bytes"
+)
+
+// StreamFlush sets how the answer chunks of a chat stream are coalesced
+// before they are sent: held until Interval has passed since the first of
+// them or Bytes are buffered, whichever comes first. A zero field disables
+// its trigger; with both zero every chunk is sent as it arrives.
+type StreamFlush struct {
+	Interval time.Duration
+	Bytes    int
+}
+
+// NewStreamFlush returns the StreamFlush holding chunks for up to ms
+// milliseconds or bytes bytes, clamped to the range a request may ask for.
+func NewStreamFlush(ms, bytes int64) StreamFlush {
+	return StreamFlush{
+		Interval: time.Duration(min(max(ms, 0), maxStreamFlushMS)) * time.Millisecond,
+		Bytes:    int(min(max(bytes, 0), maxStreamFlushBytes)),
+	}
+}
+
+func (f StreamFlush) enabled() bool { return f.Interval > 0 || f.Bytes > 0 }
+
+// override returns f with the fields of a per-request override applied;
+// nil leaves a field at f's value.
+func (f StreamFlush) override(ms, bytes *int) (StreamFlush, error) {
+	if ms != nil {
+		if *ms < 0 || *ms > maxStreamFlushMS {
+			return f, fmt.Errorf("flush_ms must be between 0 and %d", maxStreamFlushMS)
+		}
+		f.Interval = time.Duration(*ms) * time.Millisecond
+	}
+	if bytes != nil {
+		if *bytes < 0 || *bytes > maxStreamFlushBytes {
+			return f, fmt.Errorf("flush_bytes must be between 0 and %d", maxStreamFlushBytes)
+		}
+		f.Bytes = *bytes
+	}
+	return f, nil
+}
+
+// overrideFromHeaders is override with the values of the X-Ollqd-Flush-Ms
+// and X-Ollqd-Flush-Bytes headers of r.
+func (f StreamFlush) overrideFromHeaders(r *http.Request) (StreamFlush, error) {
+	parse := func(header string) (*int, error) {
+		v := r.Header.Get(header)
+		if v == "" {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", header)
+		}
+		return &n, nil
+	}
+	ms, err := parse(streamFlushMSHeader)
+	if err != nil {
+		return f, err
+	}
+	bytes, err := parse(streamFlushBytesHeader)
+	if err != nil {
+		return f, err
+	}
+	return f.override(ms, bytes)
+}
+
+// chunkCoalescer merges consecutive answer chunks so slow clients and
+// proxies get fewer, larger frames. Buffered text is handed to emit when
+// the flush settings say so and before any other event of the stream,
+// which is sent through do. emit and the functions passed to do never run
+// concurrently, so they may share an unsynchronised writer.
+type chunkCoalescer struct {
+	flush StreamFlush
+	emit  func(string) bool
+
+	mu      sync.Mutex
+	buf     strings.Builder
+	timer   *time.Timer
+	failed  bool
+	stopped bool
+}
+
+// newChunkCoalescer creates a chunkCoalescer handing text to emit, which
+// reports false once the client can no longer be written to.
+func newChunkCoalescer(flush StreamFlush, emit func(string) bool) *chunkCoalescer {
+	return &chunkCoalescer{flush: flush, emit: emit}
+}
+
+// add queues an answer chunk. It returns false once emit has failed.
+func (c *chunkCoalescer) add(s string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped || c.failed {
+		return false
+	}
+	if !c.flush.enabled() {
+		c.failed = !c.emit(s)
+		return !c.failed
+	}
+	c.buf.WriteString(s)
+	if c.flush.Bytes > 0 && c.buf.Len() >= c.flush.Bytes {
+		c.flushLocked()
+	} else if c.flush.Interval > 0 && c.timer == nil {
+		c.timer = time.AfterFunc(c.flush.Interval, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if !c.stopped {
+				c.flushLocked()
+			}
+		})
+	}
+	return !c.failed
+}
+
+// do sends the buffered text, then runs fn.
+func (c *chunkCoalescer) do(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.stopped {
+		c.flushLocked()
+	}
+	fn()
+}
+
+// close sends the buffered text and stops the flush timer. Nothing is
+// emitted after it returns.
+func (c *chunkCoalescer) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.stopped {
+		c.flushLocked()
+		c.stopped = true
+	}
+}
+
+func (c *chunkCoalescer) flushLocked() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if c.buf.Len() == 0 || c.failed {
+		return
+	}
+	s := c.buf.String()
+	c.buf.Reset()
+	c.failed = !c.emit(s)
+}
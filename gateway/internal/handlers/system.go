@@ -1,59 +1,82 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/alfagnish/ollqd-gateway/internal/config"
 	"github.com/alfagnish/ollqd-gateway/internal/docker"
 	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
 	"github.com/go-chi/chi/v5"
 )
 
 // SystemHandler provides endpoints for health checks and configuration
 // management. It proxies config-related requests to the Python gRPC worker.
 type SystemHandler struct {
-	cfg      *config.Config
-	grpc     *grpcclient.Client
-	httpCli  *http.Client
-	docker   *docker.Manager
+	cfg       *config.Config
+	grpc      *grpcclient.Client
+	httpCli   *http.Client
+	qdrantCli *http.Client
+	docker    *docker.Manager
+
+	updating sync.Mutex // held while the Ollama container is updated
 }
 
-// NewSystemHandler creates a new SystemHandler.
-func NewSystemHandler(cfg *config.Config, gc *grpcclient.Client, dm *docker.Manager) *SystemHandler {
+// NewSystemHandler creates a new SystemHandler. The health check reaches
+// Ollama through ollama and Qdrant through qdrant, which carries its API
+// key and TLS settings.
+func NewSystemHandler(cfg *config.Config, gc *grpcclient.Client, dm *docker.Manager, ollama, qdrant http.RoundTripper) *SystemHandler {
 	return &SystemHandler{
-		cfg:     cfg,
-		grpc:    gc,
-		httpCli: &http.Client{Timeout: 5 * time.Second},
-		docker:  dm,
+		cfg:       cfg,
+		grpc:      gc,
+		httpCli:   &http.Client{Timeout: 5 * time.Second, Transport: ollama},
+		qdrantCli: &http.Client{Timeout: 5 * time.Second, Transport: qdrant},
+		docker:    dm,
 	}
 }
 
-// Routes registers all system routes on the given chi router.
+// Routes registers all system routes on the given chi router. Changing or
+// resetting the configuration is admin-only.
 func (h *SystemHandler) Routes(r chi.Router) {
 	r.Get("/health", h.Health)
+	r.Get("/worker", h.WorkerInfo)
 	r.Get("/config", h.GetConfig)
-	r.Put("/config/mounted-paths", h.UpdateMountedPaths)
 	r.Get("/config/embedding", h.GetEmbeddingInfo)
-	r.Put("/config/embedding", h.SetEmbeddingModel)
 	r.Post("/config/embedding/test", h.TestEmbed)
 	r.Post("/config/embedding/compare", h.CompareModels)
 	r.Get("/config/pii", h.GetPIIConfig)
-	r.Put("/config/pii", h.UpdatePII)
 	r.Post("/config/pii/test", h.TestMasking)
 	r.Get("/config/docling", h.GetDoclingConfig)
-	r.Put("/config/docling", h.UpdateDocling)
-	r.Put("/config/distance", h.UpdateDistance)
-	r.Put("/config/ollama", h.UpdateOllama)
-	r.Put("/config/qdrant", h.UpdateQdrant)
-	r.Put("/config/chunking", h.UpdateChunking)
-	r.Put("/config/image", h.UpdateImage)
-	r.Delete("/config/{section}", h.ResetConfig)
+	ocr := r.With(requireWorker(h.grpc, grpcclient.ServiceOCR))
+	ocr.Get("/config/ocr", h.GetOCRConfig)
+	ocr.With(middleware.RequireAdmin).Put("/config/ocr", h.UpdateOCRConfig)
+	ocr.Post("/config/ocr/test", h.TestOCR)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireAdmin)
+		r.Put("/config/mounted-paths", h.UpdateMountedPaths)
+		r.Put("/config/embedding", h.SetEmbeddingModel)
+		r.Put("/config/pii", h.UpdatePII)
+		r.Put("/config/docling", h.UpdateDocling)
+		r.Put("/config/distance", h.UpdateDistance)
+		r.Put("/config/ollama", h.UpdateOllama)
+		r.Put("/config/qdrant", h.UpdateQdrant)
+		r.Put("/config/chunking", h.UpdateChunking)
+		r.Put("/config/image", h.UpdateImage)
+		r.Delete("/config/{section}", h.ResetConfig)
+	})
 	r.Get("/ollama/container", h.OllamaContainerStatus)
 	r.Post("/ollama/container", h.ManageOllamaContainer)
+	r.With(middleware.RequireAdmin).Post("/ollama/container/update", h.UpdateOllamaContainer)
 }
 
 // serviceStatus is used by the Health endpoint to report the health of
@@ -77,9 +100,9 @@ func (h *SystemHandler) Health(w http.ResponseWriter, r *http.Request) {
 		qdrantURL = h.cfg.QdrantURL
 	}
 
-	ollamaStatus := h.pingService(ollamaURL + "/api/tags")
+	ollamaStatus := h.pingService(h.httpCli, ollamaURL+"/api/tags")
 	ollamaStatus.URL = ollamaURL
-	qdrantStatus := h.pingService(qdrantURL + "/collections")
+	qdrantStatus := h.pingService(h.qdrantCli, qdrantURL+"/collections")
 	qdrantStatus.URL = qdrantURL
 
 	overall := "ok"
@@ -94,9 +117,9 @@ func (h *SystemHandler) Health(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *SystemHandler) pingService(url string) serviceStatus {
+func (h *SystemHandler) pingService(client *http.Client, url string) serviceStatus {
 	start := time.Now()
-	resp, err := h.httpCli.Get(url)
+	resp, err := client.Get(url)
 	latency := time.Since(start)
 
 	if err != nil {
@@ -116,6 +139,8 @@ func (h *SystemHandler) pingService(url string) serviceStatus {
 }
 
 // GetConfig retrieves the full application config from the gRPC worker.
+// The client caches it, so polling is cheap; the response carries an ETag
+// and a matching If-None-Match gets 304 Not Modified.
 func (h *SystemHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 	if h.grpc.Config == nil {
 		writeError(w, http.StatusServiceUnavailable, "config service not available")
@@ -124,11 +149,20 @@ func (h *SystemHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 
 	cfg, err := h.grpc.Config.GetConfig(r.Context())
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, cfg)
+	body, err := json.Marshal(cfg)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
 }
 
 // UpdateMountedPaths updates the list of mounted paths in the config.
@@ -150,7 +184,7 @@ func (h *SystemHandler) UpdateMountedPaths(w http.ResponseWriter, r *http.Reques
 		Paths: req.Paths,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -166,33 +200,44 @@ func (h *SystemHandler) GetEmbeddingInfo(w http.ResponseWriter, r *http.Request)
 
 	info, err := h.grpc.Embedding.GetInfo(r.Context())
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, info)
 }
 
-// SetEmbeddingModel changes the active embedding model.
+// SetEmbeddingModel changes the active embedding model. A model whose
+// dimension differs from existing collections needs "confirm"; see
+// guardConfigChange.
 func (h *SystemHandler) SetEmbeddingModel(w http.ResponseWriter, r *http.Request) {
-	if h.grpc.Embedding == nil {
+	if h.grpc.Embedding == nil || h.grpc.Config == nil {
 		writeError(w, http.StatusServiceUnavailable, "embedding service not available")
 		return
 	}
 
 	var req struct {
-		Model string `json:"model"`
+		Model   string `json:"model"`
+		Confirm bool   `json:"confirm"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
+	p, err := h.preflightModel(r.Context(), req.Model)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	if !guardConfigChange(w, r, p, req.Confirm) {
+		return
+	}
 
 	resp, err := h.grpc.Embedding.SetModel(r.Context(), &grpcclient.SetEmbedModelRequest{
 		Model: req.Model,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -218,7 +263,7 @@ func (h *SystemHandler) TestEmbed(w http.ResponseWriter, r *http.Request) {
 		Text: req.Text,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -248,7 +293,7 @@ func (h *SystemHandler) CompareModels(w http.ResponseWriter, r *http.Request) {
 		Model2: req.Model2,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -264,7 +309,7 @@ func (h *SystemHandler) GetPIIConfig(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.grpc.Config.GetPIIConfig(r.Context())
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -286,7 +331,7 @@ func (h *SystemHandler) UpdatePII(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.grpc.Config.UpdatePII(r.Context(), &req)
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -312,7 +357,7 @@ func (h *SystemHandler) TestMasking(w http.ResponseWriter, r *http.Request) {
 		Text: req.Text,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -328,7 +373,7 @@ func (h *SystemHandler) GetDoclingConfig(w http.ResponseWriter, r *http.Request)
 
 	resp, err := h.grpc.Config.GetDoclingConfig(r.Context())
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -350,14 +395,15 @@ func (h *SystemHandler) UpdateDocling(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.grpc.Config.UpdateDocling(r.Context(), &req)
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, resp)
 }
 
-// UpdateDistance updates the default vector distance metric.
+// UpdateDistance updates the default vector distance metric. A metric
+// existing collections do not use needs "confirm"; see guardConfigChange.
 func (h *SystemHandler) UpdateDistance(w http.ResponseWriter, r *http.Request) {
 	if h.grpc.Config == nil {
 		writeError(w, http.StatusServiceUnavailable, "config service not available")
@@ -366,17 +412,26 @@ func (h *SystemHandler) UpdateDistance(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		Distance string `json:"distance"`
+		Confirm  bool   `json:"confirm"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
+	p, err := h.preflightDistance(r.Context(), req.Distance)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	if !guardConfigChange(w, r, p, req.Confirm) {
+		return
+	}
 
 	resp, err := h.grpc.Config.UpdateDistance(r.Context(), &grpcclient.UpdateDistanceRequest{
 		Distance: req.Distance,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -398,29 +453,62 @@ func (h *SystemHandler) UpdateOllama(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.grpc.Config.UpdateOllama(r.Context(), &req)
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, resp)
 }
 
-// UpdateQdrant updates the Qdrant configuration.
+// UpdateQdrant updates the Qdrant configuration. A URL or default distance
+// that would orphan or break existing collections needs "confirm"; see
+// guardConfigChange.
 func (h *SystemHandler) UpdateQdrant(w http.ResponseWriter, r *http.Request) {
 	if h.grpc.Config == nil {
 		writeError(w, http.StatusServiceUnavailable, "config service not available")
 		return
 	}
 
-	var req grpcclient.UpdateQdrantRequest
+	var req struct {
+		URL               *string `json:"url"`
+		DefaultCollection *string `json:"default_collection"`
+		DefaultDistance   *string `json:"default_distance"`
+		Confirm           bool    `json:"confirm"`
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
+	p := &configPreflight{Change: "changing the Qdrant settings"}
+	for _, check := range []struct {
+		set bool
+		run func() (*configPreflight, error)
+	}{
+		{req.URL != nil, func() (*configPreflight, error) { return h.preflightQdrantURL(r.Context(), *req.URL) }},
+		{req.DefaultDistance != nil, func() (*configPreflight, error) { return h.preflightDistance(r.Context(), *req.DefaultDistance) }},
+	} {
+		if !check.set {
+			continue
+		}
+		sub, err := check.run()
+		if err != nil {
+			writeGRPCError(w, err)
+			return
+		}
+		p.Warnings = append(p.Warnings, sub.Warnings...)
+		p.Collections = append(p.Collections, sub.Collections...)
+	}
+	if !guardConfigChange(w, r, p, req.Confirm) {
+		return
+	}
 
-	resp, err := h.grpc.Config.UpdateQdrant(r.Context(), &req)
+	resp, err := h.grpc.Config.UpdateQdrant(r.Context(), &grpcclient.UpdateQdrantRequest{
+		Url:               req.URL,
+		DefaultCollection: req.DefaultCollection,
+		DefaultDistance:   req.DefaultDistance,
+	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -442,7 +530,7 @@ func (h *SystemHandler) UpdateChunking(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.grpc.Config.UpdateChunking(r.Context(), &req)
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -464,7 +552,7 @@ func (h *SystemHandler) UpdateImage(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.grpc.Config.UpdateImage(r.Context(), &req)
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -487,7 +575,7 @@ func (h *SystemHandler) ResetConfig(w http.ResponseWriter, r *http.Request) {
 		Section: section,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -552,3 +640,56 @@ func (h *SystemHandler) ManageOllamaContainer(w http.ResponseWriter, r *http.Req
 		writeError(w, http.StatusBadRequest, "action must be 'start' or 'stop'")
 	}
 }
+
+// containerUpdateTimeout bounds an Ollama container update. It is far
+// longer than the worker deadline on /api/system, since pulling the image
+// can take minutes.
+const containerUpdateTimeout = 15 * time.Minute
+
+// UpdateOllamaContainer pulls the newest image of the Ollama container the
+// gateway manages and, if it changed, recreates the container from it with
+// the same configuration and the same model volume. The optional body
+// {"image": "ollama/ollama:0.6.0"} moves it to another image instead.
+func (h *SystemHandler) UpdateOllamaContainer(w http.ResponseWriter, r *http.Request) {
+	if h.docker == nil {
+		writeError(w, http.StatusServiceUnavailable, "docker not available")
+		return
+	}
+	var req struct {
+		Image string `json:"image"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	req.Image = strings.TrimSpace(req.Image)
+	if strings.ContainsAny(req.Image, " \t\n?&#") {
+		writeError(w, http.StatusBadRequest, "image is not a valid image reference")
+		return
+	}
+	if !h.updating.TryLock() {
+		writeError(w, http.StatusConflict, "an Ollama container update is already running")
+		return
+	}
+	defer h.updating.Unlock()
+
+	// The update runs to the end even if the client goes away: stopping
+	// half-way would leave Ollama down.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), containerUpdateTimeout)
+	defer cancel()
+	c, err := h.docker.Inspect(ctx, ollamaContainerName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("docker error: %v", err))
+		return
+	}
+	if c == nil {
+		writeError(w, http.StatusNotFound, "the Ollama container is not managed by the gateway")
+		return
+	}
+	res, err := h.docker.UpdateContainer(ctx, ollamaContainerName, req.Image)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("update container: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
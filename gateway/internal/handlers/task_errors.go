@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// defaultTaskErrorsLimit and maxTaskErrorsLimit bound the page size of
+	// GET /api/rag/tasks/{id}/errors.
+	defaultTaskErrorsLimit = 100
+	maxTaskErrorsLimit     = 1000
+)
+
+// ListErrors pages through the files a task failed to index, in the order
+// they failed. ?stage= keeps one stage and ?q= the errors whose file or
+// message contains it; ?offset= and ?limit= (default 100) page the result.
+func (h *TasksHandler) ListErrors(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	q := r.URL.Query()
+	offset, limit := 0, defaultTaskErrorsLimit
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		offset = n
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxTaskErrorsLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxTaskErrorsLimit))
+			return
+		}
+		limit = n
+	}
+
+	all, dropped, ok := h.tm.FileErrors(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("task %s not found", id))
+		return
+	}
+	stage, needle := q.Get("stage"), strings.ToLower(q.Get("q"))
+	matched := all[:0]
+	for _, fe := range all {
+		if stage != "" && fe.Stage != stage {
+			continue
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(fe.File), needle) && !strings.Contains(strings.ToLower(fe.Error), needle) {
+			continue
+		}
+		matched = append(matched, fe)
+	}
+
+	page := []tasks.FileError{}
+	if offset < len(matched) {
+		page = matched[offset:min(offset+limit, len(matched))]
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"task_id": id,
+		"errors":  page,
+		"total":   len(matched),
+		"offset":  offset,
+		"limit":   limit,
+		"dropped": dropped,
+	})
+}
+
+// RetryFailed re-launches a finished task for only the files it failed to
+// index, with the task's other request parameters.
+func (h *TasksHandler) RetryFailed(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	task := h.tm.Get(id)
+	if task == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("task %s not found", id))
+		return
+	}
+	if !task.Status.Finished() {
+		writeError(w, http.StatusConflict, fmt.Sprintf("task %s is in state %s, cannot retry", id, task.Status))
+		return
+	}
+	if h.grpc.Indexing == nil {
+		writeError(w, http.StatusServiceUnavailable, "indexing service not available")
+		return
+	}
+
+	if h.remoteTask(w, id) {
+		return
+	}
+	failed := h.tm.FailedFiles(id)
+	if len(failed) == 0 {
+		writeError(w, http.StatusConflict, fmt.Sprintf("task %s has no failed files to retry", id))
+		return
+	}
+	params, _ := h.tm.RawParams(id)
+	if params == nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("request params of task %s are no longer retained, cannot retry", id))
+		return
+	}
+	params, err := failedFilesParams(task.Type, params, failed)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.relaunch(w, r, task, params)
+}
+
+// failedFilesParams returns a copy of the request params of a task of type
+// taskType that indexes only the failed files.
+func failedFilesParams(taskType string, params map[string]interface{}, failed []string) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		out[k] = v
+	}
+	switch taskType {
+	case "index_codebase":
+		if files := stringSliceParam(params, "files"); len(files) > 0 {
+			out["files"], out["display_names"] = keepFailed(files, stringSliceParam(params, "display_names"), failed)
+		} else {
+			// Root-relative paths, sent as the explicit file list.
+			out["files"] = failed
+		}
+	case "index_uploads":
+		out["saved_paths"], out["display_names"] = keepFailed(stringSliceParam(params, "saved_paths"), stringSliceParam(params, "display_names"), failed)
+	case "index_documents":
+		out["paths"] = failed
+	case "index_smb":
+		out["remote_paths"] = failed
+	default:
+		return nil, fmt.Errorf("retrying only the failed files is not supported for %s tasks", taskType)
+	}
+	for _, k := range []string{"files", "saved_paths"} {
+		if v, ok := out[k].([]string); ok && len(v) == 0 {
+			return nil, errors.New("none of the failed files is in the task's file list")
+		}
+	}
+	return out, nil
+}
+
+// keepFailed returns the entries of files that failed, with their display
+// names when names lines up with files.
+func keepFailed(files, names, failed []string) ([]string, []string) {
+	isFailed := make(map[string]bool, len(failed))
+	for _, f := range failed {
+		isFailed[f] = true
+	}
+	keptFiles := []string{}
+	var keptNames []string
+	for i, f := range files {
+		if !isFailed[f] {
+			continue
+		}
+		keptFiles = append(keptFiles, f)
+		if len(names) == len(files) {
+			keptNames = append(keptNames, names[i])
+		}
+	}
+	return keptFiles, keptNames
+}
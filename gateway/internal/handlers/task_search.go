@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/fulltext"
+	"github.com/alfagnish/ollqd-gateway/internal/i18n"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+const (
+	// searchDefaultLimit and searchMaxLimit bound the hits of one search
+	// of tasks or audit entries.
+	searchDefaultLimit = 20
+	searchMaxLimit     = 200
+)
+
+// taskSearchFields are the fields of a task a query can name, as in
+// "status:failed".
+var taskSearchFields = []string{"id", "type", "status", "error", "params", "files", "warnings", "collection", "node"}
+
+// TaskSearch keeps a full-text index of the tasks the manager tracks. The
+// index is brought up to date before every search: tasks whose searchable
+// state changed are indexed again and cleared tasks are dropped.
+type TaskSearch struct {
+	tm    *tasks.Manager
+	index *fulltext.Index
+
+	mu   sync.Mutex
+	seen map[string]string // task ID -> fingerprint of the indexed state
+}
+
+// NewTaskSearch creates a TaskSearch over the tasks of tm.
+func NewTaskSearch(tm *tasks.Manager) *TaskSearch {
+	return &TaskSearch{tm: tm, index: fulltext.New(), seen: make(map[string]string)}
+}
+
+// Search returns the tasks matching q, best first, with their hits, and
+// how many matched in all.
+func (s *TaskSearch) Search(q fulltext.Query, limit int) ([]*tasks.TaskInfo, []fulltext.Hit, int) {
+	byID := s.sync()
+	hits, total := s.index.Search(q, limit)
+	found := make([]*tasks.TaskInfo, 0, len(hits))
+	kept := hits[:0]
+	for _, hit := range hits {
+		// A task cleared since sync is left out.
+		if t, ok := byID[hit.ID]; ok {
+			found = append(found, t)
+			kept = append(kept, hit)
+		}
+	}
+	return found, kept, total
+}
+
+// sync indexes the tasks whose searchable state changed since the last
+// search and returns all current tasks by ID.
+func (s *TaskSearch) sync() map[string]*tasks.TaskInfo {
+	list := s.tm.List()
+	byID := make(map[string]*tasks.TaskInfo, len(list))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range list {
+		byID[t.ID] = t
+		fp := taskFingerprint(t)
+		if s.seen[t.ID] == fp {
+			continue
+		}
+		var fileErrs []tasks.FileError
+		if t.ErrorCount > 0 {
+			fileErrs, _, _ = s.tm.FileErrors(t.ID)
+		}
+		s.index.Put(taskDoc(t, fileErrs))
+		s.seen[t.ID] = fp
+	}
+	for id := range s.seen {
+		if _, ok := byID[id]; !ok {
+			s.index.Remove(id)
+			delete(s.seen, id)
+		}
+	}
+	return byID
+}
+
+// taskFingerprint summarises the searchable state of a task; it changes
+// whenever taskDoc would index the task differently.
+func taskFingerprint(t *tasks.TaskInfo) string {
+	return fmt.Sprintf("%s|%t|%s|%s|%d|%d|%t|%d|%s|%d|%s",
+		t.Status, t.Stalled, t.Error, t.CancelReason, t.ErrorCount, t.ErrorsDropped,
+		t.ParamsDropped, len(t.Warnings), t.LockedCollection, len(t.Result), t.Node)
+}
+
+// taskDoc is the searchable text of a task. Request params are the
+// redacted ones task listings show, so searches cannot reveal secrets.
+func taskDoc(t *tasks.TaskInfo, fileErrs []tasks.FileError) fulltext.Doc {
+	status := string(t.Status)
+	if t.Stalled {
+		status += " stalled"
+	}
+	errText := []string{t.Error, t.CancelReason}
+	var files []string
+	for _, fe := range fileErrs {
+		files = append(files, fe.File, fe.Stage)
+		errText = append(errText, fe.Error)
+	}
+	var params []string
+	flattenParams(t.RequestParams, &params)
+	collections := []string{t.LockedCollection, t.Result["collection"]}
+	if c, ok := t.RequestParams["collection"].(string); ok {
+		collections = append(collections, c)
+	}
+	return fulltext.Doc{
+		ID: t.ID,
+		At: t.CreatedAt,
+		Fields: map[string]string{
+			"id":         t.ID,
+			"type":       t.Type,
+			"status":     status,
+			"error":      strings.Join(errText, "\n"),
+			"params":     strings.Join(params, "\n"),
+			"files":      strings.Join(files, "\n"),
+			"warnings":   strings.Join(t.Warnings, "\n"),
+			"collection": strings.Join(collections, "\n"),
+			"node":       t.Node,
+		},
+	}
+}
+
+// flattenParams appends the keys and values of request params to out in
+// key order, leaving out redacted values.
+func flattenParams(v interface{}, out *[]string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			*out = append(*out, k)
+			flattenParams(val[k], out)
+		}
+	case []interface{}:
+		for _, e := range val {
+			flattenParams(e, out)
+		}
+	case string:
+		if val != tasks.RedactedValue {
+			*out = append(*out, val)
+		}
+	case nil:
+	default:
+		*out = append(*out, fmt.Sprint(val))
+	}
+}
+
+// parseSearchRequest reads the q and limit parameters of a search request.
+// It writes a 400 response and returns false when they are unusable.
+func parseSearchRequest(w http.ResponseWriter, r *http.Request, fields []string) (fulltext.Query, int, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get("q"))
+	if raw == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return fulltext.Query{}, 0, false
+	}
+	q, err := fulltext.ParseQuery(raw, fields, time.Local)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return fulltext.Query{}, 0, false
+	}
+	if q.Empty() {
+		writeError(w, http.StatusBadRequest, "q has no searchable words")
+		return fulltext.Query{}, 0, false
+	}
+	limit := searchDefaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return fulltext.Query{}, 0, false
+		}
+		limit = n
+	}
+	return q, min(limit, searchMaxLimit), true
+}
+
+// Search finds tasks by the words in their type, status, errors, request
+// params, failed files, warnings and collection.
+func (h *TasksHandler) Search(w http.ResponseWriter, r *http.Request) {
+	q, limit, ok := parseSearchRequest(w, r, taskSearchFields)
+	if !ok {
+		return
+	}
+	found, hits, total := h.search.Search(q, limit)
+	lang := i18n.RequestLang(r)
+	results := make([]map[string]interface{}, len(found))
+	for i, t := range found {
+		t.StatusLabel = i18n.StatusLabel(lang, string(t.Status))
+		results[i] = map[string]interface{}{
+			"task":    t,
+			"score":   hits[i].Score,
+			"matched": hits[i].Fields,
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"query":   r.URL.Query().Get("q"),
+		"results": results,
+		"count":   len(results),
+		"total":   total,
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/go-chi/chi/v5"
+)
+
+// timelineEntry is a timeline event with its offset from the task's
+// creation and the time since the event before it, in seconds.
+type timelineEntry struct {
+	tasks.TimelineEvent
+	ElapsedSeconds float64 `json:"elapsed_s"`
+	DeltaSeconds   float64 `json:"delta_s"`
+}
+
+// timelinePhases splits a task's time into waiting for a slot and running.
+// Running is up to now for a task that has not finished.
+type timelinePhases struct {
+	QueuedSeconds  float64 `json:"queued_s"`
+	RunningSeconds float64 `json:"running_s"`
+	TotalSeconds   float64 `json:"total_s"`
+}
+
+// Timeline returns the significant events of a task in order: creation,
+// queueing, start, every 10% of progress, stalls, worker reconnects and the
+// end, each with the time since creation and since the event before.
+func (h *TasksHandler) Timeline(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	task := h.tm.Get(id)
+	events, dropped, ok := h.tm.Timeline(id)
+	if task == nil || !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("task %s not found", id))
+		return
+	}
+
+	entries := make([]timelineEntry, len(events))
+	prev := task.CreatedAt
+	for i, ev := range events {
+		entries[i] = timelineEntry{
+			TimelineEvent:  ev,
+			ElapsedSeconds: roundSeconds(ev.At.Sub(task.CreatedAt)),
+			DeltaSeconds:   roundSeconds(ev.At.Sub(prev)),
+		}
+		prev = ev.At
+	}
+
+	end := time.Now()
+	if task.CompletedAt != nil {
+		end = *task.CompletedAt
+	}
+	var phases timelinePhases
+	phases.TotalSeconds = roundSeconds(end.Sub(task.CreatedAt))
+	if task.StartedAt != nil {
+		phases.QueuedSeconds = roundSeconds(task.StartedAt.Sub(task.CreatedAt))
+		phases.RunningSeconds = roundSeconds(end.Sub(*task.StartedAt))
+	} else {
+		phases.QueuedSeconds = phases.TotalSeconds
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"task_id": id,
+		"type":    task.Type,
+		"status":  task.Status,
+		"events":  entries,
+		"phases":  phases,
+		"dropped": dropped,
+	})
+}
@@ -2,43 +2,80 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
 
 	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/i18n"
+	"github.com/alfagnish/ollqd-gateway/internal/imagemeta"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
 	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
 	"github.com/go-chi/chi/v5"
 )
 
 // TasksHandler provides endpoints for listing, inspecting, cancelling,
-// retrying, and clearing background tasks.
+// retrying, and clearing background tasks, and for the index run reports.
 type TasksHandler struct {
-	grpc *grpcclient.Client
-	tm   *tasks.Manager
+	grpc      *grpcclient.Client
+	tm        *tasks.Manager
+	meta      *imagemeta.Attacher
+	reports   *IndexReports
+	instances *OllamaInstances
+	guard     *Guardrails
+	search    *TaskSearch
 }
 
-// NewTasksHandler creates a new TasksHandler.
-func NewTasksHandler(gc *grpcclient.Client, tm *tasks.Manager) *TasksHandler {
-	return &TasksHandler{grpc: gc, tm: tm}
+// NewTasksHandler creates a new TasksHandler. Retried image and upload
+// tasks re-extract image metadata with meta; retried runs pinned to an
+// Ollama instance look it up again in instances. Retries are checked
+// against guard like new tasks.
+func NewTasksHandler(gc *grpcclient.Client, tm *tasks.Manager, meta *imagemeta.Attacher, reports *IndexReports, instances *OllamaInstances, guard *Guardrails) *TasksHandler {
+	return &TasksHandler{grpc: gc, tm: tm, meta: meta, reports: reports, instances: instances, guard: guard, search: NewTaskSearch(tm)}
 }
 
 // Routes registers all task-management routes on the given chi router.
 func (h *TasksHandler) Routes(r chi.Router) {
 	r.Get("/", h.List)
 	r.Delete("/", h.ClearFinished)
+	r.Get("/stats", h.Stats)
+	r.Get("/search", h.Search)
+	r.Get("/reports", h.Reports)
+	r.With(middleware.RequireAdmin).Delete("/reports", h.ClearReports)
 	r.Get("/{id}", h.Get)
 	r.Post("/{id}/cancel", h.Cancel)
 	r.Post("/{id}/retry", h.Retry)
+	r.Post("/{id}/retry-failed", h.RetryFailed)
+	r.Get("/{id}/errors", h.ListErrors)
+	r.Get("/{id}/timeline", h.Timeline)
+	r.With(middleware.RequireAdmin).Put("/{id}/priority", h.SetPriority)
+	r.With(middleware.RequireAdmin).Get("/{id}/params", h.RawParams)
+	r.Get("/{id}/artifacts", h.ListArtifacts)
+	r.Get("/{id}/artifacts/{name}", h.DownloadArtifact)
 }
 
 // List returns all tracked tasks.
 func (h *TasksHandler) List(w http.ResponseWriter, r *http.Request) {
 	taskList := h.tm.List()
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"tasks": taskList,
-		"count": len(taskList),
-	})
+	lang := i18n.RequestLang(r)
+	stalled := 0
+	for _, t := range taskList {
+		t.StatusLabel = i18n.StatusLabel(lang, string(t.Status))
+		if t.Stalled && t.Status == tasks.StatusRunning {
+			stalled++
+		}
+	}
+	writeJSON(w, http.StatusOK, api.TaskList{Tasks: taskList, Count: len(taskList), Stalled: stalled})
+}
+
+// Stats returns task counts by status and the watchdog's stall counters.
+func (h *TasksHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.tm.Stats())
 }
 
 // ClearFinished removes all completed, failed, or cancelled tasks.
@@ -57,22 +94,137 @@ func (h *TasksHandler) Get(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, fmt.Sprintf("task %s not found", id))
 		return
 	}
+	task.StatusLabel = i18n.StatusLabel(i18n.RequestLang(r), string(task.Status))
 	writeJSON(w, http.StatusOK, task)
 }
 
-// Cancel cancels a running task.
+// Cancel cancels a queued or running task. The response carries the task's
+// status afterwards: a task that had already finished keeps its state.
 func (h *TasksHandler) Cancel(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if ok := h.tm.Cancel(id); !ok {
 		writeError(w, http.StatusNotFound, fmt.Sprintf("task %s not found", id))
 		return
 	}
+	status := tasks.StatusCancelled
+	if task := h.tm.Get(id); task != nil {
+		status = task.Status
+	}
 	writeJSON(w, http.StatusOK, map[string]string{
 		"task_id": id,
-		"status":  "cancelled",
+		"status":  string(status),
+	})
+}
+
+// RawParams returns a task's unredacted request params (admin only).
+func (h *TasksHandler) RawParams(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	task := h.tm.Get(id)
+	if task == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("task %s not found", id))
+		return
+	}
+	params, _ := h.tm.RawParams(id)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"task_id":        id,
+		"request_params": params,
+		"params_dropped": task.ParamsDropped,
+	})
+}
+
+// SetPriority bumps or demotes a queued task (admin only).
+func (h *TasksHandler) SetPriority(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req struct {
+		Priority string `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	priority, err := tasks.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.tm.SetPriority(id, priority); err != nil {
+		if errors.Is(err, tasks.ErrNotFound) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("task %s not found", id))
+			return
+		}
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"task_id":        id,
+		"priority":       priority,
+		"queue_position": h.tm.QueuePosition(id),
 	})
 }
 
+// ListArtifacts returns the result artifacts attached to a task.
+func (h *TasksHandler) ListArtifacts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	task := h.tm.Get(id)
+	if task == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("task %s not found", id))
+		return
+	}
+
+	type artifactJSON struct {
+		tasks.Artifact
+		URL string `json:"url"`
+	}
+	artifacts := make([]artifactJSON, 0, len(task.Artifacts))
+	for _, a := range task.Artifacts {
+		artifacts = append(artifacts, artifactJSON{
+			Artifact: a,
+			URL:      middleware.ExternalURL(r, "/api/rag/tasks/"+id+"/artifacts/"+url.PathEscape(a.Name)),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"task_id":   id,
+		"artifacts": artifacts,
+		"count":     len(artifacts),
+	})
+}
+
+// DownloadArtifact serves a single task artifact with its recorded content
+// type. Pass ?inline=true to display it in the browser instead of downloading.
+func (h *TasksHandler) DownloadArtifact(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	name, _ := url.PathUnescape(chi.URLParam(r, "name"))
+
+	if h.tm.Get(id) == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("task %s not found", id))
+		return
+	}
+
+	artifact, path, ok := h.tm.ArtifactPath(id, name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("artifact %s not found", name))
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("artifact %s not found", name))
+		return
+	}
+	defer f.Close()
+
+	disposition := "attachment"
+	if r.URL.Query().Get("inline") == "true" {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", artifact.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": artifact.Name}))
+	http.ServeContent(w, r, artifact.Name, artifact.CreatedAt, f)
+}
+
 // Retry re-launches a task using the stored request parameters. Only
 // completed, failed, or cancelled tasks can be retried.
 func (h *TasksHandler) Retry(w http.ResponseWriter, r *http.Request) {
@@ -84,10 +236,7 @@ func (h *TasksHandler) Retry(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Only terminal tasks can be retried.
-	switch task.Status {
-	case tasks.StatusCompleted, tasks.StatusFailed, tasks.StatusCancelled:
-		// OK
-	default:
+	if !task.Status.Finished() {
 		writeError(w, http.StatusConflict, fmt.Sprintf("task %s is in state %s, cannot retry", id, task.Status))
 		return
 	}
@@ -97,76 +246,150 @@ func (h *TasksHandler) Retry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create a new task with the same parameters.
-	newID := h.tm.Create(task.Type, task.RequestParams)
-	h.tm.Start(newID)
+	if h.remoteTask(w, id) {
+		return
+	}
+	// Listings are redacted, so rebuild the request from the raw params.
+	params, _ := h.tm.RawParams(id)
+	if params == nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("request params of task %s are no longer retained, cannot retry", id))
+		return
+	}
+	h.relaunch(w, r, task, params)
+}
+
+// remoteTask writes a 409 response and returns true if the task belongs to
+// another gateway replica in cluster mode. Request params are not shared,
+// so only that replica can retry it.
+func (h *TasksHandler) remoteTask(w http.ResponseWriter, id string) bool {
+	node, remote := h.tm.RemoteNode(id)
+	if remote {
+		writeError(w, http.StatusConflict, fmt.Sprintf("task %s ran on gateway node %s, which keeps its request params; retry it there", id, node))
+	}
+	return remote
+}
 
+// relaunch starts a new task of the same type as task from the given
+// request params and writes the 202 response.
+func (h *TasksHandler) relaunch(w http.ResponseWriter, r *http.Request, task *tasks.TaskInfo, params map[string]interface{}) {
+	id := task.ID
 	ctx, cancel := context.WithCancel(context.Background())
-	h.tm.SetCancelFunc(newID, cancel)
+	ctx, err := h.instances.pin(ctx, stringParam(params, "ollama_instance"))
+	if err != nil {
+		cancel()
+		writeError(w, http.StatusConflict, fmt.Sprintf("cannot retry task %s: %v", id, err))
+		return
+	}
+	warnings, ok := h.guard.admit(r.Context(), w, 0)
+	if !ok {
+		cancel()
+		return
+	}
 
-	params := task.RequestParams
+	// Create a new task with the same parameters and priority.
+	priority, _ := tasks.ParsePriority(stringParam(params, "priority"))
+	newID := h.tm.Create(task.Type, params)
+	h.tm.AddWarnings(newID, warnings)
+	lockMode, _ := tasks.ParseLockMode(stringParam(params, "lock"))
+	if !lockIndexTarget(w, h.tm, newID, stringParam(params, "collection"), workerDefaultCollectionFor(task.Type), lockMode) {
+		cancel()
+		return
+	}
+	h.tm.SetCancelFunc(newID, cancel)
+	prov := retryProvenance(task.Type, newID, middleware.UsernameFromContext(r.Context()), params)
 
 	switch task.Type {
 	case "index_codebase":
-		go h.runRetryStream(ctx, newID, func() (grpcclient.IndexingStream, error) {
-			return h.grpc.Indexing.IndexCodebase(ctx, &grpcclient.IndexCodebaseRequest{
-				RootPath:      stringParam(params, "root_path"),
-				Collection:    stringParam(params, "collection"),
-				Incremental:   boolParam(params, "incremental"),
-				ChunkSize:     int32Param(params, "chunk_size"),
-				ChunkOverlap:  int32Param(params, "chunk_overlap"),
-				ExtraSkipDirs: stringSliceParam(params, "extra_skip_dirs"),
+		h.tm.Enqueue(newID, priority, func() {
+			// Uploads routed to the codebase indexer carry their files and
+			// original names.
+			files := stringSliceParam(params, "files")
+			names := displayNameMap(files, stringSliceParam(params, "display_names"))
+			consumeNamedIndex(ctx, h.tm, h.grpc, newID, files, names, func(ctx context.Context, batch []string) (grpcclient.IndexingStream, error) {
+				sctx := withDisplayNames(ctx, batch, names)
+				return h.grpc.Indexing.IndexCodebase(grpcclient.WithProvenance(sctx, prov), &grpcclient.IndexCodebaseRequest{
+					RootPath:      stringParam(params, "root_path"),
+					Collection:    stringParam(params, "collection"),
+					Incremental:   boolParam(params, "incremental"),
+					ChunkSize:     int32Param(params, "chunk_size"),
+					ChunkOverlap:  int32Param(params, "chunk_overlap"),
+					ExtraSkipDirs: stringSliceParam(params, "extra_skip_dirs"),
+					Files:         batch,
+				})
 			})
 		})
 	case "index_documents":
-		go h.runRetryStream(ctx, newID, func() (grpcclient.IndexingStream, error) {
-			return h.grpc.Indexing.IndexDocuments(ctx, &grpcclient.IndexDocumentsRequest{
-				Paths:        stringSliceParam(params, "paths"),
-				Collection:   stringParam(params, "collection"),
-				ChunkSize:    int32Param(params, "chunk_size"),
-				ChunkOverlap: int32Param(params, "chunk_overlap"),
-				SourceTag:    stringParam(params, "source_tag"),
+		h.tm.Enqueue(newID, priority, func() {
+			h.runRetryStream(ctx, newID, func() (grpcclient.IndexingStream, error) {
+				mctx := grpcclient.WithProvenance(grpcclient.WithTableOptions(ctx, tableParam(params)), prov)
+				return h.grpc.Indexing.IndexDocuments(mctx, &grpcclient.IndexDocumentsRequest{
+					Paths:        stringSliceParam(params, "paths"),
+					Collection:   stringParam(params, "collection"),
+					ChunkSize:    int32Param(params, "chunk_size"),
+					ChunkOverlap: int32Param(params, "chunk_overlap"),
+					SourceTag:    stringParam(params, "source_tag"),
+				})
 			})
 		})
 	case "index_images":
-		go h.runRetryStream(ctx, newID, func() (grpcclient.IndexingStream, error) {
-			return h.grpc.Indexing.IndexImages(ctx, &grpcclient.IndexImagesRequest{
-				RootPath:       stringParam(params, "root_path"),
-				Collection:     stringParam(params, "collection"),
-				VisionModel:    stringParam(params, "vision_model"),
-				CaptionPrompt:  stringParam(params, "caption_prompt"),
-				Incremental:    boolParam(params, "incremental"),
-				MaxImageSizeKb: int32Param(params, "max_image_size_kb"),
-				ExtraSkipDirs:  stringSliceParam(params, "extra_skip_dirs"),
+		h.tm.Enqueue(newID, priority, func() {
+			mctx, cleanup := h.meta.AttachDir(ctx, stringParam(params, "root_path"), stringSliceParam(params, "extra_skip_dirs"))
+			defer cleanup()
+			h.runRetryStream(ctx, newID, func() (grpcclient.IndexingStream, error) {
+				return h.grpc.Indexing.IndexImages(grpcclient.WithProvenance(mctx, prov), &grpcclient.IndexImagesRequest{
+					RootPath:       stringParam(params, "root_path"),
+					Collection:     stringParam(params, "collection"),
+					VisionModel:    stringParam(params, "vision_model"),
+					CaptionPrompt:  stringParam(params, "caption_prompt"),
+					Incremental:    boolParam(params, "incremental"),
+					MaxImageSizeKb: int32Param(params, "max_image_size_kb"),
+					ExtraSkipDirs:  stringSliceParam(params, "extra_skip_dirs"),
+				})
 			})
 		})
 	case "index_uploads":
-		go h.runRetryStream(ctx, newID, func() (grpcclient.IndexingStream, error) {
-			return h.grpc.Indexing.IndexUploads(ctx, &grpcclient.IndexUploadsRequest{
-				SavedPaths:    stringSliceParam(params, "saved_paths"),
-				Collection:    stringParam(params, "collection"),
-				ChunkSize:     int32Param(params, "chunk_size"),
-				ChunkOverlap:  int32Param(params, "chunk_overlap"),
-				SourceTag:     stringParam(params, "source_tag"),
-				VisionModel:   stringParam(params, "vision_model"),
-				CaptionPrompt: stringParam(params, "caption_prompt"),
+		h.tm.Enqueue(newID, priority, func() {
+			savedPaths := stringSliceParam(params, "saved_paths")
+			names := displayNameMap(savedPaths, stringSliceParam(params, "display_names"))
+			mctx, cleanup := h.meta.AttachFiles(ctx, savedPaths)
+			defer cleanup()
+			if ocr, ok := params["ocr"].(bool); ok {
+				mctx = grpcclient.WithDoclingOCR(mctx, &ocr)
+			}
+			mctx = grpcclient.WithTableOptions(mctx, tableParam(params))
+			consumeNamedIndex(mctx, h.tm, h.grpc, newID, savedPaths, names, func(ctx context.Context, batch []string) (grpcclient.IndexingStream, error) {
+				return h.grpc.Indexing.IndexUploads(grpcclient.WithProvenance(withDisplayNames(ctx, batch, names), prov), &grpcclient.IndexUploadsRequest{
+					SavedPaths:    batch,
+					Collection:    stringParam(params, "collection"),
+					ChunkSize:     int32Param(params, "chunk_size"),
+					ChunkOverlap:  int32Param(params, "chunk_overlap"),
+					SourceTag:     stringParam(params, "source_tag"),
+					VisionModel:   stringParam(params, "vision_model"),
+					CaptionPrompt: stringParam(params, "caption_prompt"),
+				})
 			})
 		})
 	case "index_smb":
-		go h.runRetryStream(ctx, newID, func() (grpcclient.IndexingStream, error) {
-			return h.grpc.Indexing.IndexSMBFiles(ctx, &grpcclient.IndexSMBFilesRequest{
-				ShareId:      stringParam(params, "share_id"),
-				RemotePaths:  stringSliceParam(params, "remote_paths"),
-				Collection:   stringParam(params, "collection"),
-				ChunkSize:    int32Param(params, "chunk_size"),
-				ChunkOverlap: int32Param(params, "chunk_overlap"),
-				SourceTag:    stringParam(params, "source_tag"),
-				Server:       stringParam(params, "server"),
-				Share:        stringParam(params, "share"),
-				Username:     stringParam(params, "username"),
-				Password:     stringParam(params, "password"),
-				Domain:       stringParam(params, "domain"),
-				Port:         int32Param(params, "port"),
+		h.tm.Enqueue(newID, priority, func() {
+			h.runRetryStream(ctx, newID, func() (grpcclient.IndexingStream, error) {
+				mctx := grpcclient.WithSMBACLs(ctx, boolParam(params, "capture_acls"))
+				mctx = grpcclient.WithProvenance(mctx, prov)
+				req := &grpcclient.IndexSMBFilesRequest{
+					ShareId:      stringParam(params, "share_id"),
+					RemotePaths:  stringSliceParam(params, "remote_paths"),
+					Collection:   stringParam(params, "collection"),
+					ChunkSize:    int32Param(params, "chunk_size"),
+					ChunkOverlap: int32Param(params, "chunk_overlap"),
+					SourceTag:    stringParam(params, "source_tag"),
+					Server:       stringParam(params, "server"),
+					Share:        stringParam(params, "share"),
+					Username:     stringParam(params, "username"),
+					Password:     stringParam(params, "password"),
+					Domain:       stringParam(params, "domain"),
+					Port:         int32Param(params, "port"),
+				}
+				smbAuthParam(params).setRequestAuth(req)
+				return h.grpc.Indexing.IndexSMBFiles(mctx, req)
 			})
 		})
 	default:
@@ -178,56 +401,72 @@ func (h *TasksHandler) Retry(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusAccepted, map[string]interface{}{
 		"task_id":          newID,
 		"original_task_id": id,
-		"status":           "started",
+		"status":           taskStartStatus(h.tm, newID),
 	})
 }
 
 // runRetryStream is the same logic as RAGHandler.runIndexStream but lives on
 // TasksHandler for retry access.
 func (h *TasksHandler) runRetryStream(ctx context.Context, taskID string, openStream func() (grpcclient.IndexingStream, error)) {
-	stream, err := openStream()
-	if err != nil {
-		h.tm.Fail(taskID, fmt.Sprintf("failed to open stream: %v", err))
-		return
-	}
-	defer stream.Close()
-
-	for {
-		select {
-		case <-ctx.Done():
-			h.tm.Fail(taskID, "cancelled")
-			return
-		default:
-		}
+	h.tm.ConsumeIndexStream(ctx, h.grpc, taskID, openStream)
+}
 
-		progress, err := stream.Recv()
-		if err != nil {
-			if err == io.EOF {
-				h.tm.Complete(taskID, nil)
-			} else {
-				h.tm.Fail(taskID, fmt.Sprintf("stream error: %v", err))
-			}
-			return
-		}
+// taskStartStatus reports whether a newly enqueued task started right away
+// or is waiting for a free slot.
+func taskStartStatus(tm *tasks.Manager, taskID string) string {
+	if tm.QueuePosition(taskID) > 0 {
+		return "queued"
+	}
+	return "started"
+}
 
-		switch progress.Status {
-		case "running":
-			h.tm.UpdateProgress(taskID, float64(progress.Progress), "running")
-		case "completed":
-			h.tm.Complete(taskID, progress.Result)
-			return
-		case "failed":
-			h.tm.Fail(taskID, progress.Error)
-			return
-		case "cancelled":
-			h.tm.Cancel(taskID)
-			return
-		}
+// writeTaskAccepted writes the standard 202 response for a newly enqueued
+// background task.
+func writeTaskAccepted(w http.ResponseWriter, tm *tasks.Manager, taskID string) {
+	resp := api.TaskAccepted{
+		TaskID:        taskID,
+		Status:        taskStartStatus(tm, taskID),
+		QueuePosition: tm.QueuePosition(taskID),
+	}
+	if t := tm.Get(taskID); t != nil {
+		resp.Warnings = t.Warnings
 	}
+	writeJSON(w, http.StatusAccepted, resp)
 }
 
 // --- Param extraction helpers ---
 
+// retryProvenance returns the provenance of task id, started by uploader to
+// retry a task of type taskType with params. The source type and sources
+// of uploads are kept in their params; other types imply theirs.
+func retryProvenance(taskType, id, uploader string, params map[string]interface{}) grpcclient.Provenance {
+	p := grpcclient.Provenance{
+		SourceType: stringParam(params, "source_type"),
+		Uploader:   uploader,
+		TaskID:     id,
+		Sources:    stringMapParam(params, "sources"),
+	}
+	if taskType == "index_smb" {
+		p.ShareID = stringParam(params, "share_id")
+	}
+	if p.SourceType != "" {
+		return p
+	}
+	switch taskType {
+	case "index_codebase":
+		p.SourceType = grpcclient.SourceCodebase
+	case "index_documents":
+		p.SourceType = grpcclient.SourceDocuments
+	case "index_images":
+		p.SourceType = grpcclient.SourceImages
+	case "index_uploads":
+		p.SourceType = grpcclient.SourceUpload
+	case "index_smb":
+		p.SourceType = grpcclient.SourceSMB
+	}
+	return p
+}
+
 func stringParam(m map[string]interface{}, key string) string {
 	v, _ := m[key].(string)
 	return v
@@ -269,3 +508,20 @@ func stringSliceParam(m map[string]interface{}, key string) []string {
 		return nil
 	}
 }
+
+func stringMapParam(m map[string]interface{}, key string) map[string]string {
+	switch v := m[key].(type) {
+	case map[string]string:
+		return v
+	case map[string]interface{}:
+		out := make(map[string]string, len(v))
+		for k, item := range v {
+			if s, ok := item.(string); ok {
+				out[k] = s
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
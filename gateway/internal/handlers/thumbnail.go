@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff"
+)
+
+const (
+	// thumbDirName is the cache directory under UploadDir. It is a dot
+	// directory so uploads listings and purge-uploads leave it alone.
+	thumbDirName = ".thumbs"
+
+	maxThumbDim     = 2048
+	defaultThumbQ   = 80
+	thumbMaxAge     = 7 * 24 * 3600 // seconds; cache keys change with the source
+	maxSourcePixels = 80_000_000    // refuse decompression bombs
+)
+
+// thumbSem bounds concurrent decodes; full-size images can take hundreds of
+// MB once decoded.
+var thumbSem = make(chan struct{}, 4)
+
+// thumbSpec is a parsed thumbnail request.
+type thumbSpec struct {
+	w, h    int
+	format  string // "jpeg" or "webp"
+	quality int
+}
+
+// wantsThumbnail reports whether the request asks for a resized image.
+func wantsThumbnail(r *http.Request) bool {
+	q := r.URL.Query()
+	return q.Get("w") != "" || q.Get("h") != ""
+}
+
+func parseThumbSpec(r *http.Request) (thumbSpec, error) {
+	q := r.URL.Query()
+	spec := thumbSpec{format: "jpeg", quality: defaultThumbQ}
+
+	dim := func(name string) (int, error) {
+		v := q.Get(name)
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxThumbDim {
+			return 0, fmt.Errorf("%s must be between 1 and %d", name, maxThumbDim)
+		}
+		return n, nil
+	}
+	var err error
+	if spec.w, err = dim("w"); err != nil {
+		return spec, err
+	}
+	if spec.h, err = dim("h"); err != nil {
+		return spec, err
+	}
+
+	switch f := strings.ToLower(q.Get("format")); f {
+	case "", "jpeg", "jpg":
+	case "webp":
+		spec.format = "webp"
+	default:
+		return spec, fmt.Errorf("unsupported format %q (use jpeg or webp)", f)
+	}
+
+	if v := q.Get("q"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			return spec, fmt.Errorf("q must be between 1 and 100")
+		}
+		spec.quality = n
+	}
+	return spec, nil
+}
+
+// serveThumbnail serves a resized copy of the image at fullPath, generating
+// and caching it on first request.
+func (h *ImageHandler) serveThumbnail(w http.ResponseWriter, r *http.Request, fullPath string, src os.FileInfo) {
+	spec, err := parseThumbSpec(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key := thumbKey(fullPath, src, spec)
+	cachePath := filepath.Join(h.cfg.UploadDir, thumbDirName, key[:2], key+"."+spec.format)
+
+	if _, err := os.Stat(cachePath); err != nil {
+		if err := generateThumbnail(fullPath, cachePath, spec); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "thumbnail: "+err.Error())
+			return
+		}
+	}
+
+	f, err := os.Open(cachePath)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "thumbnail: "+err.Error())
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "image/"+spec.format)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", thumbMaxAge))
+	w.Header().Set("ETag", `"`+key+`"`)
+	http.ServeContent(w, r, "", src.ModTime(), f)
+}
+
+// thumbKey identifies a thumbnail by source path, size, mtime and output
+// spec, so editing the original yields a fresh key rather than a stale hit.
+func thumbKey(fullPath string, src os.FileInfo, spec thumbSpec) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%d\x00%dx%d\x00%s\x00%d",
+		fullPath, src.Size(), src.ModTime().UnixNano(), spec.w, spec.h, spec.format, spec.quality)
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+func generateThumbnail(srcPath, dstPath string, spec thumbSpec) error {
+	thumbSem <- struct{}{}
+	defer func() { <-thumbSem }()
+
+	// Another request may have produced it while we waited.
+	if _, err := os.Stat(dstPath); err == nil {
+		return nil
+	}
+
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return fmt.Errorf("unsupported image: %w", err)
+	}
+	if cfg.Width*cfg.Height > maxSourcePixels {
+		return fmt.Errorf("image too large (%dx%d)", cfg.Width, cfg.Height)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	out := resizeToFit(img, spec.w, spec.h)
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), "thumb-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	switch spec.format {
+	case "webp":
+		err = nativewebp.Encode(tmp, out, nil)
+	default:
+		err = jpeg.Encode(tmp, flatten(out), &jpeg.Options{Quality: spec.quality})
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	return os.Rename(tmp.Name(), dstPath)
+}
+
+// resizeToFit scales img to fit within maxW x maxH (either may be 0 to leave
+// that side unconstrained), preserving aspect ratio. Images already small
+// enough are returned unchanged.
+func resizeToFit(img image.Image, maxW, maxH int) image.Image {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw == 0 || sh == 0 {
+		return img
+	}
+
+	scale := 1.0
+	if maxW > 0 && sw > maxW {
+		scale = float64(maxW) / float64(sw)
+	}
+	if maxH > 0 && sh > maxH {
+		if s := float64(maxH) / float64(sh); s < scale {
+			scale = s
+		}
+	}
+	if scale >= 1 {
+		return img
+	}
+
+	dw := max(1, int(float64(sw)*scale+0.5))
+	dh := max(1, int(float64(sh)*scale+0.5))
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// flatten composites img onto white, since JPEG has no alpha channel and
+// transparent pixels would otherwise turn black.
+func flatten(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, b, img, b.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+)
+
+// transcriptLanguage is the language of chunks the worker transcribed
+// from audio and video. Their start_line and end_line hold the time range
+// of the chunk in whole seconds.
+const transcriptLanguage = "transcript"
+
+// MediaRange locates a transcript hit in its recording; see api.MediaRange.
+type MediaRange = api.MediaRange
+
+// mediaExtensions are the uploadable audio and video extensions, which the
+// worker transcribes rather than parses.
+var mediaExtensions = map[string]bool{
+	".mp3": true, ".wav": true, ".m4a": true, ".ogg": true, ".oga": true,
+	".opus": true, ".flac": true, ".aac": true,
+	".mp4": true, ".m4v": true, ".mkv": true, ".webm": true, ".mov": true,
+	".avi": true,
+}
+
+// errTranscriptionUnsupported is returned for audio and video uploads the
+// connected worker cannot transcribe.
+var errTranscriptionUnsupported = errors.New("the connected worker cannot transcribe audio or video; install the worker's transcription extra and enable TRANSCRIPTION_ENABLED")
+
+// checkMediaUpload returns errTranscriptionUnsupported for an audio or
+// video extension when the worker lacks transcription.
+func checkMediaUpload(gc *grpcclient.Client, ext string) error {
+	if mediaExtensions[ext] && !gc.Worker().HasFeature(grpcclient.FeatureTranscription) {
+		return errTranscriptionUnsupported
+	}
+	return nil
+}
+
+// transcriptRange returns the time range of a transcript hit in seconds,
+// and false for other hits.
+func transcriptRange(hit *grpcclient.SearchHit) (int, int, bool) {
+	if hit.GetLanguage() != transcriptLanguage {
+		return 0, 0, false
+	}
+	start, end := parseLineRange(hit.GetLines())
+	return start, end, true
+}
+
+// mediaFragment returns the media fragment (#t=start,end) that makes
+// browsers play a recording from start to end.
+func mediaFragment(start, end int) string {
+	if end > start {
+		return fmt.Sprintf("#t=%d,%d", start, end)
+	}
+	return fmt.Sprintf("#t=%d", start)
+}
+
+// formatTimestamp formats seconds as M:SS, or H:MM:SS from an hour on,
+// like the timestamps in transcript chunks.
+func formatTimestamp(seconds int) string {
+	h, m, s := seconds/3600, seconds/60%60, seconds%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// mediaRange returns the time range of a transcript hit with a signed URL
+// playing it, when the recording is in the upload directory, or nil for
+// other hits.
+func (s *ImageSigner) mediaRange(r *http.Request, hit *grpcclient.SearchHit) *MediaRange {
+	start, end, ok := transcriptRange(hit)
+	if !ok {
+		return nil
+	}
+	m := &MediaRange{Start: start, End: end}
+	if s == nil {
+		return m
+	}
+	file := hit.GetAbsPath()
+	if file == "" {
+		file = hit.GetFilePath()
+	}
+	if p, ok := s.PathFor(file); ok {
+		m.URL = middleware.ExternalURL(r, p) + mediaFragment(start, end)
+	}
+	return m
+}
@@ -2,19 +2,22 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/alfagnish/ollqd-gateway/internal/config"
 	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/imagemeta"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
 	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
 	"github.com/go-chi/chi/v5"
-	"github.com/google/uuid"
 )
 
 // allowedExtensions is the set of file extensions accepted for upload.
@@ -50,6 +53,34 @@ var allowedExtensions = map[string]bool{
 	".odt":  true,
 	".rtf":  true,
 	".csv":  true,
+	".tsv":  true,
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".webp": true,
+	".svg":  true,
+	".bmp":  true,
+	".tiff": true,
+	".mp3":  true,
+	".wav":  true,
+	".m4a":  true,
+	".ogg":  true,
+	".oga":  true,
+	".opus": true,
+	".flac": true,
+	".aac":  true,
+	".mp4":  true,
+	".m4v":  true,
+	".mkv":  true,
+	".webm": true,
+	".mov":  true,
+	".avi":  true,
+}
+
+// imageExtensions is the subset of allowedExtensions served back through the
+// image endpoint.
+var imageExtensions = map[string]bool{
 	".png":  true,
 	".jpg":  true,
 	".jpeg": true,
@@ -63,42 +94,64 @@ var allowedExtensions = map[string]bool{
 // UploadHandler handles multipart file uploads and triggers background
 // indexing of the uploaded files.
 type UploadHandler struct {
-	cfg  *config.Config
-	grpc *grpcclient.Client
-	tm   *tasks.Manager
+	cfg   *config.Config
+	grpc  *grpcclient.Client
+	tm    *tasks.Manager
+	colls *CollectionSettings
+	meta  *imagemeta.Attacher
+
+	// routing sends uploads to pipelines by file type and source tag.
+	routing *UploadRouting
+
+	// qdrant scrolls point payloads when looking for orphaned uploads.
+	qdrant *SourcesHandler
+
+	// images signs the URLs of uploaded images.
+	images *ImageSigner
+
+	// guard refuses uploads when disk or memory is short.
+	guard *Guardrails
 }
 
-// NewUploadHandler creates a new UploadHandler.
-func NewUploadHandler(cfg *config.Config, gc *grpcclient.Client, tm *tasks.Manager) *UploadHandler {
-	return &UploadHandler{cfg: cfg, grpc: gc, tm: tm}
+// NewUploadHandler creates a new UploadHandler. Uploaded images are indexed
+// with the EXIF and format metadata meta extracts, and files are routed to
+// pipelines by routing. Orphaned uploads are found through sources. The
+// response links uploaded images with URLs signed by images. Uploads are
+// checked against guard before anything is written.
+func NewUploadHandler(cfg *config.Config, gc *grpcclient.Client, tm *tasks.Manager, colls *CollectionSettings, meta *imagemeta.Attacher, routing *UploadRouting, sources *SourcesHandler, images *ImageSigner, guard *Guardrails) *UploadHandler {
+	return &UploadHandler{cfg: cfg, grpc: gc, tm: tm, colls: colls, meta: meta, routing: routing, qdrant: sources, images: images, guard: guard}
 }
 
 // Routes registers upload routes.
 func (h *UploadHandler) Routes(r chi.Router) {
 	r.Post("/", h.Upload)
+	r.Post("/url", h.UploadFromURL)
+	r.With(requireWorker(h.grpc, grpcclient.ServicePreview)).Post("/preview", h.Preview)
+	r.Get("/orphans", h.ListOrphans)
+	r.Delete("/orphans", h.DeleteOrphans)
 }
 
-// Upload parses the multipart form, validates file extensions and sizes,
-// saves files to UPLOAD_DIR, and starts a background gRPC IndexUploads stream.
+// maxUploadFieldBytes bounds the value of a non-file upload form field.
+const maxUploadFieldBytes = 64 << 10
+
+// Upload reads the multipart form as it arrives, validates file extensions
+// and sizes, saves files to UPLOAD_DIR, and starts background gRPC
+// IndexUploads streams. When every form field comes before the files,
+// indexing starts with the first saved file and each later file is handed
+// to the running task as soon as it is saved; otherwise indexing starts
+// once the whole upload is saved.
 func (h *UploadHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	maxBytes := h.cfg.MaxUploadSizeMB << 20 // convert MB to bytes
 	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 
-	if err := r.ParseMultipartForm(maxBytes); err != nil {
-		writeError(w, http.StatusRequestEntityTooLarge,
-			fmt.Sprintf("upload exceeds maximum size of %d MB", h.cfg.MaxUploadSizeMB))
+	warnings, ok := h.guard.admit(r.Context(), w, max(r.ContentLength, 0))
+	if !ok {
 		return
 	}
 
-	// Read optional form fields.
-	collection := r.FormValue("collection")
-	sourceTag := r.FormValue("source_tag")
-	visionModel := r.FormValue("vision_model")
-	captionPrompt := r.FormValue("caption_prompt")
-
-	files := r.MultipartForm.File["files"]
-	if len(files) == 0 {
-		writeError(w, http.StatusBadRequest, "no files provided in 'files' field")
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "expected a multipart/form-data body")
 		return
 	}
 
@@ -108,127 +161,517 @@ func (h *UploadHandler) Upload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dest := h.newUploadDest()
+	fields := map[string]string{}
+	var pipe *uploadPipeline
 	var savedPaths []string
 	var savedNames []string
+	imageURLs := map[string]string{}
+	fail := func(status int, msg string) {
+		if pipe != nil {
+			pipe.abort()
+		}
+		writeError(w, status, msg)
+	}
 
-	for _, fh := range files {
-		ext := strings.ToLower(filepath.Ext(fh.Filename))
-		if !allowedExtensions[ext] {
-			writeError(w, http.StatusBadRequest,
-				fmt.Sprintf("file extension %s is not allowed", ext))
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fail(uploadReadStatus(err), h.uploadReadError(err))
 			return
 		}
 
-		// Generate a unique filename to prevent collisions.
-		destName := uuid.New().String() + ext
-		destPath := filepath.Join(h.cfg.UploadDir, destName)
+		if part.FormName() != "files" || part.FileName() == "" {
+			if pipe != nil {
+				part.Close()
+				fail(http.StatusBadRequest, fmt.Sprintf("form field %s must come before the files", part.FormName()))
+				return
+			}
+			value, err := io.ReadAll(io.LimitReader(part, maxUploadFieldBytes+1))
+			part.Close()
+			if err != nil {
+				fail(uploadReadStatus(err), h.uploadReadError(err))
+				return
+			}
+			if len(value) > maxUploadFieldBytes {
+				fail(http.StatusBadRequest, fmt.Sprintf("form field %s is too long", part.FormName()))
+				return
+			}
+			if _, seen := fields[part.FormName()]; !seen {
+				fields[part.FormName()] = string(value)
+			}
+			continue
+		}
+
+		// Pipeline the upload if its settings are known before its files.
+		if len(savedPaths) == 0 && len(fields) > 0 && h.grpc.Indexing != nil && h.grpc.Supports(grpcclient.ServiceIndexing) {
+			opts, err := parseUploadOptions(fields, h.grpc)
+			if err != nil {
+				part.Close()
+				writeTableOptionsError(w, err)
+				return
+			}
+			opts.Warnings = warnings
+			opts.Provenance = uploadProvenance(r)
+			pipe = h.newUploadPipeline(opts)
+		}
 
-		src, err := fh.Open()
+		filename := part.FileName()
+		ext := strings.ToLower(filepath.Ext(filename))
+		if !allowedExtensions[ext] {
+			part.Close()
+			fail(http.StatusBadRequest, fmt.Sprintf("file extension %s is not allowed", ext))
+			return
+		}
+		if err := checkMediaUpload(h.grpc, ext); err != nil {
+			part.Close()
+			if pipe != nil {
+				pipe.abort()
+			}
+			writeErrorCode(w, http.StatusNotImplemented, CodeWorkerUnsupported, err.Error())
+			return
+		}
+
+		destName, err := dest.next(filename, ext)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, "failed to read uploaded file")
+			part.Close()
+			fail(http.StatusInternalServerError, "failed to create upload directory")
 			return
 		}
+		destPath := filepath.Join(h.cfg.UploadDir, filepath.FromSlash(destName))
 
 		dst, err := os.Create(destPath)
 		if err != nil {
-			src.Close()
-			writeError(w, http.StatusInternalServerError, "failed to save uploaded file")
+			part.Close()
+			fail(http.StatusInternalServerError, "failed to save uploaded file")
+			return
+		}
+		_, err = io.Copy(dst, part)
+		part.Close()
+		if cerr := dst.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(destPath)
+			if uploadReadStatus(err) == http.StatusRequestEntityTooLarge {
+				fail(http.StatusRequestEntityTooLarge, h.uploadReadError(err))
+			} else {
+				fail(http.StatusInternalServerError, "failed to write uploaded file")
+			}
 			return
 		}
 
-		if _, err := io.Copy(dst, src); err != nil {
-			src.Close()
-			dst.Close()
-			writeError(w, http.StatusInternalServerError, "failed to write uploaded file")
+		savedPaths = append(savedPaths, destPath)
+		savedNames = append(savedNames, filename)
+		if imageExtensions[ext] {
+			imageURLs[filename] = middleware.ExternalURL(r, h.images.Path(destName))
+		}
+		if pipe != nil && !pipe.add(w, destPath, filename) {
 			return
 		}
+	}
 
-		src.Close()
-		dst.Close()
+	if len(savedPaths) == 0 {
+		writeError(w, http.StatusBadRequest, "no files provided in 'files' field")
+		return
+	}
+	if pipe != nil {
+		pipe.finish(w, savedNames, imageURLs)
+		return
+	}
 
-		savedPaths = append(savedPaths, destPath)
-		savedNames = append(savedNames, fh.Filename)
+	opts, err := parseUploadOptions(fields, h.grpc)
+	if err != nil {
+		writeTableOptionsError(w, err)
+		return
+	}
+	opts.Warnings = warnings
+	opts.Provenance = uploadProvenance(r)
+	h.startIndexing(w, opts, savedPaths, savedNames, imageURLs)
+}
+
+// parseUploadOptions reads the indexing settings from the form fields of
+// an upload. Table options are checked against the worker behind gc.
+func parseUploadOptions(fields map[string]string, gc *grpcclient.Client) (uploadOptions, error) {
+	opts := uploadOptions{
+		Collection:    fields["collection"],
+		SourceTag:     fields["source_tag"],
+		VisionModel:   fields["vision_model"],
+		CaptionPrompt: fields["caption_prompt"],
+	}
+	var err error
+	if opts.Priority, err = tasks.ParsePriority(fields["priority"]); err != nil {
+		return opts, err
+	}
+	if opts.Lock, err = tasks.ParseLockMode(fields["lock"]); err != nil {
+		return opts, err
+	}
+	if opts.Route, err = parseRouteParam(fields["routing"]); err != nil {
+		return opts, err
+	}
+	if opts.Table, err = parseTableFields(fields); err != nil {
+		return opts, err
+	}
+	if err := checkTableOptions(gc, opts.Table); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// uploadReadStatus is the status answering a failure to read an upload
+// body: 413 if it exceeded the size limit, else 400.
+func uploadReadStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// uploadReadError describes a failure to read an upload body.
+func (h *UploadHandler) uploadReadError(err error) string {
+	if uploadReadStatus(err) == http.StatusRequestEntityTooLarge {
+		return fmt.Sprintf("upload exceeds maximum size of %d MB", h.cfg.MaxUploadSizeMB)
+	}
+	return fmt.Sprintf("invalid multipart body: %v", err)
+}
+
+// uploadOptions are the indexing settings shared by all upload sources.
+type uploadOptions struct {
+	Collection    string
+	SourceTag     string
+	VisionModel   string
+	CaptionPrompt string
+	Priority      tasks.Priority
+	Lock          tasks.LockMode
+	// Table chunks spreadsheets of the documents pipeline as tables.
+	Table *grpcclient.TableOptions
+	// Route applies the upload routing rules; files no rule matches, and
+	// all files when Route is false, use the options above.
+	Route bool
+	// Warnings are the guardrail problems the upload was accepted despite.
+	Warnings []string
+	// Provenance is recorded on the points of every task of the upload.
+	// Each task sets its own TaskID and keeps the Sources of its files.
+	Provenance grpcclient.Provenance
+}
+
+// parseRouteParam parses the "routing" field of an upload; empty means on.
+func parseRouteParam(s string) (bool, error) {
+	if s == "" {
+		return true, nil
+	}
+	on, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("invalid routing %q (want true or false)", s)
+	}
+	return on, nil
+}
+
+// uploadTask is one indexing task started for an upload: the files one
+// routing rule matched, or the files none matched.
+type uploadTask struct {
+	rule  *RoutingRule
+	paths []string
+	names []string
+
+	collection    string
+	visionModel   string
+	captionPrompt string
+	ocr           *bool
+	id            string
+}
+
+// pipeline names the pipeline the task runs.
+func (t *uploadTask) pipeline() string {
+	if t.rule == nil {
+		return pipelineUploads
+	}
+	return t.rule.Pipeline
+}
+
+// planUploadTasks groups the saved files by the routing rule matching them,
+// of cfg, in rule order, with unrouted files last.
+func (h *UploadHandler) planUploadTasks(opts uploadOptions, cfg RoutingConfig, savedPaths, savedNames []string) []*uploadTask {
+	groups := map[*RoutingRule]*uploadTask{}
+	for i, p := range savedPaths {
+		rule := cfg.route(savedNames[i], opts.SourceTag)
+		g := groups[rule]
+		if g == nil {
+			g = h.newUploadTask(opts, rule)
+			groups[rule] = g
+		}
+		g.paths = append(g.paths, p)
+		g.names = append(g.names, savedNames[i])
+	}
+
+	var out []*uploadTask
+	for i := range cfg.Rules {
+		g := groups[&cfg.Rules[i]]
+		if g == nil {
+			continue
+		}
+		out = append(out, g)
+	}
+	if g := groups[nil]; g != nil {
+		out = append(out, g)
+	}
+	return out
+}
+
+// uploadRouting returns the routing rules an upload applies: none unless
+// opts.Route is set.
+func (h *UploadHandler) uploadRouting(opts uploadOptions) RoutingConfig {
+	if !opts.Route {
+		return RoutingConfig{}
+	}
+	return h.routing.Get()
+}
+
+// newUploadTask returns an empty task for the files rule matches, or for
+// unrouted files if rule is nil, with the settings the rule overrides.
+func (h *UploadHandler) newUploadTask(opts uploadOptions, rule *RoutingRule) *uploadTask {
+	t := &uploadTask{rule: rule, visionModel: opts.VisionModel, captionPrompt: opts.CaptionPrompt}
+	if rule == nil {
+		t.collection, _, _ = h.colls.ResolveIndex(opts.Collection, 0, 0)
+		return t
+	}
+	t.collection = rule.collection()
+	if rule.VisionModel != "" {
+		t.visionModel = rule.VisionModel
 	}
+	if rule.CaptionPrompt != "" {
+		t.captionPrompt = rule.CaptionPrompt
+	}
+	t.ocr = rule.OCR
+	return t
+}
+
+// uploadRel returns the slash path of a saved upload relative to
+// UPLOAD_DIR, the root the codebase pipeline indexes from.
+func (h *UploadHandler) uploadRel(p string) string {
+	rel, err := filepath.Rel(h.cfg.UploadDir, p)
+	if err != nil {
+		return filepath.Base(p)
+	}
+	return filepath.ToSlash(rel)
+}
 
+// startIndexing launches the background indexing tasks for files already
+// saved to UPLOAD_DIR, one per routing rule that matched, and writes the
+// response.
+func (h *UploadHandler) startIndexing(w http.ResponseWriter, opts uploadOptions, savedPaths, savedNames []string, imageURLs map[string]string) {
 	// If no gRPC indexing service, just report saved files.
-	if h.grpc.Indexing == nil {
+	if h.grpc.Indexing == nil || !h.grpc.Supports(grpcclient.ServiceIndexing) {
 		writeJSON(w, http.StatusOK, map[string]interface{}{
 			"saved":   savedNames,
 			"count":   len(savedPaths),
 			"message": "files saved but indexing service unavailable",
+			"urls":    imageURLs,
 		})
 		return
 	}
 
-	// Create a background indexing task.
+	// Create and lock every task before queueing any, so that a locked
+	// collection leaves none of them running.
+	planned := h.planUploadTasks(opts, h.uploadRouting(opts), savedPaths, savedNames)
+	locked := map[string]bool{}
+	for i, t := range planned {
+		if !h.createAndLock(w, opts, t, locked) {
+			for _, prev := range planned[:i] {
+				h.tm.Fail(prev.id, "another task of the same upload could not lock its collection")
+			}
+			return
+		}
+	}
+	for _, t := range planned {
+		h.enqueueUploadTask(opts, t)
+	}
+	h.writeUploadAccepted(w, opts, planned, savedNames, imageURLs, false)
+}
+
+// createAndLock creates the task record of t and locks its collection.
+// Tasks sharing a collection are covered by the lock of the first; locked
+// holds the collections locked so far. On failure it fails the task,
+// writes the 423 response and returns false.
+func (h *UploadHandler) createAndLock(w http.ResponseWriter, opts uploadOptions, t *uploadTask, locked map[string]bool) bool {
+	t.id = h.createUploadTask(opts, t)
+	h.tm.AddWarnings(t.id, opts.Warnings)
+	target := t.collection
+	if target == "" {
+		target = workerDefaultDocumentsCollection
+	}
+	lock := opts.Lock
+	if locked[target] {
+		lock = tasks.LockNone
+	}
+	if !lockIndexTarget(w, h.tm, t.id, target, workerDefaultDocumentsCollection, lock) {
+		return false
+	}
+	locked[target] = true
+	return true
+}
+
+// writeUploadAccepted writes the 202 response listing the tasks started
+// for an upload; pipelined marks tasks that started while it was received.
+func (h *UploadHandler) writeUploadAccepted(w http.ResponseWriter, opts uploadOptions, planned []*uploadTask, savedNames []string, imageURLs map[string]string, pipelined bool) {
+	routes := make([]api.UploadRoute, 0, len(planned))
+	for _, t := range planned {
+		route := api.UploadRoute{
+			TaskID:     t.id,
+			Status:     taskStartStatus(h.tm, t.id),
+			Pipeline:   t.pipeline(),
+			Collection: t.collection,
+			Files:      t.names,
+		}
+		if t.rule != nil {
+			route.Rule = t.rule.Name
+		}
+		routes = append(routes, route)
+	}
+
+	resp := api.UploadAccepted{
+		TaskID:    planned[0].id,
+		Status:    taskStartStatus(h.tm, planned[0].id),
+		Files:     savedNames,
+		Count:     len(savedNames),
+		URLs:      imageURLs,
+		Routes:    routes,
+		Pipelined: pipelined,
+		Warnings:  opts.Warnings,
+	}
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// createUploadTask creates the task record for t. Codebase tasks keep the
+// saved paths too, so orphan cleanup sees them as pending. The provenance
+// source type and sources are kept for retries.
+func (h *UploadHandler) createUploadTask(opts uploadOptions, t *uploadTask) string {
+	if t.pipeline() == PipelineCodebase {
+		params := map[string]interface{}{
+			"root_path":     h.cfg.UploadDir,
+			"collection":    t.collection,
+			"incremental":   false,
+			"files":         h.codebaseFiles(t),
+			"saved_paths":   t.paths,
+			"display_names": t.names,
+			"route":         t.rule.Name,
+			"priority":      string(opts.Priority),
+			"lock":          string(opts.Lock),
+			"source_type":   opts.Provenance.SourceType,
+		}
+		if sources := provenanceSources(opts.Provenance.Sources, t.paths); sources != nil {
+			params["sources"] = sources
+		}
+		return h.tm.Create("index_codebase", params)
+	}
+
 	params := map[string]interface{}{
-		"saved_paths":    savedPaths,
-		"collection":     collection,
-		"source_tag":     sourceTag,
-		"vision_model":   visionModel,
-		"caption_prompt": captionPrompt,
+		"saved_paths":    t.paths,
+		"display_names":  t.names,
+		"collection":     t.collection,
+		"source_tag":     opts.SourceTag,
+		"vision_model":   t.visionModel,
+		"caption_prompt": t.captionPrompt,
+		"priority":       string(opts.Priority),
+		"lock":           string(opts.Lock),
+		"source_type":    opts.Provenance.SourceType,
+	}
+	if sources := provenanceSources(opts.Provenance.Sources, t.paths); sources != nil {
+		params["sources"] = sources
+	}
+	if t.rule != nil {
+		params["route"] = t.rule.Name
 	}
+	if t.ocr != nil {
+		params["ocr"] = *t.ocr
+	}
+	if opts.Table != nil {
+		params["table"] = opts.Table
+	}
+	return h.tm.Create("index_uploads", params)
+}
 
-	taskID := h.tm.Create("index_uploads", params)
-	h.tm.Start(taskID)
+// codebaseFiles returns the files of t relative to UPLOAD_DIR.
+func (h *UploadHandler) codebaseFiles(t *uploadTask) []string {
+	files := make([]string, len(t.paths))
+	for i, p := range t.paths {
+		files[i] = h.uploadRel(p)
+	}
+	return files
+}
 
+// enqueueUploadTask queues the indexing stream of t.
+func (h *UploadHandler) enqueueUploadTask(opts uploadOptions, t *uploadTask) {
 	ctx, cancel := context.WithCancel(context.Background())
-	h.tm.SetCancelFunc(taskID, cancel)
-
-	go func() {
-		stream, err := h.grpc.Indexing.IndexUploads(ctx, &grpcclient.IndexUploadsRequest{
-			SavedPaths:    savedPaths,
-			Collection:    collection,
-			SourceTag:     sourceTag,
-			VisionModel:   visionModel,
-			CaptionPrompt: captionPrompt,
+	h.tm.SetCancelFunc(t.id, cancel)
+
+	if t.pipeline() == PipelineCodebase {
+		files := h.codebaseFiles(t)
+		names := displayNameMap(files, t.names)
+		h.tm.Enqueue(t.id, opts.Priority, func() {
+			consumeNamedIndex(ctx, h.tm, h.grpc, t.id, files, names, func(ctx context.Context, batch []string) (grpcclient.IndexingStream, error) {
+				mctx := withUploadProvenance(withDisplayNames(ctx, batch, names), opts.Provenance, t.id, t.paths)
+				return h.grpc.Indexing.IndexCodebase(mctx, &grpcclient.IndexCodebaseRequest{
+					RootPath:   h.cfg.UploadDir,
+					Collection: t.collection,
+					Files:      batch,
+				})
+			})
 		})
-		if err != nil {
-			h.tm.Fail(taskID, fmt.Sprintf("failed to open stream: %v", err))
-			return
-		}
-		defer stream.Close()
+		return
+	}
 
-		for {
-			select {
-			case <-ctx.Done():
-				h.tm.Fail(taskID, "cancelled")
-				return
-			default:
-			}
+	names := displayNameMap(t.paths, t.names)
+	h.tm.Enqueue(t.id, opts.Priority, func() {
+		mctx, cleanup := h.meta.AttachFiles(ctx, t.paths)
+		defer cleanup()
+		mctx = grpcclient.WithDoclingOCR(mctx, t.ocr)
+		mctx = grpcclient.WithTableOptions(mctx, opts.Table)
+		consumeNamedIndex(mctx, h.tm, h.grpc, t.id, t.paths, names, func(ctx context.Context, batch []string) (grpcclient.IndexingStream, error) {
+			pctx := withUploadProvenance(withDisplayNames(ctx, batch, names), opts.Provenance, t.id, batch)
+			return h.grpc.Indexing.IndexUploads(pctx, &grpcclient.IndexUploadsRequest{
+				SavedPaths:    batch,
+				Collection:    t.collection,
+				SourceTag:     opts.SourceTag,
+				VisionModel:   t.visionModel,
+				CaptionPrompt: t.captionPrompt,
+			})
+		})
+	})
+}
 
-			progress, err := stream.Recv()
-			if err != nil {
-				if err == io.EOF {
-					h.tm.Complete(taskID, nil)
-				} else {
-					h.tm.Fail(taskID, fmt.Sprintf("stream error: %v", err))
-				}
-				return
-			}
+// uploadProvenance returns the provenance of a multipart upload by the
+// user of r.
+func uploadProvenance(r *http.Request) grpcclient.Provenance {
+	return grpcclient.Provenance{
+		SourceType: grpcclient.SourceUpload,
+		Uploader:   middleware.UsernameFromContext(r.Context()),
+	}
+}
 
-			switch progress.Status {
-			case "running":
-				h.tm.UpdateProgress(taskID, float64(progress.Progress), "running")
-			case "completed":
-				h.tm.Complete(taskID, progress.Result)
-				return
-			case "failed":
-				h.tm.Fail(taskID, progress.Error)
-				return
-			case "cancelled":
-				h.tm.Cancel(taskID)
-				return
-			default:
-				log.Printf("[upload task %s] unknown status: %s", taskID, progress.Status)
+// withUploadProvenance attaches the provenance of the upload task id
+// indexing the saved files paths.
+func withUploadProvenance(ctx context.Context, p grpcclient.Provenance, id string, paths []string) context.Context {
+	p.TaskID = id
+	p.Sources = provenanceSources(p.Sources, paths)
+	return grpcclient.WithProvenance(ctx, p)
+}
+
+// provenanceSources returns the entries of sources for paths, or nil if
+// there are none.
+func provenanceSources(sources map[string]string, paths []string) map[string]string {
+	var out map[string]string
+	for _, p := range paths {
+		if src, ok := sources[p]; ok {
+			if out == nil {
+				out = map[string]string{}
 			}
+			out[p] = src
 		}
-	}()
-
-	writeJSON(w, http.StatusAccepted, map[string]interface{}{
-		"task_id": taskID,
-		"status":  "started",
-		"files":   savedNames,
-		"count":   len(savedPaths),
-	})
+	}
+	return out
 }
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+// defaultOrphanMinAge protects fresh uploads whose indexing task has not
+// written any points yet.
+const defaultOrphanMinAge = time.Hour
+
+// orphanFile is one file in UPLOAD_DIR that no collection point references.
+type orphanFile struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// ListOrphans reports uploaded files that no point in any collection
+// references any more. Nothing is deleted.
+func (h *UploadHandler) ListOrphans(w http.ResponseWriter, r *http.Request) {
+	orphans, minAge, ok := h.orphans(w, r)
+	if !ok {
+		return
+	}
+	var total int64
+	for _, o := range orphans {
+		total += o.Size
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"orphans":     orphans,
+		"count":       len(orphans),
+		"total_bytes": total,
+		"min_age":     minAge.String(),
+	})
+}
+
+// DeleteOrphans removes the files ListOrphans would report.
+func (h *UploadHandler) DeleteOrphans(w http.ResponseWriter, r *http.Request) {
+	orphans, minAge, ok := h.orphans(w, r)
+	if !ok {
+		return
+	}
+	deleted := []string{}
+	failed := map[string]string{}
+	var freed int64
+	for _, o := range orphans {
+		full := filepath.Join(h.cfg.UploadDir, filepath.FromSlash(o.Name))
+		if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+			failed[o.Name] = err.Error()
+			continue
+		}
+		deleted = append(deleted, o.Name)
+		freed += o.Size
+		h.removeEmptyDirs(filepath.Dir(full))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"deleted":     deleted,
+		"failed":      failed,
+		"count":       len(deleted),
+		"freed_bytes": freed,
+		"min_age":     minAge.String(),
+	})
+}
+
+// orphans parses ?min_age= and collects the orphaned uploads, writing the
+// error response itself when it fails.
+func (h *UploadHandler) orphans(w http.ResponseWriter, r *http.Request) ([]orphanFile, time.Duration, bool) {
+	minAge := defaultOrphanMinAge
+	if v := r.URL.Query().Get("min_age"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			writeError(w, http.StatusBadRequest, "min_age must be a non-negative duration like 30m or 24h")
+			return nil, 0, false
+		}
+		minAge = d
+	}
+
+	candidates, err := h.uploadCandidates(minAge)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to scan upload dir: %v", err))
+		return nil, 0, false
+	}
+	if len(candidates) == 0 {
+		return []orphanFile{}, minAge, true
+	}
+
+	// A single unreadable collection would make its files look orphaned,
+	// so any Qdrant error aborts the whole scan.
+	referenced, err := h.referencedUploads(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("qdrant error: %v", err))
+		return nil, 0, false
+	}
+	for _, p := range h.pendingUploads() {
+		referenced[h.uploadKey(p)] = true
+	}
+
+	out := []orphanFile{}
+	for _, c := range candidates {
+		if !referenced[c.Name] && !referenced[path.Base(c.Name)] {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, minAge, true
+}
+
+// uploadCandidates lists the files in UPLOAD_DIR older than minAge. Dot
+// directories hold gateway caches (.preview, .thumbs, .imagemeta) and are
+// skipped.
+func (h *UploadHandler) uploadCandidates(minAge time.Duration) ([]orphanFile, error) {
+	root := h.cfg.UploadDir
+	cutoff := time.Now().Add(-minAge)
+	var out []orphanFile
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != root {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		out = append(out, orphanFile{Name: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	return out, err
+}
+
+// referencedUploads returns the upload keys of every file_path and abs_path
+// in every collection.
+func (h *UploadHandler) referencedUploads(ctx context.Context) (map[string]bool, error) {
+	collections, err := listQdrantCollections(ctx, h.qdrant.client, h.qdrant.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool)
+	for _, coll := range collections {
+		err := h.qdrant.scrollPayloads(ctx, coll, []string{"file_path", "abs_path"}, func(payload map[string]interface{}) {
+			for _, key := range []string{"file_path", "abs_path"} {
+				if p, _ := payload[key].(string); p != "" {
+					out[h.uploadKey(p)] = true
+				}
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", coll, err)
+		}
+	}
+	return out, nil
+}
+
+// pendingUploads returns the saved paths of upload tasks that have not
+// finished yet, whose points may not exist so far.
+func (h *UploadHandler) pendingUploads() []string {
+	var out []string
+	for _, t := range h.tm.List() {
+		if t.Status != tasks.StatusPending && t.Status != tasks.StatusRunning && t.Status != tasks.StatusReconnecting {
+			continue
+		}
+		if params, ok := h.tm.RawParams(t.ID); ok {
+			out = append(out, stringSliceParam(params, "saved_paths")...)
+		}
+	}
+	return out
+}
+
+// uploadKey identifies a saved upload referenced by path: its slash path
+// relative to UPLOAD_DIR, or its base name for paths outside it (a worker
+// mounting the uploads volume elsewhere). Flat uploads have unique names,
+// so the base name matches them; a preserved name matching by base name
+// alone keeps more files than needed, never fewer.
+func (h *UploadHandler) uploadKey(p string) string {
+	rel, err := filepath.Rel(h.cfg.UploadDir, p)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return filepath.Base(p)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// removeEmptyDirs removes dir and its parents below UPLOAD_DIR while they
+// are empty, cleaning up the directories of preserved-name uploads.
+func (h *UploadHandler) removeEmptyDirs(dir string) {
+	root := filepath.Clean(h.cfg.UploadDir)
+	for dir = filepath.Clean(dir); strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alfagnish/ollqd-gateway/internal/config"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/google/uuid"
+)
+
+// Limits for preserved names.
+const (
+	maxUploadNameDepth   = 8
+	maxUploadSegmentSize = 120
+)
+
+// uploadDest hands out destination paths for the files of one upload
+// request.
+type uploadDest struct {
+	root     string
+	preserve bool
+	batch    string          // per-request directory, preserve mode only
+	used     map[string]bool // lowercased relative paths already handed out
+}
+
+// newUploadDest returns the destination allocator for one request.
+func (h *UploadHandler) newUploadDest() *uploadDest {
+	return &uploadDest{
+		root:     h.cfg.UploadDir,
+		preserve: h.cfg.UploadFilenames == config.UploadNamesPreserve,
+		batch:    uuid.New().String(),
+		used:     make(map[string]bool),
+	}
+}
+
+// next returns the slash path relative to UPLOAD_DIR to save a file called
+// original (as sent by the client) under, creating its directory. ext is
+// the validated, lowercased extension.
+func (d *uploadDest) next(original, ext string) (string, error) {
+	if !d.preserve {
+		return uuid.New().String() + ext, nil
+	}
+
+	rel := d.unique(sanitizeUploadName(original, ext))
+	full := filepath.Join(d.root, d.batch, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return "", err
+	}
+	return d.batch + "/" + rel, nil
+}
+
+// unique appends " (2)", " (3)", ... before the extension while rel
+// collides, case-insensitively, with a path already handed out.
+func (d *uploadDest) unique(rel string) string {
+	ext := path.Ext(rel)
+	stem := strings.TrimSuffix(rel, ext)
+	candidate := rel
+	for n := 2; d.used[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+	}
+	d.used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// sanitizeUploadName turns a client-supplied file name, which may carry
+// directories ("docs/specs/api.md"), into a safe relative slash path:
+// "." and ".." segments and leading dots are dropped, characters outside
+// letters, digits, space and "._-()+,@" become "_", segments are bounded in
+// length and the extension is forced to ext.
+func sanitizeUploadName(name, ext string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' })
+	var segs []string
+	for _, p := range parts {
+		p = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(" ._-()+,@", r) {
+				return r
+			}
+			return '_'
+		}, p)
+		p = strings.TrimLeft(strings.TrimSpace(p), ".")
+		if p == "" {
+			continue
+		}
+		segs = append(segs, p)
+	}
+	if len(segs) > maxUploadNameDepth {
+		segs = segs[len(segs)-maxUploadNameDepth:]
+	}
+
+	base := "file"
+	if len(segs) > 0 {
+		base = segs[len(segs)-1]
+		segs = segs[:len(segs)-1]
+	}
+	stem := strings.TrimSpace(strings.TrimSuffix(base, filepath.Ext(base)))
+	if stem == "" {
+		stem = "file"
+	}
+	stem = truncateUTF8(stem, maxUploadSegmentSize-len(ext))
+	for i, s := range segs {
+		segs[i] = strings.TrimSpace(truncateUTF8(s, maxUploadSegmentSize))
+	}
+	return path.Join(append(segs, stem+ext)...)
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// displayNameMap pairs saved upload files with their original names, given
+// as parallel slices. It returns nil if the slices do not match.
+func displayNameMap(savedPaths, names []string) map[string]string {
+	if len(names) != len(savedPaths) {
+		return nil
+	}
+	m := make(map[string]string, len(names))
+	for i, p := range savedPaths {
+		m[p] = names[i]
+	}
+	return m
+}
+
+// withDisplayNames attaches the original names, of names keyed by saved
+// path, of the files of one IndexUploads or IndexCodebase call.
+func withDisplayNames(ctx context.Context, files []string, names map[string]string) context.Context {
+	m := make(map[string]string, len(files))
+	for _, p := range files {
+		if name, ok := names[p]; ok {
+			m[p] = name
+		}
+	}
+	ctx, ok := grpcclient.WithDisplayNames(ctx, m)
+	if !ok {
+		log.Printf("WARNING: %d upload display names exceed the metadata limit; not sent", len(m))
+	}
+	return ctx
+}
+
+// consumeNamedIndex runs the index task taskID over files with open, in one
+// stream if the display names of the files fit the metadata of one call,
+// and otherwise in batches that do, so no name is dropped.
+func consumeNamedIndex(ctx context.Context, tm *tasks.Manager, gc *grpcclient.Client, taskID string, files []string, names map[string]string, open func(ctx context.Context, batch []string) (grpcclient.IndexingStream, error)) {
+	batches := grpcclient.SplitDisplayNames(files, names)
+	if len(batches) <= 1 {
+		tm.ConsumeIndexStream(ctx, gc, taskID, func() (grpcclient.IndexingStream, error) {
+			return open(ctx, files)
+		})
+		return
+	}
+	tm.ConsumeIndexBatches(ctx, gc, taskID, func(context.Context) ([]string, bool) {
+		if len(batches) == 0 {
+			return nil, false
+		}
+		batch := batches[0]
+		batches = batches[1:]
+		return batch, true
+	}, open)
+}
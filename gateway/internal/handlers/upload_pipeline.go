@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+)
+
+// uploadBatchFiles bounds the files one IndexUploads stream of a pipelined
+// upload is given; files saved meanwhile wait for the next stream.
+const uploadBatchFiles = 64
+
+// uploadFeed hands the files of a pipelined upload task to its indexing
+// stream as they are saved.
+type uploadFeed struct {
+	mu     sync.Mutex
+	paths  []string
+	names  map[string]string // saved path -> original name
+	sent   int
+	closed bool
+	ready  chan struct{}
+}
+
+func newUploadFeed() *uploadFeed {
+	return &uploadFeed{names: map[string]string{}, ready: make(chan struct{}, 1)}
+}
+
+// add queues a saved file for indexing.
+func (f *uploadFeed) add(path, name string) {
+	f.mu.Lock()
+	if !f.closed {
+		f.paths = append(f.paths, path)
+		f.names[path] = name
+	}
+	f.mu.Unlock()
+	f.wake()
+}
+
+// close tells the task no more files will come.
+func (f *uploadFeed) close() {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+	f.wake()
+}
+
+func (f *uploadFeed) wake() {
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+}
+
+// next waits for files not yet handed out and returns up to
+// uploadBatchFiles of them, as many as their display names allow, or false
+// once the feed is closed and drained or ctx is done.
+func (f *uploadFeed) next(ctx context.Context) ([]string, bool) {
+	for {
+		f.mu.Lock()
+		if f.sent < len(f.paths) {
+			end := min(len(f.paths), f.sent+uploadBatchFiles)
+			batch := grpcclient.SplitDisplayNames(f.paths[f.sent:end:end], f.names)[0]
+			f.sent += len(batch)
+			f.mu.Unlock()
+			return batch, true
+		}
+		closed := f.closed
+		f.mu.Unlock()
+		if closed {
+			return nil, false
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-f.ready:
+		}
+	}
+}
+
+// displayNames returns the original names of a batch, keyed by path.
+func (f *uploadFeed) displayNames(batch []string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make(map[string]string, len(batch))
+	for _, p := range batch {
+		names[p] = f.names[p]
+	}
+	return names
+}
+
+// uploadPipeline starts the indexing of an upload while it is still being
+// received. Each routing group gets its task with its first file, and
+// further files are fed to the running task. Codebase groups index a
+// fixed file list, so their files are held until the upload ends.
+type uploadPipeline struct {
+	h      *UploadHandler
+	opts   uploadOptions
+	routes RoutingConfig
+
+	groups  map[*RoutingRule]*uploadTask
+	feeds   map[*uploadTask]*uploadFeed
+	started []*uploadTask
+	locked  map[string]bool
+
+	heldPaths []string
+	heldNames []string
+}
+
+func (h *UploadHandler) newUploadPipeline(opts uploadOptions) *uploadPipeline {
+	return &uploadPipeline{
+		h:      h,
+		opts:   opts,
+		routes: h.uploadRouting(opts),
+		groups: map[*RoutingRule]*uploadTask{},
+		feeds:  map[*uploadTask]*uploadFeed{},
+		locked: map[string]bool{},
+	}
+}
+
+// add passes a saved file to the task of its routing group, starting the
+// task with the group's first file. It returns false, with the response
+// written and the upload aborted, if the task cannot lock its collection.
+func (p *uploadPipeline) add(w http.ResponseWriter, path, name string) bool {
+	rule := p.routes.route(name, p.opts.SourceTag)
+	if rule != nil && rule.Pipeline == PipelineCodebase {
+		p.heldPaths = append(p.heldPaths, path)
+		p.heldNames = append(p.heldNames, name)
+		return true
+	}
+
+	t := p.groups[rule]
+	if t == nil {
+		t = p.h.newUploadTask(p.opts, rule)
+		t.paths, t.names = []string{path}, []string{name}
+		if !p.h.createAndLock(w, p.opts, t, p.locked) {
+			p.abort()
+			return false
+		}
+		p.groups[rule] = t
+		p.started = append(p.started, t)
+		feed := newUploadFeed()
+		p.feeds[t] = feed
+		feed.add(path, name)
+		p.enqueue(t, feed)
+		return true
+	}
+
+	t.paths = append(t.paths, path)
+	t.names = append(t.names, name)
+	n := len(t.paths)
+	p.h.tm.UpdateParams(t.id, map[string]interface{}{
+		"saved_paths":   t.paths[:n:n],
+		"display_names": t.names[:n:n],
+	})
+	p.feeds[t].add(path, name)
+	return true
+}
+
+// enqueue queues the task of t, which indexes each batch its feed hands
+// out with its own IndexUploads stream.
+func (p *uploadPipeline) enqueue(t *uploadTask, feed *uploadFeed) {
+	h, opts := p.h, p.opts
+	ctx, cancel := context.WithCancel(context.Background())
+	h.tm.SetCancelFunc(t.id, cancel)
+
+	h.tm.Enqueue(t.id, opts.Priority, func() {
+		cleanup := func() {}
+		defer func() { cleanup() }()
+		h.tm.ConsumeIndexBatches(ctx, h.grpc, t.id, feed.next, func(ctx context.Context, batch []string) (grpcclient.IndexingStream, error) {
+			cleanup()
+			var mctx context.Context
+			mctx, cleanup = h.meta.AttachFiles(ctx, batch)
+			mctx = withDisplayNames(mctx, batch, feed.displayNames(batch))
+			mctx = grpcclient.WithDoclingOCR(mctx, t.ocr)
+			mctx = grpcclient.WithTableOptions(mctx, opts.Table)
+			mctx = withUploadProvenance(mctx, opts.Provenance, t.id, batch)
+			return h.grpc.Indexing.IndexUploads(mctx, &grpcclient.IndexUploadsRequest{
+				SavedPaths:    batch,
+				Collection:    t.collection,
+				SourceTag:     opts.SourceTag,
+				VisionModel:   t.visionModel,
+				CaptionPrompt: t.captionPrompt,
+			})
+		})
+	})
+}
+
+// finish tells the running tasks the upload is complete, starts the held
+// codebase tasks and writes the response.
+func (p *uploadPipeline) finish(w http.ResponseWriter, savedNames []string, imageURLs map[string]string) {
+	planned := p.started
+	held := p.h.planUploadTasks(p.opts, p.routes, p.heldPaths, p.heldNames)
+	for _, t := range held {
+		if !p.h.createAndLock(w, p.opts, t, p.locked) {
+			p.abort()
+			for _, prev := range planned[len(p.started):] {
+				p.h.tm.Fail(prev.id, "another task of the same upload could not lock its collection")
+			}
+			return
+		}
+		planned = append(planned, t)
+	}
+	for _, feed := range p.feeds {
+		feed.close()
+	}
+	for _, t := range held {
+		p.h.enqueueUploadTask(p.opts, t)
+	}
+
+	// List the tasks in routing rule order, as a buffered upload would.
+	ordered := make([]*uploadTask, 0, len(planned))
+	for i := range p.routes.Rules {
+		for _, t := range planned {
+			if t.rule == &p.routes.Rules[i] {
+				ordered = append(ordered, t)
+			}
+		}
+	}
+	if t := p.groups[nil]; t != nil {
+		ordered = append(ordered, t)
+	}
+	p.h.writeUploadAccepted(w, p.opts, ordered, savedNames, imageURLs, true)
+}
+
+// abort cancels the tasks started for an upload that failed part way.
+// Files they indexed already stay indexed.
+func (p *uploadPipeline) abort() {
+	for _, t := range p.started {
+		p.feeds[t].close()
+		p.h.tm.Cancel(t.id)
+	}
+}
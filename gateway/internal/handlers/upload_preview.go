@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/google/uuid"
+)
+
+// previewDir is the UPLOAD_DIR subdirectory preview files are staged in. It
+// must live under UPLOAD_DIR so the worker can read them.
+const previewDir = ".preview"
+
+// Preview extracts and chunks a single uploaded file through the worker's
+// PreviewService without embedding or writing anything to Qdrant, so users
+// can check Docling and chunking settings before a large index run. The
+// staged file is removed before returning.
+func (h *UploadHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	if h.grpc.Preview == nil {
+		writeError(w, http.StatusServiceUnavailable, "preview service not available")
+		return
+	}
+
+	maxBytes := h.cfg.MaxUploadSizeMB << 20
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("upload exceeds maximum size of %d MB", h.cfg.MaxUploadSizeMB))
+		return
+	}
+
+	files := r.MultipartForm.File["file"]
+	if len(files) != 1 {
+		writeError(w, http.StatusBadRequest, "exactly one file is required in the 'file' field")
+		return
+	}
+	fh := files[0]
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	if !allowedExtensions[ext] {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("file extension %s is not allowed", ext))
+		return
+	}
+	if imageExtensions[ext] {
+		writeError(w, http.StatusBadRequest, "images are captioned rather than chunked; preview supports documents only")
+		return
+	}
+	if mediaExtensions[ext] {
+		writeError(w, http.StatusBadRequest, "audio and video are transcribed rather than chunked; preview supports documents only")
+		return
+	}
+
+	req := &grpcclient.PreviewUploadRequest{}
+	for _, f := range []struct {
+		key string
+		min int64
+		set func(int32)
+	}{
+		{"chunk_size", 1, func(n int32) { req.ChunkSize = n }},
+		{"chunk_overlap", 0, func(n int32) { req.ChunkOverlap = &n }},
+	} {
+		raw := r.FormValue(f.key)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || n < f.min {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("%s must be an integer >= %d", f.key, f.min))
+			return
+		}
+		f.set(int32(n))
+	}
+
+	dir := filepath.Join(h.cfg.UploadDir, previewDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create upload directory")
+		return
+	}
+	destPath := filepath.Join(dir, uuid.New().String()+ext)
+	if err := saveMultipartFile(fh, destPath); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save uploaded file")
+		return
+	}
+	defer os.Remove(destPath)
+	req.Path = destPath
+
+	out, err := h.grpc.Preview.PreviewUpload(r.Context(), req)
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newUploadPreview(fh, out))
+}
+
+// uploadPreview is the body of a preview: the worker's extraction and
+// chunks of the uploaded file.
+type uploadPreview struct {
+	Filename      string               `json:"filename"`
+	Size          int64                `json:"size"`
+	Extractor     string               `json:"extractor"`
+	Language      string               `json:"language"`
+	ContentHash   string               `json:"content_hash"`
+	Text          string               `json:"text"`
+	TextChars     int32                `json:"text_chars"`
+	TextTruncated bool                 `json:"text_truncated"`
+	ChunkSize     int32                `json:"chunk_size"`
+	ChunkOverlap  int32                `json:"chunk_overlap"`
+	TotalChunks   int32                `json:"total_chunks"`
+	Chunks        []uploadPreviewChunk `json:"chunks"`
+}
+
+type uploadPreviewChunk struct {
+	ChunkIndex int32  `json:"chunk_index"`
+	StartLine  int32  `json:"start_line"`
+	EndLine    int32  `json:"end_line"`
+	Chars      int32  `json:"chars"`
+	Content    string `json:"content"`
+	PageStart  *int32 `json:"page_start,omitempty"`
+	PageEnd    *int32 `json:"page_end,omitempty"`
+}
+
+func newUploadPreview(fh *multipart.FileHeader, out *grpcclient.PreviewUploadResponse) uploadPreview {
+	resp := uploadPreview{
+		Filename:      fh.Filename,
+		Size:          fh.Size,
+		Extractor:     out.GetExtractor(),
+		Language:      out.GetLanguage(),
+		ContentHash:   out.GetContentHash(),
+		Text:          out.GetText(),
+		TextChars:     out.GetTextChars(),
+		TextTruncated: out.GetTextTruncated(),
+		ChunkSize:     out.GetChunkSize(),
+		ChunkOverlap:  out.GetChunkOverlap(),
+		TotalChunks:   out.GetTotalChunks(),
+		Chunks:        make([]uploadPreviewChunk, 0, len(out.GetChunks())),
+	}
+	for _, c := range out.GetChunks() {
+		resp.Chunks = append(resp.Chunks, uploadPreviewChunk{
+			ChunkIndex: c.GetChunkIndex(),
+			StartLine:  c.GetStartLine(),
+			EndLine:    c.GetEndLine(),
+			Chars:      c.GetChars(),
+			Content:    c.GetContent(),
+			PageStart:  c.PageStart,
+			PageEnd:    c.PageEnd,
+		})
+	}
+	return resp
+}
+
+// saveMultipartFile copies an uploaded part to dest.
+func saveMultipartFile(fh *multipart.FileHeader, dest string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dest)
+		return err
+	}
+	return dst.Close()
+}
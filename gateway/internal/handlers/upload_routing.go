@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// uploadRoutingDoc is the store document holding the upload routing rules.
+const uploadRoutingDoc = "upload-routing"
+
+// maxRoutingRules bounds the number of routing rules.
+const maxRoutingRules = 50
+
+// Pipelines an upload routing rule can send files to.
+const (
+	// PipelineImages captions images with a vision model (the IndexUploads
+	// image path, as used by IndexImages).
+	PipelineImages = "images"
+	// PipelineDocuments extracts text, with docling and optionally OCR.
+	PipelineDocuments = "documents"
+	// PipelineCodebase chunks source files with the codebase indexer.
+	PipelineCodebase = "codebase"
+)
+
+// pipelineUploads names the regular upload pipeline used for files no rule
+// matches.
+const pipelineUploads = "uploads"
+
+// pipelineCollections are the collections routed files go to when their
+// rule names none.
+var pipelineCollections = map[string]string{
+	PipelineImages:    workerDefaultImagesCollection,
+	PipelineDocuments: workerDefaultDocumentsCollection,
+	PipelineCodebase:  workerDefaultCodebaseCollection,
+}
+
+// Upload kinds a routing rule can match, besides explicit extensions.
+const (
+	kindImage    = "image"
+	kindPDF      = "pdf"
+	kindCode     = "code"
+	kindMedia    = "media"
+	kindDocument = "document"
+)
+
+// codeExtensions are the uploadable extensions of kind "code".
+var codeExtensions = map[string]bool{
+	".py": true, ".js": true, ".ts": true, ".go": true, ".rs": true,
+	".java": true, ".c": true, ".cpp": true, ".h": true, ".hpp": true,
+	".rb": true, ".php": true, ".sh": true, ".sql": true, ".r": true,
+}
+
+// codebaseExtensions are the uploadable extensions the worker's codebase
+// indexer reads; it ignores every other file.
+var codebaseExtensions = map[string]bool{
+	".py": true, ".js": true, ".ts": true, ".go": true, ".rs": true,
+	".java": true, ".c": true, ".cpp": true, ".h": true, ".hpp": true,
+	".rb": true, ".php": true, ".sh": true, ".sql": true, ".r": true,
+	".html": true, ".css": true, ".yml": true, ".yaml": true,
+	".toml": true, ".json": true, ".md": true,
+}
+
+// uploadKind classifies an uploaded file by its lowercased extension.
+func uploadKind(ext string) string {
+	switch {
+	case imageExtensions[ext]:
+		return kindImage
+	case ext == ".pdf":
+		return kindPDF
+	case codeExtensions[ext]:
+		return kindCode
+	case mediaExtensions[ext]:
+		return kindMedia
+	default:
+		return kindDocument
+	}
+}
+
+// pipelineAccepts reports whether pipeline can index files with ext.
+func pipelineAccepts(pipeline, ext string) bool {
+	switch pipeline {
+	case PipelineImages:
+		return imageExtensions[ext]
+	case PipelineCodebase:
+		return codebaseExtensions[ext]
+	default:
+		return !imageExtensions[ext]
+	}
+}
+
+// RoutingRule sends matching uploads to a pipeline. A file matches when its
+// kind or extension is listed (or neither list is set) and the upload's
+// source tag is listed (or no tags are set), and the pipeline can index it.
+type RoutingRule struct {
+	Name string `json:"name"`
+
+	// Kinds are "image", "pdf", "code", "media" and "document".
+	Kinds      []string `json:"kinds,omitempty"`
+	Extensions []string `json:"extensions,omitempty"`
+	SourceTags []string `json:"source_tags,omitempty"`
+
+	Pipeline string `json:"pipeline"`
+	// Collection defaults to the pipeline's worker default.
+	Collection string `json:"collection,omitempty"`
+	// VisionModel and CaptionPrompt apply to the images pipeline.
+	VisionModel   string `json:"vision_model,omitempty"`
+	CaptionPrompt string `json:"caption_prompt,omitempty"`
+	// OCR turns docling OCR on or off for the documents pipeline; unset
+	// keeps the worker's setting.
+	OCR *bool `json:"ocr,omitempty"`
+}
+
+// matches reports whether the rule routes a file with the lowercased
+// extension ext uploaded with sourceTag.
+func (rule RoutingRule) matches(ext, sourceTag string) bool {
+	if !pipelineAccepts(rule.Pipeline, ext) {
+		return false
+	}
+	if len(rule.SourceTags) > 0 && !slices.Contains(rule.SourceTags, sourceTag) {
+		return false
+	}
+	if len(rule.Kinds) == 0 && len(rule.Extensions) == 0 {
+		return true
+	}
+	return slices.Contains(rule.Kinds, uploadKind(ext)) || slices.Contains(rule.Extensions, ext)
+}
+
+// collection returns the collection the rule indexes into.
+func (rule RoutingRule) collection() string {
+	if rule.Collection != "" {
+		return rule.Collection
+	}
+	return pipelineCollections[rule.Pipeline]
+}
+
+// validate checks and normalises rule, returning an error naming the
+// offending field.
+func (rule *RoutingRule) validate() error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if _, ok := pipelineCollections[rule.Pipeline]; !ok {
+		return fmt.Errorf("pipeline must be %s, %s or %s", PipelineImages, PipelineDocuments, PipelineCodebase)
+	}
+	if len(rule.Kinds) == 0 && len(rule.Extensions) == 0 && len(rule.SourceTags) == 0 {
+		return fmt.Errorf("set kinds, extensions or source_tags")
+	}
+	for _, k := range rule.Kinds {
+		switch k {
+		case kindImage, kindPDF, kindCode, kindMedia, kindDocument:
+		default:
+			return fmt.Errorf("unknown kind %q (want image, pdf, code, media or document)", k)
+		}
+		if (k == kindImage) != (rule.Pipeline == PipelineImages) ||
+			((k == kindPDF || k == kindMedia) && rule.Pipeline == PipelineCodebase) {
+			return fmt.Errorf("the %s pipeline cannot index kind %s", rule.Pipeline, k)
+		}
+	}
+	for i, ext := range rule.Extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if !allowedExtensions[ext] {
+			return fmt.Errorf("extension %s is not accepted for upload", ext)
+		}
+		if !pipelineAccepts(rule.Pipeline, ext) {
+			return fmt.Errorf("the %s pipeline cannot index %s files", rule.Pipeline, ext)
+		}
+		rule.Extensions[i] = ext
+	}
+	if rule.Pipeline != PipelineImages && (rule.VisionModel != "" || rule.CaptionPrompt != "") {
+		return fmt.Errorf("vision_model and caption_prompt only apply to the %s pipeline", PipelineImages)
+	}
+	if rule.Pipeline != PipelineDocuments && rule.OCR != nil {
+		return fmt.Errorf("ocr only applies to the %s pipeline", PipelineDocuments)
+	}
+	return nil
+}
+
+// RoutingConfig holds the upload routing rules. The first matching rule
+// routes a file; files no rule matches are indexed as before.
+type RoutingConfig struct {
+	Rules     []RoutingRule `json:"rules"`
+	UpdatedAt *time.Time    `json:"updated_at,omitempty"`
+}
+
+// validate checks every rule and that names are unique.
+func (c *RoutingConfig) validate() error {
+	if len(c.Rules) > maxRoutingRules {
+		return fmt.Errorf("at most %d rules are allowed", maxRoutingRules)
+	}
+	seen := map[string]bool{}
+	for i := range c.Rules {
+		if err := c.Rules[i].validate(); err != nil {
+			return fmt.Errorf("rules[%d]: %v", i, err)
+		}
+		if seen[c.Rules[i].Name] {
+			return fmt.Errorf("rules[%d]: duplicate name %q", i, c.Rules[i].Name)
+		}
+		seen[c.Rules[i].Name] = true
+	}
+	return nil
+}
+
+// route returns the rule routing a file called name uploaded with
+// sourceTag, or nil.
+func (c RoutingConfig) route(name, sourceTag string) *RoutingRule {
+	ext := strings.ToLower(filepath.Ext(name))
+	for i := range c.Rules {
+		if c.Rules[i].matches(ext, sourceTag) {
+			return &c.Rules[i]
+		}
+	}
+	return nil
+}
+
+// UploadRouting holds the routing rules, persisted in the gateway store.
+type UploadRouting struct {
+	mu    sync.RWMutex
+	store *store.Store
+	data  RoutingConfig
+}
+
+// NewUploadRouting loads the routing rules from st.
+func NewUploadRouting(st *store.Store) *UploadRouting {
+	u := &UploadRouting{store: st}
+	if _, err := st.Load(uploadRoutingDoc, &u.data); err != nil {
+		log.Printf("WARNING: upload routing: %v", err)
+	}
+	return u
+}
+
+// Get returns the current rules. A nil UploadRouting has none.
+func (u *UploadRouting) Get() RoutingConfig {
+	if u == nil {
+		return RoutingConfig{Rules: []RoutingRule{}}
+	}
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	c := u.data
+	if c.Rules == nil {
+		c.Rules = []RoutingRule{}
+	}
+	return c
+}
+
+// Set validates and replaces the rules.
+func (u *UploadRouting) Set(c RoutingConfig) (RoutingConfig, error) {
+	if err := c.validate(); err != nil {
+		return c, err
+	}
+	if c.Rules == nil {
+		c.Rules = []RoutingRule{}
+	}
+	now := time.Now().UTC()
+	c.UpdatedAt = &now
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.data = c
+	return c, u.store.Save(uploadRoutingDoc, u.data)
+}
+
+// UploadRoutingHandler serves /api/system/config/routing.
+type UploadRoutingHandler struct {
+	routing *UploadRouting
+}
+
+// NewUploadRoutingHandler creates a new UploadRoutingHandler.
+func NewUploadRoutingHandler(routing *UploadRouting) *UploadRoutingHandler {
+	return &UploadRoutingHandler{routing: routing}
+}
+
+// Routes registers the routing rule routes on the given chi router.
+func (h *UploadRoutingHandler) Routes(r chi.Router) {
+	r.Get("/", h.Get)
+	r.Post("/test", h.Test)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireAdmin)
+		r.Put("/", h.Put)
+		r.Delete("/", h.Reset)
+	})
+}
+
+// Get returns the routing rules.
+func (h *UploadRoutingHandler) Get(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.routing.Get())
+}
+
+// Put replaces the routing rules.
+func (h *UploadRoutingHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var req RoutingConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	saved, err := h.routing.Set(req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// Reset removes every routing rule.
+func (h *UploadRoutingHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	saved, err := h.routing.Set(RoutingConfig{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// Test reports where the given file names would be routed, without
+// uploading anything.
+func (h *UploadRoutingHandler) Test(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Files     []string `json:"files"`
+		SourceTag string   `json:"source_tag"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if len(req.Files) == 0 {
+		writeError(w, http.StatusBadRequest, "files is required")
+		return
+	}
+
+	cfg := h.routing.Get()
+	routes := make([]map[string]interface{}, 0, len(req.Files))
+	for _, name := range req.Files {
+		route := map[string]interface{}{
+			"file":     name,
+			"kind":     uploadKind(strings.ToLower(filepath.Ext(name))),
+			"pipeline": pipelineUploads,
+		}
+		if rule := cfg.route(name, req.SourceTag); rule != nil {
+			route["rule"] = rule.Name
+			route["pipeline"] = rule.Pipeline
+			route["collection"] = rule.collection()
+		}
+		routes = append(routes, route)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"routes": routes})
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+// maxURLsPerRequest caps how many remote files one request may fetch.
+const maxURLsPerRequest = 20
+
+// urlFetchTimeout bounds the download of one remote file.
+const urlFetchTimeout = 5 * time.Minute
+
+// errPrivateAddress is returned when a fetch resolves to a blocked address.
+var errPrivateAddress = errors.New("destination address is not allowed")
+
+// UploadFromURL downloads one or more remote files into UPLOAD_DIR and
+// indexes them exactly like multipart uploads. Only http(s) URLs are
+// accepted, each download is capped at MAX_UPLOAD_SIZE_MB, and private or
+// loopback destinations are refused unless URL_FETCH_ALLOW_PRIVATE=true.
+func (h *UploadHandler) UploadFromURL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL           string   `json:"url"`
+		URLs          []string `json:"urls"`
+		Collection    string   `json:"collection"`
+		SourceTag     string   `json:"source_tag"`
+		VisionModel   string   `json:"vision_model"`
+		CaptionPrompt string   `json:"caption_prompt"`
+		Priority      string   `json:"priority"`
+		Lock          string   `json:"lock"`
+		Routing       *bool    `json:"routing"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	urls := req.URLs
+	if req.URL != "" {
+		urls = append([]string{req.URL}, urls...)
+	}
+	if len(urls) == 0 {
+		writeError(w, http.StatusBadRequest, "url or urls is required")
+		return
+	}
+	if len(urls) > maxURLsPerRequest {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d urls per request", maxURLsPerRequest))
+		return
+	}
+	priority, err := tasks.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	lockMode, err := tasks.ParseLockMode(req.Lock)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Remote sizes are unknown until fetched; MAX_UPLOAD_SIZE_MB caps each.
+	warnings, ok := h.guard.admit(r.Context(), w, 0)
+	if !ok {
+		return
+	}
+
+	if err := os.MkdirAll(h.cfg.UploadDir, 0o755); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create upload directory")
+		return
+	}
+
+	client := h.fetchClient()
+	dest := h.newUploadDest()
+	var savedPaths []string
+	var savedNames []string
+	imageURLs := map[string]string{}
+	prov := grpcclient.Provenance{
+		SourceType: grpcclient.SourceURL,
+		Uploader:   middleware.UsernameFromContext(r.Context()),
+		Sources:    map[string]string{},
+	}
+
+	for _, raw := range urls {
+		ctx, cancel := fetchContext(r.Context())
+		name, destName, status, err := h.fetchToUploadDir(ctx, client, dest, raw)
+		cancel()
+		if err != nil {
+			for _, p := range savedPaths {
+				os.Remove(p)
+			}
+			code := codeForStatus(status)
+			if errors.Is(err, errTranscriptionUnsupported) {
+				code = CodeWorkerUnsupported
+			}
+			writeErrorCode(w, status, code, fmt.Sprintf("%s: %v", raw, err))
+			return
+		}
+		saved := filepath.Join(h.cfg.UploadDir, filepath.FromSlash(destName))
+		savedPaths = append(savedPaths, saved)
+		savedNames = append(savedNames, name)
+		prov.Sources[saved] = raw
+		if imageExtensions[strings.ToLower(filepath.Ext(destName))] {
+			imageURLs[name] = middleware.ExternalURL(r, h.images.Path(destName))
+		}
+	}
+
+	h.startIndexing(w, uploadOptions{
+		Collection:    req.Collection,
+		SourceTag:     req.SourceTag,
+		VisionModel:   req.VisionModel,
+		CaptionPrompt: req.CaptionPrompt,
+		Priority:      priority,
+		Lock:          lockMode,
+		Route:         req.Routing == nil || *req.Routing,
+		Warnings:      warnings,
+		Provenance:    prov,
+	}, savedPaths, savedNames, imageURLs)
+}
+
+// fetchToUploadDir downloads a single URL to a path from dest and returns
+// the original file name, the saved path relative to UPLOAD_DIR, and an HTTP
+// status to report on failure.
+func (h *UploadHandler) fetchToUploadDir(ctx context.Context, client *http.Client, dest *uploadDest, raw string) (string, string, int, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", http.StatusBadRequest, errors.New("only absolute http(s) URLs are supported")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return "", "", http.StatusBadRequest, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, errPrivateAddress) {
+			return "", "", http.StatusForbidden, errPrivateAddress
+		}
+		return "", "", http.StatusBadGateway, fmt.Errorf("fetch failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", http.StatusBadGateway, fmt.Errorf("remote returned status %d", resp.StatusCode)
+	}
+
+	name := remoteFilename(resp, u)
+	ext := strings.ToLower(filepath.Ext(name))
+	if !allowedExtensions[ext] {
+		return "", "", http.StatusBadRequest, fmt.Errorf("file extension %q is not allowed", ext)
+	}
+	if err := checkMediaUpload(h.grpc, ext); err != nil {
+		return "", "", http.StatusNotImplemented, err
+	}
+
+	maxBytes := h.cfg.MaxUploadSizeMB << 20
+	if resp.ContentLength > maxBytes {
+		return "", "", http.StatusRequestEntityTooLarge,
+			fmt.Errorf("file exceeds maximum size of %d MB", h.cfg.MaxUploadSizeMB)
+	}
+
+	destName, err := dest.next(name, ext)
+	if err != nil {
+		return "", "", http.StatusInternalServerError, errors.New("failed to create upload directory")
+	}
+	destPath := filepath.Join(h.cfg.UploadDir, filepath.FromSlash(destName))
+	dst, err := os.Create(destPath)
+	if err != nil {
+		return "", "", http.StatusInternalServerError, errors.New("failed to save downloaded file")
+	}
+
+	n, err := io.Copy(dst, io.LimitReader(resp.Body, maxBytes+1))
+	dst.Close()
+	if err != nil {
+		os.Remove(destPath)
+		return "", "", http.StatusBadGateway, fmt.Errorf("download interrupted: %v", err)
+	}
+	if n > maxBytes {
+		os.Remove(destPath)
+		return "", "", http.StatusRequestEntityTooLarge,
+			fmt.Errorf("file exceeds maximum size of %d MB", h.cfg.MaxUploadSizeMB)
+	}
+
+	return name, destName, http.StatusOK, nil
+}
+
+// remoteFilename picks a file name from Content-Disposition, then the URL
+// path, adding an extension derived from Content-Type when missing.
+func remoteFilename(resp *http.Response, u *url.URL) string {
+	var name string
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		name = filepath.Base(params["filename"])
+	}
+	if name == "" || name == "." || name == "/" {
+		name = path.Base(u.Path)
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "download"
+	}
+
+	if filepath.Ext(name) == "" {
+		if ct, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+			if exts, _ := mime.ExtensionsByType(ct); len(exts) > 0 {
+				for _, e := range exts {
+					if allowedExtensions[e] {
+						return name + e
+					}
+				}
+			}
+			switch ct {
+			case "text/plain":
+				return name + ".txt"
+			case "text/markdown":
+				return name + ".md"
+			}
+		}
+	}
+	return name
+}
+
+// fetchContext returns the context for one download. It is detached from
+// the worker deadline on /api/rag, which is far shorter than a large
+// download may take, and bounded by urlFetchTimeout instead. A client that
+// goes away still cancels it.
+func fetchContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), urlFetchTimeout)
+	stop := context.AfterFunc(parent, func() {
+		if !errors.Is(parent.Err(), context.DeadlineExceeded) {
+			cancel()
+		}
+	})
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// fetchClient returns the HTTP client for URL uploads; see newFetchClient.
+func (h *UploadHandler) fetchClient() *http.Client {
+	return newFetchClient(h.cfg.URLFetchAllowPrivate)
+}
+
+// newFetchClient returns an HTTP client that refuses to connect to private,
+// loopback, or link-local addresses unless allowPrivate is set. The check
+// runs at dial time so DNS rebinding and redirects are covered too. It
+// could not see the destination behind an HTTP proxy, so the environment's
+// proxy is only used when private addresses are allowed anyway.
+func newFetchClient(allowPrivate bool) *http.Client {
+	dialer := newFetchDialer(allowPrivate)
+	var proxy func(*http.Request) (*url.URL, error)
+	if allowPrivate {
+		proxy = http.ProxyFromEnvironment
+	}
+	return &http.Client{
+		Timeout: urlFetchTimeout,
+		Transport: &http.Transport{
+			Proxy:               proxy,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("redirect to unsupported scheme")
+			}
+			return nil
+		},
+	}
+}
+
+// newFetchDialer returns the dialer of newFetchClient, for connections
+// other than HTTP.
+func newFetchDialer(allowPrivate bool) *net.Dialer {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+				ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	return dialer
+}
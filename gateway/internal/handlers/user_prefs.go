@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// userPreferencesDoc is the store document holding every user's
+// preferences.
+const userPreferencesDoc = "user-preferences"
+
+const (
+	// maxPreferencesBody bounds a preferences update, in bytes.
+	maxPreferencesBody = 64 << 10
+	// maxPinnedSearches bounds the pinned searches of one user.
+	maxPinnedSearches = 50
+	// maxPinnedQuery bounds the query of a pinned search, in bytes.
+	maxPinnedQuery = 2000
+	// maxPreferenceName bounds names, collections and models, in bytes.
+	maxPreferenceName = 255
+)
+
+// DefaultModels are the models the UI preselects for a user. Empty fields
+// leave the server defaults.
+type DefaultModels struct {
+	Chat   string `json:"chat,omitempty"`
+	Embed  string `json:"embed,omitempty"`
+	Vision string `json:"vision,omitempty"`
+}
+
+// PinnedSearch is a search a user keeps at hand.
+type PinnedSearch struct {
+	Name       string `json:"name"`
+	Query      string `json:"query"`
+	Collection string `json:"collection,omitempty"`
+}
+
+// UserPreferences are one user's UI settings. UI is a free-form JSON object
+// owned by the frontend (layout, theme, open panels...); the gateway only
+// stores it.
+type UserPreferences struct {
+	UI                json.RawMessage `json:"ui,omitempty"`
+	DefaultCollection string          `json:"default_collection,omitempty"`
+	DefaultModels     DefaultModels   `json:"default_models"`
+	PinnedSearches    []PinnedSearch  `json:"pinned_searches"`
+	UpdatedAt         *time.Time      `json:"updated_at,omitempty"`
+}
+
+// userPreferencesUpdate is a PUT body. Fields left out keep their stored
+// value; an empty value ("", {} or []) clears them.
+type userPreferencesUpdate struct {
+	UI                json.RawMessage `json:"ui"`
+	DefaultCollection *string         `json:"default_collection"`
+	DefaultModels     *DefaultModels  `json:"default_models"`
+	PinnedSearches    *[]PinnedSearch `json:"pinned_searches"`
+}
+
+// apply merges u into p and validates the result, returning an error naming
+// the offending field.
+func (u userPreferencesUpdate) apply(p *UserPreferences) error {
+	if ui := bytes.TrimSpace(u.UI); len(ui) > 0 && !bytes.Equal(ui, []byte("null")) {
+		if ui[0] != '{' {
+			return errors.New("ui must be a JSON object")
+		}
+		p.UI = ui
+		if bytes.Equal(ui, []byte("{}")) {
+			p.UI = nil
+		}
+	}
+	if u.DefaultCollection != nil {
+		p.DefaultCollection = strings.TrimSpace(*u.DefaultCollection)
+		if len(p.DefaultCollection) > maxPreferenceName {
+			return fmt.Errorf("default_collection must be at most %d bytes", maxPreferenceName)
+		}
+	}
+	if u.DefaultModels != nil {
+		m := DefaultModels{
+			Chat:   strings.TrimSpace(u.DefaultModels.Chat),
+			Embed:  strings.TrimSpace(u.DefaultModels.Embed),
+			Vision: strings.TrimSpace(u.DefaultModels.Vision),
+		}
+		if len(m.Chat) > maxPreferenceName || len(m.Embed) > maxPreferenceName || len(m.Vision) > maxPreferenceName {
+			return fmt.Errorf("default_models entries must be at most %d bytes", maxPreferenceName)
+		}
+		p.DefaultModels = m
+	}
+	if u.PinnedSearches != nil {
+		pins := *u.PinnedSearches
+		if len(pins) > maxPinnedSearches {
+			return fmt.Errorf("at most %d searches can be pinned", maxPinnedSearches)
+		}
+		for i := range pins {
+			pins[i].Name = strings.TrimSpace(pins[i].Name)
+			pins[i].Query = strings.TrimSpace(pins[i].Query)
+			pins[i].Collection = strings.TrimSpace(pins[i].Collection)
+			switch {
+			case pins[i].Query == "" || len(pins[i].Query) > maxPinnedQuery:
+				return fmt.Errorf("pinned_searches[%d].query must be 1-%d bytes", i, maxPinnedQuery)
+			case len(pins[i].Name) > maxPreferenceName || len(pins[i].Collection) > maxPreferenceName:
+				return fmt.Errorf("pinned_searches[%d]: name and collection must be at most %d bytes", i, maxPreferenceName)
+			}
+			if pins[i].Name == "" {
+				pins[i].Name = pins[i].Query
+			}
+		}
+		p.PinnedSearches = pins
+	}
+	return nil
+}
+
+// UserPreferencesStore holds every user's preferences, persisted in the
+// gateway store so they follow the user across browsers and devices.
+type UserPreferencesStore struct {
+	mu    sync.RWMutex
+	store *store.Store
+	data  struct {
+		Users map[string]UserPreferences `json:"users"`
+	}
+}
+
+// NewUserPreferencesStore loads the preferences from st.
+func NewUserPreferencesStore(st *store.Store) *UserPreferencesStore {
+	s := &UserPreferencesStore{store: st}
+	if _, err := st.Load(userPreferencesDoc, &s.data); err != nil {
+		log.Printf("WARNING: user preferences: %v", err)
+	}
+	if s.data.Users == nil {
+		s.data.Users = make(map[string]UserPreferences)
+	}
+	return s
+}
+
+// Get returns a user's preferences (zero value if none).
+func (s *UserPreferencesStore) Get(username string) UserPreferences {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p := s.data.Users[username]
+	if p.PinnedSearches == nil {
+		p.PinnedSearches = []PinnedSearch{}
+	}
+	return p
+}
+
+// Update merges u into a user's preferences and saves them.
+func (s *UserPreferencesStore) Update(username string, u userPreferencesUpdate) (UserPreferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.data.Users[username]
+	if err := u.apply(&p); err != nil {
+		return p, err
+	}
+	now := time.Now().UTC()
+	p.UpdatedAt = &now
+	s.data.Users[username] = p
+	if p.PinnedSearches == nil {
+		p.PinnedSearches = []PinnedSearch{}
+	}
+	return p, s.store.Save(userPreferencesDoc, s.data)
+}
+
+// Delete removes a user's preferences.
+func (s *UserPreferencesStore) Delete(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Users[username]; !ok {
+		return nil
+	}
+	delete(s.data.Users, username)
+	return s.store.Save(userPreferencesDoc, s.data)
+}
+
+// UserPreferencesHandler lets users read and edit their own preferences.
+type UserPreferencesHandler struct {
+	store *UserPreferencesStore
+}
+
+// NewUserPreferencesHandler creates a new UserPreferencesHandler.
+func NewUserPreferencesHandler(store *UserPreferencesStore) *UserPreferencesHandler {
+	return &UserPreferencesHandler{store: store}
+}
+
+// Routes registers the preference routes on the given chi router.
+func (h *UserPreferencesHandler) Routes(r chi.Router) {
+	r.Get("/preferences", h.Get)
+	r.Put("/preferences", h.Update)
+	r.Delete("/preferences", h.Reset)
+}
+
+// Get returns the caller's preferences.
+func (h *UserPreferencesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.store.Get(middleware.UsernameFromContext(r.Context())))
+}
+
+// Update merges the body into the caller's preferences.
+func (h *UserPreferencesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req userPreferencesUpdate
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreferencesBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	prefs, err := h.store.Update(middleware.UsernameFromContext(r.Context()), req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+// Reset clears the caller's preferences.
+func (h *UserPreferencesHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.Delete(middleware.UsernameFromContext(r.Context())); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "reset"})
+}
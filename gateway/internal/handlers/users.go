@@ -3,20 +3,26 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 
 	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
 	"github.com/go-chi/chi/v5"
 )
 
 // UsersHandler provides user management endpoints (admin only).
 type UsersHandler struct {
-	grpc *grpcclient.Client
+	grpc     *grpcclient.Client
+	sessions *middleware.SessionStore
+	prefs    *UserPreferencesStore
+	searches *SavedSearches
 }
 
-// NewUsersHandler creates a new UsersHandler.
-func NewUsersHandler(gc *grpcclient.Client) *UsersHandler {
-	return &UsersHandler{grpc: gc}
+// NewUsersHandler creates a new UsersHandler. Deleting a user also removes
+// their preferences from prefs and their saved searches from searches.
+func NewUsersHandler(gc *grpcclient.Client, sessions *middleware.SessionStore, prefs *UserPreferencesStore, searches *SavedSearches) *UsersHandler {
+	return &UsersHandler{grpc: gc, sessions: sessions, prefs: prefs, searches: searches}
 }
 
 // Routes registers user management routes on the given chi router.
@@ -24,13 +30,16 @@ func (h *UsersHandler) Routes(r chi.Router) {
 	r.Get("/", h.ListUsers)
 	r.Post("/", h.CreateUser)
 	r.Delete("/{username}", h.DeleteUser)
+	r.Get("/{username}/sessions", h.ListSessions)
+	r.Delete("/{username}/sessions", h.RevokeSessions)
+	r.Delete("/{username}/sessions/{id}", h.RevokeSession)
 }
 
 // ListUsers returns all users.
 func (h *UsersHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	resp, err := h.grpc.Auth.ListUsers(r.Context())
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -68,7 +77,7 @@ func (h *UsersHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		Role:     req.Role,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -87,7 +96,7 @@ func (h *UsersHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		Username: username,
 	})
 	if err != nil {
-		writeError(w, http.StatusBadGateway, fmt.Sprintf("grpc error: %v", err))
+		writeGRPCError(w, err)
 		return
 	}
 
@@ -96,5 +105,57 @@ func (h *UsersHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.sessions.RevokeUser(username)
+	if err := h.prefs.Delete(username); err != nil {
+		log.Printf("WARNING: user preferences: deleting %s: %v", username, err)
+	}
+	if err := h.searches.DeleteUser(username); err != nil {
+		log.Printf("WARNING: saved searches: deleting %s: %v", username, err)
+	}
 	writeJSON(w, http.StatusOK, map[string]bool{"deleted": true})
 }
+
+// ListSessions returns a user's active sessions with their open
+// WebSocket connection counts.
+func (h *UsersHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	sessions := h.sessions.List(username)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"username": username,
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+// RevokeSessions force-logs-out a user by revoking all of their sessions
+// and closing their WebSocket connections.
+func (h *UsersHandler) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	n := h.sessions.RevokeUser(username)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"username": username,
+		"revoked":  n,
+	})
+}
+
+// RevokeSession revokes a single session of a user.
+func (h *UsersHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	id := chi.URLParam(r, "id")
+
+	owned := false
+	for _, s := range h.sessions.List(username) {
+		if s.ID == id {
+			owned = true
+			break
+		}
+	}
+	if !owned || !h.sessions.Revoke(id) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("session %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"username": username,
+		"revoked":  1,
+	})
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/go-chi/chi/v5"
+)
+
+// graphExportFormats maps each supported export format to its content type
+// and file extension.
+var graphExportFormats = map[string]struct {
+	contentType string
+	ext         string
+}{
+	"graphml": {"application/graphml+xml", "graphml"},
+	"dot":     {"text/vnd.graphviz", "dot"},
+	"png":     {"image/png", "png"},
+}
+
+// VisualizeOverviewExport converts the overview graph for a collection into
+// a standard graph format (?format=graphml|dot|png) for use in tools such as
+// Gephi or Graphviz. PNG rendering requires the Graphviz `dot` binary.
+func (h *RAGHandler) VisualizeOverviewExport(w http.ResponseWriter, r *http.Request) {
+	if h.grpc.Visualization == nil {
+		writeError(w, http.StatusServiceUnavailable, "visualization service not available")
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "graphml"
+	}
+	spec, ok := graphExportFormats[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "format must be one of: graphml, dot, png")
+		return
+	}
+
+	collection := chi.URLParam(r, "collection")
+	limit := int32(200)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = int32(n)
+		}
+	}
+
+	resp, err := h.grpc.Visualization.Overview(r.Context(), &grpcclient.OverviewRequest{
+		Collection: collection,
+		Limit:      limit,
+	})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	var out []byte
+	switch format {
+	case "graphml":
+		out, err = overviewToGraphML(collection, resp)
+	case "dot":
+		out = overviewToDOT(collection, resp)
+	case "png":
+		out, err = renderDOT(r.Context(), overviewToDOT(collection, resp), "png")
+		if err == errRendererMissing {
+			writeError(w, http.StatusNotImplemented, "png export requires graphviz (dot) on the gateway host")
+			return
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("export failed: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", spec.contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s-overview.%s"`, safeFilename(collection), spec.ext))
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// GraphML document structure (http://graphml.graphdrawing.org/).
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func overviewToGraphML(collection string, resp *grpcclient.OverviewResponse) ([]byte, error) {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+			{ID: "file_path", For: "node", AttrName: "file_path", AttrType: "string"},
+			{ID: "language", For: "node", AttrName: "language", AttrType: "string"},
+			{ID: "chunks", For: "node", AttrName: "chunks", AttrType: "int"},
+			{ID: "level", For: "node", AttrName: "level", AttrType: "int"},
+			{ID: "size", For: "node", AttrName: "size", AttrType: "int"},
+			{ID: "color", For: "node", AttrName: "color", AttrType: "string"},
+		},
+		Graph: graphMLGraph{ID: collection, EdgeDefault: "directed"},
+	}
+
+	for _, n := range resp.GetNodes() {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: "n" + strconv.Itoa(int(n.Id)),
+			Data: []graphMLData{
+				{Key: "label", Value: n.Label},
+				{Key: "file_path", Value: n.FilePath},
+				{Key: "language", Value: n.Language},
+				{Key: "chunks", Value: strconv.Itoa(int(n.Chunks))},
+				{Key: "level", Value: strconv.Itoa(int(n.Level))},
+				{Key: "size", Value: strconv.Itoa(int(n.Size))},
+				{Key: "color", Value: n.Color},
+			},
+		})
+	}
+	for _, e := range resp.GetEdges() {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: "n" + strconv.Itoa(int(e.From)),
+			Target: "n" + strconv.Itoa(int(e.To)),
+		})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func overviewToDOT(collection string, resp *grpcclient.OverviewResponse) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(collection))
+	b.WriteString("  graph [overlap=false, splines=true];\n")
+	b.WriteString("  node [style=filled, fontname=\"Helvetica\"];\n")
+	for _, n := range resp.GetNodes() {
+		attrs := []string{"label=" + dotQuote(n.Label)}
+		if n.Color != "" {
+			attrs = append(attrs, "fillcolor="+dotQuote(n.Color))
+		}
+		if n.FilePath != "" {
+			attrs = append(attrs, "tooltip="+dotQuote(n.FilePath))
+		}
+		fmt.Fprintf(&b, "  n%d [%s];\n", n.Id, strings.Join(attrs, ", "))
+	}
+	for _, e := range resp.GetEdges() {
+		fmt.Fprintf(&b, "  n%d -> n%d;\n", e.From, e.To)
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// dotQuote returns s as a quoted DOT identifier.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+var errRendererMissing = fmt.Errorf("graphviz dot binary not found")
+
+// renderDOT pipes a DOT document through the Graphviz `dot` binary.
+func renderDOT(ctx context.Context, src []byte, format string) ([]byte, error) {
+	bin, err := exec.LookPath("dot")
+	if err != nil {
+		return nil, errRendererMissing
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, "-T"+format)
+	cmd.Stdin = bytes.NewReader(src)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("dot: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// safeFilename strips characters that are awkward in a download filename.
+func safeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+}
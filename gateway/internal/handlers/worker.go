@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+)
+
+// WorkerInfo reports the connected worker's version, services and features
+// as negotiated at connect time, and the services the gateway uses that the
+// worker lacks. Clients hide the features built on missing services; their
+// routes answer 501 WORKER_UNSUPPORTED.
+func (h *SystemHandler) WorkerInfo(w http.ResponseWriter, r *http.Request) {
+	wi := h.grpc.Worker()
+	connection := "not connected"
+	if conn := h.grpc.Conn(); conn != nil {
+		connection = strings.ToLower(conn.GetState().String())
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address":    h.cfg.WorkerAddr,
+		"connection": connection,
+		"status":     wi.Status,
+		"version":    wi.Version,
+		"services":   wi.Services,
+		"features":   wi.Features,
+		"missing":    wi.Missing(),
+		"checked_at": wi.CheckedAt,
+	})
+}
+
+// requireWorker answers 501 for routes needing a worker service the
+// connected worker does not implement, rather than relaying the worker's
+// Unimplemented error.
+func requireWorker(gc *grpcclient.Client, services ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if svc, ok := unsupportedService(gc, services...); !ok {
+				writeErrorCode(w, http.StatusNotImplemented, CodeWorkerUnsupported, unsupportedMessage(gc, svc))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unsupportedService returns the first of services the worker lacks, and
+// false, or "" and true if it implements them all.
+func unsupportedService(gc *grpcclient.Client, services ...string) (string, bool) {
+	wi := gc.Worker()
+	for _, svc := range services {
+		if !wi.Supports(svc) {
+			return svc, false
+		}
+	}
+	return "", true
+}
+
+func unsupportedMessage(gc *grpcclient.Client, service string) string {
+	return fmt.Sprintf("the connected worker (version %s) does not implement %s; upgrade the worker to use this feature",
+		gc.Worker().Version, service)
+}
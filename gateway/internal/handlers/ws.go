@@ -3,11 +3,18 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 )
@@ -21,12 +28,28 @@ var upgrader = websocket.Upgrader{
 
 // WSHandler bridges WebSocket connections to the gRPC ChatService stream.
 type WSHandler struct {
-	grpc *grpcclient.Client
+	grpc      *grpcclient.Client
+	prefs     *ChatPrefsStore
+	sessions  *middleware.SessionStore
+	tm        *tasks.Manager
+	cite      *CitationEnricher
+	instances *OllamaInstances
+	router    *ChatRouter
+	grounding *GroundingScorer
+	flush     StreamFlush
 }
 
-// NewWSHandler creates a new WSHandler.
-func NewWSHandler(gc *grpcclient.Client) *WSHandler {
-	return &WSHandler{grpc: gc}
+// NewWSHandler creates a new WSHandler. Per-user chat defaults from prefs
+// fill in any options a message leaves unset. Connections are registered
+// with sessions so revoking a session disconnects them, and reindex locks in
+// tm are honoured before answering from a collection. Sources events are
+// enriched with citations by cite. A message may pin one of instances to
+// answer it. Messages with route "auto" have their collections picked by
+// router. Finished answers are scored against their sources by grounding,
+// unless it is nil. Answer chunks are coalesced as flush says, unless a
+// message overrides it.
+func NewWSHandler(gc *grpcclient.Client, prefs *ChatPrefsStore, sessions *middleware.SessionStore, tm *tasks.Manager, cite *CitationEnricher, instances *OllamaInstances, router *ChatRouter, grounding *GroundingScorer, flush StreamFlush) *WSHandler {
+	return &WSHandler{grpc: gc, prefs: prefs, sessions: sessions, tm: tm, cite: cite, instances: instances, router: router, grounding: grounding, flush: flush}
 }
 
 // Routes registers the WebSocket endpoint.
@@ -34,28 +57,43 @@ func (h *WSHandler) Routes(r chi.Router) {
 	r.Get("/", h.HandleWS)
 }
 
-// wsMessage is the JSON structure expected from WebSocket clients.
-type wsMessage struct {
-	Message    string `json:"message"`
-	Collection string `json:"collection"`
-	Model      string `json:"model"`
-	PIIEnabled bool   `json:"pii_enabled"`
-}
+// wsMessage is the JSON structure expected from WebSocket clients. A
+// message with type "cancel" aborts the response currently streaming;
+// anything else starts a chat turn.
+type wsMessage = api.ChatMessage
 
 // wsEvent is the JSON structure sent back to WebSocket clients, mirroring
 // ChatEvent from the gRPC service.
-type wsEvent struct {
-	Type             string              `json:"type"`
-	Content          string              `json:"content,omitempty"`
-	Sources          []grpcclient.SearchHit `json:"sources,omitempty"`
-	PIIMasked        bool                `json:"pii_masked,omitempty"`
-	PIIEntitiesCount int32               `json:"pii_entities_count,omitempty"`
+type wsEvent = api.ChatEvent
+
+// chatReconnectTimeout bounds how long a new chat turn waits for a
+// restarting worker before reporting it unavailable.
+const chatReconnectTimeout = 15 * time.Second
+
+// chatTurn is an in-flight chat response.
+type chatTurn struct {
+	cancel    context.CancelFunc
+	cancelled atomic.Bool // set when the client asked to stop
+	done      chan struct{}
+}
+
+func (t *chatTurn) running() bool {
+	if t == nil {
+		return false
+	}
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
 }
 
 // HandleWS upgrades the HTTP connection to a WebSocket, then enters a
-// read loop. For each message received it opens a gRPC Chat stream and
-// pipes ChatEvent frames back as JSON over the WebSocket. When the
-// WebSocket disconnects the active gRPC context is cancelled.
+// read loop. Each chat message opens a gRPC Chat stream on its own
+// goroutine whose ChatEvent frames are piped back as JSON, so the loop keeps
+// reading and a {"type": "cancel"} message can abort the response mid-way.
+// When the WebSocket disconnects the active gRPC context is cancelled.
 func (h *WSHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -63,6 +101,27 @@ func (h *WSHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer conn.Close()
+	defer activeStreams.track("websocket")()
+
+	out := newWSWriter(conn)
+	defer out.close()
+
+	username := middleware.UsernameFromContext(r.Context())
+	untrack := h.sessions.TrackConn(middleware.SessionIDFromContext(r.Context()), func() {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked"),
+			time.Now().Add(time.Second))
+		conn.Close()
+	})
+	defer untrack()
+
+	var turn *chatTurn
+	defer func() {
+		if turn != nil {
+			turn.cancel()
+			<-turn.done
+		}
+	}()
 
 	for {
 		// Read next message from the client.
@@ -76,52 +135,224 @@ func (h *WSHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
 
 		var msg wsMessage
 		if err := json.Unmarshal(raw, &msg); err != nil {
-			h.writeWSError(conn, "invalid JSON message")
+			writeWSError(out, "invalid JSON message")
 			continue
 		}
 
-		if h.grpc.Chat == nil {
-			h.writeWSError(conn, "chat service not available")
+		if msg.Type == "cancel" {
+			if !turn.running() {
+				writeWSError(out, "no response in progress")
+				continue
+			}
+			turn.cancelled.Store(true)
+			turn.cancel()
+			continue
+		}
+		if turn.running() {
+			writeWSError(out, `a response is already streaming; send {"type": "cancel"} first`)
 			continue
 		}
 
-		// Create a cancellable context for this chat exchange. If the
-		// WebSocket closes while streaming, the gRPC call is cancelled.
+		// Create a cancellable context for this chat exchange. It ends
+		// when the client cancels or the WebSocket closes.
 		ctx, cancel := context.WithCancel(r.Context())
+		turn = &chatTurn{cancel: cancel, done: make(chan struct{})}
+		go func(t *chatTurn) {
+			defer close(t.done)
+			defer cancel()
+			defer activeStreams.track("chat")()
+			h.chat(ctx, out, username, msg, t)
+		}(turn)
+	}
+}
 
-		stream, err := h.grpc.Chat.Chat(ctx, &grpcclient.ChatRequest{
-			Message:    msg.Message,
-			Collection: msg.Collection,
-			Model:      msg.Model,
-			PiiEnabled: msg.PIIEnabled,
-		})
-		if err != nil {
-			cancel()
-			h.writeWSError(conn, "failed to start chat: "+err.Error())
-			continue
+// chat runs one chat turn: it routes the question if asked to, checks
+// collection locks and options, opens the gRPC Chat stream and relays its
+// events.
+func (h *WSHandler) chat(ctx context.Context, out *wsWriter, username string, msg wsMessage, turn *chatTurn) {
+	if h.grpc.Chat == nil {
+		writeWSError(out, "chat service not available")
+		return
+	}
+	if svc, ok := unsupportedService(h.grpc, grpcclient.ServiceChat); !ok {
+		writeWSError(out, unsupportedMessage(h.grpc, svc))
+		return
+	}
+
+	collections := []string{msg.Collection}
+	switch msg.Route {
+	case "":
+	case api.ChatRouteAuto:
+		routed, ok := h.route(ctx, out, msg)
+		if !ok {
+			return
 		}
+		collections = routed
+	default:
+		writeWSError(out, `route must be "auto" or empty`)
+		return
+	}
+	for i, coll := range collections {
+		if coll == "" {
+			collections[i] = workerDefaultCodebaseCollection
+		}
+		if l, locked := h.tm.CollectionLock(collections[i]); locked {
+			if l.Mode == tasks.LockBlock {
+				writeWSError(out, "collection "+collections[i]+" is being reindexed, please retry later")
+				return
+			}
+			out.send(wsEvent{Type: "warning", Content: "collection " + collections[i] + " is being reindexed; results may be incomplete"})
+		}
+	}
 
-		// Stream gRPC events to the WebSocket.
-		h.streamToWS(conn, stream)
+	opts := msg.ChatOptions.Merge(h.prefs.Defaults(username))
+	if err := validateChatOptions(opts); err != nil {
+		writeWSError(out, err.Error())
+		return
+	}
+	flush, err := h.flush.override(msg.FlushMS, msg.FlushBytes)
+	if err != nil {
+		writeWSError(out, err.Error())
+		return
+	}
+	ctx = grpcclient.WithChatCollections(ctx, collections)
+	ctx, err = h.instances.pin(ctx, msg.Instance)
+	if err != nil {
+		writeWSError(out, err.Error())
+		return
+	}
+
+	chatReq := &grpcclient.ChatRequest{
+		Message:    msg.Message,
+		Collection: collections[0],
+		Model:      msg.Model,
+		PiiEnabled: msg.PIIEnabled,
+	}
+	opts.Apply(chatReq)
+	stream, err := h.grpc.Chat.Chat(ctx, chatReq)
+	if err != nil && grpcclient.IsUnavailable(err) {
+		// Nothing has been sent yet, so it is safe to wait for a
+		// restarting worker and try once more.
+		out.send(wsEvent{Type: "status", Content: "worker reconnecting"})
+		if h.grpc.WaitReady(ctx, chatReconnectTimeout) {
+			stream, err = h.grpc.Chat.Chat(ctx, chatReq)
+		}
+	}
+	if err != nil {
+		switch {
+		case turn.cancelled.Load():
+			out.send(wsEvent{Type: "cancelled", Content: "Request cancelled by client"})
+		case grpcclient.IsUnavailable(err):
+			out.send(wsEvent{Type: "error", Content: "chat service unavailable, please retry", Retryable: true})
+		default:
+			writeWSError(out, "failed to start chat: "+err.Error())
+		}
+		return
+	}
+	defer stream.Close()
+
+	// Stream gRPC events to the WebSocket.
+	var ground func(string, []*grpcclient.SearchHit) *ChatGrounding
+	if h.grounding != nil {
+		ground = func(answer string, hits []*grpcclient.SearchHit) *ChatGrounding {
+			g, err := h.grounding.Score(ctx, answer, hits, msg.Instance)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("chat grounding: %v", err)
+				}
+				return nil
+			}
+			return g
+		}
+	}
+	streamToWS(out, stream, turn, flush, func(hits []*grpcclient.SearchHit) []Citation {
+		return h.cite.EnrichAcross(ctx, collections, hits)
+	}, ground)
+}
 
-		stream.Close()
-		cancel()
+// route picks the collections of a turn with route "auto" and announces
+// them in a "route" event. When no collection can be scored the turn falls
+// back to its collection, with a warning. It reports false after sending
+// an error.
+func (h *WSHandler) route(ctx context.Context, out *wsWriter, msg wsMessage) ([]string, bool) {
+	limit := msg.RouteMax
+	if limit == 0 {
+		limit = 1
 	}
+	if limit < 1 || limit > maxRouteCollections {
+		writeWSError(out, fmt.Sprintf("route_max must be between 1 and %d", maxRouteCollections))
+		return nil, false
+	}
+	if h.router == nil {
+		writeWSError(out, "chat routing not available")
+		return nil, false
+	}
+	route, err := h.router.Route(ctx, msg.Message, msg.Instance, msg.RouteCollections, limit)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false
+		}
+		fallback := msg.Collection
+		if fallback == "" {
+			fallback = workerDefaultCodebaseCollection
+		}
+		out.send(wsEvent{Type: api.ChatEventWarning, Content: "routing failed (" + err.Error() + "); answering from " + fallback})
+		return []string{fallback}, true
+	}
+	out.send(wsEvent{Type: api.ChatEventRoute, Content: strings.Join(route.Collections, ", "), Route: route})
+	return route.Collections, true
 }
 
-// streamToWS reads from the gRPC stream and writes each event as a JSON
-// frame on the WebSocket.
-func (h *WSHandler) streamToWS(conn *websocket.Conn, stream grpcclient.ChatStream) {
+// streamToWS reads from the gRPC stream and queues each event as a JSON
+// frame on the WebSocket. Queuing never blocks, so a slow client cannot
+// stall the stream; its token chunks are coalesced instead. Token chunks
+// are also coalesced as flush says. Sources are passed through cite to
+// attach citations. Unless ground is nil, the answer and its sources are
+// passed to it before the "done" event, which carries the grounding it
+// returns.
+func streamToWS(out *wsWriter, stream grpcclient.ChatStream, turn *chatTurn, flush StreamFlush, cite func([]*grpcclient.SearchHit) []Citation, ground func(string, []*grpcclient.SearchHit) *ChatGrounding) {
+	var (
+		answer  strings.Builder
+		sources []*grpcclient.SearchHit
+	)
+	chunks := newChunkCoalescer(flush, func(s string) bool {
+		return out.send(wsEvent{Type: api.ChatEventChunk, Content: s})
+	})
+	defer chunks.close()
+	send := func(evt wsEvent) (ok bool) {
+		chunks.do(func() { ok = out.send(evt) })
+		return ok
+	}
 	for {
 		event, err := stream.Recv()
 		if err == io.EOF {
 			return
 		}
 		if err != nil {
-			h.writeWSError(conn, "stream error: "+err.Error())
+			if turn.cancelled.Load() {
+				send(wsEvent{Type: "cancelled", Content: "Request cancelled by client"})
+				return
+			}
+			// A worker restart mid-answer cannot be resumed transparently
+			// (partial output was already sent), so let the client resend.
+			if grpcclient.IsUnavailable(err) {
+				send(wsEvent{Type: "error", Content: "worker restarted during response, please retry", Retryable: true})
+				return
+			}
+			send(wsEvent{Type: "error", Content: "stream error: " + err.Error()})
 			return
 		}
 
+		if ground != nil && event.Type == api.ChatEventChunk && answer.Len() < maxGroundingAnswer {
+			answer.WriteString(event.Content)
+		}
+		if event.Type == api.ChatEventChunk {
+			if !chunks.add(event.Content) {
+				return
+			}
+			continue
+		}
+
 		wsEvt := wsEvent{
 			Type:             event.Type,
 			Content:          event.Content,
@@ -129,24 +360,23 @@ func (h *WSHandler) streamToWS(conn *websocket.Conn, stream grpcclient.ChatStrea
 			PIIEntitiesCount: event.PiiEntitiesCount,
 		}
 		if len(event.Sources) > 0 {
-			wsEvt.Sources = make([]grpcclient.SearchHit, len(event.Sources))
-			for i, s := range event.Sources {
-				wsEvt.Sources[i] = *s
-			}
+			wsEvt.Sources = event.Sources
+			wsEvt.Citations = cite(event.Sources)
+			sources = event.Sources
+		}
+		if ground != nil && event.Type == api.ChatEventDone {
+			wsEvt.Grounding = ground(answer.String(), sources)
 		}
 
-		data, _ := json.Marshal(wsEvt)
-		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-			log.Printf("websocket write error: %v", err)
+		if !send(wsEvt) {
 			return
 		}
 	}
 }
 
-func (h *WSHandler) writeWSError(conn *websocket.Conn, msg string) {
-	data, _ := json.Marshal(wsEvent{
+func writeWSError(out *wsWriter, msg string) {
+	out.send(wsEvent{
 		Type:    "error",
 		Content: msg,
 	})
-	conn.WriteMessage(websocket.TextMessage, data)
 }
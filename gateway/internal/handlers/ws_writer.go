@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteTimeout bounds a single frame write; a client that cannot
+	// accept a frame in this time is disconnected.
+	wsWriteTimeout = 10 * time.Second
+
+	// wsMaxQueue caps queued non-token events. Token chunks never grow the
+	// queue because they are merged, so reaching this means the client has
+	// stopped reading.
+	wsMaxQueue = 256
+)
+
+// wsWriter serialises writes to a WebSocket on a dedicated goroutine so the
+// gRPC Recv loop never blocks on a slow client. While the client is behind,
+// consecutive "chunk" events are coalesced into one frame instead of piling
+// up.
+type wsWriter struct {
+	conn *websocket.Conn
+
+	mu     sync.Mutex
+	queue  []wsEvent
+	closed bool
+
+	wake chan struct{}
+	done chan struct{}
+	exit chan struct{}
+}
+
+func newWSWriter(conn *websocket.Conn) *wsWriter {
+	w := &wsWriter{
+		conn: conn,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+		exit: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// send queues evt without blocking. It returns false once the writer has
+// stopped (connection closed or client stalled).
+func (w *wsWriter) send(evt wsEvent) bool {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return false
+	}
+	if n := len(w.queue); n > 0 && evt.Type == "chunk" && w.queue[n-1].Type == "chunk" {
+		w.queue[n-1].Content += evt.Content
+	} else if n >= wsMaxQueue {
+		w.stopLocked()
+		w.mu.Unlock()
+		log.Printf("websocket client stalled, closing connection")
+		w.conn.Close() // also aborts a write in progress
+		return false
+	} else {
+		w.queue = append(w.queue, evt)
+	}
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// close stops the writer goroutine and waits for it to exit. Queued events
+// that were not written yet are discarded.
+func (w *wsWriter) close() {
+	w.mu.Lock()
+	w.stopLocked()
+	w.mu.Unlock()
+	<-w.exit
+}
+
+func (w *wsWriter) stopLocked() {
+	if !w.closed {
+		w.closed = true
+		close(w.done)
+	}
+}
+
+func (w *wsWriter) run() {
+	defer close(w.exit)
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.wake:
+		}
+
+		w.mu.Lock()
+		batch := w.queue
+		w.queue = nil
+		w.mu.Unlock()
+
+		for _, evt := range batch {
+			data, _ := json.Marshal(evt)
+			w.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := w.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				log.Printf("websocket write error: %v", err)
+				w.mu.Lock()
+				w.stopLocked()
+				w.mu.Unlock()
+				w.conn.Close() // unblocks the read loop
+				return
+			}
+		}
+	}
+}
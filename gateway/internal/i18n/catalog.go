@@ -0,0 +1,181 @@
+package i18n
+
+// entry is one catalog message. en is the text call sites write, with %s,
+// %q, %d or %v for arguments; translations refer to them as %[1]s, %[2]s.
+type entry struct {
+	key          string
+	en           string
+	translations map[string]string
+}
+
+// catalog lists the translated messages. More specific messages go first,
+// since the first match wins.
+var catalog = []entry{
+	// Requests
+	{"invalid_json", "invalid JSON body", map[string]string{
+		"pt-BR": "corpo JSON inválido",
+		"es":    "cuerpo JSON no válido",
+	}},
+	{"invalid_header", "invalid %s header", map[string]string{
+		"pt-BR": "cabeçalho %[1]s inválido",
+		"es":    "encabezado %[1]s no válido",
+	}},
+	{"invalid_path", "invalid path", map[string]string{
+		"pt-BR": "caminho inválido",
+		"es":    "ruta no válida",
+	}},
+	{"name_required", "name is required", map[string]string{
+		"pt-BR": "o nome é obrigatório",
+		"es":    "el nombre es obligatorio",
+	}},
+	{"model_name_required", "model name is required", map[string]string{
+		"pt-BR": "o nome do modelo é obrigatório",
+		"es":    "el nombre del modelo es obligatorio",
+	}},
+
+	// Authentication
+	{"auth_required", "authentication required", map[string]string{
+		"pt-BR": "autenticação necessária",
+		"es":    "se requiere autenticación",
+	}},
+	{"invalid_token", "invalid or expired token", map[string]string{
+		"pt-BR": "token inválido ou expirado",
+		"es":    "token no válido o caducado",
+	}},
+	{"session_revoked", "session revoked", map[string]string{
+		"pt-BR": "sessão revogada",
+		"es":    "sesión revocada",
+	}},
+	{"admin_required", "admin access required", map[string]string{
+		"pt-BR": "acesso de administrador necessário",
+		"es":    "se requiere acceso de administrador",
+	}},
+
+	// Not found
+	{"collection_reindexing", "collection %s is being reindexed (task %s)", map[string]string{
+		"pt-BR": "a coleção %[1]s está sendo reindexada (tarefa %[2]s)",
+		"es":    "la colección %[1]s se está reindexando (tarea %[2]s)",
+	}},
+	{"collection_missing", "collection %s not found; index files into it first or search another collection", map[string]string{
+		"pt-BR": "coleção %[1]s não encontrada; indexe arquivos nela primeiro ou pesquise em outra coleção",
+		"es":    "colección %[1]s no encontrada; indexe archivos en ella primero o busque en otra colección",
+	}},
+	{"collection_empty", "collection %s exists but has 0 points; run an index into it first", map[string]string{
+		"pt-BR": "a coleção %[1]s existe, mas não tem pontos; execute uma indexação nela primeiro",
+		"es":    "la colección %[1]s existe pero no tiene puntos; ejecute primero una indexación en ella",
+	}},
+	{"collection_not_found", "collection %s not found", map[string]string{
+		"pt-BR": "coleção %[1]s não encontrada",
+		"es":    "colección %[1]s no encontrada",
+	}},
+	{"task_not_found", "task %s not found", map[string]string{
+		"pt-BR": "tarefa %[1]s não encontrada",
+		"es":    "tarea %[1]s no encontrada",
+	}},
+	{"share_not_found", "share %s not found", map[string]string{
+		"pt-BR": "compartilhamento %[1]s não encontrado",
+		"es":    "recurso compartido %[1]s no encontrado",
+	}},
+	{"template_not_found", "template %s not found", map[string]string{
+		"pt-BR": "modelo %[1]s não encontrado",
+		"es":    "plantilla %[1]s no encontrada",
+	}},
+	{"ignore_profile_not_found", "ignore profile %s not found", map[string]string{
+		"pt-BR": "perfil de exclusão %[1]s não encontrado",
+		"es":    "perfil de exclusión %[1]s no encontrado",
+	}},
+	{"artifact_not_found", "artifact %s not found", map[string]string{
+		"pt-BR": "artefato %[1]s não encontrado",
+		"es":    "artefacto %[1]s no encontrado",
+	}},
+	{"session_not_found", "session %s not found", map[string]string{
+		"pt-BR": "sessão %[1]s não encontrada",
+		"es":    "sesión %[1]s no encontrada",
+	}},
+	{"file_not_found", "file not found", map[string]string{
+		"pt-BR": "arquivo não encontrado",
+		"es":    "archivo no encontrado",
+	}},
+	{"no_pull", "no pull in progress for %s", map[string]string{
+		"pt-BR": "nenhum download em andamento para %[1]s",
+		"es":    "no hay ninguna descarga en curso para %[1]s",
+	}},
+
+	// Tasks and models
+	{"task_cannot_retry", "task %s is in state %s, cannot retry", map[string]string{
+		"pt-BR": "a tarefa %[1]s está no estado %[2]s e não pode ser repetida",
+		"es":    "la tarea %[1]s está en estado %[2]s y no se puede reintentar",
+	}},
+	{"model_in_use", "model %s is in use by the worker for %s; pass ?force=true to delete anyway", map[string]string{
+		"pt-BR": "o modelo %[1]s está em uso pelo worker para %[2]s; use ?force=true para excluí-lo mesmo assim",
+		"es":    "el modelo %[1]s está en uso por el worker para %[2]s; use ?force=true para eliminarlo de todos modos",
+	}},
+
+	// Uploads
+	{"no_files", "no files provided in 'files' field", map[string]string{
+		"pt-BR": "nenhum arquivo enviado no campo 'files'",
+		"es":    "no se enviaron archivos en el campo 'files'",
+	}},
+	{"file_extension_not_allowed", "file extension %s is not allowed", map[string]string{
+		"pt-BR": "a extensão de arquivo %[1]s não é permitida",
+		"es":    "la extensión de archivo %[1]s no está permitida",
+	}},
+	{"upload_save_failed", "failed to save uploaded file", map[string]string{
+		"pt-BR": "falha ao salvar o arquivo enviado",
+		"es":    "no se pudo guardar el archivo subido",
+	}},
+
+	// Upstreams
+	{"service_unavailable", "%s service not available", map[string]string{
+		"pt-BR": "serviço %[1]s indisponível",
+		"es":    "servicio %[1]s no disponible",
+	}},
+	{"worker_deadline", "worker deadline exceeded", map[string]string{
+		"pt-BR": "prazo do worker excedido",
+		"es":    "se superó el plazo del worker",
+	}},
+	{"qdrant_response_invalid", "failed to parse qdrant response", map[string]string{
+		"pt-BR": "falha ao interpretar a resposta do Qdrant",
+		"es":    "no se pudo interpretar la respuesta de Qdrant",
+	}},
+	{"qdrant_error", "qdrant error: %v", map[string]string{
+		"pt-BR": "erro do Qdrant: %[1]s",
+		"es":    "error de Qdrant: %[1]s",
+	}},
+	{"ollama_error", "ollama error: %v", map[string]string{
+		"pt-BR": "erro do Ollama: %[1]s",
+		"es":    "error de Ollama: %[1]s",
+	}},
+	{"grpc_error", "grpc error: %v", map[string]string{
+		"pt-BR": "erro de gRPC: %[1]s",
+		"es":    "error de gRPC: %[1]s",
+	}},
+}
+
+// statusLabels are the display labels of task statuses.
+var statusLabels = map[string]map[string]string{
+	"en": {
+		"pending":      "Pending",
+		"running":      "Running",
+		"reconnecting": "Reconnecting",
+		"completed":    "Completed",
+		"failed":       "Failed",
+		"cancelled":    "Cancelled",
+	},
+	"pt-BR": {
+		"pending":      "Pendente",
+		"running":      "Em execução",
+		"reconnecting": "Reconectando",
+		"completed":    "Concluída",
+		"failed":       "Com falha",
+		"cancelled":    "Cancelada",
+	},
+	"es": {
+		"pending":      "Pendiente",
+		"running":      "En ejecución",
+		"reconnecting": "Reconectando",
+		"completed":    "Completada",
+		"failed":       "Fallida",
+		"cancelled":    "Cancelada",
+	},
+}
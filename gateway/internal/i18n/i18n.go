@@ -0,0 +1,161 @@
+// Package i18n localizes API error details and task status labels.
+//
+// The language is negotiated from Accept-Language by Middleware and
+// recorded in the response's Content-Language header, which is where the
+// error writers pick it up; they do not see the request. Messages are
+// looked up by their English text, so call sites keep writing English and
+// unknown messages pass through untranslated. A matched message also
+// yields a stable key and its arguments, for clients that localize
+// themselves.
+package i18n
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the language used when negotiation finds no match.
+const Default = "en"
+
+// Supported lists the languages with a catalog, Default first.
+var Supported = []string{"en", "pt-BR", "es"}
+
+// Negotiate picks the supported language that best matches an
+// Accept-Language header. A tag matches exactly, or by its primary
+// subtag ("pt-PT" gets "pt-BR", "es-419" gets "es").
+func Negotiate(header string) string {
+	type tag struct {
+		name string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.TrimSpace(fields[0])
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if p, err := strconv.ParseFloat(v, 64); err == nil {
+					q = p
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{name, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if t.name == "*" {
+			return Default
+		}
+		for _, s := range Supported {
+			if strings.EqualFold(t.name, s) {
+				return s
+			}
+		}
+		primary, _, _ := strings.Cut(t.name, "-")
+		for _, s := range Supported {
+			if sp, _, _ := strings.Cut(s, "-"); strings.EqualFold(primary, sp) {
+				return s
+			}
+		}
+	}
+	return Default
+}
+
+// Middleware negotiates the response language. Requests with an
+// Accept-Language header get a Content-Language header naming it; others
+// are answered in Default without one.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		if accept := r.Header.Get("Accept-Language"); accept != "" {
+			w.Header().Set("Content-Language", Negotiate(accept))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ResponseLang returns the language Middleware chose for a response.
+func ResponseLang(h http.Header) string {
+	if lang := h.Get("Content-Language"); lang != "" {
+		return lang
+	}
+	return Default
+}
+
+// RequestLang returns the language negotiated for a request.
+func RequestLang(r *http.Request) string {
+	return Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// Message is a localized message. Key and Args are empty for messages the
+// catalog does not know, whose Text is the original.
+type Message struct {
+	Text string
+	Key  string
+	Args []string
+}
+
+// compiled is a catalog entry with a pattern matching its English text.
+type compiled struct {
+	entry
+	re *regexp.Regexp
+}
+
+var (
+	verb     = regexp.MustCompile(`%[sqdv]`)
+	patterns = compile(catalog)
+)
+
+func compile(entries []entry) []compiled {
+	out := make([]compiled, 0, len(entries))
+	for _, e := range entries {
+		parts := verb.Split(e.en, -1)
+		for i, p := range parts {
+			parts[i] = regexp.QuoteMeta(p)
+		}
+		out = append(out, compiled{e, regexp.MustCompile("(?s)^" + strings.Join(parts, "(.+?)") + "$")})
+	}
+	return out
+}
+
+// Localize translates an English message into lang.
+func Localize(lang, text string) Message {
+	for _, c := range patterns {
+		m := c.re.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		msg := Message{Text: text, Key: c.key, Args: m[1:]}
+		if tmpl, ok := c.translations[lang]; ok {
+			args := make([]interface{}, len(msg.Args))
+			for i, a := range msg.Args {
+				args[i] = a
+			}
+			msg.Text = fmt.Sprintf(tmpl, args...)
+		}
+		return msg
+	}
+	return Message{Text: text}
+}
+
+// StatusLabel returns the display label of a task status in lang.
+func StatusLabel(lang, status string) string {
+	if l, ok := statusLabels[lang][status]; ok {
+		return l
+	}
+	if l, ok := statusLabels[Default][status]; ok {
+		return l
+	}
+	return status
+}
@@ -0,0 +1,184 @@
+// Package ignore matches root-relative paths against gitignore-style
+// patterns. The worker implements the same rules in discovery.py, so a
+// pattern skips the same files in gateway scans and in worker indexing.
+//
+// Entries of an extra_skip_dirs list are read two ways: a plain name such
+// as "fixtures" skips directories with that name at any depth, as before;
+// anything containing one of *?[]/!\ is a gitignore pattern.
+package ignore
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// MaxPatternLen bounds a single pattern.
+const MaxPatternLen = 512
+
+// IsPattern reports whether entry is a gitignore pattern rather than a
+// plain directory name.
+func IsPattern(entry string) bool {
+	return strings.ContainsAny(entry, `*?[]/!\`)
+}
+
+// Rule is one compiled gitignore pattern.
+type Rule struct {
+	Source  string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Compile parses a gitignore pattern:
+//
+//   - a leading "!" re-includes what earlier patterns excluded;
+//   - a trailing "/" matches directories only;
+//   - a pattern with a "/" anywhere else is anchored at the root, otherwise
+//     it matches the last path element at any depth;
+//   - "*" and "?" do not cross "/", "**" does, "[...]" is a character class
+//     ("[!...]" negated) and "\" escapes the next character.
+func Compile(pattern string) (Rule, error) {
+	r := Rule{Source: pattern}
+	p := strings.TrimRight(pattern, " \t")
+	switch {
+	case p == "":
+		return r, fmt.Errorf("empty pattern")
+	case len(p) > MaxPatternLen:
+		return r, fmt.Errorf("pattern longer than %d characters", MaxPatternLen)
+	case strings.HasPrefix(p, "#"):
+		return r, fmt.Errorf("%q is a comment, not a pattern", pattern)
+	}
+	if strings.HasPrefix(p, "!") {
+		r.negate = true
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "/") && !strings.HasSuffix(p, `\/`) {
+		r.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return r, fmt.Errorf("%q matches nothing", pattern)
+	}
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				atStart := i == 0 || p[i-1] == '/'
+				switch {
+				case atStart && i+2 < len(p) && p[i+2] == '/':
+					b.WriteString("(?:.*/)?")
+					i += 2
+				case atStart && i+2 == len(p):
+					b.WriteString(".*")
+					i++
+				default:
+					b.WriteString("[^/]*")
+					i++
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := classEnd(p, i)
+			if end < 0 {
+				return r, fmt.Errorf("%q: unterminated character class", pattern)
+			}
+			class := p[i+1 : end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i = end
+		case '\\':
+			if i+1 == len(p) {
+				return r, fmt.Errorf("%q: trailing backslash", pattern)
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return r, fmt.Errorf("%q: %v", pattern, err)
+	}
+	r.re = re
+	return r, nil
+}
+
+// classEnd returns the index of the "]" closing the class opened at i, or
+// -1. A "]" right after "[" or "[!" is a literal.
+func classEnd(p string, i int) int {
+	j := i + 1
+	if j < len(p) && p[j] == '!' {
+		j++
+	}
+	if j < len(p) && p[j] == ']' {
+		j++
+	}
+	for ; j < len(p); j++ {
+		if p[j] == ']' {
+			return j
+		}
+	}
+	return -1
+}
+
+// Matcher decides which paths a walk skips.
+type Matcher struct {
+	dirNames map[string]bool
+	rules    []Rule
+}
+
+// New builds a Matcher from extra_skip_dirs entries, see the package doc.
+func New(entries []string) (*Matcher, error) {
+	m := &Matcher{dirNames: make(map[string]bool)}
+	for _, e := range entries {
+		if !IsPattern(e) {
+			if e = strings.TrimSpace(e); e != "" {
+				m.dirNames[e] = true
+			}
+			continue
+		}
+		r, err := Compile(e)
+		if err != nil {
+			return nil, err
+		}
+		m.rules = append(m.rules, r)
+	}
+	return m, nil
+}
+
+// Ignored reports whether rel, a root-relative slash path, is skipped.
+// skipByDefault is the caller's built-in verdict (e.g. a default skip
+// directory); patterns apply after it in order, so "!vendor/" re-includes a
+// default-skipped directory.
+func (m *Matcher) Ignored(rel string, isDir, skipByDefault bool) bool {
+	ignored := skipByDefault || (isDir && m.dirNames[path.Base(rel)])
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
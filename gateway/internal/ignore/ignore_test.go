@@ -0,0 +1,76 @@
+package ignore
+
+import "testing"
+
+func TestMatcherIgnored(t *testing.T) {
+	tests := []struct {
+		name          string
+		entries       []string
+		rel           string
+		isDir         bool
+		skipByDefault bool
+		want          bool
+	}{
+		{"plain name at root", []string{"fixtures"}, "fixtures", true, false, true},
+		{"plain name nested", []string{"fixtures"}, "a/b/fixtures", true, false, true},
+		{"plain name skips dirs only", []string{"fixtures"}, "fixtures", false, false, false},
+		{"unanchored glob", []string{"*.min.js"}, "static/app.min.js", false, false, true},
+		{"star stays in element", []string{"src/*.go"}, "src/a/b.go", false, false, false},
+		{"anchored at root", []string{"/docs/"}, "docs", true, false, true},
+		{"anchored misses nested", []string{"/docs/"}, "src/docs", true, false, false},
+		{"inner slash anchors", []string{"src/gen"}, "lib/src/gen", true, false, false},
+		{"dir only skips dir", []string{"build/"}, "out/build", true, false, true},
+		{"dir only keeps file", []string{"build/"}, "out/build", false, false, false},
+		{"double star prefix", []string{"**/testdata"}, "a/b/testdata", true, false, true},
+		{"double star middle", []string{"src/**/gen/"}, "src/gen", true, false, true},
+		{"double star deep", []string{"src/**/gen/"}, "src/a/b/gen", true, false, true},
+		{"double star suffix", []string{"logs/**"}, "logs/2024/x.log", false, false, true},
+		{"question mark", []string{"file?.txt"}, "file1.txt", false, false, true},
+		{"character class", []string{"log[0-9].txt"}, "log3.txt", false, false, true},
+		{"negated class", []string{"tmp[!a].py"}, "tmpa.py", false, false, false},
+		{"escaped star", []string{`\*.txt`}, "*.txt", false, false, true},
+		{"escaped star literal only", []string{`\*.txt`}, "a.txt", false, false, false},
+		{"negation re-includes default", []string{"!vendor/"}, "vendor", true, true, false},
+		{"default skip without rules", nil, "vendor", true, true, true},
+		{"negation re-includes earlier", []string{"*.md", "!README.md"}, "README.md", false, false, false},
+		{"negation leaves others", []string{"*.md", "!README.md"}, "CHANGES.md", false, false, true},
+		{"later rule wins", []string{"!keep.md", "*.md"}, "keep.md", false, false, true},
+		{"negated dir only keeps file skipped", []string{"*.gen", "!x.gen/"}, "x.gen", false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.entries)
+			if err != nil {
+				t.Fatalf("New(%q): %v", tt.entries, err)
+			}
+			if got := m.Ignored(tt.rel, tt.isDir, tt.skipByDefault); got != tt.want {
+				t.Errorf("Ignored(%q, dir=%v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, p := range []string{"", "   ", "# comment", "!", "/", "[abc", `foo\`} {
+		if _, err := Compile(p); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", p)
+		}
+	}
+}
+
+func TestIsPattern(t *testing.T) {
+	tests := map[string]bool{
+		"node_modules": false,
+		"build-output": false,
+		"*.log":        true,
+		"docs/":        true,
+		"!vendor":      true,
+		"a?b":          true,
+		`a\b`:          true,
+	}
+	for entry, want := range tests {
+		if got := IsPattern(entry); got != want {
+			t.Errorf("IsPattern(%q) = %v, want %v", entry, got, want)
+		}
+	}
+}
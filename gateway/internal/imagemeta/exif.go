@@ -0,0 +1,304 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+// EXIF tags read by the gateway. Everything else is ignored.
+const (
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagOrientation      = 0x0112
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagOffsetTimeOrig   = 0x9011
+
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+	tagGPSAltitudeRef  = 0x0005
+	tagGPSAltitude     = 0x0006
+)
+
+// maxIFDEntries guards against corrupt directories claiming huge counts.
+const maxIFDEntries = 512
+
+var errNoExif = errors.New("no EXIF data")
+
+// exifData holds the fields decoded from a TIFF/EXIF block.
+type exifData struct {
+	make, model string
+	orientation int
+	taken       time.Time
+	lat, lon    float64
+	hasGPS      bool
+	alt         float64
+	hasAlt      bool
+}
+
+// findExif locates the raw TIFF-structured EXIF block inside a JPEG, PNG,
+// WebP or TIFF file.
+func findExif(data []byte) ([]byte, error) {
+	switch {
+	case len(data) > 4 && data[0] == 0xFF && data[1] == 0xD8:
+		return jpegExif(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return pngExif(data)
+	case len(data) > 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return webpExif(data)
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return data, nil
+	}
+	return nil, errNoExif
+}
+
+func jpegExif(data []byte) ([]byte, error) {
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return nil, errNoExif
+		}
+		marker := data[i+1]
+		if marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 || marker == 0xFF {
+			i++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image
+			break
+		}
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			break
+		}
+		seg := data[i+4 : i+2+n]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return seg[6:], nil
+		}
+		i += 2 + n
+	}
+	return nil, errNoExif
+}
+
+func pngExif(data []byte) ([]byte, error) {
+	i := 8
+	for i+8 <= len(data) {
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		typ := string(data[i+4 : i+8])
+		if n < 0 || i+8+n > len(data) {
+			break
+		}
+		if typ == "eXIf" {
+			return data[i+8 : i+8+n], nil
+		}
+		if typ == "IDAT" || typ == "IEND" {
+			break
+		}
+		i += 12 + n // length, type, data, crc
+	}
+	return nil, errNoExif
+}
+
+func webpExif(data []byte) ([]byte, error) {
+	i := 12
+	for i+8 <= len(data) {
+		typ := string(data[i : i+4])
+		n := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if n < 0 || i+8+n > len(data) {
+			break
+		}
+		if typ == "EXIF" {
+			// Some encoders keep the JPEG APP1 prefix.
+			return bytes.TrimPrefix(data[i+8:i+8+n], []byte("Exif\x00\x00")), nil
+		}
+		i += 8 + n + n%2 // chunks are padded to even sizes
+	}
+	return nil, errNoExif
+}
+
+// tiffReader decodes IFD entries from a TIFF block with bounds checking.
+type tiffReader struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // raw value bytes, resolved from the offset when needed
+}
+
+// typeSizes maps TIFF field types to their size in bytes.
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+func parseExif(b []byte) (*exifData, error) {
+	if len(b) < 8 {
+		return nil, errNoExif
+	}
+	r := &tiffReader{b: b}
+	switch string(b[0:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return nil, errors.New("invalid TIFF header")
+	}
+	if r.order.Uint16(b[2:]) != 42 {
+		return nil, errors.New("invalid TIFF header")
+	}
+
+	ifd0 := r.readIFD(r.order.Uint32(b[4:]))
+	if ifd0 == nil {
+		return nil, errNoExif
+	}
+
+	out := &exifData{
+		make:        r.str(ifd0[tagMake]),
+		model:       r.str(ifd0[tagModel]),
+		orientation: int(r.uint(ifd0[tagOrientation])),
+	}
+	date, offset := r.str(ifd0[tagDateTime]), ""
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if sub := r.readIFD(r.uint(e)); sub != nil {
+			if d := r.str(sub[tagDateTimeOriginal]); d != "" {
+				date = d
+				offset = r.str(sub[tagOffsetTimeOrig])
+			}
+		}
+	}
+	out.taken = parseExifTime(date, offset)
+
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if gps := r.readIFD(r.uint(e)); gps != nil {
+			lat, latOK := r.degrees(gps[tagGPSLatitude])
+			lon, lonOK := r.degrees(gps[tagGPSLongitude])
+			if latOK && lonOK && lat <= 90 && lon <= 180 {
+				if strings.EqualFold(r.str(gps[tagGPSLatitudeRef]), "S") {
+					lat = -lat
+				}
+				if strings.EqualFold(r.str(gps[tagGPSLongitudeRef]), "W") {
+					lon = -lon
+				}
+				out.lat, out.lon, out.hasGPS = lat, lon, true
+			}
+			if alt, ok := r.rational(gps[tagGPSAltitude], 0); ok {
+				if ref := gps[tagGPSAltitudeRef]; len(ref.value) > 0 && ref.value[0] == 1 {
+					alt = -alt // below sea level
+				}
+				out.alt, out.hasAlt = alt, true
+			}
+		}
+	}
+	return out, nil
+}
+
+// readIFD returns the entries of the directory at off keyed by tag, or nil if
+// the offset is out of range.
+func (r *tiffReader) readIFD(off uint32) map[uint16]ifdEntry {
+	if off < 8 || int(off)+2 > len(r.b) {
+		return nil
+	}
+	n := int(r.order.Uint16(r.b[off:]))
+	if n > maxIFDEntries {
+		return nil
+	}
+	entries := make(map[uint16]ifdEntry, n)
+	for i := 0; i < n; i++ {
+		p := int(off) + 2 + i*12
+		if p+12 > len(r.b) {
+			break
+		}
+		tag := r.order.Uint16(r.b[p:])
+		typ := r.order.Uint16(r.b[p+2:])
+		count := r.order.Uint32(r.b[p+4:])
+		size, ok := typeSizes[typ]
+		if !ok || count > uint32(len(r.b)) {
+			continue
+		}
+		total := size * int(count)
+		var val []byte
+		if total <= 4 {
+			val = r.b[p+8 : p+8+total]
+		} else {
+			vo := int(r.order.Uint32(r.b[p+8:]))
+			if vo < 0 || vo+total > len(r.b) {
+				continue
+			}
+			val = r.b[vo : vo+total]
+		}
+		entries[tag] = ifdEntry{typ: typ, count: count, value: val}
+	}
+	return entries
+}
+
+func (r *tiffReader) str(e ifdEntry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	s := string(e.value)
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func (r *tiffReader) uint(e ifdEntry) uint32 {
+	switch {
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(r.order.Uint16(e.value))
+	case (e.typ == 4 || e.typ == 9) && len(e.value) >= 4:
+		return r.order.Uint32(e.value)
+	case (e.typ == 1 || e.typ == 7) && len(e.value) >= 1:
+		return uint32(e.value[0])
+	}
+	return 0
+}
+
+// rational returns the i-th RATIONAL value of e.
+func (r *tiffReader) rational(e ifdEntry, i int) (float64, bool) {
+	if e.typ != 5 || len(e.value) < (i+1)*8 {
+		return 0, false
+	}
+	num := r.order.Uint32(e.value[i*8:])
+	den := r.order.Uint32(e.value[i*8+4:])
+	if den == 0 {
+		return 0, false
+	}
+	return float64(num) / float64(den), true
+}
+
+// degrees converts a GPS degrees/minutes/seconds triple to decimal degrees.
+func (r *tiffReader) degrees(e ifdEntry) (float64, bool) {
+	d, ok1 := r.rational(e, 0)
+	m, ok2 := r.rational(e, 1)
+	s, ok3 := r.rational(e, 2)
+	if !ok1 || !ok2 || !ok3 {
+		return 0, false
+	}
+	return d + m/60 + s/3600, true
+}
+
+// parseExifTime parses "YYYY:MM:DD HH:MM:SS" with an optional "+HH:MM"
+// offset. Without an offset the camera's local time is reported as UTC.
+func parseExifTime(date, offset string) time.Time {
+	if date == "" {
+		return time.Time{}
+	}
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", date+offset); err == nil {
+			return t
+		}
+	}
+	t, err := time.Parse("2006:01:02 15:04:05", date)
+	if err != nil || t.Year() < 1900 {
+		return time.Time{}
+	}
+	return t
+}
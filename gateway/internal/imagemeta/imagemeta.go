@@ -0,0 +1,217 @@
+// Package imagemeta extracts EXIF and basic image properties (format,
+// dimensions, capture date, camera, GPS position) in the gateway and hands
+// them to the worker as payload metadata for IndexImages and IndexUploads.
+package imagemeta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	_ "image/gif" // register decoders for image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/manifest"
+	"github.com/google/uuid"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// Extensions are the image types the worker indexes.
+var Extensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true,
+	".webp": true, ".bmp": true, ".tiff": true,
+}
+
+// maxReadSize bounds how much of a file is read looking for EXIF.
+const maxReadSize = 32 << 20
+
+// Meta is the per-image payload metadata. Field names are the Qdrant payload
+// keys; Location uses Qdrant's geo point shape so it can back a geo index.
+type Meta struct {
+	Format      string    `json:"format,omitempty"`
+	Width       int       `json:"width,omitempty"`
+	Height      int       `json:"height,omitempty"`
+	TakenAt     string    `json:"taken_at,omitempty"` // RFC 3339
+	CameraMake  string    `json:"camera_make,omitempty"`
+	CameraModel string    `json:"camera_model,omitempty"`
+	Orientation int       `json:"orientation,omitempty"`
+	Location    *GeoPoint `json:"location,omitempty"`
+	Altitude    *float64  `json:"altitude,omitempty"`
+}
+
+// GeoPoint is a WGS84 position in decimal degrees.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Extract reads format, dimensions and EXIF fields from the image at path.
+// Missing EXIF is not an error; an undecodable file is.
+func Extract(path string) (*Meta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxReadSize))
+	if err != nil {
+		return nil, err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	m := &Meta{Format: format, Width: cfg.Width, Height: cfg.Height}
+
+	raw, err := findExif(data)
+	if err != nil {
+		return m, nil
+	}
+	x, err := parseExif(raw)
+	if err != nil {
+		return m, nil
+	}
+	m.CameraMake, m.CameraModel, m.Orientation = x.make, x.model, x.orientation
+	if !x.taken.IsZero() {
+		m.TakenAt = x.taken.Format(time.RFC3339)
+	}
+	if x.hasGPS {
+		m.Location = &GeoPoint{Lat: x.lat, Lon: x.lon}
+	}
+	if x.hasAlt {
+		alt := x.alt
+		m.Altitude = &alt
+	}
+	return m, nil
+}
+
+// Files extracts metadata for each image in paths, keyed by absolute path.
+// Non-images and unreadable files are skipped.
+func Files(paths []string) map[string]*Meta {
+	out := make(map[string]*Meta)
+	for _, p := range paths {
+		if !Extensions[strings.ToLower(filepath.Ext(p))] {
+			continue
+		}
+		add(out, p)
+	}
+	return out
+}
+
+// Dir walks root with the worker's discovery rules and extracts metadata for
+// every image, keyed by absolute path.
+func Dir(root string, extraSkipDirs []string) map[string]*Meta {
+	skip := make(map[string]bool)
+	for _, d := range manifest.DefaultSkipDirs {
+		skip[d] = true
+	}
+	for _, d := range extraSkipDirs {
+		skip[d] = true
+	}
+
+	out := make(map[string]*Meta)
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (skip[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && Extensions[strings.ToLower(filepath.Ext(path))] {
+			add(out, path)
+		}
+		return nil
+	})
+	return out
+}
+
+func add(out map[string]*Meta, path string) {
+	m, err := Extract(path)
+	if err != nil {
+		return
+	}
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	if resolved, err := filepath.EvalSymlinks(key); err == nil {
+		key = resolved
+	}
+	out[key] = m
+}
+
+// Attacher adds extracted metadata to outgoing worker calls. Maps too large
+// for gRPC metadata are written under spillDir, which must be visible to the
+// worker at the same path (the shared uploads volume).
+type Attacher struct {
+	spillDir string
+}
+
+// NewAttacher creates an Attacher spilling large maps into spillDir.
+func NewAttacher(spillDir string) *Attacher {
+	return &Attacher{spillDir: spillDir}
+}
+
+// Attach returns ctx carrying metas and a cleanup func to call once the
+// indexing stream has finished. A nil Attacher or empty map is a no-op.
+func (a *Attacher) Attach(ctx context.Context, metas map[string]*Meta) (context.Context, func()) {
+	noop := func() {}
+	if a == nil || len(metas) == 0 {
+		return ctx, noop
+	}
+	if mctx, ok := grpcclient.WithImageMeta(ctx, metas); ok {
+		return mctx, noop
+	}
+	if a.spillDir == "" {
+		return ctx, noop
+	}
+	encoded, err := json.Marshal(metas)
+	if err != nil {
+		return ctx, noop
+	}
+
+	if err := os.MkdirAll(a.spillDir, 0o755); err != nil {
+		log.Printf("WARNING: image metadata: %v", err)
+		return ctx, noop
+	}
+	path := filepath.Join(a.spillDir, uuid.New().String()+".json")
+	if err := os.WriteFile(path, encoded, 0o644); err != nil {
+		log.Printf("WARNING: image metadata: %v", err)
+		return ctx, noop
+	}
+	return grpcclient.WithImageMetaFile(ctx, path), func() { os.Remove(path) }
+}
+
+// AttachDir extracts metadata for an IndexImages root and attaches it.
+func (a *Attacher) AttachDir(ctx context.Context, root string, extraSkipDirs []string) (context.Context, func()) {
+	if a == nil {
+		return ctx, func() {}
+	}
+	return a.Attach(ctx, Dir(root, extraSkipDirs))
+}
+
+// AttachFiles extracts metadata for IndexUploads paths and attaches it.
+func (a *Attacher) AttachFiles(ctx context.Context, paths []string) (context.Context, func()) {
+	if a == nil {
+		return ctx, func() {}
+	}
+	return a.Attach(ctx, Files(paths))
+}
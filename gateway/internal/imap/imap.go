@@ -0,0 +1,359 @@
+// Package imap is a small read-only IMAP4rev1 client: it signs in, opens a
+// folder with EXAMINE, searches it by UID and fetches whole messages. It
+// covers what the mailbox connector needs and nothing more.
+package imap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Connection security modes.
+const (
+	SecurityTLS      = "tls"      // TLS from the start, usually port 993
+	SecurityStartTLS = "starttls" // STARTTLS after the greeting, usually port 143
+	SecurityNone     = "none"     // plain text
+)
+
+// commandTimeout bounds one command and its responses.
+const commandTimeout = 2 * time.Minute
+
+// fetchBatch is how many UIDs one UID FETCH of sizes names.
+const fetchBatch = 500
+
+var (
+	literalRe = regexp.MustCompile(`\{(\d+)\+?\}$`)
+	uidRe     = regexp.MustCompile(`\bUID (\d+)`)
+	sizeRe    = regexp.MustCompile(`\bRFC822\.SIZE (\d+)`)
+	validRe   = regexp.MustCompile(`\[UIDVALIDITY (\d+)\]`)
+	existsRe  = regexp.MustCompile(`^(\d+) EXISTS$`)
+)
+
+// ErrTooLarge is returned by Fetch for messages over the client's limit.
+// The connection cannot be used after it.
+var ErrTooLarge = errors.New("message too large")
+
+// Client is a connection to an IMAP server. It is not safe for concurrent
+// use.
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+	// MaxLiteral bounds a message read by Fetch; 0 means no bound.
+	MaxLiteral int64
+}
+
+// response is one server response line, with the literals it carried cut
+// out of Text.
+type response struct {
+	Text     string
+	Literals [][]byte
+}
+
+// Dial connects to addr ("host:port") with security and reads the
+// greeting. Cancelling ctx closes the connection, failing any command in
+// progress.
+func Dial(ctx context.Context, dialer *net.Dialer, addr, security string) (_ *Client, err error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if security == SecurityTLS {
+		tc := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tc
+	}
+	c := &Client{conn: conn, r: bufio.NewReader(conn)}
+	stop := context.AfterFunc(ctx, func() { c.conn.Close() })
+	defer func() {
+		if err != nil {
+			stop()
+			c.conn.Close()
+		}
+	}()
+
+	c.conn.SetDeadline(time.Now().Add(commandTimeout))
+	greeting, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting.Text, "* OK") && !strings.HasPrefix(greeting.Text, "* PREAUTH") {
+		err = fmt.Errorf("imap greeting: %s", greeting.Text)
+		return nil, err
+	}
+
+	if security == SecurityStartTLS {
+		if _, err = c.command("STARTTLS"); err != nil {
+			return nil, err
+		}
+		tc := tls.Client(c.conn, &tls.Config{ServerName: host})
+		if err = tc.HandshakeContext(ctx); err != nil {
+			return nil, err
+		}
+		c.conn, c.r = tc, bufio.NewReader(tc)
+	}
+	return c, nil
+}
+
+// Close closes the connection without logging out.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Logout ends the session and closes the connection.
+func (c *Client) Logout() error {
+	_, err := c.command("LOGOUT")
+	c.conn.Close()
+	return err
+}
+
+// Login signs in. Credentials must be printable ASCII.
+func (c *Client) Login(username, password string) error {
+	user, err := quote(username)
+	if err != nil {
+		return fmt.Errorf("username: %w", err)
+	}
+	pass, err := quote(password)
+	if err != nil {
+		return fmt.Errorf("password: %w", err)
+	}
+	_, err = c.command("LOGIN " + user + " " + pass)
+	return err
+}
+
+// Mailbox is the state of a folder opened by Examine.
+type Mailbox struct {
+	UIDValidity uint32
+	Messages    int
+}
+
+// Examine opens folder read-only, so fetching does not mark messages seen.
+func (c *Client) Examine(folder string) (*Mailbox, error) {
+	name, err := quote(EncodeMailbox(folder))
+	if err != nil {
+		return nil, err
+	}
+	resps, err := c.command("EXAMINE " + name)
+	if err != nil {
+		return nil, err
+	}
+	mb := &Mailbox{}
+	for _, r := range resps {
+		text := strings.TrimPrefix(r.Text, "* ")
+		if m := validRe.FindStringSubmatch(text); m != nil {
+			v, _ := strconv.ParseUint(m[1], 10, 32)
+			mb.UIDValidity = uint32(v)
+		}
+		if m := existsRe.FindStringSubmatch(text); m != nil {
+			mb.Messages, _ = strconv.Atoi(m[1])
+		}
+	}
+	return mb, nil
+}
+
+// SearchUIDs returns the UIDs of the open folder's messages, or of those
+// received on or after since when it is set.
+func (c *Client) SearchUIDs(since time.Time) ([]uint32, error) {
+	cmd := "UID SEARCH ALL"
+	if !since.IsZero() {
+		cmd = "UID SEARCH SINCE " + since.Format("2-Jan-2006")
+	}
+	resps, err := c.command(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, r := range resps {
+		rest, ok := strings.CutPrefix(r.Text, "* SEARCH")
+		if !ok {
+			continue
+		}
+		for _, f := range strings.Fields(rest) {
+			if v, err := strconv.ParseUint(f, 10, 32); err == nil {
+				uids = append(uids, uint32(v))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// Sizes returns the sizes in bytes of the messages with uids.
+func (c *Client) Sizes(uids []uint32) (map[uint32]int64, error) {
+	sizes := make(map[uint32]int64, len(uids))
+	for start := 0; start < len(uids); start += fetchBatch {
+		end := min(start+fetchBatch, len(uids))
+		resps, err := c.command("UID FETCH " + uidSet(uids[start:end]) + " (UID RFC822.SIZE)")
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resps {
+			uid, size := uidRe.FindStringSubmatch(r.Text), sizeRe.FindStringSubmatch(r.Text)
+			if uid == nil || size == nil {
+				continue
+			}
+			u, _ := strconv.ParseUint(uid[1], 10, 32)
+			sizes[uint32(u)], _ = strconv.ParseInt(size[1], 10, 64)
+		}
+	}
+	return sizes, nil
+}
+
+// Fetch returns the raw RFC 822 message with uid, without marking it seen.
+func (c *Client) Fetch(uid uint32) ([]byte, error) {
+	u := strconv.FormatUint(uint64(uid), 10)
+	resps, err := c.command("UID FETCH " + u + " (UID BODY.PEEK[])")
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range resps {
+		if m := uidRe.FindStringSubmatch(r.Text); m != nil && m[1] == u && len(r.Literals) > 0 {
+			return r.Literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("message %s not returned", u)
+}
+
+// command sends cmd and returns its untagged responses, or an error unless
+// it ends with OK.
+func (c *Client) command(cmd string) ([]response, error) {
+	c.tag++
+	tag := "A" + strconv.Itoa(c.tag)
+	c.conn.SetDeadline(time.Now().Add(commandTimeout))
+	if _, err := io.WriteString(c.conn, tag+" "+cmd+"\r\n"); err != nil {
+		return nil, err
+	}
+	verb, _, _ := strings.Cut(cmd, " ")
+	if verb == "UID" {
+		verb = cmd[:strings.IndexByte(cmd[4:], ' ')+4]
+	}
+
+	var untagged []response
+	for {
+		r, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		status, ok := strings.CutPrefix(r.Text, tag+" ")
+		if !ok {
+			untagged = append(untagged, r)
+			continue
+		}
+		if strings.HasPrefix(status, "OK") {
+			return untagged, nil
+		}
+		return nil, fmt.Errorf("imap %s: %s", verb, status)
+	}
+}
+
+// read reads one response line and the literals it announces.
+func (c *Client) read() (response, error) {
+	var r response
+	var text strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return r, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		m := literalRe.FindStringSubmatch(line)
+		if m == nil {
+			text.WriteString(line)
+			r.Text = text.String()
+			return r, nil
+		}
+		text.WriteString(line[:len(line)-len(m[0])])
+		n, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return r, err
+		}
+		if c.MaxLiteral > 0 && n > c.MaxLiteral {
+			return r, ErrTooLarge
+		}
+		lit := make([]byte, n)
+		if _, err := io.ReadFull(c.r, lit); err != nil {
+			return r, err
+		}
+		r.Literals = append(r.Literals, lit)
+		text.WriteString("\x00")
+	}
+}
+
+// quote returns s as an IMAP quoted string.
+func quote(s string) (string, error) {
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e {
+			return "", errors.New("only printable ASCII is supported")
+		}
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`, nil
+}
+
+// uidSet formats uids as a comma-separated sequence set.
+func uidSet(uids []uint32) string {
+	parts := make([]string, len(uids))
+	for i, u := range uids {
+		parts[i] = strconv.FormatUint(uint64(u), 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// EncodeMailbox encodes a folder name in the modified UTF-7 of RFC 3501,
+// so folders named outside ASCII can be opened.
+func EncodeMailbox(name string) string {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+,"
+	var b strings.Builder
+	var pending []uint16
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		b.WriteByte('&')
+		var bits, n uint32
+		for _, u := range pending {
+			bits = bits<<16 | uint32(u)
+			n += 16
+			for n >= 6 {
+				n -= 6
+				b.WriteByte(alphabet[bits>>n&0x3f])
+			}
+		}
+		if n > 0 {
+			b.WriteByte(alphabet[bits<<(6-n)&0x3f])
+		}
+		b.WriteByte('-')
+		pending = pending[:0]
+	}
+	for _, r := range name {
+		if r >= 0x20 && r <= 0x7e {
+			flush()
+			if r == '&' {
+				b.WriteString("&-")
+			} else {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		pending = append(pending, utf16.Encode([]rune{r})...)
+	}
+	flush()
+	return b.String()
+}
@@ -0,0 +1,54 @@
+// Package lifecycle tracks the gateway's startup and drain state for the
+// Kubernetes probes.
+//
+// A drain starts either from the preStop hook or from SIGTERM, whichever
+// comes first. From then on the readiness probe fails so the pod is taken
+// out of its Service; after the drain delay, which gives endpoint updates
+// time to reach every proxy, the server can shut down without new
+// requests arriving.
+package lifecycle
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State is the gateway's lifecycle state. The zero value is not usable;
+// create one with New.
+type State struct {
+	delay time.Duration
+
+	started  atomic.Bool
+	draining atomic.Bool
+
+	once    sync.Once
+	drained chan struct{}
+}
+
+// New creates a State whose drains last delay.
+func New(delay time.Duration) *State {
+	return &State{delay: delay, drained: make(chan struct{})}
+}
+
+// MarkStarted records that the gateway finished starting up and is
+// listening.
+func (s *State) MarkStarted() { s.started.Store(true) }
+
+// Started reports whether MarkStarted was called.
+func (s *State) Started() bool { return s.started.Load() }
+
+// Draining reports whether a drain has started.
+func (s *State) Draining() bool { return s.draining.Load() }
+
+// Drain starts the drain sequence, unless it already started, and returns
+// a channel closed once the drain delay has passed. reason is logged.
+func (s *State) Drain(reason string) <-chan struct{} {
+	s.once.Do(func() {
+		s.draining.Store(true)
+		log.Printf("draining (%s); waiting %s before shutdown", reason, s.delay)
+		time.AfterFunc(s.delay, func() { close(s.drained) })
+	})
+	return s.drained
+}
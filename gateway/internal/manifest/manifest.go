@@ -0,0 +1,217 @@
+// Package manifest records which files the gateway has sent for indexing,
+// keyed by collection and root path, so later index requests can be reduced
+// to the files that actually changed.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/ignore"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+)
+
+// DefaultSkipDirs mirrors the worker's discovery skip list so the gateway
+// does not hash trees the worker would never index.
+var DefaultSkipDirs = []string{
+	"node_modules", "__pycache__",
+	"venv", "env",
+	"dist", "build", "target", "out", "bin", "obj",
+	"vendor", "third_party",
+	"coverage",
+}
+
+// DefaultSkipFiles mirrors the worker's list of lock files it never indexes.
+var DefaultSkipFiles = []string{
+	"package-lock.json", "yarn.lock", "pnpm-lock.yaml",
+	"go.sum", "Cargo.lock", "poetry.lock", "uv.lock",
+	"Pipfile.lock", "composer.lock", "Gemfile.lock",
+}
+
+// MaxFileSize matches the worker's default max_file_size_kb; larger files are
+// skipped by discovery, so they are not tracked either.
+const MaxFileSize = 512 * 1024
+
+// Manifest maps root-relative file paths to their SHA-256 content hash.
+type Manifest struct {
+	Collection string            `json:"collection"`
+	Root       string            `json:"root"`
+	Files      map[string]string `json:"files"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// Diff is the set of files that differ between a manifest and a fresh scan.
+type Diff struct {
+	Changed []string // new or modified files
+	Removed []string // files no longer present under the root
+}
+
+// Empty reports whether nothing changed.
+func (d Diff) Empty() bool {
+	return len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// Diff compares the manifest against the hashes of a fresh scan.
+func (m *Manifest) Diff(current map[string]string) Diff {
+	var d Diff
+	for path, hash := range current {
+		if m.Files[path] != hash {
+			d.Changed = append(d.Changed, path)
+		}
+	}
+	for path := range m.Files {
+		if _, ok := current[path]; !ok {
+			d.Removed = append(d.Removed, path)
+		}
+	}
+	sort.Strings(d.Changed)
+	sort.Strings(d.Removed)
+	return d
+}
+
+// Store persists manifests as one document per collection/root pair.
+type Store struct {
+	st *store.Store
+}
+
+// NewStore creates a manifest Store backed by st.
+func NewStore(st *store.Store) *Store {
+	return &Store{st: st}
+}
+
+// Load returns the manifest for a collection/root pair. A missing manifest
+// yields an empty one and false.
+func (s *Store) Load(collection, root string) (*Manifest, bool, error) {
+	m := &Manifest{Collection: collection, Root: root}
+	ok, err := s.st.Load(docName(collection, root), m)
+	if err != nil || !ok {
+		m = &Manifest{Collection: collection, Root: root}
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]string)
+	}
+	return m, ok, err
+}
+
+// Save writes the manifest, stamping its update time.
+func (s *Store) Save(m *Manifest) error {
+	m.UpdatedAt = time.Now()
+	return s.st.Save(docName(m.Collection, m.Root), m)
+}
+
+// Roots returns the root paths with a manifest for collection, sorted.
+func (s *Store) Roots(collection string) ([]string, error) {
+	names, err := s.st.Names()
+	if err != nil {
+		return nil, err
+	}
+	var roots []string
+	for _, n := range names {
+		if !strings.HasPrefix(n, "manifest-") {
+			continue
+		}
+		var m Manifest
+		if ok, err := s.st.Load(n, &m); err != nil || !ok {
+			continue
+		}
+		if m.Collection == collection && m.Root != "" {
+			roots = append(roots, m.Root)
+		}
+	}
+	sort.Strings(roots)
+	return roots, nil
+}
+
+// Scan walks root and hashes every regular file the worker could index,
+// returning root-relative slash paths mapped to hex SHA-256 digests.
+// extraSkipDirs takes directory names and gitignore patterns, as the worker
+// does.
+func Scan(root string, extraSkipDirs []string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := Walk(root, extraSkipDirs, func(rel, path string, info fs.FileInfo) error {
+		if info.Size() > MaxFileSize {
+			return nil
+		}
+		if hash, err := hashFile(path); err == nil {
+			files[rel] = hash
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Walk calls fn with the root-relative slash path, full path and info of
+// every regular file under root that the worker's discovery does not skip,
+// whatever its size or extension. extraSkipDirs is as for Scan. An error
+// returned by fn stops the walk and is returned.
+func Walk(root string, extraSkipDirs []string, fn func(rel, path string, info fs.FileInfo) error) error {
+	skip := make(map[string]bool, len(DefaultSkipDirs))
+	for _, d := range DefaultSkipDirs {
+		skip[d] = true
+	}
+	skipFile := make(map[string]bool, len(DefaultSkipFiles))
+	for _, f := range DefaultSkipFiles {
+		skipFile[f] = true
+	}
+	matcher, err := ignore.New(extraSkipDirs)
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil // unreadable entries are skipped, as the worker does
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") || matcher.Ignored(rel, true, skip[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || matcher.Ignored(rel, false, skipFile[d.Name()]) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		return fn(rel, path, info)
+	})
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func docName(collection, root string) string {
+	sum := sha256.Sum256([]byte(collection + "\x00" + root))
+	return "manifest-" + hex.EncodeToString(sum[:8])
+}
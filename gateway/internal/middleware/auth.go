@@ -2,20 +2,23 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/alfagnish/ollqd-gateway/internal/i18n"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type contextKey string
 
 const (
-	ContextKeyUsername contextKey = "auth_username"
-	ContextKeyRole    contextKey = "auth_role"
+	ContextKeyUsername  contextKey = "auth_username"
+	ContextKeyRole      contextKey = "auth_role"
+	ContextKeySessionID contextKey = "auth_session_id"
 
-	CookieName = "ollqd_token"
+	CookieName  = "ollqd_token"
 	TokenExpiry = 24 * time.Hour
 )
 
@@ -26,13 +29,20 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a signed JWT for the given user.
-func GenerateToken(secret, username, role string) (string, error) {
+// GenerateToken creates a signed JWT for the given user. sessionID becomes
+// the token's jti and ties it to an entry in the SessionStore.
+func GenerateToken(secret, username, role, sessionID string) (string, error) {
+	return GenerateTokenWithExpiry(secret, username, role, sessionID, TokenExpiry)
+}
+
+// GenerateTokenWithExpiry is GenerateToken with a custom lifetime.
+func GenerateTokenWithExpiry(secret, username, role, sessionID string, ttl time.Duration) (string, error) {
 	claims := &Claims{
 		Username: username,
 		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenExpiry)),
+			ID:        sessionID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -40,29 +50,44 @@ func GenerateToken(secret, username, role string) (string, error) {
 	return token.SignedString([]byte(secret))
 }
 
+// ParseToken validates a signed JWT and returns its claims.
+func ParseToken(secret, tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
 // RequireAuth returns middleware that validates JWT tokens.
 // It checks the ollqd_token cookie first, then the Authorization header.
-func RequireAuth(secret string) func(http.Handler) http.Handler {
+// When sessions is non-nil the token's session must also still be active.
+func RequireAuth(secret string, sessions *SessionStore) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			tokenStr := extractToken(r)
+			tokenStr := TokenFromRequest(r)
 			if tokenStr == "" {
-				http.Error(w, `{"detail":"authentication required"}`, http.StatusUnauthorized)
+				writeError(w, http.StatusUnauthorized, "UNAUTHENTICATED", "authentication required")
 				return
 			}
 
-			claims := &Claims{}
-			token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
-				return []byte(secret), nil
-			})
-			if err != nil || !token.Valid {
-				http.Error(w, `{"detail":"invalid or expired token"}`, http.StatusUnauthorized)
+			claims, err := ParseToken(secret, tokenStr)
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, "UNAUTHENTICATED", "invalid or expired token")
+				return
+			}
+			if sessions != nil && !sessions.Touch(claims.ID) {
+				writeError(w, http.StatusUnauthorized, "UNAUTHENTICATED", "session revoked")
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), ContextKeyUsername, claims.Username)
-			ctx = context.WithValue(ctx, ContextKeyRole, claims.Role)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
 		})
 	}
 }
@@ -73,13 +98,27 @@ func RequireAdmin(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		role, _ := r.Context().Value(ContextKeyRole).(string)
 		if role != "admin" {
-			http.Error(w, `{"detail":"admin access required"}`, http.StatusForbidden)
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "admin access required")
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
+// writeError writes an error response in the format of the handlers
+// package, localized like theirs.
+func writeError(w http.ResponseWriter, status int, code, detail string) {
+	msg := i18n.Localize(i18n.ResponseLang(w.Header()), detail)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Detail      string   `json:"detail"`
+		Code        string   `json:"code"`
+		MessageKey  string   `json:"message_key,omitempty"`
+		MessageArgs []string `json:"message_args,omitempty"`
+	}{msg.Text, code, msg.Key, msg.Args})
+}
+
 // UsernameFromContext extracts the username from the request context.
 func UsernameFromContext(ctx context.Context) string {
 	v, _ := ctx.Value(ContextKeyUsername).(string)
@@ -92,7 +131,15 @@ func RoleFromContext(ctx context.Context) string {
 	return v
 }
 
-func extractToken(r *http.Request) string {
+// SessionIDFromContext extracts the session ID (token jti) from the request context.
+func SessionIDFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(ContextKeySessionID).(string)
+	return v
+}
+
+// TokenFromRequest returns the raw auth token from the cookie or the
+// Authorization header.
+func TokenFromRequest(r *http.Request) string {
 	// Cookie first (browser clients)
 	if c, err := r.Cookie(CookieName); err == nil && c.Value != "" {
 		return c.Value
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeoutHeader lets clients bound how long the gateway waits on the worker.
+const TimeoutHeader = "X-Timeout-Seconds"
+
+// WorkerDeadline returns middleware that attaches a deadline to the request
+// context so proxied gRPC calls cannot block indefinitely. Clients may request
+// a shorter or longer budget via X-Timeout-Seconds, capped at max. A zero
+// fallback leaves requests without the header unbounded. WebSocket upgrades
+// are passed through untouched since they are long-lived by design.
+func WorkerDeadline(fallback, max time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			timeout := fallback
+			if v := r.Header.Get(TimeoutHeader); v != "" {
+				secs, err := strconv.ParseFloat(v, 64)
+				if err != nil || secs <= 0 {
+					writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("invalid %s header", TimeoutHeader))
+					return
+				}
+				timeout = time.Duration(secs * float64(time.Second))
+			}
+			if max > 0 && timeout > max {
+				timeout = max
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
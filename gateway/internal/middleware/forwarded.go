@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	contextKeyScheme   contextKey = "external_scheme"
+	contextKeyHost     contextKey = "external_host"
+	contextKeyBasePath contextKey = "external_base_path"
+)
+
+// ParseTrustedProxies converts a list of CIDRs or bare IPs into networks.
+func ParseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if !strings.Contains(e, "/") {
+			ip := net.ParseIP(e)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", e)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			e = fmt.Sprintf("%s/%d", e, bits)
+		}
+		_, n, err := net.ParseCIDR(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", e, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// Forwarded replaces chi's RealIP middleware. X-Forwarded-For, X-Real-IP,
+// X-Forwarded-Proto, X-Forwarded-Host, and X-Forwarded-Prefix are only
+// honoured when the direct peer is inside one of the trusted networks;
+// otherwise they are ignored. The resolved scheme, host, and base path are
+// stored in the request context for ExternalURL.
+func Forwarded(trusted []*net.IPNet, basePath string) func(http.Handler) http.Handler {
+	basePath = strings.TrimRight(basePath, "/")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme := "http"
+			if r.TLS != nil {
+				scheme = "https"
+			}
+			host := r.Host
+			prefix := basePath
+
+			if ipTrusted(remoteIP(r.RemoteAddr), trusted) {
+				if ip := clientIP(r, trusted); ip != "" {
+					r.RemoteAddr = ip
+				}
+				if p := firstValue(r.Header.Get("X-Forwarded-Proto")); p == "http" || p == "https" {
+					scheme = p
+				}
+				if h := firstValue(r.Header.Get("X-Forwarded-Host")); h != "" {
+					host = h
+				}
+				if p := firstValue(r.Header.Get("X-Forwarded-Prefix")); p != "" {
+					prefix = "/" + strings.Trim(p, "/")
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), contextKeyScheme, scheme)
+			ctx = context.WithValue(ctx, contextKeyHost, host)
+			ctx = context.WithValue(ctx, contextKeyBasePath, prefix)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ExternalURL builds an absolute URL for a gateway path (e.g. "/api/rag/image")
+// as seen by the client, accounting for trusted proxy headers and the
+// configured base path.
+func ExternalURL(r *http.Request, path string) string {
+	scheme, _ := r.Context().Value(contextKeyScheme).(string)
+	if scheme == "" {
+		scheme = "http"
+	}
+	host, _ := r.Context().Value(contextKeyHost).(string)
+	if host == "" {
+		host = r.Host
+	}
+	prefix, _ := r.Context().Value(contextKeyBasePath).(string)
+	return scheme + "://" + host + prefix + path
+}
+
+// clientIP walks X-Forwarded-For from right to left and returns the first
+// address that is not a trusted proxy, falling back to X-Real-IP.
+func clientIP(r *http.Request, trusted []*net.IPNet) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+		for i := len(parts) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(parts[i]))
+			if ip == nil {
+				break
+			}
+			if !ipTrusted(ip, trusted) || i == 0 {
+				return ip.String()
+			}
+		}
+	}
+	if xrip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); xrip != nil {
+		return xrip.String()
+	}
+	return ""
+}
+
+func ipTrusted(ip net.IP, trusted []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func remoteIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.ParseIP(host)
+}
+
+func firstValue(v string) string {
+	if i := strings.IndexByte(v, ','); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v)
+}
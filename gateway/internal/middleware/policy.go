@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Auth modes accepted by AUTH_MODE.
+const (
+	AuthDisabled = "disabled" // no login; every request acts as an admin
+	AuthOptional = "optional" // tokens are honoured but not required
+	AuthRequired = "required" // every non-public route needs a valid token
+)
+
+// AnonymousUser is the username given to requests without a token when
+// auth is disabled or optional.
+const AnonymousUser = "anonymous"
+
+// DefaultPublicPaths are reachable without a token in every mode.
+var DefaultPublicPaths = []string{
+	"/api/health",
+	"/livez",
+	"/readyz",
+	"/startupz",
+	"/prestop",
+	"/api/auth/login",
+	"/api/auth/logout",
+	"/api/share/*",
+	// The chat widget authenticates with its own embed tokens.
+	"/embed/*",
+	"/api/system/branding/*",
+	// Image URLs are public so signed ones work in <img> tags; the handler
+	// still requires a login for unsigned requests. The rest of
+	// /api/rag/image, such as caption tests, is not.
+	"/api/rag/image",
+	"/api/rag/image/",
+}
+
+// ParseAuthMode normalises an AUTH_MODE value. Empty means required.
+func ParseAuthMode(s string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(s)); mode {
+	case "":
+		return AuthRequired, nil
+	case AuthDisabled, AuthOptional, AuthRequired:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown auth mode %q (want disabled, optional or required)", s)
+	}
+}
+
+// Policy decides how each request is authenticated. It is applied once
+// around the whole router so individual handlers do not have to wire
+// RequireAuth themselves; admin-only groups still add RequireAdmin.
+type Policy struct {
+	mode     string
+	secret   string
+	sessions *SessionStore
+	public   []string
+}
+
+// NewPolicy builds a Policy. public lists extra paths reachable without a
+// token on top of DefaultPublicPaths; an entry ending in "/*" matches the
+// whole subtree.
+func NewPolicy(mode, secret string, sessions *SessionStore, public []string) (*Policy, error) {
+	mode, err := ParseAuthMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	p := &Policy{mode: mode, secret: secret, sessions: sessions}
+	for _, path := range append(append([]string{}, DefaultPublicPaths...), public...) {
+		if path = strings.TrimSpace(path); path != "" {
+			p.public = append(p.public, path)
+		}
+	}
+	return p, nil
+}
+
+// Mode returns the effective auth mode.
+func (p *Policy) Mode() string { return p.mode }
+
+// IsPublic reports whether path is on the allowlist.
+func (p *Policy) IsPublic(path string) bool {
+	for _, pub := range p.public {
+		if prefix, ok := strings.CutSuffix(pub, "/*"); ok {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+		} else if path == pub {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate populates the request identity according to the mode.
+// Public paths always pass through; a valid token on them is still
+// honoured so handlers can see who is calling.
+func (p *Policy) Authenticate(next http.Handler) http.Handler {
+	required := RequireAuth(p.secret, p.sessions)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.mode == AuthRequired && !p.IsPublic(r.URL.Path) {
+			required.ServeHTTP(w, r)
+			return
+		}
+		if claims := p.claims(r); claims != nil {
+			next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
+			return
+		}
+		if p.mode == AuthDisabled {
+			next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), &Claims{Username: AnonymousUser, Role: "admin"})))
+			return
+		}
+		if p.mode == AuthOptional {
+			next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), &Claims{Username: AnonymousUser})))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// claims returns the caller's claims when the request carries a valid,
+// unrevoked token, or nil otherwise.
+func (p *Policy) claims(r *http.Request) *Claims {
+	tokenStr := TokenFromRequest(r)
+	if tokenStr == "" {
+		return nil
+	}
+	claims, err := ParseToken(p.secret, tokenStr)
+	if err != nil {
+		return nil
+	}
+	if p.sessions != nil && !p.sessions.Touch(claims.ID) {
+		return nil
+	}
+	return claims
+}
+
+func withClaims(ctx context.Context, c *Claims) context.Context {
+	ctx = context.WithValue(ctx, ContextKeyUsername, c.Username)
+	ctx = context.WithValue(ctx, ContextKeyRole, c.Role)
+	if c.ID != "" {
+		ctx = context.WithValue(ctx, ContextKeySessionID, c.ID)
+	}
+	return ctx
+}
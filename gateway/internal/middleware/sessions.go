@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/google/uuid"
+)
+
+// sessionsDoc is the store document holding active sessions.
+const sessionsDoc = "sessions"
+
+// reloadInterval throttles re-reading the sessions document when a token
+// references an unknown session.
+const reloadInterval = time.Second
+
+// Session is an issued login token, identified by its JWT ID (jti).
+type Session struct {
+	ID          string    `json:"id"`
+	Username    string    `json:"username"`
+	Role        string    `json:"role"`
+	RemoteAddr  string    `json:"remote_addr,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	IssuedAt    time.Time `json:"issued_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	LastSeen    time.Time `json:"last_seen"`
+	Connections int       `json:"ws_connections"`
+}
+
+// SessionStore tracks issued tokens and the WebSocket connections opened
+// with them. A token is only accepted while its session exists, so
+// revoking a session logs the holder out before the token expires.
+// Sessions are persisted so revocations survive a gateway restart.
+type SessionStore struct {
+	mu       sync.Mutex
+	store    *store.Store
+	sessions map[string]*Session
+	conns    map[string]map[int]func()
+	nextConn int
+
+	// revoked remembers revoked session IDs until they expire so a reload
+	// cannot resurrect them from a stale copy written by another process.
+	revoked    map[string]time.Time
+	lastReload time.Time
+}
+
+// NewSessionStore loads unexpired sessions from st.
+func NewSessionStore(st *store.Store) *SessionStore {
+	s := &SessionStore{
+		store:    st,
+		sessions: make(map[string]*Session),
+		conns:    make(map[string]map[int]func()),
+		revoked:  make(map[string]time.Time),
+	}
+	if _, err := st.Load(sessionsDoc, &s.sessions); err != nil {
+		log.Printf("WARNING: sessions: %v", err)
+	}
+	s.mu.Lock()
+	s.pruneLocked(time.Now())
+	s.mu.Unlock()
+	return s
+}
+
+// Create registers a new session for a successful login.
+func (s *SessionStore) Create(username, role string, r *http.Request) *Session {
+	return s.Issue(username, role, r.RemoteAddr, r.UserAgent(), TokenExpiry)
+}
+
+// Issue registers a session valid for ttl. It is used directly for tokens
+// minted outside a login request, e.g. by the create-admin-token command.
+func (s *SessionStore) Issue(username, role, remoteAddr, userAgent string, ttl time.Duration) *Session {
+	now := time.Now()
+	sess := &Session{
+		ID:         uuid.New().String(),
+		Username:   username,
+		Role:       role,
+		RemoteAddr: remoteAddr,
+		UserAgent:  userAgent,
+		IssuedAt:   now,
+		ExpiresAt:  now.Add(ttl),
+		LastSeen:   now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(now)
+	s.sessions[sess.ID] = sess
+	s.saveLocked()
+
+	cp := *sess
+	return &cp
+}
+
+// Touch reports whether the session is active and records its use.
+func (s *SessionStore) Touch(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		// The session may have been issued by another process sharing the
+		// store (create-admin-token); pick it up from disk.
+		sess, ok = s.reloadLocked(id)
+		if !ok {
+			return false
+		}
+	}
+	now := time.Now()
+	if now.After(sess.ExpiresAt) {
+		return false
+	}
+	sess.LastSeen = now
+	return true
+}
+
+// List returns the active sessions of a user, oldest first.
+func (s *SessionStore) List(username string) []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	out := make([]Session, 0)
+	for id, sess := range s.sessions {
+		if sess.Username != username || now.After(sess.ExpiresAt) {
+			continue
+		}
+		cp := *sess
+		cp.Connections = len(s.conns[id])
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].IssuedAt.Before(out[j].IssuedAt) })
+	return out
+}
+
+// Revoke ends a single session and closes its WebSocket connections.
+// It returns false if the session does not exist.
+func (s *SessionStore) Revoke(id string) bool {
+	s.mu.Lock()
+	_, ok := s.sessions[id]
+	closers := s.revokeLocked(id)
+	if ok {
+		s.saveLocked()
+	}
+	s.mu.Unlock()
+
+	for _, c := range closers {
+		c()
+	}
+	return ok
+}
+
+// RevokeUser ends every session belonging to username and returns how many
+// were revoked.
+func (s *SessionStore) RevokeUser(username string) int {
+	s.mu.Lock()
+	var closers []func()
+	n := 0
+	for id, sess := range s.sessions {
+		if sess.Username == username {
+			closers = append(closers, s.revokeLocked(id)...)
+			n++
+		}
+	}
+	if n > 0 {
+		s.saveLocked()
+	}
+	s.mu.Unlock()
+
+	for _, c := range closers {
+		c()
+	}
+	return n
+}
+
+// Prune drops expired sessions and persists the result. It returns how many
+// sessions were removed.
+func (s *SessionStore) Prune() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.sessions)
+	s.pruneLocked(time.Now())
+	removed := before - len(s.sessions)
+	if removed > 0 {
+		s.saveLocked()
+	}
+	return removed
+}
+
+// TrackConn registers a WebSocket connection opened with the given session.
+// closeFn is called if the session is revoked; the returned func must be
+// called when the connection ends.
+func (s *SessionStore) TrackConn(id string, closeFn func()) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextConn++
+	n := s.nextConn
+	if s.conns[id] == nil {
+		s.conns[id] = make(map[int]func())
+	}
+	s.conns[id][n] = closeFn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if m, ok := s.conns[id]; ok {
+			delete(m, n)
+			if len(m) == 0 {
+				delete(s.conns, id)
+			}
+		}
+	}
+}
+
+// revokeLocked removes a session and returns the close funcs of its
+// connections. Callers must hold s.mu and invoke the funcs after unlocking.
+func (s *SessionStore) revokeLocked(id string) []func() {
+	if sess, ok := s.sessions[id]; ok {
+		s.revoked[id] = sess.ExpiresAt
+	}
+	delete(s.sessions, id)
+	var closers []func()
+	for _, c := range s.conns[id] {
+		closers = append(closers, c)
+	}
+	delete(s.conns, id)
+	return closers
+}
+
+// reloadLocked merges sessions written to the store by other processes and
+// looks up id again. Disk reads are throttled to one per reloadInterval.
+func (s *SessionStore) reloadLocked(id string) (*Session, bool) {
+	now := time.Now()
+	if id == "" || now.Sub(s.lastReload) < reloadInterval {
+		return nil, false
+	}
+	s.lastReload = now
+
+	var onDisk map[string]*Session
+	if _, err := s.store.Load(sessionsDoc, &onDisk); err != nil {
+		log.Printf("WARNING: reload sessions: %v", err)
+		return nil, false
+	}
+	for sid, sess := range onDisk {
+		_, known := s.sessions[sid]
+		_, revoked := s.revoked[sid]
+		if !known && !revoked && now.Before(sess.ExpiresAt) {
+			s.sessions[sid] = sess
+		}
+	}
+	sess, ok := s.sessions[id]
+	return sess, ok
+}
+
+func (s *SessionStore) pruneLocked(now time.Time) {
+	for id, sess := range s.sessions {
+		if now.After(sess.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+	for id, exp := range s.revoked {
+		if now.After(exp) {
+			delete(s.revoked, id)
+		}
+	}
+}
+
+func (s *SessionStore) saveLocked() {
+	if err := s.store.Save(sessionsDoc, s.sessions); err != nil {
+		log.Printf("WARNING: save sessions: %v", err)
+	}
+}
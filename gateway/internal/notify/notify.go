@@ -0,0 +1,512 @@
+// Package notify sends task completion and failure notifications, and
+// other alerts, by SMTP email and Slack incoming webhooks. Settings are
+// persisted in the gateway store and edited through
+// /api/system/notifications.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+// settingsDoc is the store document holding notification settings.
+const settingsDoc = "notifications"
+
+// sendTimeout bounds one delivery attempt.
+const sendTimeout = 30 * time.Second
+
+// Channels and events a Rule may name.
+const (
+	ChannelEmail = "email"
+	ChannelSlack = "slack"
+)
+
+var (
+	validChannels = map[string]bool{ChannelEmail: true, ChannelSlack: true}
+	validEvents   = map[string]bool{
+		string(tasks.StatusCompleted): true,
+		string(tasks.StatusFailed):    true,
+		string(tasks.StatusCancelled): true,
+	}
+)
+
+// DefaultSubject and DefaultBody are used when no rule sets a template.
+const (
+	DefaultSubject = `[Ollqd] {{.Type}} {{.Status}}`
+	DefaultBody    = `Task {{.ID}} ({{.Type}}) {{.Status}}{{if .Duration}} after {{.Duration}}{{end}}.
+{{- if .Error}}
+
+Error: {{.Error}}
+{{- end}}
+{{- range $k, $v := .Result}}
+{{$k}}: {{$v}}
+{{- end}}
+`
+)
+
+// Settings are the global channel settings plus notification rules.
+type Settings struct {
+	Email EmailSettings `json:"email"`
+	Slack SlackSettings `json:"slack"`
+
+	// Default applies to every task type without an override.
+	Default Rule `json:"default"`
+
+	// TaskTypes overrides Default per task type (e.g. "index_codebase").
+	// Unset fields are inherited; an explicit empty list mutes them.
+	TaskTypes map[string]Rule `json:"task_types,omitempty"`
+}
+
+// EmailSettings configure SMTP delivery.
+type EmailSettings struct {
+	Enabled  bool     `json:"enabled"`
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	// TLS is "starttls" (required), "tls" (implicit, usually port 465),
+	// "none", or empty to use STARTTLS when the server offers it.
+	TLS string `json:"tls,omitempty"`
+}
+
+// SlackSettings configure Slack incoming-webhook delivery.
+type SlackSettings struct {
+	Enabled    bool   `json:"enabled"`
+	WebhookURL string `json:"webhook_url"`
+	Username   string `json:"username,omitempty"`
+}
+
+// Rule selects which events notify on which channels, and how messages are
+// rendered. Subject and Body are text/template sources executed with an
+// Event.
+type Rule struct {
+	Events   []string `json:"events"`
+	Channels []string `json:"channels"`
+	Subject  string   `json:"subject,omitempty"`
+	Body     string   `json:"body,omitempty"`
+}
+
+// Event is the template data for a notification: the finished task plus its
+// run time.
+type Event struct {
+	tasks.TaskInfo
+	Duration time.Duration
+}
+
+// DeliveryStatus is the outcome of the last delivery on a channel.
+type DeliveryStatus struct {
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Notifier delivers notifications according to its persisted settings.
+type Notifier struct {
+	mu       sync.RWMutex
+	store    *store.Store
+	settings Settings
+	status   map[string]*DeliveryStatus
+}
+
+// New loads notification settings from st. Missing settings yield a
+// notifier with every channel disabled.
+func New(st *store.Store) *Notifier {
+	n := &Notifier{
+		store: st,
+		settings: Settings{
+			Default: Rule{
+				Events:   []string{string(tasks.StatusFailed)},
+				Channels: []string{ChannelEmail, ChannelSlack},
+			},
+		},
+		status: make(map[string]*DeliveryStatus),
+	}
+	if _, err := st.Load(settingsDoc, &n.settings); err != nil {
+		log.Printf("WARNING: notification settings: %v", err)
+	}
+	return n
+}
+
+// Settings returns a copy of the settings. Secrets are masked unless
+// withSecrets is set.
+func (n *Notifier) Settings(withSecrets bool) Settings {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	s := n.settings.clone()
+	if !withSecrets {
+		s.Email.Password = mask(s.Email.Password)
+		s.Slack.WebhookURL = mask(s.Slack.WebhookURL)
+	}
+	return s
+}
+
+// Status returns the last delivery outcome per channel.
+func (n *Notifier) Status() map[string]DeliveryStatus {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	out := make(map[string]DeliveryStatus, len(n.status))
+	for k, v := range n.status {
+		out[k] = *v
+	}
+	return out
+}
+
+// Prepare resolves masked secrets in s against the current settings and
+// validates the result. A channel whose secret is masked but unknown here
+// (e.g. settings imported from another gateway without credentials) is
+// disabled with a warning.
+func (n *Notifier) Prepare(s Settings) (Settings, []string, error) {
+	n.mu.RLock()
+	cur := n.settings
+	n.mu.RUnlock()
+
+	var warnings []string
+	if s.Email.Password == tasks.RedactedValue {
+		s.Email.Password = cur.Email.Password
+		if s.Email.Password == "" && s.Email.Username != "" && s.Email.Enabled {
+			s.Email.Enabled = false
+			warnings = append(warnings, "email disabled: SMTP password not included")
+		}
+	}
+	if s.Slack.WebhookURL == tasks.RedactedValue {
+		s.Slack.WebhookURL = cur.Slack.WebhookURL
+		if s.Slack.WebhookURL == "" && s.Slack.Enabled {
+			s.Slack.Enabled = false
+			warnings = append(warnings, "slack disabled: webhook URL not included")
+		}
+	}
+	if s.Email.Port == 0 {
+		s.Email.Port = 587
+	}
+	if err := s.validate(); err != nil {
+		return s, warnings, err
+	}
+	return s, warnings, nil
+}
+
+// Update validates and stores new settings; see Prepare for secret
+// handling.
+func (n *Notifier) Update(s Settings) ([]string, error) {
+	s, warnings, err := n.Prepare(s)
+	if err != nil {
+		return warnings, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.settings = s.clone()
+	return warnings, n.store.Save(settingsDoc, &n.settings)
+}
+
+// Notify sends the notifications t's rule asks for. It is a
+// tasks.FinishFunc and blocks until delivery finishes.
+func (n *Notifier) Notify(t tasks.TaskInfo) {
+	n.mu.RLock()
+	s := n.settings.clone()
+	n.mu.RUnlock()
+
+	rule := s.ruleFor(t.Type)
+	if !contains(rule.Events, string(t.Status)) {
+		return
+	}
+	ev := newEvent(t)
+	for _, ch := range rule.Channels {
+		if !s.channelEnabled(ch) {
+			continue
+		}
+		err := n.send(ch, s, rule, ev)
+		if err != nil {
+			log.Printf("WARNING: notify %s for task %s: %v", ch, t.ID, err)
+		}
+	}
+}
+
+// Test sends a sample notification for taskType on one channel, using the
+// current settings even if the channel or rule would not fire.
+func (n *Notifier) Test(channel, taskType string) error {
+	if !validChannels[channel] {
+		return fmt.Errorf("unknown channel %q", channel)
+	}
+	n.mu.RLock()
+	s := n.settings.clone()
+	n.mu.RUnlock()
+
+	if taskType == "" {
+		taskType = "index_codebase"
+	}
+	return n.send(channel, s, s.ruleFor(taskType), sampleEvent(taskType))
+}
+
+// Alert sends a message that is not about a task, such as a saved search
+// alert, on channels, or on every enabled channel when channels is empty.
+// Task rules do not apply; disabled channels are skipped. It blocks until
+// delivery finishes and returns the delivery errors, if any.
+func (n *Notifier) Alert(channels []string, subject, body string) error {
+	n.mu.RLock()
+	s := n.settings.clone()
+	n.mu.RUnlock()
+
+	if len(channels) == 0 {
+		channels = []string{ChannelEmail, ChannelSlack}
+	}
+	subject = strings.Join(strings.Fields(subject), " ")
+	var errs []error
+	for _, ch := range channels {
+		if !s.channelEnabled(ch) {
+			continue
+		}
+		if err := n.deliver(ch, s, subject, strings.TrimSpace(body)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Enabled reports whether any channel is enabled.
+func (n *Notifier) Enabled() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.settings.Email.Enabled || n.settings.Slack.Enabled
+}
+
+// ValidChannel reports whether ch names a channel.
+func ValidChannel(ch string) bool {
+	return validChannels[ch]
+}
+
+func (n *Notifier) send(channel string, s Settings, rule Rule, ev Event) error {
+	subject, body, err := render(rule, ev)
+	if err != nil {
+		n.record(channel, err)
+		return err
+	}
+	return n.deliver(channel, s, subject, body)
+}
+
+// deliver sends one rendered message and records the outcome.
+func (n *Notifier) deliver(channel string, s Settings, subject, body string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	var err error
+	switch channel {
+	case ChannelEmail:
+		err = sendEmail(ctx, s.Email, subject, body)
+	case ChannelSlack:
+		err = sendSlack(ctx, s.Slack, subject, body)
+	}
+	n.record(channel, err)
+	return err
+}
+
+// record stores the outcome of a delivery on channel.
+func (n *Notifier) record(channel string, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	st, ok := n.status[channel]
+	if !ok {
+		st = &DeliveryStatus{}
+		n.status[channel] = st
+	}
+	now := time.Now()
+	if err != nil {
+		st.LastError, st.LastErrorAt = err.Error(), &now
+	} else {
+		st.LastSentAt = &now
+	}
+}
+
+// ruleFor merges the override for taskType onto the default rule.
+func (s Settings) ruleFor(taskType string) Rule {
+	r := s.Default
+	o, ok := s.TaskTypes[taskType]
+	if !ok {
+		return r
+	}
+	if o.Events != nil {
+		r.Events = o.Events
+	}
+	if o.Channels != nil {
+		r.Channels = o.Channels
+	}
+	if o.Subject != "" {
+		r.Subject = o.Subject
+	}
+	if o.Body != "" {
+		r.Body = o.Body
+	}
+	return r
+}
+
+func (s Settings) channelEnabled(ch string) bool {
+	switch ch {
+	case ChannelEmail:
+		return s.Email.Enabled
+	case ChannelSlack:
+		return s.Slack.Enabled
+	}
+	return false
+}
+
+func (s Settings) clone() Settings {
+	c := s
+	c.Email.To = append([]string(nil), s.Email.To...)
+	if s.TaskTypes != nil {
+		c.TaskTypes = make(map[string]Rule, len(s.TaskTypes))
+		for k, v := range s.TaskTypes {
+			c.TaskTypes[k] = v
+		}
+	}
+	return c
+}
+
+func (s Settings) validate() error {
+	if e := s.Email; e.Enabled {
+		switch {
+		case e.Host == "":
+			return fmt.Errorf("email.host is required")
+		case e.Port < 1 || e.Port > 65535:
+			return fmt.Errorf("email.port must be between 1 and 65535")
+		case len(e.To) == 0:
+			return fmt.Errorf("email.to needs at least one recipient")
+		}
+		if _, err := mail.ParseAddress(e.From); err != nil {
+			return fmt.Errorf("email.from: %v", err)
+		}
+		for _, to := range e.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("email.to %q: %v", to, err)
+			}
+		}
+	}
+	switch s.Email.TLS {
+	case "", "starttls", "tls", "none":
+	default:
+		return fmt.Errorf("email.tls must be one of: starttls, tls, none")
+	}
+	if sl := s.Slack; sl.Enabled {
+		u, err := url.Parse(sl.WebhookURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("slack.webhook_url must be an https URL")
+		}
+	}
+
+	if err := s.Default.validate(); err != nil {
+		return fmt.Errorf("default: %v", err)
+	}
+	types := make([]string, 0, len(s.TaskTypes))
+	for t := range s.TaskTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		if strings.TrimSpace(t) == "" {
+			return fmt.Errorf("task_types: empty task type")
+		}
+		if err := s.ruleFor(t).validate(); err != nil {
+			return fmt.Errorf("task_types.%s: %v", t, err)
+		}
+	}
+	return nil
+}
+
+func (r Rule) validate() error {
+	for _, e := range r.Events {
+		if !validEvents[e] {
+			return fmt.Errorf("unknown event %q (use completed, failed or cancelled)", e)
+		}
+	}
+	for _, c := range r.Channels {
+		if !validChannels[c] {
+			return fmt.Errorf("unknown channel %q (use email or slack)", c)
+		}
+	}
+	_, _, err := render(r, sampleEvent("index_codebase"))
+	return err
+}
+
+// render executes the rule's templates, falling back to the defaults.
+// Newlines are stripped from the subject so it is a safe mail header.
+func render(r Rule, ev Event) (string, string, error) {
+	subjectSrc, bodySrc := r.Subject, r.Body
+	if subjectSrc == "" {
+		subjectSrc = DefaultSubject
+	}
+	if bodySrc == "" {
+		bodySrc = DefaultBody
+	}
+	subject, err := execute("subject", subjectSrc, ev)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := execute("body", bodySrc, ev)
+	if err != nil {
+		return "", "", err
+	}
+	subject = strings.Join(strings.Fields(subject), " ")
+	return subject, strings.TrimSpace(body), nil
+}
+
+func execute(name, src string, ev Event) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("%s template: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ev); err != nil {
+		return "", fmt.Errorf("%s template: %v", name, err)
+	}
+	return buf.String(), nil
+}
+
+func newEvent(t tasks.TaskInfo) Event {
+	ev := Event{TaskInfo: t}
+	if t.StartedAt != nil && t.CompletedAt != nil {
+		ev.Duration = t.CompletedAt.Sub(*t.StartedAt).Round(time.Second)
+	}
+	return ev
+}
+
+func sampleEvent(taskType string) Event {
+	start := time.Now().Add(-90 * time.Second)
+	end := time.Now()
+	return newEvent(tasks.TaskInfo{
+		ID:          "00000000-0000-0000-0000-000000000000",
+		Type:        taskType,
+		Status:      tasks.StatusCompleted,
+		Progress:    100,
+		Result:      map[string]string{"files": "42", "chunks": "318"},
+		CreatedAt:   start,
+		StartedAt:   &start,
+		CompletedAt: &end,
+	})
+}
+
+func mask(s string) string {
+	if s == "" {
+		return ""
+	}
+	return tasks.RedactedValue
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+// Package plugin lets deployments extend the gateway without forking it.
+//
+// A plugin is a Go package that calls Register from its init function,
+// the way database/sql drivers do, and is compiled in by a blank import in
+// cmd/gateway/plugins.go. Besides Name, a plugin implements any of the hook
+// interfaces:
+//
+//   - Initializer runs once at startup with the gateway configuration and
+//     settings store.
+//   - RequestMutator sees every authenticated request before it is routed
+//     and may change or reject it.
+//   - RouteProvider serves extra routes under /api/plugins/<name>.
+//   - SearchProcessor post-processes the hits of every search endpoint.
+//
+// Hooks of several plugins run in the order of their names. PLUGINS_DISABLED
+// turns registered plugins off without rebuilding.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/alfagnish/ollqd-gateway/internal/config"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// Plugin is a gateway extension. Name is also its route prefix under
+// /api/plugins, so it should be a short lowercase word.
+type Plugin interface {
+	Name() string
+}
+
+// Host is what a plugin is given at startup.
+type Host struct {
+	Config *config.Config
+	// Store persists plugin settings in DATA_DIR; use documents named
+	// "plugin-<name>" to stay clear of the gateway's own.
+	Store *store.Store
+}
+
+// Initializer is implemented by plugins that need setup. An Init error
+// stops the gateway from starting.
+type Initializer interface {
+	Init(host Host) error
+}
+
+// RequestMutator is implemented by plugins that inspect or change requests.
+// It runs after authentication, so the caller's claims are in the request
+// context. Returning a different request replaces it; returning an error
+// rejects the request, with the status of an *Error or 400 otherwise, and
+// code PLUGIN_REJECTED.
+type RequestMutator interface {
+	MutateRequest(r *http.Request) (*http.Request, error)
+}
+
+// RouteProvider is implemented by plugins that serve their own endpoints,
+// mounted under /api/plugins/<name> behind the usual authentication.
+type RouteProvider interface {
+	Routes(r chi.Router)
+}
+
+// SearchProcessor is implemented by plugins that post-process search
+// results, e.g. to scrub or re-rank hits. It may modify the hits in place
+// and returns the hits to keep. An error fails the search.
+type SearchProcessor interface {
+	ProcessSearch(ctx context.Context, search Search, hits []*grpcclient.SearchHit) ([]*grpcclient.SearchHit, error)
+}
+
+// Search describes the search whose hits a SearchProcessor receives.
+type Search struct {
+	Query      string
+	Collection string
+	// Mode is "vector", "keyword" or "related" (neighbours of a stored
+	// point; Query is empty).
+	Mode string
+}
+
+// Error is a RequestMutator error carrying the HTTP status to reject the
+// request with.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Reject returns an *Error for status and message.
+func Reject(status int, message string) error {
+	return &Error{Status: status, Message: message}
+}
+
+var (
+	mu       sync.RWMutex
+	plugins  = map[string]Plugin{}
+	disabled = map[string]bool{}
+)
+
+// Register adds p to the gateway. It panics if a plugin of the same name
+// is already registered; call it from an init function.
+func Register(p Plugin) {
+	mu.Lock()
+	defer mu.Unlock()
+	name := p.Name()
+	if name == "" {
+		panic("plugin: Register with an empty name")
+	}
+	if _, dup := plugins[name]; dup {
+		panic(fmt.Sprintf("plugin: Register called twice for %q", name))
+	}
+	plugins[name] = p
+}
+
+// enabled returns the registered plugins not disabled, sorted by name.
+func enabled() []Plugin {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]Plugin, 0, len(plugins))
+	for name, p := range plugins {
+		if !disabled[name] {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// Init disables the plugins named in host.Config.PluginsDisabled and
+// initialises the others. Disabling a plugin that is not registered is
+// logged, not an error, so one configuration can serve several builds.
+func Init(host Host) error {
+	mu.Lock()
+	for _, name := range host.Config.PluginsDisabled {
+		if _, ok := plugins[name]; !ok {
+			log.Printf("WARNING: PLUGINS_DISABLED: no plugin %q is registered", name)
+		}
+		disabled[name] = true
+	}
+	mu.Unlock()
+
+	for _, p := range enabled() {
+		if in, ok := p.(Initializer); ok {
+			if err := in.Init(host); err != nil {
+				return fmt.Errorf("plugin %s: %w", p.Name(), err)
+			}
+		}
+		log.Printf("plugin %s enabled (%v)", p.Name(), hooks(p))
+	}
+	return nil
+}
+
+// Middleware runs the RequestMutators on every request.
+func Middleware(next http.Handler) http.Handler {
+	var mutators []Plugin
+	for _, p := range enabled() {
+		if _, ok := p.(RequestMutator); ok {
+			mutators = append(mutators, p)
+		}
+	}
+	if len(mutators) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, p := range mutators {
+			nr, err := p.(RequestMutator).MutateRequest(r)
+			if err != nil {
+				status := http.StatusBadRequest
+				var pe *Error
+				if errors.As(err, &pe) && pe.Status != 0 {
+					status = pe.Status
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				json.NewEncoder(w).Encode(map[string]string{"detail": err.Error(), "code": "PLUGIN_REJECTED"})
+				return
+			}
+			if nr != nil {
+				r = nr
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Mount registers the routes of every RouteProvider under its name.
+func Mount(r chi.Router) {
+	for _, p := range enabled() {
+		if rp, ok := p.(RouteProvider); ok {
+			r.Route("/"+p.Name(), rp.Routes)
+		}
+	}
+}
+
+// ProcessSearch passes hits through every SearchProcessor in turn.
+func ProcessSearch(ctx context.Context, search Search, hits []*grpcclient.SearchHit) ([]*grpcclient.SearchHit, error) {
+	for _, p := range enabled() {
+		sp, ok := p.(SearchProcessor)
+		if !ok {
+			continue
+		}
+		var err error
+		if hits, err = sp.ProcessSearch(ctx, search, hits); err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+	}
+	return hits, nil
+}
+
+// Info describes a registered plugin.
+type Info struct {
+	Name    string   `json:"name"`
+	Enabled bool     `json:"enabled"`
+	Hooks   []string `json:"hooks"`
+}
+
+// List describes every registered plugin, sorted by name.
+func List() []Info {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]Info, 0, len(plugins))
+	for name, p := range plugins {
+		out = append(out, Info{Name: name, Enabled: !disabled[name], Hooks: hooks(p)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// hooks names the hook interfaces p implements.
+func hooks(p Plugin) []string {
+	out := []string{}
+	if _, ok := p.(Initializer); ok {
+		out = append(out, "init")
+	}
+	if _, ok := p.(RequestMutator); ok {
+		out = append(out, "request")
+	}
+	if _, ok := p.(RouteProvider); ok {
+		out = append(out, "routes")
+	}
+	if _, ok := p.(SearchProcessor); ok {
+		out = append(out, "search")
+	}
+	return out
+}
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"context"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -8,45 +9,79 @@ import (
 	"time"
 )
 
-// NewOllamaProxy creates an HTTP reverse proxy to the Ollama API.
+// ollamaTargetKey is the context key for a per-request Ollama target.
+type ollamaTargetKey struct{}
+
+// InstanceParam is the query parameter selecting an Ollama instance. The
+// proxy strips it before forwarding.
+const InstanceParam = "instance"
+
+// WithOllamaTarget makes the Ollama proxy send the request to target instead
+// of the URL it was created with.
+func WithOllamaTarget(ctx context.Context, target *url.URL) context.Context {
+	return context.WithValue(ctx, ollamaTargetKey{}, target)
+}
+
+// NewOllamaProxy creates an HTTP reverse proxy to the Ollama API. Requests
+// go to targetURL unless their context carries another target (see
+// WithOllamaTarget).
 //
 // It supports streaming responses (chunked transfer encoding) for
 // endpoints like /api/chat, /api/generate, and /api/pull by setting
 // FlushInterval to -1, which causes the proxy to flush every write
-// to the client immediately.
-func NewOllamaProxy(targetURL string) (*httputil.ReverseProxy, error) {
+// to the client immediately. Requests go through transport (see
+// NewOllamaTransport).
+func NewOllamaProxy(targetURL string, transport http.RoundTripper) (*httputil.ReverseProxy, error) {
 	target, err := url.Parse(targetURL)
 	if err != nil {
 		return nil, err
 	}
 
-	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy := &httputil.ReverseProxy{}
 
-	// Replace the default director to strip the /api/ollama prefix and
-	// forward the remaining path to Ollama.
-	originalDirector := proxy.Director
+	// Strip the /api/ollama prefix and forward the remaining path to the
+	// request's Ollama instance.
 	proxy.Director = func(req *http.Request) {
-		originalDirector(req)
+		t := target
+		if override, ok := req.Context().Value(ollamaTargetKey{}).(*url.URL); ok && override != nil {
+			t = override
+		}
 		// Strip the /api/ollama prefix so /api/ollama/api/tags becomes /api/tags.
-		req.URL.Path = strings.TrimPrefix(req.URL.Path, "/api/ollama")
-		if req.URL.Path == "" {
-			req.URL.Path = "/"
+		path := strings.TrimPrefix(req.URL.Path, "/api/ollama")
+		if path == "" {
+			path = "/"
 		}
+		req.URL.Scheme = t.Scheme
+		req.URL.Host = t.Host
+		req.URL.Path = strings.TrimSuffix(t.Path, "/") + path
 		req.URL.RawPath = ""
-		req.Host = target.Host
+		q := req.URL.Query()
+		if q.Has(InstanceParam) {
+			q.Del(InstanceParam)
+			req.URL.RawQuery = q.Encode()
+		}
+		req.Host = t.Host
+		if _, ok := req.Header["User-Agent"]; !ok {
+			// Keep net/http from adding its default User-Agent.
+			req.Header.Set("User-Agent", "")
+		}
 	}
 
 	// Enable streaming: flush every chunk immediately.
 	proxy.FlushInterval = -1 * time.Millisecond
 
-	// Increase default transport timeouts for long-running model operations.
-	proxy.Transport = &http.Transport{
-		MaxIdleConns:        100,
-		IdleConnTimeout:     90 * time.Second,
-		TLSHandshakeTimeout: 10 * time.Second,
-		// ResponseHeaderTimeout is intentionally 0 (no timeout) to support
-		// long-running streaming responses from Ollama.
-	}
+	proxy.Transport = transport
 
 	return proxy, nil
 }
+
+// NewOllamaTransport returns the transport shared by all requests to
+// Ollama instances. Ollama is always reached directly, never through
+// HTTP_PROXY. It sets no response header timeout: generating and pulling
+// can take minutes before Ollama answers, so callers bound their requests
+// themselves.
+func NewOllamaTransport(opts TransportOptions) http.RoundTripper {
+	t := newPooledTransport(opts)
+	t.Proxy = nil
+	return withRetries(t, opts.Retries)
+}
@@ -1,23 +1,111 @@
 package proxy
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"os"
 	"strings"
 )
 
-// NewQdrantProxy creates an HTTP reverse proxy to the Qdrant vector database.
+// QdrantAPIKeyHeader is the header Qdrant reads its API key from.
+const QdrantAPIKeyHeader = "api-key"
+
+// QdrantOptions configures how the gateway connects to Qdrant.
+type QdrantOptions struct {
+	URL string
+	// APIKey is sent as the api-key header on every request to URL.
+	APIKey string
+	// CAFile is a PEM bundle trusted, next to the system roots, for
+	// Qdrant's certificate.
+	CAFile string
+	// Insecure skips verification of Qdrant's certificate.
+	Insecure bool
+	// Pool tunes the connection pool and retries.
+	Pool TransportOptions
+}
+
+// NewQdrantTransport returns the transport for requests to Qdrant. It
+// verifies Qdrant's certificate against opts.CAFile when set and adds the
+// API key to requests for opts.URL's host only, so that requests to other
+// hosts made with it never carry the key. Idempotent requests are retried
+// as opts.Pool says.
+func NewQdrantTransport(opts QdrantOptions) (http.RoundTripper, error) {
+	target, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	base := newPooledTransport(opts.Pool)
+	if opts.CAFile != "" || opts.Insecure {
+		tlsCfg := &tls.Config{InsecureSkipVerify: opts.Insecure}
+		if opts.CAFile != "" {
+			pool, err := LoadCAFile(opts.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("QDRANT_CA_FILE: %v", err)
+			}
+			tlsCfg.RootCAs = pool
+		}
+		base.TLSClientConfig = tlsCfg
+	}
+	if opts.APIKey == "" {
+		return withRetries(base, opts.Pool.Retries), nil
+	}
+	return withRetries(&qdrantTransport{base: base, host: target.Host, apiKey: opts.APIKey}, opts.Pool.Retries), nil
+}
+
+// qdrantTransport adds the API key to requests for host.
+type qdrantTransport struct {
+	base   http.RoundTripper
+	host   string
+	apiKey string
+}
+
+func (t *qdrantTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(QdrantAPIKeyHeader, t.apiKey)
+	// Qdrant also reads a bearer token as its key; the caller's gateway
+	// token must not compete with the configured one.
+	req.Header.Del("Authorization")
+	return t.base.RoundTrip(req)
+}
+
+// LoadCAFile reads a PEM CA bundle into a pool that also holds the system
+// roots.
+func LoadCAFile(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no PEM certificates found")
+	}
+	return pool, nil
+}
+
+// NewQdrantProxy creates an HTTP reverse proxy to the Qdrant vector database
+// that sends its requests through transport (see NewQdrantTransport).
 //
 // Unlike the Ollama proxy, Qdrant responses are not streamed, so no special
 // flush configuration is needed.
-func NewQdrantProxy(targetURL string) (*httputil.ReverseProxy, error) {
+func NewQdrantProxy(targetURL string, transport http.RoundTripper) (*httputil.ReverseProxy, error) {
 	target, err := url.Parse(targetURL)
 	if err != nil {
 		return nil, err
 	}
 
 	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = transport
 
 	// Replace the default director to strip the /api/qdrant prefix and
 	// forward the remaining path to Qdrant.
@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportOptions tunes the connection pool of the transport to one
+// backend. Zero values keep net/http's defaults.
+type TransportOptions struct {
+	// MaxIdleConnsPerHost is how many idle keep-alive connections are kept
+	// per host. net/http keeps only 2, so concurrent requests beyond that
+	// open and tear down a connection each.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept.
+	IdleConnTimeout time.Duration
+	// DialTimeout bounds opening a TCP connection.
+	DialTimeout time.Duration
+	// Retries is how many times a GET or HEAD is retried after a
+	// connection error or a 502, 503 or 504 response.
+	Retries int
+}
+
+// retryBackoff is the wait before the first retry; it doubles per retry.
+const retryBackoff = 100 * time.Millisecond
+
+// NewTransport returns a pooled transport to one backend, wrapped to retry
+// idempotent requests as opts says. The gateway shares one per backend, so
+// keep-alive connections are reused across handlers.
+func NewTransport(opts TransportOptions) http.RoundTripper {
+	return withRetries(newPooledTransport(opts), opts.Retries)
+}
+
+// newPooledTransport returns a copy of http.DefaultTransport with the pool
+// settings of opts.
+func newPooledTransport(opts TransportOptions) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+		if t.MaxIdleConns < opts.MaxIdleConnsPerHost {
+			t.MaxIdleConns = opts.MaxIdleConnsPerHost
+		}
+	}
+	if opts.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.DialTimeout > 0 {
+		t.DialContext = (&net.Dialer{Timeout: opts.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	}
+	return t
+}
+
+// withRetries wraps base to retry idempotent requests up to retries times.
+func withRetries(base http.RoundTripper, retries int) http.RoundTripper {
+	if retries <= 0 {
+		return base
+	}
+	return &retryTransport{base: base, retries: retries}
+}
+
+// retryTransport retries bodiless GET and HEAD requests that fail to
+// connect or get a 502, 503 or 504 answer, with exponential backoff. Other
+// requests go through once.
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryable(req) {
+		return t.base.RoundTrip(req)
+	}
+	wait := retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt == t.retries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// retryable reports whether req can safely be sent again.
+func retryable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+// shouldRetry reports whether the outcome of one attempt is worth another.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
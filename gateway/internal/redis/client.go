@@ -0,0 +1,340 @@
+// Package redis is a small Redis client covering what the gateway's cluster
+// mode needs: plain commands over a pool of connections and pub/sub. It
+// speaks RESP2, which every Redis version and most compatible servers
+// (Valkey, KeyDB, Dragonfly) understand.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// dialTimeout bounds opening a connection when the context has no
+	// earlier deadline.
+	dialTimeout = 5 * time.Second
+	// maxIdleConns is how many idle connections the pool keeps.
+	maxIdleConns = 8
+)
+
+// Error is an error reply from the server, such as "WRONGTYPE ...".
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// ErrNil is returned by the typed helpers when the reply is a nil bulk
+// string, as for GET of a missing key.
+var ErrNil = errors.New("redis: nil reply")
+
+// Options says how to reach a Redis server.
+type Options struct {
+	Addr     string // host:port
+	Username string // ACL user ("" = default user)
+	Password string // "" = no AUTH
+	DB       int
+	TLS      bool
+}
+
+// ParseURL parses redis://[user:password@]host[:port][/db]; rediss:// turns
+// on TLS.
+func ParseURL(raw string) (Options, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Options{}, fmt.Errorf("redis url: %w", err)
+	}
+	var opts Options
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		opts.TLS = true
+	default:
+		return Options{}, fmt.Errorf("redis url: unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return Options{}, errors.New("redis url: missing host")
+	}
+	opts.Addr = u.Host
+	if u.Port() == "" {
+		opts.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+		if _, set := u.User.Password(); !set {
+			// redis://secret@host is the common short form for a password.
+			opts.Username, opts.Password = "", u.User.Username()
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil || n < 0 {
+			return Options{}, fmt.Errorf("redis url: invalid database %q", db)
+		}
+		opts.DB = n
+	}
+	return opts, nil
+}
+
+// Client runs commands on a pool of connections. It is safe for concurrent
+// use.
+type Client struct {
+	opts Options
+
+	mu   sync.Mutex
+	idle []*conn
+}
+
+// New creates a Client for opts. Connections are opened on first use.
+func New(opts Options) *Client {
+	return &Client{opts: opts}
+}
+
+// Do sends one command and returns its reply: a string for simple and bulk
+// strings, an int64 for integers, a []interface{} for arrays and nil for
+// nil replies. An error reply is returned as an Error.
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cn.setDeadline(ctx)
+	if err := cn.write(args); err != nil {
+		cn.Close()
+		return nil, err
+	}
+	reply, err := cn.read()
+	var rerr Error
+	if err != nil && !errors.As(err, &rerr) {
+		cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// String runs a command whose reply is a string. A nil reply gives ErrNil.
+func (c *Client) String(ctx context.Context, args ...string) (string, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	switch v := reply.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", ErrNil
+	}
+	return "", fmt.Errorf("redis: unexpected %T reply to %s", reply, args[0])
+}
+
+// Int runs a command whose reply is an integer.
+func (c *Client) Int(ctx context.Context, args ...string) (int64, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	if v, ok := reply.(int64); ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("redis: unexpected %T reply to %s", reply, args[0])
+}
+
+// Strings runs a command whose reply is an array of strings. Nil elements,
+// as MGET gives for missing keys, come back as "".
+func (c *Client) Strings(ctx context.Context, args ...string) ([]string, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected %T reply to %s", reply, args[0])
+	}
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i], _ = item.(string)
+	}
+	return out, nil
+}
+
+// Ping checks that the server answers.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections. Connections in use are closed when
+// they are returned.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	idle := c.idle
+	c.idle = nil
+	c.mu.Unlock()
+	for _, cn := range idle {
+		cn.Close()
+	}
+	return nil
+}
+
+// get takes an idle connection or opens a new one.
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+	return c.dial(ctx)
+}
+
+// put returns a healthy connection to the pool.
+func (c *Client) put(cn *conn) {
+	cn.SetDeadline(time.Time{})
+	c.mu.Lock()
+	if len(c.idle) < maxIdleConns {
+		c.idle = append(c.idle, cn)
+		cn = nil
+	}
+	c.mu.Unlock()
+	if cn != nil {
+		cn.Close()
+	}
+}
+
+// dial opens a connection, authenticates and selects the database.
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	d := net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}
+	var nc net.Conn
+	var err error
+	if c.opts.TLS {
+		host, _, _ := net.SplitHostPort(c.opts.Addr)
+		td := tls.Dialer{NetDialer: &d, Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+		nc, err = td.DialContext(ctx, "tcp", c.opts.Addr)
+	} else {
+		nc, err = d.DialContext(ctx, "tcp", c.opts.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis: connect %s: %w", c.opts.Addr, err)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	cn.setDeadline(ctx)
+
+	var setup [][]string
+	switch {
+	case c.opts.Password != "" && c.opts.Username != "":
+		setup = append(setup, []string{"AUTH", c.opts.Username, c.opts.Password})
+	case c.opts.Password != "":
+		setup = append(setup, []string{"AUTH", c.opts.Password})
+	}
+	if c.opts.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.opts.DB)})
+	}
+	for _, args := range setup {
+		if err := cn.write(args); err != nil {
+			cn.Close()
+			return nil, err
+		}
+		if _, err := cn.read(); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("redis: %s: %w", strings.ToLower(args[0]), err)
+		}
+	}
+	return cn, nil
+}
+
+// conn is one connection speaking RESP2.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// setDeadline applies ctx's deadline to the connection, if it has one.
+func (cn *conn) setDeadline(ctx context.Context) {
+	if dl, ok := ctx.Deadline(); ok {
+		cn.SetDeadline(dl)
+	} else {
+		cn.SetDeadline(time.Time{})
+	}
+}
+
+// write sends a command as an array of bulk strings.
+func (cn *conn) write(args []string) error {
+	fmt.Fprintf(cn.w, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(cn.w, "$%d\r\n%s\r\n", len(a), a)
+	}
+	return cn.w.Flush()
+}
+
+// read reads one reply. An error reply is returned as an Error after the
+// whole reply was consumed, so the connection stays usable.
+func (cn *conn) read() (interface{}, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, Error(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(cn.r, buf); err != nil {
+			return nil, err
+		}
+		if buf[n] != '\r' || buf[n+1] != '\n' {
+			return nil, fmt.Errorf("redis: bulk string of %d bytes not followed by CRLF", n)
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		var firstErr error
+		for i := range items {
+			item, err := cn.read()
+			var rerr Error
+			if err != nil && !errors.As(err, &rerr) {
+				return nil, err
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			items[i] = item
+		}
+		return items, firstErr
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
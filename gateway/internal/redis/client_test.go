@@ -0,0 +1,182 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeConn returns a conn that reads replies from the given wire data.
+func fakeConn(wire string) *conn {
+	return &conn{r: bufio.NewReader(strings.NewReader(wire))}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		wire    string
+		want    interface{}
+		wantErr error // an Error reply, or nil
+		bad     bool  // a protocol error
+	}{
+		{"simple string", "+OK\r\n", "OK", nil, false},
+		{"empty simple string", "+\r\n", "", nil, false},
+		{"error", "-WRONGTYPE wrong kind\r\n", nil, Error("WRONGTYPE wrong kind"), false},
+		{"integer", ":42\r\n", int64(42), nil, false},
+		{"negative integer", ":-7\r\n", int64(-7), nil, false},
+		{"bulk", "$5\r\nhello\r\n", "hello", nil, false},
+		{"bulk with CRLF inside", "$8\r\nab\r\ncd\r\n\r\n", "ab\r\ncd\r\n", nil, false},
+		{"empty bulk", "$0\r\n\r\n", "", nil, false},
+		{"nil bulk", "$-1\r\n", nil, nil, false},
+		{"array", "*3\r\n$3\r\nfoo\r\n:1\r\n$-1\r\n", []interface{}{"foo", int64(1), nil}, nil, false},
+		{"empty array", "*0\r\n", []interface{}{}, nil, false},
+		{"nil array", "*-1\r\n", nil, nil, false},
+		{"nested array", "*2\r\n*1\r\n+a\r\n$1\r\nb\r\n", []interface{}{[]interface{}{"a"}, "b"}, nil, false},
+		{"array with error", "*2\r\n-ERR first\r\n+OK\r\n", []interface{}{nil, "OK"}, Error("ERR first"), false},
+		{"unknown type", "?x\r\n", nil, nil, true},
+		{"no CRLF", "+OK\n", nil, nil, true},
+		{"bad integer", ":x\r\n", nil, nil, true},
+		{"bad bulk length", "$x\r\n", nil, nil, true},
+		{"bad array length", "*x\r\n", nil, nil, true},
+		{"short bulk", "$5\r\nhel", nil, nil, true},
+		{"bulk without CRLF", "$3\r\nfooXX", nil, nil, true},
+		{"truncated array", "*2\r\n+a\r\n", nil, nil, true},
+		{"empty input", "", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fakeConn(tt.wire).read()
+			var rerr Error
+			switch {
+			case tt.bad:
+				if err == nil || errors.As(err, &rerr) {
+					t.Fatalf("read = %#v, %v; want a protocol error", got, err)
+				}
+				return
+			case tt.wantErr != nil:
+				if err != tt.wantErr {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("read: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// An error reply, even inside an array, is consumed whole so the next reply
+// reads cleanly.
+func TestReadKeepsStreamInSync(t *testing.T) {
+	cn := fakeConn("*2\r\n-ERR first\r\n$2\r\nok\r\n-ERR next\r\n:5\r\n")
+	if _, err := cn.read(); err != Error("ERR first") {
+		t.Fatalf("array err = %v", err)
+	}
+	if _, err := cn.read(); err != Error("ERR next") {
+		t.Fatalf("error reply err = %v", err)
+	}
+	if got, err := cn.read(); err != nil || got != int64(5) {
+		t.Fatalf("read = %v, %v; want 5", got, err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var b strings.Builder
+	cn := &conn{w: bufio.NewWriter(&b)}
+	if err := cn.write([]string{"SET", "k", "a b\r\n"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$5\r\na b\r\n\r\n"
+	if b.String() != want {
+		t.Errorf("wrote %q, want %q", b.String(), want)
+	}
+}
+
+// pipeClient returns a Client whose pool holds one connection to a fake
+// server that answers each command with the next of replies.
+func pipeClient(t *testing.T, replies ...string) *Client {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() { client.Close(); server.Close() })
+	go func() {
+		r := bufio.NewReader(server)
+		for _, reply := range replies {
+			cmd := &conn{r: r}
+			if _, err := cmd.read(); err != nil {
+				return
+			}
+			if _, err := server.Write([]byte(reply)); err != nil {
+				return
+			}
+		}
+	}()
+	c := New(Options{})
+	c.idle = []*conn{{Conn: client, r: bufio.NewReader(client), w: bufio.NewWriter(client)}}
+	return c
+}
+
+func TestClientHelpers(t *testing.T) {
+	ctx := context.Background()
+	c := pipeClient(t,
+		"$5\r\nvalue\r\n",
+		"$-1\r\n",
+		":3\r\n",
+		"*2\r\n$1\r\na\r\n$-1\r\n",
+		"-WRONGTYPE bad\r\n",
+		"+PONG\r\n",
+	)
+
+	if s, err := c.String(ctx, "GET", "k"); err != nil || s != "value" {
+		t.Errorf("String = %q, %v", s, err)
+	}
+	if _, err := c.String(ctx, "GET", "missing"); err != ErrNil {
+		t.Errorf("String of nil = %v, want ErrNil", err)
+	}
+	if n, err := c.Int(ctx, "INCR", "n"); err != nil || n != 3 {
+		t.Errorf("Int = %d, %v", n, err)
+	}
+	if ss, err := c.Strings(ctx, "MGET", "a", "b"); err != nil || !reflect.DeepEqual(ss, []string{"a", ""}) {
+		t.Errorf("Strings = %q, %v", ss, err)
+	}
+	if _, err := c.Do(ctx, "LPUSH", "k", "v"); err != Error("WRONGTYPE bad") {
+		t.Errorf("Do err = %v, want the error reply", err)
+	}
+	// The connection survived the error reply and went back to the pool.
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("Ping after an error reply: %v", err)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Options
+		bad  bool
+	}{
+		{raw: "redis://localhost", want: Options{Addr: "localhost:6379"}},
+		{raw: "rediss://cache:6380/2", want: Options{Addr: "cache:6380", DB: 2, TLS: true}},
+		{raw: "redis://secret@cache", want: Options{Addr: "cache:6379", Password: "secret"}},
+		{raw: "redis://app:pw@cache", want: Options{Addr: "cache:6379", Username: "app", Password: "pw"}},
+		{raw: "http://cache", bad: true},
+		{raw: "redis://", bad: true},
+		{raw: "redis://cache/x", bad: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseURL(tt.raw)
+		if tt.bad {
+			if err == nil {
+				t.Errorf("ParseURL(%q) succeeded", tt.raw)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseURL(%q) = %+v, %v; want %+v", tt.raw, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Message is a message received on a subscribed channel.
+type Message struct {
+	Channel string
+	Payload string
+}
+
+// Subscription is a connection in subscribe mode. It is not safe for
+// concurrent use; one goroutine should call Receive in a loop.
+type Subscription struct {
+	cn *conn
+}
+
+// Subscribe opens a dedicated connection subscribed to the given channels.
+func (c *Client) Subscribe(ctx context.Context, channels ...string) (*Subscription, error) {
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cn.setDeadline(ctx)
+	if err := cn.write(append([]string{"SUBSCRIBE"}, channels...)); err != nil {
+		cn.Close()
+		return nil, err
+	}
+	for range channels {
+		if _, err := cn.read(); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("redis: subscribe: %w", err)
+		}
+	}
+	cn.SetDeadline(time.Time{})
+	return &Subscription{cn: cn}, nil
+}
+
+// Receive waits for the next message. It returns an error once the
+// connection fails or is closed; the caller should then subscribe again.
+func (s *Subscription) Receive() (Message, error) {
+	for {
+		reply, err := s.cn.read()
+		if err != nil {
+			return Message{}, err
+		}
+		items, ok := reply.([]interface{})
+		if !ok || len(items) != 3 {
+			continue
+		}
+		if kind, _ := items[0].(string); kind != "message" {
+			continue // subscribe confirmations and pongs
+		}
+		channel, _ := items[1].(string)
+		payload, _ := items[2].(string)
+		return Message{Channel: channel, Payload: payload}, nil
+	}
+}
+
+// Close ends the subscription and closes its connection, which makes a
+// pending Receive return.
+func (s *Subscription) Close() error {
+	return s.cn.Close()
+}
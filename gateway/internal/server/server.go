@@ -1,97 +1,359 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/alfagnish/ollqd-gateway/internal/config"
 	"github.com/alfagnish/ollqd-gateway/internal/docker"
 	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/grpcserver"
 	"github.com/alfagnish/ollqd-gateway/internal/handlers"
+	"github.com/alfagnish/ollqd-gateway/internal/i18n"
+	"github.com/alfagnish/ollqd-gateway/internal/imagemeta"
+	"github.com/alfagnish/ollqd-gateway/internal/lifecycle"
+	"github.com/alfagnish/ollqd-gateway/internal/manifest"
 	authmw "github.com/alfagnish/ollqd-gateway/internal/middleware"
+	"github.com/alfagnish/ollqd-gateway/internal/notify"
+	"github.com/alfagnish/ollqd-gateway/internal/plugin"
 	"github.com/alfagnish/ollqd-gateway/internal/proxy"
+	"github.com/alfagnish/ollqd-gateway/internal/redis"
+	"github.com/alfagnish/ollqd-gateway/internal/store"
 	"github.com/alfagnish/ollqd-gateway/internal/tasks"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"google.golang.org/grpc"
 )
 
 // New creates a fully-configured chi router with all route groups,
-// middleware, and handlers wired together.
-func New(cfg *config.Config, gc *grpcclient.Client, tm *tasks.Manager) (http.Handler, error) {
+// middleware, and handlers wired together. When cfg.GRPCListenAddr is set it
+// also returns the gRPC task server sharing the same task manager and
+// settings; otherwise the returned *grpc.Server is nil.
+func New(cfg *config.Config, gc *grpcclient.Client, tm *tasks.Manager, lc *lifecycle.State) (http.Handler, *grpc.Server, error) {
 	r := chi.NewRouter()
 
+	trusted, err := authmw.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// ── Middleware ───────────────────────────────────────────
-	r.Use(cors.Handler(cors.Options{
+	// The chat widget under /embed answers CORS itself, per embed token.
+	r.Use(skipPrefix("/embed/", cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: true,
 		MaxAge:           300,
-	}))
+	})))
 	r.Use(requestLogger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.RealIP)
+	r.Use(authmw.Forwarded(trusted, cfg.BasePath))
+	r.Use(i18n.Middleware)
+
+	// ── Auth policy ─────────────────────────────────────────
+	// Applied once to every route; DefaultPublicPaths plus AUTH_PUBLIC_PATHS
+	// stay reachable without a token, admin-only groups add RequireAdmin.
+	st := store.New(cfg.DataDir)
+	sessions := authmw.NewSessionStore(st)
+	policy, err := authmw.NewPolicy(cfg.AuthMode, cfg.JWTSecret, sessions, cfg.AuthPublicPaths)
+	if err != nil {
+		return nil, nil, err
+	}
+	if policy.Mode() == authmw.AuthDisabled {
+		log.Printf("WARNING: AUTH_MODE=disabled; every request is treated as an admin")
+	}
+	r.Use(policy.Authenticate)
+
+	// ── Change events ───────────────────────────────────────
+	// Successful configuration changes are broadcast on /api/system/events.
+	events := handlers.NewEventBus()
+	r.Use(events.Middleware)
+
+	// ── Audit log ───────────────────────────────────────────
+	// Every change made through the API is recorded with who made it.
+	audit := handlers.NewAuditLog(cfg.DataDir, cfg.AuditMaxEntries)
+	r.Use(audit.Middleware)
+
+	// ── Capture mode ────────────────────────────────────────
+	// Admins can record redacted request and response bodies of chosen
+	// routes to reproduce mismatches between the UI, gateway and worker.
+	captures := handlers.NewCaptureLog(cfg.DataDir, cfg.CaptureMaxEntries,
+		int(min(cfg.CaptureMaxBodyKB<<10, 1<<20)), cfg.TaskRedactParams)
+	r.Use(captures.Middleware)
+
+	// ── Cluster mode ────────────────────────────────────────
+	// With REDIS_URL set, replicas share their tasks and change events, so
+	// any replica behind the load balancer can report on any task.
+	if cfg.RedisURL != "" {
+		redisOpts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("REDIS_URL: %w", err)
+		}
+		rc := redis.New(redisOpts)
+		err = tm.EnableCluster(context.Background(), tasks.ClusterOptions{
+			Client: rc,
+			NodeID: cfg.ClusterNodeID,
+			Prefix: cfg.RedisPrefix,
+			TTL:    time.Duration(cfg.ClusterTaskTTLHours) * time.Hour,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("cluster mode: redis at %s: %w", redisOpts.Addr, err)
+		}
+		events.Bridge(context.Background(), rc, cfg.RedisPrefix+":events", cfg.ClusterNodeID)
+		log.Printf("cluster mode: node %s sharing tasks through redis at %s", cfg.ClusterNodeID, redisOpts.Addr)
+	}
+
+	// ── Plugins ─────────────────────────────────────────────
+	// Compiled-in plugins (see cmd/gateway/plugins.go) are initialised before
+	// their request hooks are installed.
+	if err := plugin.Init(plugin.Host{Config: cfg, Store: st}); err != nil {
+		return nil, nil, err
+	}
+	r.Use(plugin.Middleware)
 
 	// ── Reverse proxies ─────────────────────────────────────
-	ollamaProxy, err := proxy.NewOllamaProxy(cfg.OllamaURL)
+	// Every request to Ollama or Qdrant, proxied or made by a handler, goes
+	// through one pooled transport per backend.
+	pool := proxy.TransportOptions{
+		MaxIdleConnsPerHost: cfg.HTTPMaxIdlePerHost,
+		IdleConnTimeout:     time.Duration(cfg.HTTPIdleTimeout) * time.Second,
+		DialTimeout:         time.Duration(cfg.HTTPDialTimeout) * time.Second,
+		Retries:             cfg.HTTPGetRetries,
+	}
+	ollamaTransport := proxy.NewOllamaTransport(pool)
+	ollamaClients := handlers.NewOllamaClients(ollamaTransport, time.Duration(cfg.OllamaTimeout)*time.Second)
+	ollamaProxy, err := proxy.NewOllamaProxy(cfg.OllamaURL, ollamaTransport)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	qdrantTransport, err := proxy.NewQdrantTransport(proxy.QdrantOptions{
+		URL:      cfg.QdrantURL,
+		APIKey:   cfg.QdrantAPIKey,
+		CAFile:   cfg.QdrantCAFile,
+		Insecure: cfg.QdrantInsecure,
+		Pool:     pool,
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	qdrantClient := &http.Client{Transport: qdrantTransport, Timeout: time.Duration(cfg.QdrantTimeout) * time.Second}
 
-	qdrantProxy, err := proxy.NewQdrantProxy(cfg.QdrantURL)
+	qdrantProxy, err := proxy.NewQdrantProxy(cfg.QdrantURL, qdrantTransport)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// ── Docker manager ─────────────────────────────────────
 	dm := docker.New(cfg.DockerSocket)
 
+	// ── Persistent settings ────────────────────────────────
+	colls := handlers.NewCollectionSettings(st, cfg.DefaultCollection)
+	manifests := manifest.NewStore(st)
+	ignores := handlers.NewIgnoreProfiles(st)
+	searchDefaults := handlers.NewSearchDefaults(st)
+	searchTerms := handlers.NewSearchTerms(st)
+	branding := handlers.NewBranding(st)
+	uploadRouting := handlers.NewUploadRouting(st)
+	ollamaInstances := handlers.NewOllamaInstances(st, cfg.OllamaURL)
+	var ollamaReleases *handlers.OllamaReleases
+	if cfg.OllamaUpdateCheck {
+		ollamaReleases = handlers.NewOllamaReleases(cfg.OllamaReleasesURL)
+	}
+	imageSigner := handlers.NewImageSigner(cfg.JWTSecret, time.Duration(cfg.ImageURLTTLMinutes)*time.Minute, cfg.UploadDir)
+	diffIdx := handlers.NewDiffIndexer(manifests, cfg.QdrantURL, qdrantClient)
+	notifier := notify.New(st)
+	tm.OnFinish(notifier.Notify)
+	indexReports := handlers.NewIndexReports(st, gc)
+	tm.OnFinish(indexReports.Record)
+	savedSearches := handlers.NewSavedSearches(st)
+	imageMeta := imagemeta.NewAttacher(filepath.Join(cfg.UploadDir, ".imagemeta"))
+
 	// ── Handlers ────────────────────────────────────────────
-	authH := handlers.NewAuthHandler(cfg, gc)
-	usersH := handlers.NewUsersHandler(gc)
-	systemH := handlers.NewSystemHandler(cfg, gc, dm)
-	ollamaH := handlers.NewOllamaHandler(ollamaProxy, cfg.OllamaURL)
-	qdrantH := handlers.NewQdrantHandler(qdrantProxy, cfg.QdrantURL, gc)
-	ragH := handlers.NewRAGHandler(gc, tm)
-	tasksH := handlers.NewTasksHandler(gc, tm)
-	uploadH := handlers.NewUploadHandler(cfg, gc, tm)
-	wsH := handlers.NewWSHandler(gc)
-	smbH := handlers.NewSMBHandler(gc, tm)
-	imageH := handlers.NewImageHandler(cfg)
-
-	// ── Public routes (no auth) ─────────────────────────────
+	authH := handlers.NewAuthHandler(cfg, gc, sessions)
+	userPrefs := handlers.NewUserPreferencesStore(st)
+	usersH := handlers.NewUsersHandler(gc, sessions, userPrefs, savedSearches)
+	userPrefsH := handlers.NewUserPreferencesHandler(userPrefs)
+	systemH := handlers.NewSystemHandler(cfg, gc, dm, ollamaTransport, qdrantTransport)
+	ignoresH := handlers.NewIgnoreProfilesHandler(ignores, colls)
+	searchDefaultsH := handlers.NewSearchDefaultsHandler(searchDefaults)
+	searchTermsH := handlers.NewSearchTermsHandler(searchTerms)
+	brandingH := handlers.NewBrandingHandler(branding)
+	uploadRoutingH := handlers.NewUploadRoutingHandler(uploadRouting)
+	ollamaH := handlers.NewOllamaHandler(ollamaProxy, ollamaInstances, ollamaClients, gc, events, handlers.PullOptions{
+		MaxConcurrent:  cfg.MaxConcurrentPulls,
+		Retries:        cfg.PullRetries,
+		Mirror:         cfg.RegistryMirror,
+		MirrorInsecure: cfg.RegistryInsecure,
+		CAFile:         cfg.RegistryCAFile,
+	}, ollamaReleases, dm)
+	qdrantH := handlers.NewQdrantHandler(qdrantProxy, cfg.QdrantURL, qdrantClient, gc, colls, tm, searchDefaults, searchTerms, indexReports, events, cfg.ImportMaxMB)
+	keyword := handlers.NewKeywordSearcher(cfg.QdrantURL, qdrantClient, cfg.KeywordFallback)
+	guard := handlers.NewGuardrails(handlers.GuardrailOptions{
+		Mode:             cfg.GuardMode,
+		MinFreeDiskMB:    cfg.GuardMinFreeDiskMB,
+		MaxMemoryPercent: cfg.GuardMaxMemoryPct,
+		UploadDir:        cfg.UploadDir,
+		QdrantDir:        cfg.GuardQdrantDir,
+		QdrantURL:        cfg.QdrantURL,
+		QdrantClient:     qdrantClient,
+	})
+	collCheck := handlers.NewCollectionChecker(cfg.QdrantURL, qdrantClient, time.Duration(cfg.SearchCheckTTL)*time.Second)
+	ragH := handlers.NewRAGHandler(gc, tm, colls, diffIdx, imageMeta, keyword, ignores, searchDefaults, searchTerms, ollamaInstances, imageSigner, guard, collCheck)
+	liveSearchH := handlers.NewLiveSearchHandler(ragH, ollamaH, sessions, cfg.QdrantURL, qdrantClient)
+	savedSearchesH := handlers.NewSavedSearchesHandler(savedSearches, ollamaH, notifier, cfg.QdrantURL, qdrantClient)
+	tm.OnFinish(savedSearchesH.CheckAlerts)
+	estimateH := handlers.NewIndexEstimateHandler(gc, colls, ignores, indexReports)
+	presetsH := handlers.NewIndexPresetsHandler(handlers.NewIndexPresets(st), ragH)
+	tasksH := handlers.NewTasksHandler(gc, tm, imageMeta, indexReports, ollamaInstances, guard)
+	sourcesH := handlers.NewSourcesHandler(cfg.QdrantURL, qdrantClient)
+	migrateH := handlers.NewMigrationHandler(qdrantH, ollamaH, tm)
+	dedupeH := handlers.NewDedupeHandler(qdrantH, tm)
+	retentionH := handlers.NewRetentionHandler(handlers.NewRetentionPolicies(st), qdrantH, tm)
+	if cfg.RetentionMinutes > 0 {
+		retentionH.StartRetention(context.Background(), time.Duration(cfg.RetentionMinutes)*time.Minute)
+	}
+	uploadH := handlers.NewUploadHandler(cfg, gc, tm, colls, imageMeta, uploadRouting, sourcesH, imageSigner, guard)
+	chatPrefs := handlers.NewChatPrefsStore(chatDefaults(cfg))
+	chatPrefsH := handlers.NewChatPrefsHandler(chatPrefs)
+	citations := handlers.NewCitationEnricher(cfg.QdrantURL, qdrantClient, imageSigner, cfg.BasePath)
+	chatRouter := handlers.NewChatRouter(colls, ollamaH, tm, cfg.QdrantURL, qdrantClient)
+	grounding := handlers.NewGroundingScorer(ollamaH, cfg.ChatGroundingMinPct)
+	chatFlush := handlers.NewStreamFlush(cfg.ChatFlushMS, cfg.ChatFlushBytes)
+	wsH := handlers.NewWSHandler(gc, chatPrefs, sessions, tm, citations, ollamaInstances, chatRouter, grounding, chatFlush)
+	openaiH := handlers.NewOpenAIHandler(gc, chatPrefs, tm, citations, ollamaInstances, cfg.QdrantURL, qdrantClient, chatFlush)
+	smbH := handlers.NewSMBHandler(gc, tm, colls, st, diffIdx, guard)
+	smbH.StartSyncScheduler(context.Background())
+	connectorsH := handlers.NewConnectorsHandler(cfg, gc, tm, colls, st, diffIdx)
+	connectorsH.StartScheduler(context.Background())
+	bundleH := handlers.NewConfigBundleHandler(gc, colls, smbH, notifier, ignores, searchDefaults)
+	notificationsH := handlers.NewNotificationsHandler(notifier)
+	imageH := handlers.NewImageHandler(cfg, imageSigner, gc, ollamaInstances, ollamaClients.Stream)
+	previewH := handlers.NewPreviewHandler(cfg, gc, colls, manifests, cfg.QdrantURL, qdrantClient)
+	probesH := handlers.NewProbesHandler(gc, lc, cfg.DrainToken)
+	shareH := handlers.NewShareHandler(handlers.NewShareLinks(st), previewH, cfg.JWTSecret, cfg.BasePath)
+	embedH := handlers.NewEmbedHandler(handlers.NewEmbedTokens(st), gc, chatPrefs, tm, citations, chatFlush)
+
+	// ── Routes ──────────────────────────────────────────────
+	workerDeadline := authmw.WorkerDeadline(
+		time.Duration(cfg.WorkerTimeout)*time.Second,
+		time.Duration(cfg.WorkerTimeoutMax)*time.Second,
+	)
+
+	probesH.Routes(r)
 	r.Route("/api/auth", authH.Routes)
 	r.Get("/api/health", systemH.Health)
 
-	// ── Protected routes (auth required) ────────────────────
-	r.Group(func(r chi.Router) {
-		r.Use(authmw.RequireAuth(cfg.JWTSecret))
+	// Diagnostics sit outside the worker deadline so CPU profiles and
+	// traces can run for as long as they ask.
+	if cfg.DebugEndpoints {
+		debugH := handlers.NewDebugHandler(gc, tm)
+		r.Route("/api/system/debug", func(r chi.Router) {
+			r.Use(authmw.RequireAdmin)
+			debugH.Routes(r)
+		})
+	}
+	// The event stream stays open, so it sits outside the worker deadline.
+	r.Get("/api/system/events", events.Stream)
+	r.Route("/api/system", func(r chi.Router) {
+		r.Use(workerDeadline)
+		systemH.Routes(r)
+		r.Route("/config/ignore-profiles", ignoresH.Routes)
+		r.Route("/config/search", func(r chi.Router) {
+			searchDefaultsH.Routes(r)
+			searchTermsH.Routes(r)
+		})
+		r.Route("/config/routing", uploadRoutingH.Routes)
+		r.Route("/branding", brandingH.Routes)
+		r.Group(func(r chi.Router) {
+			r.Use(authmw.RequireAdmin)
+			bundleH.Routes(r)
+			r.Route("/notifications", notificationsH.Routes)
+			r.Route("/audit", handlers.NewAuditHandler(audit).Routes)
+			r.Route("/captures", handlers.NewCaptureHandler(captures).Routes)
+			r.Get("/plugins", handlers.ListPlugins)
+			r.Route("/embed-tokens", embedH.Routes)
+		})
+	})
+	r.Route("/api/ollama", ollamaH.Routes)
+	r.Route("/api/qdrant", func(r chi.Router) {
+		qdrantH.Routes(r)
+		retentionH.Routes(r)
+		r.Group(func(r chi.Router) {
+			r.Use(authmw.RequireAdmin)
+			retentionH.AdminRoutes(r)
+		})
+	})
 
-		r.Route("/api/system", systemH.Routes)
-		r.Route("/api/ollama", ollamaH.Routes)
-		r.Route("/api/qdrant", qdrantH.Routes)
+	r.Route("/api/rag", func(r chi.Router) {
+		r.Use(workerDeadline)
+		ragH.Routes(r)
+		r.Route("/search/live", liveSearchH.Routes)
+		r.Post("/index/estimate", estimateH.Estimate)
+		r.Route("/tasks", tasksH.Routes)
+		r.Route("/presets", presetsH.Routes)
+		r.Route("/upload", uploadH.Routes)
+		r.Route("/ws", wsH.Routes)
+		r.Route("/image", imageH.Routes)
+		r.Route("/sources", sourcesH.Routes)
+		r.Route("/migrate", migrateH.Routes)
+		r.Route("/collections", dedupeH.Routes)
+		r.Route("/preview", previewH.Routes)
+		r.Route("/chat", chatPrefsH.Routes)
+		r.Route("/share", shareH.Routes)
+	})
+	// Shared links are public; their signed token is the authorization.
+	r.Route("/api/share", shareH.PublicRoutes)
+	// The chat widget is public too: its embed token is the authorization,
+	// and answers stream outside the worker deadline like WebSocket chat.
+	r.Route("/embed", embedH.PublicRoutes)
 
-		r.Route("/api/rag", func(r chi.Router) {
-			ragH.Routes(r)
-			r.Route("/tasks", tasksH.Routes)
-			r.Route("/upload", uploadH.Routes)
-			r.Route("/ws", wsH.Routes)
-			r.Route("/image", imageH.Routes)
+	r.Route("/api/smb", smbH.Routes)
+	r.Route("/api/connectors", func(r chi.Router) {
+		connectorsH.Routes(r)
+		r.Group(func(r chi.Router) {
+			r.Use(authmw.RequireAdmin)
+			connectorsH.AdminRoutes(r)
 		})
+	})
+	// OpenAI-compatible chat. Answers stream for as long as the model
+	// writes, so it sits outside the worker deadline like WebSocket chat.
+	r.Route("/v1", openaiH.Routes)
+	r.Route("/api/plugins", plugin.Mount)
 
-		r.Route("/api/smb", smbH.Routes)
-
-		// Admin-only user management
-		r.Route("/api/users", func(r chi.Router) {
+	// Every user manages their own preferences; the rest of user
+	// management is admin-only.
+	r.Route("/api/users", func(r chi.Router) {
+		r.Route("/me", func(r chi.Router) {
+			userPrefsH.Routes(r)
+			r.Route("/searches", savedSearchesH.Routes)
+		})
+		r.Group(func(r chi.Router) {
 			r.Use(authmw.RequireAdmin)
 			usersH.Routes(r)
 		})
 	})
 
-	return r, nil
+	// When mounted under a sub-path (e.g. /ollqd/), strip it so routes and
+	// proxy directors see the same paths as a root deployment.
+	var handler http.Handler = r
+	if cfg.BasePath != "" {
+		handler = http.StripPrefix(cfg.BasePath, r)
+	}
+
+	// ── gRPC task API ───────────────────────────────────────
+	var gs *grpc.Server
+	if cfg.GRPCListenAddr != "" {
+		gs = grpcserver.New(gc, tm, colls.ResolveIndex, ignores.Merge, imageMeta, guard.Admit, cfg.GRPCAuthToken)
+	}
+	return handler, gs, nil
 }
 
 // requestLogger is a simple middleware that logs each HTTP request with
@@ -104,7 +366,8 @@ func requestLogger(next http.Handler) http.Handler {
 		next.ServeHTTP(ww, r)
 
 		// Only log API requests to reduce noise from static file serving.
-		if strings.HasPrefix(r.URL.Path, "/api/") {
+		if strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/v1/") ||
+			strings.HasPrefix(r.URL.Path, "/embed/") {
 			duration := time.Since(start)
 			status := ww.Status()
 			if status == 0 {
@@ -119,3 +382,36 @@ func requestLogger(next http.Handler) http.Handler {
 		}
 	})
 }
+
+// skipPrefix applies mw to every request except those under prefix.
+func skipPrefix(prefix string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// chatDefaults returns the server's default chat options from cfg: the
+// context packing settings that are set.
+func chatDefaults(cfg *config.Config) grpcclient.ChatOptions {
+	var o grpcclient.ChatOptions
+	if cfg.ChatContextMaxTokens != 0 {
+		n := int32(cfg.ChatContextMaxTokens)
+		o.ContextMaxTokens = &n
+	}
+	if cfg.ChatSourceMaxTokens != 0 {
+		n := int32(cfg.ChatSourceMaxTokens)
+		o.SourceMaxTokens = &n
+	}
+	if cfg.ChatDedupeFiles {
+		o.DedupeFiles = &cfg.ChatDedupeFiles
+	}
+	o.ContextOrder = cfg.ChatContextOrder
+	return o
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SchemaVersion is the current layout version of the store, recorded in the
+// "meta" document by the migrate-store command.
+const SchemaVersion = 1
+
+// MetaDoc is the name of the document holding store metadata.
+const MetaDoc = "meta"
+
+// Meta is the content of the MetaDoc document.
+type Meta struct {
+	SchemaVersion int `json:"schema_version"`
+}
+
+// Store persists small named JSON documents (gateway settings, templates,
+// etc.) as individual files under a data directory. Writes are atomic so a
+// crash never leaves a half-written document behind.
+type Store struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// New creates a Store rooted at dir. An empty dir yields a store that keeps
+// nothing on disk: Load reports no data and Save is a no-op.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory documents are stored in.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Load decodes the named document into v. It returns false (and no error)
+// if the document does not exist yet.
+func (s *Store) Load(name string, v interface{}) (bool, error) {
+	if s == nil || s.dir == "" {
+		return false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("decode %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// Save encodes v and atomically replaces the named document.
+func (s *Store) Save(name string, v interface{}) error {
+	if s == nil || s.dir == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("create store dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(name)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Names lists the documents currently in the store, sorted.
+func (s *Store) Names() ([]string, error) {
+	if s == nil || s.dir == "" {
+		return nil, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(m), ".json"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
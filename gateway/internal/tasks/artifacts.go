@@ -0,0 +1,127 @@
+package tasks
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArtifactPrefix marks entries in a worker TaskProgress result map that carry
+// a structured result document rather than a summary value. The remainder of
+// the key is the artifact name, e.g. "artifact:skipped_files.json".
+const ArtifactPrefix = "artifact:"
+
+// Artifact describes a result document persisted for a task.
+type Artifact struct {
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ArtifactStore persists task artifacts on disk under <dir>/<task_id>/<name>.
+type ArtifactStore struct {
+	dir string
+}
+
+// NewArtifactStore creates an ArtifactStore rooted at dir. An empty dir
+// disables artifact persistence.
+func NewArtifactStore(dir string) *ArtifactStore {
+	return &ArtifactStore{dir: dir}
+}
+
+// Extract splits artifact entries out of a worker result map, writes them to
+// disk, and returns the remaining summary entries plus the saved artifacts.
+func (s *ArtifactStore) Extract(taskID string, result map[string]string) (map[string]string, []Artifact, error) {
+	if len(result) == 0 {
+		return result, nil, nil
+	}
+
+	summary := make(map[string]string, len(result))
+	var saved []Artifact
+	var firstErr error
+
+	for k, v := range result {
+		if !strings.HasPrefix(k, ArtifactPrefix) {
+			summary[k] = v
+			continue
+		}
+		if s == nil || s.dir == "" {
+			continue
+		}
+		a, err := s.Save(taskID, strings.TrimPrefix(k, ArtifactPrefix), []byte(v))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		saved = append(saved, a)
+	}
+	return summary, saved, firstErr
+}
+
+// Save writes a single artifact for the given task.
+func (s *ArtifactStore) Save(taskID, name string, data []byte) (Artifact, error) {
+	name, err := cleanArtifactName(name)
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	dir := filepath.Join(s.dir, taskID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Artifact{}, fmt.Errorf("create artifact dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		return Artifact{}, fmt.Errorf("write artifact %s: %w", name, err)
+	}
+
+	return Artifact{
+		Name:        name,
+		ContentType: artifactContentType(name, data),
+		Size:        int64(len(data)),
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// Path returns the on-disk location of a task artifact.
+func (s *ArtifactStore) Path(taskID, name string) (string, error) {
+	name, err := cleanArtifactName(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, taskID, name), nil
+}
+
+// Remove deletes all artifacts belonging to a task.
+func (s *ArtifactStore) Remove(taskID string) {
+	if s == nil || s.dir == "" || taskID == "" {
+		return
+	}
+	os.RemoveAll(filepath.Join(s.dir, taskID))
+}
+
+// cleanArtifactName rejects names that could escape the task directory.
+func cleanArtifactName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid artifact name %q", name)
+	}
+	return name, nil
+}
+
+// artifactContentType picks a content type from the artifact's extension,
+// falling back to JSON or plain text based on the payload.
+func artifactContentType(name string, data []byte) string {
+	if ct := mime.TypeByExtension(filepath.Ext(name)); ct != "" {
+		return ct
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
@@ -0,0 +1,149 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+)
+
+// ConsumeIndexBatches runs an index task whose files arrive while it runs,
+// such as an upload that is still being received. next blocks until more
+// files are ready and returns them, or returns false once no more will
+// come. Each batch is indexed by its own stream, opened by open; a batch
+// whose stream breaks because the worker restarted is sent again, as in
+// ConsumeIndexStream.
+//
+// The task's progress is that of the files seen so far, so it can fall back
+// when a new batch arrives. Numeric results of the batches are added up.
+// The task completes after the last batch, and fails or is cancelled as
+// soon as one batch does.
+func (m *Manager) ConsumeIndexBatches(ctx context.Context, gc *grpcclient.Client, taskID string, next func(ctx context.Context) ([]string, bool), open func(ctx context.Context, batch []string) (grpcclient.IndexingStream, error)) {
+	result := map[string]string{}
+	done, seen := 0, 0
+	for n := 1; ; n++ {
+		batch, ok := next(ctx)
+		if ctx.Err() != nil {
+			m.Cancel(taskID)
+			m.cancelOnWorker(gc, taskID)
+			return
+		}
+		if !ok {
+			m.Complete(taskID, result)
+			return
+		}
+		seen += len(batch)
+		m.RecordEvent(taskID, EventBatch, fmt.Sprintf("batch %d: %d files (%d in all)", n, len(batch), seen))
+
+		for attempt := 0; ; attempt++ {
+			br, finished, err := m.indexBatchOnce(ctx, taskID, func() (grpcclient.IndexingStream, error) {
+				return open(ctx, batch)
+			}, func(p float64) float64 {
+				// Later batches may already be waiting; count only the
+				// files handed to the worker so far.
+				return (float64(done) + p*float64(len(batch))) / float64(seen)
+			})
+			if finished {
+				return
+			}
+			if err == nil {
+				addResults(result, br)
+				break
+			}
+			if ctx.Err() != nil {
+				m.Cancel(taskID)
+				m.cancelOnWorker(gc, taskID)
+				return
+			}
+			retries := m.restartPolicy().Retries
+			if !grpcclient.IsUnavailable(err) || attempt >= retries {
+				m.Fail(taskID, err.Error())
+				return
+			}
+			log.Printf("[task %s] worker unavailable, waiting to resume batch %d (attempt %d/%d): %v",
+				taskID, n, attempt+1, retries, err)
+			m.RecordEvent(taskID, EventWorkerUnavailable, fmt.Sprintf("batch %d, attempt %d/%d: %v", n, attempt+1, retries, err))
+			if !m.awaitWorker(ctx, gc, taskID, attempt) {
+				if ctx.Err() != nil {
+					m.Cancel(taskID)
+					m.cancelOnWorker(gc, taskID)
+					return
+				}
+				m.Fail(taskID, fmt.Sprintf("worker unavailable: %v", err))
+				return
+			}
+			m.RecordEvent(taskID, EventReconnected, fmt.Sprintf("batch %d restarted from the beginning", n))
+			m.setReconnecting(taskID, false)
+		}
+		done += len(batch)
+	}
+}
+
+// indexBatchOnce runs the stream of one batch, mapping its progress onto the
+// task with overall. It returns the batch's result once the worker has
+// indexed it, finished if the batch moved the task to a terminal state, or
+// an error describing why the stream broke.
+func (m *Manager) indexBatchOnce(ctx context.Context, taskID string, openStream func() (grpcclient.IndexingStream, error), overall func(float64) float64) (result map[string]string, finished bool, err error) {
+	stream, err := openStream()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open stream: %w", err)
+	}
+	defer stream.Close()
+
+	var workerID string
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		default:
+		}
+
+		progress, err := stream.Recv()
+		if err == io.EOF {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("stream error: %w", err)
+		}
+		if id := progress.GetTaskId(); id != "" && id != workerID {
+			workerID = id
+			m.SetWorkerTaskID(taskID, id)
+		}
+		m.takeFileErrors(taskID, progress.Result)
+
+		switch progress.Status {
+		case "running":
+			m.UpdateProgress(taskID, overall(float64(progress.Progress)), "running")
+		case "completed":
+			return m.AddArtifacts(taskID, progress.Result), false, nil
+		case "failed":
+			m.AddArtifacts(taskID, progress.Result)
+			m.Fail(taskID, progress.Error)
+			return nil, true, nil
+		case "cancelled":
+			m.WorkerCancelled(taskID, overall(float64(progress.Progress)), progress.Result)
+			return nil, true, nil
+		default:
+			log.Printf("[task %s] unknown status: %s", taskID, progress.Status)
+		}
+	}
+}
+
+// addResults adds the result of one batch to the task's: counts are
+// summed, other values keep the latest.
+func addResults(total, batch map[string]string) {
+	for k, v := range batch {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			total[k] = v
+			continue
+		}
+		if prev, err := strconv.ParseInt(total[k], 10, 64); err == nil {
+			n += prev
+		}
+		total[k] = strconv.FormatInt(n, 10)
+	}
+}
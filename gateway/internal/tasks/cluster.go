@@ -0,0 +1,569 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/internal/redis"
+)
+
+// ClusterOptions configures sharing task state between gateway replicas
+// through Redis.
+type ClusterOptions struct {
+	Client *redis.Client
+	// NodeID names this replica. It must be unique among the replicas and
+	// should stay the same across restarts, like a pod name.
+	NodeID string
+	// Prefix starts every key and channel name, so several deployments can
+	// share one Redis.
+	Prefix string
+	// TTL is how long the shared state of a task outlives its last update.
+	TTL time.Duration
+}
+
+const (
+	// clusterFlushDelay is how long changes to tasks are collected before
+	// they are written to Redis, so a burst of progress events makes one
+	// write.
+	clusterFlushDelay = 250 * time.Millisecond
+	// clusterHeartbeat is how often a replica announces itself and looks
+	// for tasks of replicas that went away.
+	clusterHeartbeat = 10 * time.Second
+	// clusterNodeTTL is how long a replica counts as alive after its last
+	// heartbeat.
+	clusterNodeTTL = 30 * time.Second
+	// clusterTimeout bounds one round of Redis calls.
+	clusterTimeout = 5 * time.Second
+	// clusterLoadBatch is how many tasks one MGET loads.
+	clusterLoadBatch = 200
+)
+
+// Operations sent on the task channel.
+const (
+	clusterUpdate = "update" // a task changed; Task is its redacted state
+	clusterDelete = "delete" // a task was cleared
+	clusterCancel = "cancel" // the owner of task ID should cancel it
+	clusterClear  = "clear"  // every replica should clear its finished tasks
+)
+
+// clusterMessage is a message on the task channel.
+type clusterMessage struct {
+	Node string    `json:"node"`
+	Op   string    `json:"op"`
+	ID   string    `json:"id,omitempty"`
+	Task *TaskInfo `json:"task,omitempty"`
+}
+
+// taskSnapshot is the shared state of a task stored under its key. The
+// error catalog, which can be large, is stored under a key of its own.
+type taskSnapshot struct {
+	Task            *TaskInfo       `json:"task"`
+	Timeline        []TimelineEvent `json:"timeline,omitempty"`
+	TimelineDropped int             `json:"timeline_dropped,omitempty"`
+}
+
+// fileErrorsSnapshot is the shared error catalog of a task.
+type fileErrorsSnapshot struct {
+	Errors  []FileError `json:"errors"`
+	Dropped int         `json:"dropped,omitempty"`
+}
+
+// cluster shares the tasks of one Manager with the other replicas and
+// mirrors theirs. Each replica stays the only one to run and change its
+// own tasks; the others see them as read-only copies.
+type cluster struct {
+	client *redis.Client
+	node   string
+	prefix string
+	ttl    time.Duration
+	wake   chan struct{}
+
+	mu     sync.Mutex
+	dirty  map[string]bool      // task ID -> error catalog changed too
+	remote map[string]*TaskInfo // tasks of other replicas
+}
+
+// EnableCluster shares the manager's tasks through Redis and makes the
+// tasks of other replicas visible through Get, List, Timeline and
+// FileErrors until ctx is done. Cancelling another replica's task asks
+// that replica to do it. Request params, artifacts, queues and collection
+// locks stay with the replica that owns the task.
+func (m *Manager) EnableCluster(ctx context.Context, opts ClusterOptions) error {
+	c := &cluster{
+		client: opts.Client,
+		node:   opts.NodeID,
+		prefix: opts.Prefix,
+		ttl:    opts.TTL,
+		wake:   make(chan struct{}, 1),
+		dirty:  make(map[string]bool),
+		remote: make(map[string]*TaskInfo),
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, clusterTimeout)
+	defer cancel()
+	if err := c.heartbeat(pingCtx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.cluster = c
+	for id := range m.tasks {
+		c.dirty[id] = true
+	}
+	m.mu.Unlock()
+
+	go c.flushLoop(ctx, m)
+	go c.subscribeLoop(ctx, m)
+	go c.heartbeatLoop(ctx)
+	return nil
+}
+
+// shareLocked queues the state of a task for the other replicas; errs says
+// its error catalog changed too. Callers must hold m.mu.
+func (m *Manager) shareLocked(id string, errs bool) {
+	if m.cluster != nil {
+		m.cluster.mark(id, errs)
+	}
+}
+
+// RemoteNode returns the replica running a task this replica only mirrors.
+// The boolean is false for local and unknown tasks.
+func (m *Manager) RemoteNode(id string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, ok := m.tasks[id]; ok || m.cluster == nil {
+		return "", false
+	}
+	if t := m.cluster.get(id); t != nil {
+		return t.Node, true
+	}
+	return "", false
+}
+
+func (c *cluster) taskKey(id string) string   { return c.prefix + ":task:" + id }
+func (c *cluster) errorsKey(id string) string { return c.prefix + ":task:" + id + ":errors" }
+func (c *cluster) indexKey() string           { return c.prefix + ":tasks" }
+func (c *cluster) channel() string            { return c.prefix + ":task-events" }
+func (c *cluster) nodeKey(node string) string { return c.prefix + ":node:" + node }
+
+// mark queues a task for the next flush.
+func (c *cluster) mark(id string, errs bool) {
+	c.mu.Lock()
+	c.dirty[id] = c.dirty[id] || errs
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// get returns a copy of a mirrored task, or nil.
+func (c *cluster) get(id string) *TaskInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.remote[id]
+	if !ok {
+		return nil
+	}
+	cp := *t
+	return &cp
+}
+
+// list returns copies of the mirrored tasks.
+func (c *cluster) list() []*TaskInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]*TaskInfo, 0, len(c.remote))
+	for _, t := range c.remote {
+		cp := *t
+		out = append(out, &cp)
+	}
+	return out
+}
+
+// setRemote stores the state of another replica's task. A finished task is
+// not revived by a stale update.
+func (c *cluster) setRemote(t *TaskInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.remote[t.ID]; ok && old.Status.Finished() && !t.Status.Finished() {
+		return
+	}
+	c.remote[t.ID] = t
+}
+
+// flushLoop writes queued task changes to Redis until ctx is done.
+func (c *cluster) flushLoop(ctx context.Context, m *Manager) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.wake:
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(clusterFlushDelay):
+		}
+		c.flush(ctx, m)
+	}
+}
+
+// flush writes the state of every queued task, or deletes it if the task
+// was cleared meanwhile. Tasks that could not be written stay queued for
+// the next heartbeat.
+func (c *cluster) flush(ctx context.Context, m *Manager) {
+	c.mu.Lock()
+	dirty := c.dirty
+	c.dirty = make(map[string]bool)
+	c.mu.Unlock()
+	if len(dirty) == 0 {
+		return
+	}
+
+	type write struct {
+		snap   *taskSnapshot
+		errs   *fileErrorsSnapshot
+		delete bool
+	}
+	writes := make(map[string]write, len(dirty))
+	m.mu.RLock()
+	for id, errs := range dirty {
+		t, ok := m.tasks[id]
+		if !ok {
+			writes[id] = write{delete: true}
+			continue
+		}
+		w := write{snap: &taskSnapshot{
+			Task:            m.redactedCopyLocked(t),
+			Timeline:        append([]TimelineEvent(nil), t.Timeline...),
+			TimelineDropped: t.timelineDropped,
+		}}
+		if errs {
+			w.errs = &fileErrorsSnapshot{Errors: append([]FileError(nil), t.FileErrors...), Dropped: t.ErrorsDropped}
+		}
+		writes[id] = w
+	}
+	m.mu.RUnlock()
+
+	for id, w := range writes {
+		wctx, cancel := context.WithTimeout(ctx, clusterTimeout)
+		var err error
+		if w.delete {
+			err = c.remove(wctx, id, true)
+		} else {
+			err = c.store(wctx, w.snap, w.errs)
+		}
+		cancel()
+		if err != nil {
+			log.Printf("WARNING: cluster: sharing task %s: %v", id, err)
+			c.mu.Lock()
+			c.dirty[id] = c.dirty[id] || w.errs != nil
+			c.mu.Unlock()
+		}
+	}
+}
+
+// store writes the shared state of a task and announces it.
+func (c *cluster) store(ctx context.Context, snap *taskSnapshot, errs *fileErrorsSnapshot) error {
+	t := snap.Task
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	ttl := strconv.FormatInt(int64(c.ttl/time.Second), 10)
+	if _, err := c.client.Do(ctx, "SET", c.taskKey(t.ID), string(data), "EX", ttl); err != nil {
+		return err
+	}
+	if errs != nil {
+		data, err := json.Marshal(errs)
+		if err != nil {
+			return err
+		}
+		if _, err := c.client.Do(ctx, "SET", c.errorsKey(t.ID), string(data), "EX", ttl); err != nil {
+			return err
+		}
+	}
+	score := strconv.FormatInt(t.CreatedAt.Unix(), 10)
+	if _, err := c.client.Do(ctx, "ZADD", c.indexKey(), score, t.ID); err != nil {
+		return err
+	}
+	return c.publish(ctx, clusterMessage{Op: clusterUpdate, ID: t.ID, Task: t})
+}
+
+// remove deletes the shared state of a task and, if announce is set, tells
+// the other replicas.
+func (c *cluster) remove(ctx context.Context, id string, announce bool) error {
+	if _, err := c.client.Do(ctx, "DEL", c.taskKey(id), c.errorsKey(id)); err != nil {
+		return err
+	}
+	if _, err := c.client.Do(ctx, "ZREM", c.indexKey(), id); err != nil {
+		return err
+	}
+	if !announce {
+		return nil
+	}
+	return c.publish(ctx, clusterMessage{Op: clusterDelete, ID: id})
+}
+
+// publish sends a message on the task channel.
+func (c *cluster) publish(ctx context.Context, msg clusterMessage) error {
+	msg.Node = c.node
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Do(ctx, "PUBLISH", c.channel(), string(data))
+	return err
+}
+
+// publishAsync sends a message without holding up the caller, for calls
+// made under the manager lock.
+func (c *cluster) publishAsync(msg clusterMessage) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), clusterTimeout)
+		defer cancel()
+		if err := c.publish(ctx, msg); err != nil {
+			log.Printf("WARNING: cluster: sending %s: %v", msg.Op, err)
+		}
+	}()
+}
+
+// subscribeLoop applies the messages of other replicas until ctx is done,
+// subscribing again after a lost connection. Each (re)subscription loads
+// the shared tasks, so updates missed meanwhile are caught up.
+func (c *cluster) subscribeLoop(ctx context.Context, m *Manager) {
+	for ctx.Err() == nil {
+		subCtx, cancel := context.WithTimeout(ctx, clusterTimeout)
+		sub, err := c.client.Subscribe(subCtx, c.channel())
+		cancel()
+		if err == nil {
+			c.load(ctx)
+			stop := context.AfterFunc(ctx, func() { sub.Close() })
+			for {
+				msg, rerr := sub.Receive()
+				if rerr != nil {
+					err = rerr
+					break
+				}
+				c.handle(m, msg.Payload)
+			}
+			stop()
+			sub.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("WARNING: cluster: task channel: %v; reconnecting", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// handle applies one message from the task channel.
+func (c *cluster) handle(m *Manager, payload string) {
+	var msg clusterMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil || msg.Node == c.node {
+		return
+	}
+	switch msg.Op {
+	case clusterUpdate:
+		if msg.Task != nil {
+			c.setRemote(msg.Task)
+		}
+	case clusterDelete:
+		c.mu.Lock()
+		delete(c.remote, msg.ID)
+		c.mu.Unlock()
+	case clusterCancel:
+		m.mu.Lock()
+		if t, ok := m.tasks[msg.ID]; ok {
+			log.Printf("[task %s] cancel requested through gateway node %s", msg.ID, msg.Node)
+			m.cancelLocked(t, CancelByRequest)
+		}
+		m.mu.Unlock()
+	case clusterClear:
+		m.clearFinishedLocal()
+		c.clearFinished()
+	}
+}
+
+// load mirrors the shared tasks of other replicas. Tasks this replica left
+// unfinished in an earlier run are failed, as nothing runs them any more.
+func (c *cluster) load(ctx context.Context) {
+	lctx, cancel := context.WithTimeout(ctx, 4*clusterTimeout)
+	defer cancel()
+	ids, err := c.client.Strings(lctx, "ZRANGE", c.indexKey(), "0", "-1")
+	if err != nil {
+		log.Printf("WARNING: cluster: loading tasks: %v", err)
+		return
+	}
+	for start := 0; start < len(ids); start += clusterLoadBatch {
+		batch := ids[start:min(start+clusterLoadBatch, len(ids))]
+		keys := make([]string, len(batch))
+		for i, id := range batch {
+			keys[i] = c.taskKey(id)
+		}
+		vals, err := c.client.Strings(lctx, append([]string{"MGET"}, keys...)...)
+		if err != nil {
+			log.Printf("WARNING: cluster: loading tasks: %v", err)
+			return
+		}
+		for i, val := range vals {
+			if val == "" {
+				// Expired; drop it from the index too.
+				c.client.Do(lctx, "ZREM", c.indexKey(), batch[i])
+				continue
+			}
+			var snap taskSnapshot
+			if json.Unmarshal([]byte(val), &snap) != nil || snap.Task == nil {
+				continue
+			}
+			if snap.Task.Node == c.node && !snap.Task.Status.Finished() {
+				c.orphaned(lctx, &snap, "this gateway node restarted")
+				continue
+			}
+			c.setRemote(snap.Task)
+		}
+	}
+}
+
+// clearFinished drops the finished tasks of other replicas from the mirror
+// and returns their IDs.
+func (c *cluster) clearFinished() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ids []string
+	for id, t := range c.remote {
+		if t.Status.Finished() {
+			delete(c.remote, id)
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// heartbeat announces this replica as alive.
+func (c *cluster) heartbeat(ctx context.Context) error {
+	ttl := strconv.FormatInt(int64(clusterNodeTTL/time.Second), 10)
+	_, err := c.client.Do(ctx, "SET", c.nodeKey(c.node), time.Now().UTC().Format(time.RFC3339), "EX", ttl)
+	return err
+}
+
+// heartbeatLoop keeps this replica announced, retries unshared changes and
+// fails the unfinished tasks of replicas that stopped announcing
+// themselves, until ctx is done.
+func (c *cluster) heartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(clusterHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		hctx, cancel := context.WithTimeout(ctx, clusterTimeout)
+		if err := c.heartbeat(hctx); err != nil {
+			log.Printf("WARNING: cluster: heartbeat: %v", err)
+			cancel()
+			continue
+		}
+		c.mu.Lock()
+		pending := len(c.dirty) > 0
+		byNode := make(map[string][]string)
+		for id, t := range c.remote {
+			if !t.Status.Finished() {
+				byNode[t.Node] = append(byNode[t.Node], id)
+			}
+		}
+		c.mu.Unlock()
+		if pending {
+			select {
+			case c.wake <- struct{}{}:
+			default:
+			}
+		}
+		for node, ids := range byNode {
+			alive, err := c.client.Int(hctx, "EXISTS", c.nodeKey(node))
+			if err != nil || alive > 0 {
+				continue
+			}
+			for _, id := range ids {
+				val, err := c.client.String(hctx, "GET", c.taskKey(id))
+				var snap taskSnapshot
+				if err != nil || json.Unmarshal([]byte(val), &snap) != nil || snap.Task == nil {
+					c.mu.Lock()
+					delete(c.remote, id)
+					c.mu.Unlock()
+					continue
+				}
+				c.orphaned(hctx, &snap, fmt.Sprintf("gateway node %s stopped", node))
+			}
+		}
+		cancel()
+	}
+}
+
+// orphaned fails a task whose replica is gone and shares the result.
+func (c *cluster) orphaned(ctx context.Context, snap *taskSnapshot, why string) {
+	t := snap.Task
+	if t.Status.Finished() {
+		c.setRemote(t)
+		return
+	}
+	log.Printf("[task %s] failing task of gone gateway node %s", t.ID, t.Node)
+	now := time.Now()
+	t.Error = fmt.Sprintf("%s while the task was %s", why, t.Status)
+	t.Status = StatusFailed
+	t.CompletedAt = &now
+	t.Stalled = false
+	t.StalledSince = nil
+	snap.Timeline = append(snap.Timeline, TimelineEvent{
+		At:       now,
+		Event:    EventFailed,
+		Progress: math.Round(progressPercent(t.Progress)*100) / 100,
+		Detail:   t.Error,
+	})
+	c.setRemote(t)
+	if err := c.store(ctx, snap, nil); err != nil {
+		log.Printf("WARNING: cluster: sharing task %s: %v", t.ID, err)
+	}
+}
+
+// fetchTimeline loads the shared timeline of another replica's task.
+func (c *cluster) fetchTimeline(id string) ([]TimelineEvent, int, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterTimeout)
+	defer cancel()
+	val, err := c.client.String(ctx, "GET", c.taskKey(id))
+	if err != nil {
+		return nil, 0, false
+	}
+	var snap taskSnapshot
+	if json.Unmarshal([]byte(val), &snap) != nil {
+		return nil, 0, false
+	}
+	return snap.Timeline, snap.TimelineDropped, true
+}
+
+// fetchFileErrors loads the shared error catalog of another replica's
+// task. A task without errors has no catalog key.
+func (c *cluster) fetchFileErrors(id string) ([]FileError, int, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterTimeout)
+	defer cancel()
+	val, err := c.client.String(ctx, "GET", c.errorsKey(id))
+	if err != nil {
+		return nil, 0, err == redis.ErrNil
+	}
+	var snap fileErrorsSnapshot
+	if json.Unmarshal([]byte(val), &snap) != nil {
+		return nil, 0, false
+	}
+	return snap.Errors, snap.Dropped, true
+}
@@ -0,0 +1,109 @@
+package tasks
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// FileErrorsKey is the entry of a worker TaskProgress result map that
+// carries the files the run failed to index since its previous event, as a
+// JSON list of {"file", "stage", "error"} objects. It is moved to the
+// task's error catalog and not kept in the result.
+const FileErrorsKey = "file_errors"
+
+// maxFileErrors bounds the error catalog of one task; further errors are
+// only counted in ErrorsDropped.
+const maxFileErrors = 10000
+
+// FileError is a file an indexing run could not index. Stage names the step
+// that failed, such as "read", "extract" or "embed".
+type FileError struct {
+	File  string    `json:"file"`
+	Stage string    `json:"stage"`
+	Error string    `json:"error"`
+	At    time.Time `json:"at"`
+}
+
+// takeFileErrors removes the FileErrorsKey entry from a worker result map
+// and records its errors on the task. A file failing again at the same
+// stage, as when a stream is restarted, replaces its earlier entry.
+func (m *Manager) takeFileErrors(id string, result map[string]string) {
+	raw, ok := result[FileErrorsKey]
+	if !ok {
+		return
+	}
+	delete(result, FileErrorsKey)
+
+	var reported []FileError
+	if err := json.Unmarshal([]byte(raw), &reported); err != nil {
+		log.Printf("[task %s] ignoring malformed %s: %v", id, FileErrorsKey, err)
+		return
+	}
+	if len(reported) == 0 {
+		return
+	}
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[id]
+	if !ok {
+		return
+	}
+	if t.fileErrorIndex == nil {
+		t.fileErrorIndex = make(map[string]int)
+	}
+	for _, fe := range reported {
+		if fe.File == "" {
+			continue
+		}
+		fe.At = now
+		key := fe.Stage + "\x00" + fe.File
+		if i, seen := t.fileErrorIndex[key]; seen {
+			t.FileErrors[i] = fe
+			continue
+		}
+		if len(t.FileErrors) >= maxFileErrors {
+			t.ErrorsDropped++
+			continue
+		}
+		t.fileErrorIndex[key] = len(t.FileErrors)
+		t.FileErrors = append(t.FileErrors, fe)
+	}
+	t.ErrorCount = len(t.FileErrors)
+	m.shareLocked(id, true)
+}
+
+// FileErrors returns a copy of the error catalog of a task and the number
+// of errors left out of it. The boolean is false if the task is unknown.
+func (m *Manager) FileErrors(id string) ([]FileError, int, bool) {
+	m.mu.RLock()
+	t, ok := m.tasks[id]
+	if !ok {
+		c := m.cluster
+		m.mu.RUnlock()
+		if c == nil || c.get(id) == nil {
+			return nil, 0, false
+		}
+		return c.fetchFileErrors(id)
+	}
+	defer m.mu.RUnlock()
+	return append([]FileError(nil), t.FileErrors...), t.ErrorsDropped, true
+}
+
+// FailedFiles returns the distinct files in the error catalog of a task, in
+// the order they first failed.
+func (m *Manager) FailedFiles(id string) []string {
+	errs, _, _ := m.FileErrors(id)
+	seen := make(map[string]bool, len(errs))
+	var files []string
+	for _, fe := range errs {
+		if !seen[fe.File] {
+			seen[fe.File] = true
+			files = append(files, fe.File)
+		}
+	}
+	return files
+}
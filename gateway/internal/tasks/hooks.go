@@ -0,0 +1,27 @@
+package tasks
+
+// FinishFunc is called once when a task reaches a terminal state. It gets a
+// redacted copy of the task and runs on its own goroutine.
+type FinishFunc func(TaskInfo)
+
+// OnFinish registers fn to run whenever a task completes, fails or is
+// cancelled.
+func (m *Manager) OnFinish(fn FinishFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onFinish = append(m.onFinish, fn)
+}
+
+// finishedLocked runs the finish hooks for t the first time it ends, so a
+// task is reported once however many terminal transitions it sees. Callers
+// must hold m.mu.
+func (m *Manager) finishedLocked(t *TaskInfo) {
+	if t.finishReported || len(m.onFinish) == 0 {
+		return
+	}
+	t.finishReported = true
+	cp := *m.redactedCopyLocked(t)
+	for _, fn := range m.onFinish {
+		go fn(cp)
+	}
+}
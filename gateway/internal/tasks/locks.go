@@ -0,0 +1,100 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LockMode controls how searches behave on a collection being reindexed.
+type LockMode string
+
+const (
+	LockNone  LockMode = ""
+	LockBlock LockMode = "block" // searches are rejected with 423 Locked
+	LockWarn  LockMode = "warn"  // searches succeed but carry a stale-data header
+)
+
+// ErrCollectionLocked is returned when a collection is already locked by
+// another task.
+var ErrCollectionLocked = errors.New("collection is locked by another task")
+
+// ParseLockMode validates a lock mode string; empty and "none" mean no lock.
+func ParseLockMode(s string) (LockMode, error) {
+	switch s {
+	case "", "none":
+		return LockNone, nil
+	case string(LockBlock), string(LockWarn):
+		return LockMode(s), nil
+	default:
+		return "", fmt.Errorf("invalid lock %q (want block, warn or none)", s)
+	}
+}
+
+// CollectionLock records a task holding a collection during reindex.
+type CollectionLock struct {
+	Collection string    `json:"collection"`
+	TaskID     string    `json:"task_id"`
+	Mode       LockMode  `json:"mode"`
+	Since      time.Time `json:"since"`
+}
+
+// LockCollection locks collection for the lifetime of a task. The lock is
+// released automatically when the task completes, fails or is cancelled.
+func (m *Manager) LockCollection(taskID, collection string, mode LockMode) error {
+	if mode == LockNone || collection == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[taskID]
+	if !ok {
+		return ErrNotFound
+	}
+	if l, held := m.locks[collection]; held && l.TaskID != taskID {
+		return fmt.Errorf("%w: %s (task %s)", ErrCollectionLocked, collection, l.TaskID)
+	}
+	m.locks[collection] = &CollectionLock{
+		Collection: collection,
+		TaskID:     taskID,
+		Mode:       mode,
+		Since:      time.Now(),
+	}
+	t.LockedCollection = collection
+	return nil
+}
+
+// CollectionLock returns the active lock on a collection, if any.
+func (m *Manager) CollectionLock(collection string) (CollectionLock, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	l, ok := m.locks[collection]
+	if !ok {
+		return CollectionLock{}, false
+	}
+	return *l, true
+}
+
+// Locks returns all active collection locks.
+func (m *Manager) Locks() []CollectionLock {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]CollectionLock, 0, len(m.locks))
+	for _, l := range m.locks {
+		out = append(out, *l)
+	}
+	return out
+}
+
+// releaseLocksLocked drops every lock held by a task. Callers must hold m.mu.
+func (m *Manager) releaseLocksLocked(taskID string) {
+	for coll, l := range m.locks {
+		if l.TaskID == taskID {
+			delete(m.locks, coll)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package tasks
 
 import (
 	"context"
+	"errors"
+	"log"
 	"sync"
 	"time"
 
@@ -17,35 +19,136 @@ const (
 	StatusCompleted TaskStatus = "completed"
 	StatusFailed    TaskStatus = "failed"
 	StatusCancelled TaskStatus = "cancelled"
+
+	// StatusReconnecting is a running task waiting for its worker to come
+	// back after the worker dropped the stream.
+	StatusReconnecting TaskStatus = "reconnecting"
+)
+
+// Finished reports whether s is a terminal state.
+func (s TaskStatus) Finished() bool {
+	switch s {
+	case StatusCompleted, StatusFailed, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Cancel reasons recorded in TaskInfo.CancelReason.
+const (
+	CancelByRequest = "cancel requested"
+	CancelByWorker  = "cancelled by the worker"
 )
 
+// ErrNotFound is returned when a task ID is unknown.
+var ErrNotFound = errors.New("task not found")
+
 // TaskInfo holds all state for a single background task.
 type TaskInfo struct {
 	ID            string                 `json:"task_id"`
 	Type          string                 `json:"type"`
 	Status        TaskStatus             `json:"status"`
 	Progress      float64                `json:"progress"`
+	Priority      Priority               `json:"priority,omitempty"`
 	Result        map[string]string      `json:"result,omitempty"`
 	Error         string                 `json:"error,omitempty"`
+	CancelReason  string                 `json:"cancel_reason,omitempty"`
 	CreatedAt     time.Time              `json:"created_at"`
 	StartedAt     *time.Time             `json:"started_at,omitempty"`
 	CompletedAt   *time.Time             `json:"completed_at,omitempty"`
 	RequestParams map[string]interface{} `json:"request_params,omitempty"`
+	ParamsDropped bool                   `json:"params_dropped,omitempty"`
+	Artifacts     []Artifact             `json:"artifacts,omitempty"`
+
+	// StatusLabel is Status localized for display. The HTTP API fills it
+	// in per request from Accept-Language.
+	StatusLabel string `json:"status_label,omitempty"`
+
+	// WorkerTaskID is the worker's ID for the task's indexing run, used to
+	// ask the worker to stop it when the task is cancelled.
+	WorkerTaskID string `json:"worker_task_id,omitempty"`
+
+	// LockedCollection is the collection this task holds a reindex lock on.
+	LockedCollection string `json:"locked_collection,omitempty"`
+
+	// LastProgressAt is when the task last reported progress. Stalled is set
+	// by the watchdog when a running task goes quiet for too long.
+	LastProgressAt *time.Time `json:"last_progress_at,omitempty"`
+	Stalled        bool       `json:"stalled,omitempty"`
+	StalledSince   *time.Time `json:"stalled_since,omitempty"`
 
-	cancelFunc context.CancelFunc `json:"-"`
+	// Warnings are problems noticed when the task was started that did not
+	// stop it, such as low disk space.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// FileErrors is the catalog of files the run failed to index (see
+	// FileError). Task listings carry only ErrorCount and ErrorsDropped,
+	// the errors beyond the catalog's bound.
+	FileErrors     []FileError `json:"-"`
+	ErrorCount     int         `json:"error_count,omitempty"`
+	ErrorsDropped  int         `json:"errors_dropped,omitempty"`
+	fileErrorIndex map[string]int
+
+	// Node is the gateway replica that runs the task, in cluster mode.
+	Node string `json:"node,omitempty"`
+
+	// Timeline records the task's significant events (see TimelineEvent).
+	// Task listings leave it out.
+	Timeline        []TimelineEvent `json:"-"`
+	timelineDropped int
+	lastMilestone   int
+
+	cancelFunc     context.CancelFunc `json:"-"`
+	finishReported bool
 }
 
 // Manager is a thread-safe, in-memory task store that mirrors the Python
 // TaskManager. All public methods are safe for concurrent use.
 type Manager struct {
-	mu    sync.RWMutex
-	tasks map[string]*TaskInfo
+	mu        sync.RWMutex
+	tasks     map[string]*TaskInfo
+	artifacts *ArtifactStore
+
+	// Queueing: at most maxConcurrent tasks run at once (0 = unlimited);
+	// the rest wait in queue ordered by priority.
+	maxConcurrent int
+	running       int
+	queue         []*queuedTask
+	seq           uint64
+
+	// Reindex locks by collection name; released when the owning task ends.
+	locks map[string]*CollectionLock
+
+	// Request param exposure and retention (see ParamPolicy).
+	redactKeys     map[string]bool
+	paramRetention time.Duration
+
+	onFinish []FinishFunc
+
+	// Riding out worker restarts (see RestartPolicy).
+	restart RestartPolicy
+
+	// Stuck-task detection (see WatchdogPolicy).
+	watchdog      WatchdogPolicy
+	stalledTotal  int
+	autoCancelled int
+
+	// Sharing with other gateway replicas (see EnableCluster); nil when
+	// running alone.
+	cluster *cluster
 }
 
-// NewManager creates a new empty task manager.
-func NewManager() *Manager {
+// NewManager creates a new empty task manager. Result artifacts reported by
+// the worker are persisted under artifactDir, and at most maxConcurrent
+// enqueued tasks run at the same time (0 = unlimited).
+func NewManager(artifactDir string, maxConcurrent int) *Manager {
 	return &Manager{
-		tasks: make(map[string]*TaskInfo),
+		tasks:          make(map[string]*TaskInfo),
+		artifacts:      NewArtifactStore(artifactDir),
+		maxConcurrent:  maxConcurrent,
+		locks:          make(map[string]*CollectionLock),
+		paramRetention: KeepParams,
+		restart:        DefaultRestartPolicy,
 	}
 }
 
@@ -55,7 +158,7 @@ func (m *Manager) Create(taskType string, params map[string]interface{}) string
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.tasks[id] = &TaskInfo{
+	t := &TaskInfo{
 		ID:            id,
 		Type:          taskType,
 		Status:        StatusPending,
@@ -63,6 +166,8 @@ func (m *Manager) Create(taskType string, params map[string]interface{}) string
 		CreatedAt:     time.Now(),
 		RequestParams: params,
 	}
+	m.tasks[id] = t
+	m.eventLocked(t, EventCreated, "")
 	return id
 }
 
@@ -78,112 +183,306 @@ func (m *Manager) Start(id string) {
 	t.Status = StatusRunning
 	now := time.Now()
 	t.StartedAt = &now
+	m.touchLocked(t)
+	m.eventLocked(t, EventStarted, "")
 }
 
 // UpdateProgress sets the progress percentage (0-100) and optionally the
-// status string for a running task.
+// status string for a running task. Finished tasks are left alone, so a
+// progress event racing a cancel cannot revive the task.
 func (m *Manager) UpdateProgress(id string, progress float64, status string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	t, ok := m.tasks[id]
-	if !ok {
+	if !ok || t.Status.Finished() {
 		return
 	}
 	t.Progress = progress
 	if status != "" {
 		t.Status = TaskStatus(status)
 	}
+	m.touchLocked(t)
+	m.milestoneLocked(t)
 }
 
-// Complete marks a task as completed with the given result map.
+// Complete marks a task as completed with the given result map. Entries
+// prefixed with ArtifactPrefix are persisted as artifacts and removed from
+// the stored result. A task cancelled meanwhile stays cancelled.
 func (m *Manager) Complete(id string, result map[string]string) {
+	result, saved := m.extractArtifacts(id, result)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	t, ok := m.tasks[id]
-	if !ok {
+	if !ok || t.Status == StatusCancelled {
 		return
 	}
 	t.Status = StatusCompleted
 	t.Progress = 100
 	t.Result = result
+	t.Artifacts = append(t.Artifacts, saved...)
 	now := time.Now()
 	t.CompletedAt = &now
+	m.eventLocked(t, EventCompleted, "")
+	m.releaseLocksLocked(id)
+	m.finishedLocked(t)
+	m.dropParamsLocked(t)
 }
 
-// Fail marks a task as failed with the given error message.
+// Fail marks a task as failed with the given error message. A task that was
+// already cancelled keeps its state; its stream failing is a consequence of
+// the cancellation, not a separate error.
 func (m *Manager) Fail(id string, errMsg string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	t, ok := m.tasks[id]
-	if !ok {
+	if !ok || t.Status == StatusCancelled {
 		return
 	}
 	t.Status = StatusFailed
 	t.Error = errMsg
 	now := time.Now()
 	t.CompletedAt = &now
+	m.eventLocked(t, EventFailed, errMsg)
+	m.releaseLocksLocked(id)
+	m.finishedLocked(t)
+	m.dropParamsLocked(t)
+}
+
+// AddArtifacts persists any artifact entries in a worker result map for the
+// given task without changing its state, and returns the result without
+// them. This is used for failed progress events, which may still carry
+// error reports, and for the batches of ConsumeIndexBatches.
+func (m *Manager) AddArtifacts(id string, result map[string]string) map[string]string {
+	summary, saved := m.extractArtifacts(id, result)
+	if len(saved) == 0 {
+		return summary
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t, ok := m.tasks[id]; ok {
+		t.Artifacts = append(t.Artifacts, saved...)
+		m.shareLocked(id, false)
+	}
+	return summary
+}
+
+// AddWarnings records warnings on the given task.
+func (m *Manager) AddWarnings(id string, warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t, ok := m.tasks[id]; ok {
+		t.Warnings = append(t.Warnings, warnings...)
+		m.shareLocked(id, false)
+	}
+}
+
+// ArtifactPath returns the metadata and on-disk path of a named artifact for
+// the given task. The boolean is false if the task or artifact is unknown.
+func (m *Manager) ArtifactPath(id, name string) (Artifact, string, bool) {
+	m.mu.RLock()
+	t, ok := m.tasks[id]
+	var found *Artifact
+	if ok {
+		for i := range t.Artifacts {
+			if t.Artifacts[i].Name == name {
+				a := t.Artifacts[i]
+				found = &a
+				break
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	if found == nil {
+		return Artifact{}, "", false
+	}
+	path, err := m.artifacts.Path(id, name)
+	if err != nil {
+		return Artifact{}, "", false
+	}
+	return *found, path, true
+}
+
+// extractArtifacts persists artifact entries outside the manager lock since
+// it touches the filesystem.
+func (m *Manager) extractArtifacts(id string, result map[string]string) (map[string]string, []Artifact) {
+	summary, saved, err := m.artifacts.Extract(id, result)
+	if err != nil {
+		log.Printf("[task %s] artifact error: %v", id, err)
+	}
+	return summary, saved
 }
 
 // Cancel cancels a running task by invoking its cancel function and marking
-// the task as cancelled. Returns true if the task was found and cancelled.
+// the task as cancelled. Returns true if the task was found; a task that
+// already finished keeps its state. In cluster mode, a task of another
+// replica is cancelled by that replica, shortly after the call returns.
 func (m *Manager) Cancel(id string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	t, ok := m.tasks[id]
 	if !ok {
-		return false
+		if m.cluster == nil {
+			return false
+		}
+		rt := m.cluster.get(id)
+		if rt == nil {
+			return false
+		}
+		if !rt.Status.Finished() {
+			m.cluster.publishAsync(clusterMessage{Op: clusterCancel, ID: id})
+		}
+		return true
+	}
+	m.cancelLocked(t, CancelByRequest)
+	return true
+}
+
+// WorkerCancelled records that the worker stopped a task's run early. The
+// partial result (counts so far) and progress are kept; if the task was not
+// cancelled from the gateway already, it is now.
+func (m *Manager) WorkerCancelled(id string, progress float64, result map[string]string) {
+	result, saved := m.extractArtifacts(id, result)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[id]
+	if !ok || (t.Status.Finished() && t.Status != StatusCancelled) {
+		return
+	}
+	if len(result) > 0 {
+		if t.Result == nil {
+			t.Result = make(map[string]string, len(result))
+		}
+		for k, v := range result {
+			t.Result[k] = v
+		}
+	}
+	t.Artifacts = append(t.Artifacts, saved...)
+	t.Progress = max(t.Progress, progress)
+	m.cancelLocked(t, CancelByWorker)
+}
+
+// SetWorkerTaskID records the worker's ID for a task's indexing run.
+func (m *Manager) SetWorkerTaskID(id, workerID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.tasks[id]; ok {
+		t.WorkerTaskID = workerID
+		m.shareLocked(id, false)
+	}
+}
+
+// cancelLocked aborts t and moves it to the cancelled state, recording why.
+// Progress and any partial result are kept, and a cancelled task carries no
+// error. Finished tasks are left alone. Callers must hold m.mu.
+func (m *Manager) cancelLocked(t *TaskInfo, reason string) {
+	if t.Status.Finished() {
+		return
 	}
 	if t.cancelFunc != nil {
 		t.cancelFunc()
 	}
+	m.removeFromQueueLocked(t.ID)
 	t.Status = StatusCancelled
+	t.Error = ""
+	t.CancelReason = reason
 	now := time.Now()
 	t.CompletedAt = &now
-	return true
+	m.eventLocked(t, EventCancelled, reason)
+	m.releaseLocksLocked(t.ID)
+	m.finishedLocked(t)
+	m.dropParamsLocked(t)
 }
 
 // Get returns a copy of the task info for the given ID, or nil if not found.
+// Sensitive request params are redacted; use RawParams for the originals.
 func (m *Manager) Get(id string) *TaskInfo {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	t, ok := m.tasks[id]
 	if !ok {
+		if m.cluster != nil {
+			return m.cluster.get(id)
+		}
 		return nil
 	}
-	// Return a shallow copy to avoid races on mutable fields.
-	cp := *t
-	return &cp
+	// Return a copy to avoid races on mutable fields.
+	return m.redactedCopyLocked(t)
 }
 
-// List returns a copy of all tasks, most recent first.
+// List returns a redacted copy of all tasks, most recent first. In cluster
+// mode it includes the tasks of the other replicas.
 func (m *Manager) List() []*TaskInfo {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	out := make([]*TaskInfo, 0, len(m.tasks))
 	for _, t := range m.tasks {
-		cp := *t
-		out = append(out, &cp)
+		out = append(out, m.redactedCopyLocked(t))
+	}
+	if m.cluster != nil {
+		for _, t := range m.cluster.list() {
+			if _, local := m.tasks[t.ID]; !local {
+				out = append(out, t)
+			}
+		}
 	}
 	return out
 }
 
 // ClearFinished removes all tasks in a terminal state (completed, failed,
-// cancelled). Returns the number of tasks removed.
+// cancelled). Returns the number of tasks removed. In cluster mode every
+// replica clears its finished tasks too.
 func (m *Manager) ClearFinished() int {
+	count := m.clearFinishedLocal()
+
+	m.mu.RLock()
+	c := m.cluster
+	m.mu.RUnlock()
+	if c != nil {
+		ids := c.clearFinished()
+		count += len(ids)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), clusterTimeout)
+			defer cancel()
+			// Replicas that are gone cannot delete their own tasks.
+			for _, id := range ids {
+				c.remove(ctx, id, false)
+			}
+			if err := c.publish(ctx, clusterMessage{Op: clusterClear}); err != nil {
+				log.Printf("WARNING: cluster: sending %s: %v", clusterClear, err)
+			}
+		}()
+	}
+	return count
+}
+
+// clearFinishedLocal removes this replica's finished tasks and returns how
+// many it removed.
+func (m *Manager) clearFinishedLocal() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	count := 0
 	for id, t := range m.tasks {
-		switch t.Status {
-		case StatusCompleted, StatusFailed, StatusCancelled:
+		if t.Status.Finished() {
 			delete(m.tasks, id)
+			m.artifacts.Remove(id)
+			m.shareLocked(id, false)
 			count++
 		}
 	}
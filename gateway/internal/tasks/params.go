@@ -0,0 +1,208 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces sensitive request params in task listings.
+const RedactedValue = "********"
+
+// alwaysRedacted are substrings of param keys that are masked regardless of
+// configuration (e.g. the SMB "password" and "keytab" params).
+var alwaysRedacted = []string{"password", "passwd", "secret", "token", "api_key", "keytab"}
+
+// ParamPolicy controls how task request params are exposed and retained.
+type ParamPolicy struct {
+	// RedactKeys are additional param keys (case-insensitive, exact match)
+	// to mask in task listings, e.g. "root_path" or "saved_paths".
+	RedactKeys []string
+
+	// Retention is how long params are kept once a task has ended. Zero
+	// drops them on completion; a negative value keeps them indefinitely.
+	Retention time.Duration
+}
+
+// KeepParams is the Retention value that never drops params.
+const KeepParams time.Duration = -1
+
+// SetParamPolicy replaces the manager's param policy. It applies to tasks
+// ending after the call.
+func (m *Manager) SetParamPolicy(p ParamPolicy) {
+	keys := lowerKeys(p.RedactKeys)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.redactKeys = keys
+	m.paramRetention = p.Retention
+}
+
+// RawParams returns the unredacted request params of a task, as needed to
+// retry it. The boolean is false if the task is unknown; a nil map with true
+// means the params were dropped under the retention policy.
+func (m *Manager) RawParams(id string) (map[string]interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.tasks[id]
+	if !ok {
+		return nil, false
+	}
+	return t.RequestParams, true
+}
+
+// UpdateParams sets request params of a task that is still running, for
+// tasks whose input grows while they run. Finished tasks keep theirs.
+func (m *Manager) UpdateParams(id string, params map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[id]
+	if !ok || t.Status.Finished() || t.RequestParams == nil {
+		return
+	}
+	for k, v := range params {
+		t.RequestParams[k] = v
+	}
+	m.shareLocked(id, false)
+}
+
+// redactedCopyLocked returns a copy of t whose RequestParams are safe to show
+// to any authenticated user.
+func (m *Manager) redactedCopyLocked(t *TaskInfo) *TaskInfo {
+	cp := *t
+	// The error catalog is read through FileErrors, under the lock.
+	cp.FileErrors = nil
+	cp.fileErrorIndex = nil
+	cp.Timeline = nil
+	if m.cluster != nil {
+		cp.Node = m.cluster.node
+	}
+	if t.RequestParams != nil {
+		cp.RequestParams = redactMap(t.RequestParams, m.redactKeys)
+	}
+	return &cp
+}
+
+// dropParamsLocked schedules removal of a finished task's params according
+// to the retention policy. Callers must hold m.mu.
+func (m *Manager) dropParamsLocked(t *TaskInfo) {
+	switch {
+	case m.paramRetention < 0 || t.RequestParams == nil:
+		return
+	case m.paramRetention == 0:
+		t.RequestParams = nil
+		t.ParamsDropped = true
+		return
+	}
+
+	id := t.ID
+	time.AfterFunc(m.paramRetention, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if t, ok := m.tasks[id]; ok && t.CompletedAt != nil && t.RequestParams != nil {
+			t.RequestParams = nil
+			t.ParamsDropped = true
+			m.shareLocked(id, false)
+		}
+	})
+}
+
+// IsSensitiveKey reports whether values under key are masked in task
+// listings: keys containing one of the always-masked words, and the extra
+// keys (case-insensitive, exact match).
+func IsSensitiveKey(key string, extra []string) bool {
+	return shouldRedact(key, lowerKeys(extra))
+}
+
+// RedactValue returns a copy of a decoded JSON value with the values of
+// sensitive keys masked at any depth, as task listings show params.
+func RedactValue(v interface{}, extra []string) interface{} {
+	return redactValue(v, lowerKeys(extra))
+}
+
+func lowerKeys(keys []string) map[string]bool {
+	out := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		out[strings.ToLower(strings.TrimSpace(k))] = true
+	}
+	return out
+}
+
+func shouldRedact(key string, extra map[string]bool) bool {
+	k := strings.ToLower(key)
+	if extra[k] {
+		return true
+	}
+	for _, s := range alwaysRedacted {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactMap(in map[string]interface{}, extra map[string]bool) map[string]interface{} {
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		if shouldRedact(k, extra) {
+			if isEmptyParam(v) {
+				out[k] = v // nothing to hide; keeps "was a password set?" visible
+			} else {
+				out[k] = RedactedValue
+			}
+			continue
+		}
+		out[k] = redactValue(v, extra)
+	}
+	return out
+}
+
+func redactValue(v interface{}, extra map[string]bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return redactMap(val, extra)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, e := range val {
+			out[i] = redactValue(e, extra)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func isEmptyParam(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return val == ""
+	case []string:
+		return len(val) == 0
+	}
+	return false
+}
+
+// ParseRetention parses a TASK_PARAMS_RETENTION value: "" keeps params
+// forever, "0" drops them when the task ends, and a Go duration ("24h")
+// drops them that long after.
+func ParseRetention(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "":
+		return KeepParams, nil
+	case "0":
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid params retention %q: %w", s, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid params retention %q: must not be negative", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,154 @@
+package tasks
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Priority orders queued tasks when the concurrency limit is reached.
+type Priority string
+
+const (
+	PriorityHigh   Priority = "high"
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
+)
+
+// ParsePriority validates a priority string. An empty string means normal.
+func ParsePriority(s string) (Priority, error) {
+	switch Priority(s) {
+	case "", PriorityNormal:
+		return PriorityNormal, nil
+	case PriorityHigh, PriorityLow:
+		return Priority(s), nil
+	}
+	return "", fmt.Errorf("priority must be one of: high, normal, low")
+}
+
+func (p Priority) rank() int {
+	switch p {
+	case PriorityHigh:
+		return 0
+	case PriorityLow:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// queuedTask is a pending task waiting for a free slot.
+type queuedTask struct {
+	id  string
+	seq uint64
+	run func()
+}
+
+// Enqueue schedules run for the given task. If a slot is free the task is
+// started immediately; otherwise it stays pending until higher- or
+// equal-priority tasks ahead of it finish. run should block until the task
+// reaches a terminal state.
+func (m *Manager) Enqueue(id string, priority Priority, run func()) {
+	m.mu.Lock()
+	t, ok := m.tasks[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	t.Priority = priority
+	m.seq++
+	m.queue = append(m.queue, &queuedTask{id: id, seq: m.seq, run: run})
+	m.sortQueueLocked()
+	m.eventLocked(t, EventQueued, fmt.Sprintf("priority %s, position %d", priority, m.queueIndexLocked(id)+1))
+	m.mu.Unlock()
+
+	m.dispatch()
+}
+
+// SetPriority changes the priority of a task that is still queued. It
+// returns an error if the task is unknown or has already started.
+func (m *Manager) SetPriority(id string, priority Priority) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if m.queueIndexLocked(id) < 0 {
+		return fmt.Errorf("task %s is %s, only queued tasks can be reprioritised", id, t.Status)
+	}
+	t.Priority = priority
+	m.sortQueueLocked()
+	m.eventLocked(t, EventPriority, fmt.Sprintf("priority %s, position %d", priority, m.queueIndexLocked(id)+1))
+	return nil
+}
+
+// QueuePosition returns the 1-based position of a queued task, or 0 if the
+// task is not waiting in the queue.
+func (m *Manager) QueuePosition(id string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.queueIndexLocked(id) + 1
+}
+
+// dispatch starts queued tasks while slots are available.
+func (m *Manager) dispatch() {
+	for {
+		m.mu.Lock()
+		if len(m.queue) == 0 || (m.maxConcurrent > 0 && m.running >= m.maxConcurrent) {
+			m.mu.Unlock()
+			return
+		}
+		next := m.queue[0]
+		m.queue = m.queue[1:]
+		t, ok := m.tasks[next.id]
+		if !ok || t.Status != StatusPending {
+			m.mu.Unlock()
+			continue
+		}
+		m.running++
+		m.mu.Unlock()
+
+		m.Start(next.id)
+		go func(q *queuedTask) {
+			defer m.release()
+			q.run()
+		}(next)
+	}
+}
+
+// release frees a slot after a task's run function returns.
+func (m *Manager) release() {
+	m.mu.Lock()
+	m.running--
+	m.mu.Unlock()
+	m.dispatch()
+}
+
+// removeFromQueueLocked drops a task from the pending queue.
+func (m *Manager) removeFromQueueLocked(id string) {
+	if i := m.queueIndexLocked(id); i >= 0 {
+		m.queue = append(m.queue[:i], m.queue[i+1:]...)
+	}
+}
+
+func (m *Manager) queueIndexLocked(id string) int {
+	for i, q := range m.queue {
+		if q.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortQueueLocked orders the queue by priority, then submission order.
+func (m *Manager) sortQueueLocked() {
+	sort.SliceStable(m.queue, func(i, j int) bool {
+		pi := m.tasks[m.queue[i].id].Priority.rank()
+		pj := m.tasks[m.queue[j].id].Priority.rank()
+		if pi != pj {
+			return pi < pj
+		}
+		return m.queue[i].seq < m.queue[j].seq
+	})
+}
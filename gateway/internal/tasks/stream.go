@@ -0,0 +1,223 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+)
+
+const (
+	// workerReadyTimeout bounds how long to wait for the worker to come
+	// back before giving up on the task.
+	workerReadyTimeout = 60 * time.Second
+
+	// maxRestartBackoff caps the pause between reconnect attempts.
+	maxRestartBackoff = time.Minute
+
+	// workerCancelTimeout bounds the CancelTask call made when a task is
+	// cancelled.
+	workerCancelTimeout = 5 * time.Second
+)
+
+// RestartPolicy controls how tasks ride out a worker restart.
+type RestartPolicy struct {
+	// Retries is how many times a task's stream is reopened after the
+	// worker drops the connection. Zero fails the task at once.
+	Retries int
+
+	// Backoff is the pause before the first reopen. It doubles with each
+	// further attempt, up to a minute.
+	Backoff time.Duration
+}
+
+// DefaultRestartPolicy is the policy of a new Manager.
+var DefaultRestartPolicy = RestartPolicy{Retries: 3, Backoff: time.Second}
+
+// SetRestartPolicy replaces the manager's restart policy. It applies to
+// reconnects starting after the call.
+func (m *Manager) SetRestartPolicy(p RestartPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restart = p
+}
+
+// restartPolicy returns the current restart policy.
+func (m *Manager) restartPolicy() RestartPolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.restart
+}
+
+// backoff returns the pause before reconnect attempt (counting from 0).
+func (p RestartPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 0; i < attempt && d < maxRestartBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRestartBackoff {
+		d = maxRestartBackoff
+	}
+	return d
+}
+
+// awaitWorker holds a task in StatusReconnecting through the backoff of
+// reconnect attempt and until the worker is ready again. It reports false if
+// ctx is done first or the worker stays away.
+func (m *Manager) awaitWorker(ctx context.Context, gc *grpcclient.Client, taskID string, attempt int) bool {
+	m.setReconnecting(taskID, true)
+	if d := m.restartPolicy().backoff(attempt); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+		}
+	}
+	return gc.WaitReady(ctx, workerReadyTimeout)
+}
+
+// setReconnecting moves a running task to StatusReconnecting, or back to
+// StatusRunning. Finished tasks are left alone.
+func (m *Manager) setReconnecting(id string, reconnecting bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[id]
+	if !ok || t.Status.Finished() {
+		return
+	}
+	if reconnecting {
+		t.Status = StatusReconnecting
+		m.shareLocked(t.ID, false)
+		return
+	}
+	// The time spent reconnecting does not count towards a stall.
+	t.Status = StatusRunning
+	m.touchLocked(t)
+}
+
+// ConsumeIndexStream opens an indexing stream and mirrors its progress
+// events into the manager until the task reaches a terminal state.
+//
+// If the worker becomes unavailable mid-stream, the task is moved to
+// StatusReconnecting while the gateway backs off, waits for the worker to
+// reconnect and then reopens the stream (see RestartPolicy). The worker holds no state across restarts, so the task restarts
+// from the beginning; incremental runs skip already-indexed files.
+//
+// ctx is cancelled by Cancel. The task then stays cancelled rather than
+// failing on the broken stream, and the worker is told to stop the run.
+func (m *Manager) ConsumeIndexStream(ctx context.Context, gc *grpcclient.Client, taskID string, openStream func() (grpcclient.IndexingStream, error)) {
+	for attempt := 0; ; attempt++ {
+		err := m.indexStreamOnce(ctx, taskID, openStream)
+		if err == nil {
+			return
+		}
+		if ctx.Err() != nil {
+			m.Cancel(taskID)
+			m.cancelOnWorker(gc, taskID)
+			return
+		}
+
+		retries := m.restartPolicy().Retries
+		if !grpcclient.IsUnavailable(err) || attempt >= retries {
+			m.Fail(taskID, err.Error())
+			return
+		}
+
+		log.Printf("[task %s] worker unavailable, waiting to resume (attempt %d/%d): %v",
+			taskID, attempt+1, retries, err)
+		m.RecordEvent(taskID, EventWorkerUnavailable, fmt.Sprintf("attempt %d/%d: %v", attempt+1, retries, err))
+		if !m.awaitWorker(ctx, gc, taskID, attempt) {
+			if ctx.Err() != nil {
+				m.Cancel(taskID)
+				m.cancelOnWorker(gc, taskID)
+				return
+			}
+			m.Fail(taskID, fmt.Sprintf("worker unavailable: %v", err))
+			return
+		}
+		log.Printf("[task %s] worker reconnected, restarting stream", taskID)
+		m.RecordEvent(taskID, EventReconnected, "stream restarted from the beginning")
+		m.setReconnecting(taskID, false)
+		m.UpdateProgress(taskID, 0, "")
+	}
+}
+
+// indexStreamOnce runs a single stream attempt. It returns nil once the task
+// has been moved to a terminal state, or an error describing why the stream
+// broke (including ctx being cancelled) so the caller can decide whether to
+// reconnect.
+func (m *Manager) indexStreamOnce(ctx context.Context, taskID string, openStream func() (grpcclient.IndexingStream, error)) error {
+	stream, err := openStream()
+	if err != nil {
+		return fmt.Errorf("failed to open stream: %w", err)
+	}
+	defer stream.Close()
+
+	var workerID string
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		progress, err := stream.Recv()
+		if err == io.EOF {
+			// Stream ended without an explicit completed message; mark done.
+			m.Complete(taskID, nil)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("stream error: %w", err)
+		}
+		if id := progress.GetTaskId(); id != "" && id != workerID {
+			workerID = id
+			m.SetWorkerTaskID(taskID, id)
+		}
+		m.takeFileErrors(taskID, progress.Result)
+
+		switch progress.Status {
+		case "running":
+			m.UpdateProgress(taskID, float64(progress.Progress), "running")
+		case "completed":
+			m.Complete(taskID, progress.Result)
+			return nil
+		case "failed":
+			m.AddArtifacts(taskID, progress.Result)
+			m.Fail(taskID, progress.Error)
+			return nil
+		case "cancelled":
+			m.WorkerCancelled(taskID, float64(progress.Progress), progress.Result)
+			return nil
+		default:
+			log.Printf("[task %s] unknown status: %s", taskID, progress.Status)
+		}
+	}
+}
+
+// cancelOnWorker asks the worker to stop a cancelled task's run. Closing the
+// stream cancels the call too, but the worker only checks for that between
+// batches, and a run may outlive a stream the gateway already dropped.
+func (m *Manager) cancelOnWorker(gc *grpcclient.Client, taskID string) {
+	m.mu.RLock()
+	var workerID string
+	if t, ok := m.tasks[taskID]; ok {
+		workerID = t.WorkerTaskID
+	}
+	m.mu.RUnlock()
+	if workerID == "" || gc == nil || gc.Indexing == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), workerCancelTimeout)
+	defer cancel()
+	if _, err := gc.Indexing.CancelTask(ctx, &grpcclient.CancelTaskRequest{TaskId: workerID}); err != nil {
+		log.Printf("[task %s] worker CancelTask %s: %v", taskID, workerID, err)
+	}
+}
@@ -0,0 +1,107 @@
+package tasks
+
+import (
+	"math"
+	"time"
+)
+
+// Timeline event kinds, in the order a task usually sees them.
+const (
+	EventCreated           = "created"
+	EventQueued            = "queued"
+	EventPriority          = "priority_changed"
+	EventStarted           = "started"
+	EventProgress          = "progress"
+	EventBatch             = "batch"
+	EventStalled           = "stalled"
+	EventResumed           = "resumed"
+	EventWorkerUnavailable = "worker_unavailable"
+	EventReconnected       = "reconnected"
+	EventCompleted         = "completed"
+	EventFailed            = "failed"
+	EventCancelled         = "cancelled"
+)
+
+const (
+	// timelineStep is the progress, in percent, between two milestone
+	// events.
+	timelineStep = 10
+	// maxTimelineEvents bounds the timeline of one task; further events are
+	// only counted in TimelineDropped. The terminal event is always kept.
+	maxTimelineEvents = 500
+)
+
+// TimelineEvent is one significant moment in a task's life. Progress is the
+// task's progress in percent when it happened.
+type TimelineEvent struct {
+	At       time.Time `json:"at"`
+	Event    string    `json:"event"`
+	Progress float64   `json:"progress"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
+// RecordEvent adds an event to the timeline of a task, for steps of a run
+// the manager does not see itself.
+func (m *Manager) RecordEvent(id, event, detail string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.tasks[id]; ok {
+		m.eventLocked(t, event, detail)
+	}
+}
+
+// Timeline returns a copy of the timeline of a task and the number of events
+// left out of it. The boolean is false if the task is unknown.
+func (m *Manager) Timeline(id string) ([]TimelineEvent, int, bool) {
+	m.mu.RLock()
+	t, ok := m.tasks[id]
+	if !ok {
+		c := m.cluster
+		m.mu.RUnlock()
+		if c == nil || c.get(id) == nil {
+			return nil, 0, false
+		}
+		return c.fetchTimeline(id)
+	}
+	defer m.mu.RUnlock()
+	return append([]TimelineEvent(nil), t.Timeline...), t.timelineDropped, true
+}
+
+// eventLocked appends an event to t's timeline. Callers must hold m.mu.
+func (m *Manager) eventLocked(t *TaskInfo, event, detail string) {
+	m.shareLocked(t.ID, false)
+	if len(t.Timeline) >= maxTimelineEvents && !t.Status.Finished() {
+		t.timelineDropped++
+		return
+	}
+	t.Timeline = append(t.Timeline, TimelineEvent{
+		At:       time.Now(),
+		Event:    event,
+		Progress: math.Round(progressPercent(t.Progress)*100) / 100,
+		Detail:   detail,
+	})
+}
+
+// milestoneLocked records a progress event each time t's progress crosses
+// another timelineStep percent. A run restarted from the beginning reaches
+// its milestones again. Callers must hold m.mu.
+func (m *Manager) milestoneLocked(t *TaskInfo) {
+	step := int(progressPercent(t.Progress)) / timelineStep
+	if step < t.lastMilestone {
+		t.lastMilestone = step
+	}
+	if step <= t.lastMilestone || step*timelineStep >= 100 {
+		return
+	}
+	t.lastMilestone = step
+	m.eventLocked(t, EventProgress, "")
+}
+
+// progressPercent converts a task's progress to percent. Workers report
+// running tasks as a fraction; finished tasks are set to 100.
+func progressPercent(p float64) float64 {
+	if p <= 1 {
+		return p * 100
+	}
+	return p
+}
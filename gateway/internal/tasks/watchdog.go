@@ -0,0 +1,138 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// WatchdogPolicy controls stuck-task detection.
+type WatchdogPolicy struct {
+	// StallAfter is how long a running task may go without a progress event
+	// before it is flagged as stalled. Zero disables the watchdog.
+	StallAfter time.Duration
+
+	// AutoCancel cancels tasks as soon as they are flagged.
+	AutoCancel bool
+}
+
+// Stats summarises the tasks a Manager currently tracks.
+type Stats struct {
+	ByStatus      map[TaskStatus]int `json:"by_status"`
+	Queued        int                `json:"queued"`
+	Running       int                `json:"running"`
+	Stalled       int                `json:"stalled"`
+	StalledTotal  int                `json:"stalled_total"`
+	AutoCancelled int                `json:"auto_cancelled"`
+	StallAfterS   float64            `json:"stall_after_s"`
+	AutoCancel    bool               `json:"auto_cancel"`
+}
+
+// touchLocked records a sign of life for t and clears a stall flag.
+// Callers must hold m.mu.
+func (m *Manager) touchLocked(t *TaskInfo) {
+	now := time.Now()
+	t.LastProgressAt = &now
+	m.shareLocked(t.ID, false)
+	if t.Stalled {
+		log.Printf("[task %s] progress resumed after stall", t.ID)
+		t.Stalled = false
+		t.StalledSince = nil
+		m.eventLocked(t, EventResumed, "")
+	}
+}
+
+// StartWatchdog checks running tasks for missing progress until ctx is
+// done. It returns immediately when the policy disables the watchdog.
+func (m *Manager) StartWatchdog(ctx context.Context, p WatchdogPolicy) {
+	m.mu.Lock()
+	m.watchdog = p
+	m.mu.Unlock()
+	if p.StallAfter <= 0 {
+		return
+	}
+
+	interval := p.StallAfter / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				m.checkStalled(now)
+			}
+		}
+	}()
+}
+
+// checkStalled flags running tasks whose last progress is older than the
+// stall threshold, cancelling them when the policy says so.
+func (m *Manager) checkStalled(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := m.watchdog
+	if p.StallAfter <= 0 {
+		return
+	}
+	for _, t := range m.tasks {
+		if t.Status != StatusRunning || t.Stalled {
+			continue
+		}
+		last := t.LastProgressAt
+		if last == nil {
+			last = t.StartedAt
+		}
+		if last == nil || now.Sub(*last) < p.StallAfter {
+			continue
+		}
+
+		idle := now.Sub(*last).Round(time.Second)
+		t.Stalled = true
+		t.StalledSince = &now
+		m.stalledTotal++
+		m.eventLocked(t, EventStalled, fmt.Sprintf("no progress for %s", idle))
+		if !p.AutoCancel {
+			log.Printf("[task %s] stalled: no progress for %s", t.ID, idle)
+			continue
+		}
+
+		log.Printf("[task %s] stalled: no progress for %s, cancelling", t.ID, idle)
+		m.autoCancelled++
+		m.cancelLocked(t, fmt.Sprintf("cancelled by watchdog: no progress for %s", idle))
+	}
+}
+
+// Stats returns task counts by status plus watchdog counters.
+func (m *Manager) Stats() Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := Stats{
+		ByStatus:      make(map[TaskStatus]int),
+		Queued:        len(m.queue),
+		StalledTotal:  m.stalledTotal,
+		AutoCancelled: m.autoCancelled,
+		StallAfterS:   m.watchdog.StallAfter.Seconds(),
+		AutoCancel:    m.watchdog.AutoCancel,
+	}
+	for _, t := range m.tasks {
+		s.ByStatus[t.Status]++
+		if t.Status == StatusRunning {
+			s.Running++
+			if t.Stalled {
+				s.Stalled++
+			}
+		}
+	}
+	return s
+}
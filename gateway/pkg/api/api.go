@@ -0,0 +1,150 @@
+// Package api holds the request and response bodies of the gateway's REST
+// and WebSocket API. The handlers decode and encode these types and
+// pkg/client sends them, so the two cannot drift apart.
+package api
+
+import (
+	"fmt"
+
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+)
+
+// Error is the JSON body of every error response. Detail is localized for
+// the response language; MessageKey and MessageArgs identify cataloged
+// messages so clients can localize them themselves. GRPCCode is the
+// worker's status code, such as "NOT_FOUND", on errors relayed from it.
+type Error struct {
+	Detail      string   `json:"detail"`
+	Code        string   `json:"code"`
+	MessageKey  string   `json:"message_key,omitempty"`
+	MessageArgs []string `json:"message_args,omitempty"`
+	GRPCCode    string   `json:"grpc_code,omitempty"`
+
+	// StatusCode is the HTTP status the error came with. It is filled in
+	// by clients, not sent.
+	StatusCode int `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("gateway: %d: %s", e.StatusCode, e.Detail)
+	}
+	return fmt.Sprintf("gateway: %d %s: %s", e.StatusCode, e.Code, e.Detail)
+}
+
+// SearchQuery is what to search for in a search request.
+type SearchQuery struct {
+	Query    string `json:"query"`
+	TopK     int32  `json:"top_k"`
+	Language string `json:"language"`
+	FilePath string `json:"file_path"`
+	// Mode "keyword" skips the worker and ranks stored chunk text by BM25
+	// instead of vector similarity.
+	Mode string `json:"mode"`
+}
+
+// SearchTuning holds the result filters shared by the search endpoints.
+// They run in the gateway on what the worker (or keyword search) returns.
+type SearchTuning struct {
+	// ScoreThreshold drops hits scoring below it.
+	ScoreThreshold *float32 `json:"score_threshold"`
+	// MMR re-selects hits by maximal marginal relevance: each pick trades
+	// its score against its similarity to the hits already picked.
+	MMR bool `json:"mmr"`
+	// MMRLambda weighs relevance against diversity, 1 being pure relevance.
+	MMRLambda *float32 `json:"mmr_lambda"`
+	// FetchK is how many candidates MMR chooses from.
+	FetchK int32 `json:"fetch_k"`
+}
+
+// SearchRequest is the body of POST /api/rag/search and
+// POST /api/rag/search/{collection}.
+type SearchRequest struct {
+	SearchQuery
+	SearchTuning
+}
+
+// SearchHit is a search hit with a signed URL for images in the upload
+// directory, and the time range of transcribed audio and video.
+type SearchHit struct {
+	*pb.SearchHit
+	ImageURL string      `json:"image_url,omitempty"`
+	Media    *MediaRange `json:"media,omitempty"`
+}
+
+// MediaRange locates a transcript chunk (language "transcript") in its
+// audio or video recording.
+type MediaRange struct {
+	Start int `json:"start"` // seconds from the start of the recording
+	End   int `json:"end"`
+	// URL plays the recording from Start to End (a signed upload URL with
+	// a #t= media fragment); empty for recordings outside the upload dir.
+	URL string `json:"url,omitempty"`
+}
+
+// QueryRewrite describes how a collection's synonyms and stopwords changed
+// a query.
+type QueryRewrite struct {
+	Query    string   `json:"query"`              // the query searched for
+	Expanded []string `json:"expanded,omitempty"` // synonyms added
+	Removed  []string `json:"removed,omitempty"`  // stopwords dropped
+}
+
+// SearchResponse is the result of a search. Mode, Degraded and Reason are
+// only set by keyword searches; Degraded marks a keyword search run because
+// vector search was unavailable.
+type SearchResponse struct {
+	Status       string        `json:"status,omitempty"`
+	Query        string        `json:"query,omitempty"`
+	Collection   string        `json:"collection,omitempty"`
+	Results      []SearchHit   `json:"results"`
+	QueryRewrite *QueryRewrite `json:"query_rewrite,omitempty"`
+	Mode         string        `json:"mode,omitempty"`
+	Degraded     bool          `json:"degraded,omitempty"`
+	Reason       string        `json:"reason,omitempty"`
+}
+
+// Live search message types sent by clients on WS /api/rag/search/live.
+const (
+	LiveSearchMessageSearch = "search"
+	LiveSearchMessageCancel = "cancel"
+)
+
+// LiveSearchMessage is a message from a live search client. A "search"
+// message replaces the search in progress, if any; "cancel" stops it. ID is
+// echoed on the events of the search so clients can drop stale ones.
+type LiveSearchMessage struct {
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	Collection string `json:"collection"`
+	SearchQuery
+	SearchTuning
+}
+
+// Live search event types and the phases of "hits" events.
+const (
+	LiveSearchEventHits      = "hits"
+	LiveSearchEventDone      = "done"
+	LiveSearchEventError     = "error"
+	LiveSearchEventCancelled = "cancelled"
+
+	LiveSearchPhaseKeyword = "keyword"
+	LiveSearchPhaseVector  = "vector"
+)
+
+// LiveSearchEvent is an event sent to live search clients. Each phase of a
+// search sends one "hits" event as soon as its results are ready; "done"
+// follows the last. Cached marks vector hits found with a reused query
+// embedding.
+type LiveSearchEvent struct {
+	Type         string        `json:"type"`
+	ID           string        `json:"id,omitempty"`
+	Phase        string        `json:"phase,omitempty"`
+	Collection   string        `json:"collection,omitempty"`
+	Results      []SearchHit   `json:"results,omitempty"`
+	QueryRewrite *QueryRewrite `json:"query_rewrite,omitempty"`
+	Cached       bool          `json:"cached,omitempty"`
+	TookMS       int64         `json:"took_ms,omitempty"`
+	Content      string        `json:"content,omitempty"`
+	Code         string        `json:"code,omitempty"`
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	pb "github.com/alfagnish/ollqd-gateway/gen/ollqd/v1"
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+)
+
+// ChatOptions holds optional model and retrieval parameters for a chat turn.
+// Nil/empty fields leave the worker defaults in place.
+type ChatOptions = grpcclient.ChatOptions
+
+// Chat message types sent by clients on WS /api/rag/ws.
+const (
+	ChatMessageCancel = "cancel"
+)
+
+// ChatMessage is a message from a WebSocket chat client. A message with
+// type "cancel" aborts the response currently streaming; anything else
+// starts a chat turn.
+type ChatMessage struct {
+	Type       string `json:"type"`
+	Message    string `json:"message"`
+	Collection string `json:"collection"`
+	Model      string `json:"model"`
+	PIIEnabled bool   `json:"pii_enabled"`
+	// Instance names the Ollama instance that embeds the query and
+	// generates the answer; empty uses the worker's own.
+	Instance string `json:"instance"`
+	// Route "auto" lets the gateway pick the collections to search from
+	// the question, instead of Collection. RouteMax bounds how many it
+	// picks (default 1, at most 3) and RouteCollections limits the
+	// candidates; by default every collection is one.
+	Route            string   `json:"route,omitempty"`
+	RouteMax         int      `json:"route_max,omitempty"`
+	RouteCollections []string `json:"route_collections,omitempty"`
+	// FlushMS and FlushBytes override how answer chunks are coalesced
+	// for this turn: held until FlushMS milliseconds have passed or
+	// FlushBytes are buffered. 0 turns a trigger off; nil keeps the
+	// server's setting.
+	FlushMS    *int `json:"flush_ms,omitempty"`
+	FlushBytes *int `json:"flush_bytes,omitempty"`
+
+	// Optional generation and retrieval overrides.
+	ChatOptions
+}
+
+// Chat event types sent to WebSocket chat clients.
+const (
+	ChatEventChunk     = "chunk"
+	ChatEventSources   = "sources"
+	ChatEventDone      = "done"
+	ChatEventError     = "error"
+	ChatEventCancelled = "cancelled"
+	ChatEventWarning   = "warning"
+	ChatEventStatus    = "status"
+	ChatEventRoute     = "route"
+)
+
+// ChatRouteAuto is the ChatMessage route that picks collections from the
+// question.
+const ChatRouteAuto = "auto"
+
+// ChatEvent is an event sent to WebSocket chat clients, mirroring ChatEvent
+// from the gRPC service. Retryable marks errors after which the same
+// message may be sent again.
+type ChatEvent struct {
+	Type             string          `json:"type"`
+	Content          string          `json:"content,omitempty"`
+	Sources          []*pb.SearchHit `json:"sources,omitempty"`
+	Citations        []Citation      `json:"citations,omitempty"`
+	PIIMasked        bool            `json:"pii_masked,omitempty"`
+	PIIEntitiesCount int32           `json:"pii_entities_count,omitempty"`
+	Retryable        bool            `json:"retryable,omitempty"`
+	// Route is set on the "route" event of a routed chat turn.
+	Route *ChatRoute `json:"route,omitempty"`
+	// Grounding is set on the "done" event when the answer was scored
+	// against its sources.
+	Grounding *ChatGrounding `json:"grounding,omitempty"`
+}
+
+// ChatGrounding reports how well a chat answer is supported by its
+// sources. Each answer sentence is matched with its most similar source by
+// embedding cosine similarity; Score is the mean of those similarities and
+// Supported counts the sentences reaching Threshold. Low is set when Score
+// is below Threshold, meaning the answer likely contains claims the sources
+// do not support.
+type ChatGrounding struct {
+	Score     float32 `json:"score"`
+	Threshold float32 `json:"threshold"`
+	Sentences int     `json:"sentences"`
+	Supported int     `json:"supported"`
+	Low       bool    `json:"low"`
+}
+
+// ChatRoute reports the collections a routed chat turn searches, and the
+// scores of every candidate, best first.
+type ChatRoute struct {
+	Collections []string         `json:"collections"`
+	Candidates  []RouteCandidate `json:"candidates"`
+}
+
+// RouteCandidate is a collection scored by chat routing. Score is the mean
+// of the cosine similarities of the question to the collection's
+// description and to its best matching point, whichever are known.
+type RouteCandidate struct {
+	Collection       string   `json:"collection"`
+	Score            float32  `json:"score"`
+	DescriptionScore *float32 `json:"description_score,omitempty"`
+	ContentScore     *float32 `json:"content_score,omitempty"`
+}
+
+// Citation is one cited file in a chat answer. Repeated hits on the same file
+// are merged; each hit becomes an anchor.
+type Citation struct {
+	Index      int              `json:"index"` // 1-based, in order of first appearance
+	FilePath   string           `json:"file_path"`
+	Title      string           `json:"title"`
+	Kind       string           `json:"kind"` // "file", "image" or "smb"
+	Collection string           `json:"collection"`
+	SourceTag  string           `json:"source_tag,omitempty"`
+	Language   string           `json:"language"`
+	Score      float32          `json:"score"` // best anchor score
+	URL        string           `json:"url,omitempty"`
+	Anchors    []CitationAnchor `json:"anchors"`
+}
+
+// CitationAnchor locates one cited chunk within its file.
+type CitationAnchor struct {
+	ChunkIndex int     `json:"chunk_index"`
+	StartLine  int     `json:"start_line,omitempty"`
+	EndLine    int     `json:"end_line,omitempty"`
+	PageStart  int     `json:"page_start,omitempty"`
+	PageEnd    int     `json:"page_end,omitempty"`
+	Label      string  `json:"label"` // "L12-30", "p. 3", "pp. 3-4" or "1:05-1:42"
+	Score      float32 `json:"score"`
+	PreviewURL string  `json:"preview_url,omitempty"`
+	// Media is the time range of a chunk transcribed from audio or video.
+	Media *MediaRange `json:"media,omitempty"`
+}
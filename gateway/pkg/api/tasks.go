@@ -0,0 +1,91 @@
+package api
+
+import (
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/tasks"
+)
+
+// Task is a background task as returned by GET /api/rag/tasks/{id}.
+// Progress is a percentage.
+type Task = tasks.TaskInfo
+
+// TaskStatus is the lifecycle state of a task.
+type TaskStatus = tasks.TaskStatus
+
+// Task states.
+const (
+	StatusPending   = tasks.StatusPending
+	StatusRunning   = tasks.StatusRunning
+	StatusCompleted = tasks.StatusCompleted
+	StatusFailed    = tasks.StatusFailed
+	StatusCancelled = tasks.StatusCancelled
+
+	StatusReconnecting = tasks.StatusReconnecting
+)
+
+// TaskList is the body of GET /api/rag/tasks. Stalled counts the running
+// tasks the watchdog found quiet for too long.
+type TaskList struct {
+	Tasks   []*Task `json:"tasks"`
+	Count   int     `json:"count"`
+	Stalled int     `json:"stalled"`
+}
+
+// TaskAccepted is the 202 response for a newly started background task.
+// Status is "queued" while the task waits for a free slot, at
+// QueuePosition, and "started" otherwise.
+type TaskAccepted struct {
+	TaskID        string   `json:"task_id"`
+	Status        string   `json:"status"`
+	QueuePosition int      `json:"queue_position,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+// IndexCodebaseRequest is the body of POST /api/rag/index/codebase and the
+// params of a codebase index preset.
+type IndexCodebaseRequest struct {
+	RootPath      string   `json:"root_path"`
+	Collection    string   `json:"collection"`
+	Incremental   bool     `json:"incremental"`
+	ChunkSize     int32    `json:"chunk_size"`
+	ChunkOverlap  int32    `json:"chunk_overlap"`
+	ExtraSkipDirs []string `json:"extra_skip_dirs"`
+	Priority      string   `json:"priority"`
+	Lock          string   `json:"lock"`
+	Instance      string   `json:"instance"`
+}
+
+// IndexDocumentsRequest is the body of POST /api/rag/index/documents and the
+// params of a documents index preset.
+type IndexDocumentsRequest struct {
+	Paths        []string `json:"paths"`
+	Collection   string   `json:"collection"`
+	ChunkSize    int32    `json:"chunk_size"`
+	ChunkOverlap int32    `json:"chunk_overlap"`
+	SourceTag    string   `json:"source_tag"`
+	Priority     string   `json:"priority"`
+	Lock         string   `json:"lock"`
+	Instance     string   `json:"instance"`
+	// Table enables table-aware chunking of .csv, .tsv and .xlsx files,
+	// which are skipped without it.
+	Table *TableOptions `json:"table,omitempty"`
+}
+
+// TableOptions control how spreadsheets are chunked; see
+// grpcclient.TableOptions.
+type TableOptions = grpcclient.TableOptions
+
+// IndexImagesRequest is the body of POST /api/rag/index/images and the
+// params of an images index preset.
+type IndexImagesRequest struct {
+	RootPath       string   `json:"root_path"`
+	Collection     string   `json:"collection"`
+	VisionModel    string   `json:"vision_model"`
+	CaptionPrompt  string   `json:"caption_prompt"`
+	Incremental    bool     `json:"incremental"`
+	MaxImageSizeKB int32    `json:"max_image_size_kb"`
+	ExtraSkipDirs  []string `json:"extra_skip_dirs"`
+	Priority       string   `json:"priority"`
+	Lock           string   `json:"lock"`
+	Instance       string   `json:"instance"`
+}
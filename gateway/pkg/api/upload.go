@@ -0,0 +1,62 @@
+package api
+
+import "strconv"
+
+// UploadFields are the form fields of POST /api/rag/upload. Sent before the
+// files, they let indexing start while the rest of the upload arrives.
+type UploadFields struct {
+	Collection    string
+	SourceTag     string
+	VisionModel   string
+	CaptionPrompt string
+	Priority      string
+	Lock          string
+	// Routing set to false indexes every file the regular way instead of
+	// by the upload routing rules.
+	Routing *bool
+}
+
+// Values returns the set fields by form field name.
+func (f UploadFields) Values() map[string]string {
+	v := map[string]string{}
+	for name, val := range map[string]string{
+		"collection":     f.Collection,
+		"source_tag":     f.SourceTag,
+		"vision_model":   f.VisionModel,
+		"caption_prompt": f.CaptionPrompt,
+		"priority":       f.Priority,
+		"lock":           f.Lock,
+	} {
+		if val != "" {
+			v[name] = val
+		}
+	}
+	if f.Routing != nil {
+		v["routing"] = strconv.FormatBool(*f.Routing)
+	}
+	return v
+}
+
+// UploadRoute is one task started for an upload: the files a routing rule
+// (Rule, empty for unrouted files) sent to a pipeline.
+type UploadRoute struct {
+	TaskID     string   `json:"task_id"`
+	Status     string   `json:"status"`
+	Pipeline   string   `json:"pipeline"`
+	Rule       string   `json:"rule,omitempty"`
+	Collection string   `json:"collection"`
+	Files      []string `json:"files"`
+}
+
+// UploadAccepted is the 202 response of POST /api/rag/upload. TaskID is
+// the first of Routes; URLs holds signed URLs of uploaded images.
+type UploadAccepted struct {
+	TaskID    string            `json:"task_id"`
+	Status    string            `json:"status"`
+	Files     []string          `json:"files"`
+	Count     int               `json:"count"`
+	URLs      map[string]string `json:"urls"`
+	Routes    []UploadRoute     `json:"routes"`
+	Pipelined bool              `json:"pipelined,omitempty"`
+	Warnings  []string          `json:"warnings,omitempty"`
+}
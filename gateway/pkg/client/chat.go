@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+	"github.com/gorilla/websocket"
+)
+
+// chatCancelGrace is how long a cancelled chat waits for the gateway to
+// confirm before the connection is closed anyway.
+const chatCancelGrace = 5 * time.Second
+
+// Chat sends msg over a new WebSocket connection and streams the answer's
+// events: chunks of text, the sources used, and finally "done", "error" or
+// "cancelled". The channel is closed after the final event. Cancelling ctx
+// stops the answer; the events sent after that are dropped. A connection
+// lost mid-answer ends the stream with an "error" event.
+func (c *Client) Chat(ctx context.Context, msg api.ChatMessage) (<-chan api.ChatEvent, error) {
+	conn, err := c.dial(ctx, "/api/rag/ws")
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.WriteJSON(msg); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("send chat message: %w", err)
+	}
+
+	events := make(chan api.ChatEvent, 16)
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.WriteJSON(api.ChatMessage{Type: api.ChatMessageCancel})
+			time.AfterFunc(chatCancelGrace, func() { conn.Close() })
+		case <-finished:
+		}
+	}()
+	go func() {
+		defer close(events)
+		defer conn.Close()
+		defer close(finished)
+		for {
+			var evt api.ChatEvent
+			if err := conn.ReadJSON(&evt); err != nil {
+				if ctx.Err() == nil {
+					events <- api.ChatEvent{Type: api.ChatEventError, Content: "connection lost: " + err.Error(), Retryable: true}
+				}
+				return
+			}
+			if ctx.Err() == nil {
+				select {
+				case events <- evt:
+				case <-ctx.Done():
+				}
+			}
+			switch evt.Type {
+			case api.ChatEventDone, api.ChatEventError, api.ChatEventCancelled:
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// dial opens a WebSocket connection to path. A refused upgrade is returned
+// as *api.Error.
+func (c *Client) dial(ctx context.Context, path string) (*websocket.Conn, error) {
+	u := c.baseURL + path
+	switch {
+	case strings.HasPrefix(u, "https://"):
+		u = "wss://" + strings.TrimPrefix(u, "https://")
+	case strings.HasPrefix(u, "http://"):
+		u = "ws://" + strings.TrimPrefix(u, "http://")
+	}
+	h := http.Header{}
+	c.authorize(h)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u, h)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 300 {
+			return nil, decodeError(resp)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,193 @@
+// Package client is a typed Go client for the gateway's REST and WebSocket
+// API. Requests and responses are the pkg/api types the handlers use.
+//
+//	c := client.New("http://localhost:8080", client.WithToken(token))
+//	accepted, err := c.IndexCodebase(ctx, api.IndexCodebaseRequest{RootPath: "/src", Collection: "code"})
+//	for u := range c.WatchTask(ctx, accepted.TaskID, 0) {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+)
+
+// defaultTimeout bounds REST calls made with the default HTTP client.
+// Chat streams are not affected.
+const defaultTimeout = 60 * time.Second
+
+// Client calls a gateway. It is safe for concurrent use.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithToken authenticates requests with token, as returned by
+// POST /api/auth/login. It is sent as a bearer token.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithHTTPClient sends REST requests through hc instead of a client with a
+// 60-second timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// New returns a client for the gateway at baseURL, such as
+// "http://localhost:8080" or "https://example.com/ollqd" when it is mounted
+// under BASE_PATH.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Search runs a search on the default collection.
+func (c *Client) Search(ctx context.Context, req api.SearchRequest) (*api.SearchResponse, error) {
+	var resp api.SearchResponse
+	if err := c.do(ctx, http.MethodPost, "/api/rag/search", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SearchCollection runs a search on collection.
+func (c *Client) SearchCollection(ctx context.Context, collection string, req api.SearchRequest) (*api.SearchResponse, error) {
+	var resp api.SearchResponse
+	if err := c.do(ctx, http.MethodPost, "/api/rag/search/"+url.PathEscape(collection), req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// IndexCodebase starts indexing a source tree. Follow the task with
+// WatchTask.
+func (c *Client) IndexCodebase(ctx context.Context, req api.IndexCodebaseRequest) (*api.TaskAccepted, error) {
+	return c.startTask(ctx, "/api/rag/index/codebase", req)
+}
+
+// IndexDocuments starts indexing documents. Follow the task with WatchTask.
+func (c *Client) IndexDocuments(ctx context.Context, req api.IndexDocumentsRequest) (*api.TaskAccepted, error) {
+	return c.startTask(ctx, "/api/rag/index/documents", req)
+}
+
+// IndexImages starts indexing images. Follow the task with WatchTask.
+func (c *Client) IndexImages(ctx context.Context, req api.IndexImagesRequest) (*api.TaskAccepted, error) {
+	return c.startTask(ctx, "/api/rag/index/images", req)
+}
+
+func (c *Client) startTask(ctx context.Context, path string, req interface{}) (*api.TaskAccepted, error) {
+	var resp api.TaskAccepted
+	if err := c.do(ctx, http.MethodPost, path, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Tasks lists the tracked tasks.
+func (c *Client) Tasks(ctx context.Context) (*api.TaskList, error) {
+	var resp api.TaskList
+	if err := c.do(ctx, http.MethodGet, "/api/rag/tasks", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Task returns the task with id.
+func (c *Client) Task(ctx context.Context, id string) (*api.Task, error) {
+	var resp api.Task
+	if err := c.do(ctx, http.MethodGet, "/api/rag/tasks/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CancelTask cancels a queued or running task and returns its status
+// afterwards: a task that had already finished keeps its state.
+func (c *Client) CancelTask(ctx context.Context, id string) (api.TaskStatus, error) {
+	var resp struct {
+		Status api.TaskStatus `json:"status"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/rag/tasks/"+url.PathEscape(id)+"/cancel", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Status, nil
+}
+
+// do sends a JSON request and decodes the JSON response into out. Error
+// responses are returned as *api.Error.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, rd)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	c.authorize(req.Header)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+func (c *Client) authorize(h http.Header) {
+	if c.token != "" {
+		h.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
+// decodeError reads an error response. Bodies that are not an api.Error,
+// such as a proxy's error page, become its Detail.
+func decodeError(resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	e := &api.Error{StatusCode: resp.StatusCode}
+	if json.Unmarshal(b, e) != nil || e.Detail == "" {
+		e.Detail = strings.TrimSpace(string(b))
+		if e.Detail == "" {
+			e.Detail = http.StatusText(resp.StatusCode)
+		}
+	}
+	return e
+}
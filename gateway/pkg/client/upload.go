@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+)
+
+// Upload uploads the files at paths to POST /api/rag/upload, which saves
+// and indexes them. The form fields go first, so the gateway starts
+// indexing while later files are still being sent. Files are named by
+// their base name. The request is streamed and not bounded by the
+// client's timeout; use ctx to limit it.
+func (c *Client) Upload(ctx context.Context, fields api.UploadFields, paths ...string) (*api.UploadAccepted, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files to upload")
+	}
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeUploadForm(mw, fields, paths))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/rag/upload", pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	c.authorize(req.Header)
+
+	hc := *c.http
+	hc.Timeout = 0
+	resp, err := hc.Do(req)
+	pr.Close()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, decodeError(resp)
+	}
+	var out api.UploadAccepted
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode upload response: %w", err)
+	}
+	return &out, nil
+}
+
+func writeUploadForm(mw *multipart.Writer, fields api.UploadFields, paths []string) error {
+	values := fields.Values()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := mw.WriteField(name, values[name]); err != nil {
+			return err
+		}
+	}
+	for _, p := range paths {
+		if err := writeUploadFile(mw, p); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func writeUploadFile(mw *multipart.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	part, err := mw.CreateFormFile("files", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, f)
+	return err
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/alfagnish/ollqd-gateway/pkg/api"
+)
+
+// defaultPollInterval is how often WatchTask polls by default.
+const defaultPollInterval = time.Second
+
+// TaskUpdate is one state of a watched task. Err is set, and Task nil, on
+// the last update when polling failed.
+type TaskUpdate struct {
+	Task *api.Task
+	Err  error
+}
+
+// WatchTask polls the task with id every interval (one second if zero) and
+// sends its state whenever the status or progress changes. The channel is
+// closed after the task finishes, polling fails, or ctx is done.
+func (c *Client) WatchTask(ctx context.Context, id string, interval time.Duration) <-chan TaskUpdate {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	updates := make(chan TaskUpdate, 1)
+	go func() {
+		defer close(updates)
+		var last *api.Task
+		for {
+			t, err := c.Task(ctx, id)
+			if err != nil {
+				if ctx.Err() == nil {
+					updates <- TaskUpdate{Err: err}
+				}
+				return
+			}
+			if last == nil || t.Status != last.Status || t.Progress != last.Progress {
+				select {
+				case updates <- TaskUpdate{Task: t}:
+				case <-ctx.Done():
+					return
+				}
+				last = t
+			}
+			if t.Status.Finished() {
+				return
+			}
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates
+}
+
+// WaitTask blocks until the task with id finishes and returns its final
+// state, polling every interval (one second if zero).
+func (c *Client) WaitTask(ctx context.Context, id string, interval time.Duration) (*api.Task, error) {
+	var last *api.Task
+	for u := range c.WatchTask(ctx, id, interval) {
+		if u.Err != nil {
+			return nil, u.Err
+		}
+		last = u.Task
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return last, nil
+}
@@ -0,0 +1,64 @@
+// Package redact is an example gateway plugin that masks text matching
+// configured regular expressions in search results, e.g. internal ticket
+// numbers or account IDs the PII masker does not know about.
+//
+// It is not compiled in by default; add
+//
+//	import _ "github.com/alfagnish/ollqd-gateway/plugins/redact"
+//
+// to cmd/gateway/plugins.go and set REDACT_PATTERNS to a comma-separated
+// list of regular expressions. Matches in hit content and captions become
+// REDACT_REPLACEMENT (default "[redacted]").
+package redact
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	grpcclient "github.com/alfagnish/ollqd-gateway/internal/grpc"
+	"github.com/alfagnish/ollqd-gateway/internal/plugin"
+)
+
+func init() {
+	plugin.Register(&redactor{})
+}
+
+type redactor struct {
+	patterns    []*regexp.Regexp
+	replacement string
+}
+
+func (p *redactor) Name() string { return "redact" }
+
+// Init compiles REDACT_PATTERNS.
+func (p *redactor) Init(host plugin.Host) error {
+	p.replacement = os.Getenv("REDACT_REPLACEMENT")
+	if p.replacement == "" {
+		p.replacement = "[redacted]"
+	}
+	for _, expr := range strings.Split(os.Getenv("REDACT_PATTERNS"), ",") {
+		if expr = strings.TrimSpace(expr); expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("REDACT_PATTERNS: %v", err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	return nil
+}
+
+// ProcessSearch masks the patterns in every hit.
+func (p *redactor) ProcessSearch(ctx context.Context, search plugin.Search, hits []*grpcclient.SearchHit) ([]*grpcclient.SearchHit, error) {
+	for _, hit := range hits {
+		for _, re := range p.patterns {
+			hit.Content = re.ReplaceAllString(hit.Content, p.replacement)
+			hit.Caption = re.ReplaceAllString(hit.Caption, p.replacement)
+		}
+	}
+	return hits, nil
+}
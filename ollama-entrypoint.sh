@@ -1,4 +1,15 @@
 #!/bin/bash
+# Trust the CA of a private registry mirror, if one is mounted
+if [ -n "$OLLAMA_REGISTRY_CA" ]; then
+    if [ -f "$OLLAMA_REGISTRY_CA" ]; then
+        cp "$OLLAMA_REGISTRY_CA" /usr/local/share/ca-certificates/ollqd-registry-ca.crt
+        update-ca-certificates >/dev/null
+        echo "[ollqd] Installed registry CA from $OLLAMA_REGISTRY_CA."
+    else
+        echo "[ollqd] WARNING: OLLAMA_REGISTRY_CA=$OLLAMA_REGISTRY_CA does not exist."
+    fi
+fi
+
 # Start ollama server in background
 ollama serve &
 SERVE_PID=$!
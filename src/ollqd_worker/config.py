@@ -66,6 +66,23 @@ class DoclingConfig:
     ocr_engine: str = field(default_factory=lambda: os.getenv("DOCLING_OCR_ENGINE", "easyocr"))
     table_structure: bool = field(default_factory=lambda: os.getenv("DOCLING_TABLE_STRUCTURE", "true").lower() == "true")
     timeout_s: float = field(default_factory=lambda: float(os.getenv("DOCLING_TIMEOUT_S", "300")))
+    # Comma-separated OCR engine language codes; empty uses the engine's default.
+    ocr_languages: str = field(default_factory=lambda: os.getenv("DOCLING_OCR_LANGUAGES", ""))
+    # Resolution pages are rendered at for OCR; 0 uses docling's default.
+    ocr_dpi: int = field(default_factory=lambda: int(os.getenv("DOCLING_OCR_DPI", "0")))
+    # OCR every page in full, even pages with a text layer.
+    force_ocr: bool = field(default_factory=lambda: os.getenv("DOCLING_FORCE_OCR", "false").lower() == "true")
+
+
+@dataclass(slots=True)
+class TranscriptionConfig:
+    enabled: bool = field(default_factory=lambda: os.getenv("TRANSCRIPTION_ENABLED", "true").lower() == "true")
+    # A faster-whisper model size ("tiny", "base", "small", "medium", "large-v3") or a local model path.
+    model: str = field(default_factory=lambda: os.getenv("WHISPER_MODEL", "base"))
+    device: str = field(default_factory=lambda: os.getenv("WHISPER_DEVICE", "auto"))
+    compute_type: str = field(default_factory=lambda: os.getenv("WHISPER_COMPUTE_TYPE", "default"))
+    # Spoken language code; empty detects it per recording.
+    language: str = field(default_factory=lambda: os.getenv("WHISPER_LANGUAGE", ""))
 
 
 @dataclass(slots=True)
@@ -88,6 +105,7 @@ class AppConfig:
     upload: UploadConfig = field(default_factory=UploadConfig)
     pii: PIIConfig = field(default_factory=PIIConfig)
     docling: DoclingConfig = field(default_factory=DoclingConfig)
+    transcription: TranscriptionConfig = field(default_factory=TranscriptionConfig)
     server: ServerConfig = field(default_factory=ServerConfig)
     client: ClientConfig = field(default_factory=ClientConfig)
     mounted_paths: list[str] = field(
@@ -142,6 +160,12 @@ def _apply_db_overrides(cfg: AppConfig) -> None:
         cfg.docling.table_structure = _to_bool(docling["table_structure"])
     if "timeout_s" in docling:
         cfg.docling.timeout_s = _to_float(docling["timeout_s"])
+    if "ocr_languages" in docling:
+        cfg.docling.ocr_languages = docling["ocr_languages"]
+    if "ocr_dpi" in docling:
+        cfg.docling.ocr_dpi = int(docling["ocr_dpi"])
+    if "force_ocr" in docling:
+        cfg.docling.force_ocr = _to_bool(docling["force_ocr"])
 
     ollama = overrides.get("ollama", {})
     if "base_url" in ollama:
@@ -0,0 +1,106 @@
+from google.protobuf import struct_pb2 as _struct_pb2
+from ollqd.v1 import processing_pb2 as _processing_pb2
+from google.protobuf.internal import containers as _containers
+from google.protobuf import descriptor as _descriptor
+from google.protobuf import message as _message
+from collections.abc import Iterable as _Iterable, Mapping as _Mapping
+from typing import ClassVar as _ClassVar, Optional as _Optional, Union as _Union
+
+DESCRIPTOR: _descriptor.FileDescriptor
+
+class CreateTaskRequest(_message.Message):
+    __slots__ = ("index_codebase", "index_documents", "index_images", "priority")
+    INDEX_CODEBASE_FIELD_NUMBER: _ClassVar[int]
+    INDEX_DOCUMENTS_FIELD_NUMBER: _ClassVar[int]
+    INDEX_IMAGES_FIELD_NUMBER: _ClassVar[int]
+    PRIORITY_FIELD_NUMBER: _ClassVar[int]
+    index_codebase: _processing_pb2.IndexCodebaseRequest
+    index_documents: _processing_pb2.IndexDocumentsRequest
+    index_images: _processing_pb2.IndexImagesRequest
+    priority: str
+    def __init__(self, index_codebase: _Optional[_Union[_processing_pb2.IndexCodebaseRequest, _Mapping]] = ..., index_documents: _Optional[_Union[_processing_pb2.IndexDocumentsRequest, _Mapping]] = ..., index_images: _Optional[_Union[_processing_pb2.IndexImagesRequest, _Mapping]] = ..., priority: _Optional[str] = ...) -> None: ...
+
+class GetTaskRequest(_message.Message):
+    __slots__ = ("task_id",)
+    TASK_ID_FIELD_NUMBER: _ClassVar[int]
+    task_id: str
+    def __init__(self, task_id: _Optional[str] = ...) -> None: ...
+
+class ListTasksRequest(_message.Message):
+    __slots__ = ()
+    def __init__(self) -> None: ...
+
+class ListTasksResponse(_message.Message):
+    __slots__ = ("tasks",)
+    TASKS_FIELD_NUMBER: _ClassVar[int]
+    tasks: _containers.RepeatedCompositeFieldContainer[Task]
+    def __init__(self, tasks: _Optional[_Iterable[_Union[Task, _Mapping]]] = ...) -> None: ...
+
+class Task(_message.Message):
+    __slots__ = ("task_id", "type", "status", "progress", "priority", "result", "error", "cancel_reason", "created_at", "started_at", "completed_at", "request_params", "params_dropped", "artifacts", "worker_task_id", "locked_collection", "last_progress_at", "stalled", "stalled_since", "warnings", "error_count", "errors_dropped", "node")
+    class ResultEntry(_message.Message):
+        __slots__ = ("key", "value")
+        KEY_FIELD_NUMBER: _ClassVar[int]
+        VALUE_FIELD_NUMBER: _ClassVar[int]
+        key: str
+        value: str
+        def __init__(self, key: _Optional[str] = ..., value: _Optional[str] = ...) -> None: ...
+    TASK_ID_FIELD_NUMBER: _ClassVar[int]
+    TYPE_FIELD_NUMBER: _ClassVar[int]
+    STATUS_FIELD_NUMBER: _ClassVar[int]
+    PROGRESS_FIELD_NUMBER: _ClassVar[int]
+    PRIORITY_FIELD_NUMBER: _ClassVar[int]
+    RESULT_FIELD_NUMBER: _ClassVar[int]
+    ERROR_FIELD_NUMBER: _ClassVar[int]
+    CANCEL_REASON_FIELD_NUMBER: _ClassVar[int]
+    CREATED_AT_FIELD_NUMBER: _ClassVar[int]
+    STARTED_AT_FIELD_NUMBER: _ClassVar[int]
+    COMPLETED_AT_FIELD_NUMBER: _ClassVar[int]
+    REQUEST_PARAMS_FIELD_NUMBER: _ClassVar[int]
+    PARAMS_DROPPED_FIELD_NUMBER: _ClassVar[int]
+    ARTIFACTS_FIELD_NUMBER: _ClassVar[int]
+    WORKER_TASK_ID_FIELD_NUMBER: _ClassVar[int]
+    LOCKED_COLLECTION_FIELD_NUMBER: _ClassVar[int]
+    LAST_PROGRESS_AT_FIELD_NUMBER: _ClassVar[int]
+    STALLED_FIELD_NUMBER: _ClassVar[int]
+    STALLED_SINCE_FIELD_NUMBER: _ClassVar[int]
+    WARNINGS_FIELD_NUMBER: _ClassVar[int]
+    ERROR_COUNT_FIELD_NUMBER: _ClassVar[int]
+    ERRORS_DROPPED_FIELD_NUMBER: _ClassVar[int]
+    NODE_FIELD_NUMBER: _ClassVar[int]
+    task_id: str
+    type: str
+    status: str
+    progress: float
+    priority: str
+    result: _containers.ScalarMap[str, str]
+    error: str
+    cancel_reason: str
+    created_at: str
+    started_at: str
+    completed_at: str
+    request_params: _struct_pb2.Struct
+    params_dropped: bool
+    artifacts: _containers.RepeatedCompositeFieldContainer[TaskArtifact]
+    worker_task_id: str
+    locked_collection: str
+    last_progress_at: str
+    stalled: bool
+    stalled_since: str
+    warnings: _containers.RepeatedScalarFieldContainer[str]
+    error_count: int
+    errors_dropped: int
+    node: str
+    def __init__(self, task_id: _Optional[str] = ..., type: _Optional[str] = ..., status: _Optional[str] = ..., progress: _Optional[float] = ..., priority: _Optional[str] = ..., result: _Optional[_Mapping[str, str]] = ..., error: _Optional[str] = ..., cancel_reason: _Optional[str] = ..., created_at: _Optional[str] = ..., started_at: _Optional[str] = ..., completed_at: _Optional[str] = ..., request_params: _Optional[_Union[_struct_pb2.Struct, _Mapping]] = ..., params_dropped: bool = ..., artifacts: _Optional[_Iterable[_Union[TaskArtifact, _Mapping]]] = ..., worker_task_id: _Optional[str] = ..., locked_collection: _Optional[str] = ..., last_progress_at: _Optional[str] = ..., stalled: bool = ..., stalled_since: _Optional[str] = ..., warnings: _Optional[_Iterable[str]] = ..., error_count: _Optional[int] = ..., errors_dropped: _Optional[int] = ..., node: _Optional[str] = ...) -> None: ...
+
+class TaskArtifact(_message.Message):
+    __slots__ = ("name", "content_type", "size", "created_at")
+    NAME_FIELD_NUMBER: _ClassVar[int]
+    CONTENT_TYPE_FIELD_NUMBER: _ClassVar[int]
+    SIZE_FIELD_NUMBER: _ClassVar[int]
+    CREATED_AT_FIELD_NUMBER: _ClassVar[int]
+    name: str
+    content_type: str
+    size: int
+    created_at: str
+    def __init__(self, name: _Optional[str] = ..., content_type: _Optional[str] = ..., size: _Optional[int] = ..., created_at: _Optional[str] = ...) -> None: ...
@@ -0,0 +1,75 @@
+from google.protobuf.internal import containers as _containers
+from google.protobuf import descriptor as _descriptor
+from google.protobuf import message as _message
+from collections.abc import Iterable as _Iterable, Mapping as _Mapping
+from typing import ClassVar as _ClassVar, Optional as _Optional, Union as _Union
+
+DESCRIPTOR: _descriptor.FileDescriptor
+
+class GetOCRConfigRequest(_message.Message):
+    __slots__ = ()
+    def __init__(self) -> None: ...
+
+class OCRConfig(_message.Message):
+    __slots__ = ("engine", "ocr_enabled", "languages", "dpi", "force_ocr", "docling_available")
+    ENGINE_FIELD_NUMBER: _ClassVar[int]
+    OCR_ENABLED_FIELD_NUMBER: _ClassVar[int]
+    LANGUAGES_FIELD_NUMBER: _ClassVar[int]
+    DPI_FIELD_NUMBER: _ClassVar[int]
+    FORCE_OCR_FIELD_NUMBER: _ClassVar[int]
+    DOCLING_AVAILABLE_FIELD_NUMBER: _ClassVar[int]
+    engine: str
+    ocr_enabled: bool
+    languages: _containers.RepeatedScalarFieldContainer[str]
+    dpi: int
+    force_ocr: bool
+    docling_available: bool
+    def __init__(self, engine: _Optional[str] = ..., ocr_enabled: bool = ..., languages: _Optional[_Iterable[str]] = ..., dpi: _Optional[int] = ..., force_ocr: bool = ..., docling_available: bool = ...) -> None: ...
+
+class OCRLanguages(_message.Message):
+    __slots__ = ("codes",)
+    CODES_FIELD_NUMBER: _ClassVar[int]
+    codes: _containers.RepeatedScalarFieldContainer[str]
+    def __init__(self, codes: _Optional[_Iterable[str]] = ...) -> None: ...
+
+class UpdateOCRConfigRequest(_message.Message):
+    __slots__ = ("languages", "dpi", "force_ocr")
+    LANGUAGES_FIELD_NUMBER: _ClassVar[int]
+    DPI_FIELD_NUMBER: _ClassVar[int]
+    FORCE_OCR_FIELD_NUMBER: _ClassVar[int]
+    languages: OCRLanguages
+    dpi: int
+    force_ocr: bool
+    def __init__(self, languages: _Optional[_Union[OCRLanguages, _Mapping]] = ..., dpi: _Optional[int] = ..., force_ocr: bool = ...) -> None: ...
+
+class TestOCRRequest(_message.Message):
+    __slots__ = ("path", "page", "languages", "dpi")
+    PATH_FIELD_NUMBER: _ClassVar[int]
+    PAGE_FIELD_NUMBER: _ClassVar[int]
+    LANGUAGES_FIELD_NUMBER: _ClassVar[int]
+    DPI_FIELD_NUMBER: _ClassVar[int]
+    path: str
+    page: int
+    languages: OCRLanguages
+    dpi: int
+    def __init__(self, path: _Optional[str] = ..., page: _Optional[int] = ..., languages: _Optional[_Union[OCRLanguages, _Mapping]] = ..., dpi: _Optional[int] = ...) -> None: ...
+
+class TestOCRResponse(_message.Message):
+    __slots__ = ("text", "chars", "page", "pages", "took_ms", "engine", "languages", "dpi")
+    TEXT_FIELD_NUMBER: _ClassVar[int]
+    CHARS_FIELD_NUMBER: _ClassVar[int]
+    PAGE_FIELD_NUMBER: _ClassVar[int]
+    PAGES_FIELD_NUMBER: _ClassVar[int]
+    TOOK_MS_FIELD_NUMBER: _ClassVar[int]
+    ENGINE_FIELD_NUMBER: _ClassVar[int]
+    LANGUAGES_FIELD_NUMBER: _ClassVar[int]
+    DPI_FIELD_NUMBER: _ClassVar[int]
+    text: str
+    chars: int
+    page: int
+    pages: int
+    took_ms: int
+    engine: str
+    languages: _containers.RepeatedScalarFieldContainer[str]
+    dpi: int
+    def __init__(self, text: _Optional[str] = ..., chars: _Optional[int] = ..., page: _Optional[int] = ..., pages: _Optional[int] = ..., took_ms: _Optional[int] = ..., engine: _Optional[str] = ..., languages: _Optional[_Iterable[str]] = ..., dpi: _Optional[int] = ...) -> None: ...
@@ -0,0 +1,59 @@
+from google.protobuf.internal import containers as _containers
+from google.protobuf import descriptor as _descriptor
+from google.protobuf import message as _message
+from collections.abc import Iterable as _Iterable, Mapping as _Mapping
+from typing import ClassVar as _ClassVar, Optional as _Optional, Union as _Union
+
+DESCRIPTOR: _descriptor.FileDescriptor
+
+class PreviewUploadRequest(_message.Message):
+    __slots__ = ("path", "chunk_size", "chunk_overlap")
+    PATH_FIELD_NUMBER: _ClassVar[int]
+    CHUNK_SIZE_FIELD_NUMBER: _ClassVar[int]
+    CHUNK_OVERLAP_FIELD_NUMBER: _ClassVar[int]
+    path: str
+    chunk_size: int
+    chunk_overlap: int
+    def __init__(self, path: _Optional[str] = ..., chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ...) -> None: ...
+
+class PreviewUploadResponse(_message.Message):
+    __slots__ = ("extractor", "language", "content_hash", "text", "text_chars", "text_truncated", "chunk_size", "chunk_overlap", "total_chunks", "chunks")
+    EXTRACTOR_FIELD_NUMBER: _ClassVar[int]
+    LANGUAGE_FIELD_NUMBER: _ClassVar[int]
+    CONTENT_HASH_FIELD_NUMBER: _ClassVar[int]
+    TEXT_FIELD_NUMBER: _ClassVar[int]
+    TEXT_CHARS_FIELD_NUMBER: _ClassVar[int]
+    TEXT_TRUNCATED_FIELD_NUMBER: _ClassVar[int]
+    CHUNK_SIZE_FIELD_NUMBER: _ClassVar[int]
+    CHUNK_OVERLAP_FIELD_NUMBER: _ClassVar[int]
+    TOTAL_CHUNKS_FIELD_NUMBER: _ClassVar[int]
+    CHUNKS_FIELD_NUMBER: _ClassVar[int]
+    extractor: str
+    language: str
+    content_hash: str
+    text: str
+    text_chars: int
+    text_truncated: bool
+    chunk_size: int
+    chunk_overlap: int
+    total_chunks: int
+    chunks: _containers.RepeatedCompositeFieldContainer[PreviewChunk]
+    def __init__(self, extractor: _Optional[str] = ..., language: _Optional[str] = ..., content_hash: _Optional[str] = ..., text: _Optional[str] = ..., text_chars: _Optional[int] = ..., text_truncated: bool = ..., chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., total_chunks: _Optional[int] = ..., chunks: _Optional[_Iterable[_Union[PreviewChunk, _Mapping]]] = ...) -> None: ...
+
+class PreviewChunk(_message.Message):
+    __slots__ = ("chunk_index", "start_line", "end_line", "chars", "content", "page_start", "page_end")
+    CHUNK_INDEX_FIELD_NUMBER: _ClassVar[int]
+    START_LINE_FIELD_NUMBER: _ClassVar[int]
+    END_LINE_FIELD_NUMBER: _ClassVar[int]
+    CHARS_FIELD_NUMBER: _ClassVar[int]
+    CONTENT_FIELD_NUMBER: _ClassVar[int]
+    PAGE_START_FIELD_NUMBER: _ClassVar[int]
+    PAGE_END_FIELD_NUMBER: _ClassVar[int]
+    chunk_index: int
+    start_line: int
+    end_line: int
+    chars: int
+    content: str
+    page_start: int
+    page_end: int
+    def __init__(self, chunk_index: _Optional[int] = ..., start_line: _Optional[int] = ..., end_line: _Optional[int] = ..., chars: _Optional[int] = ..., content: _Optional[str] = ..., page_start: _Optional[int] = ..., page_end: _Optional[int] = ...) -> None: ...
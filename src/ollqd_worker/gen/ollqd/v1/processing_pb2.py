@@ -25,7 +25,7 @@ _sym_db = _symbol_database.Default()
 from ollqd.v1 import types_pb2 as ollqd_dot_v1_dot_types__pb2
 
 
-DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x19ollqd/v1/processing.proto\x12\x08ollqd.v1\x1a\x14ollqd/v1/types.proto\"\x96\x01\n\x14IndexCodebaseRequest\x12\x11\n\troot_path\x18\x01 \x01(\t\x12\x12\n\ncollection\x18\x02 \x01(\t\x12\x13\n\x0bincremental\x18\x03 \x01(\x08\x12\x12\n\nchunk_size\x18\x04 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x05 \x01(\x05\x12\x17\n\x0f\x65xtra_skip_dirs\x18\x06 \x03(\t\"y\n\x15IndexDocumentsRequest\x12\r\n\x05paths\x18\x01 \x03(\t\x12\x12\n\ncollection\x18\x02 \x01(\t\x12\x12\n\nchunk_size\x18\x03 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x04 \x01(\x05\x12\x12\n\nsource_tag\x18\x05 \x01(\t\"\xb2\x01\n\x12IndexImagesRequest\x12\x11\n\troot_path\x18\x01 \x01(\t\x12\x12\n\ncollection\x18\x02 \x01(\t\x12\x14\n\x0cvision_model\x18\x03 \x01(\t\x12\x16\n\x0e\x63\x61ption_prompt\x18\x04 \x01(\t\x12\x13\n\x0bincremental\x18\x05 \x01(\x08\x12\x19\n\x11max_image_size_kb\x18\x06 \x01(\x05\x12\x17\n\x0f\x65xtra_skip_dirs\x18\x07 \x03(\t\"\xab\x01\n\x13IndexUploadsRequest\x12\x13\n\x0bsaved_paths\x18\x01 \x03(\t\x12\x12\n\ncollection\x18\x02 \x01(\t\x12\x12\n\nchunk_size\x18\x03 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x04 \x01(\x05\x12\x12\n\nsource_tag\x18\x05 \x01(\t\x12\x14\n\x0cvision_model\x18\x06 \x01(\t\x12\x16\n\x0e\x63\x61ption_prompt\x18\x07 \x01(\t\"\xf2\x01\n\x14IndexSMBFilesRequest\x12\x10\n\x08share_id\x18\x01 \x01(\t\x12\x14\n\x0cremote_paths\x18\x02 \x03(\t\x12\x12\n\ncollection\x18\x03 \x01(\t\x12\x12\n\nchunk_size\x18\x04 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x05 \x01(\x05\x12\x12\n\nsource_tag\x18\x06 \x01(\t\x12\x0e\n\x06server\x18\x07 \x01(\t\x12\r\n\x05share\x18\x08 \x01(\t\x12\x10\n\x08username\x18\t \x01(\t\x12\x10\n\x08password\x18\n \x01(\t\x12\x0e\n\x06\x64omain\x18\x0b \x01(\t\x12\x0c\n\x04port\x18\x0c \x01(\x05\"$\n\x11\x43\x61ncelTaskRequest\x12\x0f\n\x07task_id\x18\x01 \x01(\t\"8\n\x12\x43\x61ncelTaskResponse\x12\x11\n\tcancelled\x18\x01 \x01(\x08\x12\x0f\n\x07message\x18\x02 \x01(\t\"R\n\rSearchRequest\x12\r\n\x05query\x18\x01 \x01(\t\x12\r\n\x05top_k\x18\x02 \x01(\x05\x12\x10\n\x08language\x18\x03 \x01(\t\x12\x11\n\tfile_path\x18\x04 \x01(\t\"p\n\x17SearchCollectionRequest\x12\x12\n\ncollection\x18\x01 \x01(\t\x12\r\n\x05query\x18\x02 \x01(\t\x12\r\n\x05top_k\x18\x03 \x01(\x05\x12\x10\n\x08language\x18\x04 \x01(\t\x12\x11\n\tfile_path\x18\x05 \x01(\t\"i\n\x0eSearchResponse\x12\x0e\n\x06status\x18\x01 \x01(\t\x12\r\n\x05query\x18\x02 \x01(\t\x12\x12\n\ncollection\x18\x03 \x01(\t\x12$\n\x07results\x18\x04 \x03(\x0b\x32\x13.ollqd.v1.SearchHit\"V\n\x0b\x43hatRequest\x12\x0f\n\x07message\x18\x01 \x01(\t\x12\x12\n\ncollection\x18\x02 \x01(\t\x12\r\n\x05model\x18\x03 \x01(\t\x12\x13\n\x0bpii_enabled\x18\x04 \x01(\x08\"\x80\x01\n\tChatEvent\x12\x0c\n\x04type\x18\x01 \x01(\t\x12\x0f\n\x07\x63ontent\x18\x02 \x01(\t\x12$\n\x07sources\x18\x03 \x03(\x0b\x32\x13.ollqd.v1.SearchHit\x12\x12\n\npii_masked\x18\x04 \x01(\x08\x12\x1a\n\x12pii_entities_count\x18\x05 \x01(\x05\"\x19\n\x17GetEmbeddingInfoRequest\"e\n\x15\x45mbeddingInfoResponse\x12\r\n\x05model\x18\x01 \x01(\t\x12\x11\n\tdimension\x18\x02 \x01(\x05\x12\x12\n\nlatency_ms\x18\x03 \x01(\x05\x12\x16\n\x0eprevious_model\x18\x04 \x01(\t\" \n\x10TestEmbedRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\"\x7f\n\x11TestEmbedResponse\x12\x11\n\tdimension\x18\x01 \x01(\x05\x12\x0b\n\x03min\x18\x02 \x01(\x01\x12\x0b\n\x03max\x18\x03 \x01(\x01\x12\x0c\n\x04mean\x18\x04 \x01(\x01\x12\r\n\x05stdev\x18\x05 \x01(\x01\x12\x0c\n\x04norm\x18\x06 \x01(\x01\x12\x12\n\nlatency_ms\x18\x07 \x01(\x05\"D\n\x14\x43ompareModelsRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\x12\x0e\n\x06model1\x18\x02 \x01(\t\x12\x0e\n\x06model2\x18\x03 \x01(\t\"\x9b\x01\n\x0fModelTestResult\x12\r\n\x05model\x18\x01 \x01(\t\x12\x11\n\tdimension\x18\x02 \x01(\x05\x12\x0b\n\x03min\x18\x03 \x01(\x01\x12\x0b\n\x03max\x18\x04 \x01(\x01\x12\x0c\n\x04mean\x18\x05 \x01(\x01\x12\r\n\x05stdev\x18\x06 \x01(\x01\x12\x0c\n\x04norm\x18\x07 \x01(\x01\x12\x12\n\nlatency_ms\x18\x08 \x01(\x05\x12\r\n\x05\x65rror\x18\t \x01(\t\"{\n\x15\x43ompareModelsResponse\x12)\n\x06model1\x18\x01 \x01(\x0b\x32\x19.ollqd.v1.ModelTestResult\x12)\n\x06model2\x18\x02 \x01(\x0b\x32\x19.ollqd.v1.ModelTestResult\x12\x0c\n\x04text\x18\x03 \x01(\t\"%\n\x14SetEmbedModelRequest\x12\r\n\x05model\x18\x01 \x01(\t\"\"\n\x12TestMaskingRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\",\n\tPIIEntity\x12\r\n\x05token\x18\x01 \x01(\t\x12\x10\n\x08original\x18\x02 \x01(\t\"t\n\x13TestMaskingResponse\x12\x10\n\x08original\x18\x01 \x01(\t\x12\x0e\n\x06masked\x18\x02 \x01(\t\x12%\n\x08\x65ntities\x18\x03 \x03(\x0b\x32\x13.ollqd.v1.PIIEntity\x12\x14\n\x0c\x65ntity_count\x18\x04 \x01(\x05\"\x12\n\x10GetConfigRequest\"*\n\x19UpdateMountedPathsRequest\x12\r\n\x05paths\x18\x01 \x03(\t\"3\n\x1aUpdateMountedPathsResponse\x12\x15\n\rmounted_paths\x18\x01 \x03(\t\"\xba\x01\n\x10UpdatePIIRequest\x12\x14\n\x07\x65nabled\x18\x01 \x01(\x08H\x00\x88\x01\x01\x12\x16\n\tuse_spacy\x18\x02 \x01(\x08H\x01\x88\x01\x01\x12\x1c\n\x0fmask_embeddings\x18\x03 \x01(\x08H\x02\x88\x01\x01\x12\x1a\n\renabled_types\x18\x04 \x01(\tH\x03\x88\x01\x01\x42\n\n\x08_enabledB\x0c\n\n_use_spacyB\x12\n\x10_mask_embeddingsB\x10\n\x0e_enabled_types\"\x80\x01\n\x11PIIConfigResponse\x12\x0f\n\x07\x65nabled\x18\x01 \x01(\x08\x12\x11\n\tuse_spacy\x18\x02 \x01(\x08\x12\x17\n\x0fmask_embeddings\x18\x03 \x01(\x08\x12\x15\n\renabled_types\x18\x04 \x01(\t\x12\x17\n\x0fspacy_available\x18\x05 \x01(\x08\"\xe2\x01\n\x14UpdateDoclingRequest\x12\x14\n\x07\x65nabled\x18\x01 \x01(\x08H\x00\x88\x01\x01\x12\x18\n\x0bocr_enabled\x18\x02 \x01(\x08H\x01\x88\x01\x01\x12\x17\n\nocr_engine\x18\x03 \x01(\tH\x02\x88\x01\x01\x12\x1c\n\x0ftable_structure\x18\x04 \x01(\x08H\x03\x88\x01\x01\x12\x16\n\ttimeout_s\x18\x05 \x01(\x01H\x04\x88\x01\x01\x42\n\n\x08_enabledB\x0e\n\x0c_ocr_enabledB\r\n\x0b_ocr_engineB\x12\n\x10_table_structureB\x0c\n\n_timeout_s\"\xae\x01\n\x15\x44oclingConfigResponse\x12\x0f\n\x07\x65nabled\x18\x01 \x01(\x08\x12\x13\n\x0bocr_enabled\x18\x02 \x01(\x08\x12\x12\n\nocr_engine\x18\x03 \x01(\t\x12\x17\n\x0ftable_structure\x18\x04 \x01(\x08\x12\x11\n\ttimeout_s\x18\x05 \x01(\x01\x12\x11\n\tavailable\x18\x06 \x01(\x08\x12\x1c\n\x14supported_extensions\x18\x07 \x03(\t\")\n\x15UpdateDistanceRequest\x12\x10\n\x08\x64istance\x18\x01 \x01(\t\"<\n\x16UpdateDistanceResponse\x12\x10\n\x08\x64istance\x18\x01 \x01(\t\x12\x10\n\x08previous\x18\x02 \x01(\t\"\xfb\x01\n\x13UpdateOllamaRequest\x12\x15\n\x08\x62\x61se_url\x18\x01 \x01(\tH\x00\x88\x01\x01\x12\x17\n\nchat_model\x18\x02 \x01(\tH\x01\x88\x01\x01\x12\x18\n\x0b\x65mbed_model\x18\x03 \x01(\tH\x02\x88\x01\x01\x12\x19\n\x0cvision_model\x18\x04 \x01(\tH\x03\x88\x01\x01\x12\x16\n\ttimeout_s\x18\x05 \x01(\x01H\x04\x88\x01\x01\x12\x12\n\x05local\x18\x06 \x01(\x08H\x05\x88\x01\x01\x42\x0b\n\t_base_urlB\r\n\x0b_chat_modelB\x0e\n\x0c_embed_modelB\x0f\n\r_vision_modelB\x0c\n\n_timeout_sB\x08\n\x06_local\"\x89\x01\n\x14OllamaConfigResponse\x12\x10\n\x08\x62\x61se_url\x18\x01 \x01(\t\x12\x12\n\nchat_model\x18\x02 \x01(\t\x12\x13\n\x0b\x65mbed_model\x18\x03 \x01(\t\x12\x14\n\x0cvision_model\x18\x04 \x01(\t\x12\x11\n\ttimeout_s\x18\x05 \x01(\x01\x12\r\n\x05local\x18\x06 \x01(\x08\"\x9b\x01\n\x13UpdateQdrantRequest\x12\x10\n\x03url\x18\x01 \x01(\tH\x00\x88\x01\x01\x12\x1f\n\x12\x64\x65\x66\x61ult_collection\x18\x02 \x01(\tH\x01\x88\x01\x01\x12\x1d\n\x10\x64\x65\x66\x61ult_distance\x18\x03 \x01(\tH\x02\x88\x01\x01\x42\x06\n\x04_urlB\x15\n\x13_default_collectionB\x13\n\x11_default_distance\"Y\n\x14QdrantConfigResponse\x12\x0b\n\x03url\x18\x01 \x01(\t\x12\x1a\n\x12\x64\x65\x66\x61ult_collection\x18\x02 \x01(\t\x12\x18\n\x10\x64\x65\x66\x61ult_distance\x18\x03 \x01(\t\"\xa1\x01\n\x15UpdateChunkingRequest\x12\x17\n\nchunk_size\x18\x01 \x01(\x05H\x00\x88\x01\x01\x12\x1a\n\rchunk_overlap\x18\x02 \x01(\x05H\x01\x88\x01\x01\x12\x1d\n\x10max_file_size_kb\x18\x03 \x01(\x05H\x02\x88\x01\x01\x42\r\n\x0b_chunk_sizeB\x10\n\x0e_chunk_overlapB\x13\n\x11_max_file_size_kb\"]\n\x16\x43hunkingConfigResponse\x12\x12\n\nchunk_size\x18\x01 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x02 \x01(\x05\x12\x18\n\x10max_file_size_kb\x18\x03 \x01(\x05\"z\n\x12UpdateImageRequest\x12\x1e\n\x11max_image_size_kb\x18\x01 \x01(\x05H\x00\x88\x01\x01\x12\x1b\n\x0e\x63\x61ption_prompt\x18\x02 \x01(\tH\x01\x88\x01\x01\x42\x14\n\x12_max_image_size_kbB\x11\n\x0f_caption_prompt\"H\n\x13ImageConfigResponse\x12\x19\n\x11max_image_size_kb\x18\x01 \x01(\x05\x12\x16\n\x0e\x63\x61ption_prompt\x18\x02 \x01(\t\"\x15\n\x13GetPIIConfigRequest\"\x19\n\x17GetDoclingConfigRequest\"3\n\x12ResetConfigRequest\x12\x0f\n\x07section\x18\x01 \x01(\t\x12\x0c\n\x04keys\x18\x02 \x03(\t\":\n\x13ResetConfigResponse\x12\x0f\n\x07section\x18\x01 \x01(\t\x12\x12\n\nreset_keys\x18\x02 \x03(\t\"4\n\x0fOverviewRequest\x12\x12\n\ncollection\x18\x01 \x01(\t\x12\r\n\x05limit\x18\x02 \x01(\x05\"\xa3\x01\n\x07VisNode\x12\n\n\x02id\x18\x01 \x01(\x05\x12\r\n\x05label\x18\x02 \x01(\t\x12\r\n\x05title\x18\x03 \x01(\t\x12\r\n\x05\x63olor\x18\x04 \x01(\t\x12\x0c\n\x04size\x18\x05 \x01(\x05\x12\r\n\x05shape\x18\x06 \x01(\t\x12\x11\n\tfile_path\x18\x07 \x01(\t\x12\x10\n\x08language\x18\x08 \x01(\t\x12\x0e\n\x06\x63hunks\x18\t \x01(\x05\x12\r\n\x05level\x18\n \x01(\x05\"#\n\x07VisEdge\x12\x0c\n\x04\x66rom\x18\x01 \x01(\x05\x12\n\n\x02to\x18\x02 \x01(\x05\"N\n\rOverviewStats\x12\x13\n\x0btotal_files\x18\x01 \x01(\x05\x12\x14\n\x0ctotal_chunks\x18\x02 \x01(\x05\x12\x12\n\ncollection\x18\x03 \x01(\t\"~\n\x10OverviewResponse\x12 \n\x05nodes\x18\x01 \x03(\x0b\x32\x11.ollqd.v1.VisNode\x12 \n\x05\x65\x64ges\x18\x02 \x03(\x0b\x32\x11.ollqd.v1.VisEdge\x12&\n\x05stats\x18\x03 \x01(\x0b\x32\x17.ollqd.v1.OverviewStats\"8\n\x0f\x46ileTreeRequest\x12\x12\n\ncollection\x18\x01 \x01(\t\x12\x11\n\tfile_path\x18\x02 \x01(\t\"\x7f\n\x10\x46ileTreeResponse\x12 \n\x05nodes\x18\x01 \x03(\x0b\x32\x11.ollqd.v1.VisNode\x12 \n\x05\x65\x64ges\x18\x02 \x03(\x0b\x32\x11.ollqd.v1.VisEdge\x12\x11\n\tfile_path\x18\x03 \x01(\t\x12\x14\n\x0ctotal_chunks\x18\x04 \x01(\x05\"Q\n\x0eVectorsRequest\x12\x12\n\ncollection\x18\x01 \x01(\t\x12\x0e\n\x06method\x18\x02 \x01(\t\x12\x0c\n\x04\x64ims\x18\x03 \x01(\x05\x12\r\n\x05limit\x18\x04 \x01(\x05\"l\n\x0bVectorPoint\x12\t\n\x01x\x18\x01 \x01(\x01\x12\t\n\x01y\x18\x02 \x01(\x01\x12\t\n\x01z\x18\x03 \x01(\x01\x12\x0c\n\x04\x66ile\x18\x04 \x01(\t\x12\x10\n\x08language\x18\x05 \x01(\t\x12\r\n\x05\x63hunk\x18\x06 \x01(\x05\x12\r\n\x05\x63olor\x18\x07 \x01(\t\"\x83\x01\n\x0fVectorsResponse\x12%\n\x06points\x18\x01 \x03(\x0b\x32\x15.ollqd.v1.VectorPoint\x12\x0e\n\x06method\x18\x02 \x01(\t\x12\x0c\n\x04\x64ims\x18\x03 \x01(\x05\x12\x15\n\roriginal_dims\x18\x04 \x01(\x05\x12\x14\n\x0ctotal_points\x18\x05 \x01(\x05\"q\n\x0eSMBTestRequest\x12\x0e\n\x06server\x18\x01 \x01(\t\x12\r\n\x05share\x18\x02 \x01(\t\x12\x10\n\x08username\x18\x03 \x01(\t\x12\x10\n\x08password\x18\x04 \x01(\t\x12\x0e\n\x06\x64omain\x18\x05 \x01(\t\x12\x0c\n\x04port\x18\x06 \x01(\x05\".\n\x0fSMBTestResponse\x12\n\n\x02ok\x18\x01 \x01(\x08\x12\x0f\n\x07message\x18\x02 \x01(\t\"\x81\x01\n\x10SMBBrowseRequest\x12\x0e\n\x06server\x18\x01 \x01(\t\x12\r\n\x05share\x18\x02 \x01(\t\x12\x10\n\x08username\x18\x03 \x01(\t\x12\x10\n\x08password\x18\x04 \x01(\t\x12\x0e\n\x06\x64omain\x18\x05 \x01(\t\x12\x0c\n\x04port\x18\x06 \x01(\x05\x12\x0c\n\x04path\x18\x07 \x01(\t\"H\n\x0cSMBFileEntry\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x0e\n\x06is_dir\x18\x02 \x01(\x08\x12\x0c\n\x04size\x18\x03 \x01(\x03\x12\x0c\n\x04path\x18\x04 \x01(\t\"H\n\x11SMBBrowseResponse\x12%\n\x05\x66iles\x18\x01 \x03(\x0b\x32\x16.ollqd.v1.SMBFileEntry\x12\x0c\n\x04path\x18\x02 \x01(\t\"2\n\x0cLoginRequest\x12\x10\n\x08username\x18\x01 \x01(\t\x12\x10\n\x08password\x18\x02 \x01(\t\"O\n\rLoginResponse\x12\x0f\n\x07success\x18\x01 \x01(\x08\x12\r\n\x05\x65rror\x18\x02 \x01(\t\x12\x10\n\x08username\x18\x03 \x01(\t\x12\x0c\n\x04role\x18\x04 \x01(\t\"%\n\x14ValidateTokenRequest\x12\r\n\x05token\x18\x01 \x01(\t\"F\n\x15ValidateTokenResponse\x12\r\n\x05valid\x18\x01 \x01(\x08\x12\x10\n\x08username\x18\x02 \x01(\t\x12\x0c\n\x04role\x18\x03 \x01(\t\"\x12\n\x10ListUsersRequest\"2\n\x11ListUsersResponse\x12\x1d\n\x05users\x18\x01 \x03(\x0b\x32\x0e.ollqd.v1.User\"E\n\x11\x43reateUserRequest\x12\x10\n\x08username\x18\x01 \x01(\t\x12\x10\n\x08password\x18\x02 \x01(\t\x12\x0c\n\x04role\x18\x03 \x01(\t\"2\n\x12\x43reateUserResponse\x12\x1c\n\x04user\x18\x01 \x01(\x0b\x32\x0e.ollqd.v1.User\"%\n\x11\x44\x65leteUserRequest\x12\x10\n\x08username\x18\x01 \x01(\t\"4\n\x12\x44\x65leteUserResponse\x12\x0f\n\x07\x64\x65leted\x18\x01 \x01(\x08\x12\r\n\x05\x65rror\x18\x02 \x01(\t2\xcd\x03\n\x0fIndexingService\x12I\n\rIndexCodebase\x12\x1e.ollqd.v1.IndexCodebaseRequest\x1a\x16.ollqd.v1.TaskProgress0\x01\x12K\n\x0eIndexDocuments\x12\x1f.ollqd.v1.IndexDocumentsRequest\x1a\x16.ollqd.v1.TaskProgress0\x01\x12\x45\n\x0bIndexImages\x12\x1c.ollqd.v1.IndexImagesRequest\x1a\x16.ollqd.v1.TaskProgress0\x01\x12G\n\x0cIndexUploads\x12\x1d.ollqd.v1.IndexUploadsRequest\x1a\x16.ollqd.v1.TaskProgress0\x01\x12I\n\rIndexSMBFiles\x12\x1e.ollqd.v1.IndexSMBFilesRequest\x1a\x16.ollqd.v1.TaskProgress0\x01\x12G\n\nCancelTask\x12\x1b.ollqd.v1.CancelTaskRequest\x1a\x1c.ollqd.v1.CancelTaskResponse2\x9d\x01\n\rSearchService\x12;\n\x06Search\x12\x17.ollqd.v1.SearchRequest\x1a\x18.ollqd.v1.SearchResponse\x12O\n\x10SearchCollection\x12!.ollqd.v1.SearchCollectionRequest\x1a\x18.ollqd.v1.SearchResponse2C\n\x0b\x43hatService\x12\x34\n\x04\x43hat\x12\x15.ollqd.v1.ChatRequest\x1a\x13.ollqd.v1.ChatEvent0\x01\x32\xc6\x02\n\x10\x45mbeddingService\x12M\n\x07GetInfo\x12!.ollqd.v1.GetEmbeddingInfoRequest\x1a\x1f.ollqd.v1.EmbeddingInfoResponse\x12\x44\n\tTestEmbed\x12\x1a.ollqd.v1.TestEmbedRequest\x1a\x1b.ollqd.v1.TestEmbedResponse\x12P\n\rCompareModels\x12\x1e.ollqd.v1.CompareModelsRequest\x1a\x1f.ollqd.v1.CompareModelsResponse\x12K\n\x08SetModel\x12\x1e.ollqd.v1.SetEmbedModelRequest\x1a\x1f.ollqd.v1.EmbeddingInfoResponse2X\n\nPIIService\x12J\n\x0bTestMasking\x12\x1c.ollqd.v1.TestMaskingRequest\x1a\x1d.ollqd.v1.TestMaskingResponse2\xca\x07\n\rConfigService\x12<\n\tGetConfig\x12\x1a.ollqd.v1.GetConfigRequest\x1a\x13.ollqd.v1.AppConfig\x12_\n\x12UpdateMountedPaths\x12#.ollqd.v1.UpdateMountedPathsRequest\x1a$.ollqd.v1.UpdateMountedPathsResponse\x12\x44\n\tUpdatePII\x12\x1a.ollqd.v1.UpdatePIIRequest\x1a\x1b.ollqd.v1.PIIConfigResponse\x12P\n\rUpdateDocling\x12\x1e.ollqd.v1.UpdateDoclingRequest\x1a\x1f.ollqd.v1.DoclingConfigResponse\x12S\n\x0eUpdateDistance\x12\x1f.ollqd.v1.UpdateDistanceRequest\x1a .ollqd.v1.UpdateDistanceResponse\x12M\n\x0cUpdateOllama\x12\x1d.ollqd.v1.UpdateOllamaRequest\x1a\x1e.ollqd.v1.OllamaConfigResponse\x12M\n\x0cUpdateQdrant\x12\x1d.ollqd.v1.UpdateQdrantRequest\x1a\x1e.ollqd.v1.QdrantConfigResponse\x12S\n\x0eUpdateChunking\x12\x1f.ollqd.v1.UpdateChunkingRequest\x1a .ollqd.v1.ChunkingConfigResponse\x12J\n\x0bUpdateImage\x12\x1c.ollqd.v1.UpdateImageRequest\x1a\x1d.ollqd.v1.ImageConfigResponse\x12J\n\x0cGetPIIConfig\x12\x1d.ollqd.v1.GetPIIConfigRequest\x1a\x1b.ollqd.v1.PIIConfigResponse\x12V\n\x10GetDoclingConfig\x12!.ollqd.v1.GetDoclingConfigRequest\x1a\x1f.ollqd.v1.DoclingConfigResponse\x12J\n\x0bResetConfig\x12\x1c.ollqd.v1.ResetConfigRequest\x1a\x1d.ollqd.v1.ResetConfigResponse2\xdc\x01\n\x14VisualizationService\x12\x41\n\x08Overview\x12\x19.ollqd.v1.OverviewRequest\x1a\x1a.ollqd.v1.OverviewResponse\x12\x41\n\x08\x46ileTree\x12\x19.ollqd.v1.FileTreeRequest\x1a\x1a.ollqd.v1.FileTreeResponse\x12>\n\x07Vectors\x12\x18.ollqd.v1.VectorsRequest\x1a\x19.ollqd.v1.VectorsResponse2\x96\x01\n\nSMBService\x12\x45\n\x0eTestConnection\x12\x18.ollqd.v1.SMBTestRequest\x1a\x19.ollqd.v1.SMBTestResponse\x12\x41\n\x06\x42rowse\x12\x1a.ollqd.v1.SMBBrowseRequest\x1a\x1b.ollqd.v1.SMBBrowseResponse2\xf1\x02\n\x0b\x41uthService\x12\x38\n\x05Login\x12\x16.ollqd.v1.LoginRequest\x1a\x17.ollqd.v1.LoginResponse\x12P\n\rValidateToken\x12\x1e.ollqd.v1.ValidateTokenRequest\x1a\x1f.ollqd.v1.ValidateTokenResponse\x12\x44\n\tListUsers\x12\x1a.ollqd.v1.ListUsersRequest\x1a\x1b.ollqd.v1.ListUsersResponse\x12G\n\nCreateUser\x12\x1b.ollqd.v1.CreateUserRequest\x1a\x1c.ollqd.v1.CreateUserResponse\x12G\n\nDeleteUser\x12\x1b.ollqd.v1.DeleteUserRequest\x1a\x1c.ollqd.v1.DeleteUserResponseB9Z7github.com/alfagnish/ollqd-gateway/gen/ollqd/v1;ollqdv1b\x06proto3')
+DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x19ollqd/v1/processing.proto\x12\x08ollqd.v1\x1a\x14ollqd/v1/types.proto\"\xcf\x01\n\x14IndexCodebaseRequest\x12\x11\n\troot_path\x18\x01 \x01(\t\x12\x12\n\ncollection\x18\x02 \x01(\t\x12\x13\n\x0bincremental\x18\x03 \x01(\x08\x12\x12\n\nchunk_size\x18\x04 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x05 \x01(\x05\x12\x17\n\x0f\x65xtra_skip_dirs\x18\x06 \x03(\t\x12\r\n\x05\x66iles\x18\x07 \x03(\t\x12(\n\nprovenance\x18\x08 \x01(\x0b\x32\x14.ollqd.v1.Provenance\"\xa3\x01\n\x15IndexDocumentsRequest\x12\r\n\x05paths\x18\x01 \x03(\t\x12\x12\n\ncollection\x18\x02 \x01(\t\x12\x12\n\nchunk_size\x18\x03 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x04 \x01(\x05\x12\x12\n\nsource_tag\x18\x05 \x01(\t\x12(\n\nprovenance\x18\x06 \x01(\x0b\x32\x14.ollqd.v1.Provenance\"\xdc\x01\n\x12IndexImagesRequest\x12\x11\n\troot_path\x18\x01 \x01(\t\x12\x12\n\ncollection\x18\x02 \x01(\t\x12\x14\n\x0cvision_model\x18\x03 \x01(\t\x12\x16\n\x0e\x63\x61ption_prompt\x18\x04 \x01(\t\x12\x13\n\x0bincremental\x18\x05 \x01(\x08\x12\x19\n\x11max_image_size_kb\x18\x06 \x01(\x05\x12\x17\n\x0f\x65xtra_skip_dirs\x18\x07 \x03(\t\x12(\n\nprovenance\x18\x08 \x01(\x0b\x32\x14.ollqd.v1.Provenance\"\xd5\x01\n\x13IndexUploadsRequest\x12\x13\n\x0bsaved_paths\x18\x01 \x03(\t\x12\x12\n\ncollection\x18\x02 \x01(\t\x12\x12\n\nchunk_size\x18\x03 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x04 \x01(\x05\x12\x12\n\nsource_tag\x18\x05 \x01(\t\x12\x14\n\x0cvision_model\x18\x06 \x01(\t\x12\x16\n\x0e\x63\x61ption_prompt\x18\x07 \x01(\t\x12(\n\nprovenance\x18\x08 \x01(\x0b\x32\x14.ollqd.v1.Provenance\"\xd6\x02\n\x14IndexSMBFilesRequest\x12\x10\n\x08share_id\x18\x01 \x01(\t\x12\x14\n\x0cremote_paths\x18\x02 \x03(\t\x12\x12\n\ncollection\x18\x03 \x01(\t\x12\x12\n\nchunk_size\x18\x04 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x05 \x01(\x05\x12\x12\n\nsource_tag\x18\x06 \x01(\t\x12\x0e\n\x06server\x18\x07 \x01(\t\x12\r\n\x05share\x18\x08 \x01(\t\x12\x10\n\x08username\x18\t \x01(\t\x12\x10\n\x08password\x18\n \x01(\t\x12\x0e\n\x06\x64omain\x18\x0b \x01(\t\x12\x0c\n\x04port\x18\x0c \x01(\x05\x12\x0c\n\x04\x61uth\x18\r \x01(\t\x12\r\n\x05realm\x18\x0e \x01(\t\x12\x0b\n\x03kdc\x18\x0f \x01(\t\x12\x0e\n\x06keytab\x18\x10 \x01(\x0c\x12(\n\nprovenance\x18\x11 \x01(\x0b\x32\x14.ollqd.v1.Provenance\"\xf7\x01\n\nProvenance\x12\x0e\n\x06schema\x18\x01 \x01(\x05\x12\x13\n\x0bsource_type\x18\x02 \x01(\t\x12\x10\n\x08share_id\x18\x03 \x01(\t\x12\x10\n\x08uploader\x18\x04 \x01(\t\x12\x12\n\nindexed_at\x18\x05 \x01(\t\x12\x0f\n\x07task_id\x18\x06 \x01(\t\x12\x17\n\x0fgateway_version\x18\x07 \x01(\t\x12\x32\n\x07sources\x18\x08 \x03(\x0b\x32!.ollqd.v1.Provenance.SourcesEntry\x1a.\n\x0cSourcesEntry\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\t:\x02\x38\x01\"$\n\x11\x43\x61ncelTaskRequest\x12\x0f\n\x07task_id\x18\x01 \x01(\t\"8\n\x12\x43\x61ncelTaskResponse\x12\x11\n\tcancelled\x18\x01 \x01(\x08\x12\x0f\n\x07message\x18\x02 \x01(\t\"R\n\rSearchRequest\x12\r\n\x05query\x18\x01 \x01(\t\x12\r\n\x05top_k\x18\x02 \x01(\x05\x12\x10\n\x08language\x18\x03 \x01(\t\x12\x11\n\tfile_path\x18\x04 \x01(\t\"p\n\x17SearchCollectionRequest\x12\x12\n\ncollection\x18\x01 \x01(\t\x12\r\n\x05query\x18\x02 \x01(\t\x12\r\n\x05top_k\x18\x03 \x01(\x05\x12\x10\n\x08language\x18\x04 \x01(\t\x12\x11\n\tfile_path\x18\x05 \x01(\t\"i\n\x0eSearchResponse\x12\x0e\n\x06status\x18\x01 \x01(\t\x12\r\n\x05query\x18\x02 \x01(\t\x12\x12\n\ncollection\x18\x03 \x01(\t\x12$\n\x07results\x18\x04 \x03(\x0b\x32\x13.ollqd.v1.SearchHit\"\xd1\x03\n\x0b\x43hatRequest\x12\x0f\n\x07message\x18\x01 \x01(\t\x12\x12\n\ncollection\x18\x02 \x01(\t\x12\r\n\x05model\x18\x03 \x01(\t\x12\x13\n\x0bpii_enabled\x18\x04 \x01(\x08\x12\x18\n\x0btemperature\x18\x05 \x01(\x01H\x00\x88\x01\x01\x12\x12\n\x05top_p\x18\x06 \x01(\x01H\x01\x88\x01\x01\x12\x17\n\nmax_tokens\x18\x07 \x01(\x05H\x02\x88\x01\x01\x12\x12\n\x05top_k\x18\x08 \x01(\x05H\x03\x88\x01\x01\x12\x15\n\rsystem_prompt\x18\t \x01(\t\x12\x1f\n\x12\x63ontext_max_tokens\x18\n \x01(\x05H\x04\x88\x01\x01\x12\x1e\n\x11source_max_tokens\x18\x0b \x01(\x05H\x05\x88\x01\x01\x12\x19\n\x0c\x64\x65\x64upe_files\x18\x0c \x01(\x08H\x06\x88\x01\x01\x12\x15\n\rcontext_order\x18\r \x01(\t\x12#\n\x07history\x18\x0e \x03(\x0b\x32\x12.ollqd.v1.ChatTurnB\x0e\n\x0c_temperatureB\x08\n\x06_top_pB\r\n\x0b_max_tokensB\x08\n\x06_top_kB\x15\n\x13_context_max_tokensB\x14\n\x12_source_max_tokensB\x0f\n\r_dedupe_files\")\n\x08\x43hatTurn\x12\x0c\n\x04role\x18\x01 \x01(\t\x12\x0f\n\x07\x63ontent\x18\x02 \x01(\t\"\x80\x01\n\tChatEvent\x12\x0c\n\x04type\x18\x01 \x01(\t\x12\x0f\n\x07\x63ontent\x18\x02 \x01(\t\x12$\n\x07sources\x18\x03 \x03(\x0b\x32\x13.ollqd.v1.SearchHit\x12\x12\n\npii_masked\x18\x04 \x01(\x08\x12\x1a\n\x12pii_entities_count\x18\x05 \x01(\x05\"\x19\n\x17GetEmbeddingInfoRequest\"e\n\x15\x45mbeddingInfoResponse\x12\r\n\x05model\x18\x01 \x01(\t\x12\x11\n\tdimension\x18\x02 \x01(\x05\x12\x12\n\nlatency_ms\x18\x03 \x01(\x05\x12\x16\n\x0eprevious_model\x18\x04 \x01(\t\" \n\x10TestEmbedRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\"\x7f\n\x11TestEmbedResponse\x12\x11\n\tdimension\x18\x01 \x01(\x05\x12\x0b\n\x03min\x18\x02 \x01(\x01\x12\x0b\n\x03max\x18\x03 \x01(\x01\x12\x0c\n\x04mean\x18\x04 \x01(\x01\x12\r\n\x05stdev\x18\x05 \x01(\x01\x12\x0c\n\x04norm\x18\x06 \x01(\x01\x12\x12\n\nlatency_ms\x18\x07 \x01(\x05\"D\n\x14\x43ompareModelsRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\x12\x0e\n\x06model1\x18\x02 \x01(\t\x12\x0e\n\x06model2\x18\x03 \x01(\t\"\x9b\x01\n\x0fModelTestResult\x12\r\n\x05model\x18\x01 \x01(\t\x12\x11\n\tdimension\x18\x02 \x01(\x05\x12\x0b\n\x03min\x18\x03 \x01(\x01\x12\x0b\n\x03max\x18\x04 \x01(\x01\x12\x0c\n\x04mean\x18\x05 \x01(\x01\x12\r\n\x05stdev\x18\x06 \x01(\x01\x12\x0c\n\x04norm\x18\x07 \x01(\x01\x12\x12\n\nlatency_ms\x18\x08 \x01(\x05\x12\r\n\x05\x65rror\x18\t \x01(\t\"{\n\x15\x43ompareModelsResponse\x12)\n\x06model1\x18\x01 \x01(\x0b\x32\x19.ollqd.v1.ModelTestResult\x12)\n\x06model2\x18\x02 \x01(\x0b\x32\x19.ollqd.v1.ModelTestResult\x12\x0c\n\x04text\x18\x03 \x01(\t\"%\n\x14SetEmbedModelRequest\x12\r\n\x05model\x18\x01 \x01(\t\"\"\n\x12TestMaskingRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\",\n\tPIIEntity\x12\r\n\x05token\x18\x01 \x01(\t\x12\x10\n\x08original\x18\x02 \x01(\t\"t\n\x13TestMaskingResponse\x12\x10\n\x08original\x18\x01 \x01(\t\x12\x0e\n\x06masked\x18\x02 \x01(\t\x12%\n\x08\x65ntities\x18\x03 \x03(\x0b\x32\x13.ollqd.v1.PIIEntity\x12\x14\n\x0c\x65ntity_count\x18\x04 \x01(\x05\"\x12\n\x10GetConfigRequest\"*\n\x19UpdateMountedPathsRequest\x12\r\n\x05paths\x18\x01 \x03(\t\"3\n\x1aUpdateMountedPathsResponse\x12\x15\n\rmounted_paths\x18\x01 \x03(\t\"\xba\x01\n\x10UpdatePIIRequest\x12\x14\n\x07\x65nabled\x18\x01 \x01(\x08H\x00\x88\x01\x01\x12\x16\n\tuse_spacy\x18\x02 \x01(\x08H\x01\x88\x01\x01\x12\x1c\n\x0fmask_embeddings\x18\x03 \x01(\x08H\x02\x88\x01\x01\x12\x1a\n\renabled_types\x18\x04 \x01(\tH\x03\x88\x01\x01\x42\n\n\x08_enabledB\x0c\n\n_use_spacyB\x12\n\x10_mask_embeddingsB\x10\n\x0e_enabled_types\"\x80\x01\n\x11PIIConfigResponse\x12\x0f\n\x07\x65nabled\x18\x01 \x01(\x08\x12\x11\n\tuse_spacy\x18\x02 \x01(\x08\x12\x17\n\x0fmask_embeddings\x18\x03 \x01(\x08\x12\x15\n\renabled_types\x18\x04 \x01(\t\x12\x17\n\x0fspacy_available\x18\x05 \x01(\x08\"\xe2\x01\n\x14UpdateDoclingRequest\x12\x14\n\x07\x65nabled\x18\x01 \x01(\x08H\x00\x88\x01\x01\x12\x18\n\x0bocr_enabled\x18\x02 \x01(\x08H\x01\x88\x01\x01\x12\x17\n\nocr_engine\x18\x03 \x01(\tH\x02\x88\x01\x01\x12\x1c\n\x0ftable_structure\x18\x04 \x01(\x08H\x03\x88\x01\x01\x12\x16\n\ttimeout_s\x18\x05 \x01(\x01H\x04\x88\x01\x01\x42\n\n\x08_enabledB\x0e\n\x0c_ocr_enabledB\r\n\x0b_ocr_engineB\x12\n\x10_table_structureB\x0c\n\n_timeout_s\"\xae\x01\n\x15\x44oclingConfigResponse\x12\x0f\n\x07\x65nabled\x18\x01 \x01(\x08\x12\x13\n\x0bocr_enabled\x18\x02 \x01(\x08\x12\x12\n\nocr_engine\x18\x03 \x01(\t\x12\x17\n\x0ftable_structure\x18\x04 \x01(\x08\x12\x11\n\ttimeout_s\x18\x05 \x01(\x01\x12\x11\n\tavailable\x18\x06 \x01(\x08\x12\x1c\n\x14supported_extensions\x18\x07 \x03(\t\")\n\x15UpdateDistanceRequest\x12\x10\n\x08\x64istance\x18\x01 \x01(\t\"<\n\x16UpdateDistanceResponse\x12\x10\n\x08\x64istance\x18\x01 \x01(\t\x12\x10\n\x08previous\x18\x02 \x01(\t\"\xfb\x01\n\x13UpdateOllamaRequest\x12\x15\n\x08\x62\x61se_url\x18\x01 \x01(\tH\x00\x88\x01\x01\x12\x17\n\nchat_model\x18\x02 \x01(\tH\x01\x88\x01\x01\x12\x18\n\x0b\x65mbed_model\x18\x03 \x01(\tH\x02\x88\x01\x01\x12\x19\n\x0cvision_model\x18\x04 \x01(\tH\x03\x88\x01\x01\x12\x16\n\ttimeout_s\x18\x05 \x01(\x01H\x04\x88\x01\x01\x12\x12\n\x05local\x18\x06 \x01(\x08H\x05\x88\x01\x01\x42\x0b\n\t_base_urlB\r\n\x0b_chat_modelB\x0e\n\x0c_embed_modelB\x0f\n\r_vision_modelB\x0c\n\n_timeout_sB\x08\n\x06_local\"\x89\x01\n\x14OllamaConfigResponse\x12\x10\n\x08\x62\x61se_url\x18\x01 \x01(\t\x12\x12\n\nchat_model\x18\x02 \x01(\t\x12\x13\n\x0b\x65mbed_model\x18\x03 \x01(\t\x12\x14\n\x0cvision_model\x18\x04 \x01(\t\x12\x11\n\ttimeout_s\x18\x05 \x01(\x01\x12\r\n\x05local\x18\x06 \x01(\x08\"\x9b\x01\n\x13UpdateQdrantRequest\x12\x10\n\x03url\x18\x01 \x01(\tH\x00\x88\x01\x01\x12\x1f\n\x12\x64\x65\x66\x61ult_collection\x18\x02 \x01(\tH\x01\x88\x01\x01\x12\x1d\n\x10\x64\x65\x66\x61ult_distance\x18\x03 \x01(\tH\x02\x88\x01\x01\x42\x06\n\x04_urlB\x15\n\x13_default_collectionB\x13\n\x11_default_distance\"Y\n\x14QdrantConfigResponse\x12\x0b\n\x03url\x18\x01 \x01(\t\x12\x1a\n\x12\x64\x65\x66\x61ult_collection\x18\x02 \x01(\t\x12\x18\n\x10\x64\x65\x66\x61ult_distance\x18\x03 \x01(\t\"\xa1\x01\n\x15UpdateChunkingRequest\x12\x17\n\nchunk_size\x18\x01 \x01(\x05H\x00\x88\x01\x01\x12\x1a\n\rchunk_overlap\x18\x02 \x01(\x05H\x01\x88\x01\x01\x12\x1d\n\x10max_file_size_kb\x18\x03 \x01(\x05H\x02\x88\x01\x01\x42\r\n\x0b_chunk_sizeB\x10\n\x0e_chunk_overlapB\x13\n\x11_max_file_size_kb\"]\n\x16\x43hunkingConfigResponse\x12\x12\n\nchunk_size\x18\x01 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x02 \x01(\x05\x12\x18\n\x10max_file_size_kb\x18\x03 \x01(\x05\"z\n\x12UpdateImageRequest\x12\x1e\n\x11max_image_size_kb\x18\x01 \x01(\x05H\x00\x88\x01\x01\x12\x1b\n\x0e\x63\x61ption_prompt\x18\x02 \x01(\tH\x01\x88\x01\x01\x42\x14\n\x12_max_image_size_kbB\x11\n\x0f_caption_prompt\"H\n\x13ImageConfigResponse\x12\x19\n\x11max_image_size_kb\x18\x01 \x01(\x05\x12\x16\n\x0e\x63\x61ption_prompt\x18\x02 \x01(\t\"\x15\n\x13GetPIIConfigRequest\"\x19\n\x17GetDoclingConfigRequest\"3\n\x12ResetConfigRequest\x12\x0f\n\x07section\x18\x01 \x01(\t\x12\x0c\n\x04keys\x18\x02 \x03(\t\":\n\x13ResetConfigResponse\x12\x0f\n\x07section\x18\x01 \x01(\t\x12\x12\n\nreset_keys\x18\x02 \x03(\t\"4\n\x0fOverviewRequest\x12\x12\n\ncollection\x18\x01 \x01(\t\x12\r\n\x05limit\x18\x02 \x01(\x05\"\xa3\x01\n\x07VisNode\x12\n\n\x02id\x18\x01 \x01(\x05\x12\r\n\x05label\x18\x02 \x01(\t\x12\r\n\x05title\x18\x03 \x01(\t\x12\r\n\x05\x63olor\x18\x04 \x01(\t\x12\x0c\n\x04size\x18\x05 \x01(\x05\x12\r\n\x05shape\x18\x06 \x01(\t\x12\x11\n\tfile_path\x18\x07 \x01(\t\x12\x10\n\x08language\x18\x08 \x01(\t\x12\x0e\n\x06\x63hunks\x18\t \x01(\x05\x12\r\n\x05level\x18\n \x01(\x05\"#\n\x07VisEdge\x12\x0c\n\x04\x66rom\x18\x01 \x01(\x05\x12\n\n\x02to\x18\x02 \x01(\x05\"N\n\rOverviewStats\x12\x13\n\x0btotal_files\x18\x01 \x01(\x05\x12\x14\n\x0ctotal_chunks\x18\x02 \x01(\x05\x12\x12\n\ncollection\x18\x03 \x01(\t\"~\n\x10OverviewResponse\x12 \n\x05nodes\x18\x01 \x03(\x0b\x32\x11.ollqd.v1.VisNode\x12 \n\x05\x65\x64ges\x18\x02 \x03(\x0b\x32\x11.ollqd.v1.VisEdge\x12&\n\x05stats\x18\x03 \x01(\x0b\x32\x17.ollqd.v1.OverviewStats\"8\n\x0f\x46ileTreeRequest\x12\x12\n\ncollection\x18\x01 \x01(\t\x12\x11\n\tfile_path\x18\x02 \x01(\t\"\x7f\n\x10\x46ileTreeResponse\x12 \n\x05nodes\x18\x01 \x03(\x0b\x32\x11.ollqd.v1.VisNode\x12 \n\x05\x65\x64ges\x18\x02 \x03(\x0b\x32\x11.ollqd.v1.VisEdge\x12\x11\n\tfile_path\x18\x03 \x01(\t\x12\x14\n\x0ctotal_chunks\x18\x04 \x01(\x05\"Q\n\x0eVectorsRequest\x12\x12\n\ncollection\x18\x01 \x01(\t\x12\x0e\n\x06method\x18\x02 \x01(\t\x12\x0c\n\x04\x64ims\x18\x03 \x01(\x05\x12\r\n\x05limit\x18\x04 \x01(\x05\"l\n\x0bVectorPoint\x12\t\n\x01x\x18\x01 \x01(\x01\x12\t\n\x01y\x18\x02 \x01(\x01\x12\t\n\x01z\x18\x03 \x01(\x01\x12\x0c\n\x04\x66ile\x18\x04 \x01(\t\x12\x10\n\x08language\x18\x05 \x01(\t\x12\r\n\x05\x63hunk\x18\x06 \x01(\x05\x12\r\n\x05\x63olor\x18\x07 \x01(\t\"\x83\x01\n\x0fVectorsResponse\x12%\n\x06points\x18\x01 \x03(\x0b\x32\x15.ollqd.v1.VectorPoint\x12\x0e\n\x06method\x18\x02 \x01(\t\x12\x0c\n\x04\x64ims\x18\x03 \x01(\x05\x12\x15\n\roriginal_dims\x18\x04 \x01(\x05\x12\x14\n\x0ctotal_points\x18\x05 \x01(\x05\"\xab\x01\n\x0eSMBTestRequest\x12\x0e\n\x06server\x18\x01 \x01(\t\x12\r\n\x05share\x18\x02 \x01(\t\x12\x10\n\x08username\x18\x03 \x01(\t\x12\x10\n\x08password\x18\x04 \x01(\t\x12\x0e\n\x06\x64omain\x18\x05 \x01(\t\x12\x0c\n\x04port\x18\x06 \x01(\x05\x12\x0c\n\x04\x61uth\x18\x07 \x01(\t\x12\r\n\x05realm\x18\x08 \x01(\t\x12\x0b\n\x03kdc\x18\t \x01(\t\x12\x0e\n\x06keytab\x18\n \x01(\x0c\".\n\x0fSMBTestResponse\x12\n\n\x02ok\x18\x01 \x01(\x08\x12\x0f\n\x07message\x18\x02 \x01(\t\"\xbb\x01\n\x10SMBBrowseRequest\x12\x0e\n\x06server\x18\x01 \x01(\t\x12\r\n\x05share\x18\x02 \x01(\t\x12\x10\n\x08username\x18\x03 \x01(\t\x12\x10\n\x08password\x18\x04 \x01(\t\x12\x0e\n\x06\x64omain\x18\x05 \x01(\t\x12\x0c\n\x04port\x18\x06 \x01(\x05\x12\x0c\n\x04path\x18\x07 \x01(\t\x12\x0c\n\x04\x61uth\x18\x08 \x01(\t\x12\r\n\x05realm\x18\t \x01(\t\x12\x0b\n\x03kdc\x18\n \x01(\t\x12\x0e\n\x06keytab\x18\x0b \x01(\x0c\"H\n\x0cSMBFileEntry\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x0e\n\x06is_dir\x18\x02 \x01(\x08\x12\x0c\n\x04size\x18\x03 \x01(\x03\x12\x0c\n\x04path\x18\x04 \x01(\t\"H\n\x11SMBBrowseResponse\x12%\n\x05\x66iles\x18\x01 \x03(\x0b\x32\x16.ollqd.v1.SMBFileEntry\x12\x0c\n\x04path\x18\x02 \x01(\t\"2\n\x0cLoginRequest\x12\x10\n\x08username\x18\x01 \x01(\t\x12\x10\n\x08password\x18\x02 \x01(\t\"O\n\rLoginResponse\x12\x0f\n\x07success\x18\x01 \x01(\x08\x12\r\n\x05\x65rror\x18\x02 \x01(\t\x12\x10\n\x08username\x18\x03 \x01(\t\x12\x0c\n\x04role\x18\x04 \x01(\t\"%\n\x14ValidateTokenRequest\x12\r\n\x05token\x18\x01 \x01(\t\"F\n\x15ValidateTokenResponse\x12\r\n\x05valid\x18\x01 \x01(\x08\x12\x10\n\x08username\x18\x02 \x01(\t\x12\x0c\n\x04role\x18\x03 \x01(\t\"\x12\n\x10ListUsersRequest\"2\n\x11ListUsersResponse\x12\x1d\n\x05users\x18\x01 \x03(\x0b\x32\x0e.ollqd.v1.User\"E\n\x11\x43reateUserRequest\x12\x10\n\x08username\x18\x01 \x01(\t\x12\x10\n\x08password\x18\x02 \x01(\t\x12\x0c\n\x04role\x18\x03 \x01(\t\"2\n\x12\x43reateUserResponse\x12\x1c\n\x04user\x18\x01 \x01(\x0b\x32\x0e.ollqd.v1.User\"%\n\x11\x44\x65leteUserRequest\x12\x10\n\x08username\x18\x01 \x01(\t\"4\n\x12\x44\x65leteUserResponse\x12\x0f\n\x07\x64\x65leted\x18\x01 \x01(\x08\x12\r\n\x05\x65rror\x18\x02 \x01(\t2\xcd\x03\n\x0fIndexingService\x12I\n\rIndexCodebase\x12\x1e.ollqd.v1.IndexCodebaseRequest\x1a\x16.ollqd.v1.TaskProgress0\x01\x12K\n\x0eIndexDocuments\x12\x1f.ollqd.v1.IndexDocumentsRequest\x1a\x16.ollqd.v1.TaskProgress0\x01\x12\x45\n\x0bIndexImages\x12\x1c.ollqd.v1.IndexImagesRequest\x1a\x16.ollqd.v1.TaskProgress0\x01\x12G\n\x0cIndexUploads\x12\x1d.ollqd.v1.IndexUploadsRequest\x1a\x16.ollqd.v1.TaskProgress0\x01\x12I\n\rIndexSMBFiles\x12\x1e.ollqd.v1.IndexSMBFilesRequest\x1a\x16.ollqd.v1.TaskProgress0\x01\x12G\n\nCancelTask\x12\x1b.ollqd.v1.CancelTaskRequest\x1a\x1c.ollqd.v1.CancelTaskResponse2\x9d\x01\n\rSearchService\x12;\n\x06Search\x12\x17.ollqd.v1.SearchRequest\x1a\x18.ollqd.v1.SearchResponse\x12O\n\x10SearchCollection\x12!.ollqd.v1.SearchCollectionRequest\x1a\x18.ollqd.v1.SearchResponse2C\n\x0b\x43hatService\x12\x34\n\x04\x43hat\x12\x15.ollqd.v1.ChatRequest\x1a\x13.ollqd.v1.ChatEvent0\x01\x32\xc6\x02\n\x10\x45mbeddingService\x12M\n\x07GetInfo\x12!.ollqd.v1.GetEmbeddingInfoRequest\x1a\x1f.ollqd.v1.EmbeddingInfoResponse\x12\x44\n\tTestEmbed\x12\x1a.ollqd.v1.TestEmbedRequest\x1a\x1b.ollqd.v1.TestEmbedResponse\x12P\n\rCompareModels\x12\x1e.ollqd.v1.CompareModelsRequest\x1a\x1f.ollqd.v1.CompareModelsResponse\x12K\n\x08SetModel\x12\x1e.ollqd.v1.SetEmbedModelRequest\x1a\x1f.ollqd.v1.EmbeddingInfoResponse2X\n\nPIIService\x12J\n\x0bTestMasking\x12\x1c.ollqd.v1.TestMaskingRequest\x1a\x1d.ollqd.v1.TestMaskingResponse2\xca\x07\n\rConfigService\x12<\n\tGetConfig\x12\x1a.ollqd.v1.GetConfigRequest\x1a\x13.ollqd.v1.AppConfig\x12_\n\x12UpdateMountedPaths\x12#.ollqd.v1.UpdateMountedPathsRequest\x1a$.ollqd.v1.UpdateMountedPathsResponse\x12\x44\n\tUpdatePII\x12\x1a.ollqd.v1.UpdatePIIRequest\x1a\x1b.ollqd.v1.PIIConfigResponse\x12P\n\rUpdateDocling\x12\x1e.ollqd.v1.UpdateDoclingRequest\x1a\x1f.ollqd.v1.DoclingConfigResponse\x12S\n\x0eUpdateDistance\x12\x1f.ollqd.v1.UpdateDistanceRequest\x1a .ollqd.v1.UpdateDistanceResponse\x12M\n\x0cUpdateOllama\x12\x1d.ollqd.v1.UpdateOllamaRequest\x1a\x1e.ollqd.v1.OllamaConfigResponse\x12M\n\x0cUpdateQdrant\x12\x1d.ollqd.v1.UpdateQdrantRequest\x1a\x1e.ollqd.v1.QdrantConfigResponse\x12S\n\x0eUpdateChunking\x12\x1f.ollqd.v1.UpdateChunkingRequest\x1a .ollqd.v1.ChunkingConfigResponse\x12J\n\x0bUpdateImage\x12\x1c.ollqd.v1.UpdateImageRequest\x1a\x1d.ollqd.v1.ImageConfigResponse\x12J\n\x0cGetPIIConfig\x12\x1d.ollqd.v1.GetPIIConfigRequest\x1a\x1b.ollqd.v1.PIIConfigResponse\x12V\n\x10GetDoclingConfig\x12!.ollqd.v1.GetDoclingConfigRequest\x1a\x1f.ollqd.v1.DoclingConfigResponse\x12J\n\x0bResetConfig\x12\x1c.ollqd.v1.ResetConfigRequest\x1a\x1d.ollqd.v1.ResetConfigResponse2\xdc\x01\n\x14VisualizationService\x12\x41\n\x08Overview\x12\x19.ollqd.v1.OverviewRequest\x1a\x1a.ollqd.v1.OverviewResponse\x12\x41\n\x08\x46ileTree\x12\x19.ollqd.v1.FileTreeRequest\x1a\x1a.ollqd.v1.FileTreeResponse\x12>\n\x07Vectors\x12\x18.ollqd.v1.VectorsRequest\x1a\x19.ollqd.v1.VectorsResponse2\x96\x01\n\nSMBService\x12\x45\n\x0eTestConnection\x12\x18.ollqd.v1.SMBTestRequest\x1a\x19.ollqd.v1.SMBTestResponse\x12\x41\n\x06\x42rowse\x12\x1a.ollqd.v1.SMBBrowseRequest\x1a\x1b.ollqd.v1.SMBBrowseResponse2\xf1\x02\n\x0b\x41uthService\x12\x38\n\x05Login\x12\x16.ollqd.v1.LoginRequest\x1a\x17.ollqd.v1.LoginResponse\x12P\n\rValidateToken\x12\x1e.ollqd.v1.ValidateTokenRequest\x1a\x1f.ollqd.v1.ValidateTokenResponse\x12\x44\n\tListUsers\x12\x1a.ollqd.v1.ListUsersRequest\x1a\x1b.ollqd.v1.ListUsersResponse\x12G\n\nCreateUser\x12\x1b.ollqd.v1.CreateUserRequest\x1a\x1c.ollqd.v1.CreateUserResponse\x12G\n\nDeleteUser\x12\x1b.ollqd.v1.DeleteUserRequest\x1a\x1c.ollqd.v1.DeleteUserResponseB9Z7github.com/alfagnish/ollqd-gateway/gen/ollqd/v1;ollqdv1b\x06proto3')
 
 _globals = globals()
 _builder.BuildMessageAndEnumDescriptors(DESCRIPTOR, _globals)
@@ -33,160 +33,168 @@ _builder.BuildTopDescriptorsAndMessages(DESCRIPTOR, 'ollqd.v1.processing_pb2', _
 if not _descriptor._USE_C_DESCRIPTORS:
   _globals['DESCRIPTOR']._loaded_options = None
   _globals['DESCRIPTOR']._serialized_options = b'Z7github.com/alfagnish/ollqd-gateway/gen/ollqd/v1;ollqdv1'
+  _globals['_PROVENANCE_SOURCESENTRY']._loaded_options = None
+  _globals['_PROVENANCE_SOURCESENTRY']._serialized_options = b'8\001'
   _globals['_INDEXCODEBASEREQUEST']._serialized_start=62
-  _globals['_INDEXCODEBASEREQUEST']._serialized_end=212
-  _globals['_INDEXDOCUMENTSREQUEST']._serialized_start=214
-  _globals['_INDEXDOCUMENTSREQUEST']._serialized_end=335
-  _globals['_INDEXIMAGESREQUEST']._serialized_start=338
-  _globals['_INDEXIMAGESREQUEST']._serialized_end=516
-  _globals['_INDEXUPLOADSREQUEST']._serialized_start=519
-  _globals['_INDEXUPLOADSREQUEST']._serialized_end=690
-  _globals['_INDEXSMBFILESREQUEST']._serialized_start=693
-  _globals['_INDEXSMBFILESREQUEST']._serialized_end=935
-  _globals['_CANCELTASKREQUEST']._serialized_start=937
-  _globals['_CANCELTASKREQUEST']._serialized_end=973
-  _globals['_CANCELTASKRESPONSE']._serialized_start=975
-  _globals['_CANCELTASKRESPONSE']._serialized_end=1031
-  _globals['_SEARCHREQUEST']._serialized_start=1033
-  _globals['_SEARCHREQUEST']._serialized_end=1115
-  _globals['_SEARCHCOLLECTIONREQUEST']._serialized_start=1117
-  _globals['_SEARCHCOLLECTIONREQUEST']._serialized_end=1229
-  _globals['_SEARCHRESPONSE']._serialized_start=1231
-  _globals['_SEARCHRESPONSE']._serialized_end=1336
-  _globals['_CHATREQUEST']._serialized_start=1338
-  _globals['_CHATREQUEST']._serialized_end=1424
-  _globals['_CHATEVENT']._serialized_start=1427
-  _globals['_CHATEVENT']._serialized_end=1555
-  _globals['_GETEMBEDDINGINFOREQUEST']._serialized_start=1557
-  _globals['_GETEMBEDDINGINFOREQUEST']._serialized_end=1582
-  _globals['_EMBEDDINGINFORESPONSE']._serialized_start=1584
-  _globals['_EMBEDDINGINFORESPONSE']._serialized_end=1685
-  _globals['_TESTEMBEDREQUEST']._serialized_start=1687
-  _globals['_TESTEMBEDREQUEST']._serialized_end=1719
-  _globals['_TESTEMBEDRESPONSE']._serialized_start=1721
-  _globals['_TESTEMBEDRESPONSE']._serialized_end=1848
-  _globals['_COMPAREMODELSREQUEST']._serialized_start=1850
-  _globals['_COMPAREMODELSREQUEST']._serialized_end=1918
-  _globals['_MODELTESTRESULT']._serialized_start=1921
-  _globals['_MODELTESTRESULT']._serialized_end=2076
-  _globals['_COMPAREMODELSRESPONSE']._serialized_start=2078
-  _globals['_COMPAREMODELSRESPONSE']._serialized_end=2201
-  _globals['_SETEMBEDMODELREQUEST']._serialized_start=2203
-  _globals['_SETEMBEDMODELREQUEST']._serialized_end=2240
-  _globals['_TESTMASKINGREQUEST']._serialized_start=2242
-  _globals['_TESTMASKINGREQUEST']._serialized_end=2276
-  _globals['_PIIENTITY']._serialized_start=2278
-  _globals['_PIIENTITY']._serialized_end=2322
-  _globals['_TESTMASKINGRESPONSE']._serialized_start=2324
-  _globals['_TESTMASKINGRESPONSE']._serialized_end=2440
-  _globals['_GETCONFIGREQUEST']._serialized_start=2442
-  _globals['_GETCONFIGREQUEST']._serialized_end=2460
-  _globals['_UPDATEMOUNTEDPATHSREQUEST']._serialized_start=2462
-  _globals['_UPDATEMOUNTEDPATHSREQUEST']._serialized_end=2504
-  _globals['_UPDATEMOUNTEDPATHSRESPONSE']._serialized_start=2506
-  _globals['_UPDATEMOUNTEDPATHSRESPONSE']._serialized_end=2557
-  _globals['_UPDATEPIIREQUEST']._serialized_start=2560
-  _globals['_UPDATEPIIREQUEST']._serialized_end=2746
-  _globals['_PIICONFIGRESPONSE']._serialized_start=2749
-  _globals['_PIICONFIGRESPONSE']._serialized_end=2877
-  _globals['_UPDATEDOCLINGREQUEST']._serialized_start=2880
-  _globals['_UPDATEDOCLINGREQUEST']._serialized_end=3106
-  _globals['_DOCLINGCONFIGRESPONSE']._serialized_start=3109
-  _globals['_DOCLINGCONFIGRESPONSE']._serialized_end=3283
-  _globals['_UPDATEDISTANCEREQUEST']._serialized_start=3285
-  _globals['_UPDATEDISTANCEREQUEST']._serialized_end=3326
-  _globals['_UPDATEDISTANCERESPONSE']._serialized_start=3328
-  _globals['_UPDATEDISTANCERESPONSE']._serialized_end=3388
-  _globals['_UPDATEOLLAMAREQUEST']._serialized_start=3391
-  _globals['_UPDATEOLLAMAREQUEST']._serialized_end=3642
-  _globals['_OLLAMACONFIGRESPONSE']._serialized_start=3645
-  _globals['_OLLAMACONFIGRESPONSE']._serialized_end=3782
-  _globals['_UPDATEQDRANTREQUEST']._serialized_start=3785
-  _globals['_UPDATEQDRANTREQUEST']._serialized_end=3940
-  _globals['_QDRANTCONFIGRESPONSE']._serialized_start=3942
-  _globals['_QDRANTCONFIGRESPONSE']._serialized_end=4031
-  _globals['_UPDATECHUNKINGREQUEST']._serialized_start=4034
-  _globals['_UPDATECHUNKINGREQUEST']._serialized_end=4195
-  _globals['_CHUNKINGCONFIGRESPONSE']._serialized_start=4197
-  _globals['_CHUNKINGCONFIGRESPONSE']._serialized_end=4290
-  _globals['_UPDATEIMAGEREQUEST']._serialized_start=4292
-  _globals['_UPDATEIMAGEREQUEST']._serialized_end=4414
-  _globals['_IMAGECONFIGRESPONSE']._serialized_start=4416
-  _globals['_IMAGECONFIGRESPONSE']._serialized_end=4488
-  _globals['_GETPIICONFIGREQUEST']._serialized_start=4490
-  _globals['_GETPIICONFIGREQUEST']._serialized_end=4511
-  _globals['_GETDOCLINGCONFIGREQUEST']._serialized_start=4513
-  _globals['_GETDOCLINGCONFIGREQUEST']._serialized_end=4538
-  _globals['_RESETCONFIGREQUEST']._serialized_start=4540
-  _globals['_RESETCONFIGREQUEST']._serialized_end=4591
-  _globals['_RESETCONFIGRESPONSE']._serialized_start=4593
-  _globals['_RESETCONFIGRESPONSE']._serialized_end=4651
-  _globals['_OVERVIEWREQUEST']._serialized_start=4653
-  _globals['_OVERVIEWREQUEST']._serialized_end=4705
-  _globals['_VISNODE']._serialized_start=4708
-  _globals['_VISNODE']._serialized_end=4871
-  _globals['_VISEDGE']._serialized_start=4873
-  _globals['_VISEDGE']._serialized_end=4908
-  _globals['_OVERVIEWSTATS']._serialized_start=4910
-  _globals['_OVERVIEWSTATS']._serialized_end=4988
-  _globals['_OVERVIEWRESPONSE']._serialized_start=4990
-  _globals['_OVERVIEWRESPONSE']._serialized_end=5116
-  _globals['_FILETREEREQUEST']._serialized_start=5118
-  _globals['_FILETREEREQUEST']._serialized_end=5174
-  _globals['_FILETREERESPONSE']._serialized_start=5176
-  _globals['_FILETREERESPONSE']._serialized_end=5303
-  _globals['_VECTORSREQUEST']._serialized_start=5305
-  _globals['_VECTORSREQUEST']._serialized_end=5386
-  _globals['_VECTORPOINT']._serialized_start=5388
-  _globals['_VECTORPOINT']._serialized_end=5496
-  _globals['_VECTORSRESPONSE']._serialized_start=5499
-  _globals['_VECTORSRESPONSE']._serialized_end=5630
-  _globals['_SMBTESTREQUEST']._serialized_start=5632
-  _globals['_SMBTESTREQUEST']._serialized_end=5745
-  _globals['_SMBTESTRESPONSE']._serialized_start=5747
-  _globals['_SMBTESTRESPONSE']._serialized_end=5793
-  _globals['_SMBBROWSEREQUEST']._serialized_start=5796
-  _globals['_SMBBROWSEREQUEST']._serialized_end=5925
-  _globals['_SMBFILEENTRY']._serialized_start=5927
-  _globals['_SMBFILEENTRY']._serialized_end=5999
-  _globals['_SMBBROWSERESPONSE']._serialized_start=6001
-  _globals['_SMBBROWSERESPONSE']._serialized_end=6073
-  _globals['_LOGINREQUEST']._serialized_start=6075
-  _globals['_LOGINREQUEST']._serialized_end=6125
-  _globals['_LOGINRESPONSE']._serialized_start=6127
-  _globals['_LOGINRESPONSE']._serialized_end=6206
-  _globals['_VALIDATETOKENREQUEST']._serialized_start=6208
-  _globals['_VALIDATETOKENREQUEST']._serialized_end=6245
-  _globals['_VALIDATETOKENRESPONSE']._serialized_start=6247
-  _globals['_VALIDATETOKENRESPONSE']._serialized_end=6317
-  _globals['_LISTUSERSREQUEST']._serialized_start=6319
-  _globals['_LISTUSERSREQUEST']._serialized_end=6337
-  _globals['_LISTUSERSRESPONSE']._serialized_start=6339
-  _globals['_LISTUSERSRESPONSE']._serialized_end=6389
-  _globals['_CREATEUSERREQUEST']._serialized_start=6391
-  _globals['_CREATEUSERREQUEST']._serialized_end=6460
-  _globals['_CREATEUSERRESPONSE']._serialized_start=6462
-  _globals['_CREATEUSERRESPONSE']._serialized_end=6512
-  _globals['_DELETEUSERREQUEST']._serialized_start=6514
-  _globals['_DELETEUSERREQUEST']._serialized_end=6551
-  _globals['_DELETEUSERRESPONSE']._serialized_start=6553
-  _globals['_DELETEUSERRESPONSE']._serialized_end=6605
-  _globals['_INDEXINGSERVICE']._serialized_start=6608
-  _globals['_INDEXINGSERVICE']._serialized_end=7069
-  _globals['_SEARCHSERVICE']._serialized_start=7072
-  _globals['_SEARCHSERVICE']._serialized_end=7229
-  _globals['_CHATSERVICE']._serialized_start=7231
-  _globals['_CHATSERVICE']._serialized_end=7298
-  _globals['_EMBEDDINGSERVICE']._serialized_start=7301
-  _globals['_EMBEDDINGSERVICE']._serialized_end=7627
-  _globals['_PIISERVICE']._serialized_start=7629
-  _globals['_PIISERVICE']._serialized_end=7717
-  _globals['_CONFIGSERVICE']._serialized_start=7720
-  _globals['_CONFIGSERVICE']._serialized_end=8690
-  _globals['_VISUALIZATIONSERVICE']._serialized_start=8693
-  _globals['_VISUALIZATIONSERVICE']._serialized_end=8913
-  _globals['_SMBSERVICE']._serialized_start=8916
-  _globals['_SMBSERVICE']._serialized_end=9066
-  _globals['_AUTHSERVICE']._serialized_start=9069
-  _globals['_AUTHSERVICE']._serialized_end=9438
+  _globals['_INDEXCODEBASEREQUEST']._serialized_end=269
+  _globals['_INDEXDOCUMENTSREQUEST']._serialized_start=272
+  _globals['_INDEXDOCUMENTSREQUEST']._serialized_end=435
+  _globals['_INDEXIMAGESREQUEST']._serialized_start=438
+  _globals['_INDEXIMAGESREQUEST']._serialized_end=658
+  _globals['_INDEXUPLOADSREQUEST']._serialized_start=661
+  _globals['_INDEXUPLOADSREQUEST']._serialized_end=874
+  _globals['_INDEXSMBFILESREQUEST']._serialized_start=877
+  _globals['_INDEXSMBFILESREQUEST']._serialized_end=1219
+  _globals['_PROVENANCE']._serialized_start=1222
+  _globals['_PROVENANCE']._serialized_end=1469
+  _globals['_PROVENANCE_SOURCESENTRY']._serialized_start=1423
+  _globals['_PROVENANCE_SOURCESENTRY']._serialized_end=1469
+  _globals['_CANCELTASKREQUEST']._serialized_start=1471
+  _globals['_CANCELTASKREQUEST']._serialized_end=1507
+  _globals['_CANCELTASKRESPONSE']._serialized_start=1509
+  _globals['_CANCELTASKRESPONSE']._serialized_end=1565
+  _globals['_SEARCHREQUEST']._serialized_start=1567
+  _globals['_SEARCHREQUEST']._serialized_end=1649
+  _globals['_SEARCHCOLLECTIONREQUEST']._serialized_start=1651
+  _globals['_SEARCHCOLLECTIONREQUEST']._serialized_end=1763
+  _globals['_SEARCHRESPONSE']._serialized_start=1765
+  _globals['_SEARCHRESPONSE']._serialized_end=1870
+  _globals['_CHATREQUEST']._serialized_start=1873
+  _globals['_CHATREQUEST']._serialized_end=2338
+  _globals['_CHATTURN']._serialized_start=2340
+  _globals['_CHATTURN']._serialized_end=2381
+  _globals['_CHATEVENT']._serialized_start=2384
+  _globals['_CHATEVENT']._serialized_end=2512
+  _globals['_GETEMBEDDINGINFOREQUEST']._serialized_start=2514
+  _globals['_GETEMBEDDINGINFOREQUEST']._serialized_end=2539
+  _globals['_EMBEDDINGINFORESPONSE']._serialized_start=2541
+  _globals['_EMBEDDINGINFORESPONSE']._serialized_end=2642
+  _globals['_TESTEMBEDREQUEST']._serialized_start=2644
+  _globals['_TESTEMBEDREQUEST']._serialized_end=2676
+  _globals['_TESTEMBEDRESPONSE']._serialized_start=2678
+  _globals['_TESTEMBEDRESPONSE']._serialized_end=2805
+  _globals['_COMPAREMODELSREQUEST']._serialized_start=2807
+  _globals['_COMPAREMODELSREQUEST']._serialized_end=2875
+  _globals['_MODELTESTRESULT']._serialized_start=2878
+  _globals['_MODELTESTRESULT']._serialized_end=3033
+  _globals['_COMPAREMODELSRESPONSE']._serialized_start=3035
+  _globals['_COMPAREMODELSRESPONSE']._serialized_end=3158
+  _globals['_SETEMBEDMODELREQUEST']._serialized_start=3160
+  _globals['_SETEMBEDMODELREQUEST']._serialized_end=3197
+  _globals['_TESTMASKINGREQUEST']._serialized_start=3199
+  _globals['_TESTMASKINGREQUEST']._serialized_end=3233
+  _globals['_PIIENTITY']._serialized_start=3235
+  _globals['_PIIENTITY']._serialized_end=3279
+  _globals['_TESTMASKINGRESPONSE']._serialized_start=3281
+  _globals['_TESTMASKINGRESPONSE']._serialized_end=3397
+  _globals['_GETCONFIGREQUEST']._serialized_start=3399
+  _globals['_GETCONFIGREQUEST']._serialized_end=3417
+  _globals['_UPDATEMOUNTEDPATHSREQUEST']._serialized_start=3419
+  _globals['_UPDATEMOUNTEDPATHSREQUEST']._serialized_end=3461
+  _globals['_UPDATEMOUNTEDPATHSRESPONSE']._serialized_start=3463
+  _globals['_UPDATEMOUNTEDPATHSRESPONSE']._serialized_end=3514
+  _globals['_UPDATEPIIREQUEST']._serialized_start=3517
+  _globals['_UPDATEPIIREQUEST']._serialized_end=3703
+  _globals['_PIICONFIGRESPONSE']._serialized_start=3706
+  _globals['_PIICONFIGRESPONSE']._serialized_end=3834
+  _globals['_UPDATEDOCLINGREQUEST']._serialized_start=3837
+  _globals['_UPDATEDOCLINGREQUEST']._serialized_end=4063
+  _globals['_DOCLINGCONFIGRESPONSE']._serialized_start=4066
+  _globals['_DOCLINGCONFIGRESPONSE']._serialized_end=4240
+  _globals['_UPDATEDISTANCEREQUEST']._serialized_start=4242
+  _globals['_UPDATEDISTANCEREQUEST']._serialized_end=4283
+  _globals['_UPDATEDISTANCERESPONSE']._serialized_start=4285
+  _globals['_UPDATEDISTANCERESPONSE']._serialized_end=4345
+  _globals['_UPDATEOLLAMAREQUEST']._serialized_start=4348
+  _globals['_UPDATEOLLAMAREQUEST']._serialized_end=4599
+  _globals['_OLLAMACONFIGRESPONSE']._serialized_start=4602
+  _globals['_OLLAMACONFIGRESPONSE']._serialized_end=4739
+  _globals['_UPDATEQDRANTREQUEST']._serialized_start=4742
+  _globals['_UPDATEQDRANTREQUEST']._serialized_end=4897
+  _globals['_QDRANTCONFIGRESPONSE']._serialized_start=4899
+  _globals['_QDRANTCONFIGRESPONSE']._serialized_end=4988
+  _globals['_UPDATECHUNKINGREQUEST']._serialized_start=4991
+  _globals['_UPDATECHUNKINGREQUEST']._serialized_end=5152
+  _globals['_CHUNKINGCONFIGRESPONSE']._serialized_start=5154
+  _globals['_CHUNKINGCONFIGRESPONSE']._serialized_end=5247
+  _globals['_UPDATEIMAGEREQUEST']._serialized_start=5249
+  _globals['_UPDATEIMAGEREQUEST']._serialized_end=5371
+  _globals['_IMAGECONFIGRESPONSE']._serialized_start=5373
+  _globals['_IMAGECONFIGRESPONSE']._serialized_end=5445
+  _globals['_GETPIICONFIGREQUEST']._serialized_start=5447
+  _globals['_GETPIICONFIGREQUEST']._serialized_end=5468
+  _globals['_GETDOCLINGCONFIGREQUEST']._serialized_start=5470
+  _globals['_GETDOCLINGCONFIGREQUEST']._serialized_end=5495
+  _globals['_RESETCONFIGREQUEST']._serialized_start=5497
+  _globals['_RESETCONFIGREQUEST']._serialized_end=5548
+  _globals['_RESETCONFIGRESPONSE']._serialized_start=5550
+  _globals['_RESETCONFIGRESPONSE']._serialized_end=5608
+  _globals['_OVERVIEWREQUEST']._serialized_start=5610
+  _globals['_OVERVIEWREQUEST']._serialized_end=5662
+  _globals['_VISNODE']._serialized_start=5665
+  _globals['_VISNODE']._serialized_end=5828
+  _globals['_VISEDGE']._serialized_start=5830
+  _globals['_VISEDGE']._serialized_end=5865
+  _globals['_OVERVIEWSTATS']._serialized_start=5867
+  _globals['_OVERVIEWSTATS']._serialized_end=5945
+  _globals['_OVERVIEWRESPONSE']._serialized_start=5947
+  _globals['_OVERVIEWRESPONSE']._serialized_end=6073
+  _globals['_FILETREEREQUEST']._serialized_start=6075
+  _globals['_FILETREEREQUEST']._serialized_end=6131
+  _globals['_FILETREERESPONSE']._serialized_start=6133
+  _globals['_FILETREERESPONSE']._serialized_end=6260
+  _globals['_VECTORSREQUEST']._serialized_start=6262
+  _globals['_VECTORSREQUEST']._serialized_end=6343
+  _globals['_VECTORPOINT']._serialized_start=6345
+  _globals['_VECTORPOINT']._serialized_end=6453
+  _globals['_VECTORSRESPONSE']._serialized_start=6456
+  _globals['_VECTORSRESPONSE']._serialized_end=6587
+  _globals['_SMBTESTREQUEST']._serialized_start=6590
+  _globals['_SMBTESTREQUEST']._serialized_end=6761
+  _globals['_SMBTESTRESPONSE']._serialized_start=6763
+  _globals['_SMBTESTRESPONSE']._serialized_end=6809
+  _globals['_SMBBROWSEREQUEST']._serialized_start=6812
+  _globals['_SMBBROWSEREQUEST']._serialized_end=6999
+  _globals['_SMBFILEENTRY']._serialized_start=7001
+  _globals['_SMBFILEENTRY']._serialized_end=7073
+  _globals['_SMBBROWSERESPONSE']._serialized_start=7075
+  _globals['_SMBBROWSERESPONSE']._serialized_end=7147
+  _globals['_LOGINREQUEST']._serialized_start=7149
+  _globals['_LOGINREQUEST']._serialized_end=7199
+  _globals['_LOGINRESPONSE']._serialized_start=7201
+  _globals['_LOGINRESPONSE']._serialized_end=7280
+  _globals['_VALIDATETOKENREQUEST']._serialized_start=7282
+  _globals['_VALIDATETOKENREQUEST']._serialized_end=7319
+  _globals['_VALIDATETOKENRESPONSE']._serialized_start=7321
+  _globals['_VALIDATETOKENRESPONSE']._serialized_end=7391
+  _globals['_LISTUSERSREQUEST']._serialized_start=7393
+  _globals['_LISTUSERSREQUEST']._serialized_end=7411
+  _globals['_LISTUSERSRESPONSE']._serialized_start=7413
+  _globals['_LISTUSERSRESPONSE']._serialized_end=7463
+  _globals['_CREATEUSERREQUEST']._serialized_start=7465
+  _globals['_CREATEUSERREQUEST']._serialized_end=7534
+  _globals['_CREATEUSERRESPONSE']._serialized_start=7536
+  _globals['_CREATEUSERRESPONSE']._serialized_end=7586
+  _globals['_DELETEUSERREQUEST']._serialized_start=7588
+  _globals['_DELETEUSERREQUEST']._serialized_end=7625
+  _globals['_DELETEUSERRESPONSE']._serialized_start=7627
+  _globals['_DELETEUSERRESPONSE']._serialized_end=7679
+  _globals['_INDEXINGSERVICE']._serialized_start=7682
+  _globals['_INDEXINGSERVICE']._serialized_end=8143
+  _globals['_SEARCHSERVICE']._serialized_start=8146
+  _globals['_SEARCHSERVICE']._serialized_end=8303
+  _globals['_CHATSERVICE']._serialized_start=8305
+  _globals['_CHATSERVICE']._serialized_end=8372
+  _globals['_EMBEDDINGSERVICE']._serialized_start=8375
+  _globals['_EMBEDDINGSERVICE']._serialized_end=8701
+  _globals['_PIISERVICE']._serialized_start=8703
+  _globals['_PIISERVICE']._serialized_end=8791
+  _globals['_CONFIGSERVICE']._serialized_start=8794
+  _globals['_CONFIGSERVICE']._serialized_end=9764
+  _globals['_VISUALIZATIONSERVICE']._serialized_start=9767
+  _globals['_VISUALIZATIONSERVICE']._serialized_end=9987
+  _globals['_SMBSERVICE']._serialized_start=9990
+  _globals['_SMBSERVICE']._serialized_end=10140
+  _globals['_AUTHSERVICE']._serialized_start=10143
+  _globals['_AUTHSERVICE']._serialized_end=10512
 # @@protoc_insertion_point(module_scope)
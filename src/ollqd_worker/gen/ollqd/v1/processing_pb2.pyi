@@ -8,37 +8,43 @@ from typing import ClassVar as _ClassVar, Optional as _Optional, Union as _Union
 DESCRIPTOR: _descriptor.FileDescriptor
 
 class IndexCodebaseRequest(_message.Message):
-    __slots__ = ("root_path", "collection", "incremental", "chunk_size", "chunk_overlap", "extra_skip_dirs")
+    __slots__ = ("root_path", "collection", "incremental", "chunk_size", "chunk_overlap", "extra_skip_dirs", "files", "provenance")
     ROOT_PATH_FIELD_NUMBER: _ClassVar[int]
     COLLECTION_FIELD_NUMBER: _ClassVar[int]
     INCREMENTAL_FIELD_NUMBER: _ClassVar[int]
     CHUNK_SIZE_FIELD_NUMBER: _ClassVar[int]
     CHUNK_OVERLAP_FIELD_NUMBER: _ClassVar[int]
     EXTRA_SKIP_DIRS_FIELD_NUMBER: _ClassVar[int]
+    FILES_FIELD_NUMBER: _ClassVar[int]
+    PROVENANCE_FIELD_NUMBER: _ClassVar[int]
     root_path: str
     collection: str
     incremental: bool
     chunk_size: int
     chunk_overlap: int
     extra_skip_dirs: _containers.RepeatedScalarFieldContainer[str]
-    def __init__(self, root_path: _Optional[str] = ..., collection: _Optional[str] = ..., incremental: bool = ..., chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., extra_skip_dirs: _Optional[_Iterable[str]] = ...) -> None: ...
+    files: _containers.RepeatedScalarFieldContainer[str]
+    provenance: Provenance
+    def __init__(self, root_path: _Optional[str] = ..., collection: _Optional[str] = ..., incremental: bool = ..., chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., extra_skip_dirs: _Optional[_Iterable[str]] = ..., files: _Optional[_Iterable[str]] = ..., provenance: _Optional[_Union[Provenance, _Mapping]] = ...) -> None: ...
 
 class IndexDocumentsRequest(_message.Message):
-    __slots__ = ("paths", "collection", "chunk_size", "chunk_overlap", "source_tag")
+    __slots__ = ("paths", "collection", "chunk_size", "chunk_overlap", "source_tag", "provenance")
     PATHS_FIELD_NUMBER: _ClassVar[int]
     COLLECTION_FIELD_NUMBER: _ClassVar[int]
     CHUNK_SIZE_FIELD_NUMBER: _ClassVar[int]
     CHUNK_OVERLAP_FIELD_NUMBER: _ClassVar[int]
     SOURCE_TAG_FIELD_NUMBER: _ClassVar[int]
+    PROVENANCE_FIELD_NUMBER: _ClassVar[int]
     paths: _containers.RepeatedScalarFieldContainer[str]
     collection: str
     chunk_size: int
     chunk_overlap: int
     source_tag: str
-    def __init__(self, paths: _Optional[_Iterable[str]] = ..., collection: _Optional[str] = ..., chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., source_tag: _Optional[str] = ...) -> None: ...
+    provenance: Provenance
+    def __init__(self, paths: _Optional[_Iterable[str]] = ..., collection: _Optional[str] = ..., chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., source_tag: _Optional[str] = ..., provenance: _Optional[_Union[Provenance, _Mapping]] = ...) -> None: ...
 
 class IndexImagesRequest(_message.Message):
-    __slots__ = ("root_path", "collection", "vision_model", "caption_prompt", "incremental", "max_image_size_kb", "extra_skip_dirs")
+    __slots__ = ("root_path", "collection", "vision_model", "caption_prompt", "incremental", "max_image_size_kb", "extra_skip_dirs", "provenance")
     ROOT_PATH_FIELD_NUMBER: _ClassVar[int]
     COLLECTION_FIELD_NUMBER: _ClassVar[int]
     VISION_MODEL_FIELD_NUMBER: _ClassVar[int]
@@ -46,6 +52,7 @@ class IndexImagesRequest(_message.Message):
     INCREMENTAL_FIELD_NUMBER: _ClassVar[int]
     MAX_IMAGE_SIZE_KB_FIELD_NUMBER: _ClassVar[int]
     EXTRA_SKIP_DIRS_FIELD_NUMBER: _ClassVar[int]
+    PROVENANCE_FIELD_NUMBER: _ClassVar[int]
     root_path: str
     collection: str
     vision_model: str
@@ -53,10 +60,11 @@ class IndexImagesRequest(_message.Message):
     incremental: bool
     max_image_size_kb: int
     extra_skip_dirs: _containers.RepeatedScalarFieldContainer[str]
-    def __init__(self, root_path: _Optional[str] = ..., collection: _Optional[str] = ..., vision_model: _Optional[str] = ..., caption_prompt: _Optional[str] = ..., incremental: bool = ..., max_image_size_kb: _Optional[int] = ..., extra_skip_dirs: _Optional[_Iterable[str]] = ...) -> None: ...
+    provenance: Provenance
+    def __init__(self, root_path: _Optional[str] = ..., collection: _Optional[str] = ..., vision_model: _Optional[str] = ..., caption_prompt: _Optional[str] = ..., incremental: bool = ..., max_image_size_kb: _Optional[int] = ..., extra_skip_dirs: _Optional[_Iterable[str]] = ..., provenance: _Optional[_Union[Provenance, _Mapping]] = ...) -> None: ...
 
 class IndexUploadsRequest(_message.Message):
-    __slots__ = ("saved_paths", "collection", "chunk_size", "chunk_overlap", "source_tag", "vision_model", "caption_prompt")
+    __slots__ = ("saved_paths", "collection", "chunk_size", "chunk_overlap", "source_tag", "vision_model", "caption_prompt", "provenance")
     SAVED_PATHS_FIELD_NUMBER: _ClassVar[int]
     COLLECTION_FIELD_NUMBER: _ClassVar[int]
     CHUNK_SIZE_FIELD_NUMBER: _ClassVar[int]
@@ -64,6 +72,7 @@ class IndexUploadsRequest(_message.Message):
     SOURCE_TAG_FIELD_NUMBER: _ClassVar[int]
     VISION_MODEL_FIELD_NUMBER: _ClassVar[int]
     CAPTION_PROMPT_FIELD_NUMBER: _ClassVar[int]
+    PROVENANCE_FIELD_NUMBER: _ClassVar[int]
     saved_paths: _containers.RepeatedScalarFieldContainer[str]
     collection: str
     chunk_size: int
@@ -71,10 +80,11 @@ class IndexUploadsRequest(_message.Message):
     source_tag: str
     vision_model: str
     caption_prompt: str
-    def __init__(self, saved_paths: _Optional[_Iterable[str]] = ..., collection: _Optional[str] = ..., chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., source_tag: _Optional[str] = ..., vision_model: _Optional[str] = ..., caption_prompt: _Optional[str] = ...) -> None: ...
+    provenance: Provenance
+    def __init__(self, saved_paths: _Optional[_Iterable[str]] = ..., collection: _Optional[str] = ..., chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., source_tag: _Optional[str] = ..., vision_model: _Optional[str] = ..., caption_prompt: _Optional[str] = ..., provenance: _Optional[_Union[Provenance, _Mapping]] = ...) -> None: ...
 
 class IndexSMBFilesRequest(_message.Message):
-    __slots__ = ("share_id", "remote_paths", "collection", "chunk_size", "chunk_overlap", "source_tag", "server", "share", "username", "password", "domain", "port")
+    __slots__ = ("share_id", "remote_paths", "collection", "chunk_size", "chunk_overlap", "source_tag", "server", "share", "username", "password", "domain", "port", "auth", "realm", "kdc", "keytab", "provenance")
     SHARE_ID_FIELD_NUMBER: _ClassVar[int]
     REMOTE_PATHS_FIELD_NUMBER: _ClassVar[int]
     COLLECTION_FIELD_NUMBER: _ClassVar[int]
@@ -87,6 +97,11 @@ class IndexSMBFilesRequest(_message.Message):
     PASSWORD_FIELD_NUMBER: _ClassVar[int]
     DOMAIN_FIELD_NUMBER: _ClassVar[int]
     PORT_FIELD_NUMBER: _ClassVar[int]
+    AUTH_FIELD_NUMBER: _ClassVar[int]
+    REALM_FIELD_NUMBER: _ClassVar[int]
+    KDC_FIELD_NUMBER: _ClassVar[int]
+    KEYTAB_FIELD_NUMBER: _ClassVar[int]
+    PROVENANCE_FIELD_NUMBER: _ClassVar[int]
     share_id: str
     remote_paths: _containers.RepeatedScalarFieldContainer[str]
     collection: str
@@ -99,7 +114,39 @@ class IndexSMBFilesRequest(_message.Message):
     password: str
     domain: str
     port: int
-    def __init__(self, share_id: _Optional[str] = ..., remote_paths: _Optional[_Iterable[str]] = ..., collection: _Optional[str] = ..., chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., source_tag: _Optional[str] = ..., server: _Optional[str] = ..., share: _Optional[str] = ..., username: _Optional[str] = ..., password: _Optional[str] = ..., domain: _Optional[str] = ..., port: _Optional[int] = ...) -> None: ...
+    auth: str
+    realm: str
+    kdc: str
+    keytab: bytes
+    provenance: Provenance
+    def __init__(self, share_id: _Optional[str] = ..., remote_paths: _Optional[_Iterable[str]] = ..., collection: _Optional[str] = ..., chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., source_tag: _Optional[str] = ..., server: _Optional[str] = ..., share: _Optional[str] = ..., username: _Optional[str] = ..., password: _Optional[str] = ..., domain: _Optional[str] = ..., port: _Optional[int] = ..., auth: _Optional[str] = ..., realm: _Optional[str] = ..., kdc: _Optional[str] = ..., keytab: _Optional[bytes] = ..., provenance: _Optional[_Union[Provenance, _Mapping]] = ...) -> None: ...
+
+class Provenance(_message.Message):
+    __slots__ = ("schema", "source_type", "share_id", "uploader", "indexed_at", "task_id", "gateway_version", "sources")
+    class SourcesEntry(_message.Message):
+        __slots__ = ("key", "value")
+        KEY_FIELD_NUMBER: _ClassVar[int]
+        VALUE_FIELD_NUMBER: _ClassVar[int]
+        key: str
+        value: str
+        def __init__(self, key: _Optional[str] = ..., value: _Optional[str] = ...) -> None: ...
+    SCHEMA_FIELD_NUMBER: _ClassVar[int]
+    SOURCE_TYPE_FIELD_NUMBER: _ClassVar[int]
+    SHARE_ID_FIELD_NUMBER: _ClassVar[int]
+    UPLOADER_FIELD_NUMBER: _ClassVar[int]
+    INDEXED_AT_FIELD_NUMBER: _ClassVar[int]
+    TASK_ID_FIELD_NUMBER: _ClassVar[int]
+    GATEWAY_VERSION_FIELD_NUMBER: _ClassVar[int]
+    SOURCES_FIELD_NUMBER: _ClassVar[int]
+    schema: int
+    source_type: str
+    share_id: str
+    uploader: str
+    indexed_at: str
+    task_id: str
+    gateway_version: str
+    sources: _containers.ScalarMap[str, str]
+    def __init__(self, schema: _Optional[int] = ..., source_type: _Optional[str] = ..., share_id: _Optional[str] = ..., uploader: _Optional[str] = ..., indexed_at: _Optional[str] = ..., task_id: _Optional[str] = ..., gateway_version: _Optional[str] = ..., sources: _Optional[_Mapping[str, str]] = ...) -> None: ...
 
 class CancelTaskRequest(_message.Message):
     __slots__ = ("task_id",)
@@ -154,16 +201,44 @@ class SearchResponse(_message.Message):
     def __init__(self, status: _Optional[str] = ..., query: _Optional[str] = ..., collection: _Optional[str] = ..., results: _Optional[_Iterable[_Union[_types_pb2.SearchHit, _Mapping]]] = ...) -> None: ...
 
 class ChatRequest(_message.Message):
-    __slots__ = ("message", "collection", "model", "pii_enabled")
+    __slots__ = ("message", "collection", "model", "pii_enabled", "temperature", "top_p", "max_tokens", "top_k", "system_prompt", "context_max_tokens", "source_max_tokens", "dedupe_files", "context_order", "history")
     MESSAGE_FIELD_NUMBER: _ClassVar[int]
     COLLECTION_FIELD_NUMBER: _ClassVar[int]
     MODEL_FIELD_NUMBER: _ClassVar[int]
     PII_ENABLED_FIELD_NUMBER: _ClassVar[int]
+    TEMPERATURE_FIELD_NUMBER: _ClassVar[int]
+    TOP_P_FIELD_NUMBER: _ClassVar[int]
+    MAX_TOKENS_FIELD_NUMBER: _ClassVar[int]
+    TOP_K_FIELD_NUMBER: _ClassVar[int]
+    SYSTEM_PROMPT_FIELD_NUMBER: _ClassVar[int]
+    CONTEXT_MAX_TOKENS_FIELD_NUMBER: _ClassVar[int]
+    SOURCE_MAX_TOKENS_FIELD_NUMBER: _ClassVar[int]
+    DEDUPE_FILES_FIELD_NUMBER: _ClassVar[int]
+    CONTEXT_ORDER_FIELD_NUMBER: _ClassVar[int]
+    HISTORY_FIELD_NUMBER: _ClassVar[int]
     message: str
     collection: str
     model: str
     pii_enabled: bool
-    def __init__(self, message: _Optional[str] = ..., collection: _Optional[str] = ..., model: _Optional[str] = ..., pii_enabled: bool = ...) -> None: ...
+    temperature: float
+    top_p: float
+    max_tokens: int
+    top_k: int
+    system_prompt: str
+    context_max_tokens: int
+    source_max_tokens: int
+    dedupe_files: bool
+    context_order: str
+    history: _containers.RepeatedCompositeFieldContainer[ChatTurn]
+    def __init__(self, message: _Optional[str] = ..., collection: _Optional[str] = ..., model: _Optional[str] = ..., pii_enabled: bool = ..., temperature: _Optional[float] = ..., top_p: _Optional[float] = ..., max_tokens: _Optional[int] = ..., top_k: _Optional[int] = ..., system_prompt: _Optional[str] = ..., context_max_tokens: _Optional[int] = ..., source_max_tokens: _Optional[int] = ..., dedupe_files: bool = ..., context_order: _Optional[str] = ..., history: _Optional[_Iterable[_Union[ChatTurn, _Mapping]]] = ...) -> None: ...
+
+class ChatTurn(_message.Message):
+    __slots__ = ("role", "content")
+    ROLE_FIELD_NUMBER: _ClassVar[int]
+    CONTENT_FIELD_NUMBER: _ClassVar[int]
+    role: str
+    content: str
+    def __init__(self, role: _Optional[str] = ..., content: _Optional[str] = ...) -> None: ...
 
 class ChatEvent(_message.Message):
     __slots__ = ("type", "content", "sources", "pii_masked", "pii_entities_count")
@@ -617,20 +692,28 @@ class VectorsResponse(_message.Message):
     def __init__(self, points: _Optional[_Iterable[_Union[VectorPoint, _Mapping]]] = ..., method: _Optional[str] = ..., dims: _Optional[int] = ..., original_dims: _Optional[int] = ..., total_points: _Optional[int] = ...) -> None: ...
 
 class SMBTestRequest(_message.Message):
-    __slots__ = ("server", "share", "username", "password", "domain", "port")
+    __slots__ = ("server", "share", "username", "password", "domain", "port", "auth", "realm", "kdc", "keytab")
     SERVER_FIELD_NUMBER: _ClassVar[int]
     SHARE_FIELD_NUMBER: _ClassVar[int]
     USERNAME_FIELD_NUMBER: _ClassVar[int]
     PASSWORD_FIELD_NUMBER: _ClassVar[int]
     DOMAIN_FIELD_NUMBER: _ClassVar[int]
     PORT_FIELD_NUMBER: _ClassVar[int]
+    AUTH_FIELD_NUMBER: _ClassVar[int]
+    REALM_FIELD_NUMBER: _ClassVar[int]
+    KDC_FIELD_NUMBER: _ClassVar[int]
+    KEYTAB_FIELD_NUMBER: _ClassVar[int]
     server: str
     share: str
     username: str
     password: str
     domain: str
     port: int
-    def __init__(self, server: _Optional[str] = ..., share: _Optional[str] = ..., username: _Optional[str] = ..., password: _Optional[str] = ..., domain: _Optional[str] = ..., port: _Optional[int] = ...) -> None: ...
+    auth: str
+    realm: str
+    kdc: str
+    keytab: bytes
+    def __init__(self, server: _Optional[str] = ..., share: _Optional[str] = ..., username: _Optional[str] = ..., password: _Optional[str] = ..., domain: _Optional[str] = ..., port: _Optional[int] = ..., auth: _Optional[str] = ..., realm: _Optional[str] = ..., kdc: _Optional[str] = ..., keytab: _Optional[bytes] = ...) -> None: ...
 
 class SMBTestResponse(_message.Message):
     __slots__ = ("ok", "message")
@@ -641,7 +724,7 @@ class SMBTestResponse(_message.Message):
     def __init__(self, ok: bool = ..., message: _Optional[str] = ...) -> None: ...
 
 class SMBBrowseRequest(_message.Message):
-    __slots__ = ("server", "share", "username", "password", "domain", "port", "path")
+    __slots__ = ("server", "share", "username", "password", "domain", "port", "path", "auth", "realm", "kdc", "keytab")
     SERVER_FIELD_NUMBER: _ClassVar[int]
     SHARE_FIELD_NUMBER: _ClassVar[int]
     USERNAME_FIELD_NUMBER: _ClassVar[int]
@@ -649,6 +732,10 @@ class SMBBrowseRequest(_message.Message):
     DOMAIN_FIELD_NUMBER: _ClassVar[int]
     PORT_FIELD_NUMBER: _ClassVar[int]
     PATH_FIELD_NUMBER: _ClassVar[int]
+    AUTH_FIELD_NUMBER: _ClassVar[int]
+    REALM_FIELD_NUMBER: _ClassVar[int]
+    KDC_FIELD_NUMBER: _ClassVar[int]
+    KEYTAB_FIELD_NUMBER: _ClassVar[int]
     server: str
     share: str
     username: str
@@ -656,7 +743,11 @@ class SMBBrowseRequest(_message.Message):
     domain: str
     port: int
     path: str
-    def __init__(self, server: _Optional[str] = ..., share: _Optional[str] = ..., username: _Optional[str] = ..., password: _Optional[str] = ..., domain: _Optional[str] = ..., port: _Optional[int] = ..., path: _Optional[str] = ...) -> None: ...
+    auth: str
+    realm: str
+    kdc: str
+    keytab: bytes
+    def __init__(self, server: _Optional[str] = ..., share: _Optional[str] = ..., username: _Optional[str] = ..., password: _Optional[str] = ..., domain: _Optional[str] = ..., port: _Optional[int] = ..., path: _Optional[str] = ..., auth: _Optional[str] = ..., realm: _Optional[str] = ..., kdc: _Optional[str] = ..., keytab: _Optional[bytes] = ...) -> None: ...
 
 class SMBFileEntry(_message.Message):
     __slots__ = ("name", "is_dir", "size", "path")
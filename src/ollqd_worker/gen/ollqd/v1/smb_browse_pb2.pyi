@@ -0,0 +1,48 @@
+from ollqd.v1 import processing_pb2 as _processing_pb2
+from google.protobuf.internal import containers as _containers
+from google.protobuf import descriptor as _descriptor
+from google.protobuf import message as _message
+from collections.abc import Iterable as _Iterable, Mapping as _Mapping
+from typing import ClassVar as _ClassVar, Optional as _Optional, Union as _Union
+
+DESCRIPTOR: _descriptor.FileDescriptor
+
+class ListSharesRequest(_message.Message):
+    __slots__ = ("server", "username", "password", "domain", "port", "include_hidden", "auth", "realm", "kdc", "keytab")
+    SERVER_FIELD_NUMBER: _ClassVar[int]
+    USERNAME_FIELD_NUMBER: _ClassVar[int]
+    PASSWORD_FIELD_NUMBER: _ClassVar[int]
+    DOMAIN_FIELD_NUMBER: _ClassVar[int]
+    PORT_FIELD_NUMBER: _ClassVar[int]
+    INCLUDE_HIDDEN_FIELD_NUMBER: _ClassVar[int]
+    AUTH_FIELD_NUMBER: _ClassVar[int]
+    REALM_FIELD_NUMBER: _ClassVar[int]
+    KDC_FIELD_NUMBER: _ClassVar[int]
+    KEYTAB_FIELD_NUMBER: _ClassVar[int]
+    server: str
+    username: str
+    password: str
+    domain: str
+    port: int
+    include_hidden: bool
+    auth: str
+    realm: str
+    kdc: str
+    keytab: bytes
+    def __init__(self, server: _Optional[str] = ..., username: _Optional[str] = ..., password: _Optional[str] = ..., domain: _Optional[str] = ..., port: _Optional[int] = ..., include_hidden: bool = ..., auth: _Optional[str] = ..., realm: _Optional[str] = ..., kdc: _Optional[str] = ..., keytab: _Optional[bytes] = ...) -> None: ...
+
+class ListSharesResponse(_message.Message):
+    __slots__ = ("shares",)
+    SHARES_FIELD_NUMBER: _ClassVar[int]
+    shares: _containers.RepeatedCompositeFieldContainer[SMBShareInfo]
+    def __init__(self, shares: _Optional[_Iterable[_Union[SMBShareInfo, _Mapping]]] = ...) -> None: ...
+
+class SMBShareInfo(_message.Message):
+    __slots__ = ("name", "comments", "is_special")
+    NAME_FIELD_NUMBER: _ClassVar[int]
+    COMMENTS_FIELD_NUMBER: _ClassVar[int]
+    IS_SPECIAL_FIELD_NUMBER: _ClassVar[int]
+    name: str
+    comments: str
+    is_special: bool
+    def __init__(self, name: _Optional[str] = ..., comments: _Optional[str] = ..., is_special: bool = ...) -> None: ...
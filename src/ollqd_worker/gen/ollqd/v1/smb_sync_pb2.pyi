@@ -0,0 +1,55 @@
+from google.protobuf.internal import containers as _containers
+from google.protobuf import descriptor as _descriptor
+from google.protobuf import message as _message
+from collections.abc import Iterable as _Iterable, Mapping as _Mapping
+from typing import ClassVar as _ClassVar, Optional as _Optional, Union as _Union
+
+DESCRIPTOR: _descriptor.FileDescriptor
+
+class ScanShareRequest(_message.Message):
+    __slots__ = ("server", "share", "username", "password", "domain", "port", "paths", "max_files", "auth", "realm", "kdc", "keytab")
+    SERVER_FIELD_NUMBER: _ClassVar[int]
+    SHARE_FIELD_NUMBER: _ClassVar[int]
+    USERNAME_FIELD_NUMBER: _ClassVar[int]
+    PASSWORD_FIELD_NUMBER: _ClassVar[int]
+    DOMAIN_FIELD_NUMBER: _ClassVar[int]
+    PORT_FIELD_NUMBER: _ClassVar[int]
+    PATHS_FIELD_NUMBER: _ClassVar[int]
+    MAX_FILES_FIELD_NUMBER: _ClassVar[int]
+    AUTH_FIELD_NUMBER: _ClassVar[int]
+    REALM_FIELD_NUMBER: _ClassVar[int]
+    KDC_FIELD_NUMBER: _ClassVar[int]
+    KEYTAB_FIELD_NUMBER: _ClassVar[int]
+    server: str
+    share: str
+    username: str
+    password: str
+    domain: str
+    port: int
+    paths: _containers.RepeatedScalarFieldContainer[str]
+    max_files: int
+    auth: str
+    realm: str
+    kdc: str
+    keytab: bytes
+    def __init__(self, server: _Optional[str] = ..., share: _Optional[str] = ..., username: _Optional[str] = ..., password: _Optional[str] = ..., domain: _Optional[str] = ..., port: _Optional[int] = ..., paths: _Optional[_Iterable[str]] = ..., max_files: _Optional[int] = ..., auth: _Optional[str] = ..., realm: _Optional[str] = ..., kdc: _Optional[str] = ..., keytab: _Optional[bytes] = ...) -> None: ...
+
+class ScanShareResponse(_message.Message):
+    __slots__ = ("files", "truncated")
+    FILES_FIELD_NUMBER: _ClassVar[int]
+    TRUNCATED_FIELD_NUMBER: _ClassVar[int]
+    files: _containers.RepeatedCompositeFieldContainer[ScannedFile]
+    truncated: bool
+    def __init__(self, files: _Optional[_Iterable[_Union[ScannedFile, _Mapping]]] = ..., truncated: bool = ...) -> None: ...
+
+class ScannedFile(_message.Message):
+    __slots__ = ("path", "file_path", "size", "mtime")
+    PATH_FIELD_NUMBER: _ClassVar[int]
+    FILE_PATH_FIELD_NUMBER: _ClassVar[int]
+    SIZE_FIELD_NUMBER: _ClassVar[int]
+    MTIME_FIELD_NUMBER: _ClassVar[int]
+    path: str
+    file_path: str
+    size: int
+    mtime: int
+    def __init__(self, path: _Optional[str] = ..., file_path: _Optional[str] = ..., size: _Optional[int] = ..., mtime: _Optional[int] = ...) -> None: ...
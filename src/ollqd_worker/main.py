@@ -19,17 +19,28 @@ from .services.chat import ChatServiceServicer
 from .services.config_svc import ConfigServiceServicer
 from .services.embedding import EmbeddingServiceServicer
 from .services.indexing import IndexingServiceServicer
+from .services.ocr import OCRServiceServicer
 from .services.pii import PIIServiceServicer
+from .services.preview import PreviewServiceServicer
 from .services.search import SearchServiceServicer
+from .services.smb_browse import SMBBrowseServiceServicer
+from .services.smb_sync import SMBSyncServiceServicer
 from .services.visualization import VisualizationServiceServicer
+from .services.worker_info import WorkerInfoServiceServicer
 
 log = logging.getLogger("ollqd.worker")
 
-# Try importing generated stubs for service registration.
-# All services are in processing_pb2_grpc (generated from processing.proto).
+# Try importing generated stubs for service registration. The core services
+# are in processing_pb2_grpc (generated from processing.proto), the others
+# have a proto file each.
 _pb2_grpc = None
 try:
+    from .gen.ollqd.v1 import ocr_pb2_grpc
+    from .gen.ollqd.v1 import preview_pb2_grpc
     from .gen.ollqd.v1 import processing_pb2_grpc as _pb2_grpc
+    from .gen.ollqd.v1 import smb_browse_pb2_grpc
+    from .gen.ollqd.v1 import smb_sync_pb2_grpc
+    from .gen.ollqd.v1 import worker_info_pb2_grpc
 except ImportError:
     pass
 
@@ -53,12 +64,20 @@ def _register_servicers(server: grpc_aio.Server) -> list[str]:
         ("ChatService", ChatServiceServicer(), _pb2_grpc.add_ChatServiceServicer_to_server),
         ("IndexingService", IndexingServiceServicer(), _pb2_grpc.add_IndexingServiceServicer_to_server),
         ("VisualizationService", VisualizationServiceServicer(), _pb2_grpc.add_VisualizationServiceServicer_to_server),
+        ("PreviewService", PreviewServiceServicer(), preview_pb2_grpc.add_PreviewServiceServicer_to_server),
+        ("SMBSyncService", SMBSyncServiceServicer(), smb_sync_pb2_grpc.add_SMBSyncServiceServicer_to_server),
+        ("SMBBrowseService", SMBBrowseServiceServicer(), smb_browse_pb2_grpc.add_SMBBrowseServiceServicer_to_server),
+        ("OCRService", OCRServiceServicer(), ocr_pb2_grpc.add_OCRServiceServicer_to_server),
     ]
 
     for name, servicer, register_fn in svc_map:
         register_fn(servicer, server)
         registered.append(name)
 
+    # Registered last so it can report the services above to the gateway.
+    registered.append("WorkerInfoService")
+    worker_info_pb2_grpc.add_WorkerInfoServiceServicer_to_server(WorkerInfoServiceServicer(registered), server)
+
     return registered
 
 
@@ -81,6 +100,7 @@ async def serve():
         options=[
             ("grpc.max_send_message_length", 50 * 1024 * 1024),     # 50 MB
             ("grpc.max_receive_message_length", 50 * 1024 * 1024),  # 50 MB
+            ("grpc.max_metadata_size", 64 * 1024),                   # upload display names
         ]
     )
 
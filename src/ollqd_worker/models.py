@@ -21,12 +21,30 @@ class Chunk:
     end_line: int
     content: str
     content_hash: str
+    page_start: Optional[int] = None
+    page_end: Optional[int] = None
+    start_time: Optional[float] = None
+    end_time: Optional[float] = None
 
     @property
     def point_id(self) -> str:
         raw = f"{self.file_path}::chunk_{self.chunk_index}"
         return hashlib.md5(raw.encode()).hexdigest()
 
+    @property
+    def page_payload(self) -> dict:
+        """Page range payload fields; empty for chunks without pages."""
+        if self.page_start is None:
+            return {}
+        return {"page_start": self.page_start, "page_end": self.page_end}
+
+    @property
+    def time_payload(self) -> dict:
+        """Recording time range payload fields; empty for chunks without one."""
+        if self.start_time is None:
+            return {}
+        return {"start_time": self.start_time, "end_time": self.end_time}
+
 @dataclass
 class SearchResult:
     score: float
@@ -11,14 +11,11 @@ from ..models import Chunk, FileInfo
 log = logging.getLogger("ollqd.chunking")
 
 
-def chunk_pdf(
-    file_path: str,
-    pdf_bytes: bytes,
-    chunk_size: int = 512,
-    chunk_overlap: int = 64,
-    content_hash: str = "",
-) -> list[Chunk]:
-    """Extract text from PDF and chunk by paragraph boundaries."""
+PDF_PAGE_SEPARATOR = "\n\n"
+
+
+def extract_pdf_pages(pdf_bytes: bytes) -> list[str]:
+    """Return the text of each PDF page."""
     import fitz  # PyMuPDF
 
     doc = fitz.open(stream=pdf_bytes, filetype="pdf")
@@ -26,12 +23,160 @@ def chunk_pdf(
     for page in doc:
         pages_text.append(page.get_text("text"))
     doc.close()
+    return pages_text
+
+
+def extract_pdf_text(pdf_bytes: bytes) -> str:
+    """Return the text of every PDF page, separated by blank lines."""
+    return PDF_PAGE_SEPARATOR.join(extract_pdf_pages(pdf_bytes))
+
+
+def pdf_page_offsets(pages: list[str]) -> list[int]:
+    """Return the character offset of each page in the joined PDF text."""
+    offsets, pos = [], 0
+    for text in pages:
+        offsets.append(pos)
+        pos += len(text) + len(PDF_PAGE_SEPARATOR)
+    return offsets
+
+
+def assign_pages(chunks: list[Chunk], text: str, page_offsets: list[int]) -> None:
+    """Set page_start/page_end (1-based) on chunks of a joined PDF text.
+
+    Chunks are located by their first and last line, which chunk_document
+    keeps verbatim; chunks that cannot be located keep no page range.
+    """
+    from bisect import bisect_right
+
+    cursor = 0
+    for c in chunks:
+        lines = c.content.split("\n")
+        head, tail = lines[0][:200], lines[-1][-200:]
+        start = text.find(head, cursor)
+        if not head or start < 0:
+            continue
+        end = text.find(tail, start)
+        end = start if end < 0 else end + len(tail) - 1
+        c.page_start = bisect_right(page_offsets, start)
+        c.page_end = bisect_right(page_offsets, end)
+        cursor = start
+
+
+def extract_docx_text(docx_bytes: bytes) -> str:
+    """Return .docx paragraphs followed by table rows (pipe-delimited)."""
+    from io import BytesIO
+    from docx import Document
+
+    doc = Document(BytesIO(docx_bytes))
+    parts: list[str] = []
+    for p in doc.paragraphs:
+        if p.text.strip():
+            parts.append(p.text.strip())
+    for table in doc.tables:
+        for row in table.rows:
+            cells = [cell.text.strip() for cell in row.cells if cell.text.strip()]
+            if cells:
+                parts.append(" | ".join(cells))
+    return "\n\n".join(parts)
+
+
+def extract_xlsx_text(xlsx_bytes: bytes) -> str:
+    """Return every .xlsx sheet with rows as pipe-delimited lines."""
+    from io import BytesIO
+    from openpyxl import load_workbook
+
+    wb = load_workbook(BytesIO(xlsx_bytes), read_only=True, data_only=True)
+    sheets_text: list[str] = []
+    for ws in wb:
+        rows: list[str] = []
+        for row in ws.iter_rows(values_only=True):
+            cells = [str(c) for c in row if c is not None]
+            if cells:
+                rows.append(" | ".join(cells))
+        if rows:
+            sheets_text.append(f"Sheet: {ws.title}\n" + "\n".join(rows))
+    wb.close()
+    return "\n\n".join(sheets_text)
+
+
+def extract_pptx_text(pptx_bytes: bytes) -> str:
+    """Return the text frames of every .pptx slide."""
+    from io import BytesIO
+    from pptx import Presentation
 
-    full_text = "\n\n".join(pages_text)
+    prs = Presentation(BytesIO(pptx_bytes))
+    slides_text: list[str] = []
+    for i, slide in enumerate(prs.slides, 1):
+        texts: list[str] = []
+        for shape in slide.shapes:
+            if shape.has_text_frame:
+                for para in shape.text_frame.paragraphs:
+                    text = para.text.strip()
+                    if text:
+                        texts.append(text)
+        if texts:
+            slides_text.append(f"Slide {i}\n" + "\n".join(texts))
+    return "\n\n".join(slides_text)
+
+
+def extract_text(
+    file_path: str, file_bytes: bytes, docling=None,
+) -> tuple[str, str, str, Optional[list[int]]]:
+    """Extract the indexable text of an uploaded document.
+
+    Tries docling first when ``docling`` (a DoclingConfig) is enabled, then
+    falls back to the per-format parsers. Returns ``(text, language,
+    extractor, page_offsets)`` where language is what chunk_document
+    expects, extractor names the parser that produced the text and
+    page_offsets (PDFs parsed by PyMuPDF only, else None) can be passed to
+    assign_pages.
+    """
+    if docling is not None and docling.enabled:
+        from .docling_converter import convert_to_markdown, parse_languages
+
+        md = convert_to_markdown(
+            file_path=file_path,
+            file_bytes=file_bytes,
+            ocr_enabled=docling.ocr_enabled,
+            ocr_engine=docling.ocr_engine,
+            table_structure=docling.table_structure,
+            timeout_s=docling.timeout_s,
+            ocr_languages=parse_languages(docling.ocr_languages),
+            ocr_dpi=docling.ocr_dpi,
+            force_ocr=docling.force_ocr,
+        )
+        if md is not None:
+            return md, "markdown", "docling", None
+
+    ext = Path(file_path).suffix.lower()
+    if ext == ".pdf":
+        pages = extract_pdf_pages(file_bytes)
+        return PDF_PAGE_SEPARATOR.join(pages), "text", "pymupdf", pdf_page_offsets(pages)
+    if ext == ".docx":
+        return extract_docx_text(file_bytes), "text", "python-docx", None
+    if ext == ".xlsx":
+        return extract_xlsx_text(file_bytes), "text", "openpyxl", None
+    if ext == ".pptx":
+        return extract_pptx_text(file_bytes), "text", "python-pptx", None
+    content = file_bytes.decode("utf-8", errors="replace")
+    lang = "markdown" if ext in (".md", ".rst") else "text"
+    return content, lang, "plain", None
+
+
+def chunk_pdf(
+    file_path: str,
+    pdf_bytes: bytes,
+    chunk_size: int = 512,
+    chunk_overlap: int = 64,
+    content_hash: str = "",
+) -> list[Chunk]:
+    """Extract text from PDF and chunk by paragraph boundaries."""
+    pages = extract_pdf_pages(pdf_bytes)
+    full_text = PDF_PAGE_SEPARATOR.join(pages)
     if not full_text.strip():
         return []
 
-    return chunk_document(
+    chunks = chunk_document(
         file_path=file_path,
         content=full_text,
         language="text",
@@ -39,6 +184,8 @@ def chunk_pdf(
         chunk_overlap=chunk_overlap,
         content_hash=content_hash,
     )
+    assign_pages(chunks, full_text, pdf_page_offsets(pages))
+    return chunks
 
 
 def chunk_docx(
@@ -49,21 +196,7 @@ def chunk_docx(
     content_hash: str = "",
 ) -> list[Chunk]:
     """Extract text from .docx (paragraphs + tables) and chunk."""
-    from io import BytesIO
-    from docx import Document
-
-    doc = Document(BytesIO(docx_bytes))
-    parts: list[str] = []
-    for p in doc.paragraphs:
-        if p.text.strip():
-            parts.append(p.text.strip())
-    for table in doc.tables:
-        for row in table.rows:
-            cells = [cell.text.strip() for cell in row.cells if cell.text.strip()]
-            if cells:
-                parts.append(" | ".join(cells))
-
-    full_text = "\n\n".join(parts)
+    full_text = extract_docx_text(docx_bytes)
     if not full_text.strip():
         return []
 
@@ -85,22 +218,7 @@ def chunk_xlsx(
     content_hash: str = "",
 ) -> list[Chunk]:
     """Extract text from .xlsx (all sheets, rows as pipe-delimited) and chunk."""
-    from io import BytesIO
-    from openpyxl import load_workbook
-
-    wb = load_workbook(BytesIO(xlsx_bytes), read_only=True, data_only=True)
-    sheets_text: list[str] = []
-    for ws in wb:
-        rows: list[str] = []
-        for row in ws.iter_rows(values_only=True):
-            cells = [str(c) for c in row if c is not None]
-            if cells:
-                rows.append(" | ".join(cells))
-        if rows:
-            sheets_text.append(f"Sheet: {ws.title}\n" + "\n".join(rows))
-    wb.close()
-
-    full_text = "\n\n".join(sheets_text)
+    full_text = extract_xlsx_text(xlsx_bytes)
     if not full_text.strip():
         return []
 
@@ -122,23 +240,7 @@ def chunk_pptx(
     content_hash: str = "",
 ) -> list[Chunk]:
     """Extract text from .pptx (slide text frames) and chunk."""
-    from io import BytesIO
-    from pptx import Presentation
-
-    prs = Presentation(BytesIO(pptx_bytes))
-    slides_text: list[str] = []
-    for i, slide in enumerate(prs.slides, 1):
-        texts: list[str] = []
-        for shape in slide.shapes:
-            if shape.has_text_frame:
-                for para in shape.text_frame.paragraphs:
-                    text = para.text.strip()
-                    if text:
-                        texts.append(text)
-        if texts:
-            slides_text.append(f"Slide {i}\n" + "\n".join(texts))
-
-    full_text = "\n\n".join(slides_text)
+    full_text = extract_pptx_text(pptx_bytes)
     if not full_text.strip():
         return []
 
@@ -152,6 +254,145 @@ def chunk_pptx(
     )
 
 
+TABLE_EXTENSIONS = (".csv", ".tsv", ".xlsx")
+TABLE_MODES = ("rows", "sheet")
+
+
+def read_table_sheets(file_path: str, file_bytes: bytes) -> list[tuple[str, list[list[str]]]]:
+    """Return ``(sheet name, rows)`` for each sheet of a spreadsheet.
+
+    Cells are strings with trailing empty cells dropped; empty rows are
+    kept so row numbers match the file. CSV and TSV files have one sheet
+    without a name.
+    """
+    ext = Path(file_path).suffix.lower()
+    if ext == ".xlsx":
+        from io import BytesIO
+        from openpyxl import load_workbook
+
+        wb = load_workbook(BytesIO(file_bytes), read_only=True, data_only=True)
+        sheets = []
+        for ws in wb:
+            rows = [_table_cells(row) for row in ws.iter_rows(values_only=True)]
+            sheets.append((ws.title, rows))
+        wb.close()
+        return sheets
+
+    import csv
+
+    text = file_bytes.decode("utf-8-sig", errors="replace")
+    delimiter = "\t" if ext == ".tsv" else ","
+    rows = [_table_cells(row) for row in csv.reader(text.splitlines(), delimiter=delimiter)]
+    return [("", rows)]
+
+
+def _table_cells(row) -> list[str]:
+    """Render a row's cells as stripped strings without trailing blanks."""
+    cells = ["" if c is None else str(c).strip() for c in row]
+    while cells and not cells[-1]:
+        cells.pop()
+    return cells
+
+
+def _table_columns(header: list[str] | None, columns: list[str], width: int) -> list[int]:
+    """Return the indexes of the selected columns: by header name
+    (case-insensitive) or 1-based position. No selection keeps all."""
+    if not columns:
+        return list(range(width))
+    names = {h.lower(): i for i, h in reversed(list(enumerate(header or [])))}
+    keep = []
+    for c in columns:
+        i = names.get(c.lower())
+        if i is None and c.isdigit() and 0 < int(c) <= width:
+            i = int(c) - 1
+        if i is not None and i not in keep:
+            keep.append(i)
+    return sorted(keep)
+
+
+def chunk_table(
+    file_path: str,
+    file_bytes: bytes,
+    options: dict,
+    chunk_size: int = 512,
+    content_hash: str = "",
+) -> list[Chunk]:
+    """Chunk a .csv, .tsv or .xlsx file as a table.
+
+    ``options`` are the gateway's table options: ``mode`` "rows" writes each
+    row as "column: value" pairs and groups up to ``rows_per_chunk`` rows
+    (0 fills chunk_size); "sheet" writes each sheet as a Markdown table,
+    split at chunk_size with the header repeated. ``header`` (default
+    true) takes the first row as column names, and ``columns`` keeps only
+    the named or numbered columns. Every chunk starts with its sheet name
+    for workbooks, and start_line/end_line are spreadsheet row numbers.
+    """
+    mode = options.get("mode") or "rows"
+    if mode not in TABLE_MODES:
+        raise ValueError(f"unknown table mode {mode!r}")
+    has_header = options.get("header", True) is not False
+    columns = [str(c).strip() for c in options.get("columns") or [] if str(c).strip()]
+    rows_per_chunk = max(0, int(options.get("rows_per_chunk") or 0))
+    language = "markdown" if mode == "sheet" else "text"
+
+    chunks: list[Chunk] = []
+
+    def emit(lines: list[str], start: int, end: int) -> None:
+        chunks.append(Chunk(
+            file_path=file_path, language=language, chunk_index=len(chunks),
+            total_chunks=-1, start_line=start, end_line=end,
+            content="\n".join(lines), content_hash=content_hash,
+        ))
+
+    for sheet, rows in read_table_sheets(file_path, file_bytes):
+        header = rows[0] if has_header and rows else None
+        first = 2 if header is not None else 1
+        body = [(n, r) for n, r in enumerate(rows[first - 1:], first) if r]
+        if not body:
+            continue
+        width = max(len(header or []), max(len(r) for _, r in body))
+        keep = _table_columns(header, columns, width)
+        if not keep:
+            continue
+        names = [(header[i] if header and i < len(header) and header[i] else f"Column {i + 1}") for i in keep]
+
+        lead = [f"Sheet: {sheet}"] if sheet else []
+        if mode == "sheet":
+            lead += ["| " + " | ".join(names) + " |", "|" + " --- |" * len(names)]
+
+        def render(row: list[str]) -> str:
+            values = [row[i] if i < len(row) else "" for i in keep]
+            if not any(values):
+                return ""
+            if mode == "sheet":
+                return "| " + " | ".join(v.replace("|", "\\|") for v in values) + " |"
+            if header is None:
+                return " | ".join(v for v in values if v)
+            return "; ".join(f"{name}: {v}" for name, v in zip(names, values) if v)
+
+        lines, start, size, count = list(lead), 0, sum(len(l) + 1 for l in lead), 0
+        for n, row in body:
+            line = render(row)
+            if not line:
+                continue
+            full = count > 0 and (size + len(line) > chunk_size or (rows_per_chunk and count >= rows_per_chunk))
+            if full:
+                emit(lines, start, last)
+                lines, size, count = list(lead), sum(len(l) + 1 for l in lead), 0
+            if count == 0:
+                start = n
+            lines.append(line)
+            size += len(line) + 1
+            count += 1
+            last = n
+        if count:
+            emit(lines, start, last)
+
+    for c in chunks:
+        c.total_chunks = len(chunks)
+    return chunks
+
+
 def chunk_with_docling(
     file_path: str,
     file_bytes: bytes,
@@ -162,6 +403,9 @@ def chunk_with_docling(
     ocr_engine: str = "easyocr",
     table_structure: bool = True,
     timeout_s: float = 300,
+    ocr_languages: list[str] | None = None,
+    ocr_dpi: int = 0,
+    force_ocr: bool = False,
 ) -> list[Chunk] | None:
     """Try to convert a file via docling and chunk the resulting markdown.
 
@@ -177,6 +421,9 @@ def chunk_with_docling(
         ocr_engine=ocr_engine,
         table_structure=table_structure,
         timeout_s=timeout_s,
+        ocr_languages=ocr_languages,
+        ocr_dpi=ocr_dpi,
+        force_ocr=force_ocr,
     )
     if md is None:
         return None
@@ -3,6 +3,7 @@
 import hashlib
 import logging
 import os
+import re
 from pathlib import Path
 from typing import Optional
 
@@ -53,20 +54,138 @@ SKIP_FILES: set[str] = {
 }
 
 
+_PATTERN_CHARS = set("*?[]/!\\")
+
+
+def _compile_ignore_pattern(pattern: str) -> tuple[re.Pattern, bool, bool]:
+    """Translate a gitignore pattern to (regex, negate, dir_only).
+
+    Mirrors the gateway's internal/ignore package: "!" re-includes, a
+    trailing "/" matches directories only, a "/" elsewhere anchors the
+    pattern at the root, "*" and "?" stay within one path element and "**"
+    crosses them.
+    """
+    p = pattern.rstrip(" \t")
+    if not p or p.startswith("#"):
+        raise ValueError(f"not a pattern: {pattern!r}")
+    negate = p.startswith("!")
+    if negate:
+        p = p[1:]
+    dir_only = p.endswith("/") and not p.endswith("\\/")
+    if dir_only:
+        p = p.rstrip("/")
+    anchored = "/" in p
+    p = p.lstrip("/") if anchored else p
+    if not p:
+        raise ValueError(f"pattern matches nothing: {pattern!r}")
+
+    out = ["^" if anchored else "^(?:.*/)?"]
+    i = 0
+    while i < len(p):
+        c = p[i]
+        if c == "*":
+            if i + 1 < len(p) and p[i + 1] == "*":
+                at_start = i == 0 or p[i - 1] == "/"
+                if at_start and i + 2 < len(p) and p[i + 2] == "/":
+                    out.append("(?:.*/)?")
+                    i += 3
+                    continue
+                if at_start and i + 2 == len(p):
+                    out.append(".*")
+                    i += 2
+                    continue
+                out.append("[^/]*")
+                i += 2
+                continue
+            out.append("[^/]*")
+        elif c == "?":
+            out.append("[^/]")
+        elif c == "[":
+            j = i + 1
+            if j < len(p) and p[j] == "!":
+                j += 1
+            if j < len(p) and p[j] == "]":
+                j += 1
+            end = p.find("]", j)
+            if end < 0:
+                raise ValueError(f"unterminated character class: {pattern!r}")
+            cls = p[i + 1:end]
+            if cls.startswith("!"):
+                cls = "^" + cls[1:]
+            out.append("[" + cls + "]")
+            i = end
+        elif c == "\\":
+            if i + 1 == len(p):
+                raise ValueError(f"trailing backslash: {pattern!r}")
+            i += 1
+            out.append(re.escape(p[i]))
+        else:
+            out.append(re.escape(c))
+        i += 1
+    out.append("$")
+    return re.compile("".join(out)), negate, dir_only
+
+
+class IgnoreMatcher:
+    """Decides which paths discovery skips.
+
+    Entries of extra_skip_dirs are plain directory names (skipped at any
+    depth) or, when they contain one of *?[]/!\\, gitignore patterns. The
+    gateway merges its ignore profiles into the list. Patterns apply in
+    order after the built-in SKIP_DIRS/SKIP_FILES verdict, so "!vendor/"
+    re-includes a default-skipped directory.
+    """
+
+    def __init__(self, entries: Optional[list[str]] = None):
+        self.dir_names: set[str] = set()
+        self.rules: list[tuple[re.Pattern, bool, bool]] = []
+        for entry in entries or ():
+            if not _PATTERN_CHARS.intersection(entry):
+                if entry.strip():
+                    self.dir_names.add(entry.strip())
+                continue
+            try:
+                self.rules.append(_compile_ignore_pattern(entry))
+            except (ValueError, re.error) as e:
+                log.warning("Ignoring invalid skip pattern %r: %s", entry, e)
+
+    def ignored(self, rel: str, is_dir: bool, skip_by_default: bool = False) -> bool:
+        ignored = skip_by_default or (is_dir and rel.rsplit("/", 1)[-1] in self.dir_names)
+        for regex, negate, dir_only in self.rules:
+            if dir_only and not is_dir:
+                continue
+            if regex.match(rel):
+                ignored = not negate
+        return ignored
+
+
+def _rel(root: Path, dirpath: str, name: str) -> str:
+    rel = os.path.relpath(os.path.join(dirpath, name), root)
+    return rel.replace(os.sep, "/")
+
+
 def discover_files(
     root: Path,
     max_file_size_kb: int = 512,
-    extra_skip_dirs: Optional[set[str]] = None,
+    extra_skip_dirs: Optional[list[str]] = None,
 ) -> list[FileInfo]:
-    """Walk the codebase and collect indexable files."""
-    skip = SKIP_DIRS | (extra_skip_dirs or set())
+    """Walk the codebase and collect indexable files.
+
+    extra_skip_dirs takes directory names and gitignore patterns, see
+    IgnoreMatcher.
+    """
+    matcher = IgnoreMatcher(extra_skip_dirs)
     files: list[FileInfo] = []
 
     for dirpath, dirnames, filenames in os.walk(root):
-        dirnames[:] = [d for d in dirnames if d not in skip and not d.startswith(".")]
+        dirnames[:] = [
+            d for d in dirnames
+            if not d.startswith(".")
+            and not matcher.ignored(_rel(root, dirpath, d), True, d in SKIP_DIRS)
+        ]
 
         for fname in filenames:
-            if fname in SKIP_FILES:
+            if matcher.ignored(_rel(root, dirpath, fname), False, fname in SKIP_FILES):
                 continue
 
             ext = Path(fname).suffix.lower()
@@ -109,16 +228,22 @@ IMAGE_EXTENSIONS: set[str] = {".png", ".jpg", ".jpeg", ".gif", ".webp", ".bmp",
 def discover_images(
     root: Path,
     max_image_size_kb: int = 10240,
-    extra_skip_dirs: Optional[set[str]] = None,
+    extra_skip_dirs: Optional[list[str]] = None,
 ) -> list[ImageFileInfo]:
     """Walk directory tree and collect image files."""
-    skip = SKIP_DIRS | (extra_skip_dirs or set())
+    matcher = IgnoreMatcher(extra_skip_dirs)
     images: list[ImageFileInfo] = []
 
     for dirpath, dirnames, filenames in os.walk(root):
-        dirnames[:] = [d for d in dirnames if d not in skip and not d.startswith(".")]
+        dirnames[:] = [
+            d for d in dirnames
+            if not d.startswith(".")
+            and not matcher.ignored(_rel(root, dirpath, d), True, d in SKIP_DIRS)
+        ]
 
         for fname in filenames:
+            if matcher.ignored(_rel(root, dirpath, fname), False):
+                continue
             ext = Path(fname).suffix.lower()
             if ext not in IMAGE_EXTENSIONS:
                 continue
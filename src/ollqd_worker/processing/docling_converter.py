@@ -26,6 +26,65 @@ def is_available() -> bool:
         return False
 
 
+# OCR option classes per docling OCR engine name.
+_OCR_OPTION_CLASSES: dict[str, str] = {
+    "easyocr": "EasyOcrOptions",
+    "tesseract": "TesseractCliOcrOptions",
+    "tesserocr": "TesseractOcrOptions",
+    "rapidocr": "RapidOcrOptions",
+    "ocrmac": "OcrMacOptions",
+}
+
+
+def parse_languages(value: str) -> list[str]:
+    """Split a comma-separated OCR language setting into codes."""
+    return [lang.strip() for lang in value.split(",") if lang.strip()]
+
+
+def _build_converter(
+    ocr_enabled: bool,
+    ocr_engine: str,
+    table_structure: bool,
+    ocr_languages: list[str] | None,
+    ocr_dpi: int,
+    force_ocr: bool,
+):
+    """Build a DocumentConverter whose PDF and image pipelines honour the OCR settings.
+
+    Falls back to docling's defaults when the installed version lacks
+    the pipeline option classes.
+    """
+    from docling.document_converter import DocumentConverter
+
+    try:
+        from docling.datamodel import pipeline_options
+        from docling.datamodel.base_models import InputFormat
+        from docling.document_converter import ImageFormatOption, PdfFormatOption
+    except ImportError:
+        return DocumentConverter()
+
+    opts = pipeline_options.PdfPipelineOptions()
+    opts.do_ocr = ocr_enabled
+    opts.do_table_structure = table_structure
+    if ocr_dpi > 0:
+        # docling renders pages at 72 DPI per unit of scale.
+        opts.images_scale = ocr_dpi / 72
+
+    cls = getattr(pipeline_options, _OCR_OPTION_CLASSES.get(ocr_engine, ""), None)
+    if cls is not None:
+        kwargs: dict = {"force_full_page_ocr": force_ocr}
+        if ocr_languages:
+            kwargs["lang"] = ocr_languages
+        opts.ocr_options = cls(**kwargs)
+    elif ocr_engine:
+        log.warning("Unknown OCR engine %r, using docling's default", ocr_engine)
+
+    return DocumentConverter(format_options={
+        InputFormat.PDF: PdfFormatOption(pipeline_options=opts),
+        InputFormat.IMAGE: ImageFormatOption(pipeline_options=opts),
+    })
+
+
 def convert_to_markdown(
     file_path: str,
     file_bytes: bytes,
@@ -33,6 +92,9 @@ def convert_to_markdown(
     ocr_engine: str = "easyocr",
     table_structure: bool = True,
     timeout_s: float = 300,
+    ocr_languages: list[str] | None = None,
+    ocr_dpi: int = 0,
+    force_ocr: bool = False,
 ) -> str | None:
     """Convert a document to Markdown using docling.
 
@@ -44,15 +106,16 @@ def convert_to_markdown(
 
     tmp_path = None
     try:
-        from docling.document_converter import DocumentConverter
-
         # Docling needs a file path — write bytes to a temp file
         suffix = Path(file_path).suffix
         with tempfile.NamedTemporaryFile(suffix=suffix, delete=False) as tmp:
             tmp.write(file_bytes)
             tmp_path = tmp.name
 
-        converter = DocumentConverter()
+        converter = _build_converter(
+            ocr_enabled, ocr_engine, table_structure,
+            ocr_languages, ocr_dpi, force_ocr,
+        )
         result = converter.convert(tmp_path)
         md = result.document.export_to_markdown()
 
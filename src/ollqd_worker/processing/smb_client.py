@@ -1,13 +1,77 @@
 """SMB/CIFS client service using pysmb — list, download, and browse remote shares."""
 
 import logging
+import shutil
 import uuid
 from dataclasses import dataclass, field
 from pathlib import Path
 from typing import Optional
 
+from .smb_kerberos import AUTH_KERBEROS, KerberosConnection
+
 log = logging.getLogger("ollqd.web.smb")
 
+# Extensions IndexSMBFiles knows how to chunk; scans skip everything else.
+INDEXABLE_EXTENSIONS = {".pdf", ".md", ".txt", ".rst", ".html"}
+
+# NT status returned for paths behind a DFS link. pysmb cannot follow DFS
+# referrals, so such paths are read through smbprotocol, which can.
+STATUS_PATH_NOT_COVERED = 0xC0000257
+
+
+# Access mask bits that let a principal read a file's content:
+# FILE_READ_DATA, GENERIC_ALL and GENERIC_READ.
+READ_ACCESS_MASK = 0x00000001 | 0x10000000 | 0x80000000
+
+# ACE types and the flag marking ACEs that only apply to children.
+ACE_TYPE_ACCESS_ALLOWED = 0x00
+ACE_TYPE_ACCESS_DENIED = 0x01
+ACE_FLAG_INHERIT_ONLY = 0x08
+
+# A file without a DACL is open to everyone.
+EVERYONE_SID = "S-1-1-0"
+
+
+def summarize_acl(sd) -> dict:
+    """Reduce a pysmb SecurityDescriptor to the owner and group SIDs and the
+    SIDs allowed and denied read access to the file.
+
+    Only ACEs granting or denying read access count; inherit-only ACEs are
+    skipped since they do not apply to the file itself.
+    """
+    allow: list[str] = []
+    deny: list[str] = []
+    if sd.dacl is None:
+        allow.append(EVERYONE_SID)
+    else:
+        for ace in sd.dacl.aces:
+            if ace.flags & ACE_FLAG_INHERIT_ONLY or not ace.mask & READ_ACCESS_MASK:
+                continue
+            sid = str(ace.sid)
+            if ace.type == ACE_TYPE_ACCESS_ALLOWED and sid not in allow:
+                allow.append(sid)
+            elif ace.type == ACE_TYPE_ACCESS_DENIED and sid not in deny:
+                deny.append(sid)
+    return {
+        "owner": str(sd.owner) if sd.owner else "",
+        "group": str(sd.group) if sd.group else "",
+        "allow": allow,
+        "deny": deny,
+    }
+
+
+def _is_dfs_redirect(exc: Exception) -> bool:
+    """Whether a pysmb OperationFailure means the path is a DFS link."""
+    for msg in getattr(exc, "smb_messages", None) or ():
+        if getattr(msg, "status", None) == STATUS_PATH_NOT_COVERED:
+            return True
+    return False
+
+
+def smb_file_label(server: str, share: str, remote_path: str) -> str:
+    """Return the stable file_path recorded for an indexed SMB file."""
+    return f"//{server}/{share}/{remote_path.lstrip('/')}"
+
 
 @dataclass
 class SMBShareConfig:
@@ -19,6 +83,12 @@ class SMBShareConfig:
     domain: str = ""
     port: int = 445
     label: str = ""
+    # "" signs in with NTLMv2; "kerberos" goes through smb_kerberos with
+    # realm, the optional kdc and the keytab (raw bytes) or password.
+    auth: str = ""
+    realm: str = ""
+    kdc: str = ""
+    keytab: bytes = b""
 
     @property
     def display_name(self) -> str:
@@ -45,6 +115,9 @@ class SMBManager:
         return list(self._shares.values())
 
     def _connect(self, config: SMBShareConfig):
+        if config.auth == AUTH_KERBEROS:
+            return KerberosConnection(config)
+
         from smb.SMBConnection import SMBConnection
 
         conn = SMBConnection(
@@ -60,6 +133,95 @@ class SMBManager:
             raise ConnectionError(f"Cannot connect to {config.server}:{config.port}")
         return conn
 
+    # ── DFS ─────────────────────────────────────────────────
+
+    @staticmethod
+    def _dfs_kwargs(config: SMBShareConfig) -> dict:
+        username = config.username or "guest"
+        if config.domain:
+            username = f"{config.domain}\\{username}"
+        return {"username": username, "password": config.password or "", "port": config.port}
+
+    @staticmethod
+    def _unc(config: SMBShareConfig, path: str) -> str:
+        rel = path.strip("/").replace("/", "\\")
+        unc = f"\\\\{config.server}\\{config.share}"
+        return f"{unc}\\{rel}" if rel else unc
+
+    def _dfs_list(self, config: SMBShareConfig, path: str) -> list[dict]:
+        """List path through smbprotocol, following DFS referrals."""
+        try:
+            import smbclient
+        except ImportError as e:
+            raise ConnectionError(
+                f"{path} on //{config.server}/{config.share} is a DFS link; "
+                "install smbprotocol to follow DFS referrals"
+            ) from e
+        out = []
+        for e in smbclient.scandir(self._unc(config, path), **self._dfs_kwargs(config)):
+            st = e.stat()
+            out.append({
+                "name": e.name,
+                "is_dir": e.is_dir(),
+                "size": 0 if e.is_dir() else st.st_size,
+                "mtime": int(st.st_mtime),
+            })
+        return out
+
+    def _dfs_retrieve(self, config: SMBShareConfig, path: str, f) -> None:
+        """Download path through smbprotocol, following DFS referrals."""
+        import smbclient
+
+        with smbclient.open_file(self._unc(config, path), mode="rb", **self._dfs_kwargs(config)) as src:
+            shutil.copyfileobj(src, f)
+
+    def _list(self, conn, config: SMBShareConfig, path: str) -> list[dict]:
+        """List one directory as {name, is_dir, size, mtime} dicts, without
+        "." and "..". Paths behind DFS links are listed via smbprotocol."""
+        from smb.smb_structs import OperationFailure
+
+        try:
+            entries = conn.listPath(config.share, path)
+        except OperationFailure as e:
+            if not _is_dfs_redirect(e):
+                raise
+            log.info("Following DFS referral for //%s/%s%s", config.server, config.share, path)
+            return self._dfs_list(config, path)
+        return [
+            {
+                "name": e.filename,
+                "is_dir": e.isDirectory,
+                "size": e.file_size,
+                "mtime": int(e.last_write_time),
+            }
+            for e in entries
+            if e.filename not in (".", "..")
+        ]
+
+    # ── Operations ──────────────────────────────────────────
+
+    def list_host_shares(self, config: SMBShareConfig, include_hidden: bool = False) -> list[dict]:
+        """List the disk shares of config.server (config.share is ignored).
+
+        Administrative and other hidden shares (names ending in "$") are
+        left out unless include_hidden is set.
+        """
+        from smb.base import SharedDevice
+
+        conn = self._connect(config)
+        try:
+            devices = conn.listShares(timeout=30)
+        finally:
+            conn.close()
+        result = []
+        for d in devices:
+            if d.type != SharedDevice.DISK_TREE:
+                continue
+            if d.name.endswith("$") and not include_hidden:
+                continue
+            result.append({"name": d.name, "comments": d.comments or "", "is_special": d.isSpecial})
+        return sorted(result, key=lambda x: x["name"].lower())
+
     def list_remote_files(self, share_id: str, remote_path: str = "/") -> list[dict]:
         config = self._shares.get(share_id)
         if not config:
@@ -67,42 +229,111 @@ class SMBManager:
 
         conn = self._connect(config)
         try:
-            entries = conn.listPath(config.share, remote_path)
             result = []
-            for e in entries:
-                if e.filename in (".", ".."):
-                    continue
+            for e in self._list(conn, config, remote_path):
                 result.append({
-                    "name": e.filename,
-                    "is_dir": e.isDirectory,
-                    "size": e.file_size,
-                    "path": f"{remote_path.rstrip('/')}/{e.filename}",
+                    "name": e["name"],
+                    "is_dir": e["is_dir"],
+                    "size": e["size"],
+                    "path": f"{remote_path.rstrip('/')}/{e['name']}",
                 })
             return sorted(result, key=lambda x: (not x["is_dir"], x["name"].lower()))
         finally:
             conn.close()
 
+    def walk_remote_files(
+        self, share_id: str, roots: list[str], max_files: int = 0,
+    ) -> tuple[list[dict], bool]:
+        """Recursively list indexable files under roots.
+
+        Returns ({path, size, mtime} dicts sorted by path, truncated). mtime is
+        the last write time in Unix seconds. Hidden entries are skipped.
+        """
+        config = self._shares.get(share_id)
+        if not config:
+            raise ValueError(f"Share {share_id} not found")
+
+        conn = self._connect(config)
+        files: dict[str, dict] = {}
+        try:
+            stack = [r if r.startswith("/") else "/" + r for r in (roots or ["/"])]
+            while stack:
+                path = stack.pop()
+                for e in self._list(conn, config, path):
+                    if e["name"].startswith("."):
+                        continue
+                    full = f"{path.rstrip('/')}/{e['name']}"
+                    if e["is_dir"]:
+                        stack.append(full)
+                        continue
+                    if Path(e["name"]).suffix.lower() not in INDEXABLE_EXTENSIONS:
+                        continue
+                    files[full] = {"path": full, "size": e["size"], "mtime": e["mtime"]}
+                    if max_files and len(files) >= max_files:
+                        return sorted(files.values(), key=lambda f: f["path"]), True
+        finally:
+            conn.close()
+        return sorted(files.values(), key=lambda f: f["path"]), False
+
     def download_files(
         self, share_id: str, remote_paths: list[str], dest_dir: Path,
     ) -> list[str]:
-        """Download remote files to local dest_dir. Returns list of local paths."""
+        """Download remote files to local dest_dir. Returns local paths in the
+        order of remote_paths; each file gets its own subdirectory so equal
+        names from different folders do not collide."""
         config = self._shares.get(share_id)
         if not config:
             raise ValueError(f"Share {share_id} not found")
 
+        from smb.smb_structs import OperationFailure
+
         conn = self._connect(config)
         local_paths = []
         try:
-            for rp in remote_paths:
-                filename = Path(rp).name
-                local_path = dest_dir / filename
+            for i, rp in enumerate(remote_paths):
+                local_path = dest_dir / str(i) / Path(rp).name
+                local_path.parent.mkdir(parents=True, exist_ok=True)
                 with open(local_path, "wb") as f:
-                    conn.retrieveFile(config.share, rp, f)
+                    try:
+                        conn.retrieveFile(config.share, rp, f)
+                    except OperationFailure as e:
+                        if not _is_dfs_redirect(e):
+                            raise
+                        f.seek(0)
+                        f.truncate()
+                        self._dfs_retrieve(config, rp, f)
                 local_paths.append(str(local_path))
         finally:
             conn.close()
         return local_paths
 
+    def read_acls(self, share_id: str, remote_paths: list[str]) -> dict[str, dict]:
+        """Read the owner and DACL of each remote file, summarized by
+        summarize_acl and keyed by remote path.
+
+        Files whose security descriptor cannot be read (no READ_CONTROL
+        right, or behind a DFS link, which pysmb cannot follow) are left
+        out and logged. Kerberos shares return no ACLs.
+        """
+        config = self._shares.get(share_id)
+        if not config:
+            raise ValueError(f"Share {share_id} not found")
+        if config.auth == AUTH_KERBEROS:
+            log.warning("Skipping ACLs of //%s/%s: not supported with Kerberos auth", config.server, config.share)
+            return {}
+
+        conn = self._connect(config)
+        acls = {}
+        try:
+            for rp in remote_paths:
+                try:
+                    acls[rp] = summarize_acl(conn.getSecurity(config.share, rp))
+                except Exception as e:
+                    log.warning("Cannot read ACL of //%s/%s%s: %s", config.server, config.share, rp, e)
+        finally:
+            conn.close()
+        return acls
+
     def test_connection(self, config: SMBShareConfig) -> dict:
         """Test if we can connect and list the share root."""
         try:
@@ -0,0 +1,190 @@
+"""Kerberos sign-in to SMB shares through smbprotocol.
+
+pysmb only speaks NTLM, so shares saved with auth "kerberos" (for domains
+that disable NTLM) are read through smbprotocol's smbclient, which signs in
+with GSSAPI. The ticket is obtained with the share's keytab (via kinit) or
+its password. Each connection writes its own krb5.conf naming the realm and,
+when set, the KDC, so the worker needs no system-wide Kerberos setup.
+"""
+
+import logging
+import os
+import shutil
+import subprocess
+import tempfile
+import threading
+from dataclasses import dataclass
+from pathlib import Path
+
+log = logging.getLogger("ollqd.web.smb")
+
+AUTH_KERBEROS = "kerberos"
+
+# KRB5_CONFIG and KRB5CCNAME are process-wide; a sign-in holds this lock for
+# as long as it has them set.
+_env_lock = threading.Lock()
+
+
+def kerberos_available() -> bool:
+    """Whether smbprotocol and its Kerberos dependencies are installed."""
+    try:
+        import gssapi  # noqa: F401
+        import smbclient  # noqa: F401
+    except ImportError:
+        return False
+    return True
+
+
+def request_auth(request) -> dict:
+    """Return the SMBShareConfig auth settings of a typed request (auth,
+    realm, kdc, keytab): {} for NTLMv2 or a gateway that predates them."""
+    auth = getattr(request, "auth", "")
+    if not auth:
+        return {}
+    return {
+        "auth": auth,
+        "realm": request.realm,
+        "kdc": request.kdc,
+        "keytab": bytes(request.keytab),
+    }
+
+
+@dataclass
+class _Entry:
+    """A directory entry shaped like pysmb's SharedFile."""
+
+    filename: str
+    isDirectory: bool
+    file_size: int
+    last_write_time: float
+
+
+class KerberosConnection:
+    """The part of pysmb's SMBConnection that SMBManager uses, over a
+    Kerberos-authenticated smbprotocol session.
+
+    DFS links are followed by smbprotocol itself. Listing a host's shares
+    and reading security descriptors are not supported.
+    """
+
+    def __init__(self, config):
+        if not kerberos_available():
+            raise ConnectionError(
+                "Kerberos SMB auth needs smbprotocol with Kerberos support; "
+                "install ollqd[kerberos] on the worker"
+            )
+        self._config = config
+        self._cache: dict = {}
+        self._tmp = Path(tempfile.mkdtemp(prefix="ollqd_krb5_"))
+        try:
+            self._sign_in()
+        except Exception:
+            self.close()
+            raise
+
+    @property
+    def _principal(self) -> str:
+        user = self._config.username
+        return user if "@" in user else f"{user}@{self._config.realm}"
+
+    @property
+    def _kwargs(self) -> dict:
+        return {
+            "username": self._principal,
+            "port": self._config.port,
+            "auth_protocol": "kerberos",
+            "connection_cache": self._cache,
+        }
+
+    def _unc(self, share: str, path: str) -> str:
+        rel = path.strip("/").replace("/", "\\")
+        unc = f"\\\\{self._config.server}\\{share}"
+        return f"{unc}\\{rel}" if rel else unc
+
+    def _write_krb5_conf(self) -> Path:
+        realm = self._config.realm
+        lines = [
+            "[libdefaults]",
+            f"  default_realm = {realm}",
+            "  dns_lookup_kdc = true",
+            "  rdns = false",
+        ]
+        if self._config.kdc:
+            lines += ["[realms]", f"  {realm} = {{", f"    kdc = {self._config.kdc}", "  }"]
+        path = self._tmp / "krb5.conf"
+        path.write_text("\n".join(lines) + "\n")
+        return path
+
+    def _kinit_keytab(self, env: dict) -> str:
+        """Get a ticket for the principal from the share's keytab into a
+        private credential cache and return the cache name."""
+        kinit = shutil.which("kinit")
+        if not kinit:
+            raise ConnectionError("kinit not found; install the Kerberos client tools (krb5-user) to use keytabs")
+        keytab = self._tmp / "client.keytab"
+        keytab.write_bytes(self._config.keytab)
+        keytab.chmod(0o600)
+        ccache = f"FILE:{self._tmp / 'ccache'}"
+        proc = subprocess.run(
+            [kinit, "-k", "-t", str(keytab), "-c", ccache, self._principal],
+            env={**os.environ, **env}, capture_output=True, text=True, timeout=30,
+        )
+        if proc.returncode != 0:
+            msg = (proc.stderr or proc.stdout).strip()
+            raise ConnectionError(f"kinit for {self._principal} failed: {msg}")
+        return ccache
+
+    def _sign_in(self) -> None:
+        import smbclient
+
+        env = {"KRB5_CONFIG": str(self._write_krb5_conf())}
+        password = self._config.password or None
+        if self._config.keytab:
+            env["KRB5CCNAME"] = self._kinit_keytab(env)
+            password = None
+        else:
+            # The ticket bought with the password stays in memory.
+            env["KRB5CCNAME"] = f"MEMORY:ollqd-{id(self)}"
+
+        with _env_lock:
+            saved = {k: os.environ.get(k) for k in env}
+            os.environ.update(env)
+            try:
+                smbclient.register_session(self._config.server, password=password, **self._kwargs)
+            finally:
+                for k, v in saved.items():
+                    if v is None:
+                        os.environ.pop(k, None)
+                    else:
+                        os.environ[k] = v
+        log.info("Signed in to %s as %s with Kerberos", self._config.server, self._principal)
+
+    def listPath(self, share: str, path: str) -> list[_Entry]:
+        import smbclient
+
+        out = []
+        for e in smbclient.scandir(self._unc(share, path), **self._kwargs):
+            st = e.stat()
+            out.append(_Entry(e.name, e.is_dir(), 0 if e.is_dir() else st.st_size, st.st_mtime))
+        return out
+
+    def retrieveFile(self, share: str, path: str, f) -> None:
+        import smbclient
+
+        with smbclient.open_file(self._unc(share, path), mode="rb", **self._kwargs) as src:
+            shutil.copyfileobj(src, f)
+
+    def listShares(self, timeout: int = 30):
+        raise ConnectionError("listing a host's shares is not supported with Kerberos auth")
+
+    def getSecurity(self, share: str, path: str):
+        raise NotImplementedError("reading ACLs is not supported with Kerberos auth")
+
+    def close(self) -> None:
+        try:
+            import smbclient
+
+            smbclient.reset_connection_cache(fail_on_error=False, connection_cache=self._cache)
+        except ImportError:
+            pass
+        shutil.rmtree(self._tmp, ignore_errors=True)
@@ -0,0 +1,123 @@
+"""Audio and video transcription with faster-whisper.
+
+Recordings are transcribed into timestamped segments, which are grouped
+into chunks that keep their time range. Transcript chunks use the
+"transcript" language and hold the range twice: precisely in start_time
+and end_time (seconds), and in whole seconds in start_line and end_line,
+which is what search results report.
+"""
+
+import logging
+import math
+import threading
+from dataclasses import dataclass
+
+from ..models import Chunk
+
+log = logging.getLogger("ollqd.transcription")
+
+TRANSCRIPT_LANGUAGE = "transcript"
+
+AUDIO_EXTENSIONS = (".mp3", ".wav", ".m4a", ".ogg", ".oga", ".opus", ".flac", ".aac")
+VIDEO_EXTENSIONS = (".mp4", ".m4v", ".mkv", ".webm", ".mov", ".avi")
+MEDIA_EXTENSIONS = AUDIO_EXTENSIONS + VIDEO_EXTENSIONS
+
+# Loaded models by (model, device, compute_type); loading takes seconds.
+_models: dict[tuple[str, str, str], object] = {}
+_models_lock = threading.Lock()
+
+
+@dataclass(slots=True)
+class Segment:
+    start: float
+    end: float
+    text: str
+
+
+def is_available() -> bool:
+    """Check if faster-whisper is installed without loading a model."""
+    try:
+        import faster_whisper  # noqa: F401
+        return True
+    except ImportError:
+        return False
+
+
+def format_timestamp(seconds: float) -> str:
+    """Format seconds as M:SS, or H:MM:SS for recordings over an hour."""
+    total = int(seconds)
+    h, rest = divmod(total, 3600)
+    m, s = divmod(rest, 60)
+    if h:
+        return f"{h}:{m:02d}:{s:02d}"
+    return f"{m}:{s:02d}"
+
+
+def _model(cfg):
+    key = (cfg.model, cfg.device, cfg.compute_type)
+    with _models_lock:
+        model = _models.get(key)
+        if model is None:
+            from faster_whisper import WhisperModel
+
+            log.info("Loading whisper model %s (device=%s, compute_type=%s)", *key)
+            model = WhisperModel(cfg.model, device=cfg.device, compute_type=cfg.compute_type)
+            _models[key] = model
+        return model
+
+
+def transcribe(file_path: str, cfg) -> tuple[list[Segment], str, float]:
+    """Transcribe the recording at file_path with the TranscriptionConfig cfg.
+
+    Returns the non-empty segments, the spoken language and the duration
+    in seconds. Video files are decoded for their audio track.
+    """
+    segments, info = _model(cfg).transcribe(
+        file_path, language=cfg.language or None, vad_filter=True,
+    )
+    out = [Segment(s.start, s.end, s.text.strip()) for s in segments if s.text.strip()]
+    log.info("Transcribed %s: %d segments, %.0fs, language %s",
+             file_path, len(out), info.duration, info.language)
+    return out, info.language, info.duration
+
+
+def chunk_transcript(
+    file_path: str,
+    segments: list[Segment],
+    chunk_size: int = 512,
+    content_hash: str = "",
+) -> list[Chunk]:
+    """Group consecutive segments into chunks of about chunk_size characters.
+
+    Each segment becomes a "[M:SS] text" line, so chunk content shows where
+    it was said. Segments are never split.
+    """
+    groups: list[list[Segment]] = []
+    current: list[Segment] = []
+    size = 0
+    for seg in segments:
+        line = len(seg.text) + 10
+        if current and size + line > chunk_size:
+            groups.append(current)
+            current, size = [], 0
+        current.append(seg)
+        size += line
+    if current:
+        groups.append(current)
+
+    chunks = []
+    for i, group in enumerate(groups):
+        start, end = group[0].start, group[-1].end
+        chunks.append(Chunk(
+            file_path=file_path,
+            language=TRANSCRIPT_LANGUAGE,
+            chunk_index=i,
+            total_chunks=len(groups),
+            start_line=int(start),
+            end_line=max(int(start), math.ceil(end)),
+            content="\n".join(f"[{format_timestamp(s.start)}] {s.text}" for s in group),
+            content_hash=content_hash,
+            start_time=round(start, 2),
+            end_time=round(end, 2),
+        ))
+    return chunks
@@ -1,6 +1,7 @@
 """Qdrant vector store manager."""
 
 import logging
+from datetime import datetime, timezone
 from typing import Optional
 
 from qdrant_client import QdrantClient
@@ -11,6 +12,9 @@ from qdrant_client.models import (
     MatchValue,
     PayloadSchemaType,
     PointStruct,
+    TextIndexParams,
+    TextIndexType,
+    TokenizerType,
     VectorParams,
 )
 
@@ -49,12 +53,26 @@ class QdrantManager:
             collection_name=self.collection,
             vectors_config=VectorParams(size=self.dimension, distance=dist),
         )
-        for field in ("file_path", "language", "content_hash"):
+        # provenance.* keys back filtering and cleanup by index run or route.
+        for field in ("file_path", "language", "content_hash",
+                      "provenance.task_id", "provenance.source_type", "provenance.uploader"):
             self.client.create_payload_index(
                 collection_name=self.collection,
                 field_name=field,
                 field_schema=PayloadSchemaType.KEYWORD,
             )
+        # The gateway's keyword search filters on the chunk text.
+        self.client.create_payload_index(
+            collection_name=self.collection,
+            field_name="content",
+            field_schema=TextIndexParams(
+                type=TextIndexType.TEXT,
+                tokenizer=TokenizerType.WORD,
+                lowercase=True,
+                min_token_len=2,
+                max_token_len=40,
+            ),
+        )
         log.info("Created collection '%s' (dim=%d, %s)", self.collection, self.dimension, self.distance)
 
     def get_indexed_hashes(self) -> dict[str, str]:
@@ -86,6 +104,11 @@ class QdrantManager:
         )
 
     def upsert_batch(self, points: list[PointStruct]):
+        # indexed_at dates every point for the gateway's retention policies.
+        indexed_at = datetime.now(timezone.utc).strftime("%Y-%m-%dT%H:%M:%SZ")
+        for p in points:
+            if p.payload is not None:
+                p.payload.setdefault("indexed_at", indexed_at)
         self.client.upsert(collection_name=self.collection, points=points)
 
     def search(
@@ -120,6 +143,7 @@ class QdrantManager:
                 "lines": f"{point.payload.get('start_line', '?')}-{point.payload.get('end_line', '?')}",
                 "chunk": f"{point.payload.get('chunk_index', 0) + 1}/{point.payload.get('total_chunks', '?')}",
                 "content": point.payload.get("content", ""),
+                "indexed_at": point.payload.get("indexed_at", ""),
             }
             # Include extra fields for image results
             if point.payload.get("language") == "image":
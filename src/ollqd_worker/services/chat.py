@@ -32,10 +32,10 @@ def _get_pii_service() -> PIIMaskingService:
     return _pii_service
 
 
-def _make_embedder() -> OllamaEmbedder:
+def _make_embedder(base_url: str = "") -> OllamaEmbedder:
     cfg = get_config()
     return OllamaEmbedder(
-        base_url=cfg.ollama.base_url,
+        base_url=base_url or cfg.ollama.base_url,
         model=cfg.ollama.embed_model,
         timeout=cfg.ollama.timeout_s,
     )
@@ -52,12 +52,174 @@ def _make_chat_event(event_type: str, **kwargs):
     return _Event(type=event_type, **kwargs)
 
 
+def _chat_options(request, context) -> dict:
+    """Read the optional generation/retrieval overrides set on the
+    ChatRequest, and the x-ollqd-* gRPC metadata sent alongside it."""
+    opts: dict = {}
+    options: dict = {}
+
+    def has(field: str) -> bool:
+        try:
+            return request.HasField(field)
+        except (AttributeError, ValueError):
+            return False
+
+    if has("temperature"):
+        options["temperature"] = request.temperature
+    if has("top_p"):
+        options["top_p"] = request.top_p
+    if has("max_tokens"):
+        options["num_predict"] = request.max_tokens
+    if has("top_k"):
+        opts["top_k"] = request.top_k
+    if getattr(request, "system_prompt", ""):
+        opts["system_prompt"] = request.system_prompt
+    if has("context_max_tokens"):
+        opts["context_max_tokens"] = request.context_max_tokens
+    if has("source_max_tokens"):
+        opts["source_max_tokens"] = request.source_max_tokens
+    if has("dedupe_files"):
+        opts["dedupe_files"] = request.dedupe_files
+    if getattr(request, "context_order", "") in CONTEXT_ORDERS:
+        opts["context_order"] = request.context_order
+    if options:
+        opts["options"] = options
+    history = _chat_history(getattr(request, "history", ()))
+    if history:
+        opts["history"] = history
+
+    try:
+        md = dict(context.invocation_metadata() or ())
+    except Exception:
+        return opts
+    if md.get("x-ollqd-ollama-url"):
+        # The turn is pinned to another Ollama instance.
+        opts["ollama_url"] = md["x-ollqd-ollama-url"]
+    if md.get("x-ollqd-chat-collections"):
+        collections = _chat_collections(md["x-ollqd-chat-collections"])
+        if collections:
+            opts["collections"] = collections
+    return opts
+
+
+def _chat_history(turns) -> list[dict]:
+    """Return the earlier turns of a conversation (ChatRequest.history) as
+    {"role", "content"} dicts; anything but user and assistant turns is
+    left out."""
+    return [
+        {"role": t.role, "content": t.content}
+        for t in turns
+        if t.role in ("user", "assistant")
+    ]
+
+
+def _chat_collections(raw: str) -> list[str]:
+    """Parse the collections a routed chat turn searches, sent as a JSON
+    list of names."""
+    try:
+        names = json.loads(raw)
+    except ValueError as e:
+        log.warning("Ignoring malformed chat collections metadata: %s", e)
+        return []
+    if not isinstance(names, list):
+        return []
+    return [str(n) for n in names if n]
+
+
+# Orders in which retrieved chunks are placed in the prompt: best match
+# first, or most recently indexed first.
+CONTEXT_ORDERS = ("score", "recency")
+
+# Characters per token assumed when estimating the size of the context;
+# there is no tokenizer for the chat model at hand.
+CHARS_PER_TOKEN = 4
+
+# Fewest tokens worth keeping of a chunk cut to fit the context budget.
+MIN_PACKED_TOKENS = 32
+
+
+def _estimate_tokens(text: str) -> int:
+    return (len(text) + CHARS_PER_TOKEN - 1) // CHARS_PER_TOKEN
+
+
+# Marks where _cut shortened a chunk.
+CUT_MARKER = "\n[...]"
+
+
+def _cut(text: str, max_tokens: int) -> str:
+    """Cut text to at most max_tokens, at a line break when one is near."""
+    if len(text) <= max_tokens * CHARS_PER_TOKEN:
+        return text
+    limit = max_tokens * CHARS_PER_TOKEN - len(CUT_MARKER)
+    cut = text.rfind("\n", limit // 2, limit)
+    return text[: cut if cut > 0 else limit].rstrip() + CUT_MARKER
+
+
+def _render_source(s: dict) -> str:
+    if s.get("language") == "image":
+        return f"[Image: {s['file_path']}]\nCaption: {s['content']}"
+    return f"[{s['file_path']} L{s['lines']}]\n{s['content']}"
+
+
+def _pack_context(sources: list[dict], top_k: int, packing: dict) -> list[dict]:
+    """Choose the retrieved chunks that go into the prompt, in prompt order.
+
+    sources are sorted best first. With dedupe_files only the best chunk of
+    each file is kept; then the top_k best are placed in context_order, each
+    cut to source_max_tokens, until context_max_tokens are used. The chunk
+    reaching the budget is cut to fit, or left out when little room is left.
+    """
+    if packing["dedupe_files"]:
+        seen = set()
+        unique = []
+        for s in sources:
+            if s.get("file_path") not in seen:
+                seen.add(s.get("file_path"))
+                unique.append(s)
+        sources = unique
+    sources = sources[:top_k]
+    if packing["context_order"] == "recency":
+        # indexed_at is an RFC 3339 UTC timestamp, so it sorts as text;
+        # the sort is stable, keeping score order among equal times.
+        sources = sorted(sources, key=lambda s: s.get("indexed_at") or "", reverse=True)
+
+    packed = []
+    budget = packing["context_max_tokens"]
+    used = 0
+    for s in sources:
+        if packing["source_max_tokens"] > 0 and s.get("language") != "image":
+            s = {**s, "content": _cut(s.get("content", ""), packing["source_max_tokens"])}
+        if budget > 0:
+            need = _estimate_tokens(_render_source(s))
+            if used + need > budget:
+                room = budget - used - (need - _estimate_tokens(s.get("content", "")))
+                if room < MIN_PACKED_TOKENS or s.get("language") == "image":
+                    break
+                s = {**s, "content": _cut(s.get("content", ""), room)}
+                need = _estimate_tokens(_render_source(s))
+            used += need
+        packed.append(s)
+    return packed
+
+
+def _context_packing(chat_opts: dict) -> dict:
+    """Return the packing options of a chat turn. Without any, every chunk
+    is used whole, best match first."""
+    return {
+        "context_max_tokens": chat_opts.get("context_max_tokens", 0),
+        "source_max_tokens": chat_opts.get("source_max_tokens", 0),
+        "dedupe_files": chat_opts.get("dedupe_files", False),
+        "context_order": chat_opts.get("context_order", "score"),
+    }
+
+
 class ChatServiceServicer:
     """gRPC servicer for RAG chat (server streaming).
 
     The Chat RPC:
       1. Embeds the user query via OllamaEmbedder
-      2. Searches Qdrant for top-k context hits
+      2. Searches Qdrant for top-k context hits and packs them into the
+         context budget
       3. Optionally masks PII in query + context
       4. Builds the prompt (system + context + user query)
       5. Streams the Ollama response back as ChatEvent messages
@@ -75,6 +237,7 @@ class ChatServiceServicer:
         collection = request.collection if hasattr(request, "collection") and request.collection else "codebase"
         model = request.model if hasattr(request, "model") and request.model else cfg.ollama.chat_model
         pii_enabled = request.pii_enabled if hasattr(request, "pii_enabled") else cfg.pii.enabled
+        chat_opts = _chat_options(request, context)
 
         if not query:
             yield _make_chat_event("error", content="query is required")
@@ -86,38 +249,47 @@ class ChatServiceServicer:
         # ── Step 1: Semantic search for context ──
         sources = []
         context_text = ""
-        embedder = _make_embedder()
+        ollama_url = chat_opts.get("ollama_url") or cfg.ollama.base_url
+        embedder = _make_embedder(ollama_url)
         try:
             dim = embedder.get_dimension()
-            qdrant = QdrantManager(
-                url=cfg.qdrant.url,
-                collection=collection,
-                dimension=dim,
-            )
             query_vec = embedder.embed_query(query)
-            sources = qdrant.search(query_vec, top_k=5)
-            context_parts = []
-            for s in sources:
-                if s.get("language") == "image":
-                    context_parts.append(f"[Image: {s['file_path']}]\nCaption: {s['content']}")
-                else:
-                    context_parts.append(f"[{s['file_path']} L{s['lines']}]\n{s['content']}")
-            context_text = "\n\n".join(context_parts)
+            top_k = chat_opts.get("top_k", 5)
+            packing = _context_packing(chat_opts)
+            # Deduplicating by file needs more chunks to still fill top_k.
+            limit = top_k * 3 if packing["dedupe_files"] else top_k
+            # A routed turn searches several collections and keeps the
+            # best hits of all of them.
+            for name in chat_opts.get("collections") or [collection]:
+                qdrant = QdrantManager(
+                    url=cfg.qdrant.url,
+                    collection=name,
+                    dimension=dim,
+                )
+                try:
+                    sources.extend(qdrant.search(query_vec, top_k=limit))
+                except Exception as e:
+                    log.warning("Search in %s failed: %s", name, e)
+            sources = sorted(sources, key=lambda s: s.get("score", 0.0), reverse=True)
+            sources = _pack_context(sources, top_k, packing)
+            context_text = "\n\n".join(_render_source(s) for s in sources)
         except Exception as e:
             log.warning("Search failed, chatting without context: %s", e)
         finally:
             embedder.close()
 
         # ── Step 2: PII masking ──
+        history = chat_opts.get("history", [])
         if registry is not None:
             masked_query = pii_svc.mask_text(query, registry)
             masked_context = pii_svc.mask_text(context_text, registry) if context_text else ""
+            history = [{**t, "content": pii_svc.mask_text(t["content"], registry)} for t in history]
         else:
             masked_query = query
             masked_context = context_text
 
         # ── Step 3: Build messages ──
-        system_content = (
+        system_content = chat_opts.get("system_prompt") or (
             "You are a helpful assistant with access to code and image context. "
             "Use the following context to answer questions. "
             "For code, cite file paths and line numbers. "
@@ -130,16 +302,18 @@ class ChatServiceServicer:
 
         messages = [
             {"role": "system", "content": system_content},
+            *history,
             {"role": "user", "content": masked_query},
         ]
 
         # ── Step 4: Stream Ollama response ──
-        ollama = OllamaService(base_url=cfg.ollama.base_url, timeout=cfg.ollama.timeout_s)
+        ollama = OllamaService(base_url=ollama_url, timeout=cfg.ollama.timeout_s)
+        gen_kwargs = {"options": chat_opts["options"]} if chat_opts.get("options") else {}
         pii_info = {}
         try:
             if registry is not None and registry.has_entities:
                 buffer = pii_svc.create_stream_buffer(registry)
-                async for chunk in ollama.chat_stream(model=model, messages=messages):
+                async for chunk in ollama.chat_stream(model=model, messages=messages, **gen_kwargs):
                     if context.cancelled():
                         yield _make_chat_event("cancelled", content="Request cancelled by client")
                         return
@@ -154,7 +328,7 @@ class ChatServiceServicer:
                     "pii_entities_count": len(registry.token_to_value),
                 }
             else:
-                async for chunk in ollama.chat_stream(model=model, messages=messages):
+                async for chunk in ollama.chat_stream(model=model, messages=messages, **gen_kwargs):
                     if context.cancelled():
                         yield _make_chat_event("cancelled", content="Request cancelled by client")
                         return
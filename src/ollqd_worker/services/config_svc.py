@@ -56,6 +56,9 @@ def _cfg_to_dict(cfg) -> dict:
             "ocr_engine": cfg.docling.ocr_engine,
             "table_structure": cfg.docling.table_structure,
             "timeout_s": cfg.docling.timeout_s,
+            "ocr_languages": cfg.docling.ocr_languages,
+            "ocr_dpi": cfg.docling.ocr_dpi,
+            "force_ocr": cfg.docling.force_ocr,
         },
     }
 
@@ -1,10 +1,13 @@
 """IndexingService gRPC servicer — all indexing operations with server-streaming progress."""
 
+import asyncio
 import base64
 import hashlib
 import json
 import logging
+import os
 import uuid
+from dataclasses import replace
 from pathlib import Path
 from tempfile import mkdtemp
 
@@ -14,16 +17,19 @@ from qdrant_client.models import PointStruct
 from ..config import get_config
 from ..errors import EmbeddingError, VectorStoreError
 from ..processing.chunking import (
+    TABLE_EXTENSIONS,
+    assign_pages,
     chunk_document,
-    chunk_docx,
     chunk_file,
     chunk_pdf,
-    chunk_pptx,
-    chunk_with_docling,
-    chunk_xlsx,
+    chunk_table,
+    extract_text,
 )
 from ..processing.discovery import discover_files, discover_images
 from ..processing.embedder import OllamaEmbedder
+from ..processing.smb_kerberos import request_auth
+from ..processing.transcription import MEDIA_EXTENSIONS, chunk_transcript, transcribe
+from ..processing.transcription import is_available as transcription_is_available
 from ..processing.vectorstore import QdrantManager
 
 log = logging.getLogger("ollqd.worker.indexing")
@@ -41,13 +47,198 @@ except ImportError:
 _cancelled_tasks: set[str] = set()
 
 
+def _image_meta_from_metadata(context) -> dict[str, dict]:
+    """Read the image metadata map (absolute path -> payload fields) the
+    gateway extracts from EXIF, sent inline as x-ollqd-image-meta or as a
+    file path in x-ollqd-image-meta-file."""
+    try:
+        md = dict(context.invocation_metadata() or ())
+    except Exception:
+        return {}
+    try:
+        raw = md.get("x-ollqd-image-meta")
+        if not raw and md.get("x-ollqd-image-meta-file"):
+            raw = Path(md["x-ollqd-image-meta-file"]).read_text()
+        if not raw:
+            return {}
+        data = json.loads(raw)
+        return data if isinstance(data, dict) else {}
+    except (OSError, ValueError, TypeError) as e:
+        log.warning("Ignoring malformed image metadata: %s", e)
+        return {}
+
+
+def _lookup_image_meta(metas: dict[str, dict], path: str) -> dict:
+    """Find the gateway metadata for path, which the gateway keys by its
+    symlink-resolved absolute path."""
+    if not metas:
+        return {}
+    meta = metas.get(path) or metas.get(os.path.realpath(path))
+    return meta if isinstance(meta, dict) else {}
+
+
+def _display_names_from_metadata(context) -> dict[str, str]:
+    """Read the original file names (saved path -> name as uploaded) the
+    gateway sends as x-ollqd-display-names when it preserves upload names."""
+    try:
+        md = dict(context.invocation_metadata() or ())
+    except Exception:
+        return {}
+    raw = md.get("x-ollqd-display-names")
+    if not raw:
+        return {}
+    try:
+        data = json.loads(raw)
+    except (ValueError, TypeError) as e:
+        log.warning("Ignoring malformed display name metadata: %s", e)
+        return {}
+    if not isinstance(data, dict):
+        return {}
+    return {str(k): str(v) for k, v in data.items() if v}
+
+
+def _file_meta_from_metadata(context) -> dict[str, dict]:
+    """Read the extra payload fields per saved path (e.g. email sender and
+    subject) the gateway's connectors send as x-ollqd-file-meta."""
+    try:
+        md = dict(context.invocation_metadata() or ())
+    except Exception:
+        return {}
+    raw = md.get("x-ollqd-file-meta")
+    if not raw:
+        return {}
+    try:
+        data = json.loads(raw)
+    except (ValueError, TypeError) as e:
+        log.warning("Ignoring malformed file metadata: %s", e)
+        return {}
+    if not isinstance(data, dict):
+        return {}
+    return {str(k): v for k, v in data.items() if isinstance(v, dict)}
+
+
+def _request_provenance(request) -> dict | None:
+    """Return the provenance record (source type, uploader, task, gateway
+    version, optional per-file sources) the gateway sets on every index
+    request, or None if it has none."""
+    if not request.HasField("provenance"):
+        return None
+    p = request.provenance
+    out = {
+        "schema": p.schema,
+        "source_type": p.source_type,
+        "indexed_at": p.indexed_at,
+        "task_id": p.task_id,
+        "gateway_version": p.gateway_version,
+    }
+    if p.share_id:
+        out["share_id"] = p.share_id
+    if p.uploader:
+        out["uploader"] = p.uploader
+    if p.sources:
+        out["sources"] = dict(p.sources)
+    return out
+
+
+def _point_provenance(provenance: dict | None, path: str, source: str) -> dict:
+    """Return the "provenance" payload field of a point of the file read from
+    path: the gateway's record with its per-file sources resolved to
+    "source", which falls back to the given name or path. Empty without a
+    record."""
+    if provenance is None:
+        return {}
+    out = {k: v for k, v in provenance.items() if k != "sources"}
+    sources = provenance.get("sources")
+    out["source"] = (sources.get(path) if isinstance(sources, dict) else None) or source
+    return {"provenance": out}
+
+
+def _docling_from_metadata(context, docling):
+    """Apply the OCR choice of an upload routing rule, sent as
+    x-ollqd-docling-ocr metadata, to the configured docling settings.
+    Asking for OCR also turns docling on."""
+    try:
+        md = dict(context.invocation_metadata() or ())
+    except Exception:
+        return docling
+    raw = md.get("x-ollqd-docling-ocr")
+    if raw == "true":
+        return replace(docling, enabled=True, ocr_enabled=True)
+    if raw == "false":
+        return replace(docling, ocr_enabled=False)
+    return docling
+
+
+def _table_options_from_metadata(context) -> dict | None:
+    """Read the table chunking options the gateway sends as
+    x-ollqd-table-options metadata, or None to treat spreadsheets as text."""
+    try:
+        md = dict(context.invocation_metadata() or ())
+    except Exception:
+        return None
+    raw = md.get("x-ollqd-table-options")
+    if not raw:
+        return None
+    try:
+        data = json.loads(raw)
+    except (ValueError, TypeError) as e:
+        log.warning("Ignoring malformed table options metadata: %s", e)
+        return None
+    return data if isinstance(data, dict) else None
+
+
+def _ollama_url_from_metadata(context, default: str) -> str:
+    """The Ollama base URL a request is pinned to (x-ollqd-ollama-url
+    metadata), or default."""
+    try:
+        md = dict(context.invocation_metadata() or ())
+    except Exception:
+        return default
+    return md.get("x-ollqd-ollama-url") or default
+
+
+def _smb_acls_from_metadata(context) -> bool:
+    """Whether the gateway asked IndexSMBFiles to capture file ACLs
+    (x-ollqd-smb-acls metadata)."""
+    try:
+        md = dict(context.invocation_metadata() or ())
+    except Exception:
+        return False
+    return md.get("x-ollqd-smb-acls") == "true"
+
+
+class _FileErrors:
+    """Files an indexing run failed to index. Each progress event takes the
+    errors added since the previous one, and the gateway keeps them as the
+    task's error catalog."""
+
+    def __init__(self):
+        self._pending: list[dict] = []
+
+    def add(self, file, stage: str, error) -> None:
+        self._pending.append({"file": str(file), "stage": stage, "error": str(error)[:500]})
+
+    def add_batch(self, files, stage: str, error) -> None:
+        """Record a failed batch against each distinct file it held."""
+        for f in dict.fromkeys(files):
+            self.add(f, stage, error)
+
+    def drain(self) -> list[dict]:
+        pending, self._pending = self._pending, []
+        return pending
+
+
 def _make_progress(task_id: str, status: str, progress: float = 0.0,
-                   message: str = "", result_json: str = ""):
+                   message: str = "", result_json: str = "",
+                   file_errors: list[dict] | None = None):
     """Build a TaskProgress message.
 
     Proto fields: task_id, progress, status, error, result (map<string,string>).
-    ``message`` is mapped to error for non-completed statuses.
+    ``message`` is mapped to error for failed events only; a cancellation is
+    not an error.
     ``result_json`` is parsed and placed in the result map if provided.
+    ``file_errors`` (see _FileErrors) go in the result map as the JSON
+    "file_errors" entry.
     """
     result_map: dict[str, str] = {}
     if result_json:
@@ -56,8 +247,10 @@ def _make_progress(task_id: str, status: str, progress: float = 0.0,
             result_map = {str(k): str(v) for k, v in parsed.items()}
         except (json.JSONDecodeError, AttributeError):
             result_map = {"raw": result_json}
+    if file_errors:
+        result_map["file_errors"] = json.dumps(file_errors)
 
-    error_str = message if status in ("failed", "cancelled") else ""
+    error_str = message if status == "failed" else ""
 
     if _STUBS_AVAILABLE:
         return types_pb2.TaskProgress(
@@ -80,6 +273,13 @@ def _make_progress(task_id: str, status: str, progress: float = 0.0,
     )
 
 
+def _cancelled_progress(task_id: str, progress: float, file_errors: list[dict] | None = None, **partial):
+    """Build the event for a cancelled task. It reports how far the task got
+    (progress and counts so far) so the gateway keeps the partial progress."""
+    partial["partial"] = "true"
+    return _make_progress(task_id, "cancelled", progress, "", json.dumps(partial), file_errors)
+
+
 def _caption_image_sync(base_url: str, model: str, image_b64: str,
                         prompt: str, timeout: float = 180.0) -> str:
     """Synchronous vision captioning for image indexing."""
@@ -114,6 +314,7 @@ class IndexingServiceServicer:
     async def IndexCodebase(self, request, context):
         """Index source code files from a codebase directory."""
         cfg = get_config()
+        ollama_url = _ollama_url_from_metadata(context, cfg.ollama.base_url)
         task_id = uuid.uuid4().hex[:12]
 
         root_path = request.root_path if hasattr(request, "root_path") else ""
@@ -122,6 +323,13 @@ class IndexingServiceServicer:
         chunk_size = request.chunk_size if hasattr(request, "chunk_size") and request.chunk_size > 0 else cfg.chunking.chunk_size
         chunk_overlap = request.chunk_overlap if hasattr(request, "chunk_overlap") and request.chunk_overlap >= 0 else cfg.chunking.chunk_overlap
         extra_skip_dirs = list(request.extra_skip_dirs) if hasattr(request, "extra_skip_dirs") else []
+        # An explicit root-relative file list replaces the scan and the hash
+        # comparison.
+        explicit_files = set(request.files) if getattr(request, "files", None) else None
+        # Uploads routed to this indexer carry their original names, keyed
+        # by root-relative path.
+        display_names = _display_names_from_metadata(context)
+        provenance = _request_provenance(request)
 
         yield _make_progress(task_id, "running", 0.0, "Starting codebase indexing")
 
@@ -131,7 +339,8 @@ class IndexingServiceServicer:
             return
 
         # Discover files
-        files = discover_files(root, cfg.chunking.max_file_size_kb, set(extra_skip_dirs))
+        files = discover_files(root, cfg.chunking.max_file_size_kb, extra_skip_dirs)
+        discovered = len(files)
         if not files:
             yield _make_progress(task_id, "completed", 1.0, "No indexable files",
                                  json.dumps({"files": 0, "chunks": 0}))
@@ -139,7 +348,7 @@ class IndexingServiceServicer:
 
         # Setup embedder + Qdrant
         embedder = OllamaEmbedder(
-            base_url=cfg.ollama.base_url,
+            base_url=ollama_url,
             model=cfg.ollama.embed_model,
             timeout=cfg.ollama.timeout_s,
         )
@@ -150,8 +359,19 @@ class IndexingServiceServicer:
         )
         qdrant.ensure_collection()
 
+        # Explicit file list from the gateway manifest: index exactly those
+        # files, replacing any points they already have.
+        if explicit_files is not None:
+            files = [f for f in files if f.path in explicit_files]
+            for f in files:
+                qdrant.delete_file_points(f.path)
+            if not files:
+                embedder.close()
+                yield _make_progress(task_id, "completed", 1.0, "All up to date",
+                                     json.dumps({"files": 0, "chunks": 0, "skipped": discovered}))
+                return
         # Incremental: filter unchanged files
-        if incremental:
+        elif incremental:
             indexed = qdrant.get_indexed_hashes()
             files = [f for f in files if indexed.get(f.path) != f.content_hash]
             for f in files:
@@ -160,7 +380,7 @@ class IndexingServiceServicer:
             if not files:
                 embedder.close()
                 yield _make_progress(task_id, "completed", 1.0, "All up to date",
-                                     json.dumps({"files": 0, "chunks": 0}))
+                                     json.dumps({"files": 0, "chunks": 0, "skipped": discovered}))
                 return
 
         yield _make_progress(task_id, "running", 0.05, f"Discovered {len(files)} files to index")
@@ -171,6 +391,8 @@ class IndexingServiceServicer:
             all_chunks.extend(chunk_file(f, chunk_size, chunk_overlap))
 
         total_upserted = 0
+        errors = 0
+        file_errors = _FileErrors()
         total_batches = max(1, (len(all_chunks) + BATCH_SIZE - 1) // BATCH_SIZE)
 
         for i in range(0, len(all_chunks), BATCH_SIZE):
@@ -178,47 +400,60 @@ class IndexingServiceServicer:
             if context.cancelled() or task_id in _cancelled_tasks:
                 _cancelled_tasks.discard(task_id)
                 embedder.close()
-                yield _make_progress(task_id, "cancelled", 0.0)
+                yield _cancelled_progress(task_id, (i // BATCH_SIZE) / total_batches,
+                                          file_errors.drain(),
+                                          files=len(files), chunks=total_upserted,
+                                          collection=collection)
                 return
 
             batch = all_chunks[i:i + BATCH_SIZE]
             try:
                 vectors = embedder.embed_chunks(batch)
-                points = [
-                    PointStruct(
-                        id=c.point_id,
-                        vector=v,
-                        payload={
-                            "file_path": c.file_path,
-                            "language": c.language,
-                            "chunk_index": c.chunk_index,
-                            "total_chunks": c.total_chunks,
-                            "start_line": c.start_line,
-                            "end_line": c.end_line,
-                            "content": c.content,
-                            "content_hash": c.content_hash,
-                        },
-                    )
-                    for c, v in zip(batch, vectors)
-                ]
+                points = []
+                for c, v in zip(batch, vectors):
+                    payload = {
+                        "file_path": c.file_path,
+                        "language": c.language,
+                        "chunk_index": c.chunk_index,
+                        "total_chunks": c.total_chunks,
+                        "start_line": c.start_line,
+                        "end_line": c.end_line,
+                        "content": c.content,
+                        "content_hash": c.content_hash,
+                    }
+                    if c.file_path in display_names:
+                        payload["display_name"] = display_names[c.file_path]
+                    abs_path = str(root / c.file_path)
+                    payload.update(_point_provenance(provenance, abs_path, display_names.get(c.file_path, abs_path)))
+                    points.append(PointStruct(id=c.point_id, vector=v, payload=payload))
                 qdrant.upsert_batch(points)
                 total_upserted += len(points)
             except (EmbeddingError, VectorStoreError) as e:
                 log.error("Batch %d failed: %s", i // BATCH_SIZE, e)
+                errors += 1
+                file_errors.add_batch((c.file_path for c in batch), "embed", e)
 
             batch_num = i // BATCH_SIZE + 1
             progress = batch_num / total_batches
             yield _make_progress(task_id, "running", progress,
-                                 f"Batch {batch_num}/{total_batches}")
+                                 f"Batch {batch_num}/{total_batches}",
+                                 file_errors=file_errors.drain())
 
         embedder.close()
-        result = {"files": len(files), "chunks": total_upserted, "collection": collection}
+        result = {
+            "files": len(files),
+            "chunks": total_upserted,
+            "skipped": discovered - len(files),
+            "errors": errors,
+            "collection": collection,
+        }
         yield _make_progress(task_id, "completed", 1.0, "Indexing complete",
-                             json.dumps(result))
+                             json.dumps(result), file_errors.drain())
 
     async def IndexDocuments(self, request, context):
         """Index document files (markdown, text, rst, html) from given paths."""
         cfg = get_config()
+        ollama_url = _ollama_url_from_metadata(context, cfg.ollama.base_url)
         task_id = uuid.uuid4().hex[:12]
 
         paths = list(request.paths) if hasattr(request, "paths") else []
@@ -226,11 +461,16 @@ class IndexingServiceServicer:
         chunk_size = request.chunk_size if hasattr(request, "chunk_size") and request.chunk_size > 0 else cfg.chunking.chunk_size
         chunk_overlap = request.chunk_overlap if hasattr(request, "chunk_overlap") and request.chunk_overlap >= 0 else cfg.chunking.chunk_overlap
         source_tag = request.source_tag if hasattr(request, "source_tag") and request.source_tag else "docs"
+        table_options = _table_options_from_metadata(context)
+        provenance = _request_provenance(request)
+        doc_exts = (".md", ".txt", ".rst", ".html")
+        if table_options is not None:
+            doc_exts += TABLE_EXTENSIONS
 
         yield _make_progress(task_id, "running", 0.0, "Starting document indexing")
 
         embedder = OllamaEmbedder(
-            base_url=cfg.ollama.base_url,
+            base_url=ollama_url,
             model=cfg.ollama.embed_model,
             timeout=cfg.ollama.timeout_s,
         )
@@ -243,15 +483,31 @@ class IndexingServiceServicer:
 
         all_chunks = []
         files_processed = 0
+        errors = 0
+        file_errors = _FileErrors()
         for p in paths:
             path = Path(p).resolve()
             file_list = [path] if path.is_file() else sorted(path.rglob("*")) if path.is_dir() else []
             for fp in file_list:
-                if not fp.is_file() or fp.suffix.lower() not in (".md", ".txt", ".rst", ".html"):
+                if not fp.is_file() or fp.suffix.lower() not in doc_exts:
+                    continue
+                if fp.suffix.lower() in TABLE_EXTENSIONS:
+                    try:
+                        raw = fp.read_bytes()
+                        chunks = chunk_table(str(fp), raw, table_options, chunk_size,
+                                             hashlib.sha256(raw).hexdigest())
+                    except Exception as e:
+                        errors += 1
+                        file_errors.add(fp, "extract", e)
+                        continue
+                    all_chunks.extend(chunks)
+                    files_processed += 1
                     continue
                 try:
                     content = fp.read_text(errors="replace")
-                except (OSError, PermissionError):
+                except (OSError, PermissionError) as e:
+                    errors += 1
+                    file_errors.add(fp, "read", e)
                     continue
                 content_hash = hashlib.sha256(content.encode()).hexdigest()
                 lang = "markdown" if fp.suffix.lower() in (".md", ".rst") else "text"
@@ -262,7 +518,8 @@ class IndexingServiceServicer:
                 files_processed += 1
 
         yield _make_progress(task_id, "running", 0.1,
-                             f"Chunked {files_processed} files into {len(all_chunks)} chunks")
+                             f"Chunked {files_processed} files into {len(all_chunks)} chunks",
+                             file_errors=file_errors.drain())
 
         total_upserted = 0
         total_batches = max(1, (len(all_chunks) + BATCH_SIZE - 1) // BATCH_SIZE)
@@ -270,7 +527,10 @@ class IndexingServiceServicer:
             if context.cancelled() or task_id in _cancelled_tasks:
                 _cancelled_tasks.discard(task_id)
                 embedder.close()
-                yield _make_progress(task_id, "cancelled", 0.0)
+                yield _cancelled_progress(task_id, (i // BATCH_SIZE) / total_batches,
+                                          file_errors.drain(),
+                                          files=files_processed, chunks=total_upserted,
+                                          collection=collection)
                 return
 
             batch = all_chunks[i:i + BATCH_SIZE]
@@ -285,7 +545,8 @@ class IndexingServiceServicer:
                             "chunk_index": c.chunk_index, "total_chunks": c.total_chunks,
                             "start_line": c.start_line, "end_line": c.end_line,
                             "content": c.content, "content_hash": c.content_hash,
-                            "source_tag": source_tag,
+                            "source_tag": source_tag, **c.page_payload,
+                            **_point_provenance(provenance, c.file_path, c.file_path),
                         },
                     )
                     for c, v in zip(batch, vectors)
@@ -294,19 +555,23 @@ class IndexingServiceServicer:
                 total_upserted += len(points)
             except Exception as e:
                 log.error("Batch %d failed: %s", i // BATCH_SIZE, e)
+                errors += 1
+                file_errors.add_batch((c.file_path for c in batch), "embed", e)
 
             batch_num = i // BATCH_SIZE + 1
             yield _make_progress(task_id, "running", batch_num / total_batches,
-                                 f"Batch {batch_num}/{total_batches}")
+                                 f"Batch {batch_num}/{total_batches}",
+                                 file_errors=file_errors.drain())
 
         embedder.close()
-        result = {"files": files_processed, "chunks": total_upserted, "collection": collection}
+        result = {"files": files_processed, "chunks": total_upserted, "errors": errors, "collection": collection}
         yield _make_progress(task_id, "completed", 1.0, "Document indexing complete",
-                             json.dumps(result))
+                             json.dumps(result), file_errors.drain())
 
     async def IndexImages(self, request, context):
         """Index image files using vision-model captioning."""
         cfg = get_config()
+        ollama_url = _ollama_url_from_metadata(context, cfg.ollama.base_url)
         task_id = uuid.uuid4().hex[:12]
 
         root_path = request.root_path if hasattr(request, "root_path") else ""
@@ -316,6 +581,8 @@ class IndexingServiceServicer:
         incremental = request.incremental if hasattr(request, "incremental") else True
         max_image_size_kb = request.max_image_size_kb if hasattr(request, "max_image_size_kb") and request.max_image_size_kb > 0 else cfg.image.max_image_size_kb
         extra_skip_dirs = list(request.extra_skip_dirs) if hasattr(request, "extra_skip_dirs") else []
+        image_meta = _image_meta_from_metadata(context)
+        provenance = _request_provenance(request)
 
         yield _make_progress(task_id, "running", 0.0, "Starting image indexing")
 
@@ -324,14 +591,14 @@ class IndexingServiceServicer:
             yield _make_progress(task_id, "failed", 0.0, f"Not a directory: {root_path}")
             return
 
-        images = discover_images(root, max_image_size_kb, set(extra_skip_dirs))
+        images = discover_images(root, max_image_size_kb, extra_skip_dirs)
         if not images:
             yield _make_progress(task_id, "completed", 1.0, "No images found",
                                  json.dumps({"images_found": 0, "images_indexed": 0}))
             return
 
         embedder = OllamaEmbedder(
-            base_url=cfg.ollama.base_url,
+            base_url=ollama_url,
             model=cfg.ollama.embed_model,
             timeout=cfg.ollama.timeout_s,
         )
@@ -358,12 +625,15 @@ class IndexingServiceServicer:
         total = len(images)
         indexed_count = 0
         failed_count = 0
+        file_errors = _FileErrors()
 
         for i, img in enumerate(images):
             if context.cancelled() or task_id in _cancelled_tasks:
                 _cancelled_tasks.discard(task_id)
                 embedder.close()
-                yield _make_progress(task_id, "cancelled", 0.0)
+                yield _cancelled_progress(task_id, i / total, file_errors.drain(),
+                                          images_found=total, images_indexed=indexed_count,
+                                          images_failed=failed_count, collection=collection)
                 return
 
             try:
@@ -371,12 +641,13 @@ class IndexingServiceServicer:
                 image_b64 = base64.b64encode(image_bytes).decode("utf-8")
 
                 caption = _caption_image_sync(
-                    cfg.ollama.base_url, vision_model, image_b64, caption_prompt
+                    ollama_url, vision_model, image_b64, caption_prompt
                 )
 
                 if not caption.strip():
                     log.warning("Empty caption for %s, skipping", img.path)
                     failed_count += 1
+                    file_errors.add(img.path, "caption", "empty caption")
                     continue
 
                 embed_text = f"Image: {img.path}\n\nCaption: {caption}"
@@ -399,6 +670,8 @@ class IndexingServiceServicer:
                 if img.width and img.height:
                     payload["width"] = img.width
                     payload["height"] = img.height
+                payload.update(_lookup_image_meta(image_meta, img.abs_path))
+                payload.update(_point_provenance(provenance, img.abs_path, img.abs_path))
 
                 point = PointStruct(id=point_id, vector=vectors[0], payload=payload)
                 qdrant.upsert_batch([point])
@@ -407,9 +680,10 @@ class IndexingServiceServicer:
             except Exception as e:
                 log.error("Failed to index image %s: %s", img.path, e)
                 failed_count += 1
+                file_errors.add(img.path, "index", e)
 
             yield _make_progress(task_id, "running", (i + 1) / total,
-                                 f"Image {i + 1}/{total}")
+                                 f"Image {i + 1}/{total}", file_errors=file_errors.drain())
 
         embedder.close()
         result = {
@@ -419,11 +693,12 @@ class IndexingServiceServicer:
             "collection": collection,
         }
         yield _make_progress(task_id, "completed", 1.0, "Image indexing complete",
-                             json.dumps(result))
+                             json.dumps(result), file_errors.drain())
 
     async def IndexUploads(self, request, context):
         """Index pre-saved uploaded files (documents and images)."""
         cfg = get_config()
+        ollama_url = _ollama_url_from_metadata(context, cfg.ollama.base_url)
         task_id = uuid.uuid4().hex[:12]
 
         saved_paths = list(request.saved_paths) if hasattr(request, "saved_paths") else []
@@ -433,6 +708,12 @@ class IndexingServiceServicer:
         source_tag = request.source_tag if hasattr(request, "source_tag") and request.source_tag else "upload"
         vision_model = request.vision_model if hasattr(request, "vision_model") and request.vision_model else cfg.ollama.vision_model
         caption_prompt = request.caption_prompt if hasattr(request, "caption_prompt") and request.caption_prompt else cfg.image.caption_prompt
+        image_meta = _image_meta_from_metadata(context)
+        display_names = _display_names_from_metadata(context)
+        file_meta = _file_meta_from_metadata(context)
+        provenance = _request_provenance(request)
+        docling = _docling_from_metadata(context, cfg.docling)
+        table_options = _table_options_from_metadata(context)
 
         yield _make_progress(task_id, "running", 0.0, "Starting upload indexing")
 
@@ -448,7 +729,7 @@ class IndexingServiceServicer:
         total_files = len(saved_paths)
 
         embedder = OllamaEmbedder(
-            base_url=cfg.ollama.base_url,
+            base_url=ollama_url,
             model=cfg.ollama.embed_model,
             timeout=cfg.ollama.timeout_s,
         )
@@ -462,50 +743,36 @@ class IndexingServiceServicer:
         # Phase 1: Process document files
         all_chunks = []
         files_processed = 0
+        errors = 0
+        file_errors = _FileErrors()
 
         for p in doc_paths:
             fp = Path(p)
-            ext = fp.suffix.lower()
             try:
                 raw = fp.read_bytes()
                 content_hash = hashlib.sha256(raw).hexdigest()
-                chunks = None
-
-                # Try docling first
-                if cfg.docling.enabled:
-                    chunks = chunk_with_docling(
-                        file_path=str(fp), file_bytes=raw,
-                        chunk_size=chunk_size, chunk_overlap=chunk_overlap,
-                        content_hash=content_hash,
-                        ocr_enabled=cfg.docling.ocr_enabled,
-                        ocr_engine=cfg.docling.ocr_engine,
-                        table_structure=cfg.docling.table_structure,
-                        timeout_s=cfg.docling.timeout_s,
-                    )
-
-                # Fallback to legacy parsers
-                if chunks is None:
-                    if ext == ".pdf":
-                        chunks = chunk_pdf(str(fp), raw, chunk_size, chunk_overlap, content_hash)
-                    elif ext == ".docx":
-                        chunks = chunk_docx(str(fp), raw, chunk_size, chunk_overlap, content_hash)
-                    elif ext == ".xlsx":
-                        chunks = chunk_xlsx(str(fp), raw, chunk_size, chunk_overlap, content_hash)
-                    elif ext == ".pptx":
-                        chunks = chunk_pptx(str(fp), raw, chunk_size, chunk_overlap, content_hash)
-                    elif ext in (".csv", ".adoc", ".asciidoc"):
-                        content = raw.decode("utf-8", errors="replace")
-                        chunks = chunk_document(str(fp), content, "text", chunk_size, chunk_overlap, content_hash)
-                    else:
-                        content = raw.decode("utf-8", errors="replace")
-                        lang = "markdown" if ext in (".md", ".rst") else "text"
-                        chunks = chunk_document(str(fp), content, lang, chunk_size, chunk_overlap, content_hash)
+                if fp.suffix.lower() in MEDIA_EXTENSIONS:
+                    if not (cfg.transcription.enabled and transcription_is_available()):
+                        raise RuntimeError("transcription is disabled or faster-whisper is not installed")
+                    yield _make_progress(task_id, "running", 0.0,
+                                         f"Transcribing {display_names.get(p, fp.name)}")
+                    segments, _, _ = await asyncio.to_thread(transcribe, str(fp), cfg.transcription)
+                    chunks = chunk_transcript(str(fp), segments, chunk_size, content_hash)
+                elif table_options is not None and fp.suffix.lower() in TABLE_EXTENSIONS:
+                    chunks = chunk_table(str(fp), raw, table_options, chunk_size, content_hash)
+                else:
+                    text, lang, _, page_offsets = extract_text(str(fp), raw, docling=docling)
+                    chunks = chunk_document(str(fp), text, lang, chunk_size, chunk_overlap, content_hash)
+                    if page_offsets:
+                        assign_pages(chunks, text, page_offsets)
 
                 if chunks:
                     all_chunks.extend(chunks)
                 files_processed += 1
             except Exception as e:
                 log.error("Failed to process uploaded file %s: %s", p, e)
+                errors += 1
+                file_errors.add(p, "extract", e)
 
         total_upserted = 0
         if all_chunks:
@@ -515,34 +782,45 @@ class IndexingServiceServicer:
                 if context.cancelled() or task_id in _cancelled_tasks:
                     _cancelled_tasks.discard(task_id)
                     embedder.close()
-                    yield _make_progress(task_id, "cancelled", 0.0)
+                    yield _cancelled_progress(task_id, doc_weight * (i // BATCH_SIZE) / total_batches,
+                                              file_errors.drain(), files=files_processed, chunks=total_upserted,
+                                              collection=collection)
                     return
                 batch = all_chunks[i:i + BATCH_SIZE]
                 try:
-                    texts = [f"File: {c.file_path} | {c.language}\n\n{c.content}" for c in batch]
-                    vectors = embedder.embed_texts(texts)
-                    points = [
-                        PointStruct(
-                            id=c.point_id, vector=v,
-                            payload={
-                                "file_path": c.file_path, "language": c.language,
-                                "chunk_index": c.chunk_index, "total_chunks": c.total_chunks,
-                                "start_line": c.start_line, "end_line": c.end_line,
-                                "content": c.content, "content_hash": c.content_hash,
-                                "source_tag": source_tag,
-                            },
-                        )
-                        for c, v in zip(batch, vectors)
+                    texts = [
+                        f"File: {display_names.get(c.file_path, c.file_path)} | {c.language}\n\n{c.content}"
+                        for c in batch
                     ]
+                    vectors = embedder.embed_texts(texts)
+                    points = []
+                    for c, v in zip(batch, vectors):
+                        payload = {
+                            "file_path": c.file_path, "language": c.language,
+                            "chunk_index": c.chunk_index, "total_chunks": c.total_chunks,
+                            "start_line": c.start_line, "end_line": c.end_line,
+                            "content": c.content, "content_hash": c.content_hash,
+                            "source_tag": source_tag, **c.page_payload, **c.time_payload,
+                        }
+                        if c.file_path in display_names:
+                            payload["display_name"] = display_names[c.file_path]
+                        for k, val in file_meta.get(c.file_path, {}).items():
+                            payload.setdefault(str(k), val)
+                        payload.update(_point_provenance(
+                            provenance, c.file_path, display_names.get(c.file_path, c.file_path)))
+                        points.append(PointStruct(id=c.point_id, vector=v, payload=payload))
                     qdrant.upsert_batch(points)
                     total_upserted += len(points)
                 except Exception as e:
                     log.error("Batch %d failed: %s", i // BATCH_SIZE, e)
+                    errors += 1
+                    file_errors.add_batch((c.file_path for c in batch), "embed", e)
 
                 batch_num = i // BATCH_SIZE + 1
                 yield _make_progress(task_id, "running",
                                      doc_weight * batch_num / total_batches,
-                                     f"Doc batch {batch_num}/{total_batches}")
+                                     f"Doc batch {batch_num}/{total_batches}",
+                                     file_errors=file_errors.drain())
 
         # Phase 2: Process image files
         images_indexed = 0
@@ -552,7 +830,12 @@ class IndexingServiceServicer:
             if context.cancelled() or task_id in _cancelled_tasks:
                 _cancelled_tasks.discard(task_id)
                 embedder.close()
-                yield _make_progress(task_id, "cancelled", 0.0)
+                yield _cancelled_progress(
+                    task_id, (len(doc_paths) + j) / max(total_files, 1), file_errors.drain(),
+                    files=files_processed, chunks=total_upserted,
+                    images_indexed=images_indexed, images_failed=images_failed,
+                    collection=collection,
+                )
                 return
 
             fp = Path(img_path)
@@ -562,18 +845,23 @@ class IndexingServiceServicer:
                 image_b64 = base64.b64encode(image_bytes).decode("utf-8")
 
                 caption = _caption_image_sync(
-                    cfg.ollama.base_url, vision_model, image_b64, caption_prompt
+                    ollama_url, vision_model, image_b64, caption_prompt
                 )
 
                 if not caption.strip():
                     log.warning("Empty caption for uploaded image %s, skipping", img_path)
                     images_failed += 1
+                    file_errors.add(img_path, "caption", "empty caption")
                     continue
 
-                embed_text = f"Image: {fp.name}\n\nCaption: {caption}"
+                display_name = display_names.get(img_path)
+                embed_text = f"Image: {display_name or fp.name}\n\nCaption: {caption}"
                 vectors = embedder.embed_texts([embed_text])
 
-                point_id = hashlib.md5(f"image::{fp.name}".encode()).hexdigest()
+                # Preserved names repeat across uploads, so key them by the
+                # saved path; generated names are unique on their own.
+                point_key = str(fp) if display_name else fp.name
+                point_id = hashlib.md5(f"image::{point_key}".encode()).hexdigest()
                 payload = {
                     "file_path": str(fp),
                     "language": "image",
@@ -587,6 +875,10 @@ class IndexingServiceServicer:
                     "end_line": 0,
                     "source_tag": source_tag,
                 }
+                if display_name:
+                    payload["display_name"] = display_name
+                payload.update(_lookup_image_meta(image_meta, str(fp)))
+                payload.update(_point_provenance(provenance, img_path, display_name or str(fp)))
 
                 point = PointStruct(id=point_id, vector=vectors[0], payload=payload)
                 qdrant.upsert_batch([point])
@@ -595,12 +887,14 @@ class IndexingServiceServicer:
             except Exception as e:
                 log.error("Failed to index uploaded image %s: %s", img_path, e)
                 images_failed += 1
+                file_errors.add(img_path, "index", e)
 
             doc_weight = len(doc_paths) / max(total_files, 1)
             img_weight = len(image_paths) / max(total_files, 1)
             yield _make_progress(task_id, "running",
                                  doc_weight + img_weight * (j + 1) / len(image_paths),
-                                 f"Image {j + 1}/{len(image_paths)}")
+                                 f"Image {j + 1}/{len(image_paths)}",
+                                 file_errors=file_errors.drain())
 
         embedder.close()
         result = {
@@ -608,14 +902,16 @@ class IndexingServiceServicer:
             "chunks": total_upserted,
             "images_indexed": images_indexed,
             "images_failed": images_failed,
+            "errors": errors,
             "collection": collection,
         }
         yield _make_progress(task_id, "completed", 1.0, "Upload indexing complete",
-                             json.dumps(result))
+                             json.dumps(result), file_errors.drain())
 
     async def IndexSMBFiles(self, request, context):
         """Download files from an SMB share, then chunk, embed, and index them."""
         cfg = get_config()
+        ollama_url = _ollama_url_from_metadata(context, cfg.ollama.base_url)
         task_id = uuid.uuid4().hex[:12]
 
         # SMB connection info from the request
@@ -634,7 +930,7 @@ class IndexingServiceServicer:
         yield _make_progress(task_id, "running", 0.0, "Starting SMB file indexing")
 
         # Download files to temp dir
-        from ..processing.smb_client import SMBManager, SMBShareConfig
+        from ..processing.smb_client import SMBManager, SMBShareConfig, smb_file_label
         smb = SMBManager()
         smb_config = SMBShareConfig(
             id="grpc_temp",
@@ -644,6 +940,7 @@ class IndexingServiceServicer:
             password=password,
             domain=domain,
             port=port,
+            **request_auth(request),
         )
         smb.add_share(smb_config)
 
@@ -654,11 +951,26 @@ class IndexingServiceServicer:
             yield _make_progress(task_id, "failed", 0.0, f"SMB download failed: {e}")
             return
 
+        # Owner and read permissions per file, stored as "smb_acl" so search
+        # results can later be filtered by what the user may open.
+        capture_acls = _smb_acls_from_metadata(context)
+        provenance = _request_provenance(request)
+        acl_payloads: dict[str, dict] = {}
+        if capture_acls:
+            try:
+                acls = smb.read_acls("grpc_temp", remote_paths)
+            except Exception as e:
+                log.warning("Reading SMB ACLs failed: %s", e)
+                acls = {}
+            acl_payloads = {
+                smb_file_label(server, share, rp): {"smb_acl": acl} for rp, acl in acls.items()
+            }
+
         yield _make_progress(task_id, "running", 0.1,
                              f"Downloaded {len(local_paths)} files from SMB")
 
         embedder = OllamaEmbedder(
-            base_url=cfg.ollama.base_url,
+            base_url=ollama_url,
             model=cfg.ollama.embed_model,
             timeout=cfg.ollama.timeout_s,
         )
@@ -671,20 +983,28 @@ class IndexingServiceServicer:
 
         all_chunks = []
         files_processed = 0
+        errors = 0
+        file_errors = _FileErrors()
+        # Errors name files by share path, which a retry downloads again.
+        remote_by_label: dict[str, str] = {}
 
-        for p in local_paths:
+        for p, rp in zip(local_paths, remote_paths):
             fp = Path(p)
             ext = fp.suffix.lower()
+            # Chunks are labelled with the share path rather than the temp
+            # download, so re-indexing a file replaces its points.
+            label = smb_file_label(server, share, rp)
+            remote_by_label[label] = rp
             try:
                 raw = fp.read_bytes()
                 content_hash = hashlib.sha256(raw).hexdigest()
 
                 if ext == ".pdf":
-                    chunks = chunk_pdf(str(fp), raw, chunk_size, chunk_overlap, content_hash)
+                    chunks = chunk_pdf(label, raw, chunk_size, chunk_overlap, content_hash)
                 elif ext in (".md", ".txt", ".rst", ".html"):
                     content = raw.decode("utf-8", errors="replace")
                     lang = "markdown" if ext in (".md", ".rst") else "text"
-                    chunks = chunk_document(str(fp), content, lang, chunk_size, chunk_overlap, content_hash)
+                    chunks = chunk_document(label, content, lang, chunk_size, chunk_overlap, content_hash)
                 else:
                     continue
 
@@ -692,11 +1012,14 @@ class IndexingServiceServicer:
                 files_processed += 1
             except Exception as e:
                 log.error("Failed to process SMB file %s: %s", p, e)
+                errors += 1
+                file_errors.add(rp, "extract", e)
 
         if not all_chunks:
             embedder.close()
             yield _make_progress(task_id, "completed", 1.0, "No content extracted",
-                                 json.dumps({"files": files_processed, "chunks": 0}))
+                                 json.dumps({"files": files_processed, "chunks": 0, "errors": errors}),
+                                 file_errors.drain())
             return
 
         total_upserted = 0
@@ -705,7 +1028,10 @@ class IndexingServiceServicer:
             if context.cancelled() or task_id in _cancelled_tasks:
                 _cancelled_tasks.discard(task_id)
                 embedder.close()
-                yield _make_progress(task_id, "cancelled", 0.0)
+                yield _cancelled_progress(task_id, 0.1 + 0.9 * (i // BATCH_SIZE) / total_batches,
+                                          file_errors.drain(),
+                                          files=files_processed, chunks=total_upserted,
+                                          collection=collection)
                 return
 
             batch = all_chunks[i:i + BATCH_SIZE]
@@ -720,7 +1046,9 @@ class IndexingServiceServicer:
                             "chunk_index": c.chunk_index, "total_chunks": c.total_chunks,
                             "start_line": c.start_line, "end_line": c.end_line,
                             "content": c.content, "content_hash": c.content_hash,
-                            "source_tag": source_tag,
+                            "source_tag": source_tag, **c.page_payload,
+                            **acl_payloads.get(c.file_path, {}),
+                            **_point_provenance(provenance, c.file_path, c.file_path),
                         },
                     )
                     for c, v in zip(batch, vectors)
@@ -729,15 +1057,20 @@ class IndexingServiceServicer:
                 total_upserted += len(points)
             except Exception as e:
                 log.error("Batch %d failed: %s", i // BATCH_SIZE, e)
+                errors += 1
+                file_errors.add_batch((remote_by_label.get(c.file_path, c.file_path) for c in batch), "embed", e)
 
             batch_num = i // BATCH_SIZE + 1
             yield _make_progress(task_id, "running", 0.1 + 0.9 * batch_num / total_batches,
-                                 f"Batch {batch_num}/{total_batches}")
+                                 f"Batch {batch_num}/{total_batches}",
+                                 file_errors=file_errors.drain())
 
         embedder.close()
-        result = {"files": files_processed, "chunks": total_upserted, "collection": collection}
+        result = {"files": files_processed, "chunks": total_upserted, "errors": errors, "collection": collection}
+        if capture_acls:
+            result["acl_files"] = len(acl_payloads)
         yield _make_progress(task_id, "completed", 1.0, "SMB indexing complete",
-                             json.dumps(result))
+                             json.dumps(result), file_errors.drain())
 
     async def CancelTask(self, request, context):
         """Cancel a running indexing task by its task_id."""
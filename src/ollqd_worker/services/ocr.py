@@ -0,0 +1,197 @@
+"""OCRService gRPC servicer — OCR settings and single-page OCR test runs.
+
+The settings live in the docling config section (ocr_languages, ocr_dpi,
+force_ocr) so they are persisted and reset with the rest of it.
+"""
+
+import asyncio
+import logging
+import re
+import time
+from pathlib import Path
+
+import grpc
+
+from .. import config_db
+from ..config import get_config
+from ..processing.docling_converter import convert_to_markdown, is_available, parse_languages
+
+log = logging.getLogger("ollqd.worker.ocr")
+
+try:
+    from ..gen.ollqd.v1 import ocr_pb2 as pb2
+except ImportError:
+    pb2 = None
+
+MAX_LANGUAGES = 10
+MIN_DPI = 72
+MAX_DPI = 600
+
+IMAGE_EXTENSIONS = (".png", ".jpg", ".jpeg", ".tiff", ".tif", ".bmp", ".gif")
+
+# Engine language codes: "en", "eng", "ch_sim", "chi_tra", "sr-Latn", ...
+_LANGUAGE_RE = re.compile(r"^[A-Za-z][A-Za-z0-9_+-]{0,19}$")
+
+
+def _ocr_settings(docling):
+    return pb2.OCRConfig(
+        engine=docling.ocr_engine,
+        ocr_enabled=docling.ocr_enabled,
+        languages=parse_languages(docling.ocr_languages),
+        dpi=docling.ocr_dpi,
+        force_ocr=docling.force_ocr,
+        docling_available=is_available(),
+    )
+
+
+def _validate(request) -> tuple[list[str] | None, int | None, str]:
+    """Return the languages and dpi set in request, or an error message."""
+    languages = dpi = None
+    if request.HasField("languages"):
+        languages = [lang.strip() for lang in request.languages.codes if lang.strip()]
+        if len(languages) > MAX_LANGUAGES:
+            return None, None, f"at most {MAX_LANGUAGES} languages are allowed"
+        for lang in languages:
+            if not _LANGUAGE_RE.match(lang):
+                return None, None, f"invalid language code: {lang}"
+    if request.HasField("dpi"):
+        dpi = request.dpi
+        if dpi != 0 and not MIN_DPI <= dpi <= MAX_DPI:
+            return None, None, f"dpi must be 0 or between {MIN_DPI} and {MAX_DPI}"
+    return languages, dpi, ""
+
+
+def _single_page(fp: Path, raw: bytes, page: int) -> tuple[bytes, int]:
+    """Return a one-page PDF holding page (1-based) of the PDF raw, and its page count."""
+    import fitz  # PyMuPDF
+
+    src = fitz.open(stream=raw, filetype="pdf")
+    try:
+        pages = src.page_count
+        if page > pages:
+            raise IndexError(f"page {page} is out of range, {fp.name} has {pages} pages")
+        out = fitz.open()
+        try:
+            out.insert_pdf(src, from_page=page - 1, to_page=page - 1)
+            return out.tobytes(), pages
+        finally:
+            out.close()
+    finally:
+        src.close()
+
+
+class OCRServiceServicer:
+    """gRPC servicer for OCR settings.
+
+    Methods:
+        GetOCRConfig    — current OCR engine, languages, dpi and force_ocr
+        UpdateOCRConfig — update and persist languages, dpi and force_ocr
+        TestOCR         — OCR one page of a saved upload and return the text
+    """
+
+    async def GetOCRConfig(self, request, context):
+        return _ocr_settings(get_config().docling)
+
+    async def UpdateOCRConfig(self, request, context):
+        """Update the fields set in the request; unset fields are kept."""
+        cfg = get_config()
+        languages, dpi, err = _validate(request)
+        if err:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, err)
+
+        if languages is not None:
+            cfg.docling.ocr_languages = ",".join(languages)
+        if dpi is not None:
+            cfg.docling.ocr_dpi = dpi
+        if request.HasField("force_ocr"):
+            cfg.docling.force_ocr = request.force_ocr
+
+        config_db.save_overrides("docling", {
+            "ocr_languages": cfg.docling.ocr_languages,
+            "ocr_dpi": str(cfg.docling.ocr_dpi),
+            "force_ocr": str(cfg.docling.force_ocr).lower(),
+        })
+        log.info("Updated OCR config: languages=%s dpi=%d force_ocr=%s",
+                 cfg.docling.ocr_languages, cfg.docling.ocr_dpi, cfg.docling.force_ocr)
+        return _ocr_settings(cfg.docling)
+
+    async def TestOCR(self, request, context):
+        """OCR a single page and return the recognised text.
+
+        Request fields: path (a PDF or image under the upload dir), page
+        (1-based, default 1) and optional languages and dpi overriding the
+        saved settings for this run. The page is always OCRed in full, so
+        the text reflects OCR quality rather than an embedded text layer.
+        """
+        cfg = get_config()
+        if not is_available():
+            await context.abort(grpc.StatusCode.FAILED_PRECONDITION, "docling is not installed")
+
+        path = request.path
+        if not path:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, "path is required")
+        fp = Path(path).resolve()
+        upload_dir = Path(cfg.upload.upload_dir).resolve()
+        if upload_dir not in fp.parents:
+            await context.abort(grpc.StatusCode.PERMISSION_DENIED, "path is outside the upload directory")
+        if not fp.is_file():
+            await context.abort(grpc.StatusCode.NOT_FOUND, f"file not found: {path}")
+
+        ext = fp.suffix.lower()
+        if ext != ".pdf" and ext not in IMAGE_EXTENSIONS:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, f"OCR test needs a PDF or image, got {ext or 'no extension'}")
+        page = request.page or 1
+        if page < 1:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, "page must be 1 or greater")
+
+        languages, dpi, err = _validate(request)
+        if err:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, err)
+        if languages is None:
+            languages = parse_languages(cfg.docling.ocr_languages)
+        if dpi is None:
+            dpi = cfg.docling.ocr_dpi
+
+        raw = fp.read_bytes()
+        pages = 1
+        if ext == ".pdf":
+            try:
+                raw, pages = await asyncio.to_thread(_single_page, fp, raw, page)
+            except IndexError as e:
+                await context.abort(grpc.StatusCode.OUT_OF_RANGE, str(e))
+            except Exception as e:
+                await context.abort(grpc.StatusCode.INVALID_ARGUMENT, f"cannot read PDF: {e}")
+        elif page != 1:
+            await context.abort(grpc.StatusCode.OUT_OF_RANGE, "images have a single page")
+
+        start = time.monotonic()
+        try:
+            md = await asyncio.wait_for(asyncio.to_thread(
+                convert_to_markdown,
+                file_path=str(fp),
+                file_bytes=raw,
+                ocr_enabled=True,
+                ocr_engine=cfg.docling.ocr_engine,
+                table_structure=cfg.docling.table_structure,
+                timeout_s=cfg.docling.timeout_s,
+                ocr_languages=languages,
+                ocr_dpi=dpi,
+                force_ocr=True,
+            ), timeout=cfg.docling.timeout_s)
+        except asyncio.TimeoutError:
+            await context.abort(grpc.StatusCode.DEADLINE_EXCEEDED, f"OCR took longer than {cfg.docling.timeout_s:g}s")
+        took_ms = int((time.monotonic() - start) * 1000)
+
+        text = md or ""
+        log.info("OCR test %s page %d/%d: %d chars in %dms", fp.name, page, pages, len(text), took_ms)
+        return pb2.TestOCRResponse(
+            text=text,
+            chars=len(text),
+            page=page,
+            pages=pages,
+            took_ms=took_ms,
+            engine=cfg.docling.ocr_engine,
+            languages=languages,
+            dpi=dpi,
+        )
+
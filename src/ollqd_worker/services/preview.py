@@ -0,0 +1,91 @@
+"""PreviewService gRPC servicer — run upload extraction and chunking without indexing."""
+
+import asyncio
+import hashlib
+import logging
+from pathlib import Path
+
+import grpc
+
+from ..config import get_config
+from ..processing.chunking import assign_pages, chunk_document, extract_text
+
+log = logging.getLogger("ollqd.worker.preview")
+
+try:
+    from ..gen.ollqd.v1 import preview_pb2 as pb2
+except ImportError:
+    pb2 = None
+
+# Extracted text returned to the caller is capped; chunks are always complete.
+MAX_TEXT_CHARS = 200_000
+
+
+class PreviewServiceServicer:
+    """gRPC servicer for dry-run document processing.
+
+    Methods:
+        PreviewUpload — extract and chunk one saved upload, nothing is embedded
+    """
+
+    async def PreviewUpload(self, request, context):
+        """Return the extracted text and the chunks IndexUploads would produce.
+
+        Request fields: path (a file under the upload dir), chunk_size and
+        chunk_overlap (optional, default to the chunking config).
+        """
+        cfg = get_config()
+
+        path = request.path
+        if not path:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, "path is required")
+        fp = Path(path).resolve()
+        upload_dir = Path(cfg.upload.upload_dir).resolve()
+        if upload_dir not in fp.parents:
+            await context.abort(grpc.StatusCode.PERMISSION_DENIED, "path is outside the upload directory")
+        if not fp.is_file():
+            await context.abort(grpc.StatusCode.NOT_FOUND, f"file not found: {path}")
+
+        chunk_size = request.chunk_size or cfg.chunking.chunk_size
+        if request.HasField("chunk_overlap") and request.chunk_overlap >= 0:
+            chunk_overlap = request.chunk_overlap
+        else:
+            chunk_overlap = cfg.chunking.chunk_overlap
+
+        raw = fp.read_bytes()
+        content_hash = hashlib.sha256(raw).hexdigest()
+        try:
+            text, language, extractor, page_offsets = await asyncio.to_thread(
+                extract_text, str(fp), raw, cfg.docling,
+            )
+        except Exception as e:
+            log.error("Preview extraction failed for %s: %s", fp, e)
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, f"extraction failed: {e}")
+
+        chunks = chunk_document(str(fp), text, language, chunk_size, chunk_overlap, content_hash)
+        if page_offsets:
+            assign_pages(chunks, text, page_offsets)
+        log.info("Preview %s: %s, %d chars, %d chunks", fp.name, extractor, len(text), len(chunks))
+
+        return pb2.PreviewUploadResponse(
+            extractor=extractor,
+            language=language,
+            content_hash=content_hash,
+            text=text[:MAX_TEXT_CHARS],
+            text_chars=len(text),
+            text_truncated=len(text) > MAX_TEXT_CHARS,
+            chunk_size=chunk_size,
+            chunk_overlap=chunk_overlap,
+            total_chunks=len(chunks),
+            chunks=[
+                pb2.PreviewChunk(
+                    chunk_index=c.chunk_index,
+                    start_line=c.start_line,
+                    end_line=c.end_line,
+                    chars=len(c.content),
+                    content=c.content,
+                    **c.page_payload,
+                )
+                for c in chunks
+            ],
+        )
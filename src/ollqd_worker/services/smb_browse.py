@@ -0,0 +1,87 @@
+"""SMBBrowseService gRPC servicer — list the shares of a host and browse
+directories, following DFS referrals.
+"""
+
+import asyncio
+import logging
+
+import grpc
+
+from ..processing.smb_client import SMBManager, SMBShareConfig
+from ..processing.smb_kerberos import request_auth
+
+log = logging.getLogger("ollqd.worker.smb_browse")
+
+try:
+    from ..gen.ollqd.v1 import processing_pb2, smb_browse_pb2
+except ImportError:
+    processing_pb2 = smb_browse_pb2 = None
+
+
+def _share_config(request, share: str) -> SMBShareConfig:
+    return SMBShareConfig(
+        id="browse",
+        server=request.server,
+        share=share,
+        username=request.username,
+        password=request.password,
+        domain=request.domain,
+        port=request.port or 445,
+        **request_auth(request),
+    )
+
+
+class SMBBrowseServiceServicer:
+    """gRPC servicer for SMB namespace browsing.
+
+    Methods:
+        ListShares — list the disk shares of a server
+        Browse     — list one directory of a share, through DFS links
+    """
+
+    async def ListShares(self, request, context):
+        """Return the disk shares of a server.
+
+        Request fields: server, username, password, domain, port, auth,
+        realm, kdc, keytab, include_hidden (also list shares ending in "$").
+        """
+        if not request.server:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, "server is required")
+
+        smb = SMBManager()
+        try:
+            shares = await asyncio.to_thread(
+                smb.list_host_shares, _share_config(request, ""), request.include_hidden,
+            )
+        except Exception as e:
+            log.error("Listing shares of %s failed: %s", request.server, e)
+            await context.abort(grpc.StatusCode.UNAVAILABLE, f"SMB share listing failed: {e}")
+
+        return smb_browse_pb2.ListSharesResponse(
+            shares=[smb_browse_pb2.SMBShareInfo(**s) for s in shares],
+        )
+
+    async def Browse(self, request, context):
+        """Return the entries of one directory of a share.
+
+        Request fields: server, share, username, password, domain, port,
+        auth, realm, kdc, keytab, path (default "/"). Folders come first.
+        """
+        server = request.server
+        share = request.share
+        if not server or not share:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, "server and share are required")
+        path = "/" + request.path.strip("/")
+
+        smb = SMBManager()
+        smb.add_share(_share_config(request, share))
+        try:
+            files = await asyncio.to_thread(smb.list_remote_files, "browse", path)
+        except Exception as e:
+            log.error("Browsing //%s/%s%s failed: %s", server, share, path, e)
+            await context.abort(grpc.StatusCode.UNAVAILABLE, f"SMB browse failed: {e}")
+
+        return processing_pb2.SMBBrowseResponse(
+            files=[processing_pb2.SMBFileEntry(**f) for f in files],
+            path=path,
+        )
@@ -0,0 +1,79 @@
+"""SMBSyncService gRPC servicer — list a share recursively for scheduled sync."""
+
+import asyncio
+import logging
+
+import grpc
+
+from ..processing.smb_client import SMBManager, SMBShareConfig, smb_file_label
+from ..processing.smb_kerberos import request_auth
+
+log = logging.getLogger("ollqd.worker.smb_sync")
+
+try:
+    from ..gen.ollqd.v1 import smb_sync_pb2 as pb2
+except ImportError:
+    pb2 = None
+
+# Upper bound on files returned by one scan.
+MAX_SCAN_FILES = 50_000
+
+
+class SMBSyncServiceServicer:
+    """gRPC servicer for SMB share scans.
+
+    Methods:
+        ScanShare — list indexable files under some paths with size and mtime
+    """
+
+    async def ScanShare(self, request, context):
+        """Walk the requested paths and return every indexable file.
+
+        Request fields: server, share, username, password, domain, port,
+        auth, realm, kdc, keytab, paths (default ["/"]), max_files (optional,
+        capped at MAX_SCAN_FILES).
+        """
+        server = request.server
+        share = request.share
+        if not server or not share:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, "server and share are required")
+
+        max_files = request.max_files
+        if max_files <= 0 or max_files > MAX_SCAN_FILES:
+            max_files = MAX_SCAN_FILES
+
+        smb = SMBManager()
+        smb.add_share(SMBShareConfig(
+            id="sync_scan",
+            server=server,
+            share=share,
+            username=request.username,
+            password=request.password,
+            domain=request.domain,
+            port=request.port or 445,
+            **request_auth(request),
+        ))
+        paths = list(request.paths) or ["/"]
+        try:
+            files, truncated = await asyncio.to_thread(
+                smb.walk_remote_files, "sync_scan", paths, max_files,
+            )
+        except Exception as e:
+            log.error("SMB scan of //%s/%s failed: %s", server, share, e)
+            await context.abort(grpc.StatusCode.UNAVAILABLE, f"SMB scan failed: {e}")
+
+        log.info("Scanned //%s/%s: %d files%s", server, share, len(files),
+                 " (truncated)" if truncated else "")
+        return pb2.ScanShareResponse(
+            files=[
+                pb2.ScannedFile(
+                    path=f["path"],
+                    file_path=smb_file_label(server, share, f["path"]),
+                    size=f["size"],
+                    mtime=f["mtime"],
+                )
+                for f in files
+            ],
+            truncated=truncated,
+        )
+
@@ -0,0 +1,66 @@
+"""WorkerInfoService gRPC servicer — report the worker version and the
+services and features it implements, so the gateway can disable what an
+older or partial worker lacks.
+"""
+
+import logging
+from importlib import metadata
+
+from ..config import get_config
+from ..processing.transcription import is_available as transcription_is_available
+
+log = logging.getLogger("ollqd.worker.worker_info")
+
+try:
+    from ..gen.ollqd.v1 import worker_info_pb2 as pb2
+except ImportError:
+    pb2 = None
+
+# Optional behaviour of existing RPCs: request fields or x-ollqd-* request
+# metadata.
+FEATURES = [
+    "index_files",      # IndexCodebaseRequest.files: index a subset of a source
+    "image_meta",       # x-ollqd-image-meta(-file): caller-supplied image metadata
+    "smb_acls",         # x-ollqd-smb-acls: owner and read ACLs in the payload
+    "smb_kerberos",     # SMB request auth fields: Kerberos shares
+    "display_names",    # x-ollqd-display-names: original upload file names
+    "chat_sampling",    # ChatRequest sampling, system prompt and context fields
+    "chat_history",     # ChatRequest.history: earlier turns of the conversation
+    "file_errors",      # "file_errors" progress result entry: per-file failures
+    "table_options",    # x-ollqd-table-options: table-aware spreadsheet chunking
+    "provenance",       # Index*Request.provenance: "provenance" payload on indexed points
+]
+
+
+def features() -> list[str]:
+    """Return FEATURES plus the optional ones this worker can serve now."""
+    out = list(FEATURES)
+    if get_config().transcription.enabled and transcription_is_available():
+        out.append("transcription")  # IndexUploads transcribes audio and video files
+    return out
+
+
+def worker_version() -> str:
+    """Return the installed package version, or "unknown" from a source tree."""
+    try:
+        return metadata.version("ollqd")
+    except metadata.PackageNotFoundError:
+        return "unknown"
+
+
+class WorkerInfoServiceServicer:
+    """gRPC servicer for version and capability negotiation.
+
+    Methods:
+        GetVersion — report version, registered services and features
+    """
+
+    def __init__(self, services: list[str]):
+        self._services = list(services)
+
+    async def GetVersion(self, request, context):
+        """Return the version, registered services and features."""
+        return pb2.GetVersionResponse(
+            version=worker_version(), services=self._services, features=features(),
+        )
+
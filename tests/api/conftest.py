@@ -37,20 +37,22 @@ class APIClient:
 
 
 @pytest.fixture(scope="session")
-def api(gateway_url, wait_for_gateway):
-    """Return an APIClient configured with a requests.Session and the gateway URL."""
+def api(gateway_url, wait_for_gateway, auth_headers):
+    """Return an APIClient configured with a logged-in requests.Session and
+    the gateway URL."""
     session = requests.Session()
     session.headers.update({"Accept": "application/json"})
+    session.headers.update(auth_headers)
     client = APIClient(session, gateway_url)
     yield client
     session.close()
 
 
 @pytest.fixture(scope="session")
-def ollama_available(gateway_url):
+def ollama_available(gateway_url, auth_headers):
     """Return True if Ollama is reachable through the gateway, False otherwise."""
     try:
-        r = requests.get(f"{gateway_url}/api/system/health", timeout=5)
+        r = requests.get(f"{gateway_url}/api/system/health", headers=auth_headers, timeout=5)
         data = r.json()
         return data.get("ollama", {}).get("status") == "ok"
     except Exception:
@@ -58,10 +60,10 @@ def ollama_available(gateway_url):
 
 
 @pytest.fixture(scope="session")
-def worker_available(gateway_url):
+def worker_available(gateway_url, auth_headers):
     """Return True if the gRPC worker is reachable (config endpoint responds), False otherwise."""
     try:
-        r = requests.get(f"{gateway_url}/api/system/config", timeout=5)
+        r = requests.get(f"{gateway_url}/api/system/config", headers=auth_headers, timeout=5)
         # 502/503 means worker is down
         return r.status_code == 200
     except Exception:
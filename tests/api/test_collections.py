@@ -29,7 +29,7 @@ class TestListCollections:
 class TestCreateAndDeleteCollection:
     """PUT + DELETE /api/qdrant/collections/{name} lifecycle."""
 
-    def test_create_and_delete_collection(self, api, gateway_url, wait_for_qdrant):
+    def test_create_and_delete_collection(self, api, gateway_url, auth_headers, wait_for_qdrant):
         """Create a collection via PUT, verify it appears in the list, then delete it."""
         name = f"test_api_lifecycle_{int(time.time() * 1000)}"
 
@@ -37,6 +37,7 @@ class TestCreateAndDeleteCollection:
         r_create = requests.put(
             f"{gateway_url}/api/qdrant/collections/{name}",
             json={"vectors": {"size": 384, "distance": "Cosine"}},
+            headers=auth_headers,
             timeout=10,
         )
         assert r_create.status_code in (200, 201), (
@@ -34,26 +34,34 @@ def _ws_url(gateway_url: str) -> str:
     return gateway_url.replace("http://", "ws://").replace("https://", "wss://") + "/api/rag/ws"
 
 
+def _connect(ws_uri: str, auth_headers: dict):
+    """Open a WebSocket with the login header. websockets 14 renamed
+    extra_headers to additional_headers."""
+    major = int(websockets.__version__.split(".")[0])
+    key = "additional_headers" if major >= 14 else "extra_headers"
+    return websockets.connect(ws_uri, open_timeout=10, **{key: auth_headers})
+
+
 class TestWebSocketUpgrade:
     """WebSocket connection handshake."""
 
     @pytest.mark.asyncio
-    async def test_ws_upgrade(self, gateway_url):
+    async def test_ws_upgrade(self, gateway_url, auth_headers):
         """A WebSocket connection to /api/rag/ws should succeed (101 Upgrade)."""
         ws_uri = _ws_url(gateway_url)
         try:
-            async with websockets.connect(ws_uri, open_timeout=10) as ws:
+            async with _connect(ws_uri, auth_headers) as ws:
                 # Connection succeeded (context manager ensures open)
                 assert ws is not None
         except (ConnectionRefusedError, OSError) as exc:
             pytest.fail(f"WebSocket upgrade failed: {exc}")
 
     @pytest.mark.asyncio
-    async def test_ws_accepts_json_message(self, gateway_url):
+    async def test_ws_accepts_json_message(self, gateway_url, auth_headers):
         """After connecting, sending a JSON message does not crash the server."""
         ws_uri = _ws_url(gateway_url)
         try:
-            async with websockets.connect(ws_uri, open_timeout=10) as ws:
+            async with _connect(ws_uri, auth_headers) as ws:
                 msg = json.dumps({
                     "message": "ping",
                     "collection": "",
@@ -78,14 +86,14 @@ class TestWebSocketChat:
 
     @pytest.mark.asyncio
     @pytest.mark.requires_ollama
-    async def test_ws_sends_done_event(self, gateway_url, ollama_available):
+    async def test_ws_sends_done_event(self, gateway_url, auth_headers, ollama_available):
         """After sending a chat message, the server eventually sends a 'done' event."""
         if not ollama_available:
             pytest.skip("Ollama not available for chat")
 
         ws_uri = _ws_url(gateway_url)
         try:
-            async with websockets.connect(ws_uri, open_timeout=10) as ws:
+            async with _connect(ws_uri, auth_headers) as ws:
                 msg = json.dumps({
                     "message": "Say hello in one word.",
                     "collection": "",
@@ -116,11 +124,11 @@ class TestWebSocketChat:
             pytest.skip(f"WebSocket not available: {exc}")
 
     @pytest.mark.asyncio
-    async def test_ws_invalid_json_returns_error(self, gateway_url):
+    async def test_ws_invalid_json_returns_error(self, gateway_url, auth_headers):
         """Sending non-JSON text should result in an error event."""
         ws_uri = _ws_url(gateway_url)
         try:
-            async with websockets.connect(ws_uri, open_timeout=10) as ws:
+            async with _connect(ws_uri, auth_headers) as ws:
                 await ws.send("this is not json")
                 try:
                     raw = await asyncio.wait_for(ws.recv(), timeout=10)
@@ -35,6 +35,11 @@ TEST_COLLECTION = os.getenv("TEST_COLLECTION", "test_ollqd_suite")
 # Timeout for service readiness (seconds)
 HEALTH_TIMEOUT = int(os.getenv("HEALTH_TIMEOUT", "120"))
 
+# Gateway login. The gateway defaults to AUTH_MODE=required; the worker
+# seeds admin/admin on first start.
+TEST_USERNAME = os.getenv("TEST_USERNAME", "admin")
+TEST_PASSWORD = os.getenv("TEST_PASSWORD", "admin")
+
 
 # ---------------------------------------------------------------------------
 # Session-scoped: wait for services
@@ -79,7 +84,7 @@ def wait_for_gateway(gateway_url):
     last_err = None
     while time.time() < deadline:
         try:
-            r = requests.get(f"{gateway_url}/api/system/health", timeout=5)
+            r = requests.get(f"{gateway_url}/api/health", timeout=5)
             if r.status_code == 200:
                 return r.json()
         except Exception as exc:
@@ -89,12 +94,29 @@ def wait_for_gateway(gateway_url):
 
 
 @pytest.fixture(scope="session")
-def wait_for_qdrant(gateway_url):
+def auth_headers(gateway_url, wait_for_gateway):
+    """Log in as TEST_USERNAME and return the Authorization header to send.
+
+    With AUTH_MODE=disabled no login is needed, so a failed login yields no
+    header; tests then see the gateway's own 401s if auth is required.
+    """
+    r = requests.post(
+        f"{gateway_url}/api/auth/login",
+        json={"username": TEST_USERNAME, "password": TEST_PASSWORD},
+        timeout=10,
+    )
+    if r.status_code != 200:
+        return {}
+    return {"Authorization": f"Bearer {r.json()['token']}"}
+
+
+@pytest.fixture(scope="session")
+def wait_for_qdrant(gateway_url, auth_headers):
     """Block until Qdrant is reachable through the gateway proxy."""
     deadline = time.time() + HEALTH_TIMEOUT
     while time.time() < deadline:
         try:
-            r = requests.get(f"{gateway_url}/api/qdrant/collections", timeout=5)
+            r = requests.get(f"{gateway_url}/api/qdrant/collections", headers=auth_headers, timeout=5)
             if r.status_code == 200:
                 return True
         except Exception:
@@ -113,19 +135,20 @@ def test_collection_name():
 
 
 @pytest.fixture()
-def temp_collection(gateway_url, wait_for_qdrant):
+def temp_collection(gateway_url, auth_headers, wait_for_qdrant):
     """Create a temporary collection for a single test, delete after."""
     name = f"tmp_{int(time.time() * 1000)}"
     # Create via Qdrant proxy
     r = requests.put(
         f"{gateway_url}/api/qdrant/collections/{name}",
         json={"vectors": {"size": 384, "distance": "Cosine"}},
+        headers=auth_headers,
         timeout=10,
     )
     assert r.status_code in (200, 201), f"Failed to create collection: {r.text}"
     yield name
     # Cleanup
-    requests.delete(f"{gateway_url}/api/qdrant/collections/{name}", timeout=10)
+    requests.delete(f"{gateway_url}/api/qdrant/collections/{name}", headers=auth_headers, timeout=10)
 
 
 # ---------------------------------------------------------------------------
@@ -2,10 +2,19 @@
 
 from ollqd.chunking import chunk_file, chunk_document, _is_boundary_line
 from ollqd.models import FileInfo
+from ollqd_worker.processing.chunking import (
+    PDF_PAGE_SEPARATOR,
+    assign_pages,
+    chunk_document as chunk_worker_document,
+    chunk_table,
+    pdf_page_offsets,
+)
 
 import tempfile
 from pathlib import Path
 
+import pytest
+
 
 def _make_file(content: str, suffix: str = ".py") -> FileInfo:
     """Create a temp file and return FileInfo."""
@@ -85,3 +94,110 @@ class TestChunkDocument:
         content = "Short document."
         chunks = chunk_document("test.txt", content, language="text")
         assert len(chunks) == 1
+
+
+REPORT_PAGES = ["Intro to the report.\n\nFirst findings.", "Second page results.", "Closing notes on page three."]
+
+
+class TestAssignPages:
+    def _chunks(self, chunk_size):
+        text = PDF_PAGE_SEPARATOR.join(REPORT_PAGES)
+        chunks = chunk_worker_document("report.pdf", text, language="pdf", chunk_size=chunk_size, chunk_overlap=0)
+        assign_pages(chunks, text, pdf_page_offsets(REPORT_PAGES))
+        return chunks
+
+    def test_chunk_per_page(self):
+        chunks = self._chunks(8)
+        assert [(c.page_start, c.page_end) for c in chunks] == [(1, 1), (1, 1), (2, 2), (3, 3)]
+
+    def test_chunk_spanning_pages(self):
+        chunks = self._chunks(512)
+        assert len(chunks) == 1
+        assert (chunks[0].page_start, chunks[0].page_end) == (1, 3)
+
+    def test_unlocated_chunk_has_no_pages(self):
+        chunks = chunk_worker_document("report.pdf", "Appendix text.", language="pdf")
+        assign_pages(chunks, PDF_PAGE_SEPARATOR.join(REPORT_PAGES), pdf_page_offsets(REPORT_PAGES))
+        assert chunks[0].page_start is None
+
+
+PEOPLE_CSV = b"Name,Age,City\nAlice,30,Paris\nBob,25,Lyon\nCid,40,Nice\n"
+
+
+class TestChunkTable:
+    def test_rows_as_column_pairs(self):
+        chunks = chunk_table("people.csv", PEOPLE_CSV, {})
+        assert len(chunks) == 1
+        assert chunks[0].content.splitlines() == [
+            "Name: Alice; Age: 30; City: Paris",
+            "Name: Bob; Age: 25; City: Lyon",
+            "Name: Cid; Age: 40; City: Nice",
+        ]
+        assert (chunks[0].start_line, chunks[0].end_line) == (2, 4)
+
+    def test_rows_per_chunk(self):
+        chunks = chunk_table("people.csv", PEOPLE_CSV, {"rows_per_chunk": 2})
+        assert [c.content.count("\n") + 1 for c in chunks] == [2, 1]
+        assert [(c.start_line, c.end_line) for c in chunks] == [(2, 3), (4, 4)]
+        assert [c.chunk_index for c in chunks] == [0, 1]
+        assert all(c.total_chunks == 2 for c in chunks)
+
+    def test_rows_split_at_chunk_size(self):
+        chunks = chunk_table("people.csv", PEOPLE_CSV, {}, chunk_size=40)
+        assert len(chunks) == 3
+        assert all(c.start_line == c.end_line for c in chunks)
+
+    def test_sheet_split_repeats_header(self):
+        chunks = chunk_table("people.csv", PEOPLE_CSV, {"mode": "sheet"}, chunk_size=90)
+        assert len(chunks) == 2
+        for c in chunks:
+            assert c.language == "markdown"
+            assert c.content.splitlines()[:2] == ["| Name | Age | City |", "| --- | --- | --- |"]
+        assert chunks[0].content.splitlines()[2:] == ["| Alice | 30 | Paris |", "| Bob | 25 | Lyon |"]
+        assert chunks[1].content.splitlines()[2:] == ["| Cid | 40 | Nice |"]
+
+    def test_sheet_escapes_pipes(self):
+        chunks = chunk_table("notes.csv", b"Key,Value\na,x|y\n", {"mode": "sheet"})
+        assert chunks[0].content.splitlines()[-1] == "| a | x\\|y |"
+
+    def test_columns_by_name_and_position(self):
+        chunks = chunk_table("people.csv", PEOPLE_CSV, {"columns": ["city", "1"]})
+        assert chunks[0].content.splitlines()[0] == "Name: Alice; City: Paris"
+
+    def test_no_header(self):
+        chunks = chunk_table("people.csv", PEOPLE_CSV, {"header": False, "rows_per_chunk": 1})
+        assert len(chunks) == 4
+        assert chunks[0].content == "Name | Age | City"
+        assert (chunks[0].start_line, chunks[1].start_line) == (1, 2)
+
+    def test_blank_rows_keep_row_numbers(self):
+        data = "Name,City\nAlice,Paris\n\n,\nBéa,Zürich\n".encode()
+        chunks = chunk_table("people.csv", data, {"rows_per_chunk": 1})
+        assert [(c.start_line, c.content) for c in chunks] == [
+            (2, "Name: Alice; City: Paris"),
+            (5, "Name: Béa; City: Zürich"),
+        ]
+
+    def test_tsv(self):
+        chunks = chunk_table("people.tsv", b"Name\tCity\nAlice\tParis\n", {})
+        assert chunks[0].content == "Name: Alice; City: Paris"
+
+    def test_unknown_mode(self):
+        with pytest.raises(ValueError):
+            chunk_table("people.csv", PEOPLE_CSV, {"mode": "cells"})
+
+    def test_xlsx_sheets(self):
+        openpyxl = pytest.importorskip("openpyxl")
+        from io import BytesIO
+
+        wb = openpyxl.Workbook()
+        wb.active.title = "Staff"
+        wb.active.append(["Name", "Age"])
+        wb.active.append(["Alice", 30])
+        wb.create_sheet("Empty")
+        buf = BytesIO()
+        wb.save(buf)
+
+        chunks = chunk_table("people.xlsx", buf.getvalue(), {})
+        assert len(chunks) == 1
+        assert chunks[0].content == "Sheet: Staff\nName: Alice; Age: 30"
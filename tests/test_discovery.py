@@ -0,0 +1,62 @@
+"""Tests for skip patterns in codebase discovery."""
+
+from ollqd_worker.processing.discovery import IgnoreMatcher, discover_files
+
+
+class TestIgnoreMatcher:
+    def test_plain_name_skips_dir_at_any_depth(self):
+        m = IgnoreMatcher(["build"])
+        assert m.ignored("build", True)
+        assert m.ignored("src/build", True)
+        assert not m.ignored("build", False)
+
+    def test_star_stays_in_one_element(self):
+        m = IgnoreMatcher(["*.min.js"])
+        assert m.ignored("app.min.js", False)
+        assert m.ignored("static/js/app.min.js", False)
+        assert not m.ignored("app.js", False)
+
+    def test_slash_anchors_at_root(self):
+        m = IgnoreMatcher(["/docs/"])
+        assert m.ignored("docs", True)
+        assert not m.ignored("src/docs", True)
+        assert not m.ignored("docs", False)
+
+    def test_double_star_crosses_elements(self):
+        m = IgnoreMatcher(["src/**/gen/"])
+        assert m.ignored("src/gen", True)
+        assert m.ignored("src/a/b/gen", True)
+        assert not m.ignored("lib/gen", True)
+
+    def test_negation_reincludes_default_skip(self):
+        m = IgnoreMatcher(["!vendor/"])
+        assert not m.ignored("vendor", True, skip_by_default=True)
+        assert IgnoreMatcher([]).ignored("vendor", True, skip_by_default=True)
+
+    def test_later_rules_win(self):
+        m = IgnoreMatcher(["*.md", "!README.md"])
+        assert m.ignored("CHANGES.md", False)
+        assert not m.ignored("README.md", False)
+
+    def test_character_class(self):
+        m = IgnoreMatcher(["log[0-9].txt", "tmp[!a].py"])
+        assert m.ignored("log3.txt", False)
+        assert not m.ignored("logs.txt", False)
+        assert m.ignored("tmpb.py", False)
+        assert not m.ignored("tmpa.py", False)
+
+    def test_invalid_pattern_is_dropped(self):
+        m = IgnoreMatcher(["[bad", "*.log"])
+        assert len(m.rules) == 1
+        assert m.ignored("x.log", False)
+
+
+class TestDiscoverFiles:
+    def test_skip_patterns(self, tmp_path):
+        for rel in ("main.py", "gen/api.py", "src/util.py", "src/util_test.py", "vendor/lib.py"):
+            path = tmp_path / rel
+            path.parent.mkdir(parents=True, exist_ok=True)
+            path.write_text("x = 1\n")
+
+        files = discover_files(tmp_path, extra_skip_dirs=["gen", "*_test.py", "!vendor/"])
+        assert sorted(f.path for f in files) == ["main.py", "src/util.py", "vendor/lib.py"]
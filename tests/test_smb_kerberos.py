@@ -0,0 +1,20 @@
+"""Tests for reading SMB Kerberos settings from worker requests."""
+
+from types import SimpleNamespace
+
+from ollqd_worker.processing.smb_kerberos import request_auth
+
+
+class TestRequestAuth:
+    def test_request_fields(self):
+        req = SimpleNamespace(auth="kerberos", realm="CORP.EXAMPLE.COM", kdc="", keytab=b"\x05\x02")
+        assert request_auth(req) == {
+            "auth": "kerberos",
+            "realm": "CORP.EXAMPLE.COM",
+            "kdc": "",
+            "keytab": b"\x05\x02",
+        }
+
+    def test_ntlm_and_old_gateways(self):
+        assert request_auth(SimpleNamespace(auth="", realm="", kdc="", keytab=b"")) == {}
+        assert request_auth(SimpleNamespace()) == {}
@@ -10,6 +10,7 @@ function app() {
     view: "dashboard",
     showModal: null,
     health: { ollama: false, qdrant: false },
+    branding: { title: "", subtitle: "", colors: {}, logo_url: null },
 
     // User management (admin only)
     userList: [],
@@ -29,6 +30,12 @@ function app() {
     searchingCollection: null,
     searchQuery: "",
     searchResults: [],
+    relatedTo: null,
+    liveSearch: false,
+    liveSearchPhase: "",
+    _liveWs: null,
+    _liveSearchId: 0,
+    preview: null,
 
     // Create collection
     newCollection: { name: "", vector_size: 1024, distance: "Cosine" },
@@ -137,6 +144,21 @@ function app() {
 
     // ── Init ──────────────────────────────────────────────────
 
+    async loadBranding() {
+      try {
+        const r = await fetch("/api/system/branding");
+        if (!r.ok) return;
+        this.branding = await r.json();
+      } catch { return; }
+      if (this.branding.title) document.title = this.branding.title;
+      // Theme colours become CSS variables; styles.css applies the ones set.
+      const body = document.body;
+      for (const [name, value] of Object.entries(this.branding.colors || {})) {
+        body.style.setProperty(`--brand-${name}`, value);
+        body.dataset[`brand${name[0].toUpperCase()}${name.slice(1)}`] = "";
+      }
+    },
+
     async init() {
       this.nav = [
         { id: "dashboard",   icon: "fa-solid fa-gauge-high",    label: "Dashboard",   load: () => this.loadDashboard() },
@@ -148,6 +170,8 @@ function app() {
         { id: "smb",         icon: "fa-solid fa-network-wired", label: "SMB Shares",  load: () => this.loadSMBShares() },
         { id: "settings",    icon: "fa-solid fa-gear",          label: "Settings",    load: () => this.loadSettings() },
       ];
+      // Branding is public, so the login screen shows it too.
+      await this.loadBranding();
       // Check if already logged in (cookie-based)
       try {
         const r = await fetch("/api/auth/me");
@@ -382,6 +406,7 @@ function app() {
     searchInCollection(name) {
       this.searchingCollection = name;
       this.searchResults = [];
+      this.relatedTo = null;
       this.searchQuery = "";
       this.browsingCollection = null;
     },
@@ -396,11 +421,117 @@ function app() {
         });
         const d = await r.json();
         this.searchResults = d.results || [];
+        this.relatedTo = null;
       } catch (e) {
         alert("Search failed: " + e.message);
       }
     },
 
+    // Search as you type: every keystroke sends the query over the live
+    // search WebSocket, which debounces, replaces the previous query and
+    // streams keyword hits before vector hits.
+    sendLiveSearch() {
+      if (!this.searchingCollection) return;
+      if (!this._liveWs || this._liveWs.readyState > 1) {
+        const proto = location.protocol === "https:" ? "wss:" : "ws:";
+        this._liveWs = new WebSocket(`${proto}//${location.host}/api/rag/search/live`);
+        this._liveWs.onmessage = (ev) => {
+          const data = JSON.parse(ev.data);
+          if (data.id !== String(this._liveSearchId)) return;
+          if (data.type === "hits") {
+            // Vector hits replace keyword ones; late keyword hits do not
+            // replace vector ones.
+            if (data.phase === "vector" || this.liveSearchPhase !== "vector") {
+              this.searchResults = data.results || [];
+              this.relatedTo = null;
+              this.liveSearchPhase = data.phase;
+            }
+          } else if (data.type === "done") {
+            if (!this.liveSearchPhase) this.searchResults = [];
+            this.liveSearchPhase = "";
+          } else if (data.type === "error" && !data.phase) {
+            this.liveSearchPhase = "";
+          }
+        };
+      }
+      const msg = {
+        type: "search",
+        id: String(++this._liveSearchId),
+        collection: this.searchingCollection,
+        query: this.searchQuery,
+        top_k: 10,
+      };
+      this.liveSearchPhase = "";
+      const send = () => this._liveWs.send(JSON.stringify(msg));
+      if (this._liveWs.readyState === 1) send();
+      else this._liveWs.addEventListener("open", send, { once: true });
+    },
+
+    // Open the source document of a search hit with the chunk highlighted.
+    async openPreview(hit) {
+      const idx = parseInt((hit.chunk_info || "1").split("/")[0], 10) - 1;
+      const qs = new URLSearchParams({
+        collection: this.searchingCollection || "",
+        file_path: hit.file_path,
+        chunk_index: String(Math.max(idx, 0)),
+      });
+      await this._loadPreview(hit.file_path, `/api/rag/preview?${qs}`);
+    },
+
+    // Replace the results with the nearest neighbours of a hit. Related
+    // hits carry point_id, so navigation can continue from them.
+    async moreLikeThis(hit) {
+      const qs = new URLSearchParams({ collection: hit.collection || this.searchingCollection || "" });
+      if (hit.point_id !== undefined && hit.point_id !== null) {
+        qs.set("point_id", String(hit.point_id));
+      } else {
+        const idx = parseInt((hit.chunk_info || "1").split("/")[0], 10) - 1;
+        qs.set("file_path", hit.file_path);
+        qs.set("chunk_index", String(Math.max(idx, 0)));
+      }
+      try {
+        const r = await fetch(`/api/rag/related?${qs}`);
+        const d = await r.json();
+        if (!r.ok) throw new Error(d.detail || r.statusText);
+        this.searchResults = d.results || [];
+        this.relatedTo = d.source;
+      } catch (e) {
+        alert("Related search failed: " + e.message);
+      }
+    },
+
+    // Open a chat citation anchor; the gateway supplies its preview URL.
+    async openCitation(citation, anchor) {
+      if (!anchor.preview_url) {
+        if (citation.url) window.open(citation.url, "_blank");
+        return;
+      }
+      await this._loadPreview(citation.file_path, anchor.preview_url);
+    },
+
+    // Highlight offsets are bytes, so slice the UTF-8 encoding.
+    async _loadPreview(filePath, url) {
+      this.preview = { file_path: filePath, loading: true };
+      this.showModal = "preview";
+      try {
+        const r = await fetch(url);
+        const d = await r.json();
+        if (!r.ok) throw new Error(d.detail || r.statusText);
+        const bytes = new TextEncoder().encode(d.text || "");
+        const dec = new TextDecoder();
+        const { start, end } = d.highlight;
+        this.preview = {
+          ...d,
+          before: dec.decode(bytes.subarray(0, start)),
+          match: dec.decode(bytes.subarray(start, end)),
+          after: dec.decode(bytes.subarray(end)),
+        };
+        this.$nextTick(() => document.getElementById("preview-match")?.scrollIntoView({ block: "center" }));
+      } catch (e) {
+        this.preview = { file_path: filePath, error: e.message };
+      }
+    },
+
     // ── Models ────────────────────────────────────────────────
 
     async loadModels() {
@@ -518,9 +649,14 @@ function app() {
             last.content += data.content;
             last.html = this._renderMarkdown(last.content);
           }
+        } else if (data.type === "route") {
+          if (last && last.role === "assistant" && data.route) {
+            last.routedTo = data.route.collections || [];
+          }
         } else if (data.type === "sources") {
           if (last && last.role === "assistant") {
-            last.sources = data.results || [];
+            last.sources = data.sources || [];
+            last.citations = data.citations || [];
           }
         } else if (data.type === "done") {
           if (last && last.role === "assistant") {
@@ -529,6 +665,14 @@ function app() {
               last.piiMasked = true;
               last.piiEntitiesCount = data.pii_entities_count || 0;
             }
+            if (data.grounding) last.grounding = data.grounding;
+          }
+          this.chatStreaming = false;
+        } else if (data.type === "cancelled") {
+          if (last && last.role === "assistant") {
+            last.content += "\n\n_Stopped._";
+            last.html = this._renderMarkdown(last.content);
+            last.streaming = false;
           }
           this.chatStreaming = false;
         } else if (data.type === "error") {
@@ -570,15 +714,20 @@ function app() {
         html: "",
         streaming: true,
         sources: [],
+        citations: [],
+        routedTo: [],
+        grounding: null,
       });
 
       this.chatInput = "";
       this.chatStreaming = true;
 
       const send = () => {
+        const auto = this.chatCollection === "__auto__";
         this._ws.send(JSON.stringify({
           message: msg,
-          collection: this.chatCollection,
+          collection: auto ? "" : this.chatCollection,
+          route: auto ? "auto" : "",
           model: this.chatModel,
           pii_enabled: this.piiChatEnabled,
         }));
@@ -596,6 +745,11 @@ function app() {
       });
     },
 
+    cancelChat() {
+      if (!this.chatStreaming || !this._ws || this._ws.readyState !== 1) return;
+      this._ws.send(JSON.stringify({ type: "cancel" }));
+    },
+
     clearChat() {
       this.chatMessages = [];
       this._msgCounter = 0;
@@ -644,10 +798,9 @@ function app() {
 
     async startUpload() {
       if (!this.uploadFiles.length) return;
+      // Fields go first so the gateway can start indexing before the last
+      // file arrives.
       const formData = new FormData();
-      for (const f of this.uploadFiles) {
-        formData.append("files", f);
-      }
       formData.append("collection", this.uploadCollection);
       formData.append("chunk_size", this.uploadChunkSize);
       formData.append("chunk_overlap", this.uploadChunkOverlap);
@@ -655,6 +808,9 @@ function app() {
       if (this.uploadVisionModel) {
         formData.append("vision_model", this.uploadVisionModel);
       }
+      for (const f of this.uploadFiles) {
+        formData.append("files", f);
+      }
 
       try {
         const r = await fetch("/api/rag/upload", {
@@ -772,7 +928,7 @@ function app() {
           } else {
             this.tasks.unshift(t);
           }
-          if (t.status === "running" || t.status === "pending") {
+          if (t.status === "running" || t.status === "pending" || t.status === "reconnecting") {
             setTimeout(poll, 1000);
           } else {
             await this.loadCollections();
@@ -944,14 +1100,32 @@ function app() {
       }
     },
 
-    async switchEmbeddingModel() {
-      if (!this.switchEmbedModel) return;
-      try {
-        const r = await fetch("/api/system/config/embedding", {
+    // PUT a config change; if the gateway warns that it affects existing
+    // collections, ask before sending it again with confirm set. Returns
+    // null when the user declines.
+    async putConfirmed(url, body) {
+      const put = (b) =>
+        fetch(url, {
           method: "PUT",
           headers: { "Content-Type": "application/json" },
-          body: JSON.stringify({ model: this.switchEmbedModel }),
+          body: JSON.stringify(b),
         });
+      let r = await put(body);
+      if (r.status === 409) {
+        const d = await r.json();
+        if (d.code !== "CONFIRM_REQUIRED") throw new Error(d.detail);
+        const msg = d.preflight.warnings.map((w) => "• " + w).join("\n");
+        if (!confirm(`This change affects existing collections:\n\n${msg}\n\nApply it anyway?`)) return null;
+        r = await put({ ...body, confirm: true });
+      }
+      return r;
+    },
+
+    async switchEmbeddingModel() {
+      if (!this.switchEmbedModel) return;
+      try {
+        const r = await this.putConfirmed("/api/system/config/embedding", { model: this.switchEmbedModel });
+        if (!r) return;
         if (!r.ok) throw new Error((await r.json()).detail);
         const d = await r.json();
         this.embeddingInfo = d;
@@ -1076,15 +1250,12 @@ function app() {
     async saveQdrantConfig() {
       if (!this.settingsConfig) return;
       try {
-        const r = await fetch("/api/system/config/qdrant", {
-          method: "PUT",
-          headers: { "Content-Type": "application/json" },
-          body: JSON.stringify({
-            url: this.settingsConfig.qdrant.url,
-            default_collection: this.settingsConfig.qdrant.default_collection,
-            default_distance: this.settingsConfig.qdrant.default_distance,
-          }),
+        const r = await this.putConfirmed("/api/system/config/qdrant", {
+          url: this.settingsConfig.qdrant.url,
+          default_collection: this.settingsConfig.qdrant.default_collection,
+          default_distance: this.settingsConfig.qdrant.default_distance,
         });
+        if (!r) return;
         if (!r.ok) throw new Error((await r.json()).detail);
         const d = await r.json();
         this.settingsConfig.qdrant = { ...this.settingsConfig.qdrant, ...d };
@@ -1138,11 +1309,10 @@ function app() {
     async saveDistanceMetric() {
       if (!this.settingsConfig) return;
       try {
-        const r = await fetch("/api/system/config/distance", {
-          method: "PUT",
-          headers: { "Content-Type": "application/json" },
-          body: JSON.stringify({ distance: this.settingsConfig.qdrant.default_distance }),
+        const r = await this.putConfirmed("/api/system/config/distance", {
+          distance: this.settingsConfig.qdrant.default_distance,
         });
+        if (!r) return;
         if (!r.ok) throw new Error((await r.json()).detail);
         alert("Distance metric updated (applies to new collections)");
       } catch (e) {
@@ -1346,6 +1516,13 @@ function app() {
       return val.toFixed(i > 1 ? 1 : 0) + " " + units[i];
     },
 
+    formatTimestamp(seconds) {
+      const h = Math.floor(seconds / 3600);
+      const m = Math.floor(seconds / 60) % 60;
+      const s = String(seconds % 60).padStart(2, "0");
+      return h ? `${h}:${String(m).padStart(2, "0")}:${s}` : `${m}:${s}`;
+    },
+
     _escapeHtml(str) {
       const div = document.createElement("div");
       div.textContent = str;